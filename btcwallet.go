@@ -15,7 +15,10 @@ import (
 	"sync"
 
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/metrics"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
+	"github.com/btcsuite/btcwallet/rpc/macaroons"
+	"github.com/btcsuite/btcwallet/rpc/rpcserver"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/lightninglabs/neutrino"
@@ -72,11 +75,41 @@ func walletMain() error {
 	loader := wallet.NewLoader(
 		activeNet.Params, dbDir, true, cfg.DBTimeout, 250,
 	)
+	loader.SetDBDriver(cfg.DBDriver)
+	loader.SetDBEncryption(cfg.EncryptDB)
+	loader.OnWalletCreated(macaroons.Create)
+
+	// Serve metrics of the process, instrumenting the wallet database
+	// before it's opened.
+	var (
+		metricsSvc    *metricsService
+		walletMetrics *metrics.Metrics
+	)
+	if len(cfg.MetricsListeners) > 0 {
+		metricsSvc = newMetricsService()
+		walletMetrics = metricsSvc.metrics
+		loader.SetDBWrapper(walletMetrics.InstrumentDB)
+		loader.RunAfterLoad(walletMetrics.SetWallet)
+		metricsSvc.start()
+	}
 
 	// Create and start HTTP server to serve wallet client connections.
 	// This will be updated with the wallet and chain server RPC client
 	// created below after each is created.
-	rpcs, legacyRPCServer, err := startRPCServers(loader)
+	//
+	// Calls to the gRPC server are authenticated with the macaroons of the
+	// wallet once it is loaded, if enabled.
+	var macaroonAuth *rpcserver.MacaroonAuth
+	if cfg.ExperimentalRPCMacaroons {
+		macaroonAuth = rpcserver.NewMacaroonAuth()
+		loader.RunAfterLoad(func(w *wallet.Wallet) {
+			loadMacaroons(w, macaroonAuth)
+		})
+	}
+	walletService := rpcserver.NewWalletService()
+	rpcs, restGateway, legacyRPCServer, err := startRPCServers(
+		loader, walletService, walletMetrics, macaroonAuth,
+	)
 	if err != nil {
 		log.Errorf("Unable to create RPC servers: %v", err)
 		return err
@@ -85,21 +118,55 @@ func walletMain() error {
 	// Create and start chain RPC client so it's ready to connect to
 	// the wallet when loaded later.
 	if !cfg.NoInitialLoad {
-		go rpcClientConnectLoop(legacyRPCServer, loader)
+		go rpcClientConnectLoop(legacyRPCServer, loader, walletMetrics)
 	}
 
 	loader.RunAfterLoad(func(w *wallet.Wallet) {
-		startWalletRPCServices(w, rpcs, legacyRPCServer)
+		startWalletRPCServices(w, walletService, legacyRPCServer)
 	})
 
+	// Deliver wallet events to the configured webhooks once the wallet is
+	// loaded.  Events of the wallet would be missed without a dispatcher,
+	// so the process is shut down if it can't be started.
+	var webhooks *webhookService
+	webhookErr := make(chan error, 1)
+	if len(cfg.Webhooks) > 0 {
+		webhooks = &webhookService{}
+		loader.RunAfterLoad(func(w *wallet.Wallet) {
+			if err := webhooks.start(w); err != nil {
+				webhookErr <- err
+			}
+		})
+	}
+
+	// Serve payjoin requests once the wallet is loaded.
+	var payjoins *payjoinService
+	if len(cfg.PayjoinListeners) > 0 {
+		payjoins = &payjoinService{}
+		loader.RunAfterLoad(payjoins.start)
+	}
+
 	if !cfg.NoInitialLoad {
 		// Load the wallet database.  It must have been created already
-		// or this will return an appropriate error.
-		_, err = loader.OpenExistingWallet([]byte(cfg.WalletPass), true)
+		// or this will return an appropriate error.  Simulation wallets
+		// kept in memory are created instead, and lost on shutdown.
+		if cfg.CreateTemp && cfg.DBDriver == wallet.MemDBDriver {
+			err = createMemSimulationWallet(loader)
+		} else {
+			_, err = loader.OpenExistingWallet(
+				[]byte(cfg.WalletPass), true,
+			)
+		}
 		if err != nil {
 			log.Error(err)
 			return err
 		}
+		select {
+		case err := <-webhookErr:
+			log.Errorf("Unable to start webhook dispatcher: %v", err)
+			return err
+		default:
+		}
 	}
 
 	// Add interrupt handlers to shutdown the various process components
@@ -111,6 +178,36 @@ func walletMain() error {
 			log.Errorf("Failed to close wallet: %v", err)
 		}
 	})
+	if metricsSvc != nil {
+		addInterruptHandler(func() {
+			log.Warn("Stopping metrics server...")
+			metricsSvc.stop()
+			log.Info("Metrics server shutdown")
+		})
+	}
+	if webhooks != nil {
+		addInterruptHandler(func() {
+			log.Warn("Stopping webhook dispatcher...")
+			webhooks.stop()
+			log.Info("Webhook dispatcher shutdown")
+		})
+		go func() {
+			select {
+			case err := <-webhookErr:
+				log.Errorf("Unable to start webhook "+
+					"dispatcher: %v", err)
+				simulateInterrupt()
+			case <-interruptHandlersDone:
+			}
+		}()
+	}
+	if payjoins != nil {
+		addInterruptHandler(func() {
+			log.Warn("Stopping payjoin server...")
+			payjoins.stop()
+			log.Info("Payjoin server shutdown")
+		})
+	}
 	if rpcs != nil {
 		addInterruptHandler(func() {
 			// TODO: Does this need to wait for the grpc server to
@@ -120,6 +217,13 @@ func walletMain() error {
 			log.Info("RPC server shutdown")
 		})
 	}
+	if restGateway != nil {
+		addInterruptHandler(func() {
+			log.Warn("Stopping REST gateway...")
+			restGateway.stop()
+			log.Info("REST gateway shutdown")
+		})
+	}
 	if legacyRPCServer != nil {
 		addInterruptHandler(func() {
 			log.Warn("Stopping legacy RPC server...")
@@ -143,8 +247,11 @@ func walletMain() error {
 //
 // The legacy RPC is optional.  If set, the connected RPC client will be
 // associated with the server for RPC passthrough and to enable additional
-// methods.
-func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Loader) {
+// methods.  The metrics are also optional.  If set, each connected client is
+// associated with them.
+func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Loader,
+	walletMetrics *metrics.Metrics) {
+
 	var certs []byte
 	if !cfg.UseSPV {
 		certs = readCAFile()
@@ -196,6 +303,10 @@ func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Load
 			}
 		}
 
+		if walletMetrics != nil {
+			walletMetrics.SetChainClient(chainClient)
+		}
+
 		// Rather than inlining this logic directly into the loader
 		// callback, a function variable is used to avoid running any of
 		// this after the client disconnects by setting it to nil.  This
@@ -204,6 +204,38 @@ func (c *BitcoindClient) SendRawTransaction(tx *wire.MsgTx,
 	return c.chainConn.client.SendRawTransaction(tx, allowHighFees)
 }
 
+// TestMempoolAccept checks whether bitcoind would accept the transaction into
+// its mempool using the testmempoolaccept RPC. Nodes that predate the RPC are
+// handled by running the local policy checks instead.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *BitcoindClient) TestMempoolAccept(
+	tx *wire.MsgTx) (*MempoolAcceptResult, error) {
+
+	result, err := testMempoolAccept(c.chainConn.client, tx)
+	if err != nil || result != nil {
+		return result, err
+	}
+
+	info, err := c.chainConn.client.GetBlockChainInfo()
+	if err != nil {
+		return nil, err
+	}
+	medianTime := time.Unix(info.MedianTime, 0)
+	return checkMempoolPolicy(tx, info.Blocks, medianTime), nil
+}
+
+// GetUtxo returns the output of the UTXO set identified by the outpoint, or
+// ErrOutputSpent if it is spent. The script and height hint are not needed by
+// bitcoind.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *BitcoindClient) GetUtxo(op *wire.OutPoint, _ []byte,
+	_ uint32) (*wire.TxOut, error) {
+
+	return getTxOut(c.chainConn.client, op)
+}
+
 // Notifications returns a channel to retrieve notifications from.
 //
 // NOTE: This is part of the chain.Interface interface.
@@ -211,6 +243,12 @@ func (c *BitcoindClient) Notifications() <-chan interface{} {
 	return c.notificationQueue.ChanOut()
 }
 
+// NotificationQueueLen returns the number of notifications queued for
+// delivery on the Notifications channel.
+func (c *BitcoindClient) NotificationQueueLen() int {
+	return c.notificationQueue.Len()
+}
+
 // NotifyReceived allows the chain backend to notify the caller whenever a
 // transaction pays to any of the given addresses.
 //
@@ -40,6 +40,8 @@ type Interface interface {
 	FilterBlocks(*FilterBlocksRequest) (*FilterBlocksResponse, error)
 	BlockStamp() (*waddrmgr.BlockStamp, error)
 	SendRawTransaction(*wire.MsgTx, bool) (*chainhash.Hash, error)
+	TestMempoolAccept(*wire.MsgTx) (*MempoolAcceptResult, error)
+	GetUtxo(op *wire.OutPoint, pkScript []byte, heightHint uint32) (*wire.TxOut, error)
 	Rescan(*chainhash.Hash, []btcutil.Address, map[wire.OutPoint]btcutil.Address) error
 	NotifyReceived([]btcutil.Address) error
 	NotifyBlocks() error
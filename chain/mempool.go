@@ -0,0 +1,169 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// maxStandardTxWeight is the maximum weight of a transaction that is
+	// still relayed by nodes running with the default policy.
+	maxStandardTxWeight = 400000
+
+	// maxStandardSigScriptSize is the maximum size of an input signature
+	// script that is still considered standard.
+	maxStandardSigScriptSize = 1650
+)
+
+// MempoolAcceptResult describes whether a transaction would be accepted into
+// the mempool of a chain backend.
+type MempoolAcceptResult struct {
+	// TxID is the hash of the tested transaction.
+	TxID chainhash.Hash
+
+	// Allowed is true if the transaction would be accepted.
+	Allowed bool
+
+	// RejectReason is the reason reported by the backend for rejecting
+	// the transaction. It is empty if the transaction was allowed.
+	RejectReason string
+}
+
+// testMempoolAcceptResult is the JSON result of a single transaction returned
+// by the testmempoolaccept RPC.
+type testMempoolAcceptResult struct {
+	TxID         string `json:"txid"`
+	Allowed      bool   `json:"allowed"`
+	RejectReason string `json:"reject-reason"`
+}
+
+// testMempoolAccept issues a testmempoolaccept request for a single
+// transaction over the given RPC connection. If the remote server does not
+// know the method, a nil result is returned without an error so the caller can
+// fall back to the local policy checks.
+func testMempoolAccept(client *rpcclient.Client,
+	tx *wire.MsgTx) (*MempoolAcceptResult, error) {
+
+	var buf bytes.Buffer
+	buf.Grow(tx.SerializeSize())
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+
+	rawTxs, err := json.Marshal([]string{hex.EncodeToString(buf.Bytes())})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.RawRequest(
+		"testmempoolaccept", []json.RawMessage{rawTxs},
+	)
+	if rpcErr, ok := err.(*btcjson.RPCError); ok &&
+		rpcErr.Code == btcjson.ErrRPCMethodNotFound.Code {
+
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var results []testMempoolAcceptResult
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return nil, err
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("expected 1 testmempoolaccept result, "+
+			"got %d", len(results))
+	}
+
+	return &MempoolAcceptResult{
+		TxID:         tx.TxHash(),
+		Allowed:      results[0].Allowed,
+		RejectReason: results[0].RejectReason,
+	}, nil
+}
+
+// checkMempoolPolicy runs the subset of the standard mempool policy checks that
+// can be performed without access to the outputs the transaction spends. It is
+// used by backends that cannot test mempool acceptance remotely. The
+// bestHeight and medianTime are the height and median time past of the current
+// chain tip.
+func checkMempoolPolicy(tx *wire.MsgTx, bestHeight int32,
+	medianTime time.Time) *MempoolAcceptResult {
+
+	result := &MempoolAcceptResult{
+		TxID: tx.TxHash(),
+	}
+
+	reject := func(reason string) *MempoolAcceptResult {
+		result.RejectReason = reason
+		return result
+	}
+
+	btcTx := btcutil.NewTx(tx)
+	if err := blockchain.CheckTransactionSanity(btcTx); err != nil {
+		return reject(err.Error())
+	}
+	if blockchain.IsCoinBase(btcTx) {
+		return reject("coinbase")
+	}
+
+	// Nodes disagree on the highest standard version, so only versions
+	// no node relays are rejected here.
+	if tx.Version < 1 {
+		return reject("version")
+	}
+
+	// Like the mempool, time based lock times are checked against the
+	// median time past of the chain tip, which is what the next block
+	// enforces.
+	if !blockchain.IsFinalizedTransaction(btcTx, bestHeight+1, medianTime) {
+		return reject("non-final")
+	}
+	if blockchain.GetTransactionWeight(btcTx) > maxStandardTxWeight {
+		return reject("tx-size")
+	}
+
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) > maxStandardSigScriptSize {
+			return reject("scriptsig-size")
+		}
+		if !txscript.IsPushOnlyScript(txIn.SignatureScript) {
+			return reject("scriptsig-not-pushonly")
+		}
+	}
+
+	var numNullData int
+	for _, txOut := range tx.TxOut {
+		switch txscript.GetScriptClass(txOut.PkScript) {
+		case txscript.NonStandardTy:
+			return reject("scriptpubkey")
+
+		case txscript.NullDataTy:
+			numNullData++
+			continue
+		}
+
+		if mempool.IsDust(txOut, mempool.DefaultMinRelayTxFee) {
+			return reject("dust")
+		}
+	}
+	if numNullData > 1 {
+		return reject("multi-op-return")
+	}
+
+	result.Allowed = true
+	return result
+}
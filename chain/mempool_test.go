@@ -0,0 +1,137 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckMempoolPolicy ensures the local policy checks used by backends
+// without a testmempoolaccept RPC reject non-standard transactions with the
+// expected reason.
+func TestCheckMempoolPolicy(t *testing.T) {
+	t.Parallel()
+
+	p2wkhScript := append([]byte{txscript.OP_0, txscript.OP_DATA_20},
+		make([]byte, 20)...)
+	nullDataScript, err := txscript.NullDataScript([]byte("memo"))
+	require.NoError(t, err)
+
+	// The median time past lies well before the current time, so lock
+	// times in between must still be rejected.
+	medianTime := time.Unix(1600000000, 0)
+
+	newTx := func() *wire.MsgTx {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{1},
+				Index: 0,
+			},
+			Sequence: wire.MaxTxInSequenceNum,
+		})
+		tx.AddTxOut(wire.NewTxOut(100000, p2wkhScript))
+		return tx
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*wire.MsgTx)
+		reason string
+	}{
+		{
+			name:   "standard",
+			modify: func(*wire.MsgTx) {},
+		},
+		{
+			name: "null data output",
+			modify: func(tx *wire.MsgTx) {
+				tx.AddTxOut(wire.NewTxOut(0, nullDataScript))
+			},
+		},
+		{
+			name: "version 3",
+			modify: func(tx *wire.MsgTx) {
+				tx.Version = 3
+			},
+		},
+		{
+			name: "invalid version",
+			modify: func(tx *wire.MsgTx) {
+				tx.Version = 0
+			},
+			reason: "version",
+		},
+		{
+			name: "non-final",
+			modify: func(tx *wire.MsgTx) {
+				tx.LockTime = 1000
+				tx.TxIn[0].Sequence = 0
+			},
+			reason: "non-final",
+		},
+		{
+			name: "lock time before median time past",
+			modify: func(tx *wire.MsgTx) {
+				tx.LockTime = uint32(medianTime.Unix()) - 1
+				tx.TxIn[0].Sequence = 0
+			},
+		},
+		{
+			name: "lock time after median time past",
+			modify: func(tx *wire.MsgTx) {
+				tx.LockTime = uint32(medianTime.Unix()) + 3600
+				tx.TxIn[0].Sequence = 0
+			},
+			reason: "non-final",
+		},
+		{
+			name: "dust output",
+			modify: func(tx *wire.MsgTx) {
+				tx.TxOut[0].Value = 1
+			},
+			reason: "dust",
+		},
+		{
+			name: "non-standard output",
+			modify: func(tx *wire.MsgTx) {
+				tx.TxOut[0].PkScript = []byte{txscript.OP_TRUE}
+			},
+			reason: "scriptpubkey",
+		},
+		{
+			name: "multiple null data outputs",
+			modify: func(tx *wire.MsgTx) {
+				tx.AddTxOut(wire.NewTxOut(0, nullDataScript))
+				tx.AddTxOut(wire.NewTxOut(0, nullDataScript))
+			},
+			reason: "multi-op-return",
+		},
+		{
+			name: "sig script not push only",
+			modify: func(tx *wire.MsgTx) {
+				tx.TxIn[0].SignatureScript = []byte{
+					txscript.OP_CHECKSIG,
+				}
+			},
+			reason: "scriptsig-not-pushonly",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			tx := newTx()
+			test.modify(tx)
+
+			result := checkMempoolPolicy(tx, 100, medianTime)
+			require.Equal(t, tx.TxHash(), result.TxID)
+			require.Equal(t, test.reason == "", result.Allowed)
+			require.Equal(t, test.reason, result.RejectReason)
+		})
+	}
+}
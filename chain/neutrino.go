@@ -3,7 +3,9 @@ package chain
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
@@ -22,6 +24,10 @@ import (
 
 // NeutrinoClient is an implementation of the btcwalet chain.Interface interface.
 type NeutrinoClient struct {
+	// queueLen is the number of queued notifications, updated atomically.
+	// It must be 64-bit aligned.
+	queueLen int64
+
 	CS *neutrino.ChainService
 
 	chainParams *chaincfg.Params
@@ -178,6 +184,81 @@ func (s *NeutrinoClient) SendRawTransaction(tx *wire.MsgTx, allowHighFees bool)
 	return &hash, nil
 }
 
+// TestMempoolAccept checks the transaction against the local mempool policy.
+// Light clients have neither a mempool nor the outputs being spent, so only
+// the context-free policy rules are enforced.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (s *NeutrinoClient) TestMempoolAccept(
+	tx *wire.MsgTx) (*MempoolAcceptResult, error) {
+
+	chainTip, err := s.CS.BestBlock()
+	if err != nil {
+		return nil, err
+	}
+	medianTime, err := s.medianTimePast(&chainTip.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return checkMempoolPolicy(tx, chainTip.Height, medianTime), nil
+}
+
+// medianTimePast returns the median timestamp of the block and the blocks
+// preceding it, as calculated by full nodes to check the lock times of the
+// transactions of the next block.
+func (s *NeutrinoClient) medianTimePast(
+	blockHash *chainhash.Hash) (time.Time, error) {
+
+	const medianTimeBlocks = 11
+
+	timestamps := make([]int64, 0, medianTimeBlocks)
+	for len(timestamps) < medianTimeBlocks {
+		header, err := s.CS.GetBlockHeader(blockHash)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timestamps = append(timestamps, header.Timestamp.Unix())
+
+		// The genesis block has no previous block.
+		if header.PrevBlock == (chainhash.Hash{}) {
+			break
+		}
+		blockHash = &header.PrevBlock
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	return time.Unix(timestamps[len(timestamps)/2], 0), nil
+}
+
+// GetUtxo returns the output identified by the outpoint if it is unspent, or
+// ErrOutputSpent otherwise. Light clients don't have a UTXO set, so the
+// compact filters of the blocks starting at the height hint are scanned for
+// the output script to find the output and its spend.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (s *NeutrinoClient) GetUtxo(op *wire.OutPoint, pkScript []byte,
+	heightHint uint32) (*wire.TxOut, error) {
+
+	report, err := s.CS.GetUtxo(
+		neutrino.WatchInputs(neutrino.InputWithScript{
+			OutPoint: *op,
+			PkScript: pkScript,
+		}),
+		neutrino.StartBlock(&headerfs.BlockStamp{
+			Height: int32(heightHint),
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil || report.SpendingTx != nil || report.Output == nil {
+		return nil, ErrOutputSpent
+	}
+	return report.Output, nil
+}
+
 // FilterBlocks scans the blocks contained in the FilterBlocksRequest for any
 // addresses of interest. For each requested block, the corresponding compact
 // filter will first be checked for matches, skipping those that do not report
@@ -490,6 +571,12 @@ func (s *NeutrinoClient) Notifications() <-chan interface{} {
 	return s.dequeueNotification
 }
 
+// NotificationQueueLen returns the number of notifications queued for
+// delivery on the Notifications channel.
+func (s *NeutrinoClient) NotificationQueueLen() int {
+	return int(atomic.LoadInt64(&s.queueLen))
+}
+
 // SetStartTime is a non-interface method to set the birthday of the wallet
 // using this object. Since only a single rescan at a time is currently
 // supported, only one birthday needs to be set. This does not fully restart a
@@ -717,6 +804,7 @@ out:
 				dequeue = s.dequeueNotification
 			}
 			notifications = append(notifications, n)
+			atomic.StoreInt64(&s.queueLen, int64(len(notifications)))
 
 		case dequeue <- next:
 			if n, ok := next.(BlockConnected); ok {
@@ -728,6 +816,7 @@ out:
 
 			notifications[0] = nil
 			notifications = notifications[1:]
+			atomic.StoreInt64(&s.queueLen, int64(len(notifications)))
 			if len(notifications) != 0 {
 				next = notifications[0]
 			} else {
@@ -2,6 +2,7 @@ package chain
 
 import (
 	"container/list"
+	"sync/atomic"
 )
 
 // ConcurrentQueue is a concurrent-safe FIFO queue with unbounded capacity.
@@ -10,6 +11,10 @@ import (
 // items from the in channel to the out channel in the correct order that must
 // be started by calling Start().
 type ConcurrentQueue struct {
+	// overflowLen is the length of the overflow list, updated atomically
+	// so that it can be read while the queue is running.
+	overflowLen int64
+
 	chanIn   chan interface{}
 	chanOut  chan interface{}
 	quit     chan struct{}
@@ -60,6 +65,7 @@ func (cq *ConcurrentQueue) Start() {
 						return
 					default:
 						cq.overflow.PushBack(item)
+						atomic.AddInt64(&cq.overflowLen, 1)
 					}
 				case <-cq.quit:
 					return
@@ -71,8 +77,10 @@ func (cq *ConcurrentQueue) Start() {
 				select {
 				case item := <-cq.chanIn:
 					cq.overflow.PushBack(item)
+					atomic.AddInt64(&cq.overflowLen, 1)
 				case cq.chanOut <- nextElement.Value:
 					cq.overflow.Remove(nextElement)
+					atomic.AddInt64(&cq.overflowLen, -1)
 				case <-cq.quit:
 					return
 				}
@@ -81,6 +89,11 @@ func (cq *ConcurrentQueue) Start() {
 	}()
 }
 
+// Len returns the number of items waiting to be popped from the queue.
+func (cq *ConcurrentQueue) Len() int {
+	return len(cq.chanOut) + int(atomic.LoadInt64(&cq.overflowLen))
+}
+
 // Stop ends the goroutine that moves items from the in channel to the out
 // channel.
 func (cq *ConcurrentQueue) Stop() {
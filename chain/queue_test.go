@@ -0,0 +1,45 @@
+package chain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestConcurrentQueueLen tests that the length of the queue includes both the
+// buffered and overflowed items, and decreases as items are popped.
+func TestConcurrentQueueLen(t *testing.T) {
+	t.Parallel()
+
+	const bufferSize = 2
+	const numItems = 5
+
+	queue := NewConcurrentQueue(bufferSize)
+	queue.Start()
+	defer queue.Stop()
+
+	waitForLen := func(want int) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for queue.Len() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected queue length %d, got %d",
+					want, queue.Len())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitForLen(0)
+	for i := 0; i < numItems; i++ {
+		queue.ChanIn() <- i
+	}
+	waitForLen(numItems)
+
+	for i := 0; i < numItems; i++ {
+		if item := <-queue.ChanOut(); item != i {
+			t.Fatalf("expected item %d, got %v", i, item)
+		}
+		waitForLen(numItems - i - 1)
+	}
+}
@@ -7,6 +7,7 @@ package chain
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/btcjson"
@@ -24,6 +25,11 @@ import (
 // RPCClient represents a persistent client connection to a bitcoin RPC server
 // for information regarding the current best block chain.
 type RPCClient struct {
+	// The following fields are updated atomically and must be 64-bit
+	// aligned.
+	connects uint64 // number of connections to the server
+	queueLen int64  // number of queued notifications
+
 	*rpcclient.Client
 	connConfig        *rpcclient.ConnConfig // Work around unexported field
 	chainParams       *chaincfg.Params
@@ -171,6 +177,38 @@ func (c *RPCClient) Rescan(startHash *chainhash.Hash, addrs []btcutil.Address,
 	return c.Client.Rescan(startHash, addrs, flatOutpoints) // nolint:staticcheck
 }
 
+// TestMempoolAccept checks whether the remote server would accept the
+// transaction into its mempool using the testmempoolaccept RPC. Servers that do
+// not implement the RPC are handled by running the local policy checks instead.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *RPCClient) TestMempoolAccept(
+	tx *wire.MsgTx) (*MempoolAcceptResult, error) {
+
+	result, err := testMempoolAccept(c.Client, tx)
+	if err != nil || result != nil {
+		return result, err
+	}
+
+	info, err := c.GetBlockChainInfo()
+	if err != nil {
+		return nil, err
+	}
+	medianTime := time.Unix(info.MedianTime, 0)
+	return checkMempoolPolicy(tx, info.Blocks, medianTime), nil
+}
+
+// GetUtxo returns the output of the UTXO set identified by the outpoint, or
+// ErrOutputSpent if it is spent. The script and height hint are not needed by
+// full nodes.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *RPCClient) GetUtxo(op *wire.OutPoint, _ []byte,
+	_ uint32) (*wire.TxOut, error) {
+
+	return getTxOut(c.Client, op)
+}
+
 // WaitForShutdown blocks until both the client has finished disconnecting
 // and all handlers have exited.
 func (c *RPCClient) WaitForShutdown() {
@@ -186,6 +224,22 @@ func (c *RPCClient) Notifications() <-chan interface{} {
 	return c.dequeueNotification
 }
 
+// NotificationQueueLen returns the number of notifications queued for
+// delivery on the Notifications channel.
+func (c *RPCClient) NotificationQueueLen() int {
+	return int(atomic.LoadInt64(&c.queueLen))
+}
+
+// Reconnects returns the number of times the client reconnected to the
+// server after its first connection.
+func (c *RPCClient) Reconnects() uint64 {
+	connects := atomic.LoadUint64(&c.connects)
+	if connects == 0 {
+		return 0
+	}
+	return connects - 1
+}
+
 // BlockStamp returns the latest block notified by the client, or an error
 // if the client has been shut down.
 func (c *RPCClient) BlockStamp() (*waddrmgr.BlockStamp, error) {
@@ -306,6 +360,7 @@ func parseBlock(block *btcjson.BlockDetails) (*wtxmgr.BlockMeta, error) {
 }
 
 func (c *RPCClient) onClientConnect() {
+	atomic.AddUint64(&c.connects, 1)
 	select {
 	case c.enqueueNotification <- ClientConnected{}:
 	case <-c.quit:
@@ -421,6 +476,7 @@ out:
 				dequeue = c.dequeueNotification
 			}
 			notifications = append(notifications, n)
+			atomic.StoreInt64(&c.queueLen, int64(len(notifications)))
 
 		case dequeue <- next:
 			if n, ok := next.(BlockConnected); ok {
@@ -432,6 +488,7 @@ out:
 
 			notifications[0] = nil
 			notifications = notifications[1:]
+			atomic.StoreInt64(&c.queueLen, int64(len(notifications)))
 			if len(notifications) != 0 {
 				next = notifications[0]
 			} else {
@@ -0,0 +1,39 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// ErrOutputSpent is returned by GetUtxo when the requested output has been
+// spent or was never created.
+var ErrOutputSpent = errors.New("output is spent or does not exist")
+
+// getTxOut fetches an unspent output of the UTXO set of a full node over the
+// given RPC connection. Outputs that are only created by mempool transactions
+// are not considered.
+func getTxOut(client *rpcclient.Client, op *wire.OutPoint) (*wire.TxOut,
+	error) {
+
+	result, err := client.GetTxOut(&op.Hash, op.Index, false)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrOutputSpent
+	}
+
+	amount, err := btcutil.NewAmount(result.Value)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := hex.DecodeString(result.ScriptPubKey.Hex)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewTxOut(int64(amount), pkScript), nil
+}
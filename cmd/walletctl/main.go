@@ -0,0 +1,724 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wallet/cryptdb"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	"github.com/btcsuite/btcwallet/walletdb/migration"
+	_ "github.com/btcsuite/btcwallet/walletdb/sqlite"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/jessevdk/go-flags"
+)
+
+const usage = `[OPTIONS] COMMAND [ARGS]
+
+Inspects and repairs a wallet database while btcwallet is not running.
+
+Commands:
+  info            Show the database versions, sync tip and birthday
+  dump [BUCKET]   Dump the top-level buckets, or the named ones, as JSON
+  accounts        List the accounts and address counts of each key scope
+  check           Check the consistency of the transaction history
+  repair          Back up the database and repair the inconsistencies of the
+                  transaction history
+  compact         Rewrite the database file to reclaim free space
+  backup PATH     Write a copy of the database to a new file
+  migrations      Show the pending migrations and the changes they would make
+  rollback SERVICE VERSION
+                  Back up the database and roll back the migrations of the
+                  waddrmgr or wtxmgr service to the version, as far as
+                  waddrmgr version 5 and wtxmgr version 1`
+
+var (
+	datadir = btcutil.AppDataDir("btcwallet", false)
+
+	// waddrmgrNamespaceKey and wtxmgrNamespaceKey are the top-level
+	// buckets of the address and transaction managers.
+	waddrmgrNamespaceKey = []byte("waddrmgr")
+	wtxmgrNamespaceKey   = []byte("wtxmgr")
+)
+
+// Flags.
+var opts = struct {
+	Force      bool          `short:"f" description:"Repair or compact without prompt"`
+	DbPath     string        `long:"db" description:"Path to wallet database (default: datadir/<network>/wallet.db)"`
+	DBDriver   string        `long:"dbdriver" description:"The database driver of the wallet database {bdb, sqlite}"`
+	Timeout    time.Duration `long:"timeout" description:"Timeout value when opening the wallet database"`
+	WalletPass string        `long:"walletpass" default-mask:"-" description:"The public wallet password, used to open encrypted databases"`
+	TestNet3   bool          `long:"testnet" description:"Use the test bitcoin network (version 3)"`
+	SimNet     bool          `long:"simnet" description:"Use the simulation bitcoin network"`
+	SigNet     bool          `long:"signet" description:"Use the signet test network"`
+}{
+	DBDriver:   wallet.BoltDBDriver,
+	Timeout:    wallet.DefaultDBTimeout,
+	WalletPass: wallet.InsecurePubPassphrase,
+}
+
+var (
+	activeNet = &netparams.MainNetParams
+	args      []string
+)
+
+func init() {
+	parser := flags.NewParser(&opts, flags.Default)
+	parser.Usage = usage
+	var err error
+	args, err = parser.Parse()
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) == 0 {
+		parser.WriteHelp(os.Stderr)
+		os.Exit(1)
+	}
+
+	netDir := "mainnet"
+	switch {
+	case opts.TestNet3:
+		activeNet, netDir = &netparams.TestNet3Params, "testnet"
+	case opts.SimNet:
+		activeNet, netDir = &netparams.SimNetParams, "simnet"
+	case opts.SigNet:
+		activeNet, netDir = &netparams.SigNetParams, "signet"
+	}
+	if opts.DbPath == "" {
+		opts.DbPath = filepath.Join(datadir, netDir, wallet.WalletDBName)
+	}
+
+	switch opts.DBDriver {
+	case wallet.BoltDBDriver, wallet.SQLiteDBDriver:
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported database driver %q\n",
+			opts.DBDriver)
+		os.Exit(1)
+	}
+}
+
+func yes(s string) bool {
+	switch s {
+	case "y", "Y", "yes", "Yes":
+		return true
+	default:
+		return false
+	}
+}
+
+func no(s string) bool {
+	switch s {
+	case "n", "N", "no", "No":
+		return true
+	default:
+		return false
+	}
+}
+
+// confirm prompts for confirmation of an action, unless it's forced.
+func confirm(prompt string) (bool, error) {
+	if opts.Force {
+		return true, nil
+	}
+
+	scanner := bufio.NewScanner(bufio.NewReader(os.Stdin))
+	for {
+		fmt.Printf("%s [y/N] ", prompt)
+		if !scanner.Scan() {
+			// Exit on EOF.
+			return false, scanner.Err()
+		}
+		resp := scanner.Text()
+		if yes(resp) {
+			return true, nil
+		}
+		if no(resp) || resp == "" {
+			return false, nil
+		}
+
+		fmt.Println("Enter yes or no.")
+	}
+}
+
+// dbArgs returns the walletdb arguments of the database at the path with the
+// selected driver.
+func dbArgs(dbPath string) []interface{} {
+	if opts.DBDriver == wallet.SQLiteDBDriver {
+		return []interface{}{dbPath, opts.Timeout}
+	}
+	return []interface{}{dbPath, false, opts.Timeout}
+}
+
+// openDB opens the wallet database.  Encrypted databases are opened with the
+// public passphrase, unless raw is set.
+func openDB(raw bool) (walletdb.DB, error) {
+	db, err := walletdb.Open(opts.DBDriver, dbArgs(opts.DbPath)...)
+	if err != nil {
+		return nil, err
+	}
+	if raw {
+		return db, nil
+	}
+
+	encrypted, err := cryptdb.IsEncrypted(db)
+	if err != nil || !encrypted {
+		return db, err
+	}
+	cdb, err := cryptdb.Open(db, []byte(opts.WalletPass))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &encryptedDB{cdb}, nil
+}
+
+// encryptedDB is an encrypted wallet database opened by openDB.
+type encryptedDB struct {
+	walletdb.DB
+}
+
+func main() {
+	os.Exit(mainInt())
+}
+
+func mainInt() int {
+	fmt.Fprintln(os.Stderr, "Database path:", opts.DbPath)
+	_, err := os.Stat(opts.DbPath)
+	if os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "Database file does not exist")
+		return 1
+	}
+
+	command, cmdArgs := args[0], args[1:]
+	switch command {
+	case "info":
+		err = info()
+	case "dump":
+		err = dump(cmdArgs)
+	case "accounts":
+		err = accounts()
+	case "check":
+		err = check()
+	case "repair":
+		err = repair()
+	case "compact":
+		err = compact()
+	case "backup":
+		err = backup(cmdArgs)
+	case "migrations":
+		err = migrations()
+	case "rollback":
+		err = rollback(cmdArgs)
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// info shows the versions of the address and transaction managers, and the
+// block the wallet is synced to.
+func info() error {
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	return walletdb.View(db, func(tx walletdb.ReadTx) error {
+		_, encrypted := db.(*encryptedDB)
+		fmt.Println("Encrypted:", encrypted)
+
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		if addrmgrNs == nil || txmgrNs == nil {
+			return errors.New("database is not a wallet database")
+		}
+
+		addrmgrVersion, err := waddrmgr.NewMigrationManager(nil).
+			CurrentVersion(addrmgrNs)
+		if err != nil {
+			return err
+		}
+		txmgrVersion, err := wtxmgr.NewMigrationManager(nil).
+			CurrentVersion(txmgrNs)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Address manager version:", addrmgrVersion)
+		fmt.Println("Transaction manager version:", txmgrVersion)
+
+		syncedTo, err := waddrmgr.FetchSyncedTo(addrmgrNs)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Synced to: %v (height %d, %v)\n", syncedTo.Hash,
+			syncedTo.Height, syncedTo.Timestamp)
+
+		birthday, err := waddrmgr.FetchBirthdayBlock(addrmgrNs)
+		switch {
+		case waddrmgr.IsError(err, waddrmgr.ErrBirthdayBlockNotSet):
+			fmt.Println("Birthday block: not set")
+		case err != nil:
+			return err
+		default:
+			fmt.Printf("Birthday block: %v (height %d, %v)\n",
+				birthday.Hash, birthday.Height,
+				birthday.Timestamp)
+		}
+		return nil
+	})
+}
+
+// dumpBucket is the JSON representation of a bucket.  Keys, values and the
+// names of nested buckets are hex encoded.
+type dumpBucket struct {
+	Name    string        `json:"name"`
+	Values  []dumpValue   `json:"values,omitempty"`
+	Buckets []*dumpBucket `json:"buckets,omitempty"`
+}
+
+// dumpValue is the JSON representation of a key/value pair.
+type dumpValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// dumpNested returns the representation of a bucket and its nested buckets.
+func dumpNested(name string, b walletdb.ReadBucket) (*dumpBucket, error) {
+	d := &dumpBucket{Name: name}
+	err := b.ForEach(func(k, v []byte) error {
+		// Nested buckets have a nil value.
+		if v == nil {
+			if nested := b.NestedReadBucket(k); nested != nil {
+				child, err := dumpNested(hex.EncodeToString(k),
+					nested)
+				if err != nil {
+					return err
+				}
+				d.Buckets = append(d.Buckets, child)
+				return nil
+			}
+		}
+		d.Values = append(d.Values, dumpValue{
+			Key:   hex.EncodeToString(k),
+			Value: hex.EncodeToString(v),
+		})
+		return nil
+	})
+	return d, err
+}
+
+// dump writes the top-level buckets with the names, or every top-level bucket,
+// as JSON.
+func dump(names []string) error {
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var buckets []*dumpBucket
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		if len(names) == 0 {
+			err := tx.ForEachBucket(func(name []byte) error {
+				names = append(names, string(name))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			b := tx.ReadBucket([]byte(name))
+			if b == nil {
+				return fmt.Errorf("bucket %q does not exist",
+					name)
+			}
+			d, err := dumpNested(name, b)
+			if err != nil {
+				return err
+			}
+			buckets = append(buckets, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(buckets)
+}
+
+// accounts lists the accounts of each active key scope, with the number of
+// addresses derived or imported.
+func accounts() error {
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	return walletdb.View(db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		if addrmgrNs == nil {
+			return errors.New("database is not a wallet database")
+		}
+		mgr, err := waddrmgr.Open(
+			addrmgrNs, []byte(opts.WalletPass), activeNet.Params,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to open address manager: %v",
+				err)
+		}
+		defer mgr.Close()
+
+		for _, scopedMgr := range mgr.ActiveScopedKeyManagers() {
+			fmt.Printf("Key scope %v:\n", scopedMgr.Scope())
+			err := scopedMgr.ForEachAccount(addrmgrNs,
+				func(account uint32) error {
+					props, err := scopedMgr.AccountProperties(
+						addrmgrNs, account,
+					)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("  %d %q: %d external, "+
+						"%d internal, %d imported\n",
+						props.AccountNumber,
+						props.AccountName,
+						props.ExternalKeyCount,
+						props.InternalKeyCount,
+						props.ImportedKeyCount)
+					return nil
+				})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// printInconsistencies prints the inconsistencies found in the transaction
+// history.
+func printInconsistencies(inconsistencies []wtxmgr.Inconsistency) {
+	for _, i := range inconsistencies {
+		fmt.Println(i)
+	}
+	fmt.Printf("%d inconsistencies found\n", len(inconsistencies))
+}
+
+// check checks the consistency of the transaction history.
+func check() error {
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := backupBeforeChange(db); err != nil {
+		return err
+	}
+
+	var inconsistencies []wtxmgr.Inconsistency
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		if txmgrNs == nil {
+			return errors.New("database is not a wallet database")
+		}
+		inconsistencies, err = wtxmgr.Check(txmgrNs)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	printInconsistencies(inconsistencies)
+	if len(inconsistencies) != 0 {
+		return errors.New("transaction history is inconsistent")
+	}
+	return nil
+}
+
+// repair repairs the inconsistencies of the transaction history.
+func repair() error {
+	ok, err := confirm("Repair the btcwallet transaction history?")
+	if err != nil || !ok {
+		return err
+	}
+
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := backupBeforeChange(db); err != nil {
+		return err
+	}
+
+	var inconsistencies []wtxmgr.Inconsistency
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		txmgrNs := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if txmgrNs == nil {
+			return errors.New("database is not a wallet database")
+		}
+		inconsistencies, err = wtxmgr.Repair(txmgrNs)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to repair transaction history: %v",
+			err)
+	}
+
+	printInconsistencies(inconsistencies)
+	return nil
+}
+
+// copyBucket copies the key/value pairs, nested buckets and sequence of a
+// bucket.
+func copyBucket(dst, src walletdb.ReadWriteBucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+	return src.ForEach(func(k, v []byte) error {
+		// Nested buckets have a nil value.
+		var nested walletdb.ReadWriteBucket
+		if v == nil {
+			nested = src.NestedReadWriteBucket(k)
+		}
+		if nested == nil {
+			return dst.Put(k, v)
+		}
+		child, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(child, nested)
+	})
+}
+
+// compact rewrites the database to a new file, which replaces it.  Encrypted
+// databases are copied without being decrypted.
+func compact() error {
+	ok, err := confirm("Compact the btcwallet database?")
+	if err != nil || !ok {
+		return err
+	}
+
+	srcInfo, err := os.Stat(opts.DbPath)
+	if err != nil {
+		return err
+	}
+	src, err := openDB(true)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+
+	tmpPath := opts.DbPath + ".compact"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		src.Close()
+		return err
+	}
+	dst, err := walletdb.Create(opts.DBDriver, dbArgs(tmpPath)...)
+	if err != nil {
+		src.Close()
+		return fmt.Errorf("failed to create database: %v", err)
+	}
+
+	// Buckets are only read from the source, but a writable transaction is
+	// needed to read their sequences.
+	err = walletdb.Update(src, func(srcTx walletdb.ReadWriteTx) error {
+		return walletdb.Update(dst, func(dstTx walletdb.ReadWriteTx) error {
+			return srcTx.ForEachBucket(func(name []byte) error {
+				b, err := dstTx.CreateTopLevelBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(
+					b, srcTx.ReadWriteBucket(name),
+				)
+			})
+		})
+	})
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := src.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to copy database: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, opts.DbPath); err != nil {
+		return err
+	}
+	dstInfo, err := os.Stat(opts.DbPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Compacted database from %d to %d bytes\n",
+		srcInfo.Size(), dstInfo.Size())
+	return nil
+}
+
+// backup writes a copy of the database to a new file.  Encrypted databases are
+// copied without being decrypted.
+func backup(args []string) error {
+	if len(args) != 1 {
+		return errors.New("backup requires the path of the copy")
+	}
+
+	db, err := openDB(true)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := migration.Backup(db, args[0]); err != nil {
+		return fmt.Errorf("failed to back up database: %v", err)
+	}
+	fmt.Println("Database copied to", args[0])
+	return nil
+}
+
+// backupBeforeChange writes a copy of the database next to it before a command
+// changes it, so that the change can be undone by restoring the copy.
+func backupBeforeChange(db walletdb.DB) error {
+	backupPath := fmt.Sprintf("%s.%s.bak", opts.DbPath,
+		time.Now().Format("20060102150405"))
+	if err := migration.Backup(db, backupPath); err != nil {
+		return fmt.Errorf("failed to back up database: %v", err)
+	}
+	fmt.Println("Database backed up to", backupPath)
+	return nil
+}
+
+// migrationManagers returns the migration managers of the address and
+// transaction managers of the transaction.
+func migrationManagers(tx walletdb.ReadWriteTx) ([]migration.Manager, error) {
+	addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+	txmgrNs := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+	if addrmgrNs == nil || txmgrNs == nil {
+		return nil, errors.New("database is not a wallet database")
+	}
+	return []migration.Manager{
+		waddrmgr.NewMigrationManager(addrmgrNs),
+		wtxmgr.NewMigrationManager(txmgrNs),
+	}, nil
+}
+
+// migrations shows the migrations which are applied when the wallet is next
+// opened, and the changes they would make, without changing the database.
+func migrations() error {
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	reports, err := migration.DryRun(db, migrationManagers)
+	if err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	for _, report := range reports {
+		fmt.Printf("%s: version %d", report.Name,
+			report.CurrentVersion)
+		if len(report.Versions) == 0 {
+			fmt.Println(", up to date")
+			continue
+		}
+		fmt.Printf(", migrations %v\n", report.Versions)
+		for _, change := range report.Changes {
+			path := make([]string, 0, len(change.Buckets)+1)
+			for _, bucket := range change.Buckets {
+				path = append(path, hex.EncodeToString(bucket))
+			}
+			path = append(path, hex.EncodeToString(change.Key))
+			kind := "key"
+			if change.Bucket {
+				kind = "bucket"
+			}
+			fmt.Printf("  %s %s %s\n", change.Kind, kind,
+				strings.Join(path, "/"))
+		}
+	}
+	return nil
+}
+
+// rollback rolls back the migrations of a service to a previous version, so
+// the database can be opened by a previous release.
+func rollback(args []string) error {
+	if len(args) != 2 {
+		return errors.New("rollback requires a service and version")
+	}
+	var nsKey []byte
+	switch args[0] {
+	case string(waddrmgrNamespaceKey), string(wtxmgrNamespaceKey):
+		nsKey = []byte(args[0])
+	default:
+		return fmt.Errorf("unknown service %q", args[0])
+	}
+	version, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %v", args[1], err)
+	}
+
+	ok, err := confirm(fmt.Sprintf("Roll back %s to version %d?",
+		args[0], version))
+	if err != nil || !ok {
+		return err
+	}
+
+	db, err := openDB(false)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := backupBeforeChange(db); err != nil {
+		return err
+	}
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(nsKey)
+		if ns == nil {
+			return errors.New("database is not a wallet database")
+		}
+		var mgr migration.Manager = waddrmgr.NewMigrationManager(ns)
+		if args[0] == string(wtxmgrNamespaceKey) {
+			mgr = wtxmgr.NewMigrationManager(ns)
+		}
+		return migration.Rollback(mgr, uint32(version))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back: %v", err)
+	}
+	fmt.Printf("Rolled back %s to version %d\n", args[0], version)
+	return nil
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -21,7 +22,9 @@ import (
 	"github.com/btcsuite/btcwallet/internal/cfgutil"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
 	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/webhook"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/lightninglabs/neutrino"
 )
@@ -34,6 +37,7 @@ const (
 	defaultLogFilename      = "btcwallet.log"
 	defaultRPCMaxClients    = 10
 	defaultRPCMaxWebsockets = 25
+	defaultPayjoinMinConf   = 1
 )
 
 var (
@@ -50,7 +54,7 @@ type config struct {
 	ConfigFile      *cfgutil.ExplicitString `short:"C" long:"configfile" description:"Path to configuration file"`
 	ShowVersion     bool                    `short:"V" long:"version" description:"Display version information and exit"`
 	Create          bool                    `long:"create" description:"Create the wallet if it does not exist"`
-	CreateTemp      bool                    `long:"createtemp" description:"Create a temporary simulation wallet (pass=password) in the data directory indicated; must call with --datadir"`
+	CreateTemp      bool                    `long:"createtemp" description:"Create a temporary simulation wallet (pass=password) in the data directory indicated; must call with --datadir, unless the wallet is kept in memory with --dbdriver=memdb"`
 	AppDataDir      *cfgutil.ExplicitString `short:"A" long:"appdata" description:"Application data directory for wallet config, databases and logs"`
 	TestNet3        bool                    `long:"testnet" description:"Use the test Bitcoin network (version 3) (default mainnet)"`
 	SimNet          bool                    `long:"simnet" description:"Use the simulation test network (default mainnet)"`
@@ -62,6 +66,8 @@ type config struct {
 	LogDir          string                  `long:"logdir" description:"Directory to log output."`
 	Profile         string                  `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	DBTimeout       time.Duration           `long:"dbtimeout" description:"The timeout value to use when opening the wallet database."`
+	DBDriver        string                  `long:"dbdriver" description:"The database driver of the wallet database {bdb, sqlite, memdb} -- NOTE: memdb wallets are lost when the process exits"`
+	EncryptDB       bool                    `long:"encryptdb" description:"Encrypt the whole wallet database with a key derived from the public wallet password -- Existing wallets are replaced by an encrypted copy when opened"`
 
 	// Wallet options
 	WalletPass string `long:"walletpass" default-mask:"-" description:"The public wallet password -- Only required if the wallet was created with one"`
@@ -101,12 +107,28 @@ type config struct {
 	LegacyRPCMaxWebsockets int64                   `long:"rpcmaxwebsockets" description:"Max number of legacy RPC websocket connections"`
 	Username               string                  `short:"u" long:"username" description:"Username for legacy RPC and btcd authentication (if btcdusername is unset)"`
 	Password               string                  `short:"P" long:"password" default-mask:"-" description:"Password for legacy RPC and btcd authentication (if btcdpassword is unset)"`
+	RPCAuth                []string                `long:"rpcauth" default-mask:"-" description:"Additional RPC credentials as username:password:role, where role is readonly, spend or admin -- Can be specified multiple times"`
 
 	// EXPERIMENTAL RPC server options
 	//
 	// These options will change (and require changes to config files, etc.)
 	// when the new gRPC server is enabled.
-	ExperimentalRPCListeners []string `long:"experimentalrpclisten" description:"Listen for RPC connections on this interface/port"`
+	ExperimentalRPCListeners  []string `long:"experimentalrpclisten" description:"Listen for RPC connections on this interface/port"`
+	ExperimentalRPCAuth       bool     `long:"experimentalrpcauth" description:"Require RPC credentials in the authorization metadata of each call, limiting calls to the methods permitted for their role"`
+	ExperimentalRPCMacaroons  bool     `long:"experimentalrpcmacaroons" description:"Require a macaroon in the macaroon metadata of each call, limiting calls to the permissions of the macaroon"`
+	ExperimentalRESTListeners []string `long:"experimentalrestlisten" description:"Listen for REST/JSON requests to the experimental RPC server on this interface/port (requires --experimentalrpclisten)"`
+
+	// Webhook options
+	Webhooks     []string `long:"webhook" default-mask:"-" description:"Deliver wallet events to an HTTP(S) URL as url,secret, where the secret signs the requests and must differ for each webhook -- Can be specified multiple times"`
+	WebhookConfs int32    `long:"webhookconfs" description:"Number of confirmations before a transaction is reported as confirmed to webhooks"`
+
+	// Payjoin options
+	PayjoinListeners []string `long:"payjoinlisten" description:"Listen for BIP78 payjoin requests over plain HTTP on this interface/port -- NOTE: Must be exposed as an onion service or behind a proxy terminating TLS"`
+	PayjoinAccount   uint32   `long:"payjoinaccount" description:"Account whose outputs are added to received payjoins"`
+	PayjoinMinConf   int32    `long:"payjoinminconf" description:"Minimum number of confirmations of the outputs added to received payjoins"`
+
+	// Metrics options
+	MetricsListeners []string `long:"metricslisten" description:"Listen for Prometheus metrics requests over plain HTTP on this interface/port -- NOTE: Metrics are served without authentication"`
 
 	// Deprecated options
 	DataDir *cfgutil.ExplicitString `short:"b" long:"datadir" default-mask:"-" description:"DEPRECATED -- use appdata instead"`
@@ -278,6 +300,9 @@ func loadConfig() (*config, []string, error) {
 		BanDuration:            neutrino.BanDuration,
 		BanThreshold:           neutrino.BanThreshold,
 		DBTimeout:              wallet.DefaultDBTimeout,
+		DBDriver:               wallet.BoltDBDriver,
+		WebhookConfs:           webhook.DefaultConfirmations,
+		PayjoinMinConf:         defaultPayjoinMinConf,
 	}
 
 	// Pre-parse the command line options to see if an alternative config
@@ -441,9 +466,44 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the database driver.
+	switch cfg.DBDriver {
+	case wallet.BoltDBDriver, wallet.SQLiteDBDriver, wallet.MemDBDriver:
+	default:
+		err := fmt.Errorf("loadConfig: unknown database driver %q",
+			cfg.DBDriver)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	// Encrypting the wallet database with the default public passphrase
+	// doesn't protect it.
+	if cfg.EncryptDB && cfg.WalletPass == wallet.InsecurePubPassphrase {
+		fmt.Fprintln(os.Stderr, "WARNING: the wallet database is "+
+			"encrypted with the default public wallet password, "+
+			"which provides no protection -- set walletpass to "+
+			"the public password of the wallet")
+	}
+
+	// Wallets kept in memory are lost when the process exits, so they can
+	// only be created on startup as simulation wallets, or over RPC.
+	memDB := cfg.DBDriver == wallet.MemDBDriver
+	if memDB && (cfg.Create || cfg.CreateTemp == cfg.NoInitialLoad) {
+		err := fmt.Errorf("loadConfig: the memdb database driver " +
+			"requires either the --createtemp or the --noinitialload " +
+			"option, and can't be used with --create")
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Exit if you try to use a simulation wallet with a standard
-	// data directory.
-	if !(cfg.AppDataDir.ExplicitlySet() || cfg.DataDir.ExplicitlySet()) && cfg.CreateTemp {
+	// data directory.  Simulation wallets kept in memory don't write to
+	// the data directory.
+	if !(cfg.AppDataDir.ExplicitlySet() || cfg.DataDir.ExplicitlySet()) &&
+		cfg.CreateTemp && !memDB {
+
 		fmt.Fprintln(os.Stderr, "Tried to create a temporary simulation "+
 			"wallet, but failed to specify data directory!")
 		os.Exit(0)
@@ -474,7 +534,9 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	if cfg.CreateTemp { // nolint:gocritic
+	if cfg.CreateTemp && memDB {
+		// The simulation wallet is created in memory when it's loaded.
+	} else if cfg.CreateTemp { // nolint:gocritic
 		tempWalletExists := false
 
 		if dbFileExists {
@@ -685,6 +747,90 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// The REST gateway calls the experimental RPC server and shares its
+	// TLS configuration, so it may only be enabled with that server.
+	if len(cfg.ExperimentalRESTListeners) > 0 &&
+		(len(cfg.ExperimentalRPCListeners) == 0 || cfg.DisableServerTLS) {
+
+		err := fmt.Errorf("%s: the --experimentalrestlisten option "+
+			"requires the --experimentalrpclisten option and server "+
+			"TLS", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Webhook requests are always signed, so every webhook needs its own
+	// secret.  A secret shared by several webhooks would let any of their
+	// receivers forge requests to the others.
+	webhookSecrets := make(map[string]struct{}, len(cfg.Webhooks))
+	for _, opt := range cfg.Webhooks {
+		e, err := webhook.ParseEndpoint(opt)
+		if err == nil {
+			if _, ok := webhookSecrets[string(e.Secret)]; ok {
+				err = errors.New("webhook secret is used by " +
+					"another webhook")
+			}
+			webhookSecrets[string(e.Secret)] = struct{}{}
+		}
+		if err != nil {
+			err := fmt.Errorf("%s: invalid --webhook option: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+	if cfg.WebhookConfs < 1 {
+		err := fmt.Errorf("%s: the --webhookconfs option must be at "+
+			"least 1", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.PayjoinMinConf < 0 {
+		err := fmt.Errorf("%s: the --payjoinminconf option must not be "+
+			"negative", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	for _, auth := range cfg.RPCAuth {
+		if _, err := rpcauth.ParseCredential(auth); err != nil {
+			err := fmt.Errorf("%s: invalid --rpcauth option: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Experimental RPC calls can only be authenticated if any credentials
+	// are configured.
+	if cfg.ExperimentalRPCAuth && len(cfg.RPCAuth) == 0 &&
+		(cfg.Username == "" || cfg.Password == "") {
+
+		err := fmt.Errorf("%s: the --experimentalrpcauth option "+
+			"requires the --username and --password or --rpcauth "+
+			"options", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Experimental RPC calls are authenticated either by credentials or by
+	// macaroons, not both.
+	if cfg.ExperimentalRPCAuth && cfg.ExperimentalRPCMacaroons {
+		err := fmt.Errorf("%s: the --experimentalrpcauth and "+
+			"--experimentalrpcmacaroons options may not be used "+
+			"together", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Expand environment variable and leading ~ for filepaths.
 	cfg.CAFile.Value = cleanAndExpandPath(cfg.CAFile.Value)
 	cfg.RPCCert.Value = cleanAndExpandPath(cfg.RPCCert.Value)
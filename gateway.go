@@ -0,0 +1,98 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/btcsuite/btcwallet/rpc/gateway"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// restGateway serves REST/JSON requests by calling the experimental RPC
+// server.
+type restGateway struct {
+	conn    *grpc.ClientConn
+	servers []*http.Server
+}
+
+// startRESTGateway serves a REST gateway to the gRPC server on the listeners.
+// The gateway calls the server through an in-memory connection secured by
+// the TLS keypair of the server, so calls pass through the interceptors of
+// the server like any other.
+func startRESTGateway(server *grpc.Server, keyPair tls.Certificate,
+	listeners []net.Listener) (*restGateway, error) {
+
+	pipe := gateway.NewListener()
+	go func() {
+		err := server.Serve(pipe)
+		log.Tracef("Finished serving REST gateway: %v", err)
+	}()
+
+	// The connection never leaves the process, so rather than verifying
+	// the hostname of the certificate, which may not name any host the
+	// gateway could use, verify that it is the certificate of the server.
+	serverCert := keyPair.Certificate[0]
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte,
+			_ [][]*x509.Certificate) error {
+
+			if len(rawCerts) == 0 ||
+				!bytes.Equal(rawCerts[0], serverCert) {
+
+				return errors.New("unexpected RPC server " +
+					"certificate")
+			}
+			return nil
+		},
+	}
+	conn, err := grpc.Dial("gateway",
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return pipe.Dial()
+		}),
+	)
+	if err != nil {
+		pipe.Close()
+		return nil, err
+	}
+
+	g := &restGateway{conn: conn}
+	handler := gateway.New(conn)
+	for _, lis := range listeners {
+		httpServer := &http.Server{Handler: handler}
+		g.servers = append(g.servers, httpServer)
+		lis := lis
+		go func() {
+			log.Infof("Experimental REST gateway listening on %s",
+				lis.Addr())
+			err := httpServer.Serve(lis)
+			if err != http.ErrServerClosed {
+				log.Errorf("REST gateway failed: %v", err)
+			}
+		}()
+	}
+	return g, nil
+}
+
+// stop closes the REST gateway servers and its connection to the gRPC server.
+func (g *restGateway) stop() {
+	for _, server := range g.servers {
+		if err := server.Close(); err != nil {
+			log.Errorf("Unable to close REST gateway server: %v", err)
+		}
+	}
+	if err := g.conn.Close(); err != nil {
+		log.Errorf("Unable to close REST gateway connection: %v", err)
+	}
+}
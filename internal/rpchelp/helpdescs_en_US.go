@@ -9,19 +9,21 @@ package rpchelp
 
 var helpDescsEnUS = map[string]string{
 	// AddMultisigAddressCmd help.
-	"addmultisigaddress--synopsis": "Generates and imports a multisig address and redeeming script to the 'imported' account.",
+	"addmultisigaddress--synopsis": "Generates and imports a multisig address and redeeming script to the 'imported' account.\n" +
+		"An optional address_type parameter following the account selects a 'legacy' (default), 'p2sh-segwit' or 'bech32' multisig address.",
 	"addmultisigaddress-account":   "DEPRECATED -- Unused (all imported addresses belong to the imported account)",
 	"addmultisigaddress-keys":      "Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address",
 	"addmultisigaddress-nrequired": "The number of signatures required to redeem outputs paid to this address",
-	"addmultisigaddress--result0":  "The imported pay-to-script-hash address",
+	"addmultisigaddress--result0":  "The imported pay-to-script-hash or pay-to-witness-script-hash address",
 
 	// CreateMultisigCmd help.
-	"createmultisig--synopsis": "Generate a multisig address and redeem script.",
+	"createmultisig--synopsis": "Generate a multisig address and redeem script.\n" +
+		"An optional address_type parameter following the keys selects a 'legacy' (default), 'p2sh-segwit' or 'bech32' multisig address.",
 	"createmultisig-keys":      "Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address",
 	"createmultisig-nrequired": "The number of signatures required to redeem outputs paid to this address",
 
 	// CreateMultisigResult help.
-	"createmultisigresult-address":      "The generated pay-to-script-hash address",
+	"createmultisigresult-address":      "The generated pay-to-script-hash or pay-to-witness-script-hash address",
 	"createmultisigresult-redeemScript": "The script required to redeem outputs paid to the multisig address",
 
 	// DumpPrivKeyCmd help.
@@ -301,10 +303,11 @@ var helpDescsEnUS = map[string]string{
 	"settxfee--result0":  "The boolean 'true'",
 
 	// SignMessageCmd help.
-	"signmessage--synopsis": "Signs a message using the private key of a payment address.",
-	"signmessage-address":   "Payment address of private key used to sign the message with",
-	"signmessage-message":   "Message to sign",
-	"signmessage--result0":  "The signed message encoded as a base64 string",
+	"signmessage--synopsis": "Signs a message using the private key of a payment address.\n" +
+		"P2PKH addresses create a legacy compact signature, P2WKH addresses a BIP0322 simple signature and nested P2WKH addresses a BIP0322 full signature.",
+	"signmessage-address":  "Payment address of private key used to sign the message with",
+	"signmessage-message":  "Message to sign",
+	"signmessage--result0": "The signed message encoded as a base64 string",
 
 	// SignRawTransactionCmd help.
 	"signrawtransaction--synopsis": "Signs transaction inputs using private keys from this wallet and request.\n" +
@@ -326,6 +329,149 @@ var helpDescsEnUS = map[string]string{
 	"signrawtransactionerror-txid":      "The transaction hash of the referenced previous output",
 	"signrawtransactionerror-vout":      "The output index of the referenced previous output",
 
+	// AnalyzePsbtCmd help.
+	"analyzepsbt--synopsis": "Analyzes a PSBT and reports the next BIP 174 role required to process each input and the whole packet.",
+	"analyzepsbt-psbt":      "The base64 encoded PSBT",
+
+	// AnalyzePsbtResult help.
+	"analyzepsbtresult-inputs":            "The analysis of every input",
+	"analyzepsbtresult-estimated_vsize":   "The virtual size of the finalized transaction, only known once every input is signed",
+	"analyzepsbtresult-estimated_feerate": "The fee rate of the finalized transaction in BTC/kvB, only known once every input is signed",
+	"analyzepsbtresult-fee":               "The fee paid by the transaction in BTC, only known once every input has UTXO information",
+	"analyzepsbtresult-next":              "The next role required to process the PSBT (updater, signer, finalizer, extractor or creator if the PSBT is invalid)",
+	"analyzepsbtresult-error":             "The reason the PSBT is invalid",
+
+	// AnalyzePsbtInputResult help.
+	"analyzepsbtinputresult-has_utxo": "Whether the UTXO spent by the input is known",
+	"analyzepsbtinputresult-is_final": "Whether the input is finalized",
+	"analyzepsbtinputresult-next":     "The next role required to process the input, unless it is finalized",
+
+	// CombinePsbtCmd help.
+	"combinepsbt--synopsis": "Combines several PSBTs of the same transaction into one PSBT, merging their signatures and other fields. The result uses the version of the first PSBT.",
+	"combinepsbt-txs":       "The base64 encoded PSBTs to combine",
+	"combinepsbt--result0":  "The combined PSBT encoded as base64",
+
+	// DecodePsbtCmd help.
+	"decodepsbt--synopsis": "Returns a JSON object describing a PSBT. Both version 0 and version 2 PSBTs are accepted.",
+	"decodepsbt-psbt":      "The base64 encoded PSBT",
+
+	// DecodePsbtResult help.
+	"decodepsbtresult-tx":                       "The decoded unsigned transaction",
+	"decodepsbtresult-unknown":                  "The unknown global fields",
+	"decodepsbtresult-unknown--key":             "key",
+	"decodepsbtresult-unknown--value":           "value",
+	"decodepsbtresult-unknown--desc":            "The hex encoded value of the hex encoded key",
+	"decodepsbtresult-psbt_version":             "The version of the PSBT, 0 (BIP0174) or 2 (BIP0370)",
+	"decodepsbtresult-inputs":                   "The fields of every input",
+	"decodepsbtresult-outputs":                  "The fields of every output",
+	"decodepsbtresult-fee":                      "The fee paid by the transaction in BTC, if every input has UTXO information",
+	"psbtinputresult-non_witness_utxo":          "The decoded transaction whose output the input spends",
+	"psbtinputresult-witness_utxo":              "The output the input spends",
+	"psbtinputresult-partial_signatures":        "The partial signatures of the input",
+	"psbtinputresult-partial_signatures--key":   "pubkey",
+	"psbtinputresult-partial_signatures--value": "signature",
+	"psbtinputresult-partial_signatures--desc":  "The hex encoded signature of the hex encoded public key",
+	"psbtinputresult-sighash":                   "The sighash type the input is to be signed with",
+	"psbtinputresult-redeem_script":             "The redeem script of the input",
+	"psbtinputresult-witness_script":            "The witness script of the input",
+	"psbtinputresult-bip32_derivs":              "The BIP 32 derivation paths of the keys of the input",
+	"psbtinputresult-final_scriptSig":           "The final signature script of the input",
+	"psbtinputresult-final_scriptwitness":       "The hex encoded items of the final witness of the input",
+	"psbtinputresult-unknown":                   "The unknown fields of the input",
+	"psbtinputresult-unknown--key":              "key",
+	"psbtinputresult-unknown--value":            "value",
+	"psbtinputresult-unknown--desc":             "The hex encoded value of the hex encoded key",
+	"psbtoutputresult-redeem_script":            "The redeem script of the output",
+	"psbtoutputresult-witness_script":           "The witness script of the output",
+	"psbtoutputresult-bip32_derivs":             "The BIP 32 derivation paths of the keys of the output",
+	"psbtwitnessutxoresult-amount":              "The value of the output in BTC",
+	"psbtwitnessutxoresult-scriptPubKey":        "The output script",
+	"psbtscriptresult-asm":                      "Disassembly of the script",
+	"psbtscriptresult-hex":                      "The hex encoded script",
+	"psbtscriptresult-type":                     "The type of the script",
+	"psbtbip32derivresult-pubkey":               "The hex encoded public key",
+	"psbtbip32derivresult-master_fingerprint":   "The fingerprint of the master key",
+	"psbtbip32derivresult-path":                 "The derivation path of the key",
+
+	// TxRawDecodeResult help.
+	"txrawdecoderesult-txid":       "The hash of the transaction",
+	"txrawdecoderesult-version":    "The transaction version",
+	"txrawdecoderesult-locktime":   "The transaction lock time",
+	"txrawdecoderesult-vin":        "The transaction inputs",
+	"txrawdecoderesult-vout":       "The transaction outputs",
+	"vin-coinbase":                 "The hex encoded signature script of a coinbase input",
+	"vin-txid":                     "The hash of the transaction of the spent output",
+	"vin-vout":                     "The index of the spent output",
+	"vin-scriptSig":                "The signature script of the input",
+	"vin-sequence":                 "The sequence number of the input",
+	"vin-txinwitness":              "The hex encoded witness items of the input",
+	"scriptsig-asm":                "Disassembly of the script",
+	"scriptsig-hex":                "The hex encoded script",
+	"vout-value":                   "The value of the output in BTC",
+	"vout-n":                       "The index of the output",
+	"vout-scriptPubKey":            "The output script",
+	"scriptpubkeyresult-asm":       "Disassembly of the script",
+	"scriptpubkeyresult-hex":       "The hex encoded script",
+	"scriptpubkeyresult-reqSigs":   "The number of signatures required to spend the output",
+	"scriptpubkeyresult-type":      "The type of the script",
+	"scriptpubkeyresult-addresses": "The addresses paid by the script",
+
+	// FinalizePsbtCmd help.
+	"finalizepsbt--synopsis": "Finalizes the inputs of a PSBT which have all required signatures. If every input is finalized and extract is set, the network serialized transaction is returned.",
+	"finalizepsbt-psbt":      "The base64 encoded PSBT",
+	"finalizepsbt-extract":   "Whether to extract the transaction if the PSBT is complete",
+
+	// FinalizePsbtResult help.
+	"finalizepsbtresult-psbt":     "The base64 encoded PSBT, unless the transaction was extracted",
+	"finalizepsbtresult-hex":      "The hex encoded network serialized transaction, if it was extracted",
+	"finalizepsbtresult-complete": "Whether every input is finalized",
+
+	// UtxoUpdatePsbtCmd help.
+	"utxoupdatepsbt--synopsis": "Adds the UTXO information and redeem scripts known to the wallet to the inputs of a PSBT which spend wallet outputs.",
+	"utxoupdatepsbt-psbt":      "The base64 encoded PSBT",
+	"utxoupdatepsbt--result0":  "The updated PSBT encoded as base64",
+
+	// WalletCreateFundedPsbtCmd help.
+	"walletcreatefundedpsbt--synopsis": "Creates a PSBT paying to the given outputs, funded by outputs of the default account and with a change output if necessary. " +
+		"The inputs and outputs are sorted according to BIP 69. The inputs are leased for 10 minutes so they aren't spent by other transactions.\n" +
+		"An optional psbt_version parameter following bip32derivs selects the version of the returned PSBT, 0 (default) or 2.",
+	"walletcreatefundedpsbt-inputs":      "The outputs to spend. If empty, inputs are selected by the wallet",
+	"walletcreatefundedpsbt-outputs":     "The outputs to pay to, each an object mapping an address to an amount in BTC, or 'data' to hex encoded data of a null data output",
+	"walletcreatefundedpsbt-locktime":    "The lock time of the transaction",
+	"walletcreatefundedpsbt-options":     "Additional options",
+	"walletcreatefundedpsbt-bip32derivs": "Whether to include the BIP 32 derivation paths of wallet keys (default: true)",
+	"psbtinput-txid":                     "The hash of the transaction of the output to spend",
+	"psbtinput-vout":                     "The index of the output to spend",
+	"psbtinput-sequence":                 "The sequence number of the input, or 0 for the default",
+
+	// WalletCreateFundedPsbtOpts help.
+	"walletcreatefundedpsbtopts-changeAddress":          "Unsupported, the change address is chosen by the wallet",
+	"walletcreatefundedpsbtopts-changePosition":         "Unsupported, the change position follows from the BIP 69 order",
+	"walletcreatefundedpsbtopts-change_type":            "Unsupported, the change output type is chosen by the wallet",
+	"walletcreatefundedpsbtopts-includeWatching":        "Ignored",
+	"walletcreatefundedpsbtopts-lockUnspents":           "Whether to also lock the selected outputs with lockunspent",
+	"walletcreatefundedpsbtopts-feeRate":                "The fee rate in BTC/kvB",
+	"walletcreatefundedpsbtopts-subtractFeeFromOutputs": "Unsupported",
+	"walletcreatefundedpsbtopts-replaceable":            "Whether the inputs signal replaceability (BIP 125)",
+	"walletcreatefundedpsbtopts-conf_target":            "Unsupported, use feeRate",
+	"walletcreatefundedpsbtopts-estimate_mode":          "Unsupported, use feeRate",
+
+	// WalletCreateFundedPsbtResult help.
+	"walletcreatefundedpsbtresult-psbt":      "The funded PSBT encoded as base64",
+	"walletcreatefundedpsbtresult-fee":       "The fee paid by the transaction in BTC",
+	"walletcreatefundedpsbtresult-changepos": "The index of the change output, or -1 if there is none",
+
+	// WalletProcessPsbtCmd help.
+	"walletprocesspsbt--synopsis":   "Adds the information known to the wallet to a PSBT and signs the inputs spending wallet outputs, without finalizing them. Version 2 PSBTs are returned as version 2.",
+	"walletprocesspsbt-psbt":        "The base64 encoded PSBT",
+	"walletprocesspsbt-sign":        "Whether to sign the inputs spending wallet outputs",
+	"walletprocesspsbt-sighashtype": "The sighash type of inputs without one; inputs with another sighash type are rejected",
+	"walletprocesspsbt-bip32derivs": "Whether to include the BIP 32 derivation paths of wallet keys (default: true)",
+
+	// WalletProcessPsbtResult help.
+	"walletprocesspsbtresult-psbt":     "The processed PSBT encoded as base64",
+	"walletprocesspsbtresult-complete": "Whether every input is signed",
+
 	// ValidateAddressCmd help.
 	"validateaddress--synopsis": "Verify that an address is valid.\n" +
 		"Extra details are returned if the address is controlled by this wallet.\n" +
@@ -350,7 +496,8 @@ var helpDescsEnUS = map[string]string{
 	"validateaddresswalletresult-sigsrequired": "The number of required signatures to redeem outputs to the multisig address",
 
 	// VerifyMessageCmd help.
-	"verifymessage--synopsis": "Verify a message was signed with the associated private key of some address.",
+	"verifymessage--synopsis": "Verify a message was signed with the associated private key of some address.\n" +
+		"Legacy compact signatures are accepted for P2PKH addresses, and BIP0322 simple and full signatures for any address.",
 	"verifymessage-address":   "Address used to sign message",
 	"verifymessage-signature": "The signature to verify",
 	"verifymessage-message":   "The message to verify",
@@ -401,6 +548,153 @@ var helpDescsEnUS = map[string]string{
 	"listalltransactions--synopsis": "Returns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.",
 	"listalltransactions-account":   "Unused (must be unset or \"*\")",
 
+	// CreateInvoiceCmd help.
+	"createinvoice--synopsis": "Creates an invoice requesting a payment to a new address of an account.",
+	"createinvoice-amount":    "The requested amount in bitcoin, or 0 to accept any amount",
+	"createinvoice-memo":      "A description of the invoice, included in its BIP21 URI",
+	"createinvoice-expiry":    "The number of seconds after which the invoice expires if it is not fully paid, or 0 to never expire",
+	"createinvoice-account":   "The account to reserve the invoice address from",
+
+	// GetInvoiceCmd help.
+	"getinvoice--synopsis": "Returns an invoice.",
+	"getinvoice-id":        "The ID of the invoice",
+
+	// ListInvoicesCmd help.
+	"listinvoices--synopsis": "Returns every invoice, ordered by ID.",
+	"listinvoices-status":    "Only return invoices with this status (unpaid, partial, paid, overpaid or expired)",
+
+	// InvoiceResult help.
+	"invoiceresult-id":       "The ID of the invoice",
+	"invoiceresult-address":  "The address reserved for payments of the invoice",
+	"invoiceresult-account":  "The account of the invoice address",
+	"invoiceresult-amount":   "The requested amount in bitcoin",
+	"invoiceresult-received": "The amount in bitcoin paid to the invoice address",
+	"invoiceresult-memo":     "The description of the invoice",
+	"invoiceresult-created":  "The creation time of the invoice in seconds since 1 Jan 1970 GMT",
+	"invoiceresult-expiry":   "The expiry time of the invoice in seconds since 1 Jan 1970 GMT, omitted if the invoice never expires",
+	"invoiceresult-status":   "The status of the invoice (unpaid, partial, paid, overpaid or expired)",
+	"invoiceresult-uri":      "The BIP21 URI requesting payment of the invoice",
+	"invoiceresult-payments": "The outputs paying to the invoice address",
+
+	// InvoicePaymentResult help.
+	"invoicepaymentresult-txid":   "The hash of the paying transaction",
+	"invoicepaymentresult-vout":   "The output index of the payment",
+	"invoicepaymentresult-amount": "The amount of the payment in bitcoin",
+
+	// ListRescansCmd help.
+	"listrescans--synopsis": "Returns every rescan job which has not yet finished, including paused jobs.",
+
+	// RescanResult help.
+	"rescanresult-id":             "The ID used to pause, resume or cancel the rescan job",
+	"rescanresult-state":          "The state of the rescan job (active or paused)",
+	"rescanresult-running":        "Whether the job is part of the rescan currently performed",
+	"rescanresult-queued":         "Whether the job waits for the current rescan to finish",
+	"rescanresult-startheight":    "The height of the block the rescan job started from",
+	"rescanresult-starthash":      "The hash of the block the rescan job started from",
+	"rescanresult-progressheight": "The height of the last block the rescan job reported progress for, or 0 if no progress was made",
+	"rescanresult-progresshash":   "The hash of the last block the rescan job reported progress for",
+	"rescanresult-addresses":      "The number of addresses rescanned for",
+	"rescanresult-outpoints":      "The number of outpoints watched for spends",
+
+	// PauseRescanCmd help.
+	"pauserescan--synopsis": "Pauses a rescan job until it is resumed with resumerescan. A job paused while being rescanned stops recording progress, but the chain server completes the rescan.",
+	"pauserescan-id":        "The ID of the rescan job",
+
+	// RescanBlockchainCmd help.
+	"rescanblockchain--synopsis":   "Rescans the blocks of a height range for transactions relevant to the wallet's addresses and unspent outputs, returning once the rescan has passed the stop height.",
+	"rescanblockchain-startheight": "The height of the first block to rescan",
+	"rescanblockchain-stopheight":  "The height of the last block to rescan (default: the best block)",
+
+	// RescanBlockchainResult help.
+	"rescanblockchainresult-start_height":    "The height of the first rescanned block",
+	"rescanblockchainresult-stop_height":     "The height of the last rescanned block",
+	"rescanblockchainresult-transactions":    "The hashes of every wallet transaction mined in the rescanned blocks",
+	"rescanblockchainresult-newtransactions": "The hashes of the transactions which were found by the rescan",
+
+	// ResumeRescanCmd help.
+	"resumerescan--synopsis": "Resumes a paused rescan job from the last block it reported progress for.",
+	"resumerescan-id":        "The ID of the rescan job",
+
+	// CancelRescanCmd help.
+	"cancelrescan--synopsis": "Cancels a rescan job so it is never resumed.",
+	"cancelrescan-id":        "The ID of the rescan job",
+
+	// ConvertPsbtCmd help.
+	"convertpsbt--synopsis": "Converts a PSBT between version 0 (BIP0174) and version 2 (BIP0370).\n" +
+		"Converting to version 0 drops the required lock times of the inputs and the modifiable flags, as version 0 has no equivalent fields.",
+	"convertpsbt-psbt":     "The base64 encoded PSBT of either version",
+	"convertpsbt-version":  "The version to convert to",
+	"convertpsbt--result0": "The converted PSBT encoded as base64",
+
+	// CreateReserveProofCmd help.
+	"createreserveproof--synopsis": "Creates a BIP0127 proof of reserves for the given outputs and all outputs of the given accounts.\n" +
+		"The proof is a PSBT of a transaction that can never be mined, as its first input commits to the message by spending a nonexistent output.\n" +
+		"Inputs the wallet holds private keys for are signed and finalized, the remaining inputs must be signed by another wallet.",
+	"createreserveproof-message":  "The message to commit to, such as a challenge of the verifier",
+	"createreserveproof-outputs":  "Wallet outputs to prove",
+	"createreserveproof-accounts": "Accounts of the BIP0044 key scope whose outputs are all proven",
+	"createreserveproof-minconf":  "The minimum number of confirmations of the proven outputs",
+
+	// CreateReserveProofResult help.
+	"createreserveproofresult-psbt":     "The proof encoded as a base64 PSBT",
+	"createreserveproofresult-amount":   "The proven amount in BTC",
+	"createreserveproofresult-complete": "Whether every proven output is signed",
+
+	// VerifyReserveProofCmd help.
+	"verifyreserveproof--synopsis":  "Verifies a BIP0127 proof of reserves against the UTXO set of the chain backend.",
+	"verifyreserveproof-psbt":       "The proof encoded as a base64 PSBT",
+	"verifyreserveproof-message":    "The message the proof must commit to",
+	"verifyreserveproof-heighthint": "The height light clients scan the chain from for proven outputs without a height hint of the prover",
+
+	// VerifyReserveProofResult help.
+	"verifyreserveproofresult-valid":  "Whether the proof is valid and all proven outputs are unspent",
+	"verifyreserveproofresult-amount": "The proven amount in BTC",
+	"verifyreserveproofresult-error":  "The reason the proof is invalid",
+
+	// SendPayjoinCmd help.
+	"sendpayjoin--synopsis": "Pays a BIP0021 URI with a payjoin endpoint as described by BIP0078.\n" +
+		"The original transaction is posted to the endpoint, and the proposal of the receiver is checked before it is signed and broadcast.\n" +
+		"The change output may pay the fees of the inputs added by the receiver. If the receiver doesn't respond with a valid proposal, the original transaction is broadcast instead.",
+	"sendpayjoin-uri":     "The BIP0021 URI with a pj parameter",
+	"sendpayjoin-amount":  "The amount to send in bitcoin, required if the URI has no amount",
+	"sendpayjoin-account": "The account to send from",
+	"sendpayjoin-feerate": "The fee rate in bitcoin per kilobyte",
+	"sendpayjoin-minconf": "The minimum number of confirmations of the spent outputs",
+
+	// SendPayjoinResult help.
+	"sendpayjoinresult-txid":    "The hash of the broadcast transaction",
+	"sendpayjoinresult-payjoin": "Whether the payjoin transaction was broadcast rather than the original transaction",
+
+	// SweepPrivKeyCmd help.
+	"sweepprivkey--synopsis": "Spends every unspent output paying to the P2PKH, P2WPKH or P2SH-P2WPKH addresses of private keys to new internal addresses of an account, and broadcasts the transactions.\n" +
+		"The outputs are found by filtering the blocks from the start height to the best block, and are split across as many transactions as needed to keep each within the standard size.\n" +
+		"The keys are only used to sign the transactions and are never stored by the wallet.",
+	"sweepprivkey-privkeys":    "The WIF encoded private keys to sweep",
+	"sweepprivkey-startheight": "The height of the first block to search for outputs, which should precede the first use of the keys",
+	"sweepprivkey-account":     "The account receiving the swept outputs",
+	"sweepprivkey-feerate":     "The fee rate in bitcoin per kilobyte",
+
+	// SweepPrivKeyResult help.
+	"sweepprivkeyresult-txids":  "The hashes of the broadcast transactions",
+	"sweepprivkeyresult-inputs": "The number of swept outputs",
+	"sweepprivkeyresult-amount": "The total amount received by the account in bitcoin, after the fees",
+
+	// SubscribeCmd help.
+	"subscribe--synopsis": "Subscribes a websocket client to notifications of wallet events, returning every subscribed event.\n" +
+		"The events are transactions (newtx notifications of relevant transactions when they are added to the wallet and when they are mined), " +
+		"confirmations (txconfirmed notifications of transactions mined while subscribed reaching the given number of confirmations), " +
+		"balances (accountbalance notifications of the total balances of accounts whose balance changed), " +
+		"lockstate (walletlockstate notifications when the wallet is locked or unlocked) " +
+		"and rescan (rescanprogress and rescanfinished notifications of the rescans performed by the wallet).",
+	"subscribe-events":        "The events to subscribe to",
+	"subscribe-confirmations": "The number of confirmations of the confirmations event",
+	"subscribe--result0":      "Every subscribed event",
+
+	// UnsubscribeCmd help.
+	"unsubscribe--synopsis": "Unsubscribes a websocket client from notifications of wallet events, returning every remaining subscribed event.",
+	"unsubscribe-events":    "The events to unsubscribe from",
+	"unsubscribe--result0":  "Every remaining subscribed event",
+
 	// RenameAccountCmd help.
 	"renameaccount--synopsis":  "Renames an account.",
 	"renameaccount-oldaccount": "The old account name to rename",
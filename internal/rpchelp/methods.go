@@ -7,7 +7,10 @@
 
 package rpchelp
 
-import "github.com/btcsuite/btcd/btcjson"
+import (
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc/types"
+)
 
 // Common return types.
 var (
@@ -25,8 +28,12 @@ var Methods = []struct {
 	ResultTypes []interface{}
 }{
 	{"addmultisigaddress", returnsString},
+	{"analyzepsbt", []interface{}{(*types.AnalyzePsbtResult)(nil)}},
+	{"combinepsbt", returnsString},
 	{"createmultisig", []interface{}{(*btcjson.CreateMultiSigResult)(nil)}},
+	{"decodepsbt", []interface{}{(*types.DecodePsbtResult)(nil)}},
 	{"dumpprivkey", returnsString},
+	{"finalizepsbt", []interface{}{(*types.FinalizePsbtResult)(nil)}},
 	{"getaccount", returnsString},
 	{"getaccountaddress", returnsString},
 	{"getaddressesbyaccount", returnsStringArray},
@@ -56,18 +63,36 @@ var Methods = []struct {
 	{"settxfee", returnsBool},
 	{"signmessage", returnsString},
 	{"signrawtransaction", []interface{}{(*btcjson.SignRawTransactionResult)(nil)}},
+	{"utxoupdatepsbt", returnsString},
 	{"validateaddress", []interface{}{(*btcjson.ValidateAddressWalletResult)(nil)}},
 	{"verifymessage", returnsBool},
+	{"walletcreatefundedpsbt", []interface{}{(*btcjson.WalletCreateFundedPsbtResult)(nil)}},
 	{"walletlock", nil},
 	{"walletpassphrase", nil},
 	{"walletpassphrasechange", nil},
+	{"walletprocesspsbt", []interface{}{(*btcjson.WalletProcessPsbtResult)(nil)}},
+	{"cancelrescan", nil},
+	{"convertpsbt", returnsString},
+	{"createinvoice", []interface{}{(*types.InvoiceResult)(nil)}},
 	{"createnewaccount", nil},
+	{"createreserveproof", []interface{}{(*types.CreateReserveProofResult)(nil)}},
 	{"exportwatchingwallet", returnsString},
 	{"getbestblock", []interface{}{(*btcjson.GetBestBlockResult)(nil)}},
+	{"getinvoice", []interface{}{(*types.InvoiceResult)(nil)}},
 	{"getunconfirmedbalance", returnsNumber},
 	{"listaddresstransactions", returnsLTRArray},
 	{"listalltransactions", returnsLTRArray},
+	{"listinvoices", []interface{}{(*[]types.InvoiceResult)(nil)}},
+	{"listrescans", []interface{}{(*[]types.RescanResult)(nil)}},
+	{"pauserescan", nil},
 	{"renameaccount", nil},
+	{"rescanblockchain", []interface{}{(*types.RescanBlockchainResult)(nil)}},
+	{"resumerescan", nil},
+	{"sendpayjoin", []interface{}{(*types.SendPayjoinResult)(nil)}},
+	{"subscribe", returnsStringArray},
+	{"sweepprivkey", []interface{}{(*types.SweepPrivKeyResult)(nil)}},
+	{"unsubscribe", returnsStringArray},
+	{"verifyreserveproof", []interface{}{(*types.VerifyReserveProofResult)(nil)}},
 	{"walletislocked", returnsBool},
 }
 
@@ -13,9 +13,11 @@ import (
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/metrics"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/rpc/rpcserver"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/webhook"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 	"github.com/jrick/logrotate/rotator"
 	"github.com/lightninglabs/neutrino"
@@ -60,6 +62,8 @@ var (
 	grpcLog      = backendLog.Logger("GRPC")
 	legacyRPCLog = backendLog.Logger("RPCS")
 	btcnLog      = backendLog.Logger("BTCN")
+	webhookLog   = backendLog.Logger("HOOK")
+	metricsLog   = backendLog.Logger("MTRC")
 )
 
 // Initialize package-global logger variables.
@@ -71,6 +75,8 @@ func init() {
 	rpcserver.UseLogger(grpcLog)
 	legacyrpc.UseLogger(legacyRPCLog)
 	neutrino.UseLogger(btcnLog)
+	webhook.UseLogger(webhookLog)
+	metrics.UseLogger(metricsLog)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -82,6 +88,8 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"GRPC": grpcLog,
 	"RPCS": legacyRPCLog,
 	"BTCN": btcnLog,
+	"HOOK": webhookLog,
+	"MTRC": metricsLog,
 }
 
 // initLogRotator initializes the logging rotater to write logs to logFile and
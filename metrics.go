@@ -0,0 +1,67 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/btcsuite/btcwallet/metrics"
+)
+
+// metricsService serves Prometheus metrics of the wallet process.
+type metricsService struct {
+	metrics *metrics.Metrics
+
+	mu      sync.Mutex
+	servers []*http.Server
+}
+
+// newMetricsService returns a metrics service which is not yet serving.
+func newMetricsService() *metricsService {
+	return &metricsService{metrics: metrics.New()}
+}
+
+// start serves the metrics on the configured listeners.
+func (s *metricsService) start() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.Handler())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, addr := range cfg.MetricsListeners {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Errorf("Unable to listen for metrics requests on "+
+				"%s: %v", addr, err)
+			continue
+		}
+
+		server := &http.Server{Handler: mux}
+		s.servers = append(s.servers, server)
+		log.Infof("Metrics server listening on %s", listener.Addr())
+		go func() {
+			err := server.Serve(listener)
+			if err != http.ErrServerClosed {
+				log.Errorf("Metrics server failed: %v", err)
+			}
+		}()
+	}
+}
+
+// stop closes the metrics servers.
+func (s *metricsService) stop() {
+	s.mu.Lock()
+	servers := s.servers
+	s.servers = nil
+	s.mu.Unlock()
+
+	for _, server := range servers {
+		if err := server.Close(); err != nil {
+			log.Errorf("Unable to close metrics server: %v", err)
+		}
+	}
+}
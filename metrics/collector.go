@@ -0,0 +1,212 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	accountBalanceDesc = prometheus.NewDesc(
+		namespace+"_account_balance_satoshis",
+		"Total balance of an account.",
+		[]string{"scope", "account"}, nil,
+	)
+	utxosDesc = prometheus.NewDesc(
+		namespace+"_utxos",
+		"Number of unspent outputs, including unmined ones.",
+		nil, nil,
+	)
+	unminedTxsDesc = prometheus.NewDesc(
+		namespace+"_unmined_transactions",
+		"Number of unmined wallet transactions.",
+		nil, nil,
+	)
+	syncedHeightDesc = prometheus.NewDesc(
+		namespace+"_synced_height",
+		"Height of the block the wallet is synced to.",
+		nil, nil,
+	)
+	chainTipHeightDesc = prometheus.NewDesc(
+		namespace+"_chain_tip_height",
+		"Height of the best block of the chain backend.",
+		nil, nil,
+	)
+	chainSyncedDesc = prometheus.NewDesc(
+		namespace+"_chain_synced",
+		"Whether the wallet is synced to the chain backend.",
+		nil, nil,
+	)
+	rescanJobsDesc = prometheus.NewDesc(
+		namespace+"_rescan_jobs",
+		"Number of unfinished rescan jobs, including paused jobs.",
+		nil, nil,
+	)
+	rescanStartHeightDesc = prometheus.NewDesc(
+		namespace+"_rescan_start_height",
+		"Height of the block a rescan job was started from.",
+		[]string{"id"}, nil,
+	)
+	rescanProgressHeightDesc = prometheus.NewDesc(
+		namespace+"_rescan_progress_height",
+		"Height of the last block a rescan job reported progress for.",
+		[]string{"id"}, nil,
+	)
+	chainReconnectsDesc = prometheus.NewDesc(
+		namespace+"_chain_reconnects_total",
+		"Number of reconnects to the chain backend.",
+		nil, nil,
+	)
+	chainQueueLenDesc = prometheus.NewDesc(
+		namespace+"_chain_notification_queue_length",
+		"Number of chain notifications waiting to be handled.",
+		nil, nil,
+	)
+)
+
+// reconnector is implemented by chain clients counting their reconnects.
+type reconnector interface {
+	Reconnects() uint64
+}
+
+// notificationQueuer is implemented by chain clients exposing the length of
+// their notification queue.
+type notificationQueuer interface {
+	NotificationQueueLen() int
+}
+
+// chainReconnects returns the number of reconnects made by a chain client, or
+// zero if it doesn't count them.
+func chainReconnects(c chain.Interface) uint64 {
+	if r, ok := c.(reconnector); ok {
+		return r.Reconnects()
+	}
+	return 0
+}
+
+// walletCollector is a prometheus.Collector reading the state of the wallet
+// and chain client of a Metrics instance when scraped.
+type walletCollector struct {
+	m *Metrics
+}
+
+// Describe sends the descriptors of the wallet metrics.
+//
+// NOTE: This is part of the prometheus.Collector interface.
+func (c *walletCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- accountBalanceDesc
+	ch <- utxosDesc
+	ch <- unminedTxsDesc
+	ch <- syncedHeightDesc
+	ch <- chainTipHeightDesc
+	ch <- chainSyncedDesc
+	ch <- rescanJobsDesc
+	ch <- rescanStartHeightDesc
+	ch <- rescanProgressHeightDesc
+	ch <- chainReconnectsDesc
+	ch <- chainQueueLenDesc
+}
+
+// Collect sends the current values of the wallet metrics.  Metrics which can
+// not be read are logged and omitted.
+//
+// NOTE: This is part of the prometheus.Collector interface.
+func (c *walletCollector) Collect(ch chan<- prometheus.Metric) {
+	w, chainClient, reconnects := c.m.state()
+	if w != nil && chainClient == nil {
+		chainClient = w.ChainClient()
+	}
+
+	if chainClient != nil {
+		ch <- prometheus.MustNewConstMetric(
+			chainReconnectsDesc, prometheus.CounterValue,
+			float64(reconnects),
+		)
+		if q, ok := chainClient.(notificationQueuer); ok {
+			ch <- prometheus.MustNewConstMetric(
+				chainQueueLenDesc, prometheus.GaugeValue,
+				float64(q.NotificationQueueLen()),
+			)
+		}
+		_, height, err := chainClient.GetBestBlock()
+		if err != nil {
+			log.Warnf("Unable to fetch best block of chain "+
+				"backend: %v", err)
+		} else {
+			ch <- prometheus.MustNewConstMetric(
+				chainTipHeightDesc, prometheus.GaugeValue,
+				float64(height),
+			)
+		}
+	}
+
+	if w == nil || w.ShuttingDown() {
+		return
+	}
+
+	gauge := func(desc *prometheus.Desc, value float64,
+		labels ...string) {
+
+		ch <- prometheus.MustNewConstMetric(
+			desc, prometheus.GaugeValue, value, labels...,
+		)
+	}
+
+	gauge(syncedHeightDesc, float64(w.Manager.SyncedTo().Height))
+	chainSynced := 0.0
+	if w.ChainSynced() {
+		chainSynced = 1
+	}
+	gauge(chainSyncedDesc, chainSynced)
+
+	c.collectBalances(w, gauge)
+
+	stats, err := w.TxStoreStats()
+	if err != nil {
+		log.Warnf("Unable to fetch transaction store stats: %v", err)
+	} else {
+		gauge(utxosDesc, float64(stats.UnspentOutputs))
+		gauge(unminedTxsDesc, float64(stats.UnminedTxs))
+	}
+
+	rescans, err := w.Rescans()
+	if err != nil {
+		log.Warnf("Unable to fetch rescan jobs: %v", err)
+		return
+	}
+	gauge(rescanJobsDesc, float64(len(rescans)))
+	for _, rescan := range rescans {
+		id := strconv.FormatUint(rescan.ID, 10)
+		gauge(rescanStartHeightDesc,
+			float64(rescan.StartBlock.Height), id)
+		gauge(rescanProgressHeightDesc,
+			float64(rescan.ProgressBlock.Height), id)
+	}
+}
+
+// collectBalances sends the balance of every account of the active key
+// scopes of the wallet.
+func (c *walletCollector) collectBalances(w *wallet.Wallet,
+	gauge func(*prometheus.Desc, float64, ...string)) {
+
+	for _, manager := range w.Manager.ActiveScopedKeyManagers() {
+		scope := manager.Scope()
+		result, err := w.Accounts(scope)
+		if err != nil {
+			log.Warnf("Unable to fetch accounts of scope %v: %v",
+				scope, err)
+			continue
+		}
+		for _, account := range result.Accounts {
+			gauge(accountBalanceDesc,
+				float64(account.TotalBalance),
+				scope.String(), account.AccountName)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package metrics exports the metrics of a wallet process to Prometheus.
+
+A Metrics instance owns a registry which is served by its Handler in the
+Prometheus text format.  It collects the following metrics, in addition to the
+standard Go runtime and process metrics:
+
+	btcwallet_account_balance_satoshis         total balance of each account, by
+	                                           key scope and account name
+	btcwallet_utxos                            unspent outputs, including
+	                                           unmined ones
+	btcwallet_unmined_transactions             unmined wallet transactions
+	btcwallet_synced_height                    height the wallet is synced to
+	btcwallet_chain_tip_height                 best height of the chain backend
+	btcwallet_chain_synced                     1 if the wallet is synced to the
+	                                           chain backend, otherwise 0
+	btcwallet_rescan_jobs                      unfinished rescan jobs
+	btcwallet_rescan_start_height              start height of each rescan job
+	btcwallet_rescan_progress_height           last height each rescan job
+	                                           reported progress for
+	btcwallet_chain_reconnects_total           reconnects to the chain backend
+	btcwallet_chain_notification_queue_length  chain notifications waiting to
+	                                           be handled by the wallet
+	btcwallet_rpc_request_duration_seconds     duration of RPC requests, by
+	                                           server, method and result
+	btcwallet_walletdb_tx_duration_seconds     duration of database
+	                                           transactions, by type
+
+The wallet and chain backend metrics are read when the registry is scraped,
+once a wallet and chain client were set.  Request counts are exported as the
+_count series of the request duration histogram.  Requests are observed with
+ObserveRequest and the gRPC server interceptors, and database transactions by
+wrapping the wallet database with InstrumentDB.
+*/
+package metrics
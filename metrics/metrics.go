@@ -0,0 +1,163 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// namespace prefixes the names of all wallet metrics.
+const namespace = "btcwallet"
+
+// Server labels of the requests observed by ObserveRequest.
+const (
+	ServerLegacyRPC = "legacyrpc"
+	ServerGRPC      = "grpc"
+)
+
+// Metrics collects the metrics of a wallet process.  It is safe for
+// concurrent access.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	dbTxDuration    *prometheus.HistogramVec
+
+	mu          sync.Mutex
+	wallet      *wallet.Wallet
+	chainClient chain.Interface
+
+	// reconnectsBase is the number of reconnects of the chain clients
+	// replaced by SetChainClient.
+	reconnectsBase uint64
+}
+
+// New returns a Metrics instance with a registry holding all wallet metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "rpc",
+				Name:      "request_duration_seconds",
+				Help: "Duration of RPC requests by server, " +
+					"method and result.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"server", "method", "result"},
+		),
+		dbTxDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "walletdb",
+				Name:      "tx_duration_seconds",
+				Help: "Duration of wallet database transactions " +
+					"by type.",
+				Buckets: prometheus.ExponentialBuckets(
+					0.0001, 4, 10,
+				),
+			},
+			[]string{"type"},
+		),
+	}
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.dbTxDuration,
+		&walletCollector{m: m},
+	)
+	return m
+}
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus text
+// format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// SetWallet sets the wallet whose state is exported.
+func (m *Metrics) SetWallet(w *wallet.Wallet) {
+	m.mu.Lock()
+	m.wallet = w
+	m.mu.Unlock()
+}
+
+// SetChainClient sets the chain client whose reconnects and notification
+// queue are exported.  Replacing a previously set client counts as a
+// reconnect, in addition to those made by the previous client itself.
+func (m *Metrics) SetChainClient(c chain.Interface) {
+	m.mu.Lock()
+	if m.chainClient != nil {
+		m.reconnectsBase += chainReconnects(m.chainClient) + 1
+	}
+	m.chainClient = c
+	m.mu.Unlock()
+}
+
+// state returns the wallet and chain client, and the total number of chain
+// reconnects.
+func (m *Metrics) state() (*wallet.Wallet, chain.Interface, uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reconnects := m.reconnectsBase
+	if m.chainClient != nil {
+		reconnects += chainReconnects(m.chainClient)
+	}
+	return m.wallet, m.chainClient, reconnects
+}
+
+// ObserveRequest records a request to the method of an RPC server which took
+// d to handle and failed if failed is set.
+func (m *Metrics) ObserveRequest(server, method string, d time.Duration,
+	failed bool) {
+
+	result := "ok"
+	if failed {
+		result = "error"
+	}
+	m.requestDuration.WithLabelValues(server, method, result).
+		Observe(d.Seconds())
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor observing unary requests.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveRequest(
+			ServerGRPC, info.FullMethod, time.Since(start), err != nil,
+		)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor observing streaming
+// requests, which are recorded when the stream ends.
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		start := time.Now()
+		err := handler(srv, ss)
+		m.ObserveRequest(
+			ServerGRPC, info.FullMethod, time.Since(start), err != nil,
+		)
+		return err
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// scrape returns the metrics served by the handler of m.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// TestMetrics tests that observed requests and database transactions are
+// served by the handler, and that the wallet metrics are omitted until a
+// wallet is set.
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db = m.InstrumentDB(db)
+
+	bucketKey := []byte("bucket")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		_, err := tx.CreateTopLevelBucket(bucketKey)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginReadWriteTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.ObserveRequest(ServerLegacyRPC, "getbalance", time.Millisecond, false)
+	m.ObserveRequest(ServerLegacyRPC, "getbalance", time.Millisecond, true)
+	m.ObserveRequest(ServerGRPC, "/walletrpc.WalletService/Balance",
+		time.Millisecond, false)
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`btcwallet_walletdb_tx_duration_seconds_count{type="read"} 1`,
+		`btcwallet_walletdb_tx_duration_seconds_count{type="write"} 2`,
+		`btcwallet_rpc_request_duration_seconds_count{method="getbalance",result="ok",server="legacyrpc"} 1`,
+		`btcwallet_rpc_request_duration_seconds_count{method="getbalance",result="error",server="legacyrpc"} 1`,
+		`btcwallet_rpc_request_duration_seconds_count{method="/walletrpc.WalletService/Balance",result="ok",server="grpc"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing metric %q in:\n%s", want, body)
+		}
+	}
+
+	for _, unwanted := range []string{
+		"btcwallet_synced_height",
+		"btcwallet_chain_reconnects_total",
+	} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("unexpected metric %q without a wallet or "+
+				"chain client", unwanted)
+		}
+	}
+}
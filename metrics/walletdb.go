@@ -0,0 +1,121 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// Database transaction type labels.
+const (
+	txTypeRead  = "read"
+	txTypeWrite = "write"
+)
+
+// InstrumentDB returns the database wrapped to observe the duration of its
+// transactions, from their start until they are committed or rolled back.
+func (m *Metrics) InstrumentDB(db walletdb.DB) walletdb.DB {
+	return &instrumentedDB{DB: db, m: m}
+}
+
+// instrumentedDB is a walletdb.DB observing the duration of its transactions.
+type instrumentedDB struct {
+	walletdb.DB
+	m *Metrics
+}
+
+// Unwrap returns the instrumented database, which lets callers such as the
+// cryptdb package tell whether it's encrypted.
+func (db *instrumentedDB) Unwrap() walletdb.DB {
+	return db.DB
+}
+
+// observe records a transaction of the given type which started at start.
+func (db *instrumentedDB) observe(txType string, start time.Time) {
+	db.m.dbTxDuration.WithLabelValues(txType).Observe(
+		time.Since(start).Seconds(),
+	)
+}
+
+// BeginReadTx opens a database read transaction.
+func (db *instrumentedDB) BeginReadTx() (walletdb.ReadTx, error) {
+	start := time.Now()
+	tx, err := db.DB.BeginReadTx()
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedReadTx{ReadTx: tx, db: db, start: start}, nil
+}
+
+// BeginReadWriteTx opens a database read+write transaction.
+func (db *instrumentedDB) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	start := time.Now()
+	tx, err := db.DB.BeginReadWriteTx()
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedReadWriteTx{
+		ReadWriteTx: tx, db: db, start: start,
+	}, nil
+}
+
+// View opens a database read transaction and executes the function f with
+// it, observing the duration of the call including any retries.
+func (db *instrumentedDB) View(f func(tx walletdb.ReadTx) error,
+	reset func()) error {
+
+	defer db.observe(txTypeRead, time.Now())
+	return db.DB.View(f, reset)
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with it, observing the duration of the call including any retries.
+func (db *instrumentedDB) Update(f func(tx walletdb.ReadWriteTx) error,
+	reset func()) error {
+
+	defer db.observe(txTypeWrite, time.Now())
+	return db.DB.Update(f, reset)
+}
+
+// instrumentedReadTx is a read transaction observed when rolled back.
+type instrumentedReadTx struct {
+	walletdb.ReadTx
+	db    *instrumentedDB
+	start time.Time
+	once  sync.Once
+}
+
+// Rollback closes the transaction.
+func (tx *instrumentedReadTx) Rollback() error {
+	err := tx.ReadTx.Rollback()
+	tx.once.Do(func() { tx.db.observe(txTypeRead, tx.start) })
+	return err
+}
+
+// instrumentedReadWriteTx is a read+write transaction observed when committed
+// or rolled back.
+type instrumentedReadWriteTx struct {
+	walletdb.ReadWriteTx
+	db    *instrumentedDB
+	start time.Time
+	once  sync.Once
+}
+
+// Commit commits the transaction.
+func (tx *instrumentedReadWriteTx) Commit() error {
+	err := tx.ReadWriteTx.Commit()
+	tx.once.Do(func() { tx.db.observe(txTypeWrite, tx.start) })
+	return err
+}
+
+// Rollback closes the transaction, discarding its changes.
+func (tx *instrumentedReadWriteTx) Rollback() error {
+	err := tx.ReadWriteTx.Rollback()
+	tx.once.Do(func() { tx.db.observe(txTypeWrite, tx.start) })
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// payjoinService serves payjoin requests for the loaded wallet.
+type payjoinService struct {
+	mu      sync.Mutex
+	servers []*http.Server
+}
+
+// start serves payjoin requests for the wallet on the configured listeners.
+func (s *payjoinService) start(w *wallet.Wallet) {
+	receiver := wallet.NewPayjoinReceiver(
+		w, cfg.PayjoinAccount, cfg.PayjoinMinConf,
+	)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, addr := range cfg.PayjoinListeners {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Errorf("Unable to listen for payjoin requests on "+
+				"%s: %v", addr, err)
+			continue
+		}
+
+		server := &http.Server{Handler: receiver}
+		s.servers = append(s.servers, server)
+		log.Infof("Payjoin server listening on %s", listener.Addr())
+		go func() {
+			err := server.Serve(listener)
+			if err != http.ErrServerClosed {
+				log.Errorf("Payjoin server failed: %v", err)
+			}
+		}()
+	}
+}
+
+// stop closes the payjoin servers.
+func (s *payjoinService) stop() {
+	s.mu.Lock()
+	servers := s.servers
+	s.servers = nil
+	s.mu.Unlock()
+
+	for _, server := range servers {
+		if err := server.Close(); err != nil {
+			log.Errorf("Unable to close payjoin server: %v", err)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package gateway implements a REST/JSON gateway in front of the wallet's gRPC
+// server, for clients which can not speak gRPC.
+//
+// Every method of the VersionService, WalletLoaderService and WalletService is
+// mapped to a REST path.  Requests and responses are the JSON encodings of the
+// protobuf messages of the methods, with the field names of api.proto.  Bytes
+// fields are base64 encoded and 64-bit integers are encoded as strings.  The
+// responses of server streaming methods, such as TransactionNotifications,
+// are sent as server-sent events.  POST requests must have a Content-Type of
+// application/json, even if their body is empty, so browsers can not send them
+// cross-site without a CORS preflight.
+//
+// The gateway calls the gRPC server through a client connection, so calls are
+// checked by the same interceptors as other gRPC calls.  The Authorization and
+// Macaroon headers of requests are passed to the server as the authorization
+// and macaroon metadata of their calls, along with the IP address of the HTTP
+// client, which ipaddr caveats of macaroons are checked against.
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/btcsuite/btcwallet/rpc/rpcserver"
+)
+
+// maxRequestSize is the maximum size of a request body, matching the maximum
+// message size received by the gRPC server.
+const maxRequestSize = 1024 * 1024 * 4
+
+// errUnsupportedMediaType is returned when the body of a POST request is not
+// JSON.
+var errUnsupportedMediaType = errors.New("request body must have Content-Type " +
+	"application/json")
+
+// forwardedHeaders maps the request headers passed to the gRPC server to
+// their metadata keys.
+var forwardedHeaders = map[string]string{
+	"Authorization": "authorization",
+	"Macaroon":      "macaroon",
+}
+
+// marshaler encodes response messages.
+var marshaler = jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+
+// Gateway is an http.Handler serving REST requests by calling the methods of
+// a gRPC server.
+type Gateway struct {
+	conn *grpc.ClientConn
+}
+
+// New returns a gateway calling the gRPC server of the client connection.
+func New(conn *grpc.ClientConn) *Gateway {
+	return &Gateway{conn: conn}
+}
+
+// ServeHTTP serves a REST request by calling the gRPC method of its route.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		rt         *route
+		params     map[string]string
+		pathExists bool
+	)
+	for i := range routes {
+		p, ok := routes[i].match(r.URL.Path)
+		if !ok {
+			continue
+		}
+		pathExists = true
+		if routes[i].httpMethod == r.Method {
+			rt, params = &routes[i], p
+			break
+		}
+	}
+	switch {
+	case rt != nil:
+	case pathExists:
+		writeError(w, status.Errorf(codes.Unimplemented,
+			"method %s not allowed for %s", r.Method, r.URL.Path),
+			http.StatusMethodNotAllowed)
+		return
+	default:
+		writeError(w, status.Errorf(codes.NotFound,
+			"no route for %s", r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	req, err := decodeRequest(w, r, rt, params)
+	if err == errUnsupportedMediaType {
+		writeError(w, status.Errorf(codes.InvalidArgument, "%v", err),
+			http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "%v", err), 0)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	md := metadata.MD{}
+	for header, key := range forwardedHeaders {
+		if v := r.Header.Get(header); v != "" {
+			md.Set(key, v)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if ip := net.ParseIP(host); err == nil && ip != nil {
+		md.Set(rpcserver.ClientIPMetadataKey, ip.String())
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	if rt.stream {
+		g.serveStream(ctx, w, rt, req)
+		return
+	}
+
+	resp := rt.newResponse()
+	if err := g.conn.Invoke(ctx, rt.fullMethod, req, resp); err != nil {
+		writeError(w, err, 0)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := marshaler.Marshal(w, resp); err != nil {
+		writeError(w, err, 0)
+	}
+}
+
+// serveStream calls a server streaming method and sends its responses as
+// server-sent events, until the stream ends or the client disconnects.  If the
+// stream ends with an error, including an error rejecting the call, the error
+// is sent as an event of type error.  The response headers are sent before
+// the first response, as notification streams may not send any for a long
+// time.
+func (g *Gateway) serveStream(ctx context.Context, w http.ResponseWriter,
+	rt *route, req proto.Message) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, status.Errorf(codes.Internal,
+			"streaming is not supported"), 0)
+		return
+	}
+
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	stream, err := g.conn.NewStream(ctx, desc, rt.fullMethod)
+	if err != nil {
+		writeError(w, err, 0)
+		return
+	}
+	if err := stream.SendMsg(req); err != nil {
+		writeError(w, err, 0)
+		return
+	}
+	if err := stream.CloseSend(); err != nil {
+		writeError(w, err, 0)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		resp := rt.newResponse()
+		err := stream.RecvMsg(resp)
+		if err == io.EOF || ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			writeEvent(w, "error", errorBody(err))
+			flusher.Flush()
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := marshaler.Marshal(&buf, resp); err != nil {
+			writeEvent(w, "error", errorBody(err))
+			flusher.Flush()
+			return
+		}
+		writeEvent(w, "", buf.Bytes())
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a server-sent event with the JSON data.  The event type is
+// omitted if empty.
+func writeEvent(w io.Writer, event string, data []byte) {
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// decodeRequest returns the request message of a route from the JSON body of
+// a POST request, its query parameters and the parameters of its path.  The
+// body of a POST request must be JSON, as forms and plain text may be posted
+// cross-site by browsers, which would send any cached credentials of the user.
+func decodeRequest(w http.ResponseWriter, r *http.Request, rt *route,
+	params map[string]string) (proto.Message, error) {
+
+	fields := make(map[string]json.RawMessage)
+	if r.Method == http.MethodPost {
+		mediaType, _, err := mime.ParseMediaType(
+			r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return nil, errUnsupportedMediaType
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body,
+			maxRequestSize))
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(body)) != 0 {
+			if err := json.Unmarshal(body, &fields); err != nil {
+				return nil, fmt.Errorf("invalid request "+
+					"body: %v", err)
+			}
+		}
+	}
+
+	req := rt.newRequest()
+	desc := proto.MessageReflect(req).Descriptor().Fields()
+	for name, values := range r.URL.Query() {
+		value, err := paramValue(desc, name, values)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+	for name, value := range params {
+		value, err := paramValue(desc, name, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := jsonpb.Unmarshal(bytes.NewReader(encoded), req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// paramValue returns the JSON encoding of the values of a query or path
+// parameter setting the field of a request message with the same name.
+func paramValue(desc protoreflect.FieldDescriptors, name string,
+	values []string) (json.RawMessage, error) {
+
+	fd := desc.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = desc.ByJSONName(name)
+	}
+	if fd == nil {
+		return nil, fmt.Errorf("unknown parameter %q", name)
+	}
+	if fd.Kind() == protoreflect.MessageKind ||
+		fd.Kind() == protoreflect.GroupKind {
+
+		return nil, fmt.Errorf("parameter %q must be set in the "+
+			"request body", name)
+	}
+
+	encode := func(v string) (interface{}, error) {
+		switch fd.Kind() {
+		case protoreflect.BoolKind:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %q is not a "+
+					"boolean", name)
+			}
+			return b, nil
+		case protoreflect.EnumKind:
+			// Enums may be given by name or number.
+			if n, err := strconv.ParseInt(v, 10, 32); err == nil {
+				return n, nil
+			}
+			return v, nil
+		default:
+			// Numbers are accepted as strings.
+			return v, nil
+		}
+	}
+
+	if !fd.IsList() {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("parameter %q may only be set "+
+				"once", name)
+		}
+		v, err := encode(values[0])
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	}
+	list := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		v, err := encode(value)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return json.Marshal(list)
+}
+
+// errorBody returns the JSON encoding of the status of an error.
+func errorBody(err error) []byte {
+	st := status.Convert(err)
+	body, _ := json.Marshal(struct {
+		Code    codes.Code `json:"code"`
+		Message string     `json:"message"`
+	}{st.Code(), st.Message()})
+	return body
+}
+
+// writeError writes the status of an error as a JSON response.  The HTTP
+// status of the response is derived from the status code of the error, unless
+// httpStatus is not zero.
+func writeError(w http.ResponseWriter, err error, httpStatus int) {
+	if httpStatus == 0 {
+		httpStatus = httpStatusFromCode(status.Code(err))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(errorBody(err))
+}
+
+// httpStatusFromCode returns the HTTP status corresponding to a gRPC status
+// code.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return http.StatusRequestTimeout
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
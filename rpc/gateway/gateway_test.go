@@ -0,0 +1,308 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gateway
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/btcsuite/btcwallet/rpc/rpcserver"
+	pb "github.com/btcsuite/btcwallet/rpc/walletrpc"
+)
+
+// testWalletServer implements the WalletService methods called by the tests.
+// Calling any other method panics.
+type testWalletServer struct {
+	pb.WalletServiceServer
+}
+
+func (*testWalletServer) Balance(ctx context.Context,
+	req *pb.BalanceRequest) (*pb.BalanceResponse, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if macaroon := md.Get("macaroon"); len(macaroon) != 1 ||
+		macaroon[0] != "abcd" {
+
+		return nil, status.Errorf(codes.Unauthenticated, "no macaroon")
+	}
+
+	// The address of the HTTP client is forwarded over a connection from
+	// the gateway.
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr.Network() != rpcserver.GatewayNetwork {
+		return nil, status.Errorf(codes.Internal, "unexpected peer")
+	}
+	if ip := md.Get(rpcserver.ClientIPMetadataKey); len(ip) != 1 ||
+		ip[0] != "127.0.0.1" {
+
+		return nil, status.Errorf(codes.Internal, "no client IP")
+	}
+	if req.AccountNumber != 1 {
+		return nil, status.Errorf(codes.NotFound, "no account")
+	}
+	return &pb.BalanceResponse{
+		Total: int64(req.RequiredConfirmations) * 100,
+	}, nil
+}
+
+func (*testWalletServer) PauseRescan(ctx context.Context,
+	req *pb.PauseRescanRequest) (*pb.PauseRescanResponse, error) {
+
+	if req.Id != 7 {
+		return nil, status.Errorf(codes.NotFound, "no rescan")
+	}
+	return &pb.PauseRescanResponse{}, nil
+}
+
+func (*testWalletServer) AccountNotifications(req *pb.AccountNotificationsRequest,
+	stream pb.WalletService_AccountNotificationsServer) error {
+
+	for i := uint32(0); i < 2; i++ {
+		err := stream.Send(&pb.AccountNotificationsResponse{
+			AccountNumber: i,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return status.Errorf(codes.Aborted, "wallet closed")
+}
+
+// newTestGateway serves a gateway in front of a gRPC server with the test
+// wallet service, and returns the URL of the gateway.
+func newTestGateway(t *testing.T) string {
+	t.Helper()
+
+	server := grpc.NewServer()
+	rpcserver.StartVersionService(server)
+	pb.RegisterWalletServiceServer(server, &testWalletServer{})
+	lis := NewListener()
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("gateway", grpc.WithInsecure(),
+		grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	httpServer := httptest.NewServer(New(conn))
+	t.Cleanup(httpServer.Close)
+	return httpServer.URL
+}
+
+// TestRoutes tests that every method of the gRPC services has a route.
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.NewServer()
+	rpcserver.StartVersionService(server)
+	pb.RegisterWalletServiceServer(server, &testWalletServer{})
+	pb.RegisterWalletLoaderServiceServer(server,
+		&struct{ pb.WalletLoaderServiceServer }{})
+
+	methods := make(map[string]bool)
+	for name, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			methods["/"+name+"/"+method.Name] = method.IsServerStream
+		}
+	}
+	for _, rt := range routes {
+		stream, ok := methods[rt.fullMethod]
+		if !ok {
+			t.Errorf("route %s %s has unknown method %s",
+				rt.httpMethod, rt.path, rt.fullMethod)
+			continue
+		}
+		if stream != rt.stream {
+			t.Errorf("route %s %s has stream %v, method has %v",
+				rt.httpMethod, rt.path, rt.stream, stream)
+		}
+		delete(methods, rt.fullMethod)
+	}
+	for method := range methods {
+		t.Errorf("method %s has no route", method)
+	}
+}
+
+// TestGateway tests that requests are mapped to calls and their responses
+// and errors to JSON responses.
+func TestGateway(t *testing.T) {
+	t.Parallel()
+
+	url := newTestGateway(t)
+
+	tests := []struct {
+		method, path string
+		contentType  string
+		body         string
+		status       int
+		response     string
+	}{
+		{
+			"GET", "/v1/version", "", "",
+			http.StatusOK, `"major":2`,
+		},
+		{
+			"GET",
+			"/v1/balance?account_number=1&required_confirmations=6",
+			"", "",
+			http.StatusOK, `"total":"600"`,
+		},
+		{
+			"GET", "/v1/balance?accountNumber=2", "", "",
+			http.StatusNotFound, `"message":"no account"`,
+		},
+		{
+			"GET", "/v1/balance?unknown=1", "", "",
+			http.StatusBadRequest, `unknown parameter`,
+		},
+		{
+			"GET", "/v1/balance?account_number=x", "", "",
+			http.StatusBadRequest, `"code":3`,
+		},
+		{
+			"POST", "/v1/rescans/7/pause", "application/json", "",
+			http.StatusOK, `{}`,
+		},
+		{
+			"POST", "/v1/rescans/8/pause", "application/json", "{}",
+			http.StatusNotFound, `"message":"no rescan"`,
+		},
+		{
+			"POST", "/v1/rescans/7/pause", "application/json", "{",
+			http.StatusBadRequest, `invalid request body`,
+		},
+		{
+			"POST", "/v1/balance", "application/json", "",
+			http.StatusMethodNotAllowed, `"code":12`,
+		},
+		{
+			"POST", "/v1/rescans/7/pause",
+			"application/x-www-form-urlencoded", "",
+			http.StatusUnsupportedMediaType, `application/json`,
+		},
+		{
+			"POST", "/v1/rescans/7/pause", "text/plain", "{}",
+			http.StatusUnsupportedMediaType, `application/json`,
+		},
+		{
+			"POST", "/v1/rescans/7/pause", "", "",
+			http.StatusUnsupportedMediaType, `application/json`,
+		},
+		{
+			"POST", "/v1/rescans/7/pause",
+			"application/json; charset=utf-8", "{}",
+			http.StatusOK, `{}`,
+		},
+		{
+			"GET", "/v1/unknown", "", "",
+			http.StatusNotFound, `no route`,
+		},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, url+test.path,
+			strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Macaroon", "abcd")
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != test.status {
+			t.Errorf("%s %s: got status %d, want %d: %s",
+				test.method, test.path, resp.StatusCode,
+				test.status, body)
+		}
+		if !strings.Contains(string(body), test.response) {
+			t.Errorf("%s %s: response %s does not contain %s",
+				test.method, test.path, body, test.response)
+		}
+	}
+
+	// Errors rejecting calls are reported with the status of the
+	// response.
+	resp, err := http.Get(url + "/v1/balance?account_number=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %d without macaroon, want %d",
+			resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+// TestGatewayStream tests that the responses of a streaming method are sent as
+// server-sent events, followed by an error event for the error ending the
+// stream.
+func TestGatewayStream(t *testing.T) {
+	t.Parallel()
+
+	url := newTestGateway(t)
+
+	resp, err := http.Get(url + "/v1/notifications/accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %q", ct)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() != "" {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`data: {"account_number":0,`,
+		`data: {"account_number":1,`,
+		`event: error`,
+		`data: {"code":10,"message":"wallet closed"}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got events %q", lines)
+	}
+	for i := range want {
+		if !strings.HasPrefix(lines[i], want[i]) {
+			t.Errorf("got line %q, want prefix %q", lines[i], want[i])
+		}
+	}
+}
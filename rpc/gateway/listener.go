@@ -0,0 +1,84 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gateway
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/btcsuite/btcwallet/rpc/rpcserver"
+)
+
+// errListenerClosed is returned when accepting or dialing connections of a
+// closed Listener.
+var errListenerClosed = errors.New("gateway listener closed")
+
+// pipeAddr is the address of the connections of a Listener.  Its network
+// tells the gRPC server that calls come from the gateway.
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return rpcserver.GatewayNetwork }
+func (pipeAddr) String() string  { return "gateway" }
+
+// pipeConn is a connection of a Listener.
+type pipeConn struct {
+	net.Conn
+}
+
+func (pipeConn) LocalAddr() net.Addr  { return pipeAddr{} }
+func (pipeConn) RemoteAddr() net.Addr { return pipeAddr{} }
+
+// Listener is an in-memory net.Listener, connecting a gateway to a gRPC server
+// in the same process without exposing another network listener.
+type Listener struct {
+	conns chan net.Conn
+
+	closeOnce sync.Once
+	quit      chan struct{}
+}
+
+// NewListener returns a new in-memory listener.
+func NewListener() *Listener {
+	return &Listener{
+		conns: make(chan net.Conn),
+		quit:  make(chan struct{}),
+	}
+}
+
+// Accept waits for and returns the next connection dialed with Dial.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.quit:
+		return nil, errListenerClosed
+	}
+}
+
+// Close closes the listener.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() { close(l.quit) })
+	return nil
+}
+
+// Addr returns the address of the listener.
+func (l *Listener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// Dial returns a new connection to the listener, waiting for it to be
+// accepted.
+func (l *Listener) Dial() (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case l.conns <- pipeConn{server}:
+		return client, nil
+	case <-l.quit:
+		client.Close()
+		server.Close()
+		return nil, errListenerClosed
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gateway
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/btcsuite/btcwallet/rpc/walletrpc"
+)
+
+// route maps an HTTP method and path to a gRPC method.
+type route struct {
+	httpMethod string
+
+	// path is the slash separated path of the route.  A segment enclosed
+	// in braces, such as {id}, matches any value, which is set as the
+	// request field of the same name.
+	path string
+
+	// fullMethod is the full gRPC method name.
+	fullMethod string
+
+	// stream is true for server streaming methods, whose responses are
+	// sent as server-sent events.
+	stream bool
+
+	newRequest  func() proto.Message
+	newResponse func() proto.Message
+}
+
+// routes maps every method of the VersionService, WalletLoaderService and
+// WalletService to a REST path.  Methods which do not modify the wallet use
+// GET with their request fields as query parameters, while all others use
+// POST with a JSON request body.  Routes with literal path segments precede
+// routes matching the same paths with path parameters.
+var routes = []route{
+	{
+		http.MethodGet, "/v1/version",
+		"/walletrpc.VersionService/Version", false,
+		func() proto.Message { return new(pb.VersionRequest) },
+		func() proto.Message { return new(pb.VersionResponse) },
+	},
+
+	{
+		http.MethodGet, "/v1/loader/exists",
+		"/walletrpc.WalletLoaderService/WalletExists", false,
+		func() proto.Message { return new(pb.WalletExistsRequest) },
+		func() proto.Message { return new(pb.WalletExistsResponse) },
+	},
+	{
+		http.MethodPost, "/v1/loader/create",
+		"/walletrpc.WalletLoaderService/CreateWallet", false,
+		func() proto.Message { return new(pb.CreateWalletRequest) },
+		func() proto.Message { return new(pb.CreateWalletResponse) },
+	},
+	{
+		http.MethodPost, "/v1/loader/open",
+		"/walletrpc.WalletLoaderService/OpenWallet", false,
+		func() proto.Message { return new(pb.OpenWalletRequest) },
+		func() proto.Message { return new(pb.OpenWalletResponse) },
+	},
+	{
+		http.MethodPost, "/v1/loader/close",
+		"/walletrpc.WalletLoaderService/CloseWallet", false,
+		func() proto.Message { return new(pb.CloseWalletRequest) },
+		func() proto.Message { return new(pb.CloseWalletResponse) },
+	},
+	{
+		http.MethodPost, "/v1/loader/consensusrpc",
+		"/walletrpc.WalletLoaderService/StartConsensusRpc", false,
+		func() proto.Message { return new(pb.StartConsensusRpcRequest) },
+		func() proto.Message { return new(pb.StartConsensusRpcResponse) },
+	},
+
+	{
+		http.MethodGet, "/v1/ping",
+		"/walletrpc.WalletService/Ping", false,
+		func() proto.Message { return new(pb.PingRequest) },
+		func() proto.Message { return new(pb.PingResponse) },
+	},
+	{
+		http.MethodGet, "/v1/network",
+		"/walletrpc.WalletService/Network", false,
+		func() proto.Message { return new(pb.NetworkRequest) },
+		func() proto.Message { return new(pb.NetworkResponse) },
+	},
+	{
+		http.MethodGet, "/v1/accounts",
+		"/walletrpc.WalletService/Accounts", false,
+		func() proto.Message { return new(pb.AccountsRequest) },
+		func() proto.Message { return new(pb.AccountsResponse) },
+	},
+	{
+		http.MethodPost, "/v1/accounts",
+		"/walletrpc.WalletService/NextAccount", false,
+		func() proto.Message { return new(pb.NextAccountRequest) },
+		func() proto.Message { return new(pb.NextAccountResponse) },
+	},
+	{
+		http.MethodGet, "/v1/accounts/number",
+		"/walletrpc.WalletService/AccountNumber", false,
+		func() proto.Message { return new(pb.AccountNumberRequest) },
+		func() proto.Message { return new(pb.AccountNumberResponse) },
+	},
+	{
+		http.MethodPost, "/v1/accounts/{account_number}/rename",
+		"/walletrpc.WalletService/RenameAccount", false,
+		func() proto.Message { return new(pb.RenameAccountRequest) },
+		func() proto.Message { return new(pb.RenameAccountResponse) },
+	},
+	{
+		http.MethodGet, "/v1/balance",
+		"/walletrpc.WalletService/Balance", false,
+		func() proto.Message { return new(pb.BalanceRequest) },
+		func() proto.Message { return new(pb.BalanceResponse) },
+	},
+	{
+		http.MethodGet, "/v1/transactions",
+		"/walletrpc.WalletService/GetTransactions", false,
+		func() proto.Message { return new(pb.GetTransactionsRequest) },
+		func() proto.Message { return new(pb.GetTransactionsResponse) },
+	},
+	{
+		http.MethodPost, "/v1/transactions/fund",
+		"/walletrpc.WalletService/FundTransaction", false,
+		func() proto.Message { return new(pb.FundTransactionRequest) },
+		func() proto.Message { return new(pb.FundTransactionResponse) },
+	},
+	{
+		http.MethodPost, "/v1/transactions/sign",
+		"/walletrpc.WalletService/SignTransaction", false,
+		func() proto.Message { return new(pb.SignTransactionRequest) },
+		func() proto.Message { return new(pb.SignTransactionResponse) },
+	},
+	{
+		http.MethodPost, "/v1/transactions/publish",
+		"/walletrpc.WalletService/PublishTransaction", false,
+		func() proto.Message { return new(pb.PublishTransactionRequest) },
+		func() proto.Message { return new(pb.PublishTransactionResponse) },
+	},
+	{
+		http.MethodPost, "/v1/psbts/sign",
+		"/walletrpc.WalletService/SignPsbt", false,
+		func() proto.Message { return new(pb.SignPsbtRequest) },
+		func() proto.Message { return new(pb.SignPsbtResponse) },
+	},
+	{
+		http.MethodPost, "/v1/addresses",
+		"/walletrpc.WalletService/NextAddress", false,
+		func() proto.Message { return new(pb.NextAddressRequest) },
+		func() proto.Message { return new(pb.NextAddressResponse) },
+	},
+	{
+		http.MethodPost, "/v1/privatekeys",
+		"/walletrpc.WalletService/ImportPrivateKey", false,
+		func() proto.Message { return new(pb.ImportPrivateKeyRequest) },
+		func() proto.Message { return new(pb.ImportPrivateKeyResponse) },
+	},
+	{
+		http.MethodPost, "/v1/passphrase",
+		"/walletrpc.WalletService/ChangePassphrase", false,
+		func() proto.Message { return new(pb.ChangePassphraseRequest) },
+		func() proto.Message { return new(pb.ChangePassphraseResponse) },
+	},
+	{
+		http.MethodGet, "/v1/rescans",
+		"/walletrpc.WalletService/ListRescans", false,
+		func() proto.Message { return new(pb.ListRescansRequest) },
+		func() proto.Message { return new(pb.ListRescansResponse) },
+	},
+	{
+		http.MethodPost, "/v1/rescans",
+		"/walletrpc.WalletService/Rescan", true,
+		func() proto.Message { return new(pb.RescanRequest) },
+		func() proto.Message { return new(pb.RescanResponse) },
+	},
+	{
+		http.MethodPost, "/v1/rescans/{id}/pause",
+		"/walletrpc.WalletService/PauseRescan", false,
+		func() proto.Message { return new(pb.PauseRescanRequest) },
+		func() proto.Message { return new(pb.PauseRescanResponse) },
+	},
+	{
+		http.MethodPost, "/v1/rescans/{id}/resume",
+		"/walletrpc.WalletService/ResumeRescan", false,
+		func() proto.Message { return new(pb.ResumeRescanRequest) },
+		func() proto.Message { return new(pb.ResumeRescanResponse) },
+	},
+	{
+		http.MethodPost, "/v1/rescans/{id}/cancel",
+		"/walletrpc.WalletService/CancelRescan", false,
+		func() proto.Message { return new(pb.CancelRescanRequest) },
+		func() proto.Message { return new(pb.CancelRescanResponse) },
+	},
+	{
+		http.MethodGet, "/v1/invoices",
+		"/walletrpc.WalletService/ListInvoices", false,
+		func() proto.Message { return new(pb.ListInvoicesRequest) },
+		func() proto.Message { return new(pb.ListInvoicesResponse) },
+	},
+	{
+		http.MethodPost, "/v1/invoices",
+		"/walletrpc.WalletService/CreateInvoice", false,
+		func() proto.Message { return new(pb.CreateInvoiceRequest) },
+		func() proto.Message { return new(pb.CreateInvoiceResponse) },
+	},
+	{
+		http.MethodGet, "/v1/invoices/{id}",
+		"/walletrpc.WalletService/GetInvoice", false,
+		func() proto.Message { return new(pb.GetInvoiceRequest) },
+		func() proto.Message { return new(pb.GetInvoiceResponse) },
+	},
+	{
+		http.MethodPost, "/v1/macaroons",
+		"/walletrpc.WalletService/BakeMacaroon", false,
+		func() proto.Message { return new(pb.BakeMacaroonRequest) },
+		func() proto.Message { return new(pb.BakeMacaroonResponse) },
+	},
+
+	{
+		http.MethodGet, "/v1/notifications/transactions",
+		"/walletrpc.WalletService/TransactionNotifications", true,
+		func() proto.Message { return new(pb.TransactionNotificationsRequest) },
+		func() proto.Message { return new(pb.TransactionNotificationsResponse) },
+	},
+	{
+		http.MethodGet, "/v1/notifications/spentness",
+		"/walletrpc.WalletService/SpentnessNotifications", true,
+		func() proto.Message { return new(pb.SpentnessNotificationsRequest) },
+		func() proto.Message { return new(pb.SpentnessNotificationsResponse) },
+	},
+	{
+		http.MethodGet, "/v1/notifications/accounts",
+		"/walletrpc.WalletService/AccountNotifications", true,
+		func() proto.Message { return new(pb.AccountNotificationsRequest) },
+		func() proto.Message { return new(pb.AccountNotificationsResponse) },
+	},
+	{
+		http.MethodGet, "/v1/notifications/invoices",
+		"/walletrpc.WalletService/InvoiceNotifications", true,
+		func() proto.Message { return new(pb.InvoiceNotificationsRequest) },
+		func() proto.Message { return new(pb.InvoiceNotificationsResponse) },
+	},
+}
+
+// match returns the path parameters of the request path if it matches the
+// path of the route.
+func (r *route) match(path string) (map[string]string, bool) {
+	want := strings.Split(r.path, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	var params map[string]string
+	for i := range want {
+		w := want[i]
+		if strings.HasPrefix(w, "{") && strings.HasSuffix(w, "}") {
+			if got[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[w[1:len(w)-1]] = got[i]
+			continue
+		}
+		if w != got[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
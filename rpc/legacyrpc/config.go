@@ -4,11 +4,29 @@
 
 package legacyrpc
 
+import (
+	"time"
+
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
+)
+
 // Options contains the required options for running the legacy RPC server.
 type Options struct {
+	// Username and Password, if both set, are credentials of the admin
+	// role.
 	Username string
 	Password string
 
+	// Users are additional credentials, each limited to the methods
+	// permitted for its role.
+	Users []rpcauth.Credential
+
 	MaxPOSTClients      int64
 	MaxWebsocketClients int64
+
+	// ObserveRequest, if set, is called after each request is handled
+	// with the method, the time taken to handle it, and whether an error
+	// was returned.  Methods which are not wallet methods, including those
+	// passed through to the chain server, are reported as "other".
+	ObserveRequest func(method string, d time.Duration, failed bool)
 }
@@ -77,8 +77,28 @@ var (
 		Message: "No information for transaction",
 	}
 
+	ErrInvoiceNotFound = btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "No invoice with the given ID",
+	}
+
+	ErrRescanNotFound = btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "No rescan with the given ID",
+	}
+
+	ErrMethodNotPermitted = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Method not permitted for the role of the client",
+	}
+
 	ErrReservedAccountName = btcjson.RPCError{
 		Code:    btcjson.ErrRPCInvalidParameter,
 		Message: "Account name is reserved by RPC server",
 	}
+
+	ErrWebsocketOnly = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Method is only available to websocket clients",
+	}
 )
@@ -8,14 +8,18 @@ package legacyrpc
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -23,7 +27,10 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcutil/psbt"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc/types"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
@@ -73,8 +80,12 @@ var rpcHandlers = map[string]struct {
 }{
 	// Reference implementation wallet methods (implemented)
 	"addmultisigaddress":     {handler: addMultiSigAddress},
+	"analyzepsbt":            {handler: analyzePsbt},
+	"combinepsbt":            {handler: combinePsbt},
 	"createmultisig":         {handler: createMultiSig},
+	"decodepsbt":             {handler: decodePsbtCmd},
 	"dumpprivkey":            {handler: dumpPrivKey},
+	"finalizepsbt":           {handler: finalizePsbt},
 	"getaccount":             {handler: getAccount},
 	"getaccountaddress":      {handler: getAccountAddress},
 	"getaddressesbyaccount":  {handler: getAddressesByAccount},
@@ -104,11 +115,14 @@ var rpcHandlers = map[string]struct {
 	"settxfee":               {handler: setTxFee},
 	"signmessage":            {handler: signMessage},
 	"signrawtransaction":     {handlerWithChain: signRawTransaction},
+	"utxoupdatepsbt":         {handler: utxoUpdatePsbt},
 	"validateaddress":        {handler: validateAddress},
 	"verifymessage":          {handler: verifyMessage},
+	"walletcreatefundedpsbt": {handler: walletCreateFundedPsbt},
 	"walletlock":             {handler: walletLock},
 	"walletpassphrase":       {handler: walletPassphrase},
 	"walletpassphrasechange": {handler: walletPassphraseChange},
+	"walletprocesspsbt":      {handler: walletProcessPsbt},
 
 	// Reference implementation methods (still unimplemented)
 	"backupwallet":         {handler: unimplemented, noHelp: true},
@@ -124,8 +138,13 @@ var rpcHandlers = map[string]struct {
 	"setaccount":    {handler: unsupported, noHelp: true},
 
 	// Extensions to the reference client JSON-RPC API
-	"createnewaccount": {handler: createNewAccount},
-	"getbestblock":     {handler: getBestBlock},
+	"cancelrescan":       {handler: cancelRescan},
+	"convertpsbt":        {handler: convertPsbt},
+	"createinvoice":      {handler: createInvoice},
+	"createnewaccount":   {handler: createNewAccount},
+	"createreserveproof": {handler: createReserveProof},
+	"getbestblock":       {handler: getBestBlock},
+	"getinvoice":         {handler: getInvoice},
 	// This was an extension but the reference implementation added it as
 	// well, but with a different API (no account parameter).  It's listed
 	// here because it hasn't been update to use the reference
@@ -133,7 +152,17 @@ var rpcHandlers = map[string]struct {
 	"getunconfirmedbalance":   {handler: getUnconfirmedBalance},
 	"listaddresstransactions": {handler: listAddressTransactions},
 	"listalltransactions":     {handler: listAllTransactions},
+	"listinvoices":            {handler: listInvoices},
+	"listrescans":             {handler: listRescans},
+	"pauserescan":             {handler: pauseRescan},
 	"renameaccount":           {handler: renameAccount},
+	"rescanblockchain":        {handler: rescanBlockchain},
+	"resumerescan":            {handler: resumeRescan},
+	"sendpayjoin":             {handler: sendPayjoin},
+	"subscribe":               {handler: websocketOnly},
+	"sweepprivkey":            {handler: sweepPrivKey},
+	"unsubscribe":             {handler: websocketOnly},
+	"verifyreserveproof":      {handler: verifyReserveProof},
 	"walletislocked":          {handler: walletIsLocked},
 }
 
@@ -168,7 +197,7 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 	handlerData, ok := rpcHandlers[request.Method]
 	if ok && handlerData.handlerWithChain != nil && w != nil && chainClient != nil {
 		return func() (interface{}, *btcjson.RPCError) {
-			cmd, err := btcjson.UnmarshalCmd(request)
+			cmd, err := types.UnmarshalCmd(request)
 			if err != nil {
 				return nil, btcjson.ErrRPCInvalidRequest
 			}
@@ -190,7 +219,7 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 	}
 	if ok && handlerData.handler != nil && w != nil {
 		return func() (interface{}, *btcjson.RPCError) {
-			cmd, err := btcjson.UnmarshalCmd(request)
+			cmd, err := types.UnmarshalCmd(request)
 			if err != nil {
 				return nil, btcjson.ErrRPCInvalidRequest
 			}
@@ -318,16 +347,44 @@ func makeMultiSigScript(w *wallet.Wallet, keys []string, nRequired int) ([]byte,
 	return txscript.MultiSigScript(keysesPrecious, nRequired)
 }
 
+// parseMultisigAddressType parses the address_type parameter of the
+// addmultisigaddress and createmultisig requests, returning whether the
+// multisig script is a witness script, and whether it is nested within a P2SH
+// output.  Legacy P2SH addresses are used by default.
+func parseMultisigAddressType(addressType *string) (witness, nested bool,
+	err error) {
+
+	if addressType == nil {
+		return false, false, nil
+	}
+	switch *addressType {
+	case types.MultisigLegacy:
+		return false, false, nil
+	case types.MultisigP2SHSegwit:
+		return true, true, nil
+	case types.MultisigBech32:
+		return true, false, nil
+	}
+	return false, false, InvalidParameterError{
+		fmt.Errorf("unknown address type %q", *addressType),
+	}
+}
+
 // addMultiSigAddress handles an addmultisigaddress request by adding a
 // multisig address to the given wallet.
 func addMultiSigAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.AddMultisigAddressCmd)
+	cmd := icmd.(*types.AddMultisigAddressCmd)
 
 	// If an account is specified, ensure that is the imported account.
 	if cmd.Account != nil && *cmd.Account != waddrmgr.ImportedAddrAccountName {
 		return nil, &ErrNotImportedAccount
 	}
 
+	witness, nested, err := parseMultisigAddressType(cmd.AddressType)
+	if err != nil {
+		return nil, err
+	}
+
 	secp256k1Addrs := make([]btcutil.Address, len(cmd.Keys))
 	for i, k := range cmd.Keys {
 		addr, err := decodeAddress(k, w.ChainParams())
@@ -342,25 +399,44 @@ func addMultiSigAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error)
 		return nil, err
 	}
 
-	p2shAddr, err := w.ImportP2SHRedeemScript(script)
+	var addr btcutil.Address
+	if witness {
+		addr, err = w.ImportWitnessScript(script, nested)
+	} else {
+		addr, err = w.ImportP2SHRedeemScript(script)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return p2shAddr.EncodeAddress(), nil
+	return addr.EncodeAddress(), nil
 }
 
 // createMultiSig handles an createmultisig request by returning a
 // multisig address for the given inputs.
 func createMultiSig(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.CreateMultisigCmd)
+	cmd := icmd.(*types.CreateMultisigCmd)
+
+	witness, nested, err := parseMultisigAddressType(cmd.AddressType)
+	if err != nil {
+		return nil, err
+	}
 
 	script, err := makeMultiSigScript(w, cmd.Keys, cmd.NRequired)
 	if err != nil {
 		return nil, ParseError{err}
 	}
 
-	address, err := btcutil.NewAddressScriptHash(script, w.ChainParams())
+	var address btcutil.Address
+	if witness {
+		address, err = wallet.WitnessScriptAddress(
+			script, nested, w.ChainParams(),
+		)
+	} else {
+		address, err = btcutil.NewAddressScriptHash(
+			script, w.ChainParams(),
+		)
+	}
 	if err != nil {
 		// above is a valid script, shouldn't happen.
 		return nil, err
@@ -1536,22 +1612,35 @@ func signMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		return nil, err
 	}
 
-	privKey, err := w.PrivKeyForAddress(addr)
+	// Legacy signatures are created for P2PKH addresses, while witness
+	// addresses are signed using BIP0322.
+	sig, err := w.SignMessage(addr, cmd.Message, wallet.MessageSignatureDefault)
 	if err != nil {
 		return nil, err
 	}
 
-	var buf bytes.Buffer
-	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
-	_ = wire.WriteVarString(&buf, 0, cmd.Message)
-	messageHash := chainhash.DoubleHashB(buf.Bytes())
-	sigbytes, err := btcec.SignCompact(btcec.S256(), privKey,
-		messageHash, true)
-	if err != nil {
-		return nil, err
-	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
 
-	return base64.StdEncoding.EncodeToString(sigbytes), nil
+// sigHashTypes maps the sighash type names used by the RPC API to their
+// values.
+var sigHashTypes = map[string]txscript.SigHashType{
+	"ALL":                 txscript.SigHashAll,
+	"NONE":                txscript.SigHashNone,
+	"SINGLE":              txscript.SigHashSingle,
+	"ALL|ANYONECANPAY":    txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+	"NONE|ANYONECANPAY":   txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+	"SINGLE|ANYONECANPAY": txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+}
+
+// parseSigHashType returns the sighash type of the given name.
+func parseSigHashType(name string) (txscript.SigHashType, error) {
+	hashType, ok := sigHashTypes[name]
+	if !ok {
+		e := errors.New("invalid sighash parameter")
+		return 0, InvalidParameterError{e}
+	}
+	return hashType, nil
 }
 
 // signRawTransaction handles the signrawtransaction command.
@@ -1569,23 +1658,9 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		return nil, DeserializationError{e}
 	}
 
-	var hashType txscript.SigHashType
-	switch *cmd.Flags {
-	case "ALL":
-		hashType = txscript.SigHashAll
-	case "NONE":
-		hashType = txscript.SigHashNone
-	case "SINGLE":
-		hashType = txscript.SigHashSingle
-	case "ALL|ANYONECANPAY":
-		hashType = txscript.SigHashAll | txscript.SigHashAnyOneCanPay
-	case "NONE|ANYONECANPAY":
-		hashType = txscript.SigHashNone | txscript.SigHashAnyOneCanPay
-	case "SINGLE|ANYONECANPAY":
-		hashType = txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
-	default:
-		e := errors.New("invalid sighash parameter")
-		return nil, InvalidParameterError{e}
+	hashType, err := parseSigHashType(*cmd.Flags)
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO: really we probably should look these up with btcd anyway to
@@ -1826,33 +1901,237 @@ func verifyMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		return nil, err
 	}
 
-	// Validate the signature - this just shows that it was valid at all.
-	// we will compare it with the key next.
-	var buf bytes.Buffer
-	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
-	_ = wire.WriteVarString(&buf, 0, cmd.Message)
-	expectedMessageHash := chainhash.DoubleHashB(buf.Bytes())
-	pk, wasCompressed, err := btcec.RecoverCompact(btcec.S256(), sig,
-		expectedMessageHash)
+	// Both legacy and BIP0322 signatures are accepted, the format is
+	// detected from the signature.
+	valid, _, err := wallet.VerifyMessage(addr, cmd.Message, sig)
 	if err != nil {
 		return nil, err
 	}
+	return valid, nil
+}
 
-	var serializedPubKey []byte
-	if wasCompressed {
-		serializedPubKey = pk.SerializeCompressed()
-	} else {
-		serializedPubKey = pk.SerializeUncompressed()
-	}
-	// Verify that the signed-by address matches the given address
-	switch checkAddr := addr.(type) {
-	case *btcutil.AddressPubKeyHash: // ok
-		return bytes.Equal(btcutil.Hash160(serializedPubKey), checkAddr.Hash160()[:]), nil
-	case *btcutil.AddressPubKey: // ok
-		return string(serializedPubKey) == checkAddr.String(), nil
-	default:
-		return nil, errors.New("address type not supported")
+// listRescans handles a listrescans extension request by returning every
+// rescan job which has not yet finished, including paused jobs.
+func listRescans(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	rescans, err := w.Rescans()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]types.RescanResult, 0, len(rescans))
+	for _, rescan := range rescans {
+		result := types.RescanResult{
+			ID:             rescan.ID,
+			State:          rescan.State.String(),
+			Running:        rescan.Running,
+			Queued:         rescan.Queued,
+			StartHeight:    rescan.StartBlock.Height,
+			StartHash:      rescan.StartBlock.Hash.String(),
+			ProgressHeight: rescan.ProgressBlock.Height,
+			Addresses:      len(rescan.Addrs),
+			OutPoints:      len(rescan.OutPoints),
+		}
+		if rescan.ProgressBlock.Height != 0 {
+			result.ProgressHash = rescan.ProgressBlock.Hash.String()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// invoiceResult converts an invoice to the result of the invoice RPCs.
+func invoiceResult(w *wallet.Wallet, inv *wallet.Invoice) (*types.InvoiceResult, error) {
+	acctName, err := w.AccountName(inv.KeyScope, inv.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.InvoiceResult{
+		ID:       inv.ID,
+		Address:  inv.Address.EncodeAddress(),
+		Account:  acctName,
+		Amount:   inv.Amount.ToBTC(),
+		Received: inv.Received().ToBTC(),
+		Memo:     inv.Memo,
+		Created:  inv.Created.Unix(),
+		Status:   inv.Status.String(),
+		URI:      inv.URI(),
+		Payments: make([]types.InvoicePaymentResult, len(inv.Payments)),
+	}
+	if !inv.Expiry.IsZero() {
+		result.Expiry = inv.Expiry.Unix()
+	}
+	for i, p := range inv.Payments {
+		result.Payments[i] = types.InvoicePaymentResult{
+			TxID:   p.OutPoint.Hash.String(),
+			Vout:   p.OutPoint.Index,
+			Amount: p.Amount.ToBTC(),
+		}
+	}
+	return result, nil
+}
+
+// createInvoice handles a createinvoice extension request by creating an
+// invoice for a new address of an account.
+func createInvoice(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.CreateInvoiceCmd)
+
+	amount, err := btcutil.NewAmount(cmd.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if amount < 0 {
+		return nil, ErrNeedPositiveAmount
+	}
+
+	var expiry time.Duration
+	if cmd.Expiry != nil {
+		if *cmd.Expiry < 0 {
+			return nil, InvalidParameterError{
+				errors.New("expiry must not be negative"),
+			}
+		}
+		expiry = time.Duration(*cmd.Expiry) * time.Second
 	}
+
+	acctName := defaultAccountName
+	if cmd.Account != nil {
+		acctName = *cmd.Account
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	if err != nil {
+		return nil, err
+	}
+
+	var memo string
+	if cmd.Memo != nil {
+		memo = *cmd.Memo
+	}
+
+	inv, err := w.CreateInvoice(
+		waddrmgr.KeyScopeBIP0044, account, amount, memo, expiry,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return invoiceResult(w, inv)
+}
+
+// getInvoice handles a getinvoice extension request by returning an invoice.
+func getInvoice(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.GetInvoiceCmd)
+
+	inv, err := w.Invoice(cmd.ID)
+	if err == wallet.ErrInvoiceNotFound {
+		return nil, &ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invoiceResult(w, inv)
+}
+
+// listInvoices handles a listinvoices extension request by returning every
+// invoice, optionally only those with a status.
+func listInvoices(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.ListInvoicesCmd)
+
+	invoices, err := w.Invoices()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*types.InvoiceResult, 0, len(invoices))
+	for _, inv := range invoices {
+		if cmd.Status != nil && *cmd.Status != inv.Status.String() {
+			continue
+		}
+		result, err := invoiceResult(w, inv)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// rescanBlockchain handles a rescanblockchain request by rescanning the blocks
+// of a height range for transactions relevant to the wallet. The request blocks
+// until the rescan has passed the stop height.
+func rescanBlockchain(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.RescanBlockchainCmd)
+
+	var startHeight, stopHeight int32
+	if cmd.StartHeight != nil {
+		startHeight = *cmd.StartHeight
+	}
+	if cmd.StopHeight != nil {
+		stopHeight = *cmd.StopHeight
+		if stopHeight <= 0 {
+			return nil, InvalidParameterError{
+				errors.New("stop_height must be positive"),
+			}
+		}
+	}
+	if startHeight < 0 {
+		return nil, InvalidParameterError{
+			errors.New("start_height must not be negative"),
+		}
+	}
+	if cmd.StopHeight != nil && stopHeight < startHeight {
+		return nil, InvalidParameterError{
+			errors.New("stop_height must not be below start_height"),
+		}
+	}
+
+	summary, err := w.RescanBlockchain(startHeight, stopHeight, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.RescanBlockchainResult{
+		StartHeight:     summary.StartBlock.Height,
+		StopHeight:      summary.StopBlock.Height,
+		Transactions:    make([]string, len(summary.Transactions)),
+		NewTransactions: make([]string, len(summary.NewTransactions)),
+	}
+	for i, hash := range summary.Transactions {
+		result.Transactions[i] = hash.String()
+	}
+	for i, hash := range summary.NewTransactions {
+		result.NewTransactions[i] = hash.String()
+	}
+	return result, nil
+}
+
+// rescanControlError replaces errors returned when controlling a rescan job
+// with the appropriate RPC error.
+func rescanControlError(err error) error {
+	if err == wallet.ErrRescanNotFound {
+		return &ErrRescanNotFound
+	}
+	return err
+}
+
+// pauseRescan handles a pauserescan extension request by pausing a rescan job
+// until it is resumed with resumerescan.
+func pauseRescan(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.PauseRescanCmd)
+	return nil, rescanControlError(w.PauseRescan(cmd.ID))
+}
+
+// resumeRescan handles a resumerescan extension request by resuming a paused
+// rescan job from the last block it made progress on.
+func resumeRescan(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.ResumeRescanCmd)
+	return nil, rescanControlError(w.ResumeRescan(cmd.ID))
+}
+
+// cancelRescan handles a cancelrescan extension request by removing a rescan
+// job so it is never resumed.
+func cancelRescan(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.CancelRescanCmd)
+	return nil, rescanControlError(w.CancelRescan(cmd.ID))
 }
 
 // walletIsLocked handles the walletislocked extension request by
@@ -1906,6 +2185,1168 @@ func walletPassphraseChange(icmd interface{}, w *wallet.Wallet) (interface{}, er
 	return nil, err
 }
 
+// psbtLockID is the lease ID of the inputs of PSBTs funded by the
+// walletcreatefundedpsbt command.
+var psbtLockID = wtxmgr.LockID(chainhash.HashH([]byte("walletcreatefundedpsbt")))
+
+// psbtLeaseDuration is the duration the inputs of PSBTs funded by the
+// walletcreatefundedpsbt command are leased for.
+const psbtLeaseDuration = 10 * time.Minute
+
+// psbtFundingMtx serializes coin selection and leasing of the inputs of PSBTs
+// so concurrent requests never select the same outputs.
+var psbtFundingMtx sync.Mutex
+
+// decodePsbt decodes a base64 encoded PSBT.
+func decodePsbt(b64 string) (*psbt.Packet, uint32, error) {
+	b, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		e := fmt.Errorf("TX decode failed: %v", err)
+		return nil, 0, DeserializationError{e}
+	}
+	packet, version, err := wallet.DecodePsbt(b)
+	if err != nil {
+		e := fmt.Errorf("TX decode failed: %v", err)
+		return nil, 0, DeserializationError{e}
+	}
+	return packet, version, nil
+}
+
+// encodePsbt returns the base64 encoding of a PSBT.
+func encodePsbt(packet *psbt.Packet, version uint32) (string, error) {
+	b, err := wallet.EncodePsbt(packet, version)
+	if err != nil {
+		return "", &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: err.Error(),
+		}
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// copyPsbt returns a deep copy of a PSBT.
+func copyPsbt(packet *psbt.Packet) (*psbt.Packet, error) {
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	cp, err := psbt.NewFromRawBytes(&buf, false)
+	if err != nil {
+		return nil, err
+	}
+	cp.Unknowns = append([]psbt.Unknown(nil), packet.Unknowns...)
+	return cp, nil
+}
+
+// isFinalizedInput returns whether the input of a PSBT has its final scripts
+// set.
+func isFinalizedInput(in *psbt.PInput) bool {
+	return len(in.FinalScriptSig) > 0 || len(in.FinalScriptWitness) > 0
+}
+
+// psbtComplete returns whether every input of a PSBT is either finalized or
+// can be finalized.
+func psbtComplete(packet *psbt.Packet) bool {
+	finalized, err := copyPsbt(packet)
+	if err != nil {
+		return false
+	}
+	return psbt.MaybeFinalizeAll(finalized) == nil
+}
+
+// psbtError converts the errors of funding, updating and signing PSBTs to RPC
+// errors.
+func psbtError(err error) error {
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return &ErrWalletUnlockNeeded
+	}
+	return &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInternal.Code,
+		Message: err.Error(),
+	}
+}
+
+// walletCreateFundedPsbt handles the walletcreatefundedpsbt command.
+func walletCreateFundedPsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.WalletCreateFundedPsbtCmd)
+
+	version := wallet.PsbtV0
+	if cmd.PsbtVersion != nil {
+		version = *cmd.PsbtVersion
+	}
+	switch version {
+	case wallet.PsbtV0, wallet.PsbtV2:
+	default:
+		e := fmt.Errorf("unsupported PSBT version %d", version)
+		return nil, InvalidParameterError{e}
+	}
+
+	opts := cmd.Options
+	if opts == nil {
+		opts = &btcjson.WalletCreateFundedPsbtOpts{}
+	}
+	switch {
+	case opts.ChangeAddress != nil, opts.ChangePosition != nil,
+		opts.ChangeType != nil:
+
+		e := errors.New("change outputs are chosen by the wallet")
+		return nil, InvalidParameterError{e}
+
+	case opts.SubtractFeeFromOutputs != nil &&
+		len(*opts.SubtractFeeFromOutputs) != 0:
+
+		e := errors.New("subtractFeeFromOutputs is not supported")
+		return nil, InvalidParameterError{e}
+
+	case opts.ConfTarget != nil, opts.EstimateMode != nil:
+		e := errors.New("fee estimation is not supported, use feeRate")
+		return nil, InvalidParameterError{e}
+	}
+
+	feeSatPerKb := txrules.DefaultRelayFeePerKb
+	if opts.FeeRate != nil {
+		feeRate, err := btcutil.NewAmount(*opts.FeeRate)
+		if err != nil || feeRate < 0 {
+			e := errors.New("invalid feeRate")
+			return nil, InvalidParameterError{e}
+		}
+		feeSatPerKb = feeRate
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion + 1)
+	if cmd.Locktime != nil {
+		tx.LockTime = *cmd.Locktime
+	}
+
+	// Inputs signal replaceability if requested, and otherwise enable
+	// the lock time of the transaction if one is set.
+	sequence := uint32(wire.MaxTxInSequenceNum)
+	switch {
+	case opts.Replaceable != nil && *opts.Replaceable:
+		sequence = wire.MaxTxInSequenceNum - 2
+	case tx.LockTime != 0:
+		sequence = wire.MaxTxInSequenceNum - 1
+	}
+	for _, input := range cmd.Inputs {
+		txHash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, DeserializationError{err}
+		}
+		txIn := wire.NewTxIn(wire.NewOutPoint(txHash, input.Vout), nil, nil)
+		txIn.Sequence = sequence
+		if input.Sequence != 0 {
+			txIn.Sequence = input.Sequence
+		}
+		tx.AddTxIn(txIn)
+	}
+
+	for _, output := range cmd.Outputs {
+		for key, value := range output {
+			txOut, err := makePsbtOutput(key, value, w.ChainParams())
+			if err != nil {
+				return nil, err
+			}
+			tx.AddTxOut(txOut)
+		}
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+
+	// Select the inputs and lease them before releasing the lock, so they
+	// are not selected by other requests.
+	psbtFundingMtx.Lock()
+	changeIndex, err := w.FundPsbt(
+		packet, nil, 1, waddrmgr.DefaultAccountNum, feeSatPerKb,
+		wallet.CoinSelectionLargest,
+	)
+	if err == nil {
+		err = leasePsbtInputs(w, packet)
+	}
+	psbtFundingMtx.Unlock()
+	if err != nil {
+		return nil, psbtError(err)
+	}
+	if opts.LockUnspents != nil && *opts.LockUnspents {
+		for _, txIn := range packet.UnsignedTx.TxIn {
+			w.LockOutpoint(txIn.PreviousOutPoint)
+		}
+	}
+
+	bip32Derivs := cmd.Bip32Derivs == nil || *cmd.Bip32Derivs
+	if !bip32Derivs {
+		for i := range packet.Inputs {
+			packet.Inputs[i].Bip32Derivation = nil
+		}
+	}
+	if err := w.UpdatePsbt(packet, bip32Derivs); err != nil {
+		return nil, psbtError(err)
+	}
+
+	fee, err := psbtFee(packet)
+	if err != nil {
+		return nil, psbtError(err)
+	}
+	b64, err := encodePsbt(packet, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &btcjson.WalletCreateFundedPsbtResult{
+		Psbt:      b64,
+		Fee:       fee.ToBTC(),
+		ChangePos: int64(changeIndex),
+	}, nil
+}
+
+// makePsbtOutput creates a transaction output from an output of the
+// walletcreatefundedpsbt command, which either pays an amount in BTC to an
+// address or carries hex encoded data.
+func makePsbtOutput(key string, value interface{},
+	chainParams *chaincfg.Params) (*wire.TxOut, error) {
+
+	if key == "data" {
+		hexData, ok := value.(string)
+		if !ok {
+			e := errors.New("data output must be a hex string")
+			return nil, InvalidParameterError{e}
+		}
+		data, err := decodeHexStr(hexData)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := txscript.NullDataScript(data)
+		if err != nil {
+			return nil, InvalidParameterError{err}
+		}
+		return wire.NewTxOut(0, pkScript), nil
+	}
+
+	btc, ok := value.(float64)
+	if !ok {
+		e := fmt.Errorf("amount of output %s must be a number", key)
+		return nil, InvalidParameterError{e}
+	}
+	amt, err := btcutil.NewAmount(btc)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	if amt <= 0 {
+		return nil, ErrNeedPositiveAmount
+	}
+	outputs, err := makeOutputs(
+		map[string]btcutil.Amount{key: amt}, chainParams,
+	)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	return outputs[0], nil
+}
+
+// leasePsbtInputs leases the inputs of a funded PSBT.  If any input can't be
+// leased, the leases acquired so far are released.
+func leasePsbtInputs(w *wallet.Wallet, packet *psbt.Packet) error {
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		_, err := w.LeaseOutput(
+			psbtLockID, txIn.PreviousOutPoint, psbtLeaseDuration,
+		)
+		if err == nil {
+			continue
+		}
+
+		for _, txIn := range packet.UnsignedTx.TxIn[:i] {
+			err := w.ReleaseOutput(psbtLockID, txIn.PreviousOutPoint)
+			if err != nil {
+				log.Errorf("Unable to release output %v: %v",
+					txIn.PreviousOutPoint, err)
+			}
+		}
+		return fmt.Errorf("unable to lease output %v: %v",
+			txIn.PreviousOutPoint, err)
+	}
+	return nil
+}
+
+// psbtFee returns the fee of a PSBT.  All inputs must have their UTXO
+// information attached.
+func psbtFee(packet *psbt.Packet) (btcutil.Amount, error) {
+	in, err := psbt.SumUtxoInputValues(packet)
+	if err != nil {
+		return 0, err
+	}
+	var out int64
+	for _, txOut := range packet.UnsignedTx.TxOut {
+		out += txOut.Value
+	}
+	return btcutil.Amount(in - out), nil
+}
+
+// walletProcessPsbt handles the walletprocesspsbt command.
+func walletProcessPsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.WalletProcessPsbtCmd)
+
+	packet, version, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	hashType, err := parseSigHashType(*cmd.SighashType)
+	if err != nil {
+		return nil, err
+	}
+
+	bip32Derivs := cmd.Bip32Derivs == nil || *cmd.Bip32Derivs
+	if err := w.UpdatePsbt(packet, bip32Derivs); err != nil {
+		return nil, psbtError(err)
+	}
+	if cmd.Sign == nil || *cmd.Sign {
+		_, err := w.SignPsbtHashType(packet, hashType)
+		switch {
+		case err == wallet.ErrSighashMismatch:
+			return nil, InvalidParameterError{err}
+		case err != nil:
+			return nil, psbtError(err)
+		}
+	}
+
+	b64, err := encodePsbt(packet, version)
+	if err != nil {
+		return nil, err
+	}
+	return &btcjson.WalletProcessPsbtResult{
+		Psbt:     b64,
+		Complete: psbtComplete(packet),
+	}, nil
+}
+
+// finalizePsbt handles the finalizepsbt command.
+func finalizePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.FinalizePsbtCmd)
+
+	packet, version, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Finalize every input possible, the remaining ones are left for
+	// further signers.
+	for i := range packet.Inputs {
+		if !isFinalizedInput(&packet.Inputs[i]) {
+			_, _ = psbt.MaybeFinalize(packet, i)
+		}
+	}
+
+	result := &types.FinalizePsbtResult{
+		Complete: packet.IsComplete(),
+	}
+	if result.Complete && *cmd.Extract {
+		tx, err := psbt.Extract(packet)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInternal.Code,
+				Message: err.Error(),
+			}
+		}
+		var buf bytes.Buffer
+		buf.Grow(tx.SerializeSize())
+		if err := tx.Serialize(&buf); err != nil {
+			return nil, err
+		}
+		result.Hex = hex.EncodeToString(buf.Bytes())
+		return result, nil
+	}
+
+	result.Psbt, err = encodePsbt(packet, version)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// combinePsbt handles the combinepsbt command.
+func combinePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.CombinePsbtCmd)
+
+	if len(cmd.Txs) == 0 {
+		e := errors.New("at least one PSBT is required")
+		return nil, InvalidParameterError{e}
+	}
+	combined, version, err := decodePsbt(cmd.Txs[0])
+	if err != nil {
+		return nil, err
+	}
+	txHash := combined.UnsignedTx.TxHash()
+	for _, b64 := range cmd.Txs[1:] {
+		packet, _, err := decodePsbt(b64)
+		if err != nil {
+			return nil, err
+		}
+		if packet.UnsignedTx.TxHash() != txHash {
+			e := errors.New("PSBTs not compatible (different " +
+				"transactions)")
+			return nil, InvalidParameterError{e}
+		}
+		mergePsbt(combined, packet)
+	}
+
+	return encodePsbt(combined, version)
+}
+
+// mergePsbt adds the fields of a PSBT missing from another PSBT with the same
+// unsigned transaction.
+func mergePsbt(dst, src *psbt.Packet) {
+	for i := range dst.Inputs {
+		d, s := &dst.Inputs[i], &src.Inputs[i]
+		if d.NonWitnessUtxo == nil {
+			d.NonWitnessUtxo = s.NonWitnessUtxo
+		}
+		if d.WitnessUtxo == nil {
+			d.WitnessUtxo = s.WitnessUtxo
+		}
+		if d.SighashType == 0 {
+			d.SighashType = s.SighashType
+		}
+		if d.RedeemScript == nil {
+			d.RedeemScript = s.RedeemScript
+		}
+		if d.WitnessScript == nil {
+			d.WitnessScript = s.WitnessScript
+		}
+		if d.FinalScriptSig == nil {
+			d.FinalScriptSig = s.FinalScriptSig
+		}
+		if d.FinalScriptWitness == nil {
+			d.FinalScriptWitness = s.FinalScriptWitness
+		}
+		for _, sig := range s.PartialSigs {
+			if !containsPartialSig(d.PartialSigs, sig.PubKey) {
+				d.PartialSigs = append(d.PartialSigs, sig)
+			}
+		}
+		d.Bip32Derivation = mergeDerivations(
+			d.Bip32Derivation, s.Bip32Derivation,
+		)
+		for _, u := range s.Unknowns {
+			if !containsUnknown(d.Unknowns, u.Key) {
+				d.Unknowns = append(d.Unknowns, u)
+			}
+		}
+	}
+
+	for i := range dst.Outputs {
+		d, s := &dst.Outputs[i], &src.Outputs[i]
+		if d.RedeemScript == nil {
+			d.RedeemScript = s.RedeemScript
+		}
+		if d.WitnessScript == nil {
+			d.WitnessScript = s.WitnessScript
+		}
+		d.Bip32Derivation = mergeDerivations(
+			d.Bip32Derivation, s.Bip32Derivation,
+		)
+	}
+
+	for i := range src.Unknowns {
+		u := &src.Unknowns[i]
+		known := false
+		for j := range dst.Unknowns {
+			if bytes.Equal(dst.Unknowns[j].Key, u.Key) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			dst.Unknowns = append(dst.Unknowns, *u)
+		}
+	}
+	wallet.CombinePsbtModifiable(dst, src)
+}
+
+func containsPartialSig(sigs []*psbt.PartialSig, pubKey []byte) bool {
+	for _, sig := range sigs {
+		if bytes.Equal(sig.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUnknown(unknowns []*psbt.Unknown, key []byte) bool {
+	for _, u := range unknowns {
+		if bytes.Equal(u.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeDerivations(dst, src []*psbt.Bip32Derivation) []*psbt.Bip32Derivation {
+	for _, derivation := range src {
+		known := false
+		for _, d := range dst {
+			if bytes.Equal(d.PubKey, derivation.PubKey) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			dst = append(dst, derivation)
+		}
+	}
+	return dst
+}
+
+// convertPsbt handles the convertpsbt extension command.
+func convertPsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.ConvertPsbtCmd)
+
+	packet, _, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	switch *cmd.Version {
+	case wallet.PsbtV0, wallet.PsbtV2:
+	default:
+		e := fmt.Errorf("unsupported PSBT version %d", *cmd.Version)
+		return nil, InvalidParameterError{e}
+	}
+	b, err := wallet.EncodePsbt(packet, *cmd.Version)
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// decodePsbtCmd handles the decodepsbt command.
+func decodePsbtCmd(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.DecodePsbtCmd)
+
+	packet, version, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	chainParams := w.ChainParams()
+
+	result := &types.DecodePsbtResult{
+		Tx:          txRawDecodeResult(packet.UnsignedTx, chainParams),
+		Unknown:     make(map[string]string, len(packet.Unknowns)),
+		Inputs:      make([]types.PsbtInputResult, len(packet.Inputs)),
+		Outputs:     make([]types.PsbtOutputResult, len(packet.Outputs)),
+		PsbtVersion: version,
+	}
+	for _, u := range packet.Unknowns {
+		result.Unknown[hex.EncodeToString(u.Key)] =
+			hex.EncodeToString(u.Value)
+	}
+
+	for i := range packet.Inputs {
+		in := &packet.Inputs[i]
+		r := &result.Inputs[i]
+		if in.NonWitnessUtxo != nil {
+			tx := txRawDecodeResult(in.NonWitnessUtxo, chainParams)
+			r.NonWitnessUtxo = &tx
+		}
+		if in.WitnessUtxo != nil {
+			r.WitnessUtxo = &types.PsbtWitnessUtxoResult{
+				Amount: btcutil.Amount(in.WitnessUtxo.Value).ToBTC(),
+				ScriptPubKey: scriptPubKeyResult(
+					in.WitnessUtxo.PkScript, chainParams,
+				),
+			}
+		}
+		if len(in.PartialSigs) != 0 {
+			r.PartialSignatures = make(map[string]string)
+			for _, sig := range in.PartialSigs {
+				r.PartialSignatures[hex.EncodeToString(sig.PubKey)] =
+					hex.EncodeToString(sig.Signature)
+			}
+		}
+		if in.SighashType != 0 {
+			r.Sighash = sigHashTypeName(in.SighashType)
+		}
+		r.RedeemScript = psbtScriptResult(in.RedeemScript)
+		r.WitnessScript = psbtScriptResult(in.WitnessScript)
+		r.Bip32Derivs = bip32DerivResults(in.Bip32Derivation)
+		if in.FinalScriptSig != nil {
+			asm, _ := txscript.DisasmString(in.FinalScriptSig)
+			r.FinalScriptSig = &btcjson.ScriptSig{
+				Asm: asm,
+				Hex: hex.EncodeToString(in.FinalScriptSig),
+			}
+		}
+		if in.FinalScriptWitness != nil {
+			witness, err := deserializeWitness(in.FinalScriptWitness)
+			if err != nil {
+				return nil, DeserializationError{err}
+			}
+			r.FinalScriptWitness = witnessToHex(witness)
+		}
+		if len(in.Unknowns) != 0 {
+			r.Unknown = make(map[string]string)
+			for _, u := range in.Unknowns {
+				r.Unknown[hex.EncodeToString(u.Key)] =
+					hex.EncodeToString(u.Value)
+			}
+		}
+	}
+
+	for i := range packet.Outputs {
+		out := &packet.Outputs[i]
+		result.Outputs[i] = types.PsbtOutputResult{
+			RedeemScript:  psbtScriptResult(out.RedeemScript),
+			WitnessScript: psbtScriptResult(out.WitnessScript),
+			Bip32Derivs:   bip32DerivResults(out.Bip32Derivation),
+		}
+	}
+
+	if fee, err := psbtFee(packet); err == nil {
+		btc := fee.ToBTC()
+		result.Fee = &btc
+	}
+
+	return result, nil
+}
+
+// sigHashTypeName returns the RPC API name of a sighash type, or its number
+// if it has none.
+func sigHashTypeName(hashType txscript.SigHashType) string {
+	for name, t := range sigHashTypes {
+		if t == hashType {
+			return name
+		}
+	}
+	return strconv.FormatUint(uint64(hashType), 10)
+}
+
+// txRawDecodeResult returns the decoded representation of a transaction.
+func txRawDecodeResult(tx *wire.MsgTx,
+	chainParams *chaincfg.Params) btcjson.TxRawDecodeResult {
+
+	vin := make([]btcjson.Vin, len(tx.TxIn))
+	coinbase := blockchain.IsCoinBaseTx(tx)
+	for i, txIn := range tx.TxIn {
+		vin[i].Sequence = txIn.Sequence
+		if len(txIn.Witness) != 0 {
+			vin[i].Witness = witnessToHex(txIn.Witness)
+		}
+		if coinbase {
+			vin[i].Coinbase = hex.EncodeToString(txIn.SignatureScript)
+			continue
+		}
+
+		asm, _ := txscript.DisasmString(txIn.SignatureScript)
+		vin[i].Txid = txIn.PreviousOutPoint.Hash.String()
+		vin[i].Vout = txIn.PreviousOutPoint.Index
+		vin[i].ScriptSig = &btcjson.ScriptSig{
+			Asm: asm,
+			Hex: hex.EncodeToString(txIn.SignatureScript),
+		}
+	}
+
+	vout := make([]btcjson.Vout, len(tx.TxOut))
+	for i, txOut := range tx.TxOut {
+		vout[i] = btcjson.Vout{
+			Value:        btcutil.Amount(txOut.Value).ToBTC(),
+			N:            uint32(i),
+			ScriptPubKey: scriptPubKeyResult(txOut.PkScript, chainParams),
+		}
+	}
+
+	return btcjson.TxRawDecodeResult{
+		Txid:     tx.TxHash().String(),
+		Version:  tx.Version,
+		Locktime: tx.LockTime,
+		Vin:      vin,
+		Vout:     vout,
+	}
+}
+
+// scriptPubKeyResult returns the decoded representation of an output script.
+func scriptPubKeyResult(pkScript []byte,
+	chainParams *chaincfg.Params) btcjson.ScriptPubKeyResult {
+
+	asm, _ := txscript.DisasmString(pkScript)
+	class, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+		pkScript, chainParams,
+	)
+	var addresses []string
+	for _, addr := range addrs {
+		addresses = append(addresses, addr.EncodeAddress())
+	}
+
+	return btcjson.ScriptPubKeyResult{
+		Asm:       asm,
+		Hex:       hex.EncodeToString(pkScript),
+		ReqSigs:   int32(reqSigs),
+		Type:      class.String(),
+		Addresses: addresses,
+	}
+}
+
+// psbtScriptResult returns the decoded representation of a redeem or witness
+// script, or nil if the script is not set.
+func psbtScriptResult(script []byte) *types.PsbtScriptResult {
+	if script == nil {
+		return nil
+	}
+	asm, _ := txscript.DisasmString(script)
+	return &types.PsbtScriptResult{
+		Asm:  asm,
+		Hex:  hex.EncodeToString(script),
+		Type: txscript.GetScriptClass(script).String(),
+	}
+}
+
+// bip32DerivResults returns the decoded representation of BIP 32 derivation
+// paths.
+func bip32DerivResults(derivations []*psbt.Bip32Derivation) []types.PsbtBip32DerivResult {
+	if len(derivations) == 0 {
+		return nil
+	}
+
+	results := make([]types.PsbtBip32DerivResult, len(derivations))
+	for i, derivation := range derivations {
+		var fingerprint [4]byte
+		binary.LittleEndian.PutUint32(
+			fingerprint[:], derivation.MasterKeyFingerprint,
+		)
+
+		path := "m"
+		for _, index := range derivation.Bip32Path {
+			if index >= hdkeychain.HardenedKeyStart {
+				path += fmt.Sprintf("/%d'",
+					index-hdkeychain.HardenedKeyStart)
+			} else {
+				path += fmt.Sprintf("/%d", index)
+			}
+		}
+
+		results[i] = types.PsbtBip32DerivResult{
+			PubKey:            hex.EncodeToString(derivation.PubKey),
+			MasterFingerprint: hex.EncodeToString(fingerprint[:]),
+			Path:              path,
+		}
+	}
+	return results
+}
+
+// deserializeWitness decodes a witness stack serialized for a PSBT.
+func deserializeWitness(b []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(b)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count > txscript.MaxScriptSize {
+		return nil, errors.New("too many witness items")
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(
+			r, 0, txscript.MaxScriptSize, "witness item",
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return witness, nil
+}
+
+func witnessToHex(witness wire.TxWitness) []string {
+	items := make([]string, len(witness))
+	for i, item := range witness {
+		items[i] = hex.EncodeToString(item)
+	}
+	return items
+}
+
+// analyzePsbt handles the analyzepsbt command.
+func analyzePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.AnalyzePsbtCmd)
+
+	packet, _, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The roles of BIP 174 in the order they process a PSBT.
+	roles := []string{"updater", "signer", "finalizer", "extractor"}
+	next := len(roles) - 1
+
+	// Inputs are finalized on a copy to find out whether they are fully
+	// signed.
+	finalized, err := copyPsbt(packet)
+	if err != nil {
+		return nil, DeserializationError{err}
+	}
+
+	result := &types.AnalyzePsbtResult{
+		Inputs: make([]types.AnalyzePsbtInputResult, len(packet.Inputs)),
+	}
+	for i := range packet.Inputs {
+		in := &packet.Inputs[i]
+		r := &result.Inputs[i]
+		r.HasUtxo = in.WitnessUtxo != nil || in.NonWitnessUtxo != nil
+		r.IsFinal = isFinalizedInput(in)
+
+		role := len(roles) - 1
+		switch {
+		case r.IsFinal:
+		case !r.HasUtxo || missingPsbtScript(packet, i):
+			role = 0
+		default:
+			if ok, _ := psbt.MaybeFinalize(finalized, i); ok {
+				role = 2
+			} else {
+				role = 1
+			}
+		}
+		if !r.IsFinal {
+			r.Next = roles[role]
+		}
+		if role < next {
+			next = role
+		}
+	}
+	result.Next = roles[next]
+
+	if next == 0 {
+		return result, nil
+	}
+
+	fee, err := psbtFee(packet)
+	if err != nil || fee < 0 {
+		result.Error = "PSBT is not valid. Input amount invalid"
+		result.Next = "creator"
+		return result, nil
+	}
+	btc := fee.ToBTC()
+	result.Fee = &btc
+
+	// The size is only known once every input is signed.
+	tx, err := psbt.Extract(finalized)
+	if err != nil {
+		return result, nil
+	}
+	vSize := (blockchain.GetTransactionWeight(btcutil.NewTx(tx)) +
+		blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor
+	feeRate := btcutil.Amount(int64(fee) * 1000 / vSize).ToBTC()
+	result.EstimatedVSize = &vSize
+	result.EstimatedFeeRate = &feeRate
+
+	return result, nil
+}
+
+// missingPsbtScript returns whether the redeem or witness script needed to sign
+// an input of a PSBT is missing.
+func missingPsbtScript(packet *psbt.Packet, idx int) bool {
+	in := &packet.Inputs[idx]
+
+	var pkScript []byte
+	if in.WitnessUtxo != nil {
+		pkScript = in.WitnessUtxo.PkScript
+	} else {
+		prevIndex := packet.UnsignedTx.TxIn[idx].PreviousOutPoint.Index
+		if int(prevIndex) >= len(in.NonWitnessUtxo.TxOut) {
+			return true
+		}
+		pkScript = in.NonWitnessUtxo.TxOut[prevIndex].PkScript
+	}
+
+	if txscript.IsPayToScriptHash(pkScript) {
+		if in.RedeemScript == nil {
+			return true
+		}
+		pkScript = in.RedeemScript
+	}
+	return txscript.IsPayToWitnessScriptHash(pkScript) &&
+		in.WitnessScript == nil
+}
+
+// utxoUpdatePsbt handles the utxoupdatepsbt command.
+func utxoUpdatePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.UtxoUpdatePsbtCmd)
+
+	packet, version, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.UpdatePsbt(packet, false); err != nil {
+		return nil, psbtError(err)
+	}
+	return encodePsbt(packet, version)
+}
+
+// createReserveProof handles the createreserveproof extension command.
+func createReserveProof(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.CreateReserveProofCmd)
+
+	if *cmd.MinConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	var outPoints []wire.OutPoint
+	if cmd.Outputs != nil {
+		for _, input := range *cmd.Outputs {
+			txHash, err := chainhash.NewHashFromStr(input.Txid)
+			if err != nil {
+				return nil, ParseError{err}
+			}
+			outPoints = append(outPoints, wire.OutPoint{
+				Hash:  *txHash,
+				Index: input.Vout,
+			})
+		}
+	}
+	var accounts []wallet.ScopedAccount
+	if cmd.Accounts != nil {
+		for _, name := range *cmd.Accounts {
+			account, err := w.AccountNumber(
+				waddrmgr.KeyScopeBIP0044, name,
+			)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, wallet.ScopedAccount{
+				Scope:   waddrmgr.KeyScopeBIP0044,
+				Account: account,
+			})
+		}
+	}
+	if len(outPoints) == 0 && len(accounts) == 0 {
+		e := errors.New("at least one output or account is required")
+		return nil, InvalidParameterError{e}
+	}
+
+	packet, err := w.CreateReserveProof(
+		cmd.Message, outPoints, accounts, int32(*cmd.MinConf),
+	)
+	if err != nil {
+		return nil, psbtError(err)
+	}
+	b64, err := encodePsbt(packet, wallet.PsbtV0)
+	if err != nil {
+		return nil, err
+	}
+
+	// The commitment input is never signed.
+	complete := true
+	for _, in := range packet.Inputs[1:] {
+		complete = complete && isFinalizedInput(&in)
+	}
+	amount := btcutil.Amount(packet.UnsignedTx.TxOut[0].Value)
+	return &types.CreateReserveProofResult{
+		Psbt:     b64,
+		Amount:   amount.ToBTC(),
+		Complete: complete,
+	}, nil
+}
+
+// payjoinClient is the HTTP client used to request payjoin proposals.
+var payjoinClient = &http.Client{Timeout: time.Minute}
+
+// sendPayjoin handles the sendpayjoin extension command.
+func sendPayjoin(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.SendPayjoinCmd)
+
+	if *cmd.MinConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+	uri, err := wallet.ParsePayjoinURI(cmd.URI, w.ChainParams())
+	if err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	amount := uri.Amount
+	if cmd.Amount != nil {
+		amount, err = btcutil.NewAmount(*cmd.Amount)
+		if err != nil {
+			return nil, InvalidParameterError{err}
+		}
+	}
+	if amount <= 0 {
+		e := errors.New("amount must be positive")
+		return nil, InvalidParameterError{e}
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	feeSatPerKb := txrules.DefaultRelayFeePerKb
+	if cmd.FeeRate != nil {
+		feeRate, err := btcutil.NewAmount(*cmd.FeeRate)
+		if err != nil || feeRate < 0 {
+			e := errors.New("invalid feeRate")
+			return nil, InvalidParameterError{e}
+		}
+		feeSatPerKb = feeRate
+	}
+
+	pkScript, err := txscript.PayToAddrScript(uri.Address)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(wire.TxVersion + 1)
+	tx.AddTxOut(wire.NewTxOut(int64(amount), pkScript))
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The inputs stay leased while the receiver is asked for a payjoin
+	// proposal, so they're not selected by other requests.
+	psbtFundingMtx.Lock()
+	changeIndex, err := w.FundPsbt(
+		packet, nil, int32(*cmd.MinConf), account, feeSatPerKb,
+		wallet.CoinSelectionLargest,
+	)
+	if err == nil {
+		err = leasePsbtInputs(w, packet)
+	}
+	psbtFundingMtx.Unlock()
+	if err != nil {
+		return nil, psbtError(err)
+	}
+
+	sent, payjoin, err := w.SendPayjoin(
+		payjoinClient, packet, changeIndex, feeSatPerKb, uri, "",
+	)
+	if err != nil {
+		for _, txIn := range packet.UnsignedTx.TxIn {
+			err := w.ReleaseOutput(psbtLockID, txIn.PreviousOutPoint)
+			if err != nil {
+				log.Errorf("Unable to release output %v: %v",
+					txIn.PreviousOutPoint, err)
+			}
+		}
+		return nil, psbtError(err)
+	}
+
+	return &types.SendPayjoinResult{
+		TxID:    sent.TxHash().String(),
+		Payjoin: payjoin,
+	}, nil
+}
+
+// sweepPrivKey handles the sweepprivkey extension command by spending the
+// unspent outputs of WIF encoded private keys to new internal addresses of an
+// account.  The keys are not imported.
+func sweepPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.SweepPrivKeyCmd)
+
+	if len(cmd.PrivKeys) == 0 {
+		e := errors.New("at least one private key is required")
+		return nil, InvalidParameterError{e}
+	}
+	keys := make([]*btcutil.WIF, 0, len(cmd.PrivKeys))
+	for _, privKey := range cmd.PrivKeys {
+		wif, err := btcutil.DecodeWIF(privKey)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "WIF decode failed: " + err.Error(),
+			}
+		}
+		if !wif.IsForNet(w.ChainParams()) {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Key is not intended for " + w.ChainParams().Name,
+			}
+		}
+		keys = append(keys, wif)
+	}
+	if *cmd.StartHeight < 0 {
+		e := errors.New("startheight must not be negative")
+		return nil, InvalidParameterError{e}
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	feeSatPerKb := txrules.DefaultRelayFeePerKb
+	if cmd.FeeRate != nil {
+		feeRate, err := btcutil.NewAmount(*cmd.FeeRate)
+		if err != nil || feeRate < 0 {
+			e := errors.New("invalid feeRate")
+			return nil, InvalidParameterError{e}
+		}
+		feeSatPerKb = feeRate
+	}
+
+	txs, err := w.SweepPrivKeys(
+		keys, *cmd.StartHeight, waddrmgr.KeyScopeBIP0044, account,
+		feeSatPerKb,
+	)
+	txIDs := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		txIDs = append(txIDs, tx.TxHash().String())
+	}
+	switch {
+	case err == wallet.ErrNothingToSweep:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
+		}
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return nil, &ErrWalletUnlockNeeded
+	case err != nil && len(txs) > 0:
+		// Report the transactions that were already broadcast, as
+		// their outputs have been swept regardless of the error.
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCWallet,
+			Message: fmt.Sprintf("broadcast %s before failing: %v",
+				strings.Join(txIDs, ", "), err),
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	var (
+		inputs int
+		amount btcutil.Amount
+	)
+	for _, tx := range txs {
+		inputs += len(tx.TxIn)
+		amount += btcutil.Amount(tx.TxOut[0].Value)
+	}
+	return &types.SweepPrivKeyResult{
+		TxIDs:  txIDs,
+		Inputs: inputs,
+		Amount: amount.ToBTC(),
+	}, nil
+}
+
+// verifyReserveProof handles the verifyreserveproof extension command.
+func verifyReserveProof(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*types.VerifyReserveProofCmd)
+
+	packet, _, err := decodePsbt(cmd.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	chainClient := w.ChainClient()
+	if chainClient == nil {
+		return nil, &btcjson.RPCError{
+			Code:    -1,
+			Message: "Chain RPC is inactive",
+		}
+	}
+
+	amount, err := wallet.VerifyReserveProof(
+		packet, cmd.Message, chainClient, *cmd.HeightHint,
+	)
+	if e, ok := err.(*wallet.InvalidReserveProofError); ok {
+		return &types.VerifyReserveProofResult{Error: e.Reason}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &types.VerifyReserveProofResult{
+		Valid:  true,
+		Amount: amount.ToBTC(),
+	}, nil
+}
+
 // decodeHexStr decodes the hex encoding of a string, possibly prepending a
 // leading '0' character if there is an odd number of bytes in the hex string.
 // This is to prevent an error for an invalid hex string when using an odd
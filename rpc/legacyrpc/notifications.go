@@ -0,0 +1,372 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc/types"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// notificationEvents is the set of events websocket clients can subscribe to.
+var notificationEvents = map[string]struct{}{
+	types.EventTransactions:  {},
+	types.EventConfirmations: {},
+	types.EventBalances:      {},
+	types.EventLockState:     {},
+	types.EventRescan:        {},
+}
+
+// minedTx is the block of a wallet transaction which has not yet reached the
+// confirmations of a confirmations subscription.
+type minedTx struct {
+	blockHash   chainhash.Hash
+	blockHeight int32
+}
+
+// subscriptions records the events a websocket client subscribed to.
+type subscriptions struct {
+	mu     sync.Mutex
+	events map[string]struct{}
+
+	// confirmations is the number of confirmations of the confirmations
+	// subscription.  Transactions mined while the client is subscribed are
+	// kept in unconfirmed until they reach them.
+	confirmations int32
+	unconfirmed   map[chainhash.Hash]minedTx
+}
+
+// subscribe adds the events to the subscriptions and returns every subscribed
+// event.
+func (s *subscriptions) subscribe(events []string, confirmations int32) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.events == nil {
+		s.events = make(map[string]struct{})
+	}
+	for _, event := range events {
+		s.events[event] = struct{}{}
+	}
+	if _, ok := s.events[types.EventConfirmations]; ok {
+		s.confirmations = confirmations
+		if s.unconfirmed == nil {
+			s.unconfirmed = make(map[chainhash.Hash]minedTx)
+		}
+	}
+	return s.subscribed()
+}
+
+// unsubscribe removes the events from the subscriptions and returns every
+// remaining event.
+func (s *subscriptions) unsubscribe(events []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		delete(s.events, event)
+	}
+	if _, ok := s.events[types.EventConfirmations]; !ok {
+		s.unconfirmed = nil
+	}
+	return s.subscribed()
+}
+
+// subscribed returns the subscribed events in sorted order.  It must be called
+// with the mutex held.
+func (s *subscriptions) subscribed() []string {
+	events := make([]string, 0, len(s.events))
+	for event := range s.events {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	return events
+}
+
+// has returns whether the event is subscribed.
+func (s *subscriptions) has(event string) bool {
+	s.mu.Lock()
+	_, ok := s.events[event]
+	s.mu.Unlock()
+	return ok
+}
+
+// confirm records the transactions mined in the attached blocks of the
+// notification and forgets those of its detached blocks.  The txconfirmed
+// notifications of the transactions reaching the confirmations of the
+// subscription are returned.
+func (s *subscriptions) confirm(n *wallet.TransactionNotifications) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unconfirmed == nil {
+		return nil
+	}
+	for _, blockHash := range n.DetachedBlocks {
+		for txHash, tx := range s.unconfirmed {
+			if tx.blockHash == *blockHash {
+				delete(s.unconfirmed, txHash)
+			}
+		}
+	}
+
+	var ntfns []interface{}
+	for _, b := range n.AttachedBlocks {
+		for _, tx := range b.Transactions {
+			s.unconfirmed[*tx.Hash] = minedTx{
+				blockHash:   *b.Hash,
+				blockHeight: b.Height,
+			}
+		}
+		for txHash, tx := range s.unconfirmed {
+			confs := confirms(tx.blockHeight, b.Height)
+			if confs < s.confirmations {
+				continue
+			}
+			ntfns = append(ntfns, types.NewTxConfirmedNtfn(
+				txHash.String(), tx.blockHash.String(),
+				tx.blockHeight, confs,
+			))
+			delete(s.unconfirmed, txHash)
+		}
+	}
+	return ntfns
+}
+
+// handleSubscription handles a subscribe or unsubscribe request of a websocket
+// client by returning every event the client is subscribed to afterwards.
+func handleSubscription(wsc *websocketClient,
+	req *btcjson.Request) (interface{}, *btcjson.RPCError) {
+
+	cmd, err := types.UnmarshalCmd(req)
+	if err != nil {
+		return nil, btcjson.ErrRPCInvalidRequest
+	}
+
+	switch cmd := cmd.(type) {
+	case *types.SubscribeCmd:
+		for _, event := range cmd.Events {
+			if _, ok := notificationEvents[event]; !ok {
+				return nil, &btcjson.RPCError{
+					Code: btcjson.ErrRPCInvalidParameter,
+					Message: fmt.Sprintf("Unknown event %q",
+						event),
+				}
+			}
+		}
+		if *cmd.Confirmations < 1 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "confirmations must be positive",
+			}
+		}
+		return wsc.subs.subscribe(cmd.Events, *cmd.Confirmations), nil
+
+	case *types.UnsubscribeCmd:
+		return wsc.subs.unsubscribe(cmd.Events), nil
+
+	default:
+		return nil, btcjson.ErrRPCInvalidRequest
+	}
+}
+
+// websocketOnly handles a request of a method which is only available to
+// websocket clients, such as subscribe, from an HTTP POST client.
+func websocketOnly(interface{}, *wallet.Wallet) (interface{}, error) {
+	return nil, &ErrWebsocketOnly
+}
+
+// addWebsocketClient registers a websocket client so it may receive the
+// notifications it subscribes to.
+func (s *Server) addWebsocketClient(wsc *websocketClient) {
+	s.wsClientsMu.Lock()
+	s.wsClients[wsc] = struct{}{}
+	s.wsClientsMu.Unlock()
+}
+
+// removeWebsocketClient deregisters a disconnected websocket client.
+func (s *Server) removeWebsocketClient(wsc *websocketClient) {
+	s.wsClientsMu.Lock()
+	delete(s.wsClients, wsc)
+	s.wsClientsMu.Unlock()
+}
+
+// subscribers returns the websocket clients subscribed to the event.
+func (s *Server) subscribers(event string) []*websocketClient {
+	s.wsClientsMu.Lock()
+	defer s.wsClientsMu.Unlock()
+
+	var clients []*websocketClient
+	for wsc := range s.wsClients {
+		if wsc.subs.has(event) {
+			clients = append(clients, wsc)
+		}
+	}
+	return clients
+}
+
+// notify queues a notification for each of the websocket clients.
+func notify(clients []*websocketClient, ntfn interface{}) {
+	if len(clients) == 0 {
+		return
+	}
+	b, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+	if err != nil {
+		log.Errorf("Unable to marshal notification: %v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.queueNotification(b)
+	}
+}
+
+// walletNotifications sends the notifications of the wallet to the websocket
+// clients subscribed to them until the server is stopped.
+func (s *Server) walletNotifications(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	txNtfns := w.NtfnServer.TransactionNotifications()
+	defer txNtfns.Done()
+	lockNtfns := w.NtfnServer.LockStateNotifications()
+	defer lockNtfns.Done()
+	rescanNtfns := w.NtfnServer.RescanNotifications()
+	defer rescanNtfns.Done()
+
+	for {
+		select {
+		case n := <-txNtfns.C:
+			s.notifyTransactions(w, n)
+
+		case n := <-lockNtfns.C:
+			notify(s.subscribers(types.EventLockState),
+				btcjson.NewWalletLockStateNtfn(n.Locked))
+
+		case n := <-rescanNtfns.C:
+			var ntfn interface{}
+			if n.Finished {
+				ntfn = btcjson.NewRescanFinishedNtfn(
+					n.Hash.String(), n.Height, n.Time.Unix(),
+				)
+			} else {
+				ntfn = btcjson.NewRescanProgressNtfn(
+					n.Hash.String(), n.Height, n.Time.Unix(),
+				)
+			}
+			notify(s.subscribers(types.EventRescan), ntfn)
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyTransactions sends the newtx, txconfirmed and accountbalance
+// notifications of a transaction notification of the wallet.
+//
+// The wallet is unable to lock or unlock until this returns, so it must not
+// wait on the lock state of the wallet.
+func (s *Server) notifyTransactions(w *wallet.Wallet,
+	n *wallet.TransactionNotifications) {
+
+	if clients := s.subscribers(types.EventTransactions); len(clients) != 0 {
+		var txs []wallet.TransactionSummary
+		txs = append(txs, n.UnminedTransactions...)
+		for _, b := range n.AttachedBlocks {
+			txs = append(txs, b.Transactions...)
+		}
+		for _, tx := range txs {
+			results, err := w.ListTransactionDetails(tx.Hash)
+			if err != nil {
+				log.Errorf("Unable to notify transaction %v: %v",
+					tx.Hash, err)
+				continue
+			}
+			for _, result := range results {
+				notify(clients, btcjson.NewNewTxNtfn(
+					result.Account, result,
+				))
+			}
+		}
+	}
+
+	for _, wsc := range s.subscribers(types.EventConfirmations) {
+		for _, ntfn := range wsc.subs.confirm(n) {
+			notify([]*websocketClient{wsc}, ntfn)
+		}
+	}
+
+	if clients := s.subscribers(types.EventBalances); len(clients) != 0 {
+		for _, bal := range n.NewBalances {
+			name, err := w.AccountName(bal.KeyScope, bal.Account)
+			if err != nil {
+				log.Errorf("Unable to notify balance of account "+
+					"%d of scope %v: %v", bal.Account,
+					bal.KeyScope, err)
+				continue
+			}
+			notify(clients, btcjson.NewAccountBalanceNtfn(
+				name, bal.TotalBalance.ToBTC(), false,
+			))
+		}
+	}
+}
+
+// queueNotification queues a marshaled notification to be sent to the client.
+// Notifications are dropped once the client disconnects.
+func (c *websocketClient) queueNotification(b []byte) {
+	select {
+	case c.ntfns <- b:
+	case <-c.closing:
+	case <-c.quit:
+	}
+}
+
+// maxQueuedNotifications is the maximum number of notifications queued for a
+// websocket client.  Clients falling further behind are disconnected.
+const maxQueuedNotifications = 1000
+
+// websocketClientNotify sends the notifications queued for a websocket client
+// in the order they were queued.  Queuing never waits for the client, so slow
+// clients never hold up the notifications of the wallet or of other clients,
+// but a client which stops reading is disconnected once its queue is full.
+func (s *Server) websocketClientNotify(wsc *websocketClient) {
+	defer wsc.wg.Done()
+
+	var queue [][]byte
+	for {
+		var next []byte
+		var out chan<- []byte
+		if len(queue) != 0 {
+			next, out = queue[0], wsc.responses
+		}
+
+		select {
+		case b := <-wsc.ntfns:
+			if len(queue) == maxQueuedNotifications {
+				log.Warnf("Disconnecting websocket client %s "+
+					"which is not reading notifications",
+					wsc.remoteAddr)
+				wsc.conn.Close()
+				return
+			}
+			queue = append(queue, b)
+
+		case out <- next:
+			queue[0] = nil
+			queue = queue[1:]
+
+		case <-wsc.closing:
+			return
+
+		case <-wsc.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc/types"
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/websocket"
+)
+
+// TestHandleSubscription tests that subscribe and unsubscribe requests update
+// the subscriptions of a client and return its subscribed events.
+func TestHandleSubscription(t *testing.T) {
+	t.Parallel()
+
+	wsc := newWebsocketClient(nil, true, rpcauth.RoleReadOnly, "")
+
+	request := func(method string, params ...interface{}) *btcjson.Request {
+		t.Helper()
+
+		req, err := btcjson.NewRequest(btcjson.RpcVersion1, 1, method,
+			params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		req    *btcjson.Request
+		events []string
+		err    bool
+	}{
+		{
+			req:    request("subscribe", []string{"lockstate", "balances"}),
+			events: []string{"balances", "lockstate"},
+		},
+		{
+			req:    request("subscribe", []string{"confirmations"}, 3),
+			events: []string{"balances", "confirmations", "lockstate"},
+		},
+		{
+			req: request("subscribe", []string{"blocks"}),
+			err: true,
+		},
+		{
+			req: request("subscribe", []string{"confirmations"}, 0),
+			err: true,
+		},
+		{
+			req:    request("unsubscribe", []string{"lockstate", "rescan"}),
+			events: []string{"balances", "confirmations"},
+		},
+	}
+	for i, test := range tests {
+		result, jsonErr := handleSubscription(wsc, test.req)
+		if test.err {
+			if jsonErr == nil {
+				t.Errorf("test %d: no error", i)
+			}
+			continue
+		}
+		if jsonErr != nil {
+			t.Errorf("test %d: %v", i, jsonErr)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.events) {
+			t.Errorf("test %d: got events %v, want %v", i, result,
+				test.events)
+		}
+	}
+	if wsc.subs.confirmations != 3 {
+		t.Errorf("got %d confirmations, want 3", wsc.subs.confirmations)
+	}
+}
+
+// TestConfirm tests that txconfirmed notifications are returned when mined
+// transactions reach the confirmations of the subscription, and not for
+// transactions of detached blocks.
+func TestConfirm(t *testing.T) {
+	t.Parallel()
+
+	var subs subscriptions
+	subs.subscribe([]string{types.EventConfirmations}, 2)
+
+	block := func(height int32, txs ...chainhash.Hash) wallet.Block {
+		b := wallet.Block{
+			Hash:   &chainhash.Hash{byte(height)},
+			Height: height,
+		}
+		for i := range txs {
+			b.Transactions = append(b.Transactions,
+				wallet.TransactionSummary{Hash: &txs[i]})
+		}
+		return b
+	}
+	tx1, tx2 := chainhash.Hash{0xa1}, chainhash.Hash{0xa2}
+
+	tests := []struct {
+		n    wallet.TransactionNotifications
+		want []interface{}
+	}{
+		{
+			n: wallet.TransactionNotifications{
+				AttachedBlocks: []wallet.Block{
+					block(100, tx1), block(101, tx2),
+				},
+			},
+			want: []interface{}{
+				types.NewTxConfirmedNtfn(tx1.String(),
+					block(100).Hash.String(), 100, 2),
+			},
+		},
+		{
+			// The block of tx2 is replaced by a block without it.
+			n: wallet.TransactionNotifications{
+				DetachedBlocks: []*chainhash.Hash{block(101).Hash},
+				AttachedBlocks: []wallet.Block{block(101)},
+			},
+		},
+		{
+			n: wallet.TransactionNotifications{
+				AttachedBlocks: []wallet.Block{
+					block(102, tx2), block(103),
+				},
+			},
+			want: []interface{}{
+				types.NewTxConfirmedNtfn(tx2.String(),
+					block(102).Hash.String(), 102, 2),
+			},
+		},
+	}
+	for i, test := range tests {
+		got := subs.confirm(&test.n)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test %d: got %v, want %v", i, got, test.want)
+		}
+	}
+	if len(subs.unconfirmed) != 0 {
+		t.Errorf("unconfirmed transactions remain: %v", subs.unconfirmed)
+	}
+}
+
+// TestWebsocketNotifications tests that notifications are only sent to the
+// websocket clients subscribed to their events.
+func TestWebsocketNotifications(t *testing.T) {
+	t.Parallel()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(&Options{
+		Username:            "user",
+		Password:            "pass",
+		MaxPOSTClients:      1,
+		MaxWebsocketClients: 2,
+	}, nil, []net.Listener{lis})
+	defer s.Stop()
+
+	dial := func() *websocket.Conn {
+		t.Helper()
+
+		header := http.Header{}
+		header.Set("Authorization", "Basic "+
+			base64.StdEncoding.EncodeToString([]byte("user:pass")))
+		conn, _, err := (&websocket.Dialer{}).Dial(
+			"ws://"+lis.Addr().String()+"/ws", header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	read := func(conn *websocket.Conn) map[string]interface{} {
+		t.Helper()
+
+		err := conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]interface{}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	subscribed, other := dial(), dial()
+	defer subscribed.Close()
+	defer other.Close()
+
+	cmd := types.NewSubscribeCmd([]string{types.EventLockState}, nil)
+	req, err := btcjson.MarshalCmd(btcjson.RpcVersion1, 1, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := subscribed.WriteMessage(websocket.TextMessage, req); err != nil {
+		t.Fatal(err)
+	}
+	resp := read(subscribed)
+	if !reflect.DeepEqual(resp["result"], []interface{}{"lockstate"}) {
+		t.Fatalf("unexpected subscribe response %v", resp)
+	}
+
+	notify(s.subscribers(types.EventLockState),
+		btcjson.NewWalletLockStateNtfn(true))
+	notify(s.subscribers(types.EventBalances),
+		btcjson.NewAccountBalanceNtfn("default", 1, false))
+	notify(s.subscribers(types.EventLockState),
+		btcjson.NewWalletLockStateNtfn(false))
+
+	for _, locked := range []bool{true, false} {
+		ntfn := read(subscribed)
+		if ntfn["method"] != btcjson.WalletLockStateNtfnMethod ||
+			ntfn["id"] != nil ||
+			!reflect.DeepEqual(ntfn["params"], []interface{}{locked}) {
+
+			msg, _ := json.Marshal(ntfn)
+			t.Fatalf("unexpected notification %s", msg)
+		}
+	}
+
+	err = other.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, msg, err := other.ReadMessage(); err == nil {
+		t.Fatalf("unsubscribed client received %s", msg)
+	}
+}
+
+// TestWebsocketNotificationOverflow tests that a websocket client is
+// disconnected once its queue of notifications is full.
+func TestWebsocketNotificationOverflow(t *testing.T) {
+	t.Parallel()
+
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conns <- conn
+		},
+	))
+	defer srv.Close()
+
+	client, _, err := (&websocket.Dialer{}).Dial(
+		"ws://"+srv.Listener.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Responses are never read from the client, as if it stopped reading
+	// from its connection.
+	wsc := newWebsocketClient(<-conns, true, rpcauth.RoleReadOnly, "")
+	wsc.wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		(&Server{}).websocketClientNotify(wsc)
+		close(done)
+	}()
+
+	for i := 0; i < maxQueuedNotifications; i++ {
+		wsc.queueNotification([]byte("{}"))
+	}
+	select {
+	case <-done:
+		t.Fatal("client disconnected with a full queue")
+	default:
+	}
+
+	wsc.queueNotification([]byte("{}"))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client not disconnected on overflow")
+	}
+
+	err = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.ReadMessage(); err == nil {
+		t.Fatal("connection of the client not closed")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import "github.com/btcsuite/btcwallet/rpc/rpcauth"
+
+// methodRoles maps the methods which do not require the admin role to the
+// role they require.  Every other method, including stop and the methods
+// passed through to the chain server, requires the admin role.
+var methodRoles = map[string]rpcauth.Role{
+	// Methods which do not modify the wallet or reveal secrets.
+	"analyzepsbt":             rpcauth.RoleReadOnly,
+	"combinepsbt":             rpcauth.RoleReadOnly,
+	"convertpsbt":             rpcauth.RoleReadOnly,
+	"createmultisig":          rpcauth.RoleReadOnly,
+	"decodepsbt":              rpcauth.RoleReadOnly,
+	"finalizepsbt":            rpcauth.RoleReadOnly,
+	"getaccount":              rpcauth.RoleReadOnly,
+	"getaddressesbyaccount":   rpcauth.RoleReadOnly,
+	"getbalance":              rpcauth.RoleReadOnly,
+	"getbestblock":            rpcauth.RoleReadOnly,
+	"getbestblockhash":        rpcauth.RoleReadOnly,
+	"getblockcount":           rpcauth.RoleReadOnly,
+	"getinfo":                 rpcauth.RoleReadOnly,
+	"getinvoice":              rpcauth.RoleReadOnly,
+	"getreceivedbyaccount":    rpcauth.RoleReadOnly,
+	"getreceivedbyaddress":    rpcauth.RoleReadOnly,
+	"gettransaction":          rpcauth.RoleReadOnly,
+	"getunconfirmedbalance":   rpcauth.RoleReadOnly,
+	"getwalletinfo":           rpcauth.RoleReadOnly,
+	"help":                    rpcauth.RoleReadOnly,
+	"listaccounts":            rpcauth.RoleReadOnly,
+	"listaddressgroupings":    rpcauth.RoleReadOnly,
+	"listaddresstransactions": rpcauth.RoleReadOnly,
+	"listalltransactions":     rpcauth.RoleReadOnly,
+	"listinvoices":            rpcauth.RoleReadOnly,
+	"listlockunspent":         rpcauth.RoleReadOnly,
+	"listreceivedbyaccount":   rpcauth.RoleReadOnly,
+	"listreceivedbyaddress":   rpcauth.RoleReadOnly,
+	"listrescans":             rpcauth.RoleReadOnly,
+	"listsinceblock":          rpcauth.RoleReadOnly,
+	"listtransactions":        rpcauth.RoleReadOnly,
+	"listunspent":             rpcauth.RoleReadOnly,
+	"subscribe":               rpcauth.RoleReadOnly,
+	"unsubscribe":             rpcauth.RoleReadOnly,
+	"utxoupdatepsbt":          rpcauth.RoleReadOnly,
+	"validateaddress":         rpcauth.RoleReadOnly,
+	"verifymessage":           rpcauth.RoleReadOnly,
+	"verifyreserveproof":      rpcauth.RoleReadOnly,
+	"walletislocked":          rpcauth.RoleReadOnly,
+
+	// Methods which create addresses and invoices, or sign and send
+	// transactions.
+	"createinvoice":          rpcauth.RoleSpend,
+	"createreserveproof":     rpcauth.RoleSpend,
+	"getaccountaddress":      rpcauth.RoleSpend,
+	"getnewaddress":          rpcauth.RoleSpend,
+	"getrawchangeaddress":    rpcauth.RoleSpend,
+	"lockunspent":            rpcauth.RoleSpend,
+	"sendfrom":               rpcauth.RoleSpend,
+	"sendmany":               rpcauth.RoleSpend,
+	"sendpayjoin":            rpcauth.RoleSpend,
+	"sendtoaddress":          rpcauth.RoleSpend,
+	"settxfee":               rpcauth.RoleSpend,
+	"signmessage":            rpcauth.RoleSpend,
+	"signrawtransaction":     rpcauth.RoleSpend,
+	"walletcreatefundedpsbt": rpcauth.RoleSpend,
+	"walletlock":             rpcauth.RoleSpend,
+	"walletpassphrase":       rpcauth.RoleSpend,
+	"walletprocesspsbt":      rpcauth.RoleSpend,
+}
+
+// methodRole returns the role required to call a method.
+func methodRole(method string) rpcauth.Role {
+	role, ok := methodRoles[method]
+	if !ok {
+		return rpcauth.RoleAdmin
+	}
+	return role
+}
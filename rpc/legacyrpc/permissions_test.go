@@ -0,0 +1,56 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
+)
+
+// TestMethodRoles tests that every method with a role is a wallet method.
+func TestMethodRoles(t *testing.T) {
+	t.Parallel()
+
+	for method := range methodRoles {
+		if _, ok := rpcHandlers[method]; !ok {
+			t.Errorf("role of unknown method %q", method)
+		}
+	}
+}
+
+// TestHandlerClosureRole tests that handlers of methods not permitted for the
+// role of the client are denied.
+func TestHandlerClosureRole(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+
+	tests := []struct {
+		method string
+		role   rpcauth.Role
+		denied bool
+	}{
+		{"getbalance", rpcauth.RoleReadOnly, false},
+		{"sendtoaddress", rpcauth.RoleReadOnly, true},
+		{"sendtoaddress", rpcauth.RoleSpend, false},
+		{"dumpprivkey", rpcauth.RoleSpend, true},
+		{"dumpprivkey", rpcauth.RoleAdmin, false},
+		{"getblock", rpcauth.RoleSpend, true},
+		{"getblock", rpcauth.RoleAdmin, false},
+	}
+
+	for _, test := range tests {
+		req := &btcjson.Request{Method: test.method}
+		_, jsonErr := s.handlerClosure(req, test.role)()
+		denied := jsonErr != nil &&
+			jsonErr.Message == ErrMethodNotPermitted.Message
+		if denied != test.denied {
+			t.Errorf("%s with %v role: denied %v, want %v",
+				test.method, test.role, denied, test.denied)
+		}
+	}
+}
@@ -4,9 +4,13 @@ package legacyrpc
 
 func helpDescsEnUS() map[string]string {
 	return map[string]string{
-		"addmultisigaddress":      "addmultisigaddress nrequired [\"key\",...] (\"account\")\n\nGenerates and imports a multisig address and redeeming script to the 'imported' account.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n3. account   (string, optional)          DEPRECATED -- Unused (all imported addresses belong to the imported account)\n\nResult:\n\"value\" (string) The imported pay-to-script-hash address\n",
-		"createmultisig":          "createmultisig nrequired [\"key\",...]\n\nGenerate a multisig address and redeem script.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n\nResult:\n{\n \"address\": \"value\",      (string) The generated pay-to-script-hash address\n \"redeemScript\": \"value\", (string) The script required to redeem outputs paid to the multisig address\n}                         \n",
+		"addmultisigaddress":      "addmultisigaddress nrequired [\"key\",...] (\"account\")\n\nGenerates and imports a multisig address and redeeming script to the 'imported' account.\nAn optional address_type parameter following the account selects a 'legacy' (default), 'p2sh-segwit' or 'bech32' multisig address.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n3. account   (string, optional)          DEPRECATED -- Unused (all imported addresses belong to the imported account)\n\nResult:\n\"value\" (string) The imported pay-to-script-hash or pay-to-witness-script-hash address\n",
+		"analyzepsbt":             "analyzepsbt \"psbt\"\n\nAnalyzes a PSBT and reports the next BIP 174 role required to process each input and the whole packet.\n\nArguments:\n1. psbt (string, required) The base64 encoded PSBT\n\nResult:\n{\n \"inputs\": [{                (array of object) The analysis of every input\n  \"has_utxo\": true|false,    (boolean)         Whether the UTXO spent by the input is known\n  \"is_final\": true|false,    (boolean)         Whether the input is finalized\n  \"next\": \"value\",           (string)          The next role required to process the input, unless it is finalized\n },...],                                       \n \"estimated_vsize\": n,       (numeric)         The virtual size of the finalized transaction, only known once every input is signed\n \"estimated_feerate\": n.nnn, (numeric)         The fee rate of the finalized transaction in BTC/kvB, only known once every input is signed\n \"fee\": n.nnn,               (numeric)         The fee paid by the transaction in BTC, only known once every input has UTXO information\n \"next\": \"value\",            (string)          The next role required to process the PSBT (updater, signer, finalizer, extractor or creator if the PSBT is invalid)\n \"error\": \"value\",           (string)          The reason the PSBT is invalid\n}                            \n",
+		"combinepsbt":             "combinepsbt [\"tx\",...]\n\nCombines several PSBTs of the same transaction into one PSBT, merging their signatures and other fields. The result uses the version of the first PSBT.\n\nArguments:\n1. txs (array of string, required) The base64 encoded PSBTs to combine\n\nResult:\n\"value\" (string) The combined PSBT encoded as base64\n",
+		"createmultisig":          "createmultisig nrequired [\"key\",...]\n\nGenerate a multisig address and redeem script.\nAn optional address_type parameter following the keys selects a 'legacy' (default), 'p2sh-segwit' or 'bech32' multisig address.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n\nResult:\n{\n \"address\": \"value\",      (string) The generated pay-to-script-hash or pay-to-witness-script-hash address\n \"redeemScript\": \"value\", (string) The script required to redeem outputs paid to the multisig address\n}                         \n",
+		"decodepsbt":              "decodepsbt \"psbt\"\n\nReturns a JSON object describing a PSBT. Both version 0 and version 2 PSBTs are accepted.\n\nArguments:\n1. psbt (string, required) The base64 encoded PSBT\n\nResult:\n{\n \"tx\": {                         (object)          The decoded unsigned transaction\n  \"txid\": \"value\",               (string)          The hash of the transaction\n  \"version\": n,                  (numeric)         The transaction version\n  \"locktime\": n,                 (numeric)         The transaction lock time\n  \"vin\": [{                      (array of object) The transaction inputs\n   \"coinbase\": \"value\",          (string)          The hex encoded signature script of a coinbase input\n   \"txid\": \"value\",              (string)          The hash of the transaction of the spent output\n   \"vout\": n,                    (numeric)         The index of the spent output\n   \"scriptSig\": {                (object)          The signature script of the input\n    \"asm\": \"value\",              (string)          Disassembly of the script\n    \"hex\": \"value\",              (string)          The hex encoded script\n   },                                              \n   \"sequence\": n,                (numeric)         The sequence number of the input\n   \"txinwitness\": [\"value\",...], (array of string) The hex encoded witness items of the input\n  },...],                                          \n  \"vout\": [{                     (array of object) The transaction outputs\n   \"value\": n.nnn,               (numeric)         The value of the output in BTC\n   \"n\": n,                       (numeric)         The index of the output\n   \"scriptPubKey\": {             (object)          The output script\n    \"asm\": \"value\",              (string)          Disassembly of the script\n    \"hex\": \"value\",              (string)          The hex encoded script\n    \"reqSigs\": n,                (numeric)         The number of signatures required to spend the output\n    \"type\": \"value\",             (string)          The type of the script\n    \"addresses\": [\"value\",...],  (array of string) The addresses paid by the script\n   },                                              \n  },...],                                          \n },                                                \n \"unknown\": {                    (object)          The unknown global fields\n  \"key\": value, (object) The hex encoded value of the hex encoded key\n  ...\n }\n \"psbt_version\": n,               (numeric)         The version of the PSBT, 0 (BIP0174) or 2 (BIP0370)\n \"inputs\": [{                     (array of object) The fields of every input\n  \"non_witness_utxo\": {           (object)          The decoded transaction whose output the input spends\n   \"txid\": \"value\",               (string)          The hash of the transaction\n   \"version\": n,                  (numeric)         The transaction version\n   \"locktime\": n,                 (numeric)         The transaction lock time\n   \"vin\": [{                      (array of object) The transaction inputs\n    \"coinbase\": \"value\",          (string)          The hex encoded signature script of a coinbase input\n    \"txid\": \"value\",              (string)          The hash of the transaction of the spent output\n    \"vout\": n,                    (numeric)         The index of the spent output\n    \"scriptSig\": {                (object)          The signature script of the input\n     \"asm\": \"value\",              (string)          Disassembly of the script\n     \"hex\": \"value\",              (string)          The hex encoded script\n    },                                              \n    \"sequence\": n,                (numeric)         The sequence number of the input\n    \"txinwitness\": [\"value\",...], (array of string) The hex encoded witness items of the input\n   },...],                                          \n   \"vout\": [{                     (array of object) The transaction outputs\n    \"value\": n.nnn,               (numeric)         The value of the output in BTC\n    \"n\": n,                       (numeric)         The index of the output\n    \"scriptPubKey\": {             (object)          The output script\n     \"asm\": \"value\",              (string)          Disassembly of the script\n     \"hex\": \"value\",              (string)          The hex encoded script\n     \"reqSigs\": n,                (numeric)         The number of signatures required to spend the output\n     \"type\": \"value\",             (string)          The type of the script\n     \"addresses\": [\"value\",...],  (array of string) The addresses paid by the script\n    },                                              \n   },...],                                          \n  },                                                \n  \"witness_utxo\": {               (object)          The output the input spends\n   \"amount\": n.nnn,               (numeric)         The value of the output in BTC\n   \"scriptPubKey\": {              (object)          The output script\n    \"asm\": \"value\",               (string)          Disassembly of the script\n    \"hex\": \"value\",               (string)          The hex encoded script\n    \"reqSigs\": n,                 (numeric)         The number of signatures required to spend the output\n    \"type\": \"value\",              (string)          The type of the script\n    \"addresses\": [\"value\",...],   (array of string) The addresses paid by the script\n   },                                               \n  },                                                \n  \"partial_signatures\": {         (object)          The partial signatures of the input\n   \"pubkey\": signature, (object) The hex encoded signature of the hex encoded public key\n   ...\n  }\n  \"sighash\": \"value\",                   (string)          The sighash type the input is to be signed with\n  \"redeem_script\": {                    (object)          The redeem script of the input\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex encoded script\n   \"type\": \"value\",                     (string)          The type of the script\n  },                                                      \n  \"witness_script\": {                   (object)          The witness script of the input\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex encoded script\n   \"type\": \"value\",                     (string)          The type of the script\n  },                                                      \n  \"bip32_derivs\": [{                    (array of object) The BIP 32 derivation paths of the keys of the input\n   \"pubkey\": \"value\",                   (string)          The hex encoded public key\n   \"master_fingerprint\": \"value\",       (string)          The fingerprint of the master key\n   \"path\": \"value\",                     (string)          The derivation path of the key\n  },...],                                                 \n  \"final_scriptSig\": {                  (object)          The final signature script of the input\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex encoded script\n  },                                                      \n  \"final_scriptwitness\": [\"value\",...], (array of string) The hex encoded items of the final witness of the input\n  \"unknown\": {                          (object)          The unknown fields of the input\n   \"key\": value, (object) The hex encoded value of the hex encoded key\n   ...\n  }\n },...],                                            \n \"outputs\": [{                    (array of object) The fields of every output\n  \"redeem_script\": {              (object)          The redeem script of the output\n   \"asm\": \"value\",                (string)          Disassembly of the script\n   \"hex\": \"value\",                (string)          The hex encoded script\n   \"type\": \"value\",               (string)          The type of the script\n  },                                                \n  \"witness_script\": {             (object)          The witness script of the output\n   \"asm\": \"value\",                (string)          Disassembly of the script\n   \"hex\": \"value\",                (string)          The hex encoded script\n   \"type\": \"value\",               (string)          The type of the script\n  },                                                \n  \"bip32_derivs\": [{              (array of object) The BIP 32 derivation paths of the keys of the output\n   \"pubkey\": \"value\",             (string)          The hex encoded public key\n   \"master_fingerprint\": \"value\", (string)          The fingerprint of the master key\n   \"path\": \"value\",               (string)          The derivation path of the key\n  },...],                                           \n },...],                                            \n \"fee\": n.nnn,                    (numeric)         The fee paid by the transaction in BTC, if every input has UTXO information\n}                                 \n",
 		"dumpprivkey":             "dumpprivkey \"address\"\n\nReturns the private key in WIF encoding that controls some wallet address.\n\nArguments:\n1. address (string, required) The address to return a private key for\n\nResult:\n\"value\" (string) The WIF-encoded private key\n",
+		"finalizepsbt":            "finalizepsbt \"psbt\" (extract=true)\n\nFinalizes the inputs of a PSBT which have all required signatures. If every input is finalized and extract is set, the network serialized transaction is returned.\n\nArguments:\n1. psbt    (string, required)                The base64 encoded PSBT\n2. extract (boolean, optional, default=true) Whether to extract the transaction if the PSBT is complete\n\nResult:\n{\n \"psbt\": \"value\",        (string)  The base64 encoded PSBT, unless the transaction was extracted\n \"hex\": \"value\",         (string)  The hex encoded network serialized transaction, if it was extracted\n \"complete\": true|false, (boolean) Whether every input is finalized\n}                        \n",
 		"getaccount":              "getaccount \"address\"\n\nDEPRECATED -- Lookup the account name that some wallet address belongs to.\n\nArguments:\n1. address (string, required) The address to query the account for\n\nResult:\n\"value\" (string) The name of the account that 'address' belongs to\n",
 		"getaccountaddress":       "getaccountaddress \"account\"\n\nDEPRECATED -- Returns the most recent external payment address for an account that has not been seen publicly.\nA new address is generated for the account if the most recently generated address has been seen on the blockchain or in mempool.\n\nArguments:\n1. account (string, required) The account of the returned address\n\nResult:\n\"value\" (string) The unused address for 'account'\n",
 		"getaddressesbyaccount":   "getaddressesbyaccount \"account\"\n\nDEPRECATED -- Returns all addresses strings controlled by a single account.\n\nArguments:\n1. account (string, required) Account name to fetch addresses for\n\nResult:\n[\"value\",...] (array of string) All addresses controlled by 'account'\n",
@@ -34,20 +38,38 @@ func helpDescsEnUS() map[string]string {
 		"sendmany":                "sendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\n\nAuthors, signs, and sends a transaction that outputs to many payment addresses.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. fromaccount (string, required) DEPRECATED -- Account to pick unspent outputs from\n2. amounts     (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n4. comment (string, optional)             Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
 		"sendtoaddress":           "sendtoaddress \"address\" amount (\"comment\" \"commentto\")\n\nAuthors, signs, and sends a transaction that outputs some amount to a payment address.\nUnlike sendfrom, outputs are always chosen from the default account.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. address   (string, required)  Address to pay\n2. amount    (numeric, required) Amount to send to the payment address valued in bitcoin\n3. comment   (string, optional)  Unused\n4. commentto (string, optional)  Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
 		"settxfee":                "settxfee amount\n\nModify the increment used each time more fee is required for an authored transaction.\n\nArguments:\n1. amount (numeric, required) The new fee increment valued in bitcoin\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
-		"signmessage":             "signmessage \"address\" \"message\"\n\nSigns a message using the private key of a payment address.\n\nArguments:\n1. address (string, required) Payment address of private key used to sign the message with\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The signed message encoded as a base64 string\n",
+		"signmessage":             "signmessage \"address\" \"message\"\n\nSigns a message using the private key of a payment address.\nP2PKH addresses create a legacy compact signature, P2WKH addresses a BIP0322 simple signature and nested P2WKH addresses a BIP0322 full signature.\n\nArguments:\n1. address (string, required) Payment address of private key used to sign the message with\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The signed message encoded as a base64 string\n",
 		"signrawtransaction":      "signrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\n\nSigns transaction inputs using private keys from this wallet and request.\nThe valid flags options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx    (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs   (array of object, optional)       Additional data regarding inputs that this wallet may not be tracking\n3. privkeys (array of string, optional)       Additional WIF-encoded private keys to use when creating signatures\n4. flags    (string, optional, default=\"ALL\") Sighash flags\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
+		"utxoupdatepsbt":          "utxoupdatepsbt \"psbt\"\n\nAdds the UTXO information and redeem scripts known to the wallet to the inputs of a PSBT which spend wallet outputs.\n\nArguments:\n1. psbt (string, required) The base64 encoded PSBT\n\nResult:\n\"value\" (string) The updated PSBT encoded as base64\n",
 		"validateaddress":         "validateaddress \"address\"\n\nVerify that an address is valid.\nExtra details are returned if the address is controlled by this wallet.\nThe following fields are valid only when the address is controlled by this wallet (ismine=true): isscript, pubkey, iscompressed, account, addresses, hex, script, and sigsrequired.\nThe following fields are only valid when address has an associated public key: pubkey, iscompressed.\nThe following fields are only valid when address is a pay-to-script-hash address: addresses, hex, and script.\nIf the address is a multisig address controlled by this wallet, the multisig fields will be left unset if the wallet is locked since the redeem script cannot be decrypted.\n\nArguments:\n1. address (string, required) Address to validate\n\nResult:\n{\n \"isvalid\": true|false,      (boolean)         Whether or not the address is valid\n \"address\": \"value\",         (string)          The payment address (only when isvalid is true)\n \"ismine\": true|false,       (boolean)         Whether this address is controlled by the wallet (only when isvalid is true)\n \"iswatchonly\": true|false,  (boolean)         Unset\n \"isscript\": true|false,     (boolean)         Whether the payment address is a pay-to-script-hash address (only when isvalid is true)\n \"pubkey\": \"value\",          (string)          The associated public key of the payment address, if any (only when isvalid is true)\n \"iscompressed\": true|false, (boolean)         Whether the address was created by hashing a compressed public key, if any (only when isvalid is true)\n \"account\": \"value\",         (string)          The account this payment address belongs to (only when isvalid is true)\n \"addresses\": [\"value\",...], (array of string) All associated payment addresses of the script if address is a multisig address (only when isvalid is true)\n \"hex\": \"value\",             (string)          The redeem script \n \"script\": \"value\",          (string)          The class of redeem script for a multisig address\n \"sigsrequired\": n,          (numeric)         The number of required signatures to redeem outputs to the multisig address\n}                            \n",
-		"verifymessage":           "verifymessage \"address\" \"signature\" \"message\"\n\nVerify a message was signed with the associated private key of some address.\n\nArguments:\n1. address   (string, required) Address used to sign message\n2. signature (string, required) The signature to verify\n3. message   (string, required) The message to verify\n\nResult:\ntrue|false (boolean) Whether the message was signed with the private key of 'address'\n",
+		"verifymessage":           "verifymessage \"address\" \"signature\" \"message\"\n\nVerify a message was signed with the associated private key of some address.\nLegacy compact signatures are accepted for P2PKH addresses, and BIP0322 simple and full signatures for any address.\n\nArguments:\n1. address   (string, required) Address used to sign message\n2. signature (string, required) The signature to verify\n3. message   (string, required) The message to verify\n\nResult:\ntrue|false (boolean) Whether the message was signed with the private key of 'address'\n",
+		"walletcreatefundedpsbt":  "walletcreatefundedpsbt [{\"txid\":\"value\",\"vout\":n,\"sequence\":n},...] [output,...] (locktime {\"changeaddress\":changeaddress,\"changeposition\":changeposition,\"changetype\":changetype,\"includewatching\":includewatching,\"lockunspents\":lockunspents,\"feerate\":feerate,\"subtractfeefromoutputs\":subtractfeefromoutputs,\"replaceable\":replaceable,\"conftarget\":conftarget,\"estimatemode\":estimatemode} bip32derivs)\n\nCreates a PSBT paying to the given outputs, funded by outputs of the default account and with a change output if necessary. The inputs and outputs are sorted according to BIP 69. The inputs are leased for 10 minutes so they aren't spent by other transactions.\nAn optional psbt_version parameter following bip32derivs selects the version of the returned PSBT, 0 (default) or 2.\n\nArguments:\n1. inputs (array of object, required) The outputs to spend. If empty, inputs are selected by the wallet\n[{\n \"txid\": \"value\", (string)  The hash of the transaction of the output to spend\n \"vout\": n,       (numeric) The index of the output to spend\n \"sequence\": n,   (numeric) The sequence number of the input, or 0 for the default\n},...]\n2. outputs  (array of object, required) The outputs to pay to, each an object mapping an address to an amount in BTC, or 'data' to hex encoded data of a null data output\n3. locktime (numeric, optional)         The lock time of the transaction\n4. options  (object, optional)          Additional options\n{\n \"changeAddress\": \"value\",          (string)           Unsupported, the change address is chosen by the wallet\n \"changePosition\": n,               (numeric)          Unsupported, the change position follows from the BIP 69 order\n \"change_type\": \"value\",            (string)           Unsupported, the change output type is chosen by the wallet\n \"includeWatching\": true|false,     (boolean)          Ignored\n \"lockUnspents\": true|false,        (boolean)          Whether to also lock the selected outputs with lockunspent\n \"feeRate\": n.nnn,                  (numeric)          The fee rate in BTC/kvB\n \"subtractFeeFromOutputs\": [n,...], (array of numeric) Unsupported\n \"replaceable\": true|false,         (boolean)          Whether the inputs signal replaceability (BIP 125)\n \"conf_target\": n,                  (numeric)          Unsupported, use feeRate\n \"estimate_mode\": \"value\",          (string)           Unsupported, use feeRate\n}                                   \n5. bip32derivs (boolean, optional) Whether to include the BIP 32 derivation paths of wallet keys (default: true)\n\nResult:\n{\n \"psbt\": \"value\", (string)  The funded PSBT encoded as base64\n \"fee\": n.nnn,    (numeric) The fee paid by the transaction in BTC\n \"changepos\": n,  (numeric) The index of the change output, or -1 if there is none\n}                 \n",
 		"walletlock":              "walletlock\n\nLock the wallet.\n\nArguments:\nNone\n\nResult:\nNothing\n",
 		"walletpassphrase":        "walletpassphrase \"passphrase\" timeout\n\nUnlock the wallet.\n\nArguments:\n1. passphrase (string, required)  The wallet passphrase\n2. timeout    (numeric, required) The number of seconds to wait before the wallet automatically locks\n\nResult:\nNothing\n",
 		"walletpassphrasechange":  "walletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\n\nChange the wallet passphrase.\n\nArguments:\n1. oldpassphrase (string, required) The old wallet passphrase\n2. newpassphrase (string, required) The new wallet passphrase\n\nResult:\nNothing\n",
+		"walletprocesspsbt":       "walletprocesspsbt \"psbt\" (sign=true sighashtype=\"ALL\" bip32derivs)\n\nAdds the information known to the wallet to a PSBT and signs the inputs spending wallet outputs, without finalizing them. Version 2 PSBTs are returned as version 2.\n\nArguments:\n1. psbt        (string, required)                The base64 encoded PSBT\n2. sign        (boolean, optional, default=true) Whether to sign the inputs spending wallet outputs\n3. sighashtype (string, optional, default=\"ALL\") The sighash type of inputs without one; inputs with another sighash type are rejected\n4. bip32derivs (boolean, optional)               Whether to include the BIP 32 derivation paths of wallet keys (default: true)\n\nResult:\n{\n \"psbt\": \"value\",        (string)  The processed PSBT encoded as base64\n \"complete\": true|false, (boolean) Whether every input is signed\n}                        \n",
+		"cancelrescan":            "cancelrescan id\n\nCancels a rescan job so it is never resumed.\n\nArguments:\n1. id (numeric, required) The ID of the rescan job\n\nResult:\nNothing\n",
+		"convertpsbt":             "convertpsbt \"psbt\" (version=2)\n\nConverts a PSBT between version 0 (BIP0174) and version 2 (BIP0370).\nConverting to version 0 drops the required lock times of the inputs and the modifiable flags, as version 0 has no equivalent fields.\n\nArguments:\n1. psbt    (string, required)             The base64 encoded PSBT of either version\n2. version (numeric, optional, default=2) The version to convert to\n\nResult:\n\"value\" (string) The converted PSBT encoded as base64\n",
+		"createinvoice":           "createinvoice amount (memo=\"\" expiry=3600 account=\"default\")\n\nCreates an invoice requesting a payment to a new address of an account.\n\nArguments:\n1. amount  (numeric, required)                   The requested amount in bitcoin, or 0 to accept any amount\n2. memo    (string, optional, default=\"\")        A description of the invoice, included in its BIP21 URI\n3. expiry  (numeric, optional, default=3600)     The number of seconds after which the invoice expires if it is not fully paid, or 0 to never expire\n4. account (string, optional, default=\"default\") The account to reserve the invoice address from\n\nResult:\n{\n \"id\": n,            (numeric)         The ID of the invoice\n \"address\": \"value\", (string)          The address reserved for payments of the invoice\n \"account\": \"value\", (string)          The account of the invoice address\n \"amount\": n.nnn,    (numeric)         The requested amount in bitcoin\n \"received\": n.nnn,  (numeric)         The amount in bitcoin paid to the invoice address\n \"memo\": \"value\",    (string)          The description of the invoice\n \"created\": n,       (numeric)         The creation time of the invoice in seconds since 1 Jan 1970 GMT\n \"expiry\": n,        (numeric)         The expiry time of the invoice in seconds since 1 Jan 1970 GMT, omitted if the invoice never expires\n \"status\": \"value\",  (string)          The status of the invoice (unpaid, partial, paid, overpaid or expired)\n \"uri\": \"value\",     (string)          The BIP21 URI requesting payment of the invoice\n \"payments\": [{      (array of object) The outputs paying to the invoice address\n  \"txid\": \"value\",   (string)          The hash of the paying transaction\n  \"vout\": n,         (numeric)         The output index of the payment\n  \"amount\": n.nnn,   (numeric)         The amount of the payment in bitcoin\n },...],                               \n}                    \n",
 		"createnewaccount":        "createnewaccount \"account\"\n\nCreates a new account.\nThe wallet must be unlocked for this request to succeed.\n\nArguments:\n1. account (string, required) Name of the new account\n\nResult:\nNothing\n",
+		"createreserveproof":      "createreserveproof \"message\" ([{\"txid\":\"value\",\"vout\":n},...] [\"account\",...] minconf=1)\n\nCreates a BIP0127 proof of reserves for the given outputs and all outputs of the given accounts.\nThe proof is a PSBT of a transaction that can never be mined, as its first input commits to the message by spending a nonexistent output.\nInputs the wallet holds private keys for are signed and finalized, the remaining inputs must be signed by another wallet.\n\nArguments:\n1. message  (string, required)             The message to commit to, such as a challenge of the verifier\n2. outputs  (array of object, optional)    Wallet outputs to prove\n3. accounts (array of string, optional)    Accounts of the BIP0044 key scope whose outputs are all proven\n4. minconf  (numeric, optional, default=1) The minimum number of confirmations of the proven outputs\n\nResult:\n{\n \"psbt\": \"value\",        (string)  The proof encoded as a base64 PSBT\n \"amount\": n.nnn,        (numeric) The proven amount in BTC\n \"complete\": true|false, (boolean) Whether every proven output is signed\n}                        \n",
 		"exportwatchingwallet":    "exportwatchingwallet (\"account\" download=false)\n\nCreates and returns a duplicate of the wallet database without any private keys to be used as a watching-only wallet.\n\nArguments:\n1. account  (string, optional)                 Unused (must be unset or \"*\")\n2. download (boolean, optional, default=false) Unused\n\nResult:\n\"value\" (string) The watching-only database encoded as a base64 string\n",
 		"getbestblock":            "getbestblock\n\nReturns the hash and height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n{\n \"hash\": \"value\", (string)  The hash of the block\n \"height\": n,     (numeric) The blockchain height of the block\n}                 \n",
+		"getinvoice":              "getinvoice id\n\nReturns an invoice.\n\nArguments:\n1. id (numeric, required) The ID of the invoice\n\nResult:\n{\n \"id\": n,            (numeric)         The ID of the invoice\n \"address\": \"value\", (string)          The address reserved for payments of the invoice\n \"account\": \"value\", (string)          The account of the invoice address\n \"amount\": n.nnn,    (numeric)         The requested amount in bitcoin\n \"received\": n.nnn,  (numeric)         The amount in bitcoin paid to the invoice address\n \"memo\": \"value\",    (string)          The description of the invoice\n \"created\": n,       (numeric)         The creation time of the invoice in seconds since 1 Jan 1970 GMT\n \"expiry\": n,        (numeric)         The expiry time of the invoice in seconds since 1 Jan 1970 GMT, omitted if the invoice never expires\n \"status\": \"value\",  (string)          The status of the invoice (unpaid, partial, paid, overpaid or expired)\n \"uri\": \"value\",     (string)          The BIP21 URI requesting payment of the invoice\n \"payments\": [{      (array of object) The outputs paying to the invoice address\n  \"txid\": \"value\",   (string)          The hash of the paying transaction\n  \"vout\": n,         (numeric)         The output index of the payment\n  \"amount\": n.nnn,   (numeric)         The amount of the payment in bitcoin\n },...],                               \n}                    \n",
 		"getunconfirmedbalance":   "getunconfirmedbalance (\"account\")\n\nCalculates the unspent output value of all unmined transaction outputs for an account.\n\nArguments:\n1. account (string, optional) The account to query the unconfirmed balance for (default=\"default\")\n\nResult:\nn.nnn (numeric) Total amount of all unmined unspent outputs of the account valued in bitcoin.\n",
 		"listaddresstransactions": "listaddresstransactions [\"address\",...] (\"account\")\n\nReturns a JSON array of objects containing verbose details for wallet transactions pertaining some addresses.\n\nArguments:\n1. addresses (array of string, required) Addresses to filter transaction results by\n2. account   (string, optional)          Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
 		"listalltransactions":     "listalltransactions (\"account\")\n\nReturns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.\n\nArguments:\n1. account (string, optional) Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"listinvoices":            "listinvoices (\"status\")\n\nReturns every invoice, ordered by ID.\n\nArguments:\n1. status (string, optional) Only return invoices with this status (unpaid, partial, paid, overpaid or expired)\n\nResult:\n[{\n \"id\": n,            (numeric)         The ID of the invoice\n \"address\": \"value\", (string)          The address reserved for payments of the invoice\n \"account\": \"value\", (string)          The account of the invoice address\n \"amount\": n.nnn,    (numeric)         The requested amount in bitcoin\n \"received\": n.nnn,  (numeric)         The amount in bitcoin paid to the invoice address\n \"memo\": \"value\",    (string)          The description of the invoice\n \"created\": n,       (numeric)         The creation time of the invoice in seconds since 1 Jan 1970 GMT\n \"expiry\": n,        (numeric)         The expiry time of the invoice in seconds since 1 Jan 1970 GMT, omitted if the invoice never expires\n \"status\": \"value\",  (string)          The status of the invoice (unpaid, partial, paid, overpaid or expired)\n \"uri\": \"value\",     (string)          The BIP21 URI requesting payment of the invoice\n \"payments\": [{      (array of object) The outputs paying to the invoice address\n  \"txid\": \"value\",   (string)          The hash of the paying transaction\n  \"vout\": n,         (numeric)         The output index of the payment\n  \"amount\": n.nnn,   (numeric)         The amount of the payment in bitcoin\n },...],                               \n},...]\n",
+		"listrescans":             "listrescans\n\nReturns every rescan job which has not yet finished, including paused jobs.\n\nArguments:\nNone\n\nResult:\n[{\n \"id\": n,                 (numeric) The ID used to pause, resume or cancel the rescan job\n \"state\": \"value\",        (string)  The state of the rescan job (active or paused)\n \"running\": true|false,   (boolean) Whether the job is part of the rescan currently performed\n \"queued\": true|false,    (boolean) Whether the job waits for the current rescan to finish\n \"startheight\": n,        (numeric) The height of the block the rescan job started from\n \"starthash\": \"value\",    (string)  The hash of the block the rescan job started from\n \"progressheight\": n,     (numeric) The height of the last block the rescan job reported progress for, or 0 if no progress was made\n \"progresshash\": \"value\", (string)  The hash of the last block the rescan job reported progress for\n \"addresses\": n,          (numeric) The number of addresses rescanned for\n \"outpoints\": n,          (numeric) The number of outpoints watched for spends\n},...]\n",
+		"pauserescan":             "pauserescan id\n\nPauses a rescan job until it is resumed with resumerescan. A job paused while being rescanned stops recording progress, but the chain server completes the rescan.\n\nArguments:\n1. id (numeric, required) The ID of the rescan job\n\nResult:\nNothing\n",
 		"renameaccount":           "renameaccount \"oldaccount\" \"newaccount\"\n\nRenames an account.\n\nArguments:\n1. oldaccount (string, required) The old account name to rename\n2. newaccount (string, required) The new name for the account\n\nResult:\nNothing\n",
+		"rescanblockchain":        "rescanblockchain (startheight=0 stopheight)\n\nRescans the blocks of a height range for transactions relevant to the wallet's addresses and unspent outputs, returning once the rescan has passed the stop height.\n\nArguments:\n1. startheight (numeric, optional, default=0) The height of the first block to rescan\n2. stopheight  (numeric, optional)            The height of the last block to rescan (default: the best block)\n\nResult:\n{\n \"start_height\": n,                (numeric)         The height of the first rescanned block\n \"stop_height\": n,                 (numeric)         The height of the last rescanned block\n \"transactions\": [\"value\",...],    (array of string) The hashes of every wallet transaction mined in the rescanned blocks\n \"newtransactions\": [\"value\",...], (array of string) The hashes of the transactions which were found by the rescan\n}                                  \n",
+		"resumerescan":            "resumerescan id\n\nResumes a paused rescan job from the last block it reported progress for.\n\nArguments:\n1. id (numeric, required) The ID of the rescan job\n\nResult:\nNothing\n",
+		"sendpayjoin":             "sendpayjoin \"uri\" (amount account=\"default\" feerate minconf=1)\n\nPays a BIP0021 URI with a payjoin endpoint as described by BIP0078.\nThe original transaction is posted to the endpoint, and the proposal of the receiver is checked before it is signed and broadcast.\nThe change output may pay the fees of the inputs added by the receiver. If the receiver doesn't respond with a valid proposal, the original transaction is broadcast instead.\n\nArguments:\n1. uri     (string, required)                    The BIP0021 URI with a pj parameter\n2. amount  (numeric, optional)                   The amount to send in bitcoin, required if the URI has no amount\n3. account (string, optional, default=\"default\") The account to send from\n4. feerate (numeric, optional)                   The fee rate in bitcoin per kilobyte\n5. minconf (numeric, optional, default=1)        The minimum number of confirmations of the spent outputs\n\nResult:\n{\n \"txid\": \"value\",       (string)  The hash of the broadcast transaction\n \"payjoin\": true|false, (boolean) Whether the payjoin transaction was broadcast rather than the original transaction\n}                       \n",
+		"subscribe":               "subscribe [\"event\",...] (confirmations=6)\n\nSubscribes a websocket client to notifications of wallet events, returning every subscribed event.\nThe events are transactions (newtx notifications of relevant transactions when they are added to the wallet and when they are mined), confirmations (txconfirmed notifications of transactions mined while subscribed reaching the given number of confirmations), balances (accountbalance notifications of the total balances of accounts whose balance changed), lockstate (walletlockstate notifications when the wallet is locked or unlocked) and rescan (rescanprogress and rescanfinished notifications of the rescans performed by the wallet).\n\nArguments:\n1. events        (array of string, required)    The events to subscribe to\n2. confirmations (numeric, optional, default=6) The number of confirmations of the confirmations event\n\nResult:\n[\"value\",...] (array of string) Every subscribed event\n",
+		"sweepprivkey":            "sweepprivkey [\"privkey\",...] (startheight=0 account=\"default\" feerate)\n\nSpends every unspent output paying to the P2PKH, P2WPKH or P2SH-P2WPKH addresses of private keys to new internal addresses of an account, and broadcasts the transactions.\nThe outputs are found by filtering the blocks from the start height to the best block, and are split across as many transactions as needed to keep each within the standard size.\nThe keys are only used to sign the transactions and are never stored by the wallet.\n\nArguments:\n1. privkeys    (array of string, required)           The WIF encoded private keys to sweep\n2. startheight (numeric, optional, default=0)        The height of the first block to search for outputs, which should precede the first use of the keys\n3. account     (string, optional, default=\"default\") The account receiving the swept outputs\n4. feerate     (numeric, optional)                   The fee rate in bitcoin per kilobyte\n\nResult:\n{\n \"txids\": [\"value\",...], (array of string) The hashes of the broadcast transactions\n \"inputs\": n,            (numeric)         The number of swept outputs\n \"amount\": n.nnn,        (numeric)         The total amount received by the account in bitcoin, after the fees\n}                        \n",
+		"unsubscribe":             "unsubscribe [\"event\",...]\n\nUnsubscribes a websocket client from notifications of wallet events, returning every remaining subscribed event.\n\nArguments:\n1. events (array of string, required) The events to unsubscribe from\n\nResult:\n[\"value\",...] (array of string) Every remaining subscribed event\n",
+		"verifyreserveproof":      "verifyreserveproof \"psbt\" \"message\" (heighthint=0)\n\nVerifies a BIP0127 proof of reserves against the UTXO set of the chain backend.\n\nArguments:\n1. psbt       (string, required)             The proof encoded as a base64 PSBT\n2. message    (string, required)             The message the proof must commit to\n3. heighthint (numeric, optional, default=0) The height light clients scan the chain from for proven outputs without a height hint of the prover\n\nResult:\n{\n \"valid\": true|false, (boolean) Whether the proof is valid and all proven outputs are unspent\n \"amount\": n.nnn,     (numeric) The proven amount in BTC\n \"error\": \"value\",    (string)  The reason the proof is invalid\n}                     \n",
 		"walletislocked":          "walletislocked\n\nReturns whether or not the wallet is locked.\n\nArguments:\nNone\n\nResult:\ntrue|false (boolean) Whether the wallet is locked\n",
 	}
 }
@@ -56,4 +78,4 @@ var localeHelpDescs = map[string]func() map[string]string{
 	"en_US": helpDescsEnUS,
 }
 
-var requestUsages = "addmultisigaddress nrequired [\"key\",...] (\"account\")\ncreatemultisig nrequired [\"key\",...]\ndumpprivkey \"address\"\ngetaccount \"address\"\ngetaccountaddress \"account\"\ngetaddressesbyaccount \"account\"\ngetbalance (\"account\" minconf=1)\ngetbestblockhash\ngetblockcount\ngetinfo\ngetnewaddress (\"account\")\ngetrawchangeaddress (\"account\")\ngetreceivedbyaccount \"account\" (minconf=1)\ngetreceivedbyaddress \"address\" (minconf=1)\ngettransaction \"txid\" (includewatchonly=false)\nhelp (\"command\")\nimportprivkey \"privkey\" (\"label\" rescan=true)\nkeypoolrefill (newsize=100)\nlistaccounts (minconf=1)\nlistlockunspent\nlistreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\nlistreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\nlistsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\nlisttransactions (\"account\" count=10 from=0 includewatchonly=false)\nlistunspent (minconf=1 maxconf=9999999 [\"address\",...])\nlockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\nsendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\nsendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\nsendtoaddress \"address\" amount (\"comment\" \"commentto\")\nsettxfee amount\nsignmessage \"address\" \"message\"\nsignrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\nvalidateaddress \"address\"\nverifymessage \"address\" \"signature\" \"message\"\nwalletlock\nwalletpassphrase \"passphrase\" timeout\nwalletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\ncreatenewaccount \"account\"\nexportwatchingwallet (\"account\" download=false)\ngetbestblock\ngetunconfirmedbalance (\"account\")\nlistaddresstransactions [\"address\",...] (\"account\")\nlistalltransactions (\"account\")\nrenameaccount \"oldaccount\" \"newaccount\"\nwalletislocked"
+var requestUsages = "addmultisigaddress nrequired [\"key\",...] (\"account\")\nanalyzepsbt \"psbt\"\ncombinepsbt [\"tx\",...]\ncreatemultisig nrequired [\"key\",...]\ndecodepsbt \"psbt\"\ndumpprivkey \"address\"\nfinalizepsbt \"psbt\" (extract=true)\ngetaccount \"address\"\ngetaccountaddress \"account\"\ngetaddressesbyaccount \"account\"\ngetbalance (\"account\" minconf=1)\ngetbestblockhash\ngetblockcount\ngetinfo\ngetnewaddress (\"account\")\ngetrawchangeaddress (\"account\")\ngetreceivedbyaccount \"account\" (minconf=1)\ngetreceivedbyaddress \"address\" (minconf=1)\ngettransaction \"txid\" (includewatchonly=false)\nhelp (\"command\")\nimportprivkey \"privkey\" (\"label\" rescan=true)\nkeypoolrefill (newsize=100)\nlistaccounts (minconf=1)\nlistlockunspent\nlistreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\nlistreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\nlistsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\nlisttransactions (\"account\" count=10 from=0 includewatchonly=false)\nlistunspent (minconf=1 maxconf=9999999 [\"address\",...])\nlockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\nsendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\nsendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\nsendtoaddress \"address\" amount (\"comment\" \"commentto\")\nsettxfee amount\nsignmessage \"address\" \"message\"\nsignrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\nutxoupdatepsbt \"psbt\"\nvalidateaddress \"address\"\nverifymessage \"address\" \"signature\" \"message\"\nwalletcreatefundedpsbt [{\"txid\":\"value\",\"vout\":n,\"sequence\":n},...] [output,...] (locktime {\"changeaddress\":changeaddress,\"changeposition\":changeposition,\"changetype\":changetype,\"includewatching\":includewatching,\"lockunspents\":lockunspents,\"feerate\":feerate,\"subtractfeefromoutputs\":subtractfeefromoutputs,\"replaceable\":replaceable,\"conftarget\":conftarget,\"estimatemode\":estimatemode} bip32derivs)\nwalletlock\nwalletpassphrase \"passphrase\" timeout\nwalletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\nwalletprocesspsbt \"psbt\" (sign=true sighashtype=\"ALL\" bip32derivs)\ncancelrescan id\nconvertpsbt \"psbt\" (version=2)\ncreateinvoice amount (memo=\"\" expiry=3600 account=\"default\")\ncreatenewaccount \"account\"\ncreatereserveproof \"message\" ([{\"txid\":\"value\",\"vout\":n},...] [\"account\",...] minconf=1)\nexportwatchingwallet (\"account\" download=false)\ngetbestblock\ngetinvoice id\ngetunconfirmedbalance (\"account\")\nlistaddresstransactions [\"address\",...] (\"account\")\nlistalltransactions (\"account\")\nlistinvoices (\"status\")\nlistrescans\npauserescan id\nrenameaccount \"oldaccount\" \"newaccount\"\nrescanblockchain (startheight=0 stopheight)\nresumerescan id\nsendpayjoin \"uri\" (amount account=\"default\" feerate minconf=1)\nsubscribe [\"event\",...] (confirmations=6)\nsweepprivkey [\"privkey\",...] (startheight=0 account=\"default\" feerate)\nunsubscribe [\"event\",...]\nverifyreserveproof \"psbt\" \"message\" (heighthint=0)\nwalletislocked"
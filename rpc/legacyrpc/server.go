@@ -5,9 +5,6 @@
 package legacyrpc
 
 import (
-	"crypto/sha256"
-	"crypto/subtle"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"io"
@@ -20,6 +17,7 @@ import (
 
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/websocket"
 )
@@ -27,21 +25,34 @@ import (
 type websocketClient struct {
 	conn          *websocket.Conn
 	authenticated bool
+	role          rpcauth.Role
 	remoteAddr    string
 	allRequests   chan []byte
 	responses     chan []byte
 	quit          chan struct{} // closed on disconnect
 	wg            sync.WaitGroup
+
+	// subs are the events the client subscribed to.  Their notifications
+	// are queued on ntfns until closing is closed, when the client stops
+	// reading requests.
+	subs    subscriptions
+	ntfns   chan []byte
+	closing chan struct{}
 }
 
-func newWebsocketClient(c *websocket.Conn, authenticated bool, remoteAddr string) *websocketClient {
+func newWebsocketClient(c *websocket.Conn, authenticated bool, role rpcauth.Role,
+	remoteAddr string) *websocketClient {
+
 	return &websocketClient{
 		conn:          c,
 		authenticated: authenticated,
+		role:          role,
 		remoteAddr:    remoteAddr,
 		allRequests:   make(chan []byte),
 		responses:     make(chan []byte),
 		quit:          make(chan struct{}),
+		ntfns:         make(chan []byte),
+		closing:       make(chan struct{}),
 	}
 }
 
@@ -64,12 +75,17 @@ type Server struct {
 	handlerMu    sync.Mutex
 
 	listeners []net.Listener
-	authsha   [sha256.Size]byte
+	auth      *rpcauth.Authenticator
 	upgrader  websocket.Upgrader
 
 	maxPostClients      int64 // Max concurrent HTTP POST clients.
 	maxWebsocketClients int64 // Max concurrent websocket clients.
 
+	observeRequest func(method string, d time.Duration, failed bool)
+
+	wsClients   map[*websocketClient]struct{}
+	wsClientsMu sync.Mutex
+
 	wg      sync.WaitGroup
 	quit    chan struct{}
 	quitMtx sync.Mutex
@@ -89,6 +105,16 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 	serveMux := http.NewServeMux()
 	const rpcAuthTimeoutSeconds = 10
 
+	var creds []rpcauth.Credential
+	if opts.Username != "" && opts.Password != "" {
+		creds = append(creds, rpcauth.Credential{
+			Username: opts.Username,
+			Password: opts.Password,
+			Role:     rpcauth.RoleAdmin,
+		})
+	}
+	creds = append(creds, opts.Users...)
+
 	server := &Server{
 		httpServer: http.Server{
 			Handler: serveMux,
@@ -100,14 +126,14 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 		walletLoader:        walletLoader,
 		maxPostClients:      opts.MaxPOSTClients,
 		maxWebsocketClients: opts.MaxWebsocketClients,
+		observeRequest:      opts.ObserveRequest,
 		listeners:           listeners,
-		// A hash of the HTTP basic auth string is used for a constant
-		// time comparison.
-		authsha: sha256.Sum256(httpBasicAuth(opts.Username, opts.Password)),
+		auth:                rpcauth.NewAuthenticator(creds),
 		upgrader: websocket.Upgrader{
 			// Allow all origins.
 			CheckOrigin: func(r *http.Request) bool { return true },
 		},
+		wsClients:           make(map[*websocketClient]struct{}),
 		quit:                make(chan struct{}),
 		requestShutdownChan: make(chan struct{}, 1),
 	}
@@ -118,20 +144,22 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 			w.Header().Set("Content-Type", "application/json")
 			r.Close = true
 
-			if err := server.checkAuthHeader(r); err != nil {
+			role, err := server.checkAuthHeader(r)
+			if err != nil {
 				log.Warnf("Unauthorized client connection attempt")
 				jsonAuthFail(w)
 				return
 			}
 			server.wg.Add(1)
-			server.postClientRPC(w, r)
+			server.postClientRPC(w, r, role)
 			server.wg.Done()
 		}))
 
 	serveMux.Handle("/ws", throttledFn(opts.MaxWebsocketClients,
 		func(w http.ResponseWriter, r *http.Request) {
 			authenticated := false
-			switch server.checkAuthHeader(r) {
+			role, err := server.checkAuthHeader(r)
+			switch err {
 			case nil:
 				authenticated = true
 			case ErrNoAuth:
@@ -151,7 +179,9 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 					r.RemoteAddr, err)
 				return
 			}
-			wsc := newWebsocketClient(conn, authenticated, r.RemoteAddr)
+			wsc := newWebsocketClient(
+				conn, authenticated, role, r.RemoteAddr,
+			)
 			server.websocketClientRPC(wsc)
 		}))
 
@@ -162,26 +192,6 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 	return server
 }
 
-// httpBasicAuth returns the UTF-8 bytes of the HTTP Basic authentication
-// string:
-//
-//   "Basic " + base64(username + ":" + password)
-func httpBasicAuth(username, password string) []byte {
-	const header = "Basic "
-	base64 := base64.StdEncoding
-
-	b64InputLen := len(username) + len(":") + len(password)
-	b64Input := make([]byte, 0, b64InputLen)
-	b64Input = append(b64Input, username...)
-	b64Input = append(b64Input, ':')
-	b64Input = append(b64Input, password...)
-
-	output := make([]byte, len(header)+base64.EncodedLen(b64InputLen))
-	copy(output, header)
-	base64.Encode(output[len(header):], b64Input)
-	return output
-}
-
 // serve serves HTTP POST and websocket RPC for the legacy JSON-RPC RPC server.
 // This function does not block on lis.Accept.
 func (s *Server) serve(lis net.Listener) {
@@ -195,11 +205,15 @@ func (s *Server) serve(lis net.Listener) {
 }
 
 // RegisterWallet associates the legacy RPC server with the wallet.  This
-// function must be called before any wallet RPCs can be called by clients.
+// function must be called before any wallet RPCs can be called by clients, or
+// any wallet notifications are sent to websocket clients.
 func (s *Server) RegisterWallet(w *wallet.Wallet) {
 	s.handlerMu.Lock()
 	s.wallet = w
 	s.handlerMu.Unlock()
+
+	s.wg.Add(1)
+	go s.walletNotifications(w)
 }
 
 // Stop gracefully shuts down the rpc server by stopping and disconnecting all
@@ -265,22 +279,57 @@ func (s *Server) SetChainServer(chainClient chain.Interface) {
 // handlerClosure creates a closure function for handling requests of the given
 // method.  This may be a request that is handled directly by btcwallet, or
 // a chain server request that is handled by passing the request down to btcd.
+// If the method is not permitted for the role of the client, the closure
+// errors with ErrMethodNotPermitted.
 //
 // NOTE: These handlers do not handle special cases, such as the authenticate
 // method.  Each of these must be checked beforehand (the method is already
 // known) and handled accordingly.
-func (s *Server) handlerClosure(request *btcjson.Request) lazyHandler {
-	s.handlerMu.Lock()
-	// With the lock held, make copies of these pointers for the closure.
-	wallet := s.wallet
-	chainClient := s.chainClient
-	if wallet != nil && chainClient == nil {
-		chainClient = wallet.ChainClient()
-		s.chainClient = chainClient
+func (s *Server) handlerClosure(request *btcjson.Request,
+	role rpcauth.Role) lazyHandler {
+
+	var handler lazyHandler
+	if role.Permits(methodRole(request.Method)) {
+		s.handlerMu.Lock()
+		// With the lock held, make copies of these pointers for the
+		// closure.
+		wallet := s.wallet
+		chainClient := s.chainClient
+		if wallet != nil && chainClient == nil {
+			chainClient = wallet.ChainClient()
+			s.chainClient = chainClient
+		}
+		s.handlerMu.Unlock()
+
+		handler = lazyApplyHandler(request, wallet, chainClient)
+	} else {
+		log.Warnf("Denied %s request from client with %v role",
+			request.Method, role)
+		handler = func() (interface{}, *btcjson.RPCError) {
+			return nil, &ErrMethodNotPermitted
+		}
 	}
-	s.handlerMu.Unlock()
 
-	return lazyApplyHandler(request, wallet, chainClient)
+	if s.observeRequest == nil {
+		return handler
+	}
+	return s.observedHandler(request.Method, handler)
+}
+
+// observedHandler wraps a handler to report the method, duration and result
+// of the request it handles to the server's request observer.
+func (s *Server) observedHandler(method string, handler lazyHandler) lazyHandler {
+	// Only label requests with known methods to bound the number of
+	// labels clients can create.
+	if _, ok := rpcHandlers[method]; !ok {
+		method = "other"
+	}
+	return func() (interface{}, *btcjson.RPCError) {
+		start := time.Now()
+		resp, jsonErr := handler()
+		s.observeRequest(method, time.Since(start), jsonErr != nil)
+		return resp, jsonErr
+	}
 }
 
 // ErrNoAuth represents an error where authentication could not succeed
@@ -290,21 +339,21 @@ var ErrNoAuth = errors.New("no auth")
 // checkAuthHeader checks the HTTP Basic authentication supplied by a client
 // in the HTTP request r.  It errors with ErrNoAuth if the request does not
 // contain the Authorization header, or another non-nil error if the
-// authentication was provided but incorrect.
+// authentication was provided but incorrect.  The role of the credentials is
+// returned if they are accepted.
 //
 // This check is time-constant.
-func (s *Server) checkAuthHeader(r *http.Request) error {
+func (s *Server) checkAuthHeader(r *http.Request) (rpcauth.Role, error) {
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) == 0 {
-		return ErrNoAuth
+		return 0, ErrNoAuth
 	}
 
-	authsha := sha256.Sum256([]byte(authhdr[0]))
-	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
-	if cmp != 1 {
-		return errors.New("bad auth")
+	role, ok := s.auth.Authenticate(authhdr[0])
+	if !ok {
+		return 0, errors.New("bad auth")
 	}
-	return nil
+	return role, nil
 }
 
 // throttledFn wraps an http.HandlerFunc with throttling of concurrent active
@@ -343,23 +392,20 @@ func idPointer(id interface{}) (p *interface{}) {
 	return
 }
 
-// invalidAuth checks whether a websocket request is a valid (parsable)
+// authenticate checks whether a websocket request is a valid (parsable)
 // authenticate request and checks the supplied username and passphrase
-// against the server auth.
-func (s *Server) invalidAuth(req *btcjson.Request) bool {
+// against the server auth, returning the role of the credentials if they are
+// accepted.
+func (s *Server) authenticate(req *btcjson.Request) (rpcauth.Role, bool) {
 	cmd, err := btcjson.UnmarshalCmd(req)
 	if err != nil {
-		return false
+		return 0, false
 	}
 	authCmd, ok := cmd.(*btcjson.AuthenticateCmd)
 	if !ok {
-		return false
+		return 0, false
 	}
-	// Check credentials.
-	login := authCmd.Username + ":" + authCmd.Passphrase
-	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-	authSha := sha256.Sum256([]byte(auth))
-	return subtle.ConstantTimeCompare(authSha[:], s.authsha[:]) != 1
+	return s.auth.AuthenticateUser(authCmd.Username, authCmd.Passphrase)
 }
 
 func (s *Server) websocketClientRead(wsc *websocketClient) {
@@ -416,11 +462,17 @@ out:
 			}
 
 			if req.Method == "authenticate" {
-				if wsc.authenticated || s.invalidAuth(&req) {
+				if wsc.authenticated {
+					// Disconnect immediately.
+					break out
+				}
+				role, ok := s.authenticate(&req)
+				if !ok {
 					// Disconnect immediately.
 					break out
 				}
 				wsc.authenticated = true
+				wsc.role = role
 				resp := makeResponse(req.ID, nil, nil)
 				// Expected to never fail.
 				mresp, err := json.Marshal(resp)
@@ -439,8 +491,31 @@ out:
 				break out
 			}
 
-			switch req.Method {
-			case "stop":
+			// Stop and subscription requests not permitted for
+			// the role of the client are denied by the handler.
+			switch {
+			case (req.Method == "subscribe" ||
+				req.Method == "unsubscribe") &&
+				wsc.role.Permits(methodRole(req.Method)):
+
+				resp, jsonErr := handleSubscription(wsc, &req)
+				mresp, err := btcjson.MarshalResponse(
+					btcjson.RpcVersion1, req.ID, resp,
+					jsonErr,
+				)
+				if err != nil {
+					log.Errorf("Unable to marshal "+
+						"response: %v", err)
+					continue
+				}
+				err = wsc.send(mresp)
+				if err != nil {
+					break out
+				}
+
+			case req.Method == "stop" &&
+				wsc.role.Permits(methodRole(req.Method)):
+
 				resp := makeResponse(req.ID,
 					"btcwallet stopping.", nil)
 				mresp, err := json.Marshal(resp)
@@ -457,7 +532,7 @@ out:
 
 			default:
 				req := req // Copy for the closure
-				f := s.handlerClosure(&req)
+				f := s.handlerClosure(&req, wsc.role)
 				wsc.wg.Add(1)
 				go func() {
 					resp, jsonErr := f()
@@ -481,6 +556,7 @@ out:
 	}
 
 	// allow client to disconnect after all handler goroutines are done
+	close(wsc.closing)
 	wsc.wg.Wait()
 	close(wsc.responses)
 	s.wg.Done()
@@ -535,19 +611,27 @@ func (s *Server) websocketClientRPC(wsc *websocketClient) {
 	// websocket connection if the client is still connected.
 	go s.websocketClientRead(wsc)
 
+	s.addWebsocketClient(wsc)
+	wsc.wg.Add(1)
+	go s.websocketClientNotify(wsc)
+
 	s.wg.Add(2)
 	go s.websocketClientRespond(wsc)
 	go s.websocketClientSend(wsc)
 
 	<-wsc.quit
+	s.removeWebsocketClient(wsc)
 }
 
 // maxRequestSize specifies the maximum number of bytes in the request body
 // that may be read from a client.  This is currently limited to 4MB.
 const maxRequestSize = 1024 * 1024 * 4
 
-// postClientRPC processes and replies to a JSON-RPC client request.
-func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
+// postClientRPC processes and replies to a JSON-RPC client request from a
+// client with the given role.
+func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request,
+	role rpcauth.Role) {
+
 	body := http.MaxBytesReader(w, r.Body, maxRequestSize)
 	rpcRequest, err := ioutil.ReadAll(body)
 	if err != nil {
@@ -583,19 +667,21 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create the response and error from the request.  Two special cases
-	// are handled for the authenticate and stop request methods.
+	// are handled for the authenticate and stop request methods.  Stop
+	// requests not permitted for the role of the client are denied by the
+	// handler.
 	var res interface{}
 	var jsonErr *btcjson.RPCError
 	var stop bool
-	switch req.Method {
-	case "authenticate":
+	switch {
+	case req.Method == "authenticate":
 		// Drop it.
 		return
-	case "stop":
+	case req.Method == "stop" && role.Permits(methodRole(req.Method)):
 		stop = true
 		res = "btcwallet stopping"
 	default:
-		res, jsonErr = s.handlerClosure(&req)()
+		res, jsonErr = s.handlerClosure(&req, role)()
 	}
 
 	// Marshal and send.
@@ -0,0 +1,571 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package types defines the commands and results of the btcwallet JSON-RPC
+// extensions which are not provided by the btcjson package.
+//
+// Every command is registered with btcjson when the package is imported, so
+// it can be marshaled and unmarshaled with btcjson.MarshalCmd and
+// btcjson.UnmarshalCmd like the commands defined by btcjson.
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// ListRescansCmd defines the listrescans JSON-RPC command.
+type ListRescansCmd struct{}
+
+// NewListRescansCmd returns a new instance which can be used to issue a
+// listrescans JSON-RPC command.
+func NewListRescansCmd() *ListRescansCmd {
+	return &ListRescansCmd{}
+}
+
+// PauseRescanCmd defines the pauserescan JSON-RPC command.
+type PauseRescanCmd struct {
+	ID uint64
+}
+
+// NewPauseRescanCmd returns a new instance which can be used to issue a
+// pauserescan JSON-RPC command.
+func NewPauseRescanCmd(id uint64) *PauseRescanCmd {
+	return &PauseRescanCmd{ID: id}
+}
+
+// ResumeRescanCmd defines the resumerescan JSON-RPC command.
+type ResumeRescanCmd struct {
+	ID uint64
+}
+
+// NewResumeRescanCmd returns a new instance which can be used to issue a
+// resumerescan JSON-RPC command.
+func NewResumeRescanCmd(id uint64) *ResumeRescanCmd {
+	return &ResumeRescanCmd{ID: id}
+}
+
+// CancelRescanCmd defines the cancelrescan JSON-RPC command.
+type CancelRescanCmd struct {
+	ID uint64
+}
+
+// NewCancelRescanCmd returns a new instance which can be used to issue a
+// cancelrescan JSON-RPC command.
+func NewCancelRescanCmd(id uint64) *CancelRescanCmd {
+	return &CancelRescanCmd{ID: id}
+}
+
+// RescanBlockchainCmd defines the rescanblockchain JSON-RPC command.
+type RescanBlockchainCmd struct {
+	StartHeight *int32 `jsonrpcdefault:"0"`
+	StopHeight  *int32
+}
+
+// NewRescanBlockchainCmd returns a new instance which can be used to issue a
+// rescanblockchain JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRescanBlockchainCmd(startHeight, stopHeight *int32) *RescanBlockchainCmd {
+	return &RescanBlockchainCmd{
+		StartHeight: startHeight,
+		StopHeight:  stopHeight,
+	}
+}
+
+// CreateInvoiceCmd defines the createinvoice JSON-RPC command.
+type CreateInvoiceCmd struct {
+	Amount  float64
+	Memo    *string `jsonrpcdefault:"\"\""`
+	Expiry  *int64  `jsonrpcdefault:"3600"`
+	Account *string `jsonrpcdefault:"\"default\""`
+}
+
+// NewCreateInvoiceCmd returns a new instance which can be used to issue a
+// createinvoice JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCreateInvoiceCmd(amount float64, memo *string, expiry *int64,
+	account *string) *CreateInvoiceCmd {
+
+	return &CreateInvoiceCmd{
+		Amount:  amount,
+		Memo:    memo,
+		Expiry:  expiry,
+		Account: account,
+	}
+}
+
+// CreateReserveProofCmd defines the createreserveproof JSON-RPC command.
+type CreateReserveProofCmd struct {
+	Message  string
+	Outputs  *[]btcjson.TransactionInput
+	Accounts *[]string
+	MinConf  *int `jsonrpcdefault:"1"`
+}
+
+// NewCreateReserveProofCmd returns a new instance which can be used to issue
+// a createreserveproof JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCreateReserveProofCmd(message string,
+	outputs *[]btcjson.TransactionInput, accounts *[]string,
+	minConf *int) *CreateReserveProofCmd {
+
+	return &CreateReserveProofCmd{
+		Message:  message,
+		Outputs:  outputs,
+		Accounts: accounts,
+		MinConf:  minConf,
+	}
+}
+
+// VerifyReserveProofCmd defines the verifyreserveproof JSON-RPC command.
+type VerifyReserveProofCmd struct {
+	Psbt       string
+	Message    string
+	HeightHint *uint32 `jsonrpcdefault:"0"`
+}
+
+// NewVerifyReserveProofCmd returns a new instance which can be used to issue
+// a verifyreserveproof JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewVerifyReserveProofCmd(psbt, message string,
+	heightHint *uint32) *VerifyReserveProofCmd {
+
+	return &VerifyReserveProofCmd{
+		Psbt:       psbt,
+		Message:    message,
+		HeightHint: heightHint,
+	}
+}
+
+// SendPayjoinCmd defines the sendpayjoin JSON-RPC command.
+type SendPayjoinCmd struct {
+	URI     string
+	Amount  *float64
+	Account *string `jsonrpcdefault:"\"default\""`
+	FeeRate *float64
+	MinConf *int `jsonrpcdefault:"1"`
+}
+
+// NewSendPayjoinCmd returns a new instance which can be used to issue a
+// sendpayjoin JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSendPayjoinCmd(uri string, amount *float64, account *string,
+	feeRate *float64, minConf *int) *SendPayjoinCmd {
+
+	return &SendPayjoinCmd{
+		URI:     uri,
+		Amount:  amount,
+		Account: account,
+		FeeRate: feeRate,
+		MinConf: minConf,
+	}
+}
+
+// SweepPrivKeyCmd defines the sweepprivkey JSON-RPC command.
+type SweepPrivKeyCmd struct {
+	PrivKeys    []string
+	StartHeight *int32  `jsonrpcdefault:"0"`
+	Account     *string `jsonrpcdefault:"\"default\""`
+	FeeRate     *float64
+}
+
+// NewSweepPrivKeyCmd returns a new instance which can be used to issue a
+// sweepprivkey JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSweepPrivKeyCmd(privKeys []string, startHeight *int32,
+	account *string, feeRate *float64) *SweepPrivKeyCmd {
+
+	return &SweepPrivKeyCmd{
+		PrivKeys:    privKeys,
+		StartHeight: startHeight,
+		Account:     account,
+		FeeRate:     feeRate,
+	}
+}
+
+// GetInvoiceCmd defines the getinvoice JSON-RPC command.
+type GetInvoiceCmd struct {
+	ID uint64
+}
+
+// NewGetInvoiceCmd returns a new instance which can be used to issue a
+// getinvoice JSON-RPC command.
+func NewGetInvoiceCmd(id uint64) *GetInvoiceCmd {
+	return &GetInvoiceCmd{ID: id}
+}
+
+// ListInvoicesCmd defines the listinvoices JSON-RPC command.
+type ListInvoicesCmd struct {
+	Status *string
+}
+
+// NewListInvoicesCmd returns a new instance which can be used to issue a
+// listinvoices JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListInvoicesCmd(status *string) *ListInvoicesCmd {
+	return &ListInvoicesCmd{Status: status}
+}
+
+// Events which websocket clients can subscribe to with the subscribe command.
+const (
+	// EventTransactions subscribes to newtx notifications of transactions
+	// relevant to the wallet, when they are added to the wallet and when
+	// they are mined.
+	EventTransactions = "transactions"
+
+	// EventConfirmations subscribes to txconfirmed notifications of
+	// wallet transactions reaching the confirmations of the subscription.
+	EventConfirmations = "confirmations"
+
+	// EventBalances subscribes to accountbalance notifications of the
+	// total balances of accounts whose balance changed.
+	EventBalances = "balances"
+
+	// EventLockState subscribes to walletlockstate notifications when the
+	// wallet is locked or unlocked.
+	EventLockState = "lockstate"
+
+	// EventRescan subscribes to rescanprogress and rescanfinished
+	// notifications of the rescans performed by the wallet.
+	EventRescan = "rescan"
+)
+
+// SubscribeCmd defines the subscribe JSON-RPC command.
+type SubscribeCmd struct {
+	Events        []string
+	Confirmations *int32 `jsonrpcdefault:"6"`
+}
+
+// NewSubscribeCmd returns a new instance which can be used to issue a
+// subscribe JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSubscribeCmd(events []string, confirmations *int32) *SubscribeCmd {
+	return &SubscribeCmd{
+		Events:        events,
+		Confirmations: confirmations,
+	}
+}
+
+// UnsubscribeCmd defines the unsubscribe JSON-RPC command.
+type UnsubscribeCmd struct {
+	Events []string
+}
+
+// NewUnsubscribeCmd returns a new instance which can be used to issue an
+// unsubscribe JSON-RPC command.
+func NewUnsubscribeCmd(events []string) *UnsubscribeCmd {
+	return &UnsubscribeCmd{Events: events}
+}
+
+// AnalyzePsbtCmd defines the analyzepsbt JSON-RPC command.
+type AnalyzePsbtCmd struct {
+	Psbt string
+}
+
+// NewAnalyzePsbtCmd returns a new instance which can be used to issue an
+// analyzepsbt JSON-RPC command.
+func NewAnalyzePsbtCmd(psbt string) *AnalyzePsbtCmd {
+	return &AnalyzePsbtCmd{Psbt: psbt}
+}
+
+// CombinePsbtCmd defines the combinepsbt JSON-RPC command.
+type CombinePsbtCmd struct {
+	Txs []string
+}
+
+// NewCombinePsbtCmd returns a new instance which can be used to issue a
+// combinepsbt JSON-RPC command.
+func NewCombinePsbtCmd(txs []string) *CombinePsbtCmd {
+	return &CombinePsbtCmd{Txs: txs}
+}
+
+// DecodePsbtCmd defines the decodepsbt JSON-RPC command.
+type DecodePsbtCmd struct {
+	Psbt string
+}
+
+// NewDecodePsbtCmd returns a new instance which can be used to issue a
+// decodepsbt JSON-RPC command.
+func NewDecodePsbtCmd(psbt string) *DecodePsbtCmd {
+	return &DecodePsbtCmd{Psbt: psbt}
+}
+
+// FinalizePsbtCmd defines the finalizepsbt JSON-RPC command.
+type FinalizePsbtCmd struct {
+	Psbt    string
+	Extract *bool `jsonrpcdefault:"true"`
+}
+
+// NewFinalizePsbtCmd returns a new instance which can be used to issue a
+// finalizepsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewFinalizePsbtCmd(psbt string, extract *bool) *FinalizePsbtCmd {
+	return &FinalizePsbtCmd{
+		Psbt:    psbt,
+		Extract: extract,
+	}
+}
+
+// ConvertPsbtCmd defines the convertpsbt JSON-RPC command.
+type ConvertPsbtCmd struct {
+	Psbt    string
+	Version *uint32 `jsonrpcdefault:"2"`
+}
+
+// NewConvertPsbtCmd returns a new instance which can be used to issue a
+// convertpsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewConvertPsbtCmd(psbt string, version *uint32) *ConvertPsbtCmd {
+	return &ConvertPsbtCmd{
+		Psbt:    psbt,
+		Version: version,
+	}
+}
+
+// UtxoUpdatePsbtCmd defines the utxoupdatepsbt JSON-RPC command.
+type UtxoUpdatePsbtCmd struct {
+	Psbt string
+}
+
+// NewUtxoUpdatePsbtCmd returns a new instance which can be used to issue a
+// utxoupdatepsbt JSON-RPC command.
+func NewUtxoUpdatePsbtCmd(psbt string) *UtxoUpdatePsbtCmd {
+	return &UtxoUpdatePsbtCmd{Psbt: psbt}
+}
+
+// Multisig address types of the address_type parameter.
+const (
+	MultisigLegacy     = "legacy"
+	MultisigP2SHSegwit = "p2sh-segwit"
+	MultisigBech32     = "bech32"
+)
+
+// AddMultisigAddressCmd extends the addmultisigaddress JSON-RPC command of
+// btcjson with the address_type parameter.
+//
+// The command is already registered by btcjson, so it must be unmarshaled
+// with UnmarshalCmd.
+type AddMultisigAddressCmd struct {
+	btcjson.AddMultisigAddressCmd
+	AddressType *string
+}
+
+// NewAddMultisigAddressCmd returns a new instance which can be used to issue
+// an addmultisigaddress JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewAddMultisigAddressCmd(nRequired int, keys []string, account,
+	addressType *string) *AddMultisigAddressCmd {
+
+	return &AddMultisigAddressCmd{
+		AddMultisigAddressCmd: *btcjson.NewAddMultisigAddressCmd(
+			nRequired, keys, account,
+		),
+		AddressType: addressType,
+	}
+}
+
+// CreateMultisigCmd extends the createmultisig JSON-RPC command of btcjson
+// with the address_type parameter.
+//
+// The command is already registered by btcjson, so it must be unmarshaled
+// with UnmarshalCmd.
+type CreateMultisigCmd struct {
+	btcjson.CreateMultisigCmd
+	AddressType *string
+}
+
+// NewCreateMultisigCmd returns a new instance which can be used to issue a
+// createmultisig JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCreateMultisigCmd(nRequired int, keys []string,
+	addressType *string) *CreateMultisigCmd {
+
+	return &CreateMultisigCmd{
+		CreateMultisigCmd: *btcjson.NewCreateMultisigCmd(
+			nRequired, keys,
+		),
+		AddressType: addressType,
+	}
+}
+
+// WalletCreateFundedPsbtCmd extends the walletcreatefundedpsbt JSON-RPC
+// command of btcjson with the psbt_version parameter.
+//
+// The command is already registered by btcjson, so it must be unmarshaled
+// with UnmarshalCmd.
+type WalletCreateFundedPsbtCmd struct {
+	btcjson.WalletCreateFundedPsbtCmd
+	PsbtVersion *uint32
+}
+
+// NewWalletCreateFundedPsbtCmd returns a new instance which can be used to
+// issue a walletcreatefundedpsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletCreateFundedPsbtCmd(inputs []btcjson.PsbtInput,
+	outputs []btcjson.PsbtOutput, locktime *uint32,
+	options *btcjson.WalletCreateFundedPsbtOpts, bip32Derivs *bool,
+	psbtVersion *uint32) *WalletCreateFundedPsbtCmd {
+
+	return &WalletCreateFundedPsbtCmd{
+		WalletCreateFundedPsbtCmd: *btcjson.NewWalletCreateFundedPsbtCmd(
+			inputs, outputs, locktime, options, bip32Derivs,
+		),
+		PsbtVersion: psbtVersion,
+	}
+}
+
+// extendedParam describes the trailing parameter a command of btcjson is
+// extended with.
+type extendedParam struct {
+	// numParams is the number of parameters of the btcjson command.
+	numParams int
+
+	// name and typ are the name and JSON type of the parameter used in
+	// errors.
+	name, typ string
+}
+
+// extendedParams maps the commands extended with a trailing parameter to the
+// parameter.
+var extendedParams = map[string]extendedParam{
+	"addmultisigaddress":     {3, "address_type", "string"},
+	"createmultisig":         {2, "address_type", "string"},
+	"walletcreatefundedpsbt": {5, "psbt_version", "number"},
+}
+
+// UnmarshalCmd unmarshals a JSON-RPC request into a command like
+// btcjson.UnmarshalCmd.  The commands extended by this package are returned
+// as the extended command, with the additional parameters unmarshaled from
+// the end of the request parameters.
+func UnmarshalCmd(r *btcjson.Request) (interface{}, error) {
+	param, ok := extendedParams[r.Method]
+	if !ok {
+		return btcjson.UnmarshalCmd(r)
+	}
+
+	// Unmarshal the btcjson command without the extended parameter.
+	var extra json.RawMessage
+	if len(r.Params) > param.numParams {
+		if len(r.Params) > param.numParams+1 {
+			str := "too many parameters"
+			return nil, btcjson.Error{
+				ErrorCode:   btcjson.ErrNumParams,
+				Description: str,
+			}
+		}
+		extra = r.Params[param.numParams]
+		req := *r
+		req.Params = r.Params[:param.numParams]
+		r = &req
+	}
+	cmd, err := btcjson.UnmarshalCmd(r)
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshalExtra := func(v interface{}) error {
+		if extra == nil {
+			return nil
+		}
+		if err := json.Unmarshal(extra, v); err != nil {
+			str := fmt.Sprintf("parameter %s must be type %s",
+				param.name, param.typ)
+			return btcjson.Error{
+				ErrorCode:   btcjson.ErrInvalidType,
+				Description: str,
+			}
+		}
+		return nil
+	}
+
+	switch cmd := cmd.(type) {
+	case *btcjson.AddMultisigAddressCmd:
+		var addressType *string
+		if err := unmarshalExtra(&addressType); err != nil {
+			return nil, err
+		}
+		return &AddMultisigAddressCmd{
+			AddMultisigAddressCmd: *cmd,
+			AddressType:           addressType,
+		}, nil
+
+	case *btcjson.CreateMultisigCmd:
+		var addressType *string
+		if err := unmarshalExtra(&addressType); err != nil {
+			return nil, err
+		}
+		return &CreateMultisigCmd{
+			CreateMultisigCmd: *cmd,
+			AddressType:       addressType,
+		}, nil
+
+	case *btcjson.WalletCreateFundedPsbtCmd:
+		var psbtVersion *uint32
+		if err := unmarshalExtra(&psbtVersion); err != nil {
+			return nil, err
+		}
+		return &WalletCreateFundedPsbtCmd{
+			WalletCreateFundedPsbtCmd: *cmd,
+			PsbtVersion:               psbtVersion,
+		}, nil
+	}
+	return cmd, nil
+}
+
+func init() {
+	// The commands in this file are only usable with a wallet server.
+	flags := btcjson.UFWalletOnly
+
+	btcjson.MustRegisterCmd("listrescans", (*ListRescansCmd)(nil), flags)
+	btcjson.MustRegisterCmd("pauserescan", (*PauseRescanCmd)(nil), flags)
+	btcjson.MustRegisterCmd("resumerescan", (*ResumeRescanCmd)(nil), flags)
+	btcjson.MustRegisterCmd("cancelrescan", (*CancelRescanCmd)(nil), flags)
+	btcjson.MustRegisterCmd("rescanblockchain", (*RescanBlockchainCmd)(nil), flags)
+	btcjson.MustRegisterCmd("createinvoice", (*CreateInvoiceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getinvoice", (*GetInvoiceCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listinvoices", (*ListInvoicesCmd)(nil), flags)
+	btcjson.MustRegisterCmd("createreserveproof", (*CreateReserveProofCmd)(nil), flags)
+	btcjson.MustRegisterCmd("verifyreserveproof", (*VerifyReserveProofCmd)(nil), flags)
+	btcjson.MustRegisterCmd("sendpayjoin", (*SendPayjoinCmd)(nil), flags)
+	btcjson.MustRegisterCmd("sweepprivkey", (*SweepPrivKeyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("analyzepsbt", (*AnalyzePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("combinepsbt", (*CombinePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("convertpsbt", (*ConvertPsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("decodepsbt", (*DecodePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("finalizepsbt", (*FinalizePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("utxoupdatepsbt", (*UtxoUpdatePsbtCmd)(nil), flags)
+
+	// The subscription commands are only usable by websocket clients.
+	flags |= btcjson.UFWebsocketOnly
+	btcjson.MustRegisterCmd("subscribe", (*SubscribeCmd)(nil), flags)
+	btcjson.MustRegisterCmd("unsubscribe", (*UnsubscribeCmd)(nil), flags)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package types
+
+import "github.com/btcsuite/btcd/btcjson"
+
+const (
+	// TxConfirmedNtfnMethod is the method of the notification sent to
+	// websocket clients subscribed to EventConfirmations when a wallet
+	// transaction reaches the confirmations of their subscription.
+	TxConfirmedNtfnMethod = "txconfirmed"
+)
+
+// TxConfirmedNtfn defines the txconfirmed JSON-RPC notification.
+type TxConfirmedNtfn struct {
+	TxID          string
+	BlockHash     string
+	BlockHeight   int32
+	Confirmations int32
+}
+
+// NewTxConfirmedNtfn returns a new instance which can be used to issue a
+// txconfirmed JSON-RPC notification.
+func NewTxConfirmedNtfn(txID, blockHash string, blockHeight,
+	confirmations int32) *TxConfirmedNtfn {
+
+	return &TxConfirmedNtfn{
+		TxID:          txID,
+		BlockHash:     blockHash,
+		BlockHeight:   blockHeight,
+		Confirmations: confirmations,
+	}
+}
+
+func init() {
+	// The notifications in this file are only sent by a wallet server to
+	// websocket clients.
+	flags := btcjson.UFWalletOnly | btcjson.UFWebsocketOnly |
+		btcjson.UFNotification
+
+	btcjson.MustRegisterCmd(TxConfirmedNtfnMethod, (*TxConfirmedNtfn)(nil),
+		flags)
+}
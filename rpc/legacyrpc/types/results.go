@@ -0,0 +1,162 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package types
+
+import "github.com/btcsuite/btcd/btcjson"
+
+// RescanResult models the data of a persisted rescan job returned by the
+// listrescans command.
+type RescanResult struct {
+	ID             uint64 `json:"id"`
+	State          string `json:"state"`
+	Running        bool   `json:"running"`
+	Queued         bool   `json:"queued"`
+	StartHeight    int32  `json:"startheight"`
+	StartHash      string `json:"starthash"`
+	ProgressHeight int32  `json:"progressheight"`
+	ProgressHash   string `json:"progresshash,omitempty"`
+	Addresses      int    `json:"addresses"`
+	OutPoints      int    `json:"outpoints"`
+}
+
+// RescanBlockchainResult models the data returned by the rescanblockchain
+// command.
+type RescanBlockchainResult struct {
+	StartHeight     int32    `json:"start_height"`
+	StopHeight      int32    `json:"stop_height"`
+	Transactions    []string `json:"transactions"`
+	NewTransactions []string `json:"newtransactions"`
+}
+
+// InvoicePaymentResult models a payment of an invoice.
+type InvoicePaymentResult struct {
+	TxID   string  `json:"txid"`
+	Vout   uint32  `json:"vout"`
+	Amount float64 `json:"amount"`
+}
+
+// InvoiceResult models the data of an invoice returned by the createinvoice,
+// getinvoice and listinvoices commands.
+type InvoiceResult struct {
+	ID       uint64                 `json:"id"`
+	Address  string                 `json:"address"`
+	Account  string                 `json:"account"`
+	Amount   float64                `json:"amount"`
+	Received float64                `json:"received"`
+	Memo     string                 `json:"memo,omitempty"`
+	Created  int64                  `json:"created"`
+	Expiry   int64                  `json:"expiry,omitempty"`
+	Status   string                 `json:"status"`
+	URI      string                 `json:"uri"`
+	Payments []InvoicePaymentResult `json:"payments"`
+}
+
+// CreateReserveProofResult models the data returned by the createreserveproof
+// command.
+type CreateReserveProofResult struct {
+	Psbt     string  `json:"psbt"`
+	Amount   float64 `json:"amount"`
+	Complete bool    `json:"complete"`
+}
+
+// VerifyReserveProofResult models the data returned by the verifyreserveproof
+// command.
+type VerifyReserveProofResult struct {
+	Valid  bool    `json:"valid"`
+	Amount float64 `json:"amount"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// SendPayjoinResult models the data returned by the sendpayjoin command.
+type SendPayjoinResult struct {
+	TxID    string `json:"txid"`
+	Payjoin bool   `json:"payjoin"`
+}
+
+// SweepPrivKeyResult models the data returned by the sweepprivkey command.
+type SweepPrivKeyResult struct {
+	TxIDs  []string `json:"txids"`
+	Inputs int      `json:"inputs"`
+	Amount float64  `json:"amount"`
+}
+
+// PsbtScriptResult models a script of a PSBT input or output.
+type PsbtScriptResult struct {
+	Asm  string `json:"asm"`
+	Hex  string `json:"hex"`
+	Type string `json:"type"`
+}
+
+// PsbtBip32DerivResult models a BIP 32 derivation path of a PSBT input or
+// output.
+type PsbtBip32DerivResult struct {
+	PubKey            string `json:"pubkey"`
+	MasterFingerprint string `json:"master_fingerprint"`
+	Path              string `json:"path"`
+}
+
+// PsbtWitnessUtxoResult models the witness UTXO of a PSBT input.
+type PsbtWitnessUtxoResult struct {
+	Amount       float64                    `json:"amount"`
+	ScriptPubKey btcjson.ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// PsbtInputResult models an input of a PSBT returned by the decodepsbt
+// command.
+type PsbtInputResult struct {
+	NonWitnessUtxo     *btcjson.TxRawDecodeResult `json:"non_witness_utxo,omitempty"`
+	WitnessUtxo        *PsbtWitnessUtxoResult     `json:"witness_utxo,omitempty"`
+	PartialSignatures  map[string]string          `json:"partial_signatures,omitempty"`
+	Sighash            string                     `json:"sighash,omitempty"`
+	RedeemScript       *PsbtScriptResult          `json:"redeem_script,omitempty"`
+	WitnessScript      *PsbtScriptResult          `json:"witness_script,omitempty"`
+	Bip32Derivs        []PsbtBip32DerivResult     `json:"bip32_derivs,omitempty"`
+	FinalScriptSig     *btcjson.ScriptSig         `json:"final_scriptSig,omitempty"`
+	FinalScriptWitness []string                   `json:"final_scriptwitness,omitempty"`
+	Unknown            map[string]string          `json:"unknown,omitempty"`
+}
+
+// PsbtOutputResult models an output of a PSBT returned by the decodepsbt
+// command.
+type PsbtOutputResult struct {
+	RedeemScript  *PsbtScriptResult      `json:"redeem_script,omitempty"`
+	WitnessScript *PsbtScriptResult      `json:"witness_script,omitempty"`
+	Bip32Derivs   []PsbtBip32DerivResult `json:"bip32_derivs,omitempty"`
+}
+
+// DecodePsbtResult models the data returned by the decodepsbt command.
+type DecodePsbtResult struct {
+	Tx          btcjson.TxRawDecodeResult `json:"tx"`
+	Unknown     map[string]string         `json:"unknown"`
+	PsbtVersion uint32                    `json:"psbt_version"`
+	Inputs      []PsbtInputResult         `json:"inputs"`
+	Outputs     []PsbtOutputResult        `json:"outputs"`
+	Fee         *float64                  `json:"fee,omitempty"`
+}
+
+// AnalyzePsbtInputResult models the analysis of a PSBT input returned by the
+// analyzepsbt command.
+type AnalyzePsbtInputResult struct {
+	HasUtxo bool   `json:"has_utxo"`
+	IsFinal bool   `json:"is_final"`
+	Next    string `json:"next,omitempty"`
+}
+
+// AnalyzePsbtResult models the data returned by the analyzepsbt command.
+type AnalyzePsbtResult struct {
+	Inputs           []AnalyzePsbtInputResult `json:"inputs,omitempty"`
+	EstimatedVSize   *int64                   `json:"estimated_vsize,omitempty"`
+	EstimatedFeeRate *float64                 `json:"estimated_feerate,omitempty"`
+	Fee              *float64                 `json:"fee,omitempty"`
+	Next             string                   `json:"next"`
+	Error            string                   `json:"error,omitempty"`
+}
+
+// FinalizePsbtResult models the data returned by the finalizepsbt command.
+type FinalizePsbtResult struct {
+	Psbt     string `json:"psbt,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+	Complete bool   `json:"complete"`
+}
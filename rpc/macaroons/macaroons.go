@@ -0,0 +1,333 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package macaroons bakes and verifies the macaroons authenticating clients
+// of the wallet's gRPC server.
+//
+// A macaroon grants a set of permissions, each an action on an entity, such
+// as "onchain:write".  The permissions are part of the macaroon's identifier,
+// which is signed with a root key stored in the wallet database, so they can
+// not be changed by clients.  Clients may restrict a macaroon further by
+// adding first-party caveats, which the Service checks when verifying it:
+//
+//	time-before <RFC 3339 time>  the macaroon expires at the given time
+//	ipaddr <IP address>          the macaroon is only valid for calls from the
+//	                             given IP address
+//
+// The admin, readonly and invoice macaroons are baked when the wallet is
+// created, and stored alongside the root key.
+package macaroons
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	"gopkg.in/macaroon.v2"
+)
+
+// Location is the location of the macaroons baked by a Service.
+const Location = "btcwallet"
+
+// Entities of permissions.
+const (
+	EntityInfo     = "info"
+	EntityOnchain  = "onchain"
+	EntityAccount  = "account"
+	EntityAddress  = "address"
+	EntityInvoices = "invoices"
+	EntityRescan   = "rescan"
+	EntityWallet   = "wallet"
+	EntityMacaroon = "macaroon"
+)
+
+// Actions of permissions.
+const (
+	ActionRead     = "read"
+	ActionWrite    = "write"
+	ActionGenerate = "generate"
+)
+
+// Permission is an action on an entity.
+type Permission struct {
+	Entity string
+	Action string
+}
+
+// String returns the permission as entity:action.
+func (p Permission) String() string {
+	return p.Entity + ":" + p.Action
+}
+
+// ParsePermission parses a permission of the form entity:action.
+func ParsePermission(s string) (Permission, error) {
+	i := strings.Index(s, ":")
+	if i <= 0 || i == len(s)-1 || strings.ContainsAny(s, ", ") {
+		return Permission{}, fmt.Errorf("invalid permission %q", s)
+	}
+	return Permission{Entity: s[:i], Action: s[i+1:]}, nil
+}
+
+var (
+	// AdminPermissions are the permissions of the admin macaroon, which
+	// permit every method.
+	AdminPermissions = []Permission{
+		{EntityInfo, ActionRead},
+		{EntityOnchain, ActionRead},
+		{EntityOnchain, ActionWrite},
+		{EntityAccount, ActionRead},
+		{EntityAccount, ActionWrite},
+		{EntityAddress, ActionWrite},
+		{EntityInvoices, ActionRead},
+		{EntityInvoices, ActionWrite},
+		{EntityRescan, ActionRead},
+		{EntityRescan, ActionWrite},
+		{EntityWallet, ActionRead},
+		{EntityWallet, ActionWrite},
+		{EntityMacaroon, ActionGenerate},
+	}
+
+	// ReadOnlyPermissions are the permissions of the readonly macaroon,
+	// which permit the methods that do not modify the wallet.
+	ReadOnlyPermissions = []Permission{
+		{EntityInfo, ActionRead},
+		{EntityOnchain, ActionRead},
+		{EntityAccount, ActionRead},
+		{EntityInvoices, ActionRead},
+		{EntityRescan, ActionRead},
+		{EntityWallet, ActionRead},
+	}
+
+	// InvoicePermissions are the permissions of the invoice macaroon,
+	// which permit creating addresses and invoices and watching for their
+	// payments.
+	InvoicePermissions = []Permission{
+		{EntityInfo, ActionRead},
+		{EntityOnchain, ActionRead},
+		{EntityAccount, ActionRead},
+		{EntityAddress, ActionWrite},
+		{EntityInvoices, ActionRead},
+		{EntityInvoices, ActionWrite},
+	}
+)
+
+// DefaultMacaroons maps the names of the macaroons baked when the wallet is
+// created to their permissions.
+var DefaultMacaroons = map[string][]Permission{
+	"admin":    AdminPermissions,
+	"readonly": ReadOnlyPermissions,
+	"invoice":  InvoicePermissions,
+}
+
+var (
+	// ErrPermissionDenied is returned when verifying a macaroon which
+	// does not grant a required permission.
+	ErrPermissionDenied = errors.New("macaroon does not grant the " +
+		"required permissions")
+
+	// ErrInvalidMacaroon is returned when verifying a macaroon which was
+	// not baked by the service.
+	ErrInvalidMacaroon = errors.New("invalid macaroon")
+)
+
+// idVersion is the version of the encoding of macaroon identifiers.
+const idVersion = 0
+
+// nonceLen is the length of the random nonce making each macaroon identifier
+// unique.
+const nonceLen = 16
+
+// encodeID returns the identifier of a macaroon granting the permissions:
+// the version, a random nonce, and the permissions separated by commas.
+func encodeID(perms []Permission) ([]byte, error) {
+	var id bytes.Buffer
+	id.WriteByte(idVersion)
+	var nonce [nonceLen]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	id.Write(nonce[:])
+	for i, perm := range perms {
+		if i > 0 {
+			id.WriteByte(',')
+		}
+		id.WriteString(perm.String())
+	}
+	return id.Bytes(), nil
+}
+
+// decodeID returns the permissions granted by a macaroon identifier.
+func decodeID(id []byte) ([]Permission, error) {
+	if len(id) < 1+nonceLen || id[0] != idVersion {
+		return nil, ErrInvalidMacaroon
+	}
+	encoded := string(id[1+nonceLen:])
+	if encoded == "" {
+		return nil, nil
+	}
+	var perms []Permission
+	for _, s := range strings.Split(encoded, ",") {
+		perm, err := ParsePermission(s)
+		if err != nil {
+			return nil, ErrInvalidMacaroon
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+// TimeBeforeCaveat returns a caveat expiring a macaroon at the given time.
+func TimeBeforeCaveat(t time.Time) string {
+	return "time-before " + t.UTC().Format(time.RFC3339)
+}
+
+// IPAddrCaveat returns a caveat limiting a macaroon to calls from the given
+// IP address.
+func IPAddrCaveat(ip net.IP) string {
+	return "ipaddr " + ip.String()
+}
+
+// checkCaveat checks the condition of a first-party caveat for a call from the
+// given IP address, which may be nil if unknown.
+func checkCaveat(condition string, peerIP net.IP) error {
+	i := strings.Index(condition, " ")
+	if i < 0 {
+		return fmt.Errorf("unknown caveat %q", condition)
+	}
+	name, arg := condition[:i], condition[i+1:]
+	switch name {
+	case "time-before":
+		t, err := time.Parse(time.RFC3339, arg)
+		if err != nil {
+			return fmt.Errorf("invalid time-before caveat: %v", err)
+		}
+		if !time.Now().Before(t) {
+			return errors.New("macaroon has expired")
+		}
+		return nil
+
+	case "ipaddr":
+		ip := net.ParseIP(arg)
+		if ip == nil {
+			return fmt.Errorf("invalid ipaddr caveat %q", arg)
+		}
+		if !ip.Equal(peerIP) {
+			return errors.New("macaroon is not valid for the IP " +
+				"address of the client")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown caveat %q", condition)
+	}
+}
+
+// Service bakes and verifies macaroons with the root key of a wallet
+// database.  It is safe for concurrent access.
+type Service struct {
+	db      walletdb.DB
+	rootKey []byte
+}
+
+// NewService returns a Service for the macaroons of the wallet database,
+// creating the root key and default macaroons if they do not exist yet.
+func NewService(db walletdb.DB) (*Service, error) {
+	var rootKey []byte
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		if err := Create(tx); err != nil {
+			return err
+		}
+		ns := tx.ReadBucket(namespaceKey)
+		rootKey = append([]byte{}, ns.Get(rootKeyKey)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Service{db: db, rootKey: rootKey}, nil
+}
+
+// Bake returns a new macaroon granting the permissions, restricted by the
+// first-party caveats.
+func (s *Service) Bake(perms []Permission, caveats ...string) (
+	*macaroon.Macaroon, error) {
+
+	return bake(s.rootKey, perms, caveats...)
+}
+
+// bake returns a new macaroon signed with the root key.
+func bake(rootKey []byte, perms []Permission, caveats ...string) (
+	*macaroon.Macaroon, error) {
+
+	id, err := encodeID(perms)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := macaroon.New(rootKey, id, Location, macaroon.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, caveat := range caveats {
+		if err := mac.AddFirstPartyCaveat([]byte(caveat)); err != nil {
+			return nil, err
+		}
+	}
+	return mac, nil
+}
+
+// Macaroon returns the serialized default macaroon with the given name.
+func (s *Service) Macaroon(name string) ([]byte, error) {
+	var mac []byte
+	err := walletdb.View(s.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(namespaceKey)
+		if ns == nil {
+			return fmt.Errorf("no macaroon named %q", name)
+		}
+		v := ns.NestedReadBucket(defaultsBucketKey).Get([]byte(name))
+		if v == nil {
+			return fmt.Errorf("no macaroon named %q", name)
+		}
+		mac = append([]byte{}, v...)
+		return nil
+	})
+	return mac, err
+}
+
+// Verify checks that a serialized macaroon was baked by the service, that its
+// caveats are satisfied for a call from the given IP address, which may be nil
+// if unknown, and that it grants every required permission.
+func (s *Service) Verify(serialized []byte, required []Permission,
+	peerIP net.IP) error {
+
+	var mac macaroon.Macaroon
+	if err := mac.UnmarshalBinary(serialized); err != nil {
+		return ErrInvalidMacaroon
+	}
+	check := func(condition string) error {
+		return checkCaveat(condition, peerIP)
+	}
+	if err := mac.Verify(s.rootKey, check, nil); err != nil {
+		return err
+	}
+
+	perms, err := decodeID(mac.Id())
+	if err != nil {
+		return err
+	}
+	granted := make(map[Permission]struct{}, len(perms))
+	for _, perm := range perms {
+		granted[perm] = struct{}{}
+	}
+	for _, perm := range required {
+		if _, ok := granted[perm]; !ok {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
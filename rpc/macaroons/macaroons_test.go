@@ -0,0 +1,158 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package macaroons
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// newTestService returns a Service for a new in-memory database.
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestCreate tests that creating the macaroons of a database which already
+// has them keeps the root key and default macaroons.
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	s := newTestService(t)
+	admin, err := s.Macaroon("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := NewService(s.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s.rootKey, s2.rootKey) {
+		t.Fatal("root key changed")
+	}
+	admin2, err := s2.Macaroon("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(admin, admin2) {
+		t.Fatal("admin macaroon changed")
+	}
+
+	if _, err := s.Macaroon("unknown"); err == nil {
+		t.Fatal("expected error for unknown macaroon")
+	}
+}
+
+// TestVerify tests that macaroons are only accepted when they grant the
+// required permissions and their caveats are satisfied.
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	s := newTestService(t)
+	other := newTestService(t)
+
+	spend := []Permission{{EntityOnchain, ActionWrite}}
+	read := []Permission{{EntityOnchain, ActionRead}}
+	invoice := []Permission{{EntityInvoices, ActionWrite}}
+	clientIP := net.ParseIP("10.0.0.1")
+
+	bake := func(s *Service, perms []Permission, caveats ...string) []byte {
+		mac, err := s.Bake(perms, caveats...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serialized, err := mac.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return serialized
+	}
+	stored := func(name string) []byte {
+		mac, err := s.Macaroon(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mac
+	}
+
+	tests := []struct {
+		name     string
+		mac      []byte
+		required []Permission
+		ok       bool
+	}{
+		{"admin spends", stored("admin"), spend, true},
+		{"readonly reads", stored("readonly"), read, true},
+		{"readonly spends", stored("readonly"), spend, false},
+		{"invoice creates invoices", stored("invoice"), invoice, true},
+		{"invoice spends", stored("invoice"), spend, false},
+		{"no permissions required", bake(s, nil), nil, true},
+		{"other root key", bake(other, spend), spend, false},
+		{"garbage", []byte("garbage"), nil, false},
+		{
+			"unexpired",
+			bake(s, spend, TimeBeforeCaveat(time.Now().Add(time.Hour))),
+			spend, true,
+		},
+		{
+			"expired",
+			bake(s, spend, TimeBeforeCaveat(time.Now().Add(-time.Hour))),
+			spend, false,
+		},
+		{"client IP", bake(s, spend, IPAddrCaveat(clientIP)), spend, true},
+		{
+			"other IP",
+			bake(s, spend, IPAddrCaveat(net.ParseIP("10.0.0.2"))),
+			spend, false,
+		},
+		{"unknown caveat", bake(s, spend, "color red"), spend, false},
+	}
+	for _, test := range tests {
+		err := s.Verify(test.mac, test.required, clientIP)
+		if test.ok && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+// TestParsePermission tests parsing valid and invalid permissions.
+func TestParsePermission(t *testing.T) {
+	t.Parallel()
+
+	perm, err := ParsePermission("onchain:write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm != (Permission{EntityOnchain, ActionWrite}) {
+		t.Fatalf("parsed %v", perm)
+	}
+	for _, s := range []string{"", "onchain", ":write", "onchain:",
+		"onchain:write,info:read"} {
+
+		if _, err := ParsePermission(s); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+}
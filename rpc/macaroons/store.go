@@ -0,0 +1,69 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package macaroons
+
+import (
+	"crypto/rand"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+var (
+	// namespaceKey is the key of the top-level bucket holding the root key
+	// and default macaroons.
+	namespaceKey = []byte("macaroons")
+
+	// rootKeyKey is the key of the root key in the namespace bucket.
+	rootKeyKey = []byte("rootkey")
+
+	// defaultsBucketKey is the key of the bucket of the default macaroons,
+	// keyed by name, in the namespace bucket.
+	defaultsBucketKey = []byte("defaults")
+)
+
+// rootKeyLen is the length of the random root key.
+const rootKeyLen = 32
+
+// Create creates the root key and bakes the default macaroons of a wallet
+// database, unless they already exist.  It is suitable for registering with
+// wallet.Loader.OnWalletCreated.
+func Create(tx walletdb.ReadWriteTx) error {
+	ns, err := tx.CreateTopLevelBucket(namespaceKey)
+	if err != nil {
+		return err
+	}
+	rootKey := ns.Get(rootKeyKey)
+	if rootKey == nil {
+		rootKey = make([]byte, rootKeyLen)
+		if _, err := rand.Read(rootKey); err != nil {
+			return err
+		}
+		if err := ns.Put(rootKeyKey, rootKey); err != nil {
+			return err
+		}
+	}
+
+	defaults, err := ns.CreateBucketIfNotExists(defaultsBucketKey)
+	if err != nil {
+		return err
+	}
+	for name, perms := range DefaultMacaroons {
+		if defaults.Get([]byte(name)) != nil {
+			continue
+		}
+		mac, err := bake(rootKey, perms)
+		if err != nil {
+			return err
+		}
+		serialized, err := mac.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if err := defaults.Put([]byte(name), serialized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
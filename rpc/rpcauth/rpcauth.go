@@ -0,0 +1,168 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package rpcauth provides the roles and credentials shared by the wallet's
+// RPC servers.
+//
+// Every set of credentials is assigned a role, and every RPC method requires a
+// role.  Roles are ordered: the spend role is permitted to call every method
+// of the read-only role, and the admin role every method.
+package rpcauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Role is the permission class of a client.
+type Role uint8
+
+const (
+	// RoleReadOnly permits methods which do not modify the wallet or
+	// reveal secrets.
+	RoleReadOnly Role = iota
+
+	// RoleSpend additionally permits methods which create addresses,
+	// sign and send transactions, and unlock the wallet to do so.
+	RoleSpend
+
+	// RoleAdmin permits every method, including those which reveal or
+	// import private keys, change the passphrase or manage accounts and
+	// rescans.
+	RoleAdmin
+)
+
+// String returns the name of the role.
+func (r Role) String() string {
+	switch r {
+	case RoleReadOnly:
+		return "readonly"
+	case RoleSpend:
+		return "spend"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return fmt.Sprintf("Role(%d)", uint8(r))
+	}
+}
+
+// Permits returns whether the role is permitted to call methods requiring the
+// given role.
+func (r Role) Permits(required Role) bool {
+	return r >= required
+}
+
+// ParseRole returns the role with the given name.
+func ParseRole(name string) (Role, error) {
+	switch name {
+	case "readonly":
+		return RoleReadOnly, nil
+	case "spend":
+		return RoleSpend, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return 0, fmt.Errorf("unknown role %q (must be readonly, "+
+			"spend or admin)", name)
+	}
+}
+
+// Credential is a username and password assigned a role.
+type Credential struct {
+	Username string
+	Password string
+	Role     Role
+}
+
+// ParseCredential parses a credential of the form username:password:role.
+// The password may contain colons, but the username may not.
+func ParseCredential(s string) (Credential, error) {
+	first := strings.Index(s, ":")
+	last := strings.LastIndex(s, ":")
+	if first <= 0 || first == last {
+		return Credential{}, errors.New("credential must be of the " +
+			"form username:password:role")
+	}
+	role, err := ParseRole(s[last+1:])
+	if err != nil {
+		return Credential{}, err
+	}
+	password := s[first+1 : last]
+	if password == "" {
+		return Credential{}, errors.New("credential password must " +
+			"not be empty")
+	}
+	return Credential{
+		Username: s[:first],
+		Password: password,
+		Role:     role,
+	}, nil
+}
+
+// BasicAuth returns the HTTP Basic authentication string of a username and
+// password:
+//
+//	"Basic " + base64(username + ":" + password)
+func BasicAuth(username, password string) string {
+	login := username + ":" + password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+}
+
+// hashedCredential is a credential stored by the hash of its HTTP Basic
+// authentication string.
+type hashedCredential struct {
+	authsha [sha256.Size]byte
+	role    Role
+}
+
+// Authenticator checks HTTP Basic authentication strings against a set of
+// credentials.  It is safe for concurrent access.
+type Authenticator struct {
+	creds []hashedCredential
+}
+
+// NewAuthenticator returns an Authenticator accepting the given credentials.
+func NewAuthenticator(creds []Credential) *Authenticator {
+	a := &Authenticator{creds: make([]hashedCredential, 0, len(creds))}
+	for _, c := range creds {
+		auth := BasicAuth(c.Username, c.Password)
+		a.creds = append(a.creds, hashedCredential{
+			// A hash of the HTTP basic auth string is used for a
+			// constant time comparison.
+			authsha: sha256.Sum256([]byte(auth)),
+			role:    c.Role,
+		})
+	}
+	return a
+}
+
+// Authenticate returns the role of the credentials of an HTTP Basic
+// authentication string, and false if they are not accepted.
+//
+// This check is time-constant for a given set of credentials.
+func (a *Authenticator) Authenticate(auth string) (Role, bool) {
+	authsha := sha256.Sum256([]byte(auth))
+
+	// Compare against every credential, without returning early, so the
+	// time taken does not depend on which credential matched.
+	var role Role
+	var ok bool
+	for _, c := range a.creds {
+		if subtle.ConstantTimeCompare(authsha[:], c.authsha[:]) == 1 {
+			role = c.role
+			ok = true
+		}
+	}
+	return role, ok
+}
+
+// AuthenticateUser returns the role of a username and password, and false if
+// they are not accepted.
+func (a *Authenticator) AuthenticateUser(username, password string) (Role, bool) {
+	return a.Authenticate(BasicAuth(username, password))
+}
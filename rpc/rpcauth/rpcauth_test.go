@@ -0,0 +1,87 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcauth
+
+import "testing"
+
+// TestParseCredential tests that credentials are parsed from their
+// username:password:role form, with passwords containing colons.
+func TestParseCredential(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s     string
+		want  Credential
+		valid bool
+	}{
+		{
+			s:     "user:pass:readonly",
+			want:  Credential{"user", "pass", RoleReadOnly},
+			valid: true,
+		},
+		{
+			s:     "user:pa:ss:spend",
+			want:  Credential{"user", "pa:ss", RoleSpend},
+			valid: true,
+		},
+		{
+			s:     "user:pass:admin",
+			want:  Credential{"user", "pass", RoleAdmin},
+			valid: true,
+		},
+		{s: "user:pass:root"},
+		{s: "user:pass"},
+		{s: ":pass:admin"},
+		{s: "user::admin"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseCredential(test.s)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected error", test.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.s, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: got %+v, want %+v", test.s, got, test.want)
+		}
+	}
+}
+
+// TestAuthenticator tests that the role of accepted credentials is returned,
+// and that roles permit the methods of lesser roles.
+func TestAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	auth := NewAuthenticator([]Credential{
+		{"admin", "adminpass", RoleAdmin},
+		{"dashboard", "dashpass", RoleReadOnly},
+	})
+
+	role, ok := auth.AuthenticateUser("dashboard", "dashpass")
+	if !ok || role != RoleReadOnly {
+		t.Fatalf("expected readonly role, got %v (accepted: %v)",
+			role, ok)
+	}
+	role, ok = auth.Authenticate(BasicAuth("admin", "adminpass"))
+	if !ok || role != RoleAdmin {
+		t.Fatalf("expected admin role, got %v (accepted: %v)", role, ok)
+	}
+	if _, ok := auth.AuthenticateUser("dashboard", "adminpass"); ok {
+		t.Fatal("accepted invalid credentials")
+	}
+
+	if !RoleAdmin.Permits(RoleSpend) || !RoleSpend.Permits(RoleReadOnly) {
+		t.Fatal("role does not permit methods of lesser role")
+	}
+	if RoleReadOnly.Permits(RoleSpend) || RoleSpend.Permits(RoleAdmin) {
+		t.Fatal("role permits methods of greater role")
+	}
+}
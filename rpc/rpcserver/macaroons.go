@@ -0,0 +1,207 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"encoding/hex"
+	"net"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/btcsuite/btcwallet/rpc/macaroons"
+)
+
+// macaroonMetadataKey is the metadata key of the hex encoded macaroon of a
+// call.
+const macaroonMetadataKey = "macaroon"
+
+const (
+	// GatewayNetwork is the network of the remote address of connections
+	// from the REST gateway.
+	GatewayNetwork = "gateway"
+
+	// ClientIPMetadataKey is the metadata key of the IP address of the
+	// HTTP client of a call through the REST gateway, which ipaddr caveats
+	// are checked against.  It's only trusted for calls over connections
+	// from the gateway.
+	ClientIPMetadataKey = "gateway-client-ip"
+)
+
+// perm is shorthand for a macaroon permission.
+func perm(entity, action string) macaroons.Permission {
+	return macaroons.Permission{Entity: entity, Action: action}
+}
+
+// methodPermissions maps every method, given by its full gRPC method name, to
+// the macaroon permissions it requires.  Methods which are not listed are
+// denied.
+var methodPermissions = map[string][]macaroons.Permission{
+	"/walletrpc.VersionService/Version": {perm(macaroons.EntityInfo, macaroons.ActionRead)},
+
+	"/walletrpc.WalletService/Ping":                     {perm(macaroons.EntityInfo, macaroons.ActionRead)},
+	"/walletrpc.WalletService/Network":                  {perm(macaroons.EntityInfo, macaroons.ActionRead)},
+	"/walletrpc.WalletService/AccountNumber":            {perm(macaroons.EntityAccount, macaroons.ActionRead)},
+	"/walletrpc.WalletService/Accounts":                 {perm(macaroons.EntityAccount, macaroons.ActionRead)},
+	"/walletrpc.WalletService/Balance":                  {perm(macaroons.EntityOnchain, macaroons.ActionRead)},
+	"/walletrpc.WalletService/GetTransactions":          {perm(macaroons.EntityOnchain, macaroons.ActionRead)},
+	"/walletrpc.WalletService/ListRescans":              {perm(macaroons.EntityRescan, macaroons.ActionRead)},
+	"/walletrpc.WalletService/GetInvoice":               {perm(macaroons.EntityInvoices, macaroons.ActionRead)},
+	"/walletrpc.WalletService/ListInvoices":             {perm(macaroons.EntityInvoices, macaroons.ActionRead)},
+	"/walletrpc.WalletService/TransactionNotifications": {perm(macaroons.EntityOnchain, macaroons.ActionRead)},
+	"/walletrpc.WalletService/SpentnessNotifications":   {perm(macaroons.EntityOnchain, macaroons.ActionRead)},
+	"/walletrpc.WalletService/AccountNotifications":     {perm(macaroons.EntityAccount, macaroons.ActionRead)},
+	"/walletrpc.WalletService/Rescan":                   {perm(macaroons.EntityRescan, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/InvoiceNotifications":     {perm(macaroons.EntityInvoices, macaroons.ActionRead)},
+	"/walletrpc.WalletService/ChangePassphrase":         {perm(macaroons.EntityWallet, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/RenameAccount":            {perm(macaroons.EntityAccount, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/NextAccount":              {perm(macaroons.EntityAccount, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/NextAddress":              {perm(macaroons.EntityAddress, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/ImportPrivateKey":         {perm(macaroons.EntityWallet, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/FundTransaction":          {perm(macaroons.EntityOnchain, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/SignTransaction":          {perm(macaroons.EntityOnchain, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/SignPsbt":                 {perm(macaroons.EntityOnchain, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/PublishTransaction":       {perm(macaroons.EntityOnchain, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/PauseRescan":              {perm(macaroons.EntityRescan, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/ResumeRescan":             {perm(macaroons.EntityRescan, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/CancelRescan":             {perm(macaroons.EntityRescan, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/CreateInvoice":            {perm(macaroons.EntityInvoices, macaroons.ActionWrite)},
+	"/walletrpc.WalletService/BakeMacaroon":             {perm(macaroons.EntityMacaroon, macaroons.ActionGenerate)},
+
+	"/walletrpc.WalletLoaderService/WalletExists":      {perm(macaroons.EntityInfo, macaroons.ActionRead)},
+	"/walletrpc.WalletLoaderService/CreateWallet":      {perm(macaroons.EntityWallet, macaroons.ActionWrite)},
+	"/walletrpc.WalletLoaderService/OpenWallet":        {perm(macaroons.EntityWallet, macaroons.ActionWrite)},
+	"/walletrpc.WalletLoaderService/CloseWallet":       {perm(macaroons.EntityWallet, macaroons.ActionWrite)},
+	"/walletrpc.WalletLoaderService/StartConsensusRpc": {perm(macaroons.EntityWallet, macaroons.ActionWrite)},
+}
+
+// bootstrapMethods are the methods permitted without a macaroon before a
+// wallet, and with it the macaroon root key, has been loaded.
+var bootstrapMethods = map[string]struct{}{
+	"/walletrpc.VersionService/Version":           {},
+	"/walletrpc.WalletLoaderService/WalletExists": {},
+	"/walletrpc.WalletLoaderService/CreateWallet": {},
+	"/walletrpc.WalletLoaderService/OpenWallet":   {},
+}
+
+// MacaroonAuth authenticates gRPC calls with the macaroons of the loaded
+// wallet.  It is safe for concurrent access.
+type MacaroonAuth struct {
+	mu      sync.RWMutex
+	service *macaroons.Service
+}
+
+// NewMacaroonAuth returns a MacaroonAuth without a macaroon service, which
+// only permits the methods needed to create or open a wallet until a service
+// is set.
+func NewMacaroonAuth() *MacaroonAuth {
+	return &MacaroonAuth{}
+}
+
+// SetService sets the service verifying macaroons, after the wallet has been
+// loaded.
+func (a *MacaroonAuth) SetService(service *macaroons.Service) {
+	a.mu.Lock()
+	a.service = service
+	a.mu.Unlock()
+}
+
+// authorize checks that the macaroon in the "macaroon" metadata of a call
+// grants the permissions required by the method of the call.
+func (a *MacaroonAuth) authorize(ctx context.Context, fullMethod string) error {
+	a.mu.RLock()
+	service := a.service
+	a.mu.RUnlock()
+
+	if service == nil {
+		if _, ok := bootstrapMethods[fullMethod]; ok {
+			return nil
+		}
+		return status.Errorf(codes.Unavailable,
+			"macaroons are unavailable until a wallet is loaded")
+	}
+
+	required, ok := methodPermissions[fullMethod]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied,
+			"method %s has no permissions", fullMethod)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(macaroonMetadataKey)
+	if len(values) == 0 {
+		return status.Errorf(codes.Unauthenticated,
+			"missing %s metadata", macaroonMetadataKey)
+	}
+	mac, err := hex.DecodeString(values[0])
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated,
+			"macaroon is not hex encoded")
+	}
+
+	err = service.Verify(mac, required, callerIP(ctx, md))
+	switch err {
+	case nil:
+		return nil
+	case macaroons.ErrPermissionDenied:
+		return status.Errorf(codes.PermissionDenied, "%v", err)
+	default:
+		return status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+}
+
+// callerIP returns the IP address of the caller of a call, which is the HTTP
+// client of calls through the REST gateway.  Nil is returned if the address is
+// unknown, which fails ipaddr caveats.
+func callerIP(ctx context.Context, md metadata.MD) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	if p.Addr.Network() != GatewayNetwork {
+		return nil
+	}
+	values := md.Get(ClientIPMetadataKey)
+	if len(values) != 1 {
+		return nil
+	}
+	return net.ParseIP(values[0])
+}
+
+// UnaryInterceptor returns a gRPC interceptor which denies unary calls without
+// a hex encoded macaroon in their "macaroon" metadata granting the permissions
+// required by their method.
+func (a *MacaroonAuth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		if err := a.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns a gRPC interceptor which denies streaming calls
+// just like the interceptor returned by UnaryInterceptor denies unary calls.
+func (a *MacaroonAuth) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
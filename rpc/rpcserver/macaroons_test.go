@@ -0,0 +1,136 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/btcsuite/btcwallet/rpc/macaroons"
+	pb "github.com/btcsuite/btcwallet/rpc/walletrpc"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// TestMethodPermissions tests that every method of the gRPC services requires
+// macaroon permissions.
+func TestMethodPermissions(t *testing.T) {
+	t.Parallel()
+
+	server := grpc.NewServer()
+	pb.RegisterVersionServiceServer(server, &versionServer{})
+	pb.RegisterWalletServiceServer(server, &walletServer{})
+	pb.RegisterWalletLoaderServiceServer(server, &loaderServer{})
+
+	for name, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			fullMethod := "/" + name + "/" + method.Name
+			if _, ok := methodPermissions[fullMethod]; !ok {
+				t.Errorf("method %s has no permissions", fullMethod)
+			}
+		}
+	}
+}
+
+// TestMacaroonAuth tests that calls are only authorized by macaroons granting
+// the permissions of their method, and that only the bootstrap methods are
+// authorized before a macaroon service is set.
+func TestMacaroonAuth(t *testing.T) {
+	t.Parallel()
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	service, err := macaroons.NewService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readonly, err := service.Macaroon("readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withMacaroon := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(macaroonMetadataKey, hex.EncodeToString(readonly)))
+
+	const (
+		balance    = "/walletrpc.WalletService/Balance"
+		publish    = "/walletrpc.WalletService/PublishTransaction"
+		openWallet = "/walletrpc.WalletLoaderService/OpenWallet"
+		unknown    = "/walletrpc.WalletService/Unknown"
+	)
+
+	auth := NewMacaroonAuth()
+	tests := []struct {
+		ctx        context.Context
+		fullMethod string
+		code       codes.Code
+		setService bool
+	}{
+		{context.Background(), openWallet, codes.OK, false},
+		{context.Background(), balance, codes.Unavailable, false},
+		{context.Background(), openWallet, codes.Unauthenticated, true},
+		{context.Background(), balance, codes.Unauthenticated, true},
+		{withMacaroon, balance, codes.OK, true},
+		{withMacaroon, publish, codes.PermissionDenied, true},
+		{withMacaroon, unknown, codes.PermissionDenied, true},
+	}
+	for i, test := range tests {
+		if test.setService {
+			auth.SetService(service)
+		}
+		err := auth.authorize(test.ctx, test.fullMethod)
+		if code := status.Code(err); code != test.code {
+			t.Errorf("test %d: %s: got code %v, want %v", i,
+				test.fullMethod, code, test.code)
+		}
+	}
+}
+
+// testAddr is a net.Addr of any network.
+type testAddr string
+
+func (a testAddr) Network() string { return string(a) }
+func (a testAddr) String() string  { return string(a) }
+
+// TestCallerIP tests that the IP address of the HTTP client forwarded by the
+// REST gateway is only trusted for calls from the gateway.
+func TestCallerIP(t *testing.T) {
+	t.Parallel()
+
+	tcpAddr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8332}
+	forwarded := metadata.Pairs(ClientIPMetadataKey, "10.0.0.2")
+	tests := []struct {
+		addr net.Addr
+		md   metadata.MD
+		ip   string
+	}{
+		{tcpAddr, nil, "10.0.0.1"},
+		{tcpAddr, forwarded, "10.0.0.1"},
+		{testAddr(GatewayNetwork), forwarded, "10.0.0.2"},
+		{testAddr(GatewayNetwork), nil, ""},
+		{testAddr("pipe"), forwarded, ""},
+	}
+	for i, test := range tests {
+		ctx := peer.NewContext(
+			context.Background(), &peer.Peer{Addr: test.addr},
+		)
+		ip := callerIP(ctx, test.md)
+		if (ip == nil && test.ip != "") ||
+			(ip != nil && ip.String() != test.ip) {
+
+			t.Errorf("test %d: got IP %v, want %q", i, ip, test.ip)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
+)
+
+// authMetadataKey is the metadata key of the HTTP Basic authentication string
+// of a call.
+const authMetadataKey = "authorization"
+
+// methodRoles maps the methods which do not require the admin role to the
+// role they require.  Every other method requires the admin role.
+var methodRoles = map[string]rpcauth.Role{
+	// Methods which do not modify the wallet or reveal secrets.
+	"/walletrpc.VersionService/Version":                 rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/Ping":                     rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/Network":                  rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/AccountNumber":            rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/Accounts":                 rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/Balance":                  rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/GetTransactions":          rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/ListRescans":              rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/GetInvoice":               rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/ListInvoices":             rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/TransactionNotifications": rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/SpentnessNotifications":   rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/AccountNotifications":     rpcauth.RoleReadOnly,
+	"/walletrpc.WalletService/InvoiceNotifications":     rpcauth.RoleReadOnly,
+	"/walletrpc.WalletLoaderService/WalletExists":       rpcauth.RoleReadOnly,
+
+	// Methods which create addresses and invoices, or sign and publish
+	// transactions.
+	"/walletrpc.WalletService/NextAddress":        rpcauth.RoleSpend,
+	"/walletrpc.WalletService/FundTransaction":    rpcauth.RoleSpend,
+	"/walletrpc.WalletService/SignTransaction":    rpcauth.RoleSpend,
+	"/walletrpc.WalletService/SignPsbt":           rpcauth.RoleSpend,
+	"/walletrpc.WalletService/PublishTransaction": rpcauth.RoleSpend,
+	"/walletrpc.WalletService/CreateInvoice":      rpcauth.RoleSpend,
+}
+
+// methodRole returns the role required to call a method, given by its full
+// gRPC method name.
+func methodRole(fullMethod string) rpcauth.Role {
+	role, ok := methodRoles[fullMethod]
+	if !ok {
+		return rpcauth.RoleAdmin
+	}
+	return role
+}
+
+// authorize checks that the HTTP Basic credentials in the "authorization"
+// metadata of a call are accepted by auth and that their role permits the
+// method of the call.
+func authorize(ctx context.Context, auth *rpcauth.Authenticator,
+	fullMethod string) error {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authMetadataKey)
+	if len(values) == 0 {
+		return status.Errorf(codes.Unauthenticated,
+			"missing %s metadata", authMetadataKey)
+	}
+	role, ok := auth.Authenticate(values[0])
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "invalid credentials")
+	}
+	if !role.Permits(methodRole(fullMethod)) {
+		return status.Errorf(codes.PermissionDenied,
+			"method not permitted for the %v role", role)
+	}
+	return nil
+}
+
+// UnaryAuthInterceptor returns a gRPC interceptor which denies unary calls
+// without credentials accepted by auth in their "authorization" metadata, or
+// whose method is not permitted for the role of the credentials.  The
+// metadata holds an HTTP Basic authentication string, as returned by
+// rpcauth.BasicAuth.
+func UnaryAuthInterceptor(auth *rpcauth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		if err := authorize(ctx, auth, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor returns a gRPC interceptor which denies streaming
+// calls just like UnaryAuthInterceptor denies unary calls.
+func StreamAuthInterceptor(auth *rpcauth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		err := authorize(ss.Context(), auth, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
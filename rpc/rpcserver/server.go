@@ -17,7 +17,10 @@ package rpcserver
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
+	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,6 +39,7 @@ import (
 	"github.com/btcsuite/btcwallet/internal/cfgutil"
 	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/btcwallet/rpc/macaroons"
 	pb "github.com/btcsuite/btcwallet/rpc/walletrpc"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
@@ -44,10 +48,10 @@ import (
 
 // Public API version constants
 const (
-	semverString = "2.0.1"
+	semverString = "2.5.0"
 	semverMajor  = 2
-	semverMinor  = 0
-	semverPatch  = 1
+	semverMinor  = 5
+	semverPatch  = 0
 )
 
 // translateError creates a new gRPC error with an appropriate error code for
@@ -85,6 +89,8 @@ func errorCode(err error) codes.Code {
 	switch err {
 	case wallet.ErrLoaded:
 		return codes.FailedPrecondition
+	case wallet.ErrRescanNotFound, wallet.ErrInvoiceNotFound:
+		return codes.NotFound
 	case walletdb.ErrDbNotOpen:
 		return codes.Aborted
 	case walletdb.ErrDbExists:
@@ -132,11 +138,75 @@ func (*versionServer) Version(ctx context.Context, req *pb.VersionRequest) (*pb.
 	}, nil
 }
 
-// StartWalletService creates an implementation of the WalletService and
-// registers it with the gRPC server.
-func StartWalletService(server *grpc.Server, wallet *wallet.Wallet) {
-	service := &walletServer{wallet}
-	pb.RegisterWalletServiceServer(server, service)
+// WalletService is an implementation of the WalletService which becomes
+// available once a wallet is loaded.  A gRPC server does not permit registering
+// services after it has started serving, so the service is registered before
+// any wallet is loaded, and its interceptors reject calls until then.
+type WalletService struct {
+	mu     sync.Mutex
+	server walletServer
+}
+
+// NewWalletService returns a WalletService without a wallet.
+func NewWalletService() *WalletService {
+	return &WalletService{}
+}
+
+// SetWallet makes the service available for the loaded wallet.  It may only be
+// called once.
+func (s *WalletService) SetWallet(w *wallet.Wallet) {
+	s.mu.Lock()
+	s.server.wallet = w
+	s.mu.Unlock()
+}
+
+// available returns an Unavailable error for calls of WalletService methods
+// before a wallet is set.  Handlers called after it returns nil observe the
+// wallet.
+func (s *WalletService) available(fullMethod string) error {
+	if !strings.HasPrefix(fullMethod, "/walletrpc.WalletService/") {
+		return nil
+	}
+	s.mu.Lock()
+	loaded := s.server.wallet != nil
+	s.mu.Unlock()
+	if !loaded {
+		return status.Errorf(codes.Unavailable, "wallet is not loaded")
+	}
+	return nil
+}
+
+// UnaryInterceptor returns a gRPC interceptor which rejects unary calls of
+// WalletService methods before a wallet is set.
+func (s *WalletService) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		if err := s.available(info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns a gRPC interceptor which rejects streaming calls
+// of WalletService methods before a wallet is set.
+func (s *WalletService) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		if err := s.available(info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// StartWalletService registers the WalletService with the gRPC server.  The
+// server must be created with the interceptors of the service.
+func StartWalletService(server *grpc.Server, service *WalletService) {
+	pb.RegisterWalletServiceServer(server, &service.server)
 }
 
 func (s *walletServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
@@ -485,6 +555,43 @@ func (s *walletServer) SignTransaction(ctx context.Context, req *pb.SignTransact
 	return resp, nil
 }
 
+func (s *walletServer) SignPsbt(ctx context.Context, req *pb.SignPsbtRequest) (
+	*pb.SignPsbtResponse, error) {
+
+	defer zero.Bytes(req.Passphrase)
+
+	packet, version, err := wallet.DecodePsbt(req.Psbt)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"Bytes do not represent a valid PSBT: %v", err)
+	}
+
+	lock := make(chan time.Time, 1)
+	defer func() {
+		lock <- time.Time{} // send matters, not the value
+	}()
+	err = s.wallet.Unlock(req.Passphrase, lock)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	signed, err := s.wallet.SignPsbt(packet)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	serializedPsbt, err := wallet.EncodePsbt(packet, version)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	resp := &pb.SignPsbtResponse{
+		Psbt:               serializedPsbt,
+		SignedInputIndexes: signed,
+	}
+	return resp, nil
+}
+
 // BUGS:
 // - The transaction is not inspected to be relevant before publishing using
 //   sendrawtransaction, so connection errors to btcd could result in the tx
@@ -510,6 +617,226 @@ func (s *walletServer) PublishTransaction(ctx context.Context, req *pb.PublishTr
 	return &pb.PublishTransactionResponse{}, nil
 }
 
+func (s *walletServer) ListRescans(ctx context.Context, req *pb.ListRescansRequest) (
+	*pb.ListRescansResponse, error) {
+
+	rescans, err := s.wallet.Rescans()
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	resp := &pb.ListRescansResponse{
+		Rescans: make([]*pb.ListRescansResponse_Rescan, len(rescans)),
+	}
+	for i, rescan := range rescans {
+		addrs := make([]string, len(rescan.Addrs))
+		for j, addr := range rescan.Addrs {
+			addrs[j] = addr.EncodeAddress()
+		}
+
+		var state pb.ListRescansResponse_Rescan_State
+		switch rescan.State {
+		case wallet.RescanStateActive:
+			state = pb.ListRescansResponse_Rescan_ACTIVE
+		case wallet.RescanStatePaused:
+			state = pb.ListRescansResponse_Rescan_PAUSED
+		}
+
+		r := &pb.ListRescansResponse_Rescan{
+			Id:             rescan.ID,
+			State:          state,
+			Running:        rescan.Running,
+			Queued:         rescan.Queued,
+			StartHeight:    rescan.StartBlock.Height,
+			StartHash:      rescan.StartBlock.Hash[:],
+			ProgressHeight: rescan.ProgressBlock.Height,
+			Addresses:      addrs,
+			OutpointCount:  uint32(len(rescan.OutPoints)),
+		}
+		if rescan.ProgressBlock.Height != 0 {
+			r.ProgressHash = rescan.ProgressBlock.Hash[:]
+		}
+		resp.Rescans[i] = r
+	}
+	return resp, nil
+}
+
+func (s *walletServer) PauseRescan(ctx context.Context, req *pb.PauseRescanRequest) (
+	*pb.PauseRescanResponse, error) {
+
+	err := s.wallet.PauseRescan(req.Id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &pb.PauseRescanResponse{}, nil
+}
+
+func (s *walletServer) ResumeRescan(ctx context.Context, req *pb.ResumeRescanRequest) (
+	*pb.ResumeRescanResponse, error) {
+
+	err := s.wallet.ResumeRescan(req.Id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &pb.ResumeRescanResponse{}, nil
+}
+
+func (s *walletServer) CancelRescan(ctx context.Context, req *pb.CancelRescanRequest) (
+	*pb.CancelRescanResponse, error) {
+
+	err := s.wallet.CancelRescan(req.Id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &pb.CancelRescanResponse{}, nil
+}
+
+func (s *walletServer) CreateInvoice(ctx context.Context, req *pb.CreateInvoiceRequest) (
+	*pb.CreateInvoiceResponse, error) {
+
+	if req.Amount < 0 || req.ExpirySeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"amount and expiry_seconds may not be negative")
+	}
+
+	inv, err := s.wallet.CreateInvoice(waddrmgr.KeyScopeBIP0044, req.Account,
+		btcutil.Amount(req.Amount), req.Memo,
+		time.Duration(req.ExpirySeconds)*time.Second)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &pb.CreateInvoiceResponse{Invoice: marshalInvoice(inv)}, nil
+}
+
+func (s *walletServer) BakeMacaroon(ctx context.Context, req *pb.BakeMacaroonRequest) (
+	*pb.BakeMacaroonResponse, error) {
+
+	if len(req.Permissions) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"at least one permission is required")
+	}
+	if req.TimeoutSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"timeout_seconds may not be negative")
+	}
+
+	perms := make([]macaroons.Permission, 0, len(req.Permissions))
+	for _, p := range req.Permissions {
+		perm, err := macaroons.ParsePermission(p.Entity + ":" + p.Action)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		perms = append(perms, perm)
+	}
+	var caveats []string
+	if req.TimeoutSeconds != 0 {
+		timeout := time.Duration(req.TimeoutSeconds) * time.Second
+		caveats = append(caveats,
+			macaroons.TimeBeforeCaveat(time.Now().Add(timeout)))
+	}
+	if req.IpAddress != "" {
+		ip := net.ParseIP(req.IpAddress)
+		if ip == nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid ip_address %q", req.IpAddress)
+		}
+		caveats = append(caveats, macaroons.IPAddrCaveat(ip))
+	}
+
+	service, err := macaroons.NewService(s.wallet.Database())
+	if err != nil {
+		return nil, translateError(err)
+	}
+	mac, err := service.Bake(perms, caveats...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	serialized, err := mac.MarshalBinary()
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &pb.BakeMacaroonResponse{Macaroon: hex.EncodeToString(serialized)}, nil
+}
+
+func (s *walletServer) GetInvoice(ctx context.Context, req *pb.GetInvoiceRequest) (
+	*pb.GetInvoiceResponse, error) {
+
+	inv, err := s.wallet.Invoice(req.Id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &pb.GetInvoiceResponse{Invoice: marshalInvoice(inv)}, nil
+}
+
+func (s *walletServer) ListInvoices(ctx context.Context, req *pb.ListInvoicesRequest) (
+	*pb.ListInvoicesResponse, error) {
+
+	invoices, err := s.wallet.Invoices()
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	resp := &pb.ListInvoicesResponse{
+		Invoices: make([]*pb.Invoice, len(invoices)),
+	}
+	for i, inv := range invoices {
+		resp.Invoices[i] = marshalInvoice(inv)
+	}
+	return resp, nil
+}
+
+func marshalInvoiceStatus(status wallet.InvoiceStatus) pb.Invoice_Status {
+	switch status {
+	case wallet.InvoiceStatusPartial:
+		return pb.Invoice_PARTIAL
+	case wallet.InvoiceStatusPaid:
+		return pb.Invoice_PAID
+	case wallet.InvoiceStatusOverpaid:
+		return pb.Invoice_OVERPAID
+	case wallet.InvoiceStatusExpired:
+		return pb.Invoice_EXPIRED
+	default:
+		return pb.Invoice_UNPAID
+	}
+}
+
+func marshalInvoice(inv *wallet.Invoice) *pb.Invoice {
+	payments := make([]*pb.Invoice_Payment, len(inv.Payments))
+	for i := range inv.Payments {
+		p := &inv.Payments[i]
+		payments[i] = &pb.Invoice_Payment{
+			TransactionHash: p.OutPoint.Hash[:],
+			OutputIndex:     p.OutPoint.Index,
+			Amount:          int64(p.Amount),
+		}
+	}
+
+	var expiry int64
+	if !inv.Expiry.IsZero() {
+		expiry = inv.Expiry.Unix()
+	}
+
+	return &pb.Invoice{
+		Id:       inv.ID,
+		Account:  inv.Account,
+		Address:  inv.Address.EncodeAddress(),
+		Amount:   int64(inv.Amount),
+		Received: int64(inv.Received()),
+		Memo:     inv.Memo,
+		Created:  inv.Created.Unix(),
+		Expiry:   expiry,
+		Status:   marshalInvoiceStatus(inv.Status),
+		Uri:      inv.URI(),
+		Payments: payments,
+	}
+}
+
 func marshalTransactionInputs(v []wallet.TransactionSummaryInput) []*pb.TransactionDetails_Input {
 	inputs := make([]*pb.TransactionDetails_Input, len(v))
 	for i := range v {
@@ -574,6 +901,14 @@ func marshalHashes(v []*chainhash.Hash) [][]byte {
 	return hashes
 }
 
+func marshalHashSlice(v []chainhash.Hash) [][]byte {
+	hashes := make([][]byte, len(v))
+	for i := range v {
+		hashes[i] = v[i][:]
+	}
+	return hashes
+}
+
 func (s *walletServer) TransactionNotifications(req *pb.TransactionNotificationsRequest,
 	svr pb.WalletService_TransactionNotificationsServer) error {
 
@@ -601,6 +936,96 @@ func (s *walletServer) TransactionNotifications(req *pb.TransactionNotifications
 	}
 }
 
+func (s *walletServer) Rescan(req *pb.RescanRequest, svr pb.WalletService_RescanServer) error {
+	if req.StartHeight < 0 || req.StopHeight < 0 {
+		return status.Errorf(codes.InvalidArgument,
+			"start_height and stop_height may not be negative")
+	}
+	if req.StopHeight != 0 && req.StopHeight < req.StartHeight {
+		return status.Errorf(codes.InvalidArgument,
+			"stop_height may not be below start_height")
+	}
+
+	type rescanResult struct {
+		summary *wallet.RescanSummary
+		err     error
+	}
+
+	progress := make(chan waddrmgr.BlockStamp, 1)
+	done := make(chan rescanResult, 1)
+	go func() {
+		summary, err := s.wallet.RescanBlockchain(
+			req.StartHeight, req.StopHeight, progress,
+		)
+		done <- rescanResult{summary, err}
+	}()
+
+	ctxDone := svr.Context().Done()
+	for {
+		select {
+		case bs := <-progress:
+			resp := &pb.RescanResponse{
+				RescannedThrough:     bs.Height,
+				RescannedThroughHash: bs.Hash[:],
+			}
+			err := svr.Send(resp)
+			if err != nil {
+				return translateError(err)
+			}
+
+		case r := <-done:
+			if r.err != nil {
+				return translateError(r.err)
+			}
+			resp := &pb.RescanResponse{
+				RescannedThrough:     r.summary.StopBlock.Height,
+				RescannedThroughHash: r.summary.StopBlock.Hash[:],
+				Summary: &pb.RescanResponse_Summary{
+					StartHeight:          r.summary.StartBlock.Height,
+					StopHeight:           r.summary.StopBlock.Height,
+					TransactionHashes:    marshalHashSlice(r.summary.Transactions),
+					NewTransactionHashes: marshalHashSlice(r.summary.NewTransactions),
+				},
+			}
+			err := svr.Send(resp)
+			if err != nil {
+				return translateError(err)
+			}
+			return nil
+
+		case <-ctxDone:
+			// The rescan is persisted by the wallet and continues in
+			// the background.
+			return nil
+		}
+	}
+}
+
+func (s *walletServer) InvoiceNotifications(req *pb.InvoiceNotificationsRequest,
+	svr pb.WalletService_InvoiceNotificationsServer) error {
+
+	n := s.wallet.NtfnServer.InvoiceNotifications()
+	defer n.Done()
+
+	ctxDone := svr.Context().Done()
+	for {
+		select {
+		case v := <-n.C:
+			resp := pb.InvoiceNotificationsResponse{
+				Invoice:        marshalInvoice(v.Invoice),
+				PreviousStatus: marshalInvoiceStatus(v.PreviousStatus),
+			}
+			err := svr.Send(&resp)
+			if err != nil {
+				return translateError(err)
+			}
+
+		case <-ctxDone:
+			return nil
+		}
+	}
+}
+
 func (s *walletServer) SpentnessNotifications(req *pb.SpentnessNotificationsRequest,
 	svr pb.WalletService_SpentnessNotificationsServer) error {
 
@@ -12,6 +12,7 @@ It has these top-level messages:
 	VersionResponse
 	TransactionDetails
 	BlockDetails
+	Invoice
 	AccountBalance
 	PingRequest
 	PingResponse
@@ -39,8 +40,31 @@ It has these top-level messages:
 	FundTransactionResponse
 	SignTransactionRequest
 	SignTransactionResponse
+	SignPsbtRequest
+	SignPsbtResponse
 	PublishTransactionRequest
 	PublishTransactionResponse
+	ListRescansRequest
+	ListRescansResponse
+	PauseRescanRequest
+	PauseRescanResponse
+	ResumeRescanRequest
+	ResumeRescanResponse
+	CancelRescanRequest
+	CancelRescanResponse
+	RescanRequest
+	RescanResponse
+	CreateInvoiceRequest
+	CreateInvoiceResponse
+	MacaroonPermission
+	BakeMacaroonRequest
+	BakeMacaroonResponse
+	GetInvoiceRequest
+	GetInvoiceResponse
+	ListInvoicesRequest
+	ListInvoicesResponse
+	InvoiceNotificationsRequest
+	InvoiceNotificationsResponse
 	TransactionNotificationsRequest
 	TransactionNotificationsResponse
 	SpentnessNotificationsRequest
@@ -83,6 +107,36 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type Invoice_Status int32
+
+const (
+	Invoice_UNPAID   Invoice_Status = 0
+	Invoice_PARTIAL  Invoice_Status = 1
+	Invoice_PAID     Invoice_Status = 2
+	Invoice_OVERPAID Invoice_Status = 3
+	Invoice_EXPIRED  Invoice_Status = 4
+)
+
+var Invoice_Status_name = map[int32]string{
+	0: "UNPAID",
+	1: "PARTIAL",
+	2: "PAID",
+	3: "OVERPAID",
+	4: "EXPIRED",
+}
+var Invoice_Status_value = map[string]int32{
+	"UNPAID":   0,
+	"PARTIAL":  1,
+	"PAID":     2,
+	"OVERPAID": 3,
+	"EXPIRED":  4,
+}
+
+func (x Invoice_Status) String() string {
+	return proto.EnumName(Invoice_Status_name, int32(x))
+}
+func (Invoice_Status) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
+
 type NextAddressRequest_Kind int32
 
 const (
@@ -102,7 +156,7 @@ var NextAddressRequest_Kind_value = map[string]int32{
 func (x NextAddressRequest_Kind) String() string {
 	return proto.EnumName(NextAddressRequest_Kind_name, int32(x))
 }
-func (NextAddressRequest_Kind) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{17, 0} }
+func (NextAddressRequest_Kind) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{18, 0} }
 
 type ChangePassphraseRequest_Key int32
 
@@ -124,7 +178,30 @@ func (x ChangePassphraseRequest_Key) String() string {
 	return proto.EnumName(ChangePassphraseRequest_Key_name, int32(x))
 }
 func (ChangePassphraseRequest_Key) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{25, 0}
+	return fileDescriptor0, []int{26, 0}
+}
+
+type ListRescansResponse_Rescan_State int32
+
+const (
+	ListRescansResponse_Rescan_ACTIVE ListRescansResponse_Rescan_State = 0
+	ListRescansResponse_Rescan_PAUSED ListRescansResponse_Rescan_State = 1
+)
+
+var ListRescansResponse_Rescan_State_name = map[int32]string{
+	0: "ACTIVE",
+	1: "PAUSED",
+}
+var ListRescansResponse_Rescan_State_value = map[string]int32{
+	"ACTIVE": 0,
+	"PAUSED": 1,
+}
+
+func (x ListRescansResponse_Rescan_State) String() string {
+	return proto.EnumName(ListRescansResponse_Rescan_State_name, int32(x))
+}
+func (ListRescansResponse_Rescan_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{37, 0, 0}
 }
 
 type VersionRequest struct {
@@ -351,6 +428,134 @@ func (m *BlockDetails) GetTransactions() []*TransactionDetails {
 	return nil
 }
 
+type Invoice struct {
+	Id       uint64             `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Account  uint32             `protobuf:"varint,2,opt,name=account" json:"account,omitempty"`
+	Address  string             `protobuf:"bytes,3,opt,name=address" json:"address,omitempty"`
+	Amount   int64              `protobuf:"varint,4,opt,name=amount" json:"amount,omitempty"`
+	Received int64              `protobuf:"varint,5,opt,name=received" json:"received,omitempty"`
+	Memo     string             `protobuf:"bytes,6,opt,name=memo" json:"memo,omitempty"`
+	Created  int64              `protobuf:"varint,7,opt,name=created" json:"created,omitempty"`
+	Expiry   int64              `protobuf:"varint,8,opt,name=expiry" json:"expiry,omitempty"`
+	Status   Invoice_Status     `protobuf:"varint,9,opt,name=status,enum=walletrpc.Invoice_Status" json:"status,omitempty"`
+	Uri      string             `protobuf:"bytes,10,opt,name=uri" json:"uri,omitempty"`
+	Payments []*Invoice_Payment `protobuf:"bytes,11,rep,name=payments" json:"payments,omitempty"`
+}
+
+func (m *Invoice) Reset()                    { *m = Invoice{} }
+func (m *Invoice) String() string            { return proto.CompactTextString(m) }
+func (*Invoice) ProtoMessage()               {}
+func (*Invoice) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Invoice) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Invoice) GetAccount() uint32 {
+	if m != nil {
+		return m.Account
+	}
+	return 0
+}
+
+func (m *Invoice) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *Invoice) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *Invoice) GetReceived() int64 {
+	if m != nil {
+		return m.Received
+	}
+	return 0
+}
+
+func (m *Invoice) GetMemo() string {
+	if m != nil {
+		return m.Memo
+	}
+	return ""
+}
+
+func (m *Invoice) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+func (m *Invoice) GetExpiry() int64 {
+	if m != nil {
+		return m.Expiry
+	}
+	return 0
+}
+
+func (m *Invoice) GetStatus() Invoice_Status {
+	if m != nil {
+		return m.Status
+	}
+	return Invoice_UNPAID
+}
+
+func (m *Invoice) GetUri() string {
+	if m != nil {
+		return m.Uri
+	}
+	return ""
+}
+
+func (m *Invoice) GetPayments() []*Invoice_Payment {
+	if m != nil {
+		return m.Payments
+	}
+	return nil
+}
+
+type Invoice_Payment struct {
+	TransactionHash []byte `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	OutputIndex     uint32 `protobuf:"varint,2,opt,name=output_index,json=outputIndex" json:"output_index,omitempty"`
+	Amount          int64  `protobuf:"varint,3,opt,name=amount" json:"amount,omitempty"`
+}
+
+func (m *Invoice_Payment) Reset()                    { *m = Invoice_Payment{} }
+func (m *Invoice_Payment) String() string            { return proto.CompactTextString(m) }
+func (*Invoice_Payment) ProtoMessage()               {}
+func (*Invoice_Payment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
+
+func (m *Invoice_Payment) GetTransactionHash() []byte {
+	if m != nil {
+		return m.TransactionHash
+	}
+	return nil
+}
+
+func (m *Invoice_Payment) GetOutputIndex() uint32 {
+	if m != nil {
+		return m.OutputIndex
+	}
+	return 0
+}
+
+func (m *Invoice_Payment) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
 type AccountBalance struct {
 	Account      uint32 `protobuf:"varint,1,opt,name=account" json:"account,omitempty"`
 	TotalBalance int64  `protobuf:"varint,2,opt,name=total_balance,json=totalBalance" json:"total_balance,omitempty"`
@@ -359,7 +564,7 @@ type AccountBalance struct {
 func (m *AccountBalance) Reset()                    { *m = AccountBalance{} }
 func (m *AccountBalance) String() string            { return proto.CompactTextString(m) }
 func (*AccountBalance) ProtoMessage()               {}
-func (*AccountBalance) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*AccountBalance) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *AccountBalance) GetAccount() uint32 {
 	if m != nil {
@@ -381,7 +586,7 @@ type PingRequest struct {
 func (m *PingRequest) Reset()                    { *m = PingRequest{} }
 func (m *PingRequest) String() string            { return proto.CompactTextString(m) }
 func (*PingRequest) ProtoMessage()               {}
-func (*PingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*PingRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 type PingResponse struct {
 }
@@ -389,7 +594,7 @@ type PingResponse struct {
 func (m *PingResponse) Reset()                    { *m = PingResponse{} }
 func (m *PingResponse) String() string            { return proto.CompactTextString(m) }
 func (*PingResponse) ProtoMessage()               {}
-func (*PingResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*PingResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 type NetworkRequest struct {
 }
@@ -397,7 +602,7 @@ type NetworkRequest struct {
 func (m *NetworkRequest) Reset()                    { *m = NetworkRequest{} }
 func (m *NetworkRequest) String() string            { return proto.CompactTextString(m) }
 func (*NetworkRequest) ProtoMessage()               {}
-func (*NetworkRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*NetworkRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 type NetworkResponse struct {
 	ActiveNetwork uint32 `protobuf:"varint,1,opt,name=active_network,json=activeNetwork" json:"active_network,omitempty"`
@@ -406,7 +611,7 @@ type NetworkResponse struct {
 func (m *NetworkResponse) Reset()                    { *m = NetworkResponse{} }
 func (m *NetworkResponse) String() string            { return proto.CompactTextString(m) }
 func (*NetworkResponse) ProtoMessage()               {}
-func (*NetworkResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*NetworkResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *NetworkResponse) GetActiveNetwork() uint32 {
 	if m != nil {
@@ -422,7 +627,7 @@ type AccountNumberRequest struct {
 func (m *AccountNumberRequest) Reset()                    { *m = AccountNumberRequest{} }
 func (m *AccountNumberRequest) String() string            { return proto.CompactTextString(m) }
 func (*AccountNumberRequest) ProtoMessage()               {}
-func (*AccountNumberRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*AccountNumberRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
 func (m *AccountNumberRequest) GetAccountName() string {
 	if m != nil {
@@ -438,7 +643,7 @@ type AccountNumberResponse struct {
 func (m *AccountNumberResponse) Reset()                    { *m = AccountNumberResponse{} }
 func (m *AccountNumberResponse) String() string            { return proto.CompactTextString(m) }
 func (*AccountNumberResponse) ProtoMessage()               {}
-func (*AccountNumberResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*AccountNumberResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
 
 func (m *AccountNumberResponse) GetAccountNumber() uint32 {
 	if m != nil {
@@ -453,7 +658,7 @@ type AccountsRequest struct {
 func (m *AccountsRequest) Reset()                    { *m = AccountsRequest{} }
 func (m *AccountsRequest) String() string            { return proto.CompactTextString(m) }
 func (*AccountsRequest) ProtoMessage()               {}
-func (*AccountsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*AccountsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 type AccountsResponse struct {
 	Accounts           []*AccountsResponse_Account `protobuf:"bytes,1,rep,name=accounts" json:"accounts,omitempty"`
@@ -464,7 +669,7 @@ type AccountsResponse struct {
 func (m *AccountsResponse) Reset()                    { *m = AccountsResponse{} }
 func (m *AccountsResponse) String() string            { return proto.CompactTextString(m) }
 func (*AccountsResponse) ProtoMessage()               {}
-func (*AccountsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*AccountsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *AccountsResponse) GetAccounts() []*AccountsResponse_Account {
 	if m != nil {
@@ -499,7 +704,7 @@ type AccountsResponse_Account struct {
 func (m *AccountsResponse_Account) Reset()                    { *m = AccountsResponse_Account{} }
 func (m *AccountsResponse_Account) String() string            { return proto.CompactTextString(m) }
 func (*AccountsResponse_Account) ProtoMessage()               {}
-func (*AccountsResponse_Account) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12, 0} }
+func (*AccountsResponse_Account) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13, 0} }
 
 func (m *AccountsResponse_Account) GetAccountNumber() uint32 {
 	if m != nil {
@@ -551,7 +756,7 @@ type RenameAccountRequest struct {
 func (m *RenameAccountRequest) Reset()                    { *m = RenameAccountRequest{} }
 func (m *RenameAccountRequest) String() string            { return proto.CompactTextString(m) }
 func (*RenameAccountRequest) ProtoMessage()               {}
-func (*RenameAccountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*RenameAccountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *RenameAccountRequest) GetAccountNumber() uint32 {
 	if m != nil {
@@ -573,7 +778,7 @@ type RenameAccountResponse struct {
 func (m *RenameAccountResponse) Reset()                    { *m = RenameAccountResponse{} }
 func (m *RenameAccountResponse) String() string            { return proto.CompactTextString(m) }
 func (*RenameAccountResponse) ProtoMessage()               {}
-func (*RenameAccountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*RenameAccountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 type NextAccountRequest struct {
 	Passphrase  []byte `protobuf:"bytes,1,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
@@ -583,7 +788,7 @@ type NextAccountRequest struct {
 func (m *NextAccountRequest) Reset()                    { *m = NextAccountRequest{} }
 func (m *NextAccountRequest) String() string            { return proto.CompactTextString(m) }
 func (*NextAccountRequest) ProtoMessage()               {}
-func (*NextAccountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*NextAccountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *NextAccountRequest) GetPassphrase() []byte {
 	if m != nil {
@@ -606,7 +811,7 @@ type NextAccountResponse struct {
 func (m *NextAccountResponse) Reset()                    { *m = NextAccountResponse{} }
 func (m *NextAccountResponse) String() string            { return proto.CompactTextString(m) }
 func (*NextAccountResponse) ProtoMessage()               {}
-func (*NextAccountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (*NextAccountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *NextAccountResponse) GetAccountNumber() uint32 {
 	if m != nil {
@@ -623,7 +828,7 @@ type NextAddressRequest struct {
 func (m *NextAddressRequest) Reset()                    { *m = NextAddressRequest{} }
 func (m *NextAddressRequest) String() string            { return proto.CompactTextString(m) }
 func (*NextAddressRequest) ProtoMessage()               {}
-func (*NextAddressRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (*NextAddressRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
 
 func (m *NextAddressRequest) GetAccount() uint32 {
 	if m != nil {
@@ -646,7 +851,7 @@ type NextAddressResponse struct {
 func (m *NextAddressResponse) Reset()                    { *m = NextAddressResponse{} }
 func (m *NextAddressResponse) String() string            { return proto.CompactTextString(m) }
 func (*NextAddressResponse) ProtoMessage()               {}
-func (*NextAddressResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+func (*NextAddressResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
 
 func (m *NextAddressResponse) GetAddress() string {
 	if m != nil {
@@ -665,7 +870,7 @@ type ImportPrivateKeyRequest struct {
 func (m *ImportPrivateKeyRequest) Reset()                    { *m = ImportPrivateKeyRequest{} }
 func (m *ImportPrivateKeyRequest) String() string            { return proto.CompactTextString(m) }
 func (*ImportPrivateKeyRequest) ProtoMessage()               {}
-func (*ImportPrivateKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+func (*ImportPrivateKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *ImportPrivateKeyRequest) GetPassphrase() []byte {
 	if m != nil {
@@ -701,7 +906,7 @@ type ImportPrivateKeyResponse struct {
 func (m *ImportPrivateKeyResponse) Reset()                    { *m = ImportPrivateKeyResponse{} }
 func (m *ImportPrivateKeyResponse) String() string            { return proto.CompactTextString(m) }
 func (*ImportPrivateKeyResponse) ProtoMessage()               {}
-func (*ImportPrivateKeyResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+func (*ImportPrivateKeyResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
 type BalanceRequest struct {
 	AccountNumber         uint32 `protobuf:"varint,1,opt,name=account_number,json=accountNumber" json:"account_number,omitempty"`
@@ -711,7 +916,7 @@ type BalanceRequest struct {
 func (m *BalanceRequest) Reset()                    { *m = BalanceRequest{} }
 func (m *BalanceRequest) String() string            { return proto.CompactTextString(m) }
 func (*BalanceRequest) ProtoMessage()               {}
-func (*BalanceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+func (*BalanceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
 
 func (m *BalanceRequest) GetAccountNumber() uint32 {
 	if m != nil {
@@ -736,7 +941,7 @@ type BalanceResponse struct {
 func (m *BalanceResponse) Reset()                    { *m = BalanceResponse{} }
 func (m *BalanceResponse) String() string            { return proto.CompactTextString(m) }
 func (*BalanceResponse) ProtoMessage()               {}
-func (*BalanceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+func (*BalanceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
 
 func (m *BalanceResponse) GetTotal() int64 {
 	if m != nil {
@@ -785,7 +990,7 @@ type GetTransactionsRequest struct {
 func (m *GetTransactionsRequest) Reset()                    { *m = GetTransactionsRequest{} }
 func (m *GetTransactionsRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetTransactionsRequest) ProtoMessage()               {}
-func (*GetTransactionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+func (*GetTransactionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
 
 func (m *GetTransactionsRequest) GetStartingBlockHash() []byte {
 	if m != nil {
@@ -830,7 +1035,7 @@ type GetTransactionsResponse struct {
 func (m *GetTransactionsResponse) Reset()                    { *m = GetTransactionsResponse{} }
 func (m *GetTransactionsResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetTransactionsResponse) ProtoMessage()               {}
-func (*GetTransactionsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+func (*GetTransactionsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
 
 func (m *GetTransactionsResponse) GetMinedTransactions() []*BlockDetails {
 	if m != nil {
@@ -855,7 +1060,7 @@ type ChangePassphraseRequest struct {
 func (m *ChangePassphraseRequest) Reset()                    { *m = ChangePassphraseRequest{} }
 func (m *ChangePassphraseRequest) String() string            { return proto.CompactTextString(m) }
 func (*ChangePassphraseRequest) ProtoMessage()               {}
-func (*ChangePassphraseRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+func (*ChangePassphraseRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
 
 func (m *ChangePassphraseRequest) GetKey() ChangePassphraseRequest_Key {
 	if m != nil {
@@ -884,7 +1089,7 @@ type ChangePassphraseResponse struct {
 func (m *ChangePassphraseResponse) Reset()                    { *m = ChangePassphraseResponse{} }
 func (m *ChangePassphraseResponse) String() string            { return proto.CompactTextString(m) }
 func (*ChangePassphraseResponse) ProtoMessage()               {}
-func (*ChangePassphraseResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+func (*ChangePassphraseResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
 
 type FundTransactionRequest struct {
 	Account                  uint32 `protobuf:"varint,1,opt,name=account" json:"account,omitempty"`
@@ -897,7 +1102,7 @@ type FundTransactionRequest struct {
 func (m *FundTransactionRequest) Reset()                    { *m = FundTransactionRequest{} }
 func (m *FundTransactionRequest) String() string            { return proto.CompactTextString(m) }
 func (*FundTransactionRequest) ProtoMessage()               {}
-func (*FundTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+func (*FundTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
 
 func (m *FundTransactionRequest) GetAccount() uint32 {
 	if m != nil {
@@ -943,7 +1148,7 @@ type FundTransactionResponse struct {
 func (m *FundTransactionResponse) Reset()                    { *m = FundTransactionResponse{} }
 func (m *FundTransactionResponse) String() string            { return proto.CompactTextString(m) }
 func (*FundTransactionResponse) ProtoMessage()               {}
-func (*FundTransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+func (*FundTransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
 
 func (m *FundTransactionResponse) GetSelectedOutputs() []*FundTransactionResponse_PreviousOutput {
 	if m != nil {
@@ -981,7 +1186,7 @@ func (m *FundTransactionResponse_PreviousOutput) Reset() {
 func (m *FundTransactionResponse_PreviousOutput) String() string { return proto.CompactTextString(m) }
 func (*FundTransactionResponse_PreviousOutput) ProtoMessage()    {}
 func (*FundTransactionResponse_PreviousOutput) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{28, 0}
+	return fileDescriptor0, []int{29, 0}
 }
 
 func (m *FundTransactionResponse_PreviousOutput) GetTransactionHash() []byte {
@@ -1040,7 +1245,7 @@ type SignTransactionRequest struct {
 func (m *SignTransactionRequest) Reset()                    { *m = SignTransactionRequest{} }
 func (m *SignTransactionRequest) String() string            { return proto.CompactTextString(m) }
 func (*SignTransactionRequest) ProtoMessage()               {}
-func (*SignTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+func (*SignTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
 
 func (m *SignTransactionRequest) GetPassphrase() []byte {
 	if m != nil {
@@ -1071,7 +1276,7 @@ type SignTransactionResponse struct {
 func (m *SignTransactionResponse) Reset()                    { *m = SignTransactionResponse{} }
 func (m *SignTransactionResponse) String() string            { return proto.CompactTextString(m) }
 func (*SignTransactionResponse) ProtoMessage()               {}
-func (*SignTransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+func (*SignTransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
 
 func (m *SignTransactionResponse) GetTransaction() []byte {
 	if m != nil {
@@ -1087,6 +1292,56 @@ func (m *SignTransactionResponse) GetUnsignedInputIndexes() []uint32 {
 	return nil
 }
 
+type SignPsbtRequest struct {
+	Passphrase []byte `protobuf:"bytes,1,opt,name=passphrase,proto3" json:"passphrase,omitempty"`
+	// The serialized BIP 174 or BIP 370 packet.  Inputs must include their
+	// UTXO information to be signed.
+	Psbt []byte `protobuf:"bytes,2,opt,name=psbt,proto3" json:"psbt,omitempty"`
+}
+
+func (m *SignPsbtRequest) Reset()                    { *m = SignPsbtRequest{} }
+func (m *SignPsbtRequest) String() string            { return proto.CompactTextString(m) }
+func (*SignPsbtRequest) ProtoMessage()               {}
+func (*SignPsbtRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *SignPsbtRequest) GetPassphrase() []byte {
+	if m != nil {
+		return m.Passphrase
+	}
+	return nil
+}
+
+func (m *SignPsbtRequest) GetPsbt() []byte {
+	if m != nil {
+		return m.Psbt
+	}
+	return nil
+}
+
+type SignPsbtResponse struct {
+	Psbt               []byte   `protobuf:"bytes,1,opt,name=psbt,proto3" json:"psbt,omitempty"`
+	SignedInputIndexes []uint32 `protobuf:"varint,2,rep,packed,name=signed_input_indexes,json=signedInputIndexes" json:"signed_input_indexes,omitempty"`
+}
+
+func (m *SignPsbtResponse) Reset()                    { *m = SignPsbtResponse{} }
+func (m *SignPsbtResponse) String() string            { return proto.CompactTextString(m) }
+func (*SignPsbtResponse) ProtoMessage()               {}
+func (*SignPsbtResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *SignPsbtResponse) GetPsbt() []byte {
+	if m != nil {
+		return m.Psbt
+	}
+	return nil
+}
+
+func (m *SignPsbtResponse) GetSignedInputIndexes() []uint32 {
+	if m != nil {
+		return m.SignedInputIndexes
+	}
+	return nil
+}
+
 type PublishTransactionRequest struct {
 	SignedTransaction []byte `protobuf:"bytes,1,opt,name=signed_transaction,json=signedTransaction,proto3" json:"signed_transaction,omitempty"`
 }
@@ -1094,7 +1349,7 @@ type PublishTransactionRequest struct {
 func (m *PublishTransactionRequest) Reset()                    { *m = PublishTransactionRequest{} }
 func (m *PublishTransactionRequest) String() string            { return proto.CompactTextString(m) }
 func (*PublishTransactionRequest) ProtoMessage()               {}
-func (*PublishTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+func (*PublishTransactionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
 
 func (m *PublishTransactionRequest) GetSignedTransaction() []byte {
 	if m != nil {
@@ -1109,198 +1364,695 @@ type PublishTransactionResponse struct {
 func (m *PublishTransactionResponse) Reset()                    { *m = PublishTransactionResponse{} }
 func (m *PublishTransactionResponse) String() string            { return proto.CompactTextString(m) }
 func (*PublishTransactionResponse) ProtoMessage()               {}
-func (*PublishTransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+func (*PublishTransactionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
 
-type TransactionNotificationsRequest struct {
+type ListRescansRequest struct {
 }
 
-func (m *TransactionNotificationsRequest) Reset()         { *m = TransactionNotificationsRequest{} }
-func (m *TransactionNotificationsRequest) String() string { return proto.CompactTextString(m) }
-func (*TransactionNotificationsRequest) ProtoMessage()    {}
-func (*TransactionNotificationsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{33}
-}
+func (m *ListRescansRequest) Reset()                    { *m = ListRescansRequest{} }
+func (m *ListRescansRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListRescansRequest) ProtoMessage()               {}
+func (*ListRescansRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
 
-type TransactionNotificationsResponse struct {
-	// Sorted by increasing height.  This is a repeated field so many new blocks
-	// in a new best chain can be notified at once during a reorganize.
-	AttachedBlocks []*BlockDetails `protobuf:"bytes,1,rep,name=attached_blocks,json=attachedBlocks" json:"attached_blocks,omitempty"`
-	// If there was a chain reorganize, there may have been blocks with wallet
-	// transactions that are no longer in the best chain.  These are those
-	// block's hashes.
-	DetachedBlocks [][]byte `protobuf:"bytes,2,rep,name=detached_blocks,json=detachedBlocks,proto3" json:"detached_blocks,omitempty"`
-	// Any new unmined transactions are included here.  These unmined transactions
-	// refer to the current best chain, so transactions from detached blocks may
-	// be moved to mempool and included here if they are not mined or double spent
-	// in the new chain.  Additonally, if no new blocks were attached but a relevant
-	// unmined transaction is seen by the wallet, it will be reported here.
-	UnminedTransactions []*TransactionDetails `protobuf:"bytes,3,rep,name=unmined_transactions,json=unminedTransactions" json:"unmined_transactions,omitempty"`
-	// Instead of notifying all of the removed unmined transactions,
-	// just send all of the current hashes.
-	UnminedTransactionHashes [][]byte `protobuf:"bytes,4,rep,name=unmined_transaction_hashes,json=unminedTransactionHashes,proto3" json:"unmined_transaction_hashes,omitempty"`
+type ListRescansResponse struct {
+	Rescans []*ListRescansResponse_Rescan `protobuf:"bytes,1,rep,name=rescans" json:"rescans,omitempty"`
 }
 
-func (m *TransactionNotificationsResponse) Reset()         { *m = TransactionNotificationsResponse{} }
-func (m *TransactionNotificationsResponse) String() string { return proto.CompactTextString(m) }
-func (*TransactionNotificationsResponse) ProtoMessage()    {}
-func (*TransactionNotificationsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{34}
-}
+func (m *ListRescansResponse) Reset()                    { *m = ListRescansResponse{} }
+func (m *ListRescansResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListRescansResponse) ProtoMessage()               {}
+func (*ListRescansResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
 
-func (m *TransactionNotificationsResponse) GetAttachedBlocks() []*BlockDetails {
+func (m *ListRescansResponse) GetRescans() []*ListRescansResponse_Rescan {
 	if m != nil {
-		return m.AttachedBlocks
+		return m.Rescans
 	}
 	return nil
 }
 
-func (m *TransactionNotificationsResponse) GetDetachedBlocks() [][]byte {
+type ListRescansResponse_Rescan struct {
+	Id             uint64                           `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	State          ListRescansResponse_Rescan_State `protobuf:"varint,2,opt,name=state,enum=walletrpc.ListRescansResponse_Rescan_State" json:"state,omitempty"`
+	Running        bool                             `protobuf:"varint,3,opt,name=running" json:"running,omitempty"`
+	Queued         bool                             `protobuf:"varint,4,opt,name=queued" json:"queued,omitempty"`
+	StartHeight    int32                            `protobuf:"varint,5,opt,name=start_height,json=startHeight" json:"start_height,omitempty"`
+	StartHash      []byte                           `protobuf:"bytes,6,opt,name=start_hash,json=startHash,proto3" json:"start_hash,omitempty"`
+	ProgressHeight int32                            `protobuf:"varint,7,opt,name=progress_height,json=progressHeight" json:"progress_height,omitempty"`
+	ProgressHash   []byte                           `protobuf:"bytes,8,opt,name=progress_hash,json=progressHash,proto3" json:"progress_hash,omitempty"`
+	Addresses      []string                         `protobuf:"bytes,9,rep,name=addresses" json:"addresses,omitempty"`
+	OutpointCount  uint32                           `protobuf:"varint,10,opt,name=outpoint_count,json=outpointCount" json:"outpoint_count,omitempty"`
+}
+
+func (m *ListRescansResponse_Rescan) Reset()                    { *m = ListRescansResponse_Rescan{} }
+func (m *ListRescansResponse_Rescan) String() string            { return proto.CompactTextString(m) }
+func (*ListRescansResponse_Rescan) ProtoMessage()               {}
+func (*ListRescansResponse_Rescan) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37, 0} }
+
+func (m *ListRescansResponse_Rescan) GetId() uint64 {
 	if m != nil {
-		return m.DetachedBlocks
+		return m.Id
 	}
-	return nil
+	return 0
 }
 
-func (m *TransactionNotificationsResponse) GetUnminedTransactions() []*TransactionDetails {
+func (m *ListRescansResponse_Rescan) GetState() ListRescansResponse_Rescan_State {
 	if m != nil {
-		return m.UnminedTransactions
+		return m.State
 	}
-	return nil
+	return ListRescansResponse_Rescan_ACTIVE
 }
 
-func (m *TransactionNotificationsResponse) GetUnminedTransactionHashes() [][]byte {
+func (m *ListRescansResponse_Rescan) GetRunning() bool {
 	if m != nil {
-		return m.UnminedTransactionHashes
+		return m.Running
 	}
-	return nil
+	return false
 }
 
-type SpentnessNotificationsRequest struct {
-	Account         uint32 `protobuf:"varint,1,opt,name=account" json:"account,omitempty"`
-	NoNotifyUnspent bool   `protobuf:"varint,2,opt,name=no_notify_unspent,json=noNotifyUnspent" json:"no_notify_unspent,omitempty"`
-	NoNotifySpent   bool   `protobuf:"varint,3,opt,name=no_notify_spent,json=noNotifySpent" json:"no_notify_spent,omitempty"`
+func (m *ListRescansResponse_Rescan) GetQueued() bool {
+	if m != nil {
+		return m.Queued
+	}
+	return false
 }
 
-func (m *SpentnessNotificationsRequest) Reset()                    { *m = SpentnessNotificationsRequest{} }
-func (m *SpentnessNotificationsRequest) String() string            { return proto.CompactTextString(m) }
-func (*SpentnessNotificationsRequest) ProtoMessage()               {}
-func (*SpentnessNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
-
-func (m *SpentnessNotificationsRequest) GetAccount() uint32 {
+func (m *ListRescansResponse_Rescan) GetStartHeight() int32 {
 	if m != nil {
-		return m.Account
+		return m.StartHeight
 	}
 	return 0
 }
 
-func (m *SpentnessNotificationsRequest) GetNoNotifyUnspent() bool {
+func (m *ListRescansResponse_Rescan) GetStartHash() []byte {
 	if m != nil {
-		return m.NoNotifyUnspent
+		return m.StartHash
 	}
-	return false
+	return nil
 }
 
-func (m *SpentnessNotificationsRequest) GetNoNotifySpent() bool {
+func (m *ListRescansResponse_Rescan) GetProgressHeight() int32 {
 	if m != nil {
-		return m.NoNotifySpent
+		return m.ProgressHeight
 	}
-	return false
-}
-
-type SpentnessNotificationsResponse struct {
-	TransactionHash []byte                                  `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
-	OutputIndex     uint32                                  `protobuf:"varint,2,opt,name=output_index,json=outputIndex" json:"output_index,omitempty"`
-	Spender         *SpentnessNotificationsResponse_Spender `protobuf:"bytes,3,opt,name=spender" json:"spender,omitempty"`
+	return 0
 }
 
-func (m *SpentnessNotificationsResponse) Reset()         { *m = SpentnessNotificationsResponse{} }
-func (m *SpentnessNotificationsResponse) String() string { return proto.CompactTextString(m) }
-func (*SpentnessNotificationsResponse) ProtoMessage()    {}
-func (*SpentnessNotificationsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{36}
+func (m *ListRescansResponse_Rescan) GetProgressHash() []byte {
+	if m != nil {
+		return m.ProgressHash
+	}
+	return nil
 }
 
-func (m *SpentnessNotificationsResponse) GetTransactionHash() []byte {
+func (m *ListRescansResponse_Rescan) GetAddresses() []string {
 	if m != nil {
-		return m.TransactionHash
+		return m.Addresses
 	}
 	return nil
 }
 
-func (m *SpentnessNotificationsResponse) GetOutputIndex() uint32 {
+func (m *ListRescansResponse_Rescan) GetOutpointCount() uint32 {
 	if m != nil {
-		return m.OutputIndex
+		return m.OutpointCount
 	}
 	return 0
 }
 
-func (m *SpentnessNotificationsResponse) GetSpender() *SpentnessNotificationsResponse_Spender {
+type PauseRescanRequest struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *PauseRescanRequest) Reset()                    { *m = PauseRescanRequest{} }
+func (m *PauseRescanRequest) String() string            { return proto.CompactTextString(m) }
+func (*PauseRescanRequest) ProtoMessage()               {}
+func (*PauseRescanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *PauseRescanRequest) GetId() uint64 {
 	if m != nil {
-		return m.Spender
+		return m.Id
 	}
-	return nil
+	return 0
 }
 
-type SpentnessNotificationsResponse_Spender struct {
-	TransactionHash []byte `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
-	InputIndex      uint32 `protobuf:"varint,2,opt,name=input_index,json=inputIndex" json:"input_index,omitempty"`
+type PauseRescanResponse struct {
 }
 
-func (m *SpentnessNotificationsResponse_Spender) Reset() {
-	*m = SpentnessNotificationsResponse_Spender{}
-}
-func (m *SpentnessNotificationsResponse_Spender) String() string { return proto.CompactTextString(m) }
-func (*SpentnessNotificationsResponse_Spender) ProtoMessage()    {}
-func (*SpentnessNotificationsResponse_Spender) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{36, 0}
-}
+func (m *PauseRescanResponse) Reset()                    { *m = PauseRescanResponse{} }
+func (m *PauseRescanResponse) String() string            { return proto.CompactTextString(m) }
+func (*PauseRescanResponse) ProtoMessage()               {}
+func (*PauseRescanResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
 
-func (m *SpentnessNotificationsResponse_Spender) GetTransactionHash() []byte {
-	if m != nil {
-		return m.TransactionHash
-	}
-	return nil
+type ResumeRescanRequest struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
 }
 
-func (m *SpentnessNotificationsResponse_Spender) GetInputIndex() uint32 {
+func (m *ResumeRescanRequest) Reset()                    { *m = ResumeRescanRequest{} }
+func (m *ResumeRescanRequest) String() string            { return proto.CompactTextString(m) }
+func (*ResumeRescanRequest) ProtoMessage()               {}
+func (*ResumeRescanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *ResumeRescanRequest) GetId() uint64 {
 	if m != nil {
-		return m.InputIndex
+		return m.Id
 	}
 	return 0
 }
 
-type AccountNotificationsRequest struct {
+type ResumeRescanResponse struct {
 }
 
-func (m *AccountNotificationsRequest) Reset()                    { *m = AccountNotificationsRequest{} }
-func (m *AccountNotificationsRequest) String() string            { return proto.CompactTextString(m) }
-func (*AccountNotificationsRequest) ProtoMessage()               {}
-func (*AccountNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+func (m *ResumeRescanResponse) Reset()                    { *m = ResumeRescanResponse{} }
+func (m *ResumeRescanResponse) String() string            { return proto.CompactTextString(m) }
+func (*ResumeRescanResponse) ProtoMessage()               {}
+func (*ResumeRescanResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
 
-type AccountNotificationsResponse struct {
-	AccountNumber    uint32 `protobuf:"varint,1,opt,name=account_number,json=accountNumber" json:"account_number,omitempty"`
-	AccountName      string `protobuf:"bytes,2,opt,name=account_name,json=accountName" json:"account_name,omitempty"`
-	ExternalKeyCount uint32 `protobuf:"varint,3,opt,name=external_key_count,json=externalKeyCount" json:"external_key_count,omitempty"`
-	InternalKeyCount uint32 `protobuf:"varint,4,opt,name=internal_key_count,json=internalKeyCount" json:"internal_key_count,omitempty"`
-	ImportedKeyCount uint32 `protobuf:"varint,5,opt,name=imported_key_count,json=importedKeyCount" json:"imported_key_count,omitempty"`
+type CancelRescanRequest struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
 }
 
-func (m *AccountNotificationsResponse) Reset()                    { *m = AccountNotificationsResponse{} }
-func (m *AccountNotificationsResponse) String() string            { return proto.CompactTextString(m) }
-func (*AccountNotificationsResponse) ProtoMessage()               {}
-func (*AccountNotificationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+func (m *CancelRescanRequest) Reset()                    { *m = CancelRescanRequest{} }
+func (m *CancelRescanRequest) String() string            { return proto.CompactTextString(m) }
+func (*CancelRescanRequest) ProtoMessage()               {}
+func (*CancelRescanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
 
-func (m *AccountNotificationsResponse) GetAccountNumber() uint32 {
+func (m *CancelRescanRequest) GetId() uint64 {
 	if m != nil {
-		return m.AccountNumber
+		return m.Id
 	}
 	return 0
 }
 
-func (m *AccountNotificationsResponse) GetAccountName() string {
-	if m != nil {
-		return m.AccountName
-	}
+type CancelRescanResponse struct {
+}
+
+func (m *CancelRescanResponse) Reset()                    { *m = CancelRescanResponse{} }
+func (m *CancelRescanResponse) String() string            { return proto.CompactTextString(m) }
+func (*CancelRescanResponse) ProtoMessage()               {}
+func (*CancelRescanResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+type RescanRequest struct {
+	StartHeight int32 `protobuf:"varint,1,opt,name=start_height,json=startHeight" json:"start_height,omitempty"`
+	StopHeight  int32 `protobuf:"varint,2,opt,name=stop_height,json=stopHeight" json:"stop_height,omitempty"`
+}
+
+func (m *RescanRequest) Reset()                    { *m = RescanRequest{} }
+func (m *RescanRequest) String() string            { return proto.CompactTextString(m) }
+func (*RescanRequest) ProtoMessage()               {}
+func (*RescanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *RescanRequest) GetStartHeight() int32 {
+	if m != nil {
+		return m.StartHeight
+	}
+	return 0
+}
+
+func (m *RescanRequest) GetStopHeight() int32 {
+	if m != nil {
+		return m.StopHeight
+	}
+	return 0
+}
+
+type RescanResponse struct {
+	RescannedThrough     int32  `protobuf:"varint,1,opt,name=rescanned_through,json=rescannedThrough" json:"rescanned_through,omitempty"`
+	RescannedThroughHash []byte `protobuf:"bytes,2,opt,name=rescanned_through_hash,json=rescannedThroughHash,proto3" json:"rescanned_through_hash,omitempty"`
+	// Only set on the final message of the stream.
+	Summary *RescanResponse_Summary `protobuf:"bytes,3,opt,name=summary" json:"summary,omitempty"`
+}
+
+func (m *RescanResponse) Reset()                    { *m = RescanResponse{} }
+func (m *RescanResponse) String() string            { return proto.CompactTextString(m) }
+func (*RescanResponse) ProtoMessage()               {}
+func (*RescanResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *RescanResponse) GetRescannedThrough() int32 {
+	if m != nil {
+		return m.RescannedThrough
+	}
+	return 0
+}
+
+func (m *RescanResponse) GetRescannedThroughHash() []byte {
+	if m != nil {
+		return m.RescannedThroughHash
+	}
+	return nil
+}
+
+func (m *RescanResponse) GetSummary() *RescanResponse_Summary {
+	if m != nil {
+		return m.Summary
+	}
+	return nil
+}
+
+type RescanResponse_Summary struct {
+	StartHeight          int32    `protobuf:"varint,1,opt,name=start_height,json=startHeight" json:"start_height,omitempty"`
+	StopHeight           int32    `protobuf:"varint,2,opt,name=stop_height,json=stopHeight" json:"stop_height,omitempty"`
+	TransactionHashes    [][]byte `protobuf:"bytes,3,rep,name=transaction_hashes,json=transactionHashes,proto3" json:"transaction_hashes,omitempty"`
+	NewTransactionHashes [][]byte `protobuf:"bytes,4,rep,name=new_transaction_hashes,json=newTransactionHashes,proto3" json:"new_transaction_hashes,omitempty"`
+}
+
+func (m *RescanResponse_Summary) Reset()                    { *m = RescanResponse_Summary{} }
+func (m *RescanResponse_Summary) String() string            { return proto.CompactTextString(m) }
+func (*RescanResponse_Summary) ProtoMessage()               {}
+func (*RescanResponse_Summary) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45, 0} }
+
+func (m *RescanResponse_Summary) GetStartHeight() int32 {
+	if m != nil {
+		return m.StartHeight
+	}
+	return 0
+}
+
+func (m *RescanResponse_Summary) GetStopHeight() int32 {
+	if m != nil {
+		return m.StopHeight
+	}
+	return 0
+}
+
+func (m *RescanResponse_Summary) GetTransactionHashes() [][]byte {
+	if m != nil {
+		return m.TransactionHashes
+	}
+	return nil
+}
+
+func (m *RescanResponse_Summary) GetNewTransactionHashes() [][]byte {
+	if m != nil {
+		return m.NewTransactionHashes
+	}
+	return nil
+}
+
+type CreateInvoiceRequest struct {
+	Account       uint32 `protobuf:"varint,1,opt,name=account" json:"account,omitempty"`
+	Amount        int64  `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
+	Memo          string `protobuf:"bytes,3,opt,name=memo" json:"memo,omitempty"`
+	ExpirySeconds int64  `protobuf:"varint,4,opt,name=expiry_seconds,json=expirySeconds" json:"expiry_seconds,omitempty"`
+}
+
+func (m *CreateInvoiceRequest) Reset()                    { *m = CreateInvoiceRequest{} }
+func (m *CreateInvoiceRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateInvoiceRequest) ProtoMessage()               {}
+func (*CreateInvoiceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *CreateInvoiceRequest) GetAccount() uint32 {
+	if m != nil {
+		return m.Account
+	}
+	return 0
+}
+
+func (m *CreateInvoiceRequest) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *CreateInvoiceRequest) GetMemo() string {
+	if m != nil {
+		return m.Memo
+	}
+	return ""
+}
+
+func (m *CreateInvoiceRequest) GetExpirySeconds() int64 {
+	if m != nil {
+		return m.ExpirySeconds
+	}
+	return 0
+}
+
+type CreateInvoiceResponse struct {
+	Invoice *Invoice `protobuf:"bytes,1,opt,name=invoice" json:"invoice,omitempty"`
+}
+
+func (m *CreateInvoiceResponse) Reset()                    { *m = CreateInvoiceResponse{} }
+func (m *CreateInvoiceResponse) String() string            { return proto.CompactTextString(m) }
+func (*CreateInvoiceResponse) ProtoMessage()               {}
+func (*CreateInvoiceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *CreateInvoiceResponse) GetInvoice() *Invoice {
+	if m != nil {
+		return m.Invoice
+	}
+	return nil
+}
+
+type MacaroonPermission struct {
+	Entity string `protobuf:"bytes,1,opt,name=entity" json:"entity,omitempty"`
+	Action string `protobuf:"bytes,2,opt,name=action" json:"action,omitempty"`
+}
+
+func (m *MacaroonPermission) Reset()                    { *m = MacaroonPermission{} }
+func (m *MacaroonPermission) String() string            { return proto.CompactTextString(m) }
+func (*MacaroonPermission) ProtoMessage()               {}
+func (*MacaroonPermission) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+func (m *MacaroonPermission) GetEntity() string {
+	if m != nil {
+		return m.Entity
+	}
+	return ""
+}
+
+func (m *MacaroonPermission) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+type BakeMacaroonRequest struct {
+	Permissions    []*MacaroonPermission `protobuf:"bytes,1,rep,name=permissions" json:"permissions,omitempty"`
+	TimeoutSeconds int64                 `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds" json:"timeout_seconds,omitempty"`
+	IpAddress      string                `protobuf:"bytes,3,opt,name=ip_address,json=ipAddress" json:"ip_address,omitempty"`
+}
+
+func (m *BakeMacaroonRequest) Reset()                    { *m = BakeMacaroonRequest{} }
+func (m *BakeMacaroonRequest) String() string            { return proto.CompactTextString(m) }
+func (*BakeMacaroonRequest) ProtoMessage()               {}
+func (*BakeMacaroonRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+func (m *BakeMacaroonRequest) GetPermissions() []*MacaroonPermission {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+func (m *BakeMacaroonRequest) GetTimeoutSeconds() int64 {
+	if m != nil {
+		return m.TimeoutSeconds
+	}
+	return 0
+}
+
+func (m *BakeMacaroonRequest) GetIpAddress() string {
+	if m != nil {
+		return m.IpAddress
+	}
+	return ""
+}
+
+type BakeMacaroonResponse struct {
+	Macaroon string `protobuf:"bytes,1,opt,name=macaroon" json:"macaroon,omitempty"`
+}
+
+func (m *BakeMacaroonResponse) Reset()                    { *m = BakeMacaroonResponse{} }
+func (m *BakeMacaroonResponse) String() string            { return proto.CompactTextString(m) }
+func (*BakeMacaroonResponse) ProtoMessage()               {}
+func (*BakeMacaroonResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+func (m *BakeMacaroonResponse) GetMacaroon() string {
+	if m != nil {
+		return m.Macaroon
+	}
+	return ""
+}
+
+type GetInvoiceRequest struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *GetInvoiceRequest) Reset()                    { *m = GetInvoiceRequest{} }
+func (m *GetInvoiceRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetInvoiceRequest) ProtoMessage()               {}
+func (*GetInvoiceRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *GetInvoiceRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type GetInvoiceResponse struct {
+	Invoice *Invoice `protobuf:"bytes,1,opt,name=invoice" json:"invoice,omitempty"`
+}
+
+func (m *GetInvoiceResponse) Reset()                    { *m = GetInvoiceResponse{} }
+func (m *GetInvoiceResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetInvoiceResponse) ProtoMessage()               {}
+func (*GetInvoiceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *GetInvoiceResponse) GetInvoice() *Invoice {
+	if m != nil {
+		return m.Invoice
+	}
+	return nil
+}
+
+type ListInvoicesRequest struct {
+}
+
+func (m *ListInvoicesRequest) Reset()                    { *m = ListInvoicesRequest{} }
+func (m *ListInvoicesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListInvoicesRequest) ProtoMessage()               {}
+func (*ListInvoicesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+type ListInvoicesResponse struct {
+	Invoices []*Invoice `protobuf:"bytes,1,rep,name=invoices" json:"invoices,omitempty"`
+}
+
+func (m *ListInvoicesResponse) Reset()                    { *m = ListInvoicesResponse{} }
+func (m *ListInvoicesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListInvoicesResponse) ProtoMessage()               {}
+func (*ListInvoicesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+func (m *ListInvoicesResponse) GetInvoices() []*Invoice {
+	if m != nil {
+		return m.Invoices
+	}
+	return nil
+}
+
+type InvoiceNotificationsRequest struct {
+}
+
+func (m *InvoiceNotificationsRequest) Reset()                    { *m = InvoiceNotificationsRequest{} }
+func (m *InvoiceNotificationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*InvoiceNotificationsRequest) ProtoMessage()               {}
+func (*InvoiceNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
+
+type InvoiceNotificationsResponse struct {
+	Invoice        *Invoice       `protobuf:"bytes,1,opt,name=invoice" json:"invoice,omitempty"`
+	PreviousStatus Invoice_Status `protobuf:"varint,2,opt,name=previous_status,json=previousStatus,enum=walletrpc.Invoice_Status" json:"previous_status,omitempty"`
+}
+
+func (m *InvoiceNotificationsResponse) Reset()                    { *m = InvoiceNotificationsResponse{} }
+func (m *InvoiceNotificationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*InvoiceNotificationsResponse) ProtoMessage()               {}
+func (*InvoiceNotificationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{56} }
+
+func (m *InvoiceNotificationsResponse) GetInvoice() *Invoice {
+	if m != nil {
+		return m.Invoice
+	}
+	return nil
+}
+
+func (m *InvoiceNotificationsResponse) GetPreviousStatus() Invoice_Status {
+	if m != nil {
+		return m.PreviousStatus
+	}
+	return Invoice_UNPAID
+}
+
+type TransactionNotificationsRequest struct {
+}
+
+func (m *TransactionNotificationsRequest) Reset()         { *m = TransactionNotificationsRequest{} }
+func (m *TransactionNotificationsRequest) String() string { return proto.CompactTextString(m) }
+func (*TransactionNotificationsRequest) ProtoMessage()    {}
+func (*TransactionNotificationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{57}
+}
+
+type TransactionNotificationsResponse struct {
+	// Sorted by increasing height.  This is a repeated field so many new blocks
+	// in a new best chain can be notified at once during a reorganize.
+	AttachedBlocks []*BlockDetails `protobuf:"bytes,1,rep,name=attached_blocks,json=attachedBlocks" json:"attached_blocks,omitempty"`
+	// If there was a chain reorganize, there may have been blocks with wallet
+	// transactions that are no longer in the best chain.  These are those
+	// block's hashes.
+	DetachedBlocks [][]byte `protobuf:"bytes,2,rep,name=detached_blocks,json=detachedBlocks,proto3" json:"detached_blocks,omitempty"`
+	// Any new unmined transactions are included here.  These unmined transactions
+	// refer to the current best chain, so transactions from detached blocks may
+	// be moved to mempool and included here if they are not mined or double spent
+	// in the new chain.  Additonally, if no new blocks were attached but a relevant
+	// unmined transaction is seen by the wallet, it will be reported here.
+	UnminedTransactions []*TransactionDetails `protobuf:"bytes,3,rep,name=unmined_transactions,json=unminedTransactions" json:"unmined_transactions,omitempty"`
+	// Instead of notifying all of the removed unmined transactions,
+	// just send all of the current hashes.
+	UnminedTransactionHashes [][]byte `protobuf:"bytes,4,rep,name=unmined_transaction_hashes,json=unminedTransactionHashes,proto3" json:"unmined_transaction_hashes,omitempty"`
+}
+
+func (m *TransactionNotificationsResponse) Reset()         { *m = TransactionNotificationsResponse{} }
+func (m *TransactionNotificationsResponse) String() string { return proto.CompactTextString(m) }
+func (*TransactionNotificationsResponse) ProtoMessage()    {}
+func (*TransactionNotificationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{58}
+}
+
+func (m *TransactionNotificationsResponse) GetAttachedBlocks() []*BlockDetails {
+	if m != nil {
+		return m.AttachedBlocks
+	}
+	return nil
+}
+
+func (m *TransactionNotificationsResponse) GetDetachedBlocks() [][]byte {
+	if m != nil {
+		return m.DetachedBlocks
+	}
+	return nil
+}
+
+func (m *TransactionNotificationsResponse) GetUnminedTransactions() []*TransactionDetails {
+	if m != nil {
+		return m.UnminedTransactions
+	}
+	return nil
+}
+
+func (m *TransactionNotificationsResponse) GetUnminedTransactionHashes() [][]byte {
+	if m != nil {
+		return m.UnminedTransactionHashes
+	}
+	return nil
+}
+
+type SpentnessNotificationsRequest struct {
+	Account         uint32 `protobuf:"varint,1,opt,name=account" json:"account,omitempty"`
+	NoNotifyUnspent bool   `protobuf:"varint,2,opt,name=no_notify_unspent,json=noNotifyUnspent" json:"no_notify_unspent,omitempty"`
+	NoNotifySpent   bool   `protobuf:"varint,3,opt,name=no_notify_spent,json=noNotifySpent" json:"no_notify_spent,omitempty"`
+}
+
+func (m *SpentnessNotificationsRequest) Reset()                    { *m = SpentnessNotificationsRequest{} }
+func (m *SpentnessNotificationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*SpentnessNotificationsRequest) ProtoMessage()               {}
+func (*SpentnessNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{59} }
+
+func (m *SpentnessNotificationsRequest) GetAccount() uint32 {
+	if m != nil {
+		return m.Account
+	}
+	return 0
+}
+
+func (m *SpentnessNotificationsRequest) GetNoNotifyUnspent() bool {
+	if m != nil {
+		return m.NoNotifyUnspent
+	}
+	return false
+}
+
+func (m *SpentnessNotificationsRequest) GetNoNotifySpent() bool {
+	if m != nil {
+		return m.NoNotifySpent
+	}
+	return false
+}
+
+type SpentnessNotificationsResponse struct {
+	TransactionHash []byte                                  `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	OutputIndex     uint32                                  `protobuf:"varint,2,opt,name=output_index,json=outputIndex" json:"output_index,omitempty"`
+	Spender         *SpentnessNotificationsResponse_Spender `protobuf:"bytes,3,opt,name=spender" json:"spender,omitempty"`
+}
+
+func (m *SpentnessNotificationsResponse) Reset()         { *m = SpentnessNotificationsResponse{} }
+func (m *SpentnessNotificationsResponse) String() string { return proto.CompactTextString(m) }
+func (*SpentnessNotificationsResponse) ProtoMessage()    {}
+func (*SpentnessNotificationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{60}
+}
+
+func (m *SpentnessNotificationsResponse) GetTransactionHash() []byte {
+	if m != nil {
+		return m.TransactionHash
+	}
+	return nil
+}
+
+func (m *SpentnessNotificationsResponse) GetOutputIndex() uint32 {
+	if m != nil {
+		return m.OutputIndex
+	}
+	return 0
+}
+
+func (m *SpentnessNotificationsResponse) GetSpender() *SpentnessNotificationsResponse_Spender {
+	if m != nil {
+		return m.Spender
+	}
+	return nil
+}
+
+type SpentnessNotificationsResponse_Spender struct {
+	TransactionHash []byte `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	InputIndex      uint32 `protobuf:"varint,2,opt,name=input_index,json=inputIndex" json:"input_index,omitempty"`
+}
+
+func (m *SpentnessNotificationsResponse_Spender) Reset() {
+	*m = SpentnessNotificationsResponse_Spender{}
+}
+func (m *SpentnessNotificationsResponse_Spender) String() string { return proto.CompactTextString(m) }
+func (*SpentnessNotificationsResponse_Spender) ProtoMessage()    {}
+func (*SpentnessNotificationsResponse_Spender) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{60, 0}
+}
+
+func (m *SpentnessNotificationsResponse_Spender) GetTransactionHash() []byte {
+	if m != nil {
+		return m.TransactionHash
+	}
+	return nil
+}
+
+func (m *SpentnessNotificationsResponse_Spender) GetInputIndex() uint32 {
+	if m != nil {
+		return m.InputIndex
+	}
+	return 0
+}
+
+type AccountNotificationsRequest struct {
+}
+
+func (m *AccountNotificationsRequest) Reset()                    { *m = AccountNotificationsRequest{} }
+func (m *AccountNotificationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*AccountNotificationsRequest) ProtoMessage()               {}
+func (*AccountNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{61} }
+
+type AccountNotificationsResponse struct {
+	AccountNumber    uint32 `protobuf:"varint,1,opt,name=account_number,json=accountNumber" json:"account_number,omitempty"`
+	AccountName      string `protobuf:"bytes,2,opt,name=account_name,json=accountName" json:"account_name,omitempty"`
+	ExternalKeyCount uint32 `protobuf:"varint,3,opt,name=external_key_count,json=externalKeyCount" json:"external_key_count,omitempty"`
+	InternalKeyCount uint32 `protobuf:"varint,4,opt,name=internal_key_count,json=internalKeyCount" json:"internal_key_count,omitempty"`
+	ImportedKeyCount uint32 `protobuf:"varint,5,opt,name=imported_key_count,json=importedKeyCount" json:"imported_key_count,omitempty"`
+}
+
+func (m *AccountNotificationsResponse) Reset()                    { *m = AccountNotificationsResponse{} }
+func (m *AccountNotificationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*AccountNotificationsResponse) ProtoMessage()               {}
+func (*AccountNotificationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{62} }
+
+func (m *AccountNotificationsResponse) GetAccountNumber() uint32 {
+	if m != nil {
+		return m.AccountNumber
+	}
+	return 0
+}
+
+func (m *AccountNotificationsResponse) GetAccountName() string {
+	if m != nil {
+		return m.AccountName
+	}
 	return ""
 }
 
@@ -1334,7 +2086,7 @@ type CreateWalletRequest struct {
 func (m *CreateWalletRequest) Reset()                    { *m = CreateWalletRequest{} }
 func (m *CreateWalletRequest) String() string            { return proto.CompactTextString(m) }
 func (*CreateWalletRequest) ProtoMessage()               {}
-func (*CreateWalletRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+func (*CreateWalletRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{63} }
 
 func (m *CreateWalletRequest) GetPublicPassphrase() []byte {
 	if m != nil {
@@ -1363,7 +2115,7 @@ type CreateWalletResponse struct {
 func (m *CreateWalletResponse) Reset()                    { *m = CreateWalletResponse{} }
 func (m *CreateWalletResponse) String() string            { return proto.CompactTextString(m) }
 func (*CreateWalletResponse) ProtoMessage()               {}
-func (*CreateWalletResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+func (*CreateWalletResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{64} }
 
 type OpenWalletRequest struct {
 	PublicPassphrase []byte `protobuf:"bytes,1,opt,name=public_passphrase,json=publicPassphrase,proto3" json:"public_passphrase,omitempty"`
@@ -1372,7 +2124,7 @@ type OpenWalletRequest struct {
 func (m *OpenWalletRequest) Reset()                    { *m = OpenWalletRequest{} }
 func (m *OpenWalletRequest) String() string            { return proto.CompactTextString(m) }
 func (*OpenWalletRequest) ProtoMessage()               {}
-func (*OpenWalletRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+func (*OpenWalletRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{65} }
 
 func (m *OpenWalletRequest) GetPublicPassphrase() []byte {
 	if m != nil {
@@ -1387,7 +2139,7 @@ type OpenWalletResponse struct {
 func (m *OpenWalletResponse) Reset()                    { *m = OpenWalletResponse{} }
 func (m *OpenWalletResponse) String() string            { return proto.CompactTextString(m) }
 func (*OpenWalletResponse) ProtoMessage()               {}
-func (*OpenWalletResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+func (*OpenWalletResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{66} }
 
 type CloseWalletRequest struct {
 }
@@ -1395,7 +2147,7 @@ type CloseWalletRequest struct {
 func (m *CloseWalletRequest) Reset()                    { *m = CloseWalletRequest{} }
 func (m *CloseWalletRequest) String() string            { return proto.CompactTextString(m) }
 func (*CloseWalletRequest) ProtoMessage()               {}
-func (*CloseWalletRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+func (*CloseWalletRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{67} }
 
 type CloseWalletResponse struct {
 }
@@ -1403,7 +2155,7 @@ type CloseWalletResponse struct {
 func (m *CloseWalletResponse) Reset()                    { *m = CloseWalletResponse{} }
 func (m *CloseWalletResponse) String() string            { return proto.CompactTextString(m) }
 func (*CloseWalletResponse) ProtoMessage()               {}
-func (*CloseWalletResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+func (*CloseWalletResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{68} }
 
 type WalletExistsRequest struct {
 }
@@ -1411,7 +2163,7 @@ type WalletExistsRequest struct {
 func (m *WalletExistsRequest) Reset()                    { *m = WalletExistsRequest{} }
 func (m *WalletExistsRequest) String() string            { return proto.CompactTextString(m) }
 func (*WalletExistsRequest) ProtoMessage()               {}
-func (*WalletExistsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+func (*WalletExistsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{69} }
 
 type WalletExistsResponse struct {
 	Exists bool `protobuf:"varint,1,opt,name=exists" json:"exists,omitempty"`
@@ -1420,7 +2172,7 @@ type WalletExistsResponse struct {
 func (m *WalletExistsResponse) Reset()                    { *m = WalletExistsResponse{} }
 func (m *WalletExistsResponse) String() string            { return proto.CompactTextString(m) }
 func (*WalletExistsResponse) ProtoMessage()               {}
-func (*WalletExistsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+func (*WalletExistsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{70} }
 
 func (m *WalletExistsResponse) GetExists() bool {
 	if m != nil {
@@ -1439,7 +2191,7 @@ type StartConsensusRpcRequest struct {
 func (m *StartConsensusRpcRequest) Reset()                    { *m = StartConsensusRpcRequest{} }
 func (m *StartConsensusRpcRequest) String() string            { return proto.CompactTextString(m) }
 func (*StartConsensusRpcRequest) ProtoMessage()               {}
-func (*StartConsensusRpcRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+func (*StartConsensusRpcRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{71} }
 
 func (m *StartConsensusRpcRequest) GetNetworkAddress() string {
 	if m != nil {
@@ -1475,7 +2227,7 @@ type StartConsensusRpcResponse struct {
 func (m *StartConsensusRpcResponse) Reset()                    { *m = StartConsensusRpcResponse{} }
 func (m *StartConsensusRpcResponse) String() string            { return proto.CompactTextString(m) }
 func (*StartConsensusRpcResponse) ProtoMessage()               {}
-func (*StartConsensusRpcResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+func (*StartConsensusRpcResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{72} }
 
 func init() {
 	proto.RegisterType((*VersionRequest)(nil), "walletrpc.VersionRequest")
@@ -1484,6 +2236,8 @@ func init() {
 	proto.RegisterType((*TransactionDetails_Input)(nil), "walletrpc.TransactionDetails.Input")
 	proto.RegisterType((*TransactionDetails_Output)(nil), "walletrpc.TransactionDetails.Output")
 	proto.RegisterType((*BlockDetails)(nil), "walletrpc.BlockDetails")
+	proto.RegisterType((*Invoice)(nil), "walletrpc.Invoice")
+	proto.RegisterType((*Invoice_Payment)(nil), "walletrpc.Invoice.Payment")
 	proto.RegisterType((*AccountBalance)(nil), "walletrpc.AccountBalance")
 	proto.RegisterType((*PingRequest)(nil), "walletrpc.PingRequest")
 	proto.RegisterType((*PingResponse)(nil), "walletrpc.PingResponse")
@@ -1513,8 +2267,33 @@ func init() {
 	proto.RegisterType((*FundTransactionResponse_PreviousOutput)(nil), "walletrpc.FundTransactionResponse.PreviousOutput")
 	proto.RegisterType((*SignTransactionRequest)(nil), "walletrpc.SignTransactionRequest")
 	proto.RegisterType((*SignTransactionResponse)(nil), "walletrpc.SignTransactionResponse")
+	proto.RegisterType((*SignPsbtRequest)(nil), "walletrpc.SignPsbtRequest")
+	proto.RegisterType((*SignPsbtResponse)(nil), "walletrpc.SignPsbtResponse")
 	proto.RegisterType((*PublishTransactionRequest)(nil), "walletrpc.PublishTransactionRequest")
 	proto.RegisterType((*PublishTransactionResponse)(nil), "walletrpc.PublishTransactionResponse")
+	proto.RegisterType((*ListRescansRequest)(nil), "walletrpc.ListRescansRequest")
+	proto.RegisterType((*ListRescansResponse)(nil), "walletrpc.ListRescansResponse")
+	proto.RegisterType((*ListRescansResponse_Rescan)(nil), "walletrpc.ListRescansResponse.Rescan")
+	proto.RegisterType((*PauseRescanRequest)(nil), "walletrpc.PauseRescanRequest")
+	proto.RegisterType((*PauseRescanResponse)(nil), "walletrpc.PauseRescanResponse")
+	proto.RegisterType((*ResumeRescanRequest)(nil), "walletrpc.ResumeRescanRequest")
+	proto.RegisterType((*ResumeRescanResponse)(nil), "walletrpc.ResumeRescanResponse")
+	proto.RegisterType((*CancelRescanRequest)(nil), "walletrpc.CancelRescanRequest")
+	proto.RegisterType((*CancelRescanResponse)(nil), "walletrpc.CancelRescanResponse")
+	proto.RegisterType((*RescanRequest)(nil), "walletrpc.RescanRequest")
+	proto.RegisterType((*RescanResponse)(nil), "walletrpc.RescanResponse")
+	proto.RegisterType((*RescanResponse_Summary)(nil), "walletrpc.RescanResponse.Summary")
+	proto.RegisterType((*CreateInvoiceRequest)(nil), "walletrpc.CreateInvoiceRequest")
+	proto.RegisterType((*CreateInvoiceResponse)(nil), "walletrpc.CreateInvoiceResponse")
+	proto.RegisterType((*MacaroonPermission)(nil), "walletrpc.MacaroonPermission")
+	proto.RegisterType((*BakeMacaroonRequest)(nil), "walletrpc.BakeMacaroonRequest")
+	proto.RegisterType((*BakeMacaroonResponse)(nil), "walletrpc.BakeMacaroonResponse")
+	proto.RegisterType((*GetInvoiceRequest)(nil), "walletrpc.GetInvoiceRequest")
+	proto.RegisterType((*GetInvoiceResponse)(nil), "walletrpc.GetInvoiceResponse")
+	proto.RegisterType((*ListInvoicesRequest)(nil), "walletrpc.ListInvoicesRequest")
+	proto.RegisterType((*ListInvoicesResponse)(nil), "walletrpc.ListInvoicesResponse")
+	proto.RegisterType((*InvoiceNotificationsRequest)(nil), "walletrpc.InvoiceNotificationsRequest")
+	proto.RegisterType((*InvoiceNotificationsResponse)(nil), "walletrpc.InvoiceNotificationsResponse")
 	proto.RegisterType((*TransactionNotificationsRequest)(nil), "walletrpc.TransactionNotificationsRequest")
 	proto.RegisterType((*TransactionNotificationsResponse)(nil), "walletrpc.TransactionNotificationsResponse")
 	proto.RegisterType((*SpentnessNotificationsRequest)(nil), "walletrpc.SpentnessNotificationsRequest")
@@ -1532,8 +2311,10 @@ func init() {
 	proto.RegisterType((*WalletExistsResponse)(nil), "walletrpc.WalletExistsResponse")
 	proto.RegisterType((*StartConsensusRpcRequest)(nil), "walletrpc.StartConsensusRpcRequest")
 	proto.RegisterType((*StartConsensusRpcResponse)(nil), "walletrpc.StartConsensusRpcResponse")
+	proto.RegisterEnum("walletrpc.Invoice_Status", Invoice_Status_name, Invoice_Status_value)
 	proto.RegisterEnum("walletrpc.NextAddressRequest_Kind", NextAddressRequest_Kind_name, NextAddressRequest_Kind_value)
 	proto.RegisterEnum("walletrpc.ChangePassphraseRequest_Key", ChangePassphraseRequest_Key_name, ChangePassphraseRequest_Key_value)
+	proto.RegisterEnum("walletrpc.ListRescansResponse_Rescan_State", ListRescansResponse_Rescan_State_name, ListRescansResponse_Rescan_State_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1618,10 +2399,15 @@ type WalletServiceClient interface {
 	Accounts(ctx context.Context, in *AccountsRequest, opts ...grpc.CallOption) (*AccountsResponse, error)
 	Balance(ctx context.Context, in *BalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
 	GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error)
+	ListRescans(ctx context.Context, in *ListRescansRequest, opts ...grpc.CallOption) (*ListRescansResponse, error)
+	GetInvoice(ctx context.Context, in *GetInvoiceRequest, opts ...grpc.CallOption) (*GetInvoiceResponse, error)
+	ListInvoices(ctx context.Context, in *ListInvoicesRequest, opts ...grpc.CallOption) (*ListInvoicesResponse, error)
 	// Notifications
 	TransactionNotifications(ctx context.Context, in *TransactionNotificationsRequest, opts ...grpc.CallOption) (WalletService_TransactionNotificationsClient, error)
 	SpentnessNotifications(ctx context.Context, in *SpentnessNotificationsRequest, opts ...grpc.CallOption) (WalletService_SpentnessNotificationsClient, error)
 	AccountNotifications(ctx context.Context, in *AccountNotificationsRequest, opts ...grpc.CallOption) (WalletService_AccountNotificationsClient, error)
+	Rescan(ctx context.Context, in *RescanRequest, opts ...grpc.CallOption) (WalletService_RescanClient, error)
+	InvoiceNotifications(ctx context.Context, in *InvoiceNotificationsRequest, opts ...grpc.CallOption) (WalletService_InvoiceNotificationsClient, error)
 	// Control
 	ChangePassphrase(ctx context.Context, in *ChangePassphraseRequest, opts ...grpc.CallOption) (*ChangePassphraseResponse, error)
 	RenameAccount(ctx context.Context, in *RenameAccountRequest, opts ...grpc.CallOption) (*RenameAccountResponse, error)
@@ -1630,7 +2416,13 @@ type WalletServiceClient interface {
 	ImportPrivateKey(ctx context.Context, in *ImportPrivateKeyRequest, opts ...grpc.CallOption) (*ImportPrivateKeyResponse, error)
 	FundTransaction(ctx context.Context, in *FundTransactionRequest, opts ...grpc.CallOption) (*FundTransactionResponse, error)
 	SignTransaction(ctx context.Context, in *SignTransactionRequest, opts ...grpc.CallOption) (*SignTransactionResponse, error)
+	SignPsbt(ctx context.Context, in *SignPsbtRequest, opts ...grpc.CallOption) (*SignPsbtResponse, error)
 	PublishTransaction(ctx context.Context, in *PublishTransactionRequest, opts ...grpc.CallOption) (*PublishTransactionResponse, error)
+	PauseRescan(ctx context.Context, in *PauseRescanRequest, opts ...grpc.CallOption) (*PauseRescanResponse, error)
+	ResumeRescan(ctx context.Context, in *ResumeRescanRequest, opts ...grpc.CallOption) (*ResumeRescanResponse, error)
+	CancelRescan(ctx context.Context, in *CancelRescanRequest, opts ...grpc.CallOption) (*CancelRescanResponse, error)
+	CreateInvoice(ctx context.Context, in *CreateInvoiceRequest, opts ...grpc.CallOption) (*CreateInvoiceResponse, error)
+	BakeMacaroon(ctx context.Context, in *BakeMacaroonRequest, opts ...grpc.CallOption) (*BakeMacaroonResponse, error)
 }
 
 type walletServiceClient struct {
@@ -1686,9 +2478,36 @@ func (c *walletServiceClient) Balance(ctx context.Context, in *BalanceRequest, o
 	return out, nil
 }
 
-func (c *walletServiceClient) GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error) {
-	out := new(GetTransactionsResponse)
-	err := grpc.Invoke(ctx, "/walletrpc.WalletService/GetTransactions", in, out, c.cc, opts...)
+func (c *walletServiceClient) GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error) {
+	out := new(GetTransactionsResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/GetTransactions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) ListRescans(ctx context.Context, in *ListRescansRequest, opts ...grpc.CallOption) (*ListRescansResponse, error) {
+	out := new(ListRescansResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/ListRescans", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) GetInvoice(ctx context.Context, in *GetInvoiceRequest, opts ...grpc.CallOption) (*GetInvoiceResponse, error) {
+	out := new(GetInvoiceResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/GetInvoice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) ListInvoices(ctx context.Context, in *ListInvoicesRequest, opts ...grpc.CallOption) (*ListInvoicesResponse, error) {
+	out := new(ListInvoicesResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/ListInvoices", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1791,6 +2610,70 @@ func (x *walletServiceAccountNotificationsClient) Recv() (*AccountNotificationsR
 	return m, nil
 }
 
+func (c *walletServiceClient) Rescan(ctx context.Context, in *RescanRequest, opts ...grpc.CallOption) (WalletService_RescanClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_WalletService_serviceDesc.Streams[3], c.cc, "/walletrpc.WalletService/Rescan", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &walletServiceRescanClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type WalletService_RescanClient interface {
+	Recv() (*RescanResponse, error)
+	grpc.ClientStream
+}
+
+type walletServiceRescanClient struct {
+	grpc.ClientStream
+}
+
+func (x *walletServiceRescanClient) Recv() (*RescanResponse, error) {
+	m := new(RescanResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *walletServiceClient) InvoiceNotifications(ctx context.Context, in *InvoiceNotificationsRequest, opts ...grpc.CallOption) (WalletService_InvoiceNotificationsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_WalletService_serviceDesc.Streams[4], c.cc, "/walletrpc.WalletService/InvoiceNotifications", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &walletServiceInvoiceNotificationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type WalletService_InvoiceNotificationsClient interface {
+	Recv() (*InvoiceNotificationsResponse, error)
+	grpc.ClientStream
+}
+
+type walletServiceInvoiceNotificationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *walletServiceInvoiceNotificationsClient) Recv() (*InvoiceNotificationsResponse, error) {
+	m := new(InvoiceNotificationsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *walletServiceClient) ChangePassphrase(ctx context.Context, in *ChangePassphraseRequest, opts ...grpc.CallOption) (*ChangePassphraseResponse, error) {
 	out := new(ChangePassphraseResponse)
 	err := grpc.Invoke(ctx, "/walletrpc.WalletService/ChangePassphrase", in, out, c.cc, opts...)
@@ -1854,6 +2737,15 @@ func (c *walletServiceClient) SignTransaction(ctx context.Context, in *SignTrans
 	return out, nil
 }
 
+func (c *walletServiceClient) SignPsbt(ctx context.Context, in *SignPsbtRequest, opts ...grpc.CallOption) (*SignPsbtResponse, error) {
+	out := new(SignPsbtResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/SignPsbt", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *walletServiceClient) PublishTransaction(ctx context.Context, in *PublishTransactionRequest, opts ...grpc.CallOption) (*PublishTransactionResponse, error) {
 	out := new(PublishTransactionResponse)
 	err := grpc.Invoke(ctx, "/walletrpc.WalletService/PublishTransaction", in, out, c.cc, opts...)
@@ -1863,6 +2755,51 @@ func (c *walletServiceClient) PublishTransaction(ctx context.Context, in *Publis
 	return out, nil
 }
 
+func (c *walletServiceClient) PauseRescan(ctx context.Context, in *PauseRescanRequest, opts ...grpc.CallOption) (*PauseRescanResponse, error) {
+	out := new(PauseRescanResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/PauseRescan", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) ResumeRescan(ctx context.Context, in *ResumeRescanRequest, opts ...grpc.CallOption) (*ResumeRescanResponse, error) {
+	out := new(ResumeRescanResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/ResumeRescan", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) CancelRescan(ctx context.Context, in *CancelRescanRequest, opts ...grpc.CallOption) (*CancelRescanResponse, error) {
+	out := new(CancelRescanResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/CancelRescan", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) CreateInvoice(ctx context.Context, in *CreateInvoiceRequest, opts ...grpc.CallOption) (*CreateInvoiceResponse, error) {
+	out := new(CreateInvoiceResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/CreateInvoice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) BakeMacaroon(ctx context.Context, in *BakeMacaroonRequest, opts ...grpc.CallOption) (*BakeMacaroonResponse, error) {
+	out := new(BakeMacaroonResponse)
+	err := grpc.Invoke(ctx, "/walletrpc.WalletService/BakeMacaroon", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for WalletService service
 
 type WalletServiceServer interface {
@@ -1873,10 +2810,15 @@ type WalletServiceServer interface {
 	Accounts(context.Context, *AccountsRequest) (*AccountsResponse, error)
 	Balance(context.Context, *BalanceRequest) (*BalanceResponse, error)
 	GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error)
+	ListRescans(context.Context, *ListRescansRequest) (*ListRescansResponse, error)
+	GetInvoice(context.Context, *GetInvoiceRequest) (*GetInvoiceResponse, error)
+	ListInvoices(context.Context, *ListInvoicesRequest) (*ListInvoicesResponse, error)
 	// Notifications
 	TransactionNotifications(*TransactionNotificationsRequest, WalletService_TransactionNotificationsServer) error
 	SpentnessNotifications(*SpentnessNotificationsRequest, WalletService_SpentnessNotificationsServer) error
 	AccountNotifications(*AccountNotificationsRequest, WalletService_AccountNotificationsServer) error
+	Rescan(*RescanRequest, WalletService_RescanServer) error
+	InvoiceNotifications(*InvoiceNotificationsRequest, WalletService_InvoiceNotificationsServer) error
 	// Control
 	ChangePassphrase(context.Context, *ChangePassphraseRequest) (*ChangePassphraseResponse, error)
 	RenameAccount(context.Context, *RenameAccountRequest) (*RenameAccountResponse, error)
@@ -1885,7 +2827,13 @@ type WalletServiceServer interface {
 	ImportPrivateKey(context.Context, *ImportPrivateKeyRequest) (*ImportPrivateKeyResponse, error)
 	FundTransaction(context.Context, *FundTransactionRequest) (*FundTransactionResponse, error)
 	SignTransaction(context.Context, *SignTransactionRequest) (*SignTransactionResponse, error)
+	SignPsbt(context.Context, *SignPsbtRequest) (*SignPsbtResponse, error)
 	PublishTransaction(context.Context, *PublishTransactionRequest) (*PublishTransactionResponse, error)
+	PauseRescan(context.Context, *PauseRescanRequest) (*PauseRescanResponse, error)
+	ResumeRescan(context.Context, *ResumeRescanRequest) (*ResumeRescanResponse, error)
+	CancelRescan(context.Context, *CancelRescanRequest) (*CancelRescanResponse, error)
+	CreateInvoice(context.Context, *CreateInvoiceRequest) (*CreateInvoiceResponse, error)
+	BakeMacaroon(context.Context, *BakeMacaroonRequest) (*BakeMacaroonResponse, error)
 }
 
 func RegisterWalletServiceServer(s *grpc.Server, srv WalletServiceServer) {
@@ -2000,6 +2948,60 @@ func _WalletService_GetTransactions_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WalletService_ListRescans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRescansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ListRescans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/ListRescans",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ListRescans(ctx, req.(*ListRescansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_GetInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).GetInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/GetInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).GetInvoice(ctx, req.(*GetInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_ListInvoices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInvoicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ListInvoices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/ListInvoices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ListInvoices(ctx, req.(*ListInvoicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _WalletService_TransactionNotifications_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(TransactionNotificationsRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -2063,6 +3065,48 @@ func (x *walletServiceAccountNotificationsServer) Send(m *AccountNotificationsRe
 	return x.ServerStream.SendMsg(m)
 }
 
+func _WalletService_Rescan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RescanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WalletServiceServer).Rescan(m, &walletServiceRescanServer{stream})
+}
+
+type WalletService_RescanServer interface {
+	Send(*RescanResponse) error
+	grpc.ServerStream
+}
+
+type walletServiceRescanServer struct {
+	grpc.ServerStream
+}
+
+func (x *walletServiceRescanServer) Send(m *RescanResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _WalletService_InvoiceNotifications_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(InvoiceNotificationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WalletServiceServer).InvoiceNotifications(m, &walletServiceInvoiceNotificationsServer{stream})
+}
+
+type WalletService_InvoiceNotificationsServer interface {
+	Send(*InvoiceNotificationsResponse) error
+	grpc.ServerStream
+}
+
+type walletServiceInvoiceNotificationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *walletServiceInvoiceNotificationsServer) Send(m *InvoiceNotificationsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _WalletService_ChangePassphrase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ChangePassphraseRequest)
 	if err := dec(in); err != nil {
@@ -2189,6 +3233,24 @@ func _WalletService_SignTransaction_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WalletService_SignPsbt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignPsbtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).SignPsbt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/SignPsbt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).SignPsbt(ctx, req.(*SignPsbtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _WalletService_PublishTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PublishTransactionRequest)
 	if err := dec(in); err != nil {
@@ -2207,6 +3269,96 @@ func _WalletService_PublishTransaction_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WalletService_PauseRescan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRescanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).PauseRescan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/PauseRescan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).PauseRescan(ctx, req.(*PauseRescanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_ResumeRescan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRescanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ResumeRescan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/ResumeRescan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ResumeRescan(ctx, req.(*ResumeRescanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_CancelRescan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRescanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CancelRescan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/CancelRescan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CancelRescan(ctx, req.(*CancelRescanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_CreateInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CreateInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/CreateInvoice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CreateInvoice(ctx, req.(*CreateInvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_BakeMacaroon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BakeMacaroonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).BakeMacaroon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletService/BakeMacaroon",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).BakeMacaroon(ctx, req.(*BakeMacaroonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _WalletService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "walletrpc.WalletService",
 	HandlerType: (*WalletServiceServer)(nil),
@@ -2235,6 +3387,18 @@ var _WalletService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetTransactions",
 			Handler:    _WalletService_GetTransactions_Handler,
 		},
+		{
+			MethodName: "ListRescans",
+			Handler:    _WalletService_ListRescans_Handler,
+		},
+		{
+			MethodName: "GetInvoice",
+			Handler:    _WalletService_GetInvoice_Handler,
+		},
+		{
+			MethodName: "ListInvoices",
+			Handler:    _WalletService_ListInvoices_Handler,
+		},
 		{
 			MethodName: "ChangePassphrase",
 			Handler:    _WalletService_ChangePassphrase_Handler,
@@ -2263,10 +3427,34 @@ var _WalletService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SignTransaction",
 			Handler:    _WalletService_SignTransaction_Handler,
 		},
+		{
+			MethodName: "SignPsbt",
+			Handler:    _WalletService_SignPsbt_Handler,
+		},
 		{
 			MethodName: "PublishTransaction",
 			Handler:    _WalletService_PublishTransaction_Handler,
 		},
+		{
+			MethodName: "PauseRescan",
+			Handler:    _WalletService_PauseRescan_Handler,
+		},
+		{
+			MethodName: "ResumeRescan",
+			Handler:    _WalletService_ResumeRescan_Handler,
+		},
+		{
+			MethodName: "CancelRescan",
+			Handler:    _WalletService_CancelRescan_Handler,
+		},
+		{
+			MethodName: "CreateInvoice",
+			Handler:    _WalletService_CreateInvoice_Handler,
+		},
+		{
+			MethodName: "BakeMacaroon",
+			Handler:    _WalletService_BakeMacaroon_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -2284,6 +3472,16 @@ var _WalletService_serviceDesc = grpc.ServiceDesc{
 			Handler:       _WalletService_AccountNotifications_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Rescan",
+			Handler:       _WalletService_Rescan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "InvoiceNotifications",
+			Handler:       _WalletService_InvoiceNotifications_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api.proto",
 }
@@ -2487,155 +3685,217 @@ var _WalletLoaderService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("api.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 2391 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xac, 0x59, 0x5b, 0x73, 0xdc, 0x48,
-	0x15, 0x66, 0x2c, 0xdf, 0x72, 0xe6, 0xde, 0xbe, 0x4d, 0x94, 0x38, 0xc9, 0x2a, 0xbb, 0xd9, 0xec,
-	0x2e, 0x98, 0x60, 0x02, 0x2c, 0xc5, 0x56, 0xd8, 0xc4, 0x64, 0xd9, 0x21, 0xc1, 0x99, 0x92, 0x93,
-	0x4d, 0xaa, 0xa0, 0x98, 0x92, 0x35, 0xed, 0x58, 0x78, 0x46, 0x9a, 0x48, 0x9a, 0x38, 0xe1, 0x89,
-	0xa2, 0x8a, 0x47, 0x5e, 0x80, 0x07, 0x0a, 0x6a, 0x5f, 0xf8, 0x05, 0x54, 0xf1, 0xc2, 0x23, 0xfc,
-	0x06, 0x1e, 0xf9, 0x17, 0xfc, 0x02, 0xfa, 0x72, 0x7a, 0xd4, 0x2d, 0x69, 0xc6, 0xf6, 0x16, 0x6f,
-	0xa3, 0xaf, 0xcf, 0x39, 0x7d, 0xfa, 0xf4, 0xb9, 0xf6, 0xc0, 0x25, 0x6f, 0x1c, 0xec, 0x8c, 0xe3,
-	0x28, 0x8d, 0xc8, 0xa5, 0x53, 0x6f, 0x38, 0xa4, 0x69, 0x3c, 0xf6, 0x9d, 0x16, 0x34, 0xbe, 0xa0,
-	0x71, 0x12, 0x44, 0xa1, 0x4b, 0x5f, 0x4d, 0x68, 0x92, 0x3a, 0xff, 0xaa, 0x40, 0x73, 0x0a, 0x25,
-	0xe3, 0x28, 0x4c, 0x28, 0x79, 0x0f, 0x1a, 0xaf, 0x25, 0xd4, 0x4f, 0xd2, 0x38, 0x08, 0x5f, 0x76,
-	0x2a, 0x37, 0x2a, 0xb7, 0x2f, 0xb9, 0x75, 0x44, 0x0f, 0x04, 0x48, 0xd6, 0x61, 0x69, 0xe4, 0xfd,
-	0x32, 0x8a, 0x3b, 0x0b, 0x6c, 0xb5, 0xee, 0xca, 0x0f, 0x81, 0x06, 0x21, 0x43, 0x2d, 0x44, 0xf9,
-	0x07, 0x47, 0xc7, 0x5e, 0xea, 0x1f, 0x77, 0x16, 0x25, 0x2a, 0x3e, 0xc8, 0x35, 0x80, 0x71, 0x4c,
-	0x63, 0x3a, 0xa4, 0x5e, 0x42, 0x3b, 0x4b, 0x62, 0x13, 0x0d, 0xe1, 0x8a, 0x1c, 0x4e, 0x82, 0xe1,
-	0xa0, 0x3f, 0xa2, 0xa9, 0x37, 0xf0, 0x52, 0xaf, 0xb3, 0x2c, 0x15, 0x11, 0xe8, 0x4f, 0x11, 0x74,
-	0xfe, 0x69, 0x01, 0x79, 0x1a, 0x7b, 0x61, 0xe2, 0xf9, 0x29, 0x53, 0xef, 0x47, 0x0c, 0x0f, 0x86,
-	0x09, 0x21, 0xb0, 0x78, 0xec, 0x25, 0xc7, 0x42, 0xf9, 0x9a, 0x2b, 0x7e, 0x93, 0x1b, 0x50, 0x4d,
-	0x33, 0x4a, 0xa1, 0x79, 0xcd, 0xd5, 0x21, 0xf2, 0x03, 0x58, 0x1e, 0xd0, 0xc3, 0x20, 0x4d, 0xd8,
-	0x01, 0xac, 0xdb, 0xd5, 0xdd, 0x9b, 0x3b, 0x53, 0xf3, 0xed, 0x14, 0x37, 0xd9, 0xe9, 0x86, 0xe3,
-	0x49, 0xea, 0x22, 0x0b, 0xb9, 0x07, 0x2b, 0x7e, 0x4c, 0x07, 0x9c, 0x7b, 0x51, 0x70, 0xbf, 0x3b,
-	0x9f, 0xfb, 0xc9, 0x24, 0xe5, 0xec, 0x8a, 0x89, 0xb4, 0xc0, 0x3a, 0xa2, 0xd2, 0x12, 0x96, 0xcb,
-	0x7f, 0x92, 0xab, 0x70, 0x29, 0x0d, 0x46, 0xec, 0xa6, 0xbc, 0xd1, 0x58, 0x9c, 0xde, 0x72, 0x33,
-	0xc0, 0x7e, 0x05, 0x4b, 0x42, 0x01, 0x6e, 0xdf, 0x20, 0x1c, 0xd0, 0x37, 0xe2, 0xb0, 0xcc, 0xbe,
-	0xe2, 0x83, 0x7c, 0x00, 0x2d, 0x66, 0xcd, 0xd7, 0x41, 0x34, 0x49, 0xfa, 0x9e, 0xef, 0x47, 0x93,
-	0x30, 0xc5, 0xcb, 0x6a, 0x2a, 0xfc, 0xbe, 0x84, 0xc9, 0xfb, 0xd0, 0xcc, 0x48, 0x47, 0x82, 0xd2,
-	0x12, 0xbb, 0x35, 0xa6, 0x94, 0x02, 0xb5, 0x9f, 0xc2, 0xb2, 0xd4, 0x7a, 0xc6, 0x9e, 0x1d, 0x58,
-	0x31, 0xb7, 0x52, 0x9f, 0xc4, 0x86, 0xd5, 0x20, 0x4c, 0x69, 0x1c, 0x7a, 0x43, 0x21, 0x7b, 0xd5,
-	0x9d, 0x7e, 0x3b, 0x7f, 0xa9, 0x40, 0xed, 0xc1, 0x30, 0xf2, 0x4f, 0xe6, 0x5d, 0xde, 0x26, 0x2c,
-	0x1f, 0xd3, 0xe0, 0xe5, 0xb1, 0x94, 0xbc, 0xe4, 0xe2, 0x97, 0x69, 0x23, 0x2b, 0x67, 0x23, 0x72,
-	0x1f, 0x6a, 0xda, 0xfd, 0xaa, 0x8b, 0xd9, 0x9e, 0x7b, 0x31, 0xae, 0xc1, 0xe2, 0x3c, 0x81, 0x06,
-	0xda, 0xe9, 0x81, 0x37, 0xf4, 0x42, 0x9f, 0xea, 0xa7, 0xac, 0x98, 0xa7, 0xbc, 0x09, 0xf5, 0x34,
-	0x4a, 0xbd, 0x61, 0xff, 0x50, 0x92, 0x0a, 0x5d, 0x2d, 0x26, 0x90, 0x83, 0xc8, 0xee, 0xd4, 0xa1,
-	0xda, 0x63, 0x21, 0xa4, 0x82, 0xb0, 0x01, 0x35, 0xf9, 0x29, 0x03, 0x90, 0x87, 0xe9, 0x3e, 0x4d,
-	0x4f, 0xa3, 0xf8, 0x44, 0x51, 0x7c, 0x0c, 0xcd, 0x29, 0x92, 0x45, 0x29, 0xd7, 0xef, 0x35, 0xed,
-	0x87, 0x72, 0x05, 0x35, 0xa9, 0x4b, 0x14, 0xc9, 0x9d, 0xef, 0xc3, 0x3a, 0xea, 0xbe, 0x3f, 0x19,
-	0x1d, 0xd2, 0x18, 0x25, 0x92, 0x77, 0xa0, 0x86, 0x2a, 0xf7, 0x43, 0x6f, 0x44, 0x31, 0xc4, 0xab,
-	0x88, 0xed, 0x33, 0xc8, 0xb9, 0x07, 0x1b, 0x39, 0x56, 0x7d, 0x6b, 0xe4, 0x15, 0x2b, 0xd9, 0xd6,
-	0x1a, 0xb9, 0xd3, 0x86, 0x26, 0xf2, 0x27, 0xea, 0x1c, 0xff, 0xb0, 0xa0, 0x95, 0x61, 0x28, 0xee,
-	0x87, 0xb0, 0x8a, 0x8c, 0x09, 0x13, 0x94, 0x0f, 0xba, 0x3c, 0xb9, 0x02, 0xdc, 0x29, 0x13, 0xf9,
-	0x3a, 0x10, 0x7f, 0x12, 0xc7, 0x94, 0xe9, 0x73, 0xc8, 0x9d, 0xa8, 0x2f, 0x5c, 0x47, 0x06, 0x77,
-	0x0b, 0x57, 0x84, 0x77, 0x7d, 0xce, 0xdd, 0xe8, 0x0e, 0xac, 0xe7, 0xa8, 0xa5, 0x53, 0x59, 0xc2,
-	0xa9, 0x88, 0x41, 0x2f, 0x56, 0xec, 0xdf, 0x2c, 0xc0, 0x8a, 0x0a, 0x94, 0xf3, 0x9d, 0xbd, 0x60,
-	0xde, 0x85, 0x82, 0x79, 0x8b, 0x9e, 0x62, 0x15, 0x3d, 0x85, 0x1f, 0x8d, 0xbe, 0x91, 0x41, 0xd2,
-	0x3f, 0xa1, 0x6f, 0xfb, 0xd2, 0xe7, 0x64, 0x16, 0x6d, 0xa9, 0x95, 0x47, 0xf4, 0xed, 0x9e, 0x50,
-	0x8e, 0x51, 0xab, 0x90, 0xd2, 0xa8, 0x97, 0x24, 0xb5, 0x5a, 0x31, 0xa8, 0x47, 0xe3, 0x28, 0x4e,
-	0xe9, 0x40, 0xa3, 0x5e, 0x46, 0x6a, 0x5c, 0x51, 0xd4, 0xce, 0x0b, 0x58, 0x77, 0x29, 0x3f, 0x8b,
-	0xb2, 0x3f, 0x3a, 0xd2, 0x39, 0x0d, 0x72, 0x19, 0x56, 0x43, 0x7a, 0xaa, 0x1b, 0x63, 0x85, 0x7d,
-	0x0b, 0x3f, 0xdb, 0x82, 0x8d, 0x9c, 0x64, 0x8c, 0x83, 0xe7, 0x40, 0xf6, 0xd9, 0x19, 0x73, 0x1b,
-	0xf2, 0xaa, 0xe1, 0x25, 0xc9, 0xf8, 0x38, 0xe6, 0x55, 0x43, 0x26, 0x08, 0x0d, 0x39, 0x87, 0xe9,
-	0x9d, 0x4f, 0x60, 0xcd, 0x10, 0x7c, 0x31, 0xbf, 0xfe, 0x73, 0x05, 0xf5, 0x1a, 0x0c, 0x62, 0x9a,
-	0x28, 0xdf, 0x9e, 0x93, 0x13, 0xbe, 0x0b, 0x8b, 0x27, 0x2c, 0x3b, 0x0a, 0x4d, 0x1a, 0xbb, 0x8e,
-	0xe6, 0xdc, 0x45, 0x31, 0x3b, 0x8f, 0x18, 0xa5, 0x2b, 0xe8, 0x9d, 0x5d, 0x58, 0xe4, 0x5f, 0x2c,
-	0xd3, 0xb6, 0x1e, 0x74, 0x7b, 0x77, 0xee, 0xdc, 0xbd, 0xdb, 0x7f, 0xf8, 0xe2, 0xe9, 0x43, 0x77,
-	0xff, 0xfe, 0xe3, 0xd6, 0xd7, 0x74, 0xb4, 0xbb, 0x8f, 0x68, 0xc5, 0xf9, 0x26, 0x1e, 0x4d, 0x09,
-	0xc5, 0xa3, 0x71, 0xe5, 0x24, 0x84, 0x91, 0xae, 0x3e, 0x9d, 0x3f, 0x54, 0x60, 0xab, 0x2b, 0x2e,
-	0xbb, 0x17, 0x07, 0xaf, 0xbd, 0x94, 0xb2, 0x1b, 0x3f, 0xaf, 0xa9, 0x67, 0x27, 0xfb, 0x5b, 0xbc,
-	0x9e, 0x08, 0x71, 0xc2, 0xb5, 0x4e, 0x83, 0x23, 0xe1, 0xde, 0xac, 0x76, 0x8f, 0xa7, 0xbb, 0x3c,
-	0x0f, 0x8e, 0x78, 0x4e, 0x67, 0x5a, 0xf8, 0x5e, 0x28, 0x7c, 0x7a, 0xd5, 0xc5, 0x2f, 0xc7, 0x86,
-	0x4e, 0x51, 0x29, 0x74, 0x8b, 0x10, 0x1a, 0x18, 0x1e, 0x17, 0xf4, 0xc1, 0xef, 0xc0, 0x66, 0xcc,
-	0x38, 0x02, 0x56, 0x6d, 0x99, 0xb3, 0x87, 0x47, 0x41, 0x3c, 0xf2, 0x64, 0x51, 0x90, 0x05, 0x65,
-	0x43, 0xad, 0xee, 0xe9, 0x8b, 0x6c, 0xbf, 0xe6, 0x74, 0x3f, 0x34, 0x27, 0xab, 0x7d, 0x22, 0x4c,
-	0xc5, 0x3e, 0x96, 0x2b, 0x3f, 0x78, 0x21, 0x4a, 0xc6, 0x34, 0x1c, 0x78, 0x87, 0x43, 0x95, 0xf7,
-	0x33, 0x80, 0x97, 0xd8, 0x60, 0xc4, 0x64, 0x4e, 0x62, 0xda, 0x8f, 0xe9, 0xa9, 0x17, 0x0f, 0x54,
-	0x89, 0x55, 0xb0, 0x2b, 0x50, 0xe7, 0x4f, 0x0b, 0xb0, 0xf9, 0x63, 0x9a, 0x6a, 0x65, 0x69, 0xea,
-	0x63, 0x3b, 0xb0, 0xc6, 0xaa, 0x5a, 0x9c, 0xb2, 0x6a, 0xa1, 0xa7, 0x3a, 0x79, 0x33, 0x6d, 0xb5,
-	0x94, 0xe5, 0xba, 0x5d, 0xd8, 0xc8, 0xd3, 0x67, 0x15, 0xb4, 0xed, 0xae, 0x99, 0x1c, 0xb2, 0x9c,
-	0x7e, 0x08, 0x6d, 0xa6, 0x72, 0x6e, 0x07, 0x4b, 0xec, 0xd0, 0x94, 0x0b, 0x99, 0x7c, 0xa6, 0x8f,
-	0x49, 0x2b, 0xa5, 0x2f, 0x0a, 0x73, 0xb6, 0x75, 0x6a, 0x29, 0xfb, 0x1e, 0x5c, 0x61, 0x0d, 0x61,
-	0x30, 0x9a, 0x8c, 0x98, 0x09, 0x7c, 0x9e, 0x82, 0x8d, 0xda, 0xbc, 0x24, 0xf8, 0x2e, 0x23, 0x89,
-	0x2b, 0x28, 0x74, 0x33, 0x38, 0x7f, 0x67, 0xce, 0x5a, 0x30, 0x0d, 0xde, 0xc9, 0x67, 0x40, 0x18,
-	0x23, 0xbb, 0x5a, 0x43, 0xa4, 0x2c, 0x28, 0x5b, 0x5a, 0xcc, 0xe9, 0x7d, 0x86, 0xdb, 0x16, 0x2c,
-	0xba, 0x3c, 0xd2, 0x83, 0xf5, 0x49, 0x58, 0x22, 0x69, 0xe1, 0x3c, 0x8d, 0xc3, 0x1a, 0xb2, 0x1a,
-	0x5a, 0xb3, 0x26, 0x7b, 0x6b, 0xef, 0xd8, 0x0b, 0x5f, 0xd2, 0xde, 0x34, 0x76, 0xd4, 0x8d, 0x7e,
-	0x0c, 0x16, 0x0b, 0x10, 0x71, 0x83, 0x8d, 0xdd, 0x5b, 0x9a, 0xf0, 0x19, 0x0c, 0x3b, 0x3c, 0x12,
-	0x38, 0x0b, 0x77, 0xfa, 0x88, 0xf5, 0xc6, 0x5a, 0x80, 0xca, 0x8a, 0x57, 0x67, 0x68, 0xc6, 0xc6,
-	0xc9, 0x78, 0xe2, 0xd5, 0xc8, 0xe4, 0x5d, 0xd6, 0x19, 0x9a, 0x91, 0x39, 0xd7, 0xc0, 0x62, 0x92,
-	0x49, 0x15, 0x56, 0x7a, 0x6e, 0xf7, 0x8b, 0xfb, 0x4f, 0x1f, 0xb2, 0x0c, 0x03, 0xb0, 0xdc, 0x7b,
-	0xf6, 0xe0, 0x71, 0x77, 0x8f, 0xe5, 0x15, 0x16, 0x90, 0x45, 0x8d, 0x30, 0x20, 0x7f, 0xcd, 0x1c,
-	0xf6, 0xb3, 0x49, 0xa8, 0x1f, 0xfa, 0xec, 0xa4, 0xc8, 0xcb, 0x9f, 0x17, 0xbf, 0xa4, 0xa9, 0xea,
-	0x37, 0x55, 0xa3, 0x24, 0x40, 0xd9, 0x6d, 0xce, 0x89, 0x58, 0x6b, 0x4e, 0xc4, 0x92, 0x4f, 0xc0,
-	0x0e, 0x42, 0x7f, 0x38, 0x19, 0xd0, 0xfe, 0x34, 0xe4, 0xfc, 0x28, 0x08, 0x0f, 0x99, 0xd6, 0x09,
-	0x66, 0x9a, 0x0e, 0x52, 0x74, 0x91, 0x60, 0x4f, 0xad, 0xf3, 0xa0, 0x51, 0xdc, 0xbe, 0x38, 0x72,
-	0x3f, 0xf1, 0xe3, 0x60, 0x2c, 0x0b, 0xe9, 0xaa, 0xbb, 0x86, 0x8b, 0xd2, 0x1c, 0x07, 0x62, 0xc9,
-	0xf9, 0xab, 0x05, 0x5b, 0x05, 0x13, 0xa0, 0x63, 0xfe, 0x1c, 0x5a, 0x09, 0x9b, 0x68, 0x7c, 0x5e,
-	0x67, 0x23, 0xd1, 0x3b, 0x2b, 0xb7, 0xfc, 0x96, 0x76, 0xdf, 0x33, 0xb8, 0x77, 0x7a, 0xd8, 0x7f,
-	0xe3, 0xac, 0xd0, 0x54, 0xa2, 0xe4, 0x77, 0xc2, 0xcb, 0x9d, 0x6c, 0x23, 0x0c, 0x33, 0x56, 0x05,
-	0x86, 0x56, 0xbc, 0x0d, 0x2d, 0x3c, 0xc8, 0xf8, 0x44, 0x9d, 0x45, 0x3a, 0x41, 0x43, 0xe2, 0xbd,
-	0x13, 0x79, 0x0c, 0xfb, 0x3f, 0x15, 0x68, 0x98, 0x1b, 0xf2, 0x21, 0x42, 0x0b, 0x03, 0x3d, 0xdf,
-	0x34, 0x35, 0x5c, 0x64, 0x03, 0xa6, 0x8a, 0x3c, 0x5f, 0x5f, 0x0e, 0x06, 0xb2, 0x26, 0x54, 0x25,
-	0xd6, 0x15, 0xe3, 0x01, 0xcb, 0xf7, 0xc6, 0x78, 0x81, 0x5f, 0xe4, 0x0a, 0x5c, 0xca, 0x74, 0x5b,
-	0x14, 0xe2, 0x57, 0xc7, 0xa8, 0x15, 0x97, 0xcb, 0xb3, 0x05, 0xef, 0x75, 0x79, 0x5f, 0x8f, 0xf3,
-	0x51, 0x15, 0xb1, 0xa7, 0x81, 0x6c, 0xa6, 0x8e, 0xe2, 0x68, 0x34, 0xbd, 0x65, 0xd1, 0xc6, 0xac,
-	0xba, 0x35, 0x0e, 0xaa, 0x9b, 0x75, 0xfe, 0x58, 0x81, 0xcd, 0x83, 0xe0, 0x65, 0x58, 0xe2, 0xa7,
-	0x67, 0x55, 0x3a, 0xe6, 0x88, 0x09, 0x8d, 0x03, 0x6f, 0x18, 0xfc, 0xca, 0xcc, 0x0b, 0x18, 0x74,
-	0x1b, 0xd9, 0xaa, 0x26, 0x9d, 0xab, 0x15, 0x84, 0x53, 0x83, 0x50, 0x39, 0x54, 0xd6, 0xdd, 0x9a,
-	0x00, 0xbb, 0x12, 0x73, 0x5e, 0xc1, 0x56, 0x41, 0x2b, 0x74, 0x9d, 0xdc, 0xbc, 0x5a, 0x29, 0xce,
-	0xab, 0x77, 0x61, 0x73, 0x12, 0x26, 0x8c, 0x9d, 0xa9, 0x65, 0x6e, 0xb5, 0x20, 0xb6, 0x5a, 0x57,
-	0xab, 0x5d, 0x7d, 0xcb, 0x9f, 0xc0, 0xe5, 0xde, 0xe4, 0x70, 0x18, 0x24, 0xc7, 0x25, 0xb6, 0xf8,
-	0x06, 0x10, 0x14, 0x58, 0xdc, 0xbb, 0x2d, 0x57, 0x34, 0x2e, 0xe7, 0x2a, 0xd8, 0x65, 0xb2, 0x30,
-	0x37, 0xbc, 0x03, 0xd7, 0x35, 0x78, 0x3f, 0x4a, 0x83, 0xa3, 0xc0, 0xf7, 0xf4, 0xa2, 0xe6, 0x7c,
-	0xb9, 0x00, 0x37, 0x66, 0xd3, 0xa0, 0x25, 0x3e, 0x85, 0xa6, 0x97, 0xa6, 0x9e, 0x7f, 0xcc, 0xd4,
-	0x12, 0xb5, 0xe6, 0xcc, 0xd4, 0xde, 0x50, 0xf4, 0x02, 0x4d, 0x78, 0xfd, 0x1d, 0x50, 0x53, 0x02,
-	0x37, 0x11, 0x0b, 0x02, 0x05, 0x23, 0xe1, 0xac, 0x02, 0x60, 0x7d, 0xd5, 0x02, 0xc0, 0xf3, 0x51,
-	0x89, 0x44, 0x11, 0x4b, 0x54, 0x4e, 0xa4, 0x35, 0xb7, 0x53, 0x64, 0xfc, 0x5c, 0xac, 0x3b, 0xbf,
-	0xab, 0xc0, 0xf6, 0x01, 0x6b, 0x23, 0xd2, 0x90, 0xf5, 0x6b, 0x65, 0x16, 0x9c, 0x93, 0x65, 0x59,
-	0x31, 0x0f, 0xa3, 0x7e, 0xc8, 0x99, 0xde, 0xf6, 0x99, 0x2b, 0x70, 0x31, 0xc2, 0x65, 0x57, 0xdd,
-	0x66, 0x18, 0x09, 0x61, 0x6f, 0x9f, 0x49, 0x98, 0xf7, 0x6c, 0x19, 0xad, 0xa4, 0x94, 0x73, 0x7a,
-	0x5d, 0x51, 0x0a, 0x2d, 0x9c, 0xdf, 0x2f, 0xc0, 0xb5, 0x59, 0xfa, 0xe0, 0x6d, 0xfd, 0x7f, 0x93,
-	0xc6, 0x23, 0x58, 0x11, 0x6d, 0x14, 0x95, 0xaf, 0x4a, 0x66, 0xde, 0x9c, 0xaf, 0x89, 0x58, 0x66,
-	0x8c, 0xae, 0x92, 0x60, 0x3f, 0x83, 0x15, 0xc4, 0x2e, 0xa2, 0xe5, 0x75, 0xa8, 0x6a, 0xd1, 0x85,
-	0x4a, 0x42, 0x16, 0xc6, 0xce, 0x36, 0x5c, 0x51, 0xc3, 0x72, 0x99, 0x8f, 0xff, 0xb7, 0x02, 0x57,
-	0xcb, 0xd7, 0x2f, 0x34, 0x7b, 0x9c, 0x67, 0xae, 0x2c, 0x1f, 0x19, 0xad, 0x0b, 0x8d, 0x8c, 0x8b,
-	0x17, 0x1a, 0x19, 0x97, 0x66, 0x8c, 0x8c, 0xbf, 0xad, 0xc0, 0xda, 0x5e, 0x4c, 0x59, 0xfb, 0xfe,
-	0x5c, 0x5c, 0x97, 0x72, 0xd7, 0x8f, 0xa0, 0x3d, 0xe6, 0x19, 0xc3, 0xef, 0x17, 0x72, 0x6e, 0x4b,
-	0x2e, 0x68, 0xfd, 0x0b, 0xcb, 0x46, 0x6a, 0x92, 0x28, 0xb4, 0x3a, 0x6d, 0x5c, 0xd1, 0xc8, 0x09,
-	0x2c, 0x26, 0x94, 0x0e, 0xb0, 0xbe, 0x89, 0xdf, 0xce, 0x26, 0xac, 0x9b, 0x6a, 0x60, 0x6e, 0xfa,
-	0x14, 0xda, 0x4f, 0x98, 0x2b, 0x7c, 0x75, 0xe5, 0x9c, 0x75, 0x20, 0xba, 0x04, 0x94, 0xcb, 0xd0,
-	0xbd, 0x61, 0x94, 0x98, 0xa7, 0x76, 0x36, 0x98, 0x31, 0x74, 0x14, 0x89, 0x19, 0x2c, 0x91, 0x87,
-	0x6f, 0x82, 0x24, 0x7b, 0x29, 0xd9, 0x81, 0x75, 0x13, 0x46, 0x3f, 0x61, 0x05, 0x94, 0x0a, 0x44,
-	0xe8, 0xc4, 0x06, 0x26, 0xf9, 0xe5, 0x7c, 0x59, 0x81, 0xce, 0x01, 0xef, 0xe6, 0xf7, 0x38, 0x59,
-	0x98, 0x4c, 0x12, 0x77, 0xec, 0xab, 0x33, 0xb1, 0xd4, 0x87, 0x8f, 0x44, 0x7d, 0x73, 0x0a, 0x6c,
-	0x20, 0x8c, 0xe3, 0x22, 0x7f, 0xa3, 0x9b, 0x24, 0xfc, 0xca, 0xa7, 0xae, 0x35, 0xfd, 0xe6, 0x6b,
-	0xdc, 0x22, 0x8c, 0x5c, 0x59, 0x77, 0xfa, 0xcd, 0xeb, 0x94, 0x4f, 0x63, 0xf4, 0x6b, 0x8a, 0x05,
-	0x5c, 0x87, 0x9c, 0x2b, 0x70, 0xb9, 0x44, 0x3d, 0x79, 0xa8, 0x5d, 0x77, 0xfa, 0x2e, 0x7d, 0x40,
-	0xe3, 0xd7, 0x81, 0xcf, 0xd3, 0xfd, 0x0a, 0x22, 0xe4, 0xb2, 0x16, 0xec, 0xe6, 0xeb, 0xb5, 0x6d,
-	0x97, 0x2d, 0xa1, 0xcc, 0x7f, 0x57, 0xa1, 0x2e, 0x2d, 0xa8, 0x64, 0x7e, 0x0f, 0x16, 0xf9, 0x33,
-	0x1b, 0xd9, 0xd4, 0xb8, 0xb4, 0x67, 0x38, 0x7b, 0xab, 0x80, 0x4f, 0x6b, 0xcf, 0x0a, 0x3e, 0xa7,
-	0x19, 0xca, 0x98, 0x6f, 0x74, 0x86, 0x32, 0xf9, 0xc7, 0x3a, 0x17, 0xea, 0xc6, 0x53, 0x1a, 0xb9,
-	0x5e, 0x7c, 0xe1, 0x32, 0xde, 0xe7, 0xec, 0x1b, 0xb3, 0x09, 0x50, 0xe6, 0x1e, 0xac, 0xaa, 0xb7,
-	0x31, 0x62, 0x97, 0x3e, 0x98, 0x49, 0x49, 0x57, 0xe6, 0x3c, 0xa6, 0xf1, 0xa3, 0xa9, 0xa7, 0x26,
-	0xfd, 0x68, 0xe6, 0x7c, 0x6d, 0x1c, 0x2d, 0x3f, 0x0a, 0xbf, 0x80, 0x66, 0x6e, 0x22, 0x23, 0xef,
-	0x68, 0xe4, 0xe5, 0x83, 0xac, 0xed, 0xcc, 0x23, 0x41, 0xc9, 0x13, 0xe8, 0xcc, 0x6a, 0x0b, 0xc8,
-	0x87, 0xe5, 0x55, 0xb8, 0x2c, 0xf7, 0xda, 0x1f, 0x9d, 0x8b, 0x56, 0x6e, 0x7a, 0xa7, 0x42, 0x22,
-	0xd6, 0x24, 0x96, 0xd6, 0x14, 0x72, 0xfb, 0x1c, 0x65, 0x47, 0x6e, 0xf9, 0xc1, 0xb9, 0x0b, 0x14,
-	0xdb, 0x30, 0xc8, 0x9e, 0x68, 0x8d, 0xed, 0x6e, 0x95, 0xb8, 0x40, 0xd9, 0x66, 0xef, 0x9f, 0x49,
-	0x37, 0xdd, 0xea, 0x67, 0xd0, 0xca, 0x4f, 0x71, 0xc4, 0x39, 0x7b, 0xe8, 0xb4, 0x6f, 0xce, 0xa5,
-	0xc9, 0x9c, 0xdc, 0x78, 0xc7, 0x33, 0x9c, 0xbc, 0xec, 0xed, 0xd0, 0x70, 0xf2, 0xd2, 0x27, 0x40,
-	0xf2, 0x18, 0xaa, 0xda, 0x4b, 0x1d, 0xd9, 0xce, 0xbf, 0x9d, 0x99, 0xf2, 0xae, 0xcd, 0x5a, 0xce,
-	0x49, 0xc3, 0x6c, 0xb7, 0x3d, 0xf7, 0x25, 0xae, 0x28, 0x2d, 0xf7, 0xa6, 0xc6, 0x8c, 0x99, 0x7f,
-	0xa3, 0x32, 0x8c, 0x39, 0xe3, 0x55, 0xcd, 0x30, 0xe6, 0xac, 0x47, 0x2e, 0x1e, 0x56, 0xb9, 0x89,
-	0xd0, 0x08, 0xab, 0xf2, 0x71, 0xdb, 0x08, 0xab, 0x59, 0xe3, 0x28, 0x93, 0x9c, 0x1b, 0x37, 0x0c,
-	0xc9, 0xe5, 0x03, 0x92, 0x21, 0x79, 0xd6, 0xb4, 0xe2, 0x01, 0x29, 0x4e, 0x02, 0x44, 0xff, 0x0f,
-	0x6c, 0xe6, 0xd0, 0x61, 0xbf, 0x77, 0x06, 0x15, 0x66, 0xf5, 0xbf, 0x59, 0xaa, 0x5c, 0x3e, 0x8e,
-	0x3c, 0xd6, 0xc3, 0xa9, 0xdc, 0xfe, 0x04, 0x6a, 0x7a, 0xb9, 0x24, 0xfa, 0xdd, 0x95, 0x94, 0x57,
-	0xfb, 0xfa, 0xcc, 0x75, 0x3c, 0x0b, 0x13, 0xa8, 0xf7, 0x0c, 0x86, 0xc0, 0x92, 0x9e, 0xc6, 0x10,
-	0x58, 0xd6, 0x6c, 0x90, 0x2e, 0x40, 0xd6, 0x2a, 0x90, 0xab, 0x1a, 0x79, 0xa1, 0x07, 0xb1, 0xb7,
-	0x67, 0xac, 0x66, 0x6e, 0xac, 0x75, 0x12, 0x86, 0x1b, 0x17, 0xfb, 0x0e, 0xc3, 0x8d, 0x4b, 0x1a,
-	0x10, 0xf2, 0x0b, 0x68, 0x17, 0x2a, 0x33, 0xd1, 0x7d, 0x74, 0x56, 0x5b, 0x61, 0xbf, 0x3b, 0x9f,
-	0x48, 0xca, 0x3f, 0x5c, 0x16, 0x7f, 0x43, 0x7f, 0xfb, 0x7f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x22,
-	0xfc, 0x70, 0xb3, 0x93, 0x1e, 0x00, 0x00,
+	// 3390 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x1a, 0x4d, 0x6f, 0x1b, 0xc7,
+	0x35, 0x4b, 0x4a, 0x22, 0xf5, 0x28, 0x52, 0xd4, 0x8a, 0x92, 0xe8, 0xb5, 0xf5, 0x91, 0x75, 0x1c,
+	0x3b, 0x71, 0xa2, 0x3a, 0x6a, 0x92, 0xa6, 0x48, 0x90, 0x44, 0x96, 0xe5, 0x94, 0xb5, 0x23, 0x13,
+	0x2b, 0xd9, 0x31, 0x90, 0xa2, 0xc4, 0x8a, 0x1c, 0x4b, 0x5b, 0x91, 0xb3, 0xf4, 0x7e, 0x58, 0x56,
+	0x4f, 0x45, 0x83, 0x1e, 0x0b, 0x14, 0x6d, 0x0f, 0x45, 0x8b, 0xf4, 0xd0, 0x5f, 0x50, 0xb4, 0x97,
+	0x1e, 0x1b, 0xf4, 0x67, 0xb4, 0xd7, 0xfe, 0x81, 0xde, 0x0b, 0x14, 0x33, 0xf3, 0x66, 0x77, 0x86,
+	0xbb, 0xa4, 0x64, 0x23, 0xe8, 0x8d, 0xf3, 0xbe, 0xe6, 0xed, 0x9b, 0x37, 0xef, 0x6b, 0x08, 0xb3,
+	0xee, 0xd0, 0xdb, 0x1c, 0x06, 0x7e, 0xe4, 0x9b, 0xb3, 0xa7, 0x6e, 0xbf, 0x4f, 0xa2, 0x60, 0xd8,
+	0xb5, 0xeb, 0x50, 0x7b, 0x44, 0x82, 0xd0, 0xf3, 0xa9, 0x43, 0x9e, 0xc6, 0x24, 0x8c, 0xec, 0x6f,
+	0x0c, 0x98, 0x4f, 0x40, 0xe1, 0xd0, 0xa7, 0x21, 0x31, 0xaf, 0x41, 0xed, 0x99, 0x00, 0x75, 0xc2,
+	0x28, 0xf0, 0xe8, 0x51, 0xd3, 0xd8, 0x30, 0x6e, 0xcc, 0x3a, 0x55, 0x84, 0xee, 0x73, 0xa0, 0xd9,
+	0x80, 0xe9, 0x81, 0xfb, 0x13, 0x3f, 0x68, 0x16, 0x36, 0x8c, 0x1b, 0x55, 0x47, 0x2c, 0x38, 0xd4,
+	0xa3, 0x7e, 0xd0, 0x2c, 0x22, 0xd4, 0xa3, 0x02, 0x3a, 0x74, 0xa3, 0xee, 0x71, 0x73, 0x4a, 0x40,
+	0xf9, 0xc2, 0x5c, 0x03, 0x18, 0x06, 0x24, 0x20, 0x7d, 0xe2, 0x86, 0xa4, 0x39, 0xcd, 0x37, 0x51,
+	0x20, 0x4c, 0x91, 0xc3, 0xd8, 0xeb, 0xf7, 0x3a, 0x03, 0x12, 0xb9, 0x3d, 0x37, 0x72, 0x9b, 0x33,
+	0x42, 0x11, 0x0e, 0xfd, 0x1c, 0x81, 0xf6, 0xdf, 0x8b, 0x60, 0x1e, 0x04, 0x2e, 0x0d, 0xdd, 0x6e,
+	0xe4, 0xf9, 0xf4, 0x0e, 0x89, 0x5c, 0xaf, 0x1f, 0x9a, 0x26, 0x4c, 0x1d, 0xbb, 0xe1, 0x31, 0x57,
+	0x7e, 0xce, 0xe1, 0xbf, 0xcd, 0x0d, 0xa8, 0x44, 0x29, 0x25, 0xd7, 0x7c, 0xce, 0x51, 0x41, 0xe6,
+	0x87, 0x30, 0xd3, 0x23, 0x87, 0x5e, 0x14, 0x36, 0x8b, 0x1b, 0xc5, 0x1b, 0x95, 0xad, 0xab, 0x9b,
+	0x89, 0xf9, 0x36, 0xb3, 0x9b, 0x6c, 0xb6, 0xe8, 0x30, 0x8e, 0x1c, 0x64, 0x31, 0x3f, 0x86, 0x52,
+	0x37, 0x20, 0x3d, 0xc6, 0x3d, 0xc5, 0xb9, 0x5f, 0x9b, 0xcc, 0xfd, 0x20, 0x8e, 0x18, 0xbb, 0x64,
+	0x32, 0xeb, 0x50, 0x7c, 0x42, 0x84, 0x25, 0x8a, 0x0e, 0xfb, 0x69, 0x5e, 0x81, 0xd9, 0xc8, 0x1b,
+	0x90, 0x30, 0x72, 0x07, 0x43, 0xfe, 0xf5, 0x45, 0x27, 0x05, 0x58, 0x4f, 0x61, 0x9a, 0x2b, 0xc0,
+	0xec, 0xeb, 0xd1, 0x1e, 0x79, 0xce, 0x3f, 0xb6, 0xea, 0x88, 0x85, 0xf9, 0x06, 0xd4, 0x87, 0x01,
+	0x79, 0xe6, 0xf9, 0x71, 0xd8, 0x71, 0xbb, 0x5d, 0x3f, 0xa6, 0x11, 0x1e, 0xd6, 0xbc, 0x84, 0x6f,
+	0x0b, 0xb0, 0x79, 0x1d, 0xe6, 0x53, 0xd2, 0x01, 0xa7, 0x2c, 0xf2, 0xdd, 0x6a, 0x09, 0x25, 0x87,
+	0x5a, 0x07, 0x30, 0x23, 0xb4, 0x1e, 0xb3, 0x67, 0x13, 0x4a, 0xfa, 0x56, 0x72, 0x69, 0x5a, 0x50,
+	0xf6, 0x68, 0x44, 0x02, 0xea, 0xf6, 0xb9, 0xec, 0xb2, 0x93, 0xac, 0xed, 0x3f, 0x18, 0x30, 0x77,
+	0xbb, 0xef, 0x77, 0x4f, 0x26, 0x1d, 0xde, 0x32, 0xcc, 0x1c, 0x13, 0xef, 0xe8, 0x58, 0x48, 0x9e,
+	0x76, 0x70, 0xa5, 0xdb, 0xa8, 0x38, 0x62, 0x23, 0x73, 0x1b, 0xe6, 0x94, 0xf3, 0x95, 0x07, 0xb3,
+	0x3a, 0xf1, 0x60, 0x1c, 0x8d, 0xc5, 0xfe, 0x6f, 0x11, 0x4a, 0x2d, 0xfa, 0xcc, 0xf7, 0xba, 0xc4,
+	0xac, 0x41, 0xc1, 0xeb, 0x71, 0xb5, 0xa6, 0x9c, 0x82, 0xd7, 0x9b, 0xf0, 0xbd, 0x0c, 0xd3, 0xeb,
+	0x05, 0x24, 0x0c, 0xb9, 0x52, 0xb3, 0x8e, 0x5c, 0xb2, 0x0f, 0x41, 0x1b, 0x4f, 0x71, 0x6d, 0x71,
+	0xc5, 0x2c, 0x14, 0x90, 0x2e, 0xf1, 0x9e, 0x91, 0x1e, 0xfa, 0x40, 0xb2, 0x66, 0x06, 0x19, 0x90,
+	0x81, 0x8f, 0x37, 0x80, 0xff, 0x66, 0x3b, 0x74, 0x03, 0xe2, 0x46, 0xa4, 0xd7, 0x2c, 0x71, 0x72,
+	0xb9, 0x64, 0x3b, 0x90, 0xe7, 0x43, 0x2f, 0x38, 0x6b, 0x96, 0xc5, 0x0e, 0x62, 0x65, 0xbe, 0x03,
+	0x33, 0x61, 0xe4, 0x46, 0x71, 0xd8, 0x9c, 0xdd, 0x30, 0x6e, 0xd4, 0xb6, 0x2e, 0x29, 0x66, 0xc0,
+	0x2f, 0xdc, 0xdc, 0xe7, 0x04, 0x0e, 0x12, 0x32, 0x9f, 0x8c, 0x03, 0xaf, 0x09, 0x7c, 0x5f, 0xf6,
+	0xd3, 0x7c, 0x1f, 0xca, 0x43, 0xf7, 0x6c, 0x40, 0x68, 0x14, 0x36, 0x2b, 0xdc, 0x9a, 0x56, 0x8e,
+	0x98, 0xb6, 0x20, 0x71, 0x12, 0x5a, 0xcb, 0x87, 0x12, 0x02, 0x99, 0x67, 0x2a, 0x16, 0xee, 0x28,
+	0x47, 0x3d, 0xaf, 0xc0, 0x7f, 0xc0, 0x4e, 0xfd, 0x55, 0x98, 0xf3, 0xb9, 0xc3, 0x75, 0x84, 0xb7,
+	0x09, 0x2b, 0x57, 0x04, 0xac, 0xc5, 0x40, 0x8a, 0x3d, 0x8b, 0xaa, 0x3d, 0xed, 0xbb, 0x30, 0x23,
+	0x3e, 0xc6, 0x04, 0x98, 0x79, 0xb8, 0xd7, 0xde, 0x6e, 0xdd, 0xa9, 0xbf, 0x62, 0x56, 0xa0, 0xd4,
+	0xde, 0x76, 0x0e, 0x5a, 0xdb, 0xf7, 0xeb, 0x86, 0x59, 0x86, 0x29, 0x0e, 0x2e, 0x98, 0x73, 0x50,
+	0x7e, 0xf0, 0x68, 0xd7, 0xe1, 0xab, 0x22, 0x23, 0xda, 0x7d, 0xdc, 0x6e, 0x39, 0xbb, 0x77, 0xea,
+	0x53, 0xf6, 0x03, 0xa8, 0xe1, 0x3d, 0xb9, 0xed, 0xf6, 0x5d, 0xda, 0x25, 0xea, 0xa9, 0x1b, 0xfa,
+	0xa9, 0x5f, 0x85, 0x6a, 0xe4, 0x47, 0x6e, 0xbf, 0x73, 0x28, 0x48, 0xb9, 0xbe, 0x45, 0x67, 0x8e,
+	0x03, 0x91, 0xdd, 0xae, 0x42, 0xa5, 0xed, 0xd1, 0x23, 0x19, 0x84, 0x6b, 0x30, 0x27, 0x96, 0x22,
+	0x00, 0xb3, 0x30, 0xbd, 0x47, 0xa2, 0x53, 0x3f, 0x38, 0x91, 0x14, 0x1f, 0xc0, 0x7c, 0x02, 0x49,
+	0xa3, 0x34, 0xb3, 0xd2, 0x33, 0xd2, 0xa1, 0x02, 0x83, 0x9a, 0x54, 0x05, 0x14, 0xc9, 0xed, 0xef,
+	0x43, 0x03, 0x75, 0xdf, 0x8b, 0x07, 0x87, 0x24, 0x40, 0x89, 0xcc, 0xac, 0xa8, 0x72, 0x87, 0xba,
+	0x03, 0x82, 0x21, 0xbe, 0x82, 0xb0, 0x3d, 0x77, 0x40, 0xec, 0x8f, 0x61, 0x69, 0x84, 0x55, 0xdd,
+	0x1a, 0x79, 0x39, 0x26, 0xdd, 0x5a, 0x21, 0xb7, 0x17, 0x60, 0x1e, 0xf9, 0x43, 0xf9, 0x1d, 0x7f,
+	0x2b, 0x42, 0x3d, 0x85, 0xa1, 0xb8, 0x4f, 0xa0, 0x8c, 0x8c, 0x61, 0xd3, 0xc8, 0x04, 0xdd, 0x51,
+	0x72, 0x09, 0x70, 0x12, 0x26, 0xf3, 0x2d, 0x30, 0xbb, 0x71, 0x10, 0x10, 0x1a, 0x75, 0x0e, 0x59,
+	0x10, 0x11, 0xfe, 0x24, 0x82, 0x7b, 0x1d, 0x31, 0x3c, 0xba, 0x70, 0x87, 0xba, 0x05, 0x8d, 0x11,
+	0x6a, 0x11, 0x54, 0x8a, 0x3c, 0xa8, 0x98, 0x1a, 0x3d, 0xc7, 0x58, 0x3f, 0x2f, 0x40, 0x49, 0x06,
+	0xca, 0x8b, 0x7d, 0x7b, 0xc6, 0xbc, 0x85, 0x8c, 0x79, 0xb3, 0x9e, 0x52, 0xcc, 0x7a, 0x0a, 0xfb,
+	0x34, 0xf2, 0x5c, 0x04, 0xc9, 0xce, 0x09, 0x39, 0xeb, 0x74, 0x93, 0xb0, 0x51, 0x75, 0xea, 0x12,
+	0x73, 0x8f, 0x9c, 0xed, 0x70, 0xe5, 0xde, 0x02, 0xd3, 0xa3, 0x19, 0xea, 0x69, 0x41, 0xed, 0xd1,
+	0x1c, 0xea, 0xc1, 0xd0, 0x0f, 0x22, 0xd2, 0x53, 0xa8, 0x67, 0x90, 0x1a, 0x31, 0x92, 0xda, 0x7e,
+	0x0c, 0x0d, 0x87, 0xb0, 0x6f, 0x91, 0xf6, 0x47, 0x47, 0xba, 0xa0, 0x41, 0x2e, 0x41, 0x99, 0x92,
+	0x53, 0xd5, 0x18, 0x25, 0x4a, 0x4e, 0xb9, 0x9f, 0xad, 0xc0, 0xd2, 0x88, 0x64, 0xbc, 0x07, 0x5f,
+	0x80, 0xb9, 0x47, 0x9e, 0x47, 0x23, 0x1b, 0xb2, 0xaa, 0xc1, 0x0d, 0xc3, 0xe1, 0x71, 0xc0, 0xaa,
+	0x06, 0x11, 0x35, 0x14, 0xc8, 0x05, 0x4c, 0x6f, 0x7f, 0x04, 0x8b, 0x9a, 0xe0, 0x17, 0xf3, 0xeb,
+	0xdf, 0x1b, 0xa8, 0x97, 0x08, 0xe7, 0x52, 0xaf, 0xf1, 0x31, 0xe1, 0x7d, 0x98, 0x3a, 0xf1, 0x68,
+	0x8f, 0x6b, 0x52, 0xdb, 0xb2, 0x15, 0xe7, 0xce, 0x8a, 0xd9, 0xbc, 0xe7, 0xd1, 0x9e, 0xc3, 0xe9,
+	0xed, 0x2d, 0x98, 0x62, 0x2b, 0xb3, 0x01, 0xf5, 0xdb, 0xad, 0xf6, 0xad, 0x5b, 0xef, 0xbe, 0xdb,
+	0xd9, 0x7d, 0x7c, 0xb0, 0xeb, 0xec, 0x6d, 0xdf, 0xaf, 0xbf, 0xa2, 0x42, 0x5b, 0x7b, 0x08, 0x35,
+	0xec, 0xef, 0xc0, 0xa2, 0x26, 0x14, 0x3f, 0x4d, 0x49, 0x46, 0x86, 0x96, 0x8c, 0xec, 0xdf, 0x18,
+	0xb0, 0xd2, 0xe2, 0x87, 0xdd, 0x0e, 0xbc, 0x67, 0x6e, 0x44, 0xee, 0x91, 0xb3, 0x8b, 0x9a, 0x7a,
+	0x7c, 0xf2, 0x7b, 0x9d, 0xd5, 0x13, 0x5c, 0x1c, 0x77, 0xad, 0x53, 0xef, 0x09, 0x26, 0xc1, 0xea,
+	0x30, 0xd9, 0xe5, 0x0b, 0xef, 0x09, 0x0b, 0xdd, 0x01, 0x09, 0xbb, 0x2e, 0xe5, 0x3e, 0x5d, 0x76,
+	0x70, 0x65, 0x5b, 0xd0, 0xcc, 0x2a, 0x85, 0x6e, 0x41, 0xa1, 0x86, 0xd7, 0xe3, 0x05, 0x7d, 0xf0,
+	0x3d, 0x58, 0x0e, 0xc8, 0xd3, 0xd8, 0x0b, 0x48, 0xaf, 0xd3, 0xf5, 0xe9, 0x13, 0x2f, 0x18, 0xb8,
+	0xa2, 0x28, 0x10, 0x05, 0xc5, 0x92, 0xc4, 0xee, 0xa8, 0x48, 0x9b, 0xc2, 0x7c, 0xb2, 0x1f, 0x9a,
+	0xb3, 0x01, 0xd3, 0xfc, 0x9a, 0xf2, 0x7d, 0x8a, 0x8e, 0x58, 0xb0, 0x42, 0x24, 0x1c, 0x12, 0xda,
+	0x73, 0x0f, 0xfb, 0x32, 0xee, 0xa7, 0x00, 0x56, 0x62, 0x79, 0x83, 0x81, 0x1b, 0xc5, 0x01, 0xe9,
+	0x04, 0xe4, 0xd4, 0x0d, 0x7a, 0xb2, 0xc4, 0x92, 0x60, 0x87, 0x43, 0xed, 0xdf, 0x15, 0x60, 0xf9,
+	0x33, 0x12, 0x29, 0x65, 0x49, 0xe2, 0x63, 0x9b, 0xb0, 0x18, 0x46, 0x6e, 0x10, 0x79, 0xf4, 0x48,
+	0x0d, 0x75, 0xe2, 0x64, 0x16, 0x24, 0x2a, 0x8d, 0x75, 0x5b, 0xb0, 0x34, 0x4a, 0x9f, 0x56, 0x50,
+	0x0b, 0xce, 0xa2, 0xce, 0xc1, 0x51, 0xe6, 0x9b, 0xb0, 0x40, 0x68, 0x6f, 0x64, 0x87, 0xa2, 0x48,
+	0xce, 0x02, 0x91, 0xca, 0xdf, 0x84, 0x45, 0x9d, 0x56, 0x48, 0x9f, 0xe2, 0xe6, 0x5c, 0x50, 0xa9,
+	0x85, 0xec, 0x8f, 0xe1, 0xf2, 0xc0, 0xa3, 0xde, 0x20, 0x1e, 0x74, 0x02, 0xd2, 0x65, 0x21, 0x58,
+	0xab, 0xcd, 0xa6, 0x39, 0xdf, 0x25, 0x24, 0x71, 0x38, 0x85, 0x6a, 0x06, 0xfb, 0xaf, 0x06, 0xac,
+	0x64, 0x4c, 0x83, 0x67, 0x72, 0x17, 0xcc, 0x81, 0x47, 0x49, 0x4f, 0x17, 0x29, 0x12, 0xca, 0x8a,
+	0x72, 0xe7, 0xd4, 0x3a, 0xd3, 0x59, 0xe0, 0x2c, 0xaa, 0x3c, 0xb3, 0x0d, 0x8d, 0x98, 0xe6, 0x48,
+	0x2a, 0x5c, 0xa4, 0x70, 0x5c, 0x44, 0x56, 0x4d, 0xeb, 0x6f, 0x0c, 0x58, 0xd9, 0x39, 0x76, 0xe9,
+	0x11, 0x69, 0x27, 0x77, 0x47, 0x9e, 0xe8, 0x07, 0x50, 0x3c, 0x21, 0x67, 0xfc, 0x04, 0x6b, 0x5b,
+	0xaf, 0x2b, 0xc2, 0xc7, 0x30, 0x6c, 0xb2, 0x9b, 0xc0, 0x58, 0x98, 0xd3, 0xfb, 0xfd, 0x5e, 0x47,
+	0xb9, 0xa0, 0x22, 0xe3, 0x55, 0xfd, 0x7e, 0x2f, 0x65, 0x63, 0x64, 0x2c, 0xf0, 0x2a, 0x64, 0xe2,
+	0x2c, 0xab, 0x94, 0x9c, 0xa6, 0x64, 0xf6, 0x1a, 0x14, 0xef, 0x91, 0x33, 0x5e, 0x1c, 0x39, 0xad,
+	0x47, 0xdb, 0x07, 0xbb, 0xf5, 0x57, 0x58, 0xd5, 0xd4, 0x7e, 0x78, 0xfb, 0x7e, 0x6b, 0xa7, 0x6e,
+	0xb0, 0x0b, 0x99, 0xd5, 0x08, 0x2f, 0xe4, 0xcf, 0x0a, 0xb0, 0x7c, 0x37, 0xa6, 0xea, 0x47, 0x9f,
+	0x1f, 0x14, 0x59, 0xfa, 0x73, 0x83, 0x23, 0x12, 0xc9, 0x7e, 0x43, 0x16, 0x4a, 0x1c, 0x28, 0xba,
+	0x8d, 0x09, 0x37, 0xb6, 0x38, 0xe1, 0xc6, 0x9a, 0x1f, 0x81, 0xe5, 0xd1, 0x6e, 0x3f, 0xee, 0x91,
+	0x4e, 0x72, 0xe5, 0xba, 0xbe, 0x47, 0x0f, 0xdd, 0x90, 0x84, 0x18, 0x69, 0x9a, 0x48, 0xd1, 0x42,
+	0x82, 0x1d, 0x89, 0x67, 0x97, 0x46, 0x72, 0x77, 0xf9, 0x27, 0x77, 0xc2, 0x6e, 0xe0, 0x0d, 0x45,
+	0x22, 0x2d, 0x3b, 0x8b, 0x88, 0x14, 0xe6, 0xd8, 0xe7, 0x28, 0xfb, 0x4f, 0x45, 0x58, 0xc9, 0x98,
+	0x00, 0x1d, 0xf3, 0x47, 0x50, 0x0f, 0x49, 0x9f, 0x74, 0x59, 0x9e, 0x15, 0x65, 0xab, 0x74, 0xcb,
+	0x77, 0x94, 0xf3, 0x1e, 0xc3, 0xbd, 0xd9, 0xc6, 0xfe, 0x0b, 0x7b, 0xc5, 0x79, 0x29, 0x4a, 0xac,
+	0x43, 0x96, 0xee, 0x44, 0x19, 0xa1, 0x99, 0xb1, 0xc2, 0x61, 0x68, 0xc5, 0x1b, 0x50, 0xc7, 0x0f,
+	0x19, 0x9e, 0xc8, 0x6f, 0x11, 0x4e, 0x50, 0x13, 0xf0, 0xf6, 0x89, 0xf8, 0x0c, 0xeb, 0x9f, 0x06,
+	0xd4, 0xf4, 0x0d, 0xff, 0x3f, 0xa5, 0xba, 0x79, 0x19, 0x66, 0x53, 0xdd, 0xa6, 0xb8, 0xf8, 0xf2,
+	0x10, 0xb5, 0x62, 0x72, 0xb1, 0x0f, 0xea, 0xb0, 0xbe, 0x0e, 0x7b, 0xa3, 0x0a, 0xc2, 0x0e, 0x3c,
+	0x51, 0x4c, 0x3d, 0x09, 0xfc, 0x41, 0x72, 0xca, 0xbc, 0x8c, 0x29, 0x3b, 0x73, 0x0c, 0x28, 0x4f,
+	0xd6, 0xfe, 0xad, 0x01, 0xcb, 0xfb, 0xde, 0x11, 0xcd, 0xf1, 0xd3, 0xf3, 0x32, 0xdd, 0x7b, 0xb0,
+	0x1c, 0x92, 0xc0, 0x73, 0xfb, 0xde, 0x4f, 0xf5, 0xb8, 0x80, 0x97, 0x6e, 0x29, 0xc5, 0x2a, 0xd2,
+	0x99, 0x5a, 0x1e, 0x4d, 0x0c, 0x42, 0xc4, 0x50, 0xa1, 0xea, 0xcc, 0x79, 0x54, 0x5a, 0x84, 0x84,
+	0xf6, 0x53, 0x58, 0xc9, 0x68, 0x85, 0xae, 0x33, 0x32, 0xaf, 0x30, 0xb2, 0xf3, 0x8a, 0x77, 0x61,
+	0x39, 0xa6, 0xa1, 0x77, 0xc4, 0xc2, 0x95, 0xbe, 0x55, 0x81, 0x6f, 0xd5, 0x90, 0xd8, 0x96, 0xba,
+	0xe5, 0x2e, 0xcc, 0xb3, 0x2d, 0xdb, 0xe1, 0xe1, 0x85, 0xcb, 0x2a, 0x13, 0xa6, 0x86, 0xe1, 0x61,
+	0x84, 0xdf, 0xcb, 0x7f, 0xdb, 0x8f, 0xa1, 0x9e, 0x8a, 0x41, 0x95, 0x25, 0x9d, 0x91, 0xd2, 0xb1,
+	0x92, 0x7b, 0x82, 0x8a, 0x66, 0x8e, 0x82, 0x3f, 0x84, 0x4b, 0xed, 0xf8, 0xb0, 0xef, 0x85, 0xc7,
+	0x39, 0x87, 0xf5, 0x36, 0x20, 0x4b, 0x27, 0x6b, 0x9c, 0x05, 0x81, 0x51, 0xb8, 0xec, 0x2b, 0x60,
+	0xe5, 0xc9, 0xc2, 0xe0, 0xd5, 0x00, 0xf3, 0xbe, 0x17, 0x32, 0xfd, 0xbb, 0x6e, 0x92, 0x68, 0xed,
+	0x7f, 0x15, 0x61, 0x51, 0x03, 0x27, 0xbd, 0x4a, 0x49, 0x54, 0x28, 0xf2, 0x0a, 0x5f, 0x53, 0xae,
+	0x70, 0x0e, 0xc3, 0xa6, 0x58, 0x3b, 0x92, 0xcb, 0xfa, 0xaa, 0x08, 0x33, 0x02, 0x96, 0x19, 0x25,
+	0x6c, 0xc3, 0x74, 0x18, 0xb9, 0x11, 0xc1, 0x3a, 0xf1, 0xe6, 0x85, 0x24, 0xf3, 0x76, 0x9d, 0x38,
+	0x82, 0x93, 0x85, 0xdb, 0x20, 0xa6, 0x94, 0xcd, 0xec, 0xc4, 0x88, 0x45, 0x2e, 0xd9, 0xc5, 0x7b,
+	0x1a, 0x93, 0x98, 0xf4, 0x64, 0xa1, 0x25, 0x56, 0xec, 0x6e, 0xf1, 0x22, 0x40, 0xa6, 0x6e, 0x91,
+	0x82, 0x2b, 0x1c, 0x86, 0x49, 0x7b, 0x15, 0x00, 0x49, 0xd8, 0xdd, 0x9f, 0xe1, 0x66, 0x9e, 0x15,
+	0x04, 0xec, 0xd6, 0xf3, 0xd1, 0x91, 0x7f, 0x14, 0x90, 0x30, 0x94, 0x42, 0x4a, 0x5c, 0x48, 0x4d,
+	0x82, 0x51, 0xce, 0x55, 0xa8, 0xa6, 0x84, 0x4c, 0x54, 0x99, 0x8b, 0x9a, 0x4b, 0xc8, 0x98, 0xb4,
+	0x2b, 0x30, 0x8b, 0x95, 0x29, 0x61, 0x43, 0x8a, 0xe2, 0x8d, 0x59, 0x27, 0x05, 0xf0, 0x9c, 0x17,
+	0x47, 0x43, 0xdf, 0xa3, 0x11, 0xb6, 0x2b, 0x20, 0x0a, 0x3d, 0x09, 0x15, 0xbd, 0xca, 0x3a, 0x4c,
+	0x73, 0xb3, 0xb0, 0x0c, 0xb6, 0xbd, 0x73, 0xd0, 0x7a, 0x24, 0xb3, 0xd9, 0xf6, 0xc3, 0xfd, 0xdd,
+	0x3b, 0x75, 0xc3, 0x7e, 0x0d, 0xcc, 0xb6, 0x1b, 0x87, 0x04, 0x4f, 0x07, 0xfd, 0x6a, 0xe4, 0x40,
+	0xec, 0x25, 0x58, 0xd4, 0xa8, 0xd0, 0x63, 0xae, 0xc1, 0xa2, 0x43, 0xc2, 0x78, 0x70, 0x0e, 0xf7,
+	0x32, 0x34, 0x74, 0xb2, 0x94, 0x7d, 0xc7, 0xa5, 0x5d, 0xd2, 0x3f, 0x97, 0x5d, 0x27, 0x43, 0xf6,
+	0x7d, 0xa8, 0xea, 0x8c, 0xa3, 0x27, 0x68, 0x64, 0x4f, 0x70, 0x1d, 0x2a, 0x61, 0xe4, 0x0f, 0x3b,
+	0xda, 0xf8, 0x0c, 0x18, 0x48, 0x10, 0xd8, 0xff, 0x2e, 0x40, 0x4d, 0xdf, 0xc7, 0xbc, 0x09, 0x0b,
+	0xc2, 0x67, 0xf9, 0x3d, 0x3b, 0x0e, 0xfc, 0xf8, 0xe8, 0x18, 0x65, 0xd7, 0x13, 0xc4, 0x81, 0x80,
+	0xb3, 0x28, 0x94, 0x21, 0x56, 0xbb, 0xf0, 0xc6, 0x28, 0x07, 0x3f, 0xeb, 0x0f, 0xa1, 0x14, 0xc6,
+	0x83, 0x81, 0x1b, 0x9c, 0x71, 0x6f, 0xad, 0x6c, 0xbd, 0xaa, 0xb8, 0xbc, 0xae, 0xce, 0xe6, 0xbe,
+	0x20, 0x74, 0x24, 0x87, 0xf5, 0x17, 0x03, 0x4a, 0x08, 0xfc, 0x36, 0x4c, 0xc0, 0x82, 0xca, 0x68,
+	0x9e, 0xc3, 0x78, 0x3d, 0xe7, 0x2c, 0x8c, 0x64, 0x3a, 0x12, 0xb2, 0x2f, 0x66, 0x65, 0x55, 0x0e,
+	0xcb, 0x14, 0x67, 0x69, 0x50, 0x72, 0x7a, 0x30, 0xca, 0x65, 0x7f, 0x65, 0x40, 0x63, 0x87, 0xcf,
+	0xe8, 0x70, 0x4c, 0x76, 0x7e, 0x9d, 0x94, 0x66, 0xcc, 0x82, 0x96, 0x31, 0xe5, 0x40, 0xb0, 0xa8,
+	0x0c, 0x04, 0xaf, 0x41, 0x4d, 0x0c, 0xfa, 0x3a, 0x21, 0xe9, 0xfa, 0xb4, 0x17, 0xe2, 0x80, 0xb1,
+	0x2a, 0xa0, 0xfb, 0x02, 0x68, 0xef, 0xc2, 0xd2, 0x88, 0x12, 0x78, 0xe6, 0x6f, 0x41, 0xc9, 0x13,
+	0x20, 0xae, 0x45, 0x65, 0xcb, 0xcc, 0x0e, 0xf6, 0x1c, 0x49, 0x62, 0xdf, 0x01, 0xf3, 0x73, 0xb7,
+	0xeb, 0x06, 0xbe, 0x4f, 0xdb, 0x24, 0x18, 0x78, 0x61, 0xc8, 0x12, 0x12, 0x1b, 0x3d, 0xd2, 0xc8,
+	0x8b, 0xce, 0xb0, 0xd1, 0xc4, 0x15, 0xff, 0x8e, 0x34, 0x63, 0xce, 0x3a, 0xb8, 0xb2, 0xff, 0x68,
+	0xc0, 0xe2, 0x6d, 0xf7, 0x84, 0x48, 0x51, 0xd2, 0x22, 0x9f, 0x40, 0x65, 0x98, 0x48, 0x95, 0xd1,
+	0x56, 0xad, 0xbe, 0xb3, 0x7b, 0x3b, 0x2a, 0x07, 0x8b, 0x4b, 0xac, 0x5a, 0xf0, 0xe3, 0x28, 0xb1,
+	0x86, 0xb0, 0x60, 0x0d, 0xc1, 0x68, 0x0e, 0x16, 0xdf, 0xbc, 0x61, 0x47, 0x9f, 0xd5, 0xce, 0x7a,
+	0x43, 0x6c, 0xa1, 0xed, 0x2d, 0x68, 0xe8, 0xfa, 0xa1, 0xb1, 0x2c, 0x28, 0x0f, 0x10, 0x86, 0x9f,
+	0x9a, 0xac, 0xed, 0xab, 0xb0, 0xf0, 0x19, 0x89, 0x46, 0xce, 0x78, 0xf4, 0x86, 0xdf, 0x06, 0x53,
+	0x25, 0x7a, 0xa9, 0x33, 0x58, 0x12, 0x69, 0x0a, 0xe1, 0x49, 0xfa, 0xba, 0x0b, 0x0d, 0x1d, 0x8c,
+	0xc2, 0x37, 0xd9, 0x0c, 0x5e, 0xc0, 0xd0, 0xa2, 0x79, 0xd2, 0x13, 0x1a, 0x7b, 0x15, 0x2e, 0x23,
+	0x70, 0xcf, 0x8f, 0xbc, 0x27, 0x5e, 0xd7, 0x55, 0xdb, 0x51, 0xfb, 0x57, 0x06, 0x5c, 0xc9, 0xc7,
+	0xbf, 0xcc, 0xc7, 0x98, 0xb7, 0x95, 0x47, 0x08, 0x1c, 0x53, 0x17, 0xce, 0x1b, 0x53, 0x27, 0xef,
+	0x13, 0x62, 0x6d, 0xbf, 0x0a, 0xeb, 0xca, 0xb5, 0xcb, 0xd5, 0xfa, 0xeb, 0x02, 0x6c, 0x8c, 0xa7,
+	0x41, 0xcd, 0x3f, 0x85, 0x79, 0x37, 0x8a, 0xdc, 0xee, 0x31, 0xe9, 0x89, 0xde, 0xf6, 0xdc, 0x56,
+	0xb2, 0x26, 0xe9, 0x39, 0x94, 0xfb, 0x5f, 0x8f, 0xe8, 0x12, 0x0a, 0x3c, 0x34, 0xd4, 0x7a, 0x44,
+	0x23, 0x1c, 0xd7, 0x70, 0x16, 0x5f, 0xb6, 0xe1, 0x64, 0xfd, 0x4f, 0x8e, 0x44, 0x3d, 0x40, 0x35,
+	0xb3, 0x8c, 0x18, 0xa4, 0x7e, 0x69, 0xc0, 0xea, 0xfe, 0x90, 0xd0, 0x88, 0x92, 0x30, 0xcc, 0xb3,
+	0xe0, 0x84, 0x68, 0xf5, 0x26, 0x2c, 0x50, 0xbf, 0x43, 0x19, 0xd3, 0x59, 0x27, 0xa6, 0x21, 0x13,
+	0xc3, 0x0f, 0xb1, 0xec, 0xcc, 0x53, 0x9f, 0x0b, 0x3b, 0x7b, 0x28, 0xc0, 0x6c, 0x46, 0x94, 0xd2,
+	0x0a, 0x4a, 0x51, 0xb4, 0x54, 0x25, 0x25, 0xd7, 0xc2, 0xfe, 0x75, 0x01, 0xd6, 0xc6, 0xe9, 0x83,
+	0xa7, 0xf5, 0xed, 0x36, 0x29, 0xf7, 0xa0, 0xc4, 0xc7, 0x36, 0x24, 0xc0, 0xbc, 0xa4, 0xf6, 0x69,
+	0x93, 0x35, 0xe1, 0xe8, 0x1e, 0x09, 0x1c, 0x29, 0xc1, 0x7a, 0x08, 0x25, 0x84, 0xbd, 0x88, 0x96,
+	0xeb, 0x50, 0xf1, 0xe8, 0xa8, 0x92, 0x90, 0xb6, 0x0d, 0xec, 0x66, 0xca, 0xe1, 0x7c, 0x9e, 0x8f,
+	0xff, 0xc7, 0x80, 0x2b, 0xf9, 0xf8, 0x17, 0x9a, 0x75, 0x5e, 0x64, 0x8e, 0x9d, 0x3f, 0xa2, 0x2e,
+	0xbe, 0xd0, 0x88, 0x7a, 0xea, 0x85, 0x46, 0xd4, 0xd3, 0x63, 0x46, 0xd4, 0xbf, 0x30, 0x60, 0x51,
+	0x24, 0xb6, 0x2f, 0xf8, 0x71, 0x49, 0x77, 0xbd, 0x09, 0x0b, 0x43, 0xd6, 0x00, 0x74, 0x3b, 0x99,
+	0x0e, 0xa7, 0x2e, 0x10, 0xca, 0xbc, 0xe4, 0x6d, 0x30, 0xe5, 0xe4, 0x32, 0x33, 0x5a, 0x59, 0x40,
+	0x4c, 0x5b, 0x6b, 0x8b, 0x42, 0x42, 0x7a, 0xd8, 0x4f, 0xf3, 0xdf, 0xbc, 0x74, 0xd3, 0xd4, 0xc0,
+	0xd2, 0xed, 0x53, 0x58, 0x78, 0x30, 0x24, 0xf4, 0xe5, 0x95, 0x63, 0xcd, 0x8a, 0x2a, 0x21, 0x6d,
+	0x61, 0x76, 0xfa, 0x7e, 0xa8, 0x7f, 0x35, 0x4b, 0x0d, 0x1a, 0x14, 0x89, 0x97, 0x60, 0x51, 0x40,
+	0x76, 0x9f, 0x7b, 0x61, 0xfa, 0x32, 0xb3, 0x09, 0x0d, 0x1d, 0x8c, 0x7e, 0xc2, 0x5f, 0x12, 0x19,
+	0x84, 0xeb, 0x54, 0x76, 0x70, 0x65, 0x7f, 0x6d, 0x40, 0x73, 0x3f, 0x72, 0x83, 0x68, 0x87, 0x91,
+	0xd1, 0x30, 0x0e, 0x9d, 0x61, 0x57, 0x7e, 0xd3, 0x75, 0x98, 0xc7, 0x47, 0xa9, 0x8e, 0x3e, 0x75,
+	0xae, 0x21, 0x18, 0x73, 0x2b, 0xcb, 0xa1, 0x71, 0x48, 0x02, 0xc5, 0xb5, 0x92, 0x35, 0xc3, 0x31,
+	0x8b, 0x9c, 0xfa, 0x81, 0xb4, 0x6e, 0xb2, 0x66, 0x7d, 0x71, 0x97, 0x04, 0xe8, 0xd7, 0x04, 0x07,
+	0x06, 0x2a, 0xc8, 0xbe, 0x0c, 0x97, 0x72, 0xd4, 0x13, 0x1f, 0xb5, 0xe5, 0x24, 0xff, 0x83, 0xd8,
+	0x27, 0xc1, 0x33, 0x96, 0x7a, 0x3e, 0x85, 0x12, 0x42, 0x4c, 0x35, 0xd9, 0xe8, 0xff, 0x96, 0xb0,
+	0xac, 0x3c, 0x14, 0xca, 0xfc, 0x87, 0x09, 0x55, 0x61, 0x41, 0x29, 0xf3, 0x7b, 0x30, 0xd5, 0xe6,
+	0xad, 0x97, 0xc2, 0xa5, 0x3c, 0xfb, 0x59, 0x2b, 0x19, 0x78, 0x92, 0x7b, 0x4a, 0xf8, 0x7c, 0xa7,
+	0x29, 0xa3, 0xbf, 0x09, 0x5a, 0x56, 0x1e, 0x0a, 0x25, 0x38, 0x50, 0xd5, 0x9e, 0xee, 0xcc, 0xf5,
+	0xec, 0x8b, 0x9a, 0xf6, 0x1e, 0x68, 0x6d, 0x8c, 0x27, 0x40, 0x99, 0x3b, 0x50, 0xde, 0x96, 0x2f,
+	0x6e, 0x56, 0xee, 0x03, 0x9d, 0x90, 0x74, 0x79, 0xc2, 0xe3, 0x1d, 0xfb, 0x34, 0xf9, 0xb4, 0xa5,
+	0x7e, 0x9a, 0x3e, 0xcf, 0xb7, 0xac, 0x3c, 0x14, 0x4a, 0x78, 0x0c, 0xf3, 0x23, 0x13, 0x60, 0x53,
+	0x6d, 0x1b, 0xf2, 0x07, 0xe7, 0x96, 0x3d, 0x89, 0x04, 0x25, 0xdf, 0x87, 0x8a, 0xd2, 0x67, 0x9b,
+	0xab, 0xe3, 0xfa, 0x6f, 0x21, 0x71, 0x6d, 0x72, 0x7b, 0x6e, 0xb6, 0x00, 0xd2, 0xea, 0xce, 0xbc,
+	0xa2, 0xef, 0xaf, 0x57, 0x86, 0xd6, 0xea, 0x18, 0x2c, 0x8a, 0x7a, 0x00, 0x73, 0x6a, 0x35, 0x67,
+	0x8e, 0x6e, 0x3d, 0x52, 0xfd, 0x59, 0xeb, 0x63, 0xf1, 0x28, 0x30, 0x86, 0xe6, 0xb8, 0x02, 0xc8,
+	0x7c, 0x33, 0xbf, 0xde, 0xc8, 0xcb, 0x32, 0xd6, 0xcd, 0x0b, 0xd1, 0x8a, 0x4d, 0x6f, 0x19, 0xa6,
+	0x0f, 0xcb, 0xf9, 0xd9, 0xd3, 0xbc, 0x71, 0x81, 0x04, 0x2b, 0xb6, 0x7c, 0xe3, 0xc2, 0xa9, 0xf8,
+	0x96, 0x61, 0x7a, 0xe9, 0xe3, 0xb7, 0xb6, 0xdd, 0xeb, 0x39, 0xce, 0x9e, 0xb7, 0xd9, 0xf5, 0x73,
+	0xe9, 0x92, 0xad, 0x3e, 0x49, 0xc6, 0x3a, 0xcd, 0x9c, 0x26, 0x56, 0x88, 0xbb, 0x34, 0xb6, 0xbd,
+	0x15, 0xba, 0xe6, 0x95, 0xd2, 0x9a, 0xae, 0x13, 0x6a, 0x71, 0xeb, 0xfa, 0xb9, 0x74, 0xc9, 0x56,
+	0x5f, 0x42, 0x7d, 0x74, 0x96, 0x6f, 0xda, 0xe7, 0x3f, 0x3d, 0x58, 0x57, 0x27, 0xd2, 0xa4, 0xa1,
+	0x47, 0x7b, 0xcd, 0xd5, 0x42, 0x4f, 0xde, 0x0b, 0xb2, 0xb5, 0x31, 0x9e, 0x20, 0xbd, 0x99, 0xca,
+	0x7b, 0xad, 0x76, 0x33, 0xb3, 0x0f, 0xc4, 0xd6, 0xda, 0x38, 0xf4, 0x88, 0x34, 0xcc, 0x41, 0xab,
+	0x13, 0xdf, 0x63, 0xad, 0xb5, 0x71, 0x68, 0x94, 0xf6, 0x25, 0xd4, 0x47, 0x5f, 0x2a, 0x35, 0x63,
+	0x8e, 0x79, 0x5b, 0xb5, 0xae, 0x4e, 0xa4, 0x49, 0x83, 0xdd, 0xc8, 0xbb, 0x80, 0x16, 0xec, 0xf2,
+	0x1f, 0x5d, 0x2c, 0x7b, 0x12, 0x49, 0x2a, 0x79, 0x64, 0xe8, 0xac, 0x49, 0xce, 0x1f, 0x93, 0x5b,
+	0xf6, 0x24, 0x92, 0x34, 0x4f, 0xc8, 0xa1, 0xb0, 0x96, 0x27, 0x46, 0x06, 0xce, 0xd6, 0xe5, 0x5c,
+	0x1c, 0x0a, 0x71, 0xc1, 0xcc, 0xce, 0x6c, 0x4d, 0xf5, 0xef, 0x74, 0x63, 0xc7, 0xc3, 0xd6, 0xb5,
+	0x73, 0xa8, 0x52, 0x37, 0x50, 0xa6, 0x7b, 0x9a, 0x1b, 0x64, 0x67, 0x83, 0xd6, 0xda, 0x38, 0x74,
+	0x1a, 0xa3, 0xd5, 0x69, 0x9f, 0x16, 0xa3, 0x73, 0xa6, 0x85, 0xd6, 0xfa, 0x58, 0x7c, 0x2a, 0x50,
+	0x9d, 0xff, 0x69, 0x02, 0x73, 0xe6, 0x87, 0xd6, 0xfa, 0x58, 0x7c, 0x7a, 0x31, 0xb5, 0xa9, 0x8f,
+	0x76, 0x31, 0xf3, 0x86, 0x52, 0xd6, 0xc6, 0x78, 0x82, 0x54, 0x49, 0x75, 0x36, 0xa2, 0x29, 0x99,
+	0x33, 0xd4, 0xb1, 0xd6, 0xc7, 0xe2, 0xb1, 0x8a, 0xfa, 0x73, 0x51, 0x96, 0xa7, 0xf7, 0x7d, 0xb7,
+	0x47, 0x02, 0x59, 0x4b, 0x3d, 0x80, 0x39, 0xb5, 0x3c, 0xd5, 0x36, 0xca, 0x29, 0x67, 0xad, 0xf5,
+	0xb1, 0x78, 0xc5, 0xbc, 0x4a, 0x8d, 0xae, 0x9b, 0x37, 0xdb, 0x43, 0x58, 0xeb, 0x63, 0xf1, 0x69,
+	0xbe, 0x4f, 0x4b, 0x73, 0x2d, 0xdf, 0x67, 0x6a, 0x7e, 0x6b, 0x75, 0x0c, 0x36, 0xf5, 0x4c, 0xa5,
+	0x72, 0xd7, 0x3c, 0x33, 0x5b, 0xe7, 0x5b, 0x6b, 0xe3, 0xd0, 0x28, 0xed, 0xc7, 0xb0, 0x90, 0xa9,
+	0x84, 0x4d, 0x35, 0xfa, 0x8c, 0x2b, 0xe3, 0xad, 0xd7, 0x26, 0x13, 0x09, 0xf9, 0x87, 0x33, 0xfc,
+	0x6f, 0xc6, 0xdf, 0xfd, 0xdf, 0x00, 0xc8, 0xd3, 0x37, 0x0a, 0x73, 0x2c, 0x00, 0x00,
 }
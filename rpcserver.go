@@ -5,6 +5,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -17,7 +18,10 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/metrics"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
+	"github.com/btcsuite/btcwallet/rpc/macaroons"
+	"github.com/btcsuite/btcwallet/rpc/rpcauth"
 	"github.com/btcsuite/btcwallet/rpc/rpcserver"
 	"github.com/btcsuite/btcwallet/wallet"
 	"google.golang.org/grpc"
@@ -102,9 +106,117 @@ func generateRPCKeyPair(writeKey bool) (tls.Certificate, error) {
 	return keyPair, nil
 }
 
-func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Server, error) {
+// rpcUsers returns the credentials of the --rpcauth options, which are
+// validated when the config is loaded.
+func rpcUsers() []rpcauth.Credential {
+	users := make([]rpcauth.Credential, 0, len(cfg.RPCAuth))
+	for _, auth := range cfg.RPCAuth {
+		user, err := rpcauth.ParseCredential(auth)
+		if err != nil {
+			log.Errorf("Ignoring invalid rpcauth option: %v", err)
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+// rpcCredentials returns every configured RPC credential.  The username and
+// password options, if both set, are credentials of the admin role.
+func rpcCredentials() []rpcauth.Credential {
+	var creds []rpcauth.Credential
+	if cfg.Username != "" && cfg.Password != "" {
+		creds = append(creds, rpcauth.Credential{
+			Username: cfg.Username,
+			Password: cfg.Password,
+			Role:     rpcauth.RoleAdmin,
+		})
+	}
+	return append(creds, rpcUsers()...)
+}
+
+// loadMacaroons sets the macaroon service of the loaded wallet on auth, and
+// writes the default macaroons to the network directory unless they already
+// exist.
+func loadMacaroons(w *wallet.Wallet, auth *rpcserver.MacaroonAuth) {
+	service, err := macaroons.NewService(w.Database())
+	if err != nil {
+		log.Errorf("Unable to load macaroons: %v", err)
+		return
+	}
+	auth.SetService(service)
+
+	netDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
+	for name := range macaroons.DefaultMacaroons {
+		path := filepath.Join(netDir, name+".macaroon")
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			continue
+		}
+		mac, err := service.Macaroon(name)
+		if err != nil {
+			log.Errorf("Unable to read %s macaroon: %v", name, err)
+			continue
+		}
+		if err := ioutil.WriteFile(path, mac, 0600); err != nil {
+			log.Errorf("Unable to write %s macaroon: %v", name, err)
+			continue
+		}
+		log.Infof("Wrote %s macaroon to %s", name, path)
+	}
+}
+
+// chainUnaryInterceptors returns a unary interceptor calling each of the
+// interceptors in order, as a gRPC server only accepts a single one.
+func chainUnaryInterceptors(
+	interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context,
+				req interface{}) (interface{}, error) {
+
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// chainStreamInterceptors returns a stream interceptor calling each of the
+// interceptors in order, as a gRPC server only accepts a single one.
+func chainStreamInterceptors(
+	interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+
+	return func(srv interface{}, ss grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// startRPCServers creates and starts the gRPC and legacy RPC servers, and the
+// REST gateway to the gRPC server.  The WalletService of the gRPC server is
+// provided by walletService.  If walletMetrics is not nil, the requests of both
+// servers are observed by it.  If macaroonAuth is not nil, calls to the gRPC
+// server are authenticated by it.
+func startRPCServers(walletLoader *wallet.Loader,
+	walletService *rpcserver.WalletService, walletMetrics *metrics.Metrics,
+	macaroonAuth *rpcserver.MacaroonAuth) (*grpc.Server, *restGateway,
+	*legacyrpc.Server, error) {
+
 	var (
 		server       *grpc.Server
+		gateway      *restGateway
 		legacyServer *legacyrpc.Server
 		legacyListen = net.Listen
 		keyPair      tls.Certificate
@@ -115,7 +227,7 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 	} else {
 		keyPair, err = openRPCKeyPair()
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		// Change the standard net.Listen function to the tls one.
@@ -132,12 +244,48 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 			listeners := makeListeners(cfg.ExperimentalRPCListeners, net.Listen)
 			if len(listeners) == 0 {
 				err := errors.New("failed to create listeners for RPC server")
-				return nil, nil, err
+				return nil, nil, nil, err
+			}
+			var (
+				unaryInterceptors  []grpc.UnaryServerInterceptor
+				streamInterceptors []grpc.StreamServerInterceptor
+			)
+			if walletMetrics != nil {
+				unaryInterceptors = append(unaryInterceptors,
+					walletMetrics.UnaryServerInterceptor())
+				streamInterceptors = append(streamInterceptors,
+					walletMetrics.StreamServerInterceptor())
 			}
+			if cfg.ExperimentalRPCAuth {
+				auth := rpcauth.NewAuthenticator(rpcCredentials())
+				unaryInterceptors = append(unaryInterceptors,
+					rpcserver.UnaryAuthInterceptor(auth))
+				streamInterceptors = append(streamInterceptors,
+					rpcserver.StreamAuthInterceptor(auth))
+			}
+			if macaroonAuth != nil {
+				unaryInterceptors = append(unaryInterceptors,
+					macaroonAuth.UnaryInterceptor())
+				streamInterceptors = append(streamInterceptors,
+					macaroonAuth.StreamInterceptor())
+			}
+			unaryInterceptors = append(unaryInterceptors,
+				walletService.UnaryInterceptor())
+			streamInterceptors = append(streamInterceptors,
+				walletService.StreamInterceptor())
 			creds := credentials.NewServerTLSFromCert(&keyPair)
-			server = grpc.NewServer(grpc.Creds(creds))
+			server = grpc.NewServer(
+				grpc.Creds(creds),
+				grpc.UnaryInterceptor(
+					chainUnaryInterceptors(unaryInterceptors),
+				),
+				grpc.StreamInterceptor(
+					chainStreamInterceptors(streamInterceptors),
+				),
+			)
 			rpcserver.StartVersionService(server)
 			rpcserver.StartWalletLoaderService(server, walletLoader, activeNet)
+			rpcserver.StartWalletService(server, walletService)
 			for _, lis := range listeners {
 				lis := lis
 				go func() {
@@ -148,32 +296,61 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 						err)
 				}()
 			}
+
+			if len(cfg.ExperimentalRESTListeners) != 0 {
+				listeners := makeListeners(
+					cfg.ExperimentalRESTListeners, legacyListen,
+				)
+				if len(listeners) == 0 {
+					err := errors.New("failed to create " +
+						"listeners for REST gateway")
+					return nil, nil, nil, err
+				}
+				gateway, err = startRESTGateway(
+					server, keyPair, listeners,
+				)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+			}
 		}
 	}
 
-	if cfg.Username == "" || cfg.Password == "" {
-		log.Info("Legacy RPC server disabled (requires username and password)")
+	if (cfg.Username == "" || cfg.Password == "") && len(cfg.RPCAuth) == 0 {
+		log.Info("Legacy RPC server disabled (requires username and " +
+			"password or rpcauth)")
 	} else if len(cfg.LegacyRPCListeners) != 0 {
 		listeners := makeListeners(cfg.LegacyRPCListeners, legacyListen)
 		if len(listeners) == 0 {
 			err := errors.New("failed to create listeners for legacy RPC server")
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		opts := legacyrpc.Options{
 			Username:            cfg.Username,
 			Password:            cfg.Password,
+			Users:               rpcUsers(),
 			MaxPOSTClients:      cfg.LegacyRPCMaxClients,
 			MaxWebsocketClients: cfg.LegacyRPCMaxWebsockets,
 		}
+		if walletMetrics != nil {
+			opts.ObserveRequest = func(method string,
+				d time.Duration, failed bool) {
+
+				walletMetrics.ObserveRequest(
+					metrics.ServerLegacyRPC, method, d,
+					failed,
+				)
+			}
+		}
 		legacyServer = legacyrpc.NewServer(&opts, walletLoader, listeners)
 	}
 
 	// Error when neither the GRPC nor legacy RPC servers can be started.
 	if server == nil && legacyServer == nil {
-		return nil, nil, errors.New("no suitable RPC services can be started")
+		return nil, nil, nil, errors.New("no suitable RPC services can be started")
 	}
 
-	return server, legacyServer, nil
+	return server, gateway, legacyServer, nil
 }
 
 type listenFunc func(net string, laddr string) (net.Listener, error)
@@ -244,10 +421,10 @@ func makeListeners(normalizedListenAddrs []string, listen listenFunc) []net.List
 // with a wallet to enable remote wallet access.  For the GRPC server, this
 // registers the WalletService service, and for the legacy JSON-RPC server it
 // enables methods that require a loaded wallet.
-func startWalletRPCServices(wallet *wallet.Wallet, server *grpc.Server, legacyServer *legacyrpc.Server) {
-	if server != nil {
-		rpcserver.StartWalletService(server, wallet)
-	}
+func startWalletRPCServices(wallet *wallet.Wallet,
+	walletService *rpcserver.WalletService, legacyServer *legacyrpc.Server) {
+
+	walletService.SetWallet(wallet)
 	if legacyServer != nil {
 		legacyServer.RegisterWallet(wallet)
 	}
@@ -47,6 +47,15 @@ const (
 	// WitnessPubKey represents a p2wkh (pay-to-witness-key-hash) address
 	// type.
 	WitnessPubKey
+
+	// WitnessScript represents a p2wsh (pay-to-witness-script-hash)
+	// address type.
+	WitnessScript
+
+	// NestedWitnessScript represents a p2wsh output nested within a p2sh
+	// output, allowing wallets which don't recognize segwit outputs to
+	// pay to a witness script.
+	NestedWitnessScript
 )
 
 // ManagedAddress is an interface that provides acces to information regarding
@@ -502,11 +511,14 @@ func newManagedAddressFromExtKey(s *ScopedKeyManager,
 	return managedAddr, nil
 }
 
-// scriptAddress represents a pay-to-script-hash address.
+// scriptAddress represents a pay-to-script-hash address, or a
+// pay-to-witness-script-hash address which may be nested within a
+// pay-to-script-hash address.
 type scriptAddress struct {
 	manager         *ScopedKeyManager
 	account         uint32
-	address         *btcutil.AddressScriptHash
+	addrType        AddressType
+	address         btcutil.Address
 	scriptEncrypted []byte
 	scriptCT        []byte
 	scriptMutex     sync.Mutex
@@ -562,11 +574,12 @@ func (a *scriptAddress) InternalAccount() uint32 {
 //
 // This is part of the ManagedAddress interface implementation.
 func (a *scriptAddress) AddrType() AddressType {
-	return Script
+	return a.addrType
 }
 
 // Address returns the btcutil.Address which represents the managed address.
-// This will be a pay-to-script-hash address.
+// This will be a pay-to-script-hash address, or a pay-to-witness-script-hash
+// address for native witness scripts.
 //
 // This is part of the ManagedAddress interface implementation.
 func (a *scriptAddress) Address() btcutil.Address {
@@ -577,7 +590,7 @@ func (a *scriptAddress) Address() btcutil.Address {
 //
 // This is part of the ManagedAddress interface implementation.
 func (a *scriptAddress) AddrHash() []byte {
-	return a.address.Hash160()[:]
+	return a.address.ScriptAddress()
 }
 
 // Imported always returns true since script addresses are always imported
@@ -647,6 +660,44 @@ func newScriptAddress(m *ScopedKeyManager, account uint32, scriptHash,
 	return &scriptAddress{
 		manager:         m,
 		account:         account,
+		addrType:        Script,
+		address:         address,
+		scriptEncrypted: scriptEncrypted,
+	}, nil
+}
+
+// newWitnessScriptAddress initializes and returns a new
+// pay-to-witness-script-hash address, which is nested within a
+// pay-to-script-hash address if nested is set. The script hash is the SHA256
+// of the witness script for native addresses, and the hash160 of the witness
+// program for nested addresses.
+func newWitnessScriptAddress(m *ScopedKeyManager, account uint32,
+	scriptHash, scriptEncrypted []byte, nested bool) (*scriptAddress, error) {
+
+	var (
+		addrType AddressType
+		address  btcutil.Address
+		err      error
+	)
+	if nested {
+		addrType = NestedWitnessScript
+		address, err = btcutil.NewAddressScriptHashFromHash(
+			scriptHash, m.rootManager.chainParams,
+		)
+	} else {
+		addrType = WitnessScript
+		address, err = btcutil.NewAddressWitnessScriptHash(
+			scriptHash, m.rootManager.chainParams,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &scriptAddress{
+		manager:         m,
+		account:         account,
+		addrType:        addrType,
 		address:         address,
 		scriptEncrypted: scriptEncrypted,
 	}, nil
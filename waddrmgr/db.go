@@ -73,6 +73,11 @@ const (
 	adtChain  addressType = 0
 	adtImport addressType = 1 // not iota as they need to be stable for db
 	adtScript addressType = 2
+
+	// adtWitnessScript and adtNestedWitnessScript are witness script
+	// addresses, stored in the same format as script addresses.
+	adtWitnessScript       addressType = 3
+	adtNestedWitnessScript addressType = 4
 )
 
 // accountType represents a type of address stored in the database.
@@ -1528,7 +1533,7 @@ func fetchAddressByHash(ns walletdb.ReadBucket, scope *KeyScope,
 		return deserializeChainedAddress(row)
 	case adtImport:
 		return deserializeImportedAddress(row)
-	case adtScript:
+	case adtScript, adtWitnessScript, adtNestedWitnessScript:
 		return deserializeScriptAddress(row)
 	}
 
@@ -1747,6 +1752,29 @@ func putScriptAddress(ns walletdb.ReadWriteBucket, scope *KeyScope,
 	return nil
 }
 
+// putWitnessScriptAddress stores the provided witness script address
+// information to the database. The address is stored as a nested witness
+// script address if nested is set.
+func putWitnessScriptAddress(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	addressID []byte, account uint32, status syncStatus,
+	encryptedHash, encryptedScript []byte, nested bool) error {
+
+	addrType := adtWitnessScript
+	if nested {
+		addrType = adtNestedWitnessScript
+	}
+
+	rawData := serializeScriptAddress(encryptedHash, encryptedScript)
+	addrRow := dbAddressRow{
+		addrType:   addrType,
+		account:    account,
+		addTime:    uint64(time.Now().Unix()),
+		syncStatus: status,
+		rawData:    rawData,
+	}
+	return putAddress(ns, scope, addressID, &addrRow)
+}
+
 // existsAddress returns whether or not the address id exists in the database.
 func existsAddress(ns walletdb.ReadBucket, scope *KeyScope, addressID []byte) bool {
 	scopedBucket, err := fetchReadScopeBucket(ns, scope)
@@ -1985,7 +2013,7 @@ func deletePrivateKeys(ns walletdb.ReadWriteBucket) error {
 					return managerError(ErrDatabase, str, err)
 				}
 
-			case adtScript:
+			case adtScript, adtWitnessScript, adtNestedWitnessScript:
 				srow, err := deserializeScriptAddress(row)
 				if err != nil {
 					return err
@@ -2017,6 +2045,12 @@ func deletePrivateKeys(ns walletdb.ReadWriteBucket) error {
 	return nil
 }
 
+// FetchSyncedTo loads the block stamp the manager is synced to from the
+// database, without loading the manager.
+func FetchSyncedTo(ns walletdb.ReadBucket) (*BlockStamp, error) {
+	return fetchSyncedTo(ns)
+}
+
 // fetchSyncedTo loads the block stamp the manager is synced to from the
 // database.
 func fetchSyncedTo(ns walletdb.ReadBucket) (*BlockStamp, error) {
@@ -885,11 +885,13 @@ func testImportScript(tc *testContext) bool {
 	tests := []struct {
 		name       string
 		in         []byte
+		addrType   AddressType
 		blockstamp BlockStamp
 		expected   expectedAddr
 	}{
 		{
-			name: "p2sh uncompressed pubkey",
+			name:     "p2sh uncompressed pubkey",
+			addrType: Script,
 			in: hexToBytes("41048b65a0e6bb200e6dac05e74281b1ab9a41e8" +
 				"0006d6b12d8521e09981da97dd96ac72d24d1a7d" +
 				"ed9493a9fc20fdb4a714808f0b680f1f1d935277" +
@@ -904,7 +906,8 @@ func testImportScript(tc *testContext) bool {
 			},
 		},
 		{
-			name: "p2sh multisig",
+			name:     "p2sh multisig",
+			addrType: Script,
 			in: hexToBytes("524104cb9c3c222c5f7a7d3b9bd152f363a0b6d5" +
 				"4c9eb312c4d4f9af1e8551b6c421a6a4ab0e2910" +
 				"5f24de20ff463c1c91fcf3bf662cdde4783d4799" +
@@ -924,6 +927,52 @@ func testImportScript(tc *testContext) bool {
 				// script is set to the in field during tests.
 			},
 		},
+		{
+			name:     "p2wsh multisig",
+			addrType: WitnessScript,
+			in: hexToBytes("524104cb9c3c222c5f7a7d3b9bd152f363a0b6d5" +
+				"4c9eb312c4d4f9af1e8551b6c421a6a4ab0e2910" +
+				"5f24de20ff463c1c91fcf3bf662cdde4783d4799" +
+				"f787cb7c08869b4104ccc588420deeebea22a7e9" +
+				"00cc8b68620d2212c374604e3487ca08f1ff3ae1" +
+				"2bdc639514d0ec8612a2d3c519f084d9a00cbbe3" +
+				"b53d071e9b09e71e610b036aa24104ab47ad1939" +
+				"edcb3db65f7fedea62bbf781c5410d3f22a7a3a5" +
+				"6ffefb2238af8627363bdf2ed97c1f89784a1aec" +
+				"db43384f11d2acc64443c7fc299cef0400421a53ae"),
+			expected: expectedAddr{
+				address: "bc1qxxh2nnzpfjssw8c22axmca2nveuulhye03nlk" +
+					"yakasxlm90er87s33xzl5",
+				addressHash: hexToBytes("31aea9cc414ca1071f0a574dbc7553" +
+					"6679cfdc997c67fb13b6ec0dfd95f919fd"),
+				internal:   false,
+				imported:   true,
+				compressed: false,
+				// script is set to the in field during tests.
+			},
+		},
+		{
+			name:     "p2sh-p2wsh multisig",
+			addrType: NestedWitnessScript,
+			in: hexToBytes("524104cb9c3c222c5f7a7d3b9bd152f363a0b6d5" +
+				"4c9eb312c4d4f9af1e8551b6c421a6a4ab0e2910" +
+				"5f24de20ff463c1c91fcf3bf662cdde4783d4799" +
+				"f787cb7c08869b4104ccc588420deeebea22a7e9" +
+				"00cc8b68620d2212c374604e3487ca08f1ff3ae1" +
+				"2bdc639514d0ec8612a2d3c519f084d9a00cbbe3" +
+				"b53d071e9b09e71e610b036aa24104ab47ad1939" +
+				"edcb3db65f7fedea62bbf781c5410d3f22a7a3a5" +
+				"6ffefb2238af8627363bdf2ed97c1f89784a1aec" +
+				"db43384f11d2acc64443c7fc299cef0400421a53ae"),
+			expected: expectedAddr{
+				address:     "3PCoUiQPrt9sihEfs2B3QwBMfjTc5DYLpM",
+				addressHash: hexToBytes("ebfcbb41fa0a7bad83861a550db2a6c583d8d997"),
+				internal:    false,
+				imported:    true,
+				compressed:  false,
+				// script is set to the in field during tests.
+			},
+		},
 	}
 
 	// The manager must be unlocked to import a private key and also for
@@ -955,7 +1004,16 @@ func testImportScript(tc *testContext) bool {
 			err := walletdb.Update(tc.db, func(tx walletdb.ReadWriteTx) error {
 				ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
 				var err error
-				addr, err = tc.manager.ImportScript(ns, test.in, &test.blockstamp)
+				if test.addrType == Script {
+					addr, err = tc.manager.ImportScript(
+						ns, test.in, &test.blockstamp,
+					)
+				} else {
+					addr, err = tc.manager.ImportWitnessScript(
+						ns, test.in, &test.blockstamp,
+						test.addrType == NestedWitnessScript,
+					)
+				}
 				return err
 			})
 			if err != nil {
@@ -963,6 +1021,12 @@ func testImportScript(tc *testContext) bool {
 					err)
 				continue
 			}
+			if addr.AddrType() != test.addrType {
+				tc.t.Errorf("%s: unexpected address type - got "+
+					"%v, want %v", prefix, addr.AddrType(),
+					test.addrType)
+				continue
+			}
 			if !testAddress(tc, prefix, addr, &test.expected) {
 				continue
 			}
@@ -979,10 +1043,11 @@ func testImportScript(tc *testContext) bool {
 
 			// Use the Address API to retrieve each of the expected
 			// new addresses and ensure they're accurate.
-			utilAddr, err := btcutil.NewAddressScriptHash(test.in,
-				chainParams)
+			utilAddr, err := btcutil.DecodeAddress(
+				test.expected.address, chainParams,
+			)
 			if err != nil {
-				tc.t.Errorf("%s NewAddressScriptHash #%d (%s): "+
+				tc.t.Errorf("%s DecodeAddress #%d (%s): "+
 					"unexpected error: %v", prefix, i,
 					test.name, err)
 				failed = true
@@ -1003,6 +1068,13 @@ func testImportScript(tc *testContext) bool {
 				failed = true
 				continue
 			}
+			if ma.AddrType() != test.addrType {
+				tc.t.Errorf("%s: unexpected address type - got "+
+					"%v, want %v", taPrefix, ma.AddrType(),
+					test.addrType)
+				failed = true
+				continue
+			}
 			if !testAddress(tc, taPrefix, ma, &test.expected) {
 				failed = true
 				continue
@@ -2859,3 +2931,80 @@ func TestDeriveFromKeyPathCache(t *testing.T) {
 	require.Equal(t, cachedKey.Serialize(), cachedKey2.Serialize())
 	require.Equal(t, derivedKey.Serialize(), cachedKey2.Serialize())
 }
+
+// TestImportAccountWitnessScript tests that witness scripts imported into an
+// account belong to it, and can't be imported into unknown accounts.
+func TestImportAccountWitnessScript(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	script := []byte{0x51} // OP_TRUE
+	bs := &BlockStamp{Height: 0, Hash: *chaincfg.MainNetParams.GenesisHash}
+	var account uint32
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		if err := mgr.Unlock(ns, privPassphrase); err != nil {
+			return err
+		}
+		account, err = scopedMgr.NewAccount(ns, "scripts")
+		if err != nil {
+			return err
+		}
+
+		_, err := scopedMgr.ImportAccountWitnessScript(
+			ns, account+1, script, bs,
+		)
+		if !IsError(err, ErrAccountNotFound) {
+			return fmt.Errorf("expected ErrAccountNotFound, got %v",
+				err)
+		}
+
+		addr, err := scopedMgr.ImportAccountWitnessScript(
+			ns, account, script, bs,
+		)
+		if err != nil {
+			return err
+		}
+		_, addrAccount, err := mgr.AddrAccount(ns, addr.Address())
+		if err != nil {
+			return err
+		}
+		if addrAccount != account {
+			return fmt.Errorf("got account %d, want %d",
+				addrAccount, account)
+		}
+		if addr.AddrType() != WitnessScript {
+			return fmt.Errorf("got address type %v, want %v",
+				addr.AddrType(), WitnessScript)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The address is stored with its account.
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		var found bool
+		err := scopedMgr.ForEachAccountAddress(ns, account,
+			func(maddr ManagedAddress) error {
+				if maddr.AddrType() == WitnessScript {
+					found = true
+				}
+				return nil
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("script address not found in account")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
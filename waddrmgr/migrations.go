@@ -14,6 +14,10 @@ import (
 // reflect the latest database state. If the database happens to be at a version
 // number lower than the latest, migrations will be performed in order to catch
 // it up.
+//
+// The database can be rolled back as far as version 5.  The migrations to
+// versions 2 and 5 restructure the database into key scopes and can't be
+// reverted.
 var versions = []migration.Version{
 	{
 		Number:    2,
@@ -26,14 +30,22 @@ var versions = []migration.Version{
 	{
 		Number:    6,
 		Migration: populateBirthdayBlock,
+		Rollback:  rollbackBirthdayBlock,
 	},
 	{
 		Number:    7,
 		Migration: resetSyncedBlockToBirthday,
+		Rollback:  rollbackUnchangedSchema,
 	},
 	{
 		Number:    8,
 		Migration: storeMaxReorgDepth,
+		Rollback:  rollbackUnchangedSchema,
+	},
+	{
+		Number:    9,
+		Migration: nil,
+		Rollback:  rollbackWitnessScriptAddresses,
 	},
 }
 
@@ -364,6 +376,33 @@ func populateBirthdayBlock(ns walletdb.ReadWriteBucket) error {
 	})
 }
 
+// rollbackBirthdayBlock reverts populateBirthdayBlock by removing the birthday
+// block, and whether it has been verified, from the sync bucket.
+func rollbackBirthdayBlock(ns walletdb.ReadWriteBucket) error {
+	syncBucket := ns.NestedReadWriteBucket(syncBucketName)
+	if syncBucket == nil {
+		return errors.New("sync bucket does not exist")
+	}
+
+	if err := syncBucket.Delete(birthdayBlockName); err != nil {
+		str := "failed to remove birthday block"
+		return managerError(ErrDatabase, str, err)
+	}
+	if err := syncBucket.Delete(birthdayBlockVerifiedName); err != nil {
+		str := "failed to remove birthday block verification"
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// rollbackUnchangedSchema rolls back a migration which only changes data that
+// previous versions read the same way, so nothing needs to be reverted.
+// resetSyncedBlockToBirthday only forces a rescan, and storeMaxReorgDepth only
+// removes block hashes too old to be needed to recover from a reorg.
+func rollbackUnchangedSchema(walletdb.ReadWriteBucket) error {
+	return nil
+}
+
 // resetSyncedBlockToBirthday is a migration that resets the wallet's currently
 // synced block to its birthday block. This essentially serves as a migration to
 // force a rescan of the wallet.
@@ -414,3 +453,31 @@ func storeMaxReorgDepth(ns walletdb.ReadWriteBucket) error {
 
 	return nil
 }
+
+// rollbackWitnessScriptAddresses reverts version 9, which added the witness
+// script address types. Previous versions fail to load any address of these
+// types, so the rollback is refused while the wallet contains one rather than
+// removing imported scripts that may control funds.
+func rollbackWitnessScriptAddresses(ns walletdb.ReadWriteBucket) error {
+	return forEachKeyScope(ns, func(scope KeyScope) error {
+		scopeBucket, err := fetchReadScopeBucket(ns, &scope)
+		if err != nil {
+			return err
+		}
+		addrs := scopeBucket.NestedReadBucket(addrBucketName)
+		return addrs.ForEach(func(_, v []byte) error {
+			row, err := deserializeAddressRow(v)
+			if err != nil {
+				return err
+			}
+			switch row.addrType {
+			case adtWitnessScript, adtNestedWitnessScript:
+				str := fmt.Sprintf("witness script address "+
+					"exists in scope %v, can't roll back "+
+					"to version 8", scope)
+				return managerError(ErrDatabase, str, nil)
+			}
+			return nil
+		})
+	})
+}
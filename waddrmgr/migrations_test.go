@@ -11,6 +11,7 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/walletdb/migration"
 )
 
 // applyMigration is a helper function that allows us to assert the state of the
@@ -450,3 +451,98 @@ func TestMigrationStoreMaxReorgDepth(t *testing.T) {
 		}
 	}
 }
+
+// TestRollbackWitnessScriptAddresses ensures that rolling back the version
+// adding witness script addresses is refused while the wallet contains one.
+func TestRollbackWitnessScriptAddresses(t *testing.T) {
+	t.Parallel()
+
+	noop := func(walletdb.ReadWriteBucket) error { return nil }
+
+	// Without witness script addresses, the rollback succeeds.
+	applyMigration(t, noop, noop, rollbackWitnessScriptAddresses, false)
+
+	for _, nested := range []bool{false, true} {
+		beforeMigration := func(ns walletdb.ReadWriteBucket) error {
+			return putWitnessScriptAddress(
+				ns, &KeyScopeBIP0084, []byte("script"),
+				ImportedAddrAccount, ssNone, []byte{1},
+				[]byte{2}, nested,
+			)
+		}
+
+		// The address must still exist after the failed rollback.
+		afterMigration := func(ns walletdb.ReadWriteBucket) error {
+			_, err := fetchAddress(
+				ns, &KeyScopeBIP0084, []byte("script"),
+			)
+			return err
+		}
+
+		applyMigration(
+			t, beforeMigration, afterMigration,
+			rollbackWitnessScriptAddresses, true,
+		)
+	}
+}
+
+// TestMigrationRollback ensures that the database can be rolled back to the
+// versions before the birthday block was stored, but not to the versions
+// before key scopes.
+func TestMigrationRollback(t *testing.T) {
+	t.Parallel()
+
+	beforeMigration := func(ns walletdb.ReadWriteBucket) error {
+		err := PutBirthdayBlock(ns, BlockStamp{Height: 1})
+		if err != nil {
+			return err
+		}
+		return putBirthdayBlockVerification(ns, true)
+	}
+
+	// Rolling back to version 5 removes the birthday block.
+	afterMigration := func(ns walletdb.ReadWriteBucket) error {
+		version, err := fetchManagerVersion(ns)
+		if err != nil {
+			return err
+		}
+		if version != 5 {
+			return fmt.Errorf("expected version 5, got %d", version)
+		}
+		_, err = FetchBirthdayBlock(ns)
+		if !IsError(err, ErrBirthdayBlockNotSet) {
+			return fmt.Errorf("expected ErrBirthdayBlockNotSet, "+
+				"got %v", err)
+		}
+		if fetchBirthdayBlockVerification(ns) {
+			return errors.New("birthday block still verified")
+		}
+		return nil
+	}
+	rollback := func(ns walletdb.ReadWriteBucket) error {
+		return migration.Rollback(NewMigrationManager(ns), 5)
+	}
+	applyMigration(t, beforeMigration, afterMigration, rollback, false)
+
+	// The database is left unchanged when rolling back further fails.
+	noRollback := func(ns walletdb.ReadWriteBucket) error {
+		err := migration.Rollback(NewMigrationManager(ns), 4)
+		if err != migration.ErrNoRollback {
+			t.Errorf("expected ErrNoRollback, got %v", err)
+		}
+		return err
+	}
+	unchanged := func(ns walletdb.ReadWriteBucket) error {
+		version, err := fetchManagerVersion(ns)
+		if err != nil {
+			return err
+		}
+		if version != getLatestVersion() {
+			return fmt.Errorf("expected version %d, got %d",
+				getLatestVersion(), version)
+		}
+		_, err = FetchBirthdayBlock(ns)
+		return err
+	}
+	applyMigration(t, beforeMigration, unchanged, noRollback, true)
+}
@@ -1,6 +1,7 @@
 package waddrmgr
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 	"sync"
@@ -164,6 +165,22 @@ var (
 		Coin:    0,
 	}
 
+	// KeyScopePolicy is the key scope of the wallet's keys in accounts
+	// defined by a spending policy, such as a miniscript policy.  Each
+	// account of the scope holds the wallet's key of one policy, while the
+	// addresses of the account pay to the policy's witness scripts.  This
+	// keeps the keys of scripts shared with other signers apart from
+	// single key accounts.  The purpose is non-standard: BIP0048 keys are
+	// derived with an additional script type level after the account,
+	// which the scopes of the manager don't have, so these keys must not
+	// be mistaken for BIP0048 keys.  Like the other scopes, the coin type
+	// is 0 on every network.  It isn't a default key scope, and is
+	// created when the first policy account is.
+	KeyScopePolicy = KeyScope{
+		Purpose: 1048,
+		Coin:    0,
+	}
+
 	// DefaultKeyScopes is the set of default key scopes that will be
 	// created by the root manager upon initial creation.
 	DefaultKeyScopes = []KeyScope{
@@ -797,6 +814,14 @@ func (s *ScopedKeyManager) scriptAddressRowToManaged(row *dbScriptAddressRow) (M
 		return nil, managerError(ErrCrypto, str, err)
 	}
 
+	switch row.addrType {
+	case adtWitnessScript, adtNestedWitnessScript:
+		return newWitnessScriptAddress(
+			s, row.account, scriptHash, row.encryptedScript,
+			row.addrType == adtNestedWitnessScript,
+		)
+	}
+
 	return newScriptAddress(s, row.account, scriptHash, row.encryptedScript)
 }
 
@@ -2029,6 +2054,63 @@ func (s *ScopedKeyManager) toImportedPublicManagedAddress(
 func (s *ScopedKeyManager) ImportScript(ns walletdb.ReadWriteBucket,
 	script []byte, bs *BlockStamp) (ManagedScriptAddress, error) {
 
+	return s.importScript(ns, ImportedAddrAccount, script, bs, Script)
+}
+
+// ImportWitnessScript imports a user-provided witness script into the address
+// manager.  The imported script will act as a pay-to-witness-script-hash
+// address, which is nested within a pay-to-script-hash address if nested is
+// set.
+//
+// All imported witness script addresses will be part of the account defined
+// by the ImportedAddrAccount constant.
+//
+// When the address manager is watching-only, the script itself will not be
+// stored or available since it is considered private data.
+//
+// This function will return an error if the address manager is locked and not
+// watching-only, or the address already exists.  Any other errors returned are
+// generally unexpected.
+func (s *ScopedKeyManager) ImportWitnessScript(ns walletdb.ReadWriteBucket,
+	script []byte, bs *BlockStamp, nested bool) (ManagedScriptAddress,
+	error) {
+
+	addrType := WitnessScript
+	if nested {
+		addrType = NestedWitnessScript
+	}
+	return s.importScript(ns, ImportedAddrAccount, script, bs, addrType)
+}
+
+// ImportAccountWitnessScript imports a witness script into an account of the
+// address manager as a pay-to-witness-script-hash address.  This allows
+// accounts whose addresses pay to scripts derived from their keys, such as
+// the accounts of the KeyScopePolicy scope, to track the outputs paying to
+// them.
+//
+// This function will return an error if the address manager is locked and not
+// watching-only, the account doesn't exist, or the address already exists.
+// Any other errors returned are generally unexpected.
+func (s *ScopedKeyManager) ImportAccountWitnessScript(
+	ns walletdb.ReadWriteBucket, account uint32, script []byte,
+	bs *BlockStamp) (ManagedScriptAddress, error) {
+
+	if account == ImportedAddrAccount {
+		return s.ImportWitnessScript(ns, script, bs, false)
+	}
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return nil, maybeConvertDbError(err)
+	}
+	return s.importScript(ns, account, script, bs, WitnessScript)
+}
+
+// importScript imports a script into an account as a script address of the
+// given type, which must be one of Script, WitnessScript or
+// NestedWitnessScript.
+func (s *ScopedKeyManager) importScript(ns walletdb.ReadWriteBucket,
+	account uint32, script []byte, bs *BlockStamp, addrType AddressType) (
+	ManagedScriptAddress, error) {
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
@@ -2037,8 +2119,35 @@ func (s *ScopedKeyManager) ImportScript(ns walletdb.ReadWriteBucket,
 		return nil, managerError(ErrLocked, errLocked, nil)
 	}
 
+	// The script hash identifying the address is the hash160 of the
+	// script for P2SH addresses, the SHA256 of the witness script for
+	// P2WSH addresses, and the hash160 of the P2WSH witness program for
+	// nested P2WSH addresses.
+	var scriptHash []byte
+	switch addrType {
+	case Script:
+		scriptHash = btcutil.Hash160(script)
+
+	case WitnessScript:
+		witnessHash := sha256.Sum256(script)
+		scriptHash = witnessHash[:]
+
+	case NestedWitnessScript:
+		witnessHash := sha256.Sum256(script)
+		witnessProgram, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).AddData(witnessHash[:]).Script()
+		if err != nil {
+			return nil, err
+		}
+		scriptHash = btcutil.Hash160(witnessProgram)
+
+	default:
+		str := fmt.Sprintf("address type %d is not a script type",
+			addrType)
+		return nil, managerError(ErrInvalidKeyType, str, nil)
+	}
+
 	// Prevent duplicates.
-	scriptHash := btcutil.Hash160(script)
 	alreadyExists := s.existsAddress(ns, scriptHash)
 	if alreadyExists {
 		str := fmt.Sprintf("address for script hash %x already exists",
@@ -2080,10 +2189,18 @@ func (s *ScopedKeyManager) ImportScript(ns walletdb.ReadWriteBucket,
 
 	// Save the new imported address to the db and update start block (if
 	// needed) in a single transaction.
-	err = putScriptAddress(
-		ns, &s.scope, scriptHash, ImportedAddrAccount, ssNone,
-		encryptedHash, encryptedScript,
-	)
+	if addrType == Script {
+		err = putScriptAddress(
+			ns, &s.scope, scriptHash, account, ssNone,
+			encryptedHash, encryptedScript,
+		)
+	} else {
+		err = putWitnessScriptAddress(
+			ns, &s.scope, scriptHash, account, ssNone,
+			encryptedHash, encryptedScript,
+			addrType == NestedWitnessScript,
+		)
+	}
 	if err != nil {
 		return nil, maybeConvertDbError(err)
 	}
@@ -2107,9 +2224,17 @@ func (s *ScopedKeyManager) ImportScript(ns walletdb.ReadWriteBucket,
 	// when not a watching-only address manager, make a copy of the script
 	// since it will be cleared on lock and the script the caller passed
 	// should not be cleared out from under the caller.
-	scriptAddr, err := newScriptAddress(
-		s, ImportedAddrAccount, scriptHash, encryptedScript,
-	)
+	var scriptAddr *scriptAddress
+	if addrType == Script {
+		scriptAddr, err = newScriptAddress(
+			s, account, scriptHash, encryptedScript,
+		)
+	} else {
+		scriptAddr, err = newWitnessScriptAddress(
+			s, account, scriptHash, encryptedScript,
+			addrType == NestedWitnessScript,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -212,11 +212,19 @@ func (w *Wallet) connectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta) err
 		return err
 	}
 
+	// Invoices may have expired or lost payments to double spends since
+	// the last block. Their status is not updated while catching up with
+	// the chain, since expiry depends on the current time.
+	if w.ChainSynced() {
+		if err := w.updateInvoices(dbtx); err != nil {
+			return err
+		}
+	}
+
 	// Notify interested clients of the connected block.
 	//
 	// TODO: move all notifications outside of the database transaction.
-	w.NtfnServer.notifyAttachedBlock(dbtx, &b)
-	return nil
+	return w.NtfnServer.notifyAttachedBlock(dbtx, &b)
 }
 
 // disconnectBlock handles a chain server reorganize by rolling back all
@@ -259,10 +267,19 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 				return err
 			}
 
+			// Invoices paid in the rolled back blocks are
+			// reopened, as their payments may be double spent.
+			invoices, err := w.minedInvoices(dbtx, b.Height)
+			if err != nil {
+				return err
+			}
 			err = w.TxStore.Rollback(txmgrNs, b.Height)
 			if err != nil {
 				return err
 			}
+			if err := w.reopenInvoices(dbtx, invoices); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -329,6 +346,11 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 		}
 	}
 
+	// Record payments to the addresses of invoices.
+	if err := w.addInvoicePayments(dbtx, rec); err != nil {
+		return err
+	}
+
 	// Send notification of mined or unmined transaction to any interested
 	// clients.
 	//
@@ -347,7 +369,12 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 		// receiving an additional unconfirmed chain.RelevantTx
 		// notification from the chain backend.
 		if details != nil {
-			w.NtfnServer.notifyUnminedTransaction(dbtx, details)
+			err := w.NtfnServer.notifyUnminedTransaction(
+				dbtx, details,
+			)
+			if err != nil {
+				return err
+			}
 		}
 	} else {
 		details, err := w.TxStore.UniqueTxDetails(txmgrNs, &rec.Hash, &block.Block)
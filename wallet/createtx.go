@@ -58,6 +58,7 @@ func makeInputSource(eligible []wtxmgr.Credit) txauthor.InputSource {
 type secretSource struct {
 	*waddrmgr.Manager
 	addrmgrNs walletdb.ReadBucket
+	dbtx      walletdb.ReadTx
 }
 
 func (s secretSource) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
@@ -94,6 +95,55 @@ func (s secretSource) GetScript(addr btcutil.Address) ([]byte, error) {
 	return msa.Script()
 }
 
+// SignWitnessScript returns the witness spending a P2WSH output which pays to
+// a witness script with the script's template or account policy.
+//
+// This is part of the txauthor.WitnessScriptSigner interface.
+func (s secretSource) SignWitnessScript(tx *wire.MsgTx,
+	hashCache *txscript.TxSigHashes, idx int, pkScript []byte,
+	inputValue int64) (wire.TxWitness, error) {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, s.ChainParams(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 1 {
+		return nil, fmt.Errorf("unexpected P2WSH script %x", pkScript)
+	}
+	witnessScript, err := s.GetScript(addrs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	spender, err := fetchWitnessSpender(s.dbtx, addrs[0].ScriptAddress())
+	if err != nil {
+		return nil, err
+	}
+	if spender == nil {
+		return nil, fmt.Errorf("witness script of %v has no template "+
+			"or policy", addrs[0])
+	}
+
+	// Keys that aren't held by the wallet are left to the other signers of
+	// the script.
+	getKey := func(addr *btcutil.AddressPubKeyHash) (*btcec.PrivateKey,
+		error) {
+
+		privKey, _, err := s.GetKey(addr)
+		if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+			return nil, nil
+		}
+		return privKey, err
+	}
+	return spender.sign(
+		tx, hashCache, idx, inputValue, witnessScript,
+		txscript.SigHashAll, tx.TxIn[idx].Witness, s.ChainParams(),
+		getKey,
+	)
+}
+
 // txToOutputs creates a signed transaction which includes each output from
 // outputs. Previous outputs to redeem are chosen from the passed account's
 // UTXO set and minconf policy. An additional output may be added to return
@@ -173,12 +223,39 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 			inputSource = makeInputSource(positivelyYielding)
 		}
 
-		tx, err = txauthor.NewUnsignedTransaction(
+		// The witness spending an output paying to a witness script
+		// depends on the script's template or account policy, which
+		// may also require a sequence or lock time.
+		witnessSizes := func(pkScript []byte) (int, bool) {
+			spender, err := fetchWitnessSpender(dbtx, pkScript[2:])
+			if err != nil || spender == nil {
+				return 0, false
+			}
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				pkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) != 1 {
+				return 0, false
+			}
+			witnessScript, err := secretSource{
+				w.Manager, addrmgrNs, dbtx,
+			}.GetScript(addrs[0])
+			if err != nil {
+				return 0, false
+			}
+			return spender.maxWitnessSize(witnessScript), true
+		}
+		tx, err = txauthor.NewUnsignedTransactionWithWitnessSizes(
 			outputs, feeSatPerKb, inputSource, changeSource,
+			witnessSizes,
 		)
 		if err != nil {
 			return err
 		}
+		err = w.applyWitnessTimelocks(dbtx, tx, eligible, bs)
+		if err != nil {
+			return err
+		}
 
 		// Randomize change position, if change exists, before signing.
 		// This doesn't affect the serialize size, so the change amount
@@ -218,9 +295,9 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 			return err
 		}
 		if !watchOnly {
-			err = tx.AddAllInputScripts(
-				secretSource{w.Manager, addrmgrNs},
-			)
+			err = tx.AddAllInputScripts(secretSource{
+				w.Manager, addrmgrNs, dbtx,
+			})
 			if err != nil {
 				return err
 			}
@@ -326,11 +403,67 @@ func (w *Wallet) findEligibleOutputs(dbtx walletdb.ReadTx,
 		if addrAcct != account {
 			continue
 		}
+
+		// Outputs paying to witness scripts can only be spent with a
+		// template or account policy, once the sequence and lock time
+		// it requires are reached.
+		if txscript.IsPayToWitnessScriptHash(output.PkScript) {
+			spender, err := fetchWitnessSpender(
+				dbtx, addrs[0].ScriptAddress(),
+			)
+			if err != nil {
+				return nil, err
+			}
+			if spender == nil {
+				continue
+			}
+			if _, _, ok := spender.timelocks(output, bs); !ok {
+				continue
+			}
+		}
+
 		eligible = append(eligible, *output)
 	}
 	return eligible, nil
 }
 
+// applyWitnessTimelocks sets the version, lock time and input sequences of a
+// transaction spending eligible outputs, as required by the spenders of the
+// witness scripts they pay to.
+func (w *Wallet) applyWitnessTimelocks(dbtx walletdb.ReadTx,
+	tx *txauthor.AuthoredTx, eligible []wtxmgr.Credit,
+	bs *waddrmgr.BlockStamp) error {
+
+	credits := make(map[wire.OutPoint]*wtxmgr.Credit, len(eligible))
+	for i := range eligible {
+		credits[eligible[i].OutPoint] = &eligible[i]
+	}
+
+	for i, pkScript := range tx.PrevScripts {
+		if !txscript.IsPayToWitnessScriptHash(pkScript) {
+			continue
+		}
+		credit, ok := credits[tx.Tx.TxIn[i].PreviousOutPoint]
+		if !ok {
+			continue
+		}
+		spender, err := fetchWitnessSpender(dbtx, pkScript[2:])
+		if err != nil {
+			return err
+		}
+		if spender == nil {
+			continue
+		}
+		sequence, lockTime, ok := spender.timelocks(credit, bs)
+		if !ok {
+			return fmt.Errorf("output %v can't be spent yet",
+				credit.OutPoint)
+		}
+		applyTimelocks(tx.Tx, i, sequence, lockTime)
+	}
+	return nil
+}
+
 // inputYieldsPositively returns a boolean indicating whether this input yields
 // positively if added to a transaction. This determination is based on the
 // best-case added virtual size. For edge cases this function can return true
@@ -384,6 +517,12 @@ func (w *Wallet) addrMgrWithChangeSource(dbtx walletdb.ReadWriteTx,
 		scriptSize = txsizes.P2WPKHPkScriptSize
 	}
 
+	// The change of policy accounts pays to the witness script of the
+	// account's policy.
+	if *changeKeyScope == waddrmgr.KeyScopePolicy {
+		scriptSize = txsizes.P2WSHPkScriptSize
+	}
+
 	newChangeScript := func() ([]byte, error) {
 		// Derive the change output script. As a hack to allow spending
 		// from the imported account, change addresses are created from
@@ -0,0 +1,143 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cryptdb
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// benchmarkTxs is the number of transactions of the benchmarked wallets.
+const benchmarkTxs = 2000
+
+var benchmarkNamespaceKey = []byte("wtxmgr")
+
+// benchmarkStore returns a transaction store of a database of the driver with
+// benchmarkTxs mined transactions, each paying the wallet one output, and the
+// hashes of the transactions.
+func benchmarkStore(b *testing.B, dbType string) (walletdb.DB, *wtxmgr.Store,
+	[]chainhash.Hash) {
+
+	b.Helper()
+
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		b.Fatalf("unable to create db: %v", err)
+	}
+
+	var (
+		store  *wtxmgr.Store
+		hashes []chainhash.Hash
+	)
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(benchmarkNamespaceKey)
+		if err != nil {
+			return err
+		}
+		if err := wtxmgr.Create(ns); err != nil {
+			return err
+		}
+		store, err = wtxmgr.Open(ns, &chaincfg.MainNetParams)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < benchmarkTxs; i++ {
+			var prevHash chainhash.Hash
+			binary.BigEndian.PutUint32(prevHash[:], uint32(i))
+			msgTx := wire.NewMsgTx(2)
+			msgTx.AddTxIn(&wire.TxIn{
+				PreviousOutPoint: wire.OutPoint{Hash: prevHash},
+			})
+			msgTx.AddTxOut(wire.NewTxOut(1e6, []byte{0x51}))
+			rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+			if err != nil {
+				return err
+			}
+
+			var blockHash chainhash.Hash
+			binary.BigEndian.PutUint32(blockHash[:], uint32(i))
+			block := &wtxmgr.BlockMeta{
+				Block: wtxmgr.Block{
+					Hash:   blockHash,
+					Height: int32(i + 1),
+				},
+				Time: time.Now(),
+			}
+			if err := store.InsertTx(ns, rec, block); err != nil {
+				return err
+			}
+			err = store.AddCredit(ns, rec, block, 0, false)
+			if err != nil {
+				return err
+			}
+			hashes = append(hashes, rec.Hash)
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatalf("unable to populate store: %v", err)
+	}
+	return db, store, hashes
+}
+
+// BenchmarkTxDetails benchmarks looking up the details of the transactions of
+// a wallet with many transactions, with and without encryption.
+func BenchmarkTxDetails(b *testing.B) {
+	for _, dbType := range []string{"memdb", testDBType} {
+		b.Run(dbType, func(b *testing.B) {
+			db, store, hashes := benchmarkStore(b, dbType)
+			defer db.Close()
+
+			b.ResetTimer()
+			err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+				ns := tx.ReadBucket(benchmarkNamespaceKey)
+				for i := 0; i < b.N; i++ {
+					hash := &hashes[i%len(hashes)]
+					_, err := store.TxDetails(ns, hash)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
+// BenchmarkRangeTransactions benchmarks listing every transaction of a wallet
+// with many transactions, with and without encryption.
+func BenchmarkRangeTransactions(b *testing.B) {
+	for _, dbType := range []string{"memdb", testDBType} {
+		b.Run(dbType, func(b *testing.B) {
+			db, store, _ := benchmarkStore(b, dbType)
+			defer db.Close()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+					ns := tx.ReadBucket(benchmarkNamespaceKey)
+					return store.RangeTransactions(ns, 0, -1,
+						func([]wtxmgr.TxDetails) (bool, error) {
+							return false, nil
+						})
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
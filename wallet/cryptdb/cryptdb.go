@@ -0,0 +1,409 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cryptdb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+var (
+	// metaBucketKey is the top-level bucket of the underlying database
+	// holding the encryption parameters, in cleartext.  Encrypted keys
+	// are longer than it, so it never collides with an encrypted bucket.
+	metaBucketKey = []byte("cryptdb")
+
+	// paramsKey holds the scrypt parameters of the passphrase key.
+	paramsKey = []byte("params")
+
+	// masterKeyKey holds the master key encrypted with the passphrase key.
+	masterKeyKey = []byte("masterkey")
+)
+
+var (
+	// ErrNotEncrypted is returned when opening a database which isn't
+	// encrypted, or changing the passphrase with a transaction which isn't
+	// of an encrypted database.
+	ErrNotEncrypted = errors.New("database is not encrypted")
+
+	// ErrEncrypted is returned when encrypting a database which is
+	// already encrypted.
+	ErrEncrypted = errors.New("database is already encrypted")
+
+	// ErrNotEmpty is returned when encrypting a database which already
+	// has buckets, which must be copied with EncryptCopy instead.
+	ErrNotEmpty = errors.New("database is not empty")
+
+	// ErrMisplaced is returned when an encrypted key or value is read from
+	// another bucket or key than the one it was written to.
+	ErrMisplaced = errors.New("encrypted data was moved from its location")
+)
+
+// Domains of the MACs of the locations of keys and values.
+const (
+	keyDomain   = 0
+	valueDomain = 1
+)
+
+// valueTagSize is the size of the tag binding a value to its location, which
+// is encrypted along with the value.
+const valueTagSize = 16
+
+// keys are the keys encrypting the database, derived from the master key.
+type keys struct {
+	// master is the random master key, encrypted in the database with the
+	// passphrase key so the passphrase can change.
+	master snacl.CryptoKey
+
+	// crypt encrypts the keys and values.
+	crypt snacl.CryptoKey
+
+	// nonce is the HMAC key of the locations of keys and values, which
+	// derive the nonces of encrypted keys and tag encrypted values.
+	nonce [sha256.Size]byte
+}
+
+// newKeys derives the encryption keys from a master key.
+func newKeys(master *snacl.CryptoKey) *keys {
+	k := &keys{master: *master}
+	mac := hmac.New(sha256.New, master[:])
+	mac.Write([]byte("cryptdb crypt"))
+	copy(k.crypt[:], mac.Sum(nil))
+	mac = hmac.New(sha256.New, master[:])
+	mac.Write([]byte("cryptdb nonce"))
+	copy(k.nonce[:], mac.Sum(nil))
+	return k
+}
+
+// zero clears the keys.
+func (k *keys) zero() {
+	k.master.Zero()
+	k.crypt.Zero()
+	for i := range k.nonce {
+		k.nonce[i] = 0
+	}
+}
+
+// appendPath returns the path of the nested bucket with the name in the bucket
+// with the path.  Names are length prefixed so that paths are unambiguous.
+func appendPath(path, name []byte) []byte {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(name)))
+	p := make([]byte, 0, len(path)+n+len(name))
+	p = append(p, path...)
+	p = append(p, size[:n]...)
+	return append(p, name...)
+}
+
+// location returns the MAC of a key in the bucket with the path, for the
+// domain of either the key or its value.
+func (k *keys) location(domain byte, path, key []byte) []byte {
+	mac := hmac.New(sha256.New, k.nonce[:])
+	mac.Write([]byte{domain})
+	mac.Write(appendPath(path, key))
+	return mac.Sum(nil)
+}
+
+// encryptKey encrypts a key or nested bucket name of the bucket with the path,
+// or a top-level bucket name with a nil path.  Keys are encrypted
+// deterministically, with a nonce derived from their location, so a key can be
+// looked up by its encryption.
+func (k *keys) encryptKey(path, key []byte) []byte {
+	var nonce [snacl.NonceSize]byte
+	copy(nonce[:], k.location(keyDomain, path, key))
+	cryptKey := (*[snacl.KeySize]byte)(&k.crypt)
+	return secretbox.Seal(nonce[:], key, &nonce, cryptKey)
+}
+
+// decryptKey decrypts a key of the bucket with the path encrypted by
+// encryptKey.  ErrMisplaced is returned if the key was encrypted for another
+// bucket.
+func (k *keys) decryptKey(path, encKey []byte) ([]byte, error) {
+	key, err := k.crypt.Decrypt(encKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := k.location(keyDomain, path, key)[:snacl.NonceSize]
+	if !hmac.Equal(nonce, encKey[:snacl.NonceSize]) {
+		return nil, ErrMisplaced
+	}
+	return key, nil
+}
+
+// encryptValue encrypts the value of a key of the bucket with the path, with a
+// random nonce.  The value is bound to its key and bucket by a tag encrypted
+// along with it.
+func (k *keys) encryptValue(path, key, value []byte) ([]byte, error) {
+	tag := k.location(valueDomain, path, key)[:valueTagSize]
+	return k.crypt.Encrypt(append(tag, value...))
+}
+
+// decryptValue decrypts the value of a key of the bucket with the path
+// encrypted by encryptValue.  ErrMisplaced is returned if the value was
+// encrypted for another key or bucket.  Empty values are returned as a non-nil
+// slice, which callers use to tell existing keys apart.
+func (k *keys) decryptValue(path, key, encValue []byte) ([]byte, error) {
+	v, err := k.crypt.Decrypt(encValue)
+	if err != nil {
+		return nil, err
+	}
+	tag := k.location(valueDomain, path, key)[:valueTagSize]
+	if len(v) < valueTagSize || !hmac.Equal(tag, v[:valueTagSize]) {
+		return nil, ErrMisplaced
+	}
+	return v[valueTagSize:], nil
+}
+
+// putPassphrase stores the master key encrypted with a key derived from the
+// passphrase.
+func putPassphrase(meta walletdb.ReadWriteBucket, master *snacl.CryptoKey,
+	passphrase []byte, config *waddrmgr.ScryptOptions) error {
+
+	passKey, err := snacl.NewSecretKey(
+		&passphrase, config.N, config.R, config.P,
+	)
+	if err != nil {
+		return err
+	}
+	defer passKey.Zero()
+
+	encMaster, err := passKey.Encrypt(master[:])
+	if err != nil {
+		return err
+	}
+	if err := meta.Put(paramsKey, passKey.Marshal()); err != nil {
+		return err
+	}
+	return meta.Put(masterKeyKey, encMaster)
+}
+
+// unwrapper is implemented by databases wrapping another database, such as
+// the instrumented databases of the metrics package, so that IsEncrypted can
+// tell whether the database they wrap is encrypted.
+type unwrapper interface {
+	Unwrap() walletdb.DB
+}
+
+// IsEncrypted returns whether the database is encrypted.  This is true both of
+// an encrypted database opened with Open and of the underlying database
+// before it's opened.
+func IsEncrypted(db walletdb.DB) (bool, error) {
+	for {
+		switch d := db.(type) {
+		case *cryptDB:
+			return true, nil
+		case unwrapper:
+			db = d.Unwrap()
+			continue
+		}
+		break
+	}
+
+	var encrypted bool
+	err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+		encrypted = tx.ReadBucket(metaBucketKey) != nil
+		return nil
+	})
+	return encrypted, err
+}
+
+// initEncryption creates the bucket of the encryption parameters of an empty
+// database with a new master key, and returns the keys encrypting the
+// database.
+func initEncryption(tx walletdb.ReadWriteTx, passphrase []byte,
+	config *waddrmgr.ScryptOptions) (*keys, error) {
+
+	if tx.ReadBucket(metaBucketKey) != nil {
+		return nil, ErrEncrypted
+	}
+	empty := true
+	err := tx.ForEachBucket(func([]byte) error {
+		empty = false
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, ErrNotEmpty
+	}
+
+	master, err := snacl.GenerateCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+	defer master.Zero()
+
+	meta, err := tx.CreateTopLevelBucket(metaBucketKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := putPassphrase(meta, master, passphrase, config); err != nil {
+		return nil, err
+	}
+	return newKeys(master), nil
+}
+
+// Encrypt sets up the encryption of an empty database with a key derived from
+// the passphrase, and returns the encrypted database.  ErrNotEmpty is returned
+// if the database has any bucket, as encrypting its data in place would leave
+// the cleartext in the free pages of the database file, so it must be copied
+// with EncryptCopy instead.
+func Encrypt(db walletdb.DB, passphrase []byte,
+	config *waddrmgr.ScryptOptions) (walletdb.DB, error) {
+
+	var k *keys
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		var err error
+		k, err = initEncryption(tx, passphrase, config)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cryptDB{inner: db, keys: k}, nil
+}
+
+// EncryptCopy copies every bucket of the unencrypted database src to the empty
+// database dst, encrypted with a key derived from the passphrase, and returns
+// the encrypted dst.  The source database is left unchanged, so an existing
+// database file is encrypted by copying it to a new file which then replaces
+// it.
+func EncryptCopy(dst, src walletdb.DB, passphrase []byte,
+	config *waddrmgr.ScryptOptions) (walletdb.DB, error) {
+
+	var k *keys
+	err := walletdb.Update(dst, func(dstTx walletdb.ReadWriteTx) error {
+		var err error
+		k, err = initEncryption(dstTx, passphrase, config)
+		if err != nil {
+			return err
+		}
+		tx := &transaction{inner: dstTx, keys: k}
+
+		// Buckets are only read from the source, but a writable
+		// transaction is needed to read their sequences.
+		return walletdb.Update(src, func(srcTx walletdb.ReadWriteTx) error {
+			if srcTx.ReadBucket(metaBucketKey) != nil {
+				return ErrEncrypted
+			}
+			return srcTx.ForEachBucket(func(name []byte) error {
+				b, err := tx.CreateTopLevelBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(b, srcTx.ReadWriteBucket(name))
+			})
+		})
+	})
+	if err != nil {
+		if k != nil {
+			k.zero()
+		}
+		return nil, err
+	}
+
+	return &cryptDB{inner: dst, keys: k}, nil
+}
+
+// copyBucket copies the key/value pairs, nested buckets and sequence of a
+// bucket.
+func copyBucket(dst, src walletdb.ReadWriteBucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+	return src.ForEach(func(k, v []byte) error {
+		// Nested buckets have a nil value.
+		var nested walletdb.ReadWriteBucket
+		if v == nil {
+			nested = src.NestedReadWriteBucket(k)
+		}
+		if nested == nil {
+			return dst.Put(k, v)
+		}
+		child, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(child, nested)
+	})
+}
+
+// Open opens an encrypted database with the passphrase it's encrypted with.
+// A waddrmgr.ErrWrongPassphrase error is returned if the passphrase is
+// incorrect.
+func Open(db walletdb.DB, passphrase []byte) (walletdb.DB, error) {
+	var passKey snacl.SecretKey
+	var encMaster []byte
+	err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+		meta := tx.ReadBucket(metaBucketKey)
+		if meta == nil {
+			return ErrNotEncrypted
+		}
+		if err := passKey.Unmarshal(meta.Get(paramsKey)); err != nil {
+			return err
+		}
+		encMaster = append([]byte{}, meta.Get(masterKeyKey)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := passKey.DeriveKey(&passphrase); err != nil {
+		if err == snacl.ErrInvalidPassword {
+			return nil, waddrmgr.ManagerError{
+				ErrorCode:   waddrmgr.ErrWrongPassphrase,
+				Description: "invalid passphrase for database",
+				Err:         err,
+			}
+		}
+		return nil, err
+	}
+	defer passKey.Zero()
+
+	masterBytes, err := passKey.Decrypt(encMaster)
+	if err != nil {
+		return nil, err
+	}
+	if len(masterBytes) != snacl.KeySize {
+		return nil, snacl.ErrMalformed
+	}
+	var master snacl.CryptoKey
+	copy(master[:], masterBytes)
+	defer master.Zero()
+
+	return &cryptDB{inner: db, keys: newKeys(&master)}, nil
+}
+
+// SetPassphrase changes the passphrase of the encrypted database of the
+// transaction, which is used when opening the database.  The encryption keys
+// of the database don't change.  ErrNotEncrypted is returned if the
+// transaction isn't one of an encrypted database opened with Open or Encrypt.
+func SetPassphrase(tx walletdb.ReadWriteTx, passphrase []byte,
+	config *waddrmgr.ScryptOptions) error {
+
+	ctx, ok := tx.(*transaction)
+	if !ok {
+		return ErrNotEncrypted
+	}
+	rwtx, err := ctx.rw()
+	if err != nil {
+		return err
+	}
+	meta := rwtx.ReadWriteBucket(metaBucketKey)
+	if meta == nil {
+		return ErrNotEncrypted
+	}
+	return putPassphrase(meta, &ctx.keys.master, passphrase, config)
+}
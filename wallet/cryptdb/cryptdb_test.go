@@ -0,0 +1,451 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cryptdb
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+	"github.com/btcsuite/btcwallet/walletdb/walletdbtest"
+)
+
+// testDBType is a walletdb driver of encrypted in-memory databases, used to run
+// the walletdb interface tests.
+const testDBType = "cryptdb-memdb"
+
+var testPassphrase = []byte("public")
+
+func init() {
+	driver := walletdb.Driver{
+		DbType: testDBType,
+		Create: func(args ...interface{}) (walletdb.DB, error) {
+			db, err := walletdb.Create("memdb")
+			if err != nil {
+				return nil, err
+			}
+			return Encrypt(
+				db, testPassphrase, &waddrmgr.FastScryptOptions,
+			)
+		},
+		Open: func(args ...interface{}) (walletdb.DB, error) {
+			return nil, walletdb.ErrDbDoesNotExist
+		},
+	}
+	if err := walletdb.RegisterDriver(driver); err != nil {
+		panic(err)
+	}
+}
+
+// TestInterface performs all interfaces tests for encrypted databases.
+func TestInterface(t *testing.T) {
+	walletdbtest.TestInterface(t, testDBType)
+}
+
+// TestEncrypt tests that a database is encrypted by copying it, and can only be
+// opened with its passphrase.
+func TestEncrypt(t *testing.T) {
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+
+	// Populate the cleartext database with values, an empty value, a
+	// nested bucket and a sequence.
+	storeValues := map[string]string{
+		"secretkey1": "secretvalue1",
+		"secretkey2": "secretvalue2",
+		"secretkey3": "",
+	}
+	nsKey := []byte("secretns")
+	nestedKey := []byte("secretnested")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(nsKey)
+		if err != nil {
+			return err
+		}
+		nested, err := ns.CreateBucket(nestedKey)
+		if err != nil {
+			return err
+		}
+		if err := nested.SetSequence(42); err != nil {
+			return err
+		}
+		for _, b := range []walletdb.ReadWriteBucket{ns, nested} {
+			for k, v := range storeValues {
+				err := b.Put([]byte(k), []byte(v))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to populate db: %v", err)
+	}
+
+	encrypted, err := IsEncrypted(db)
+	if err != nil || encrypted {
+		t.Fatalf("IsEncrypted: got %v (%v), want false", encrypted, err)
+	}
+	if _, err := Open(db, testPassphrase); err != ErrNotEncrypted {
+		t.Fatalf("Open: got %v, want %v", err, ErrNotEncrypted)
+	}
+
+	// A database with buckets can only be encrypted by copying it.
+	_, err = Encrypt(db, testPassphrase, &waddrmgr.FastScryptOptions)
+	if err != ErrNotEmpty {
+		t.Fatalf("Encrypt: got %v, want %v", err, ErrNotEmpty)
+	}
+	dst, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	cdb, err := EncryptCopy(
+		dst, db, testPassphrase, &waddrmgr.FastScryptOptions,
+	)
+	if err != nil {
+		t.Fatalf("unable to encrypt db: %v", err)
+	}
+	_, err = Encrypt(dst, testPassphrase, &waddrmgr.FastScryptOptions)
+	if err != ErrEncrypted {
+		t.Fatalf("Encrypt: got %v, want %v", err, ErrEncrypted)
+	}
+	encrypted, err = IsEncrypted(dst)
+	if err != nil || !encrypted {
+		t.Fatalf("IsEncrypted: got %v (%v), want true", encrypted, err)
+	}
+	encrypted, err = IsEncrypted(cdb)
+	if err != nil || !encrypted {
+		t.Fatalf("IsEncrypted: got %v (%v), want true", encrypted, err)
+	}
+
+	// The source database is left unchanged.
+	encrypted, err = IsEncrypted(db)
+	if err != nil || encrypted {
+		t.Fatalf("IsEncrypted: got %v (%v), want false", encrypted, err)
+	}
+	db = dst
+
+	// No key, bucket name or value is stored in cleartext.
+	var buf bytes.Buffer
+	if err := db.Copy(&buf); err != nil {
+		t.Fatalf("unable to copy db: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret")) {
+		t.Fatal("encrypted db contains cleartext")
+	}
+
+	checkValues := func(db walletdb.DB) error {
+		return walletdb.View(db, func(tx walletdb.ReadTx) error {
+			var names [][]byte
+			err := tx.ForEachBucket(func(name []byte) error {
+				names = append(names, name)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(names, [][]byte{nsKey}) {
+				return fmt.Errorf("ForEachBucket: got %q, "+
+					"want %q", names, nsKey)
+			}
+
+			ns := tx.ReadBucket(nsKey)
+			if ns == nil {
+				return fmt.Errorf("missing bucket %s", nsKey)
+			}
+			nested := ns.NestedReadBucket(nestedKey)
+			if nested == nil {
+				return fmt.Errorf("missing bucket %s",
+					nestedKey)
+			}
+			seq := nested.(walletdb.ReadWriteBucket).Sequence()
+			if seq != 42 {
+				return fmt.Errorf("Sequence: got %d, want 42",
+					seq)
+			}
+			for _, b := range []walletdb.ReadBucket{ns, nested} {
+				for k, v := range storeValues {
+					got := b.Get([]byte(k))
+					if !reflect.DeepEqual(got, []byte(v)) {
+						return fmt.Errorf("Get(%s): "+
+							"got %q, want %q", k,
+							got, v)
+					}
+				}
+			}
+			return nil
+		})
+	}
+	if err := checkValues(cdb); err != nil {
+		t.Fatalf("encrypted db: %v", err)
+	}
+
+	// The database can be reopened with the passphrase only.
+	_, err = Open(db, []byte("wrong"))
+	if !waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		t.Fatalf("Open: got %v, want ErrWrongPassphrase", err)
+	}
+	cdb, err = Open(db, testPassphrase)
+	if err != nil {
+		t.Fatalf("unable to open db: %v", err)
+	}
+	if err := checkValues(cdb); err != nil {
+		t.Fatalf("reopened db: %v", err)
+	}
+	cdb.Close()
+}
+
+// TestSetPassphrase tests that the passphrase of an encrypted database can be
+// changed.
+func TestSetPassphrase(t *testing.T) {
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	cdb, err := Encrypt(db, testPassphrase, &waddrmgr.FastScryptOptions)
+	if err != nil {
+		t.Fatalf("unable to encrypt db: %v", err)
+	}
+
+	key, value := []byte("key"), []byte("value")
+	newPassphrase := []byte("new")
+	err = walletdb.Update(cdb, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket([]byte("ns"))
+		if err != nil {
+			return err
+		}
+		if err := ns.Put(key, value); err != nil {
+			return err
+		}
+		return SetPassphrase(
+			tx, newPassphrase, &waddrmgr.FastScryptOptions,
+		)
+	})
+	if err != nil {
+		t.Fatalf("unable to set passphrase: %v", err)
+	}
+
+	_, err = Open(db, testPassphrase)
+	if !waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		t.Fatalf("Open: got %v, want ErrWrongPassphrase", err)
+	}
+	cdb, err = Open(db, newPassphrase)
+	if err != nil {
+		t.Fatalf("unable to open db: %v", err)
+	}
+	defer cdb.Close()
+	err = walletdb.View(cdb, func(tx walletdb.ReadTx) error {
+		got := tx.ReadBucket([]byte("ns")).Get(key)
+		if !bytes.Equal(got, value) {
+			return fmt.Errorf("Get: got %q, want %q", got, value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The passphrase can't be set with a transaction of an unencrypted
+	// database.
+	plainDB, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	err = walletdb.Update(plainDB, func(tx walletdb.ReadWriteTx) error {
+		return SetPassphrase(
+			tx, newPassphrase, &waddrmgr.FastScryptOptions,
+		)
+	})
+	if err != ErrNotEncrypted {
+		t.Fatalf("SetPassphrase: got %v, want %v", err, ErrNotEncrypted)
+	}
+}
+
+// TestMisplaced tests that encrypted keys and values can't be moved to another
+// key or bucket of the underlying database.
+func TestMisplaced(t *testing.T) {
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	cdb, err := Encrypt(db, testPassphrase, &waddrmgr.FastScryptOptions)
+	if err != nil {
+		t.Fatalf("unable to encrypt db: %v", err)
+	}
+
+	key1, key2 := []byte("key1"), []byte("key2")
+	ns1, ns2 := []byte("ns1"), []byte("ns2")
+	err = walletdb.Update(cdb, func(tx walletdb.ReadWriteTx) error {
+		for _, name := range [][]byte{ns1, ns2} {
+			ns, err := tx.CreateTopLevelBucket(name)
+			if err != nil {
+				return err
+			}
+			if err := ns.Put(key1, []byte("value1")); err != nil {
+				return err
+			}
+			if err := ns.Put(key2, []byte("value2")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to populate db: %v", err)
+	}
+	k := cdb.(*cryptDB).keys
+
+	// rawBucket returns a bucket of the underlying database.
+	rawBucket := func(tx walletdb.ReadWriteTx,
+		name []byte) walletdb.ReadWriteBucket {
+
+		return tx.ReadWriteBucket(k.encryptKey(nil, name))
+	}
+
+	// Swapping the values of two keys of a bucket makes reading either fail.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := rawBucket(tx, ns1)
+		path := appendPath(nil, ns1)
+		encKey1 := k.encryptKey(path, key1)
+		encKey2 := k.encryptKey(path, key2)
+		value1 := append([]byte{}, ns.Get(encKey1)...)
+		value2 := append([]byte{}, ns.Get(encKey2)...)
+		if err := ns.Put(encKey1, value2); err != nil {
+			return err
+		}
+		return ns.Put(encKey2, value1)
+	})
+	if err != nil {
+		t.Fatalf("unable to swap values: %v", err)
+	}
+	err = walletdb.View(cdb, func(tx walletdb.ReadTx) error {
+		if v := tx.ReadBucket(ns1).Get(key1); v != nil {
+			t.Fatalf("Get: got %q, want nil", v)
+		}
+		return nil
+	})
+	if err != ErrMisplaced {
+		t.Fatalf("View: got %v, want %v", err, ErrMisplaced)
+	}
+
+	// Moving a key and its value to another bucket makes iterating over
+	// it fail.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		encKey := k.encryptKey(appendPath(nil, ns2), key1)
+		value := rawBucket(tx, ns2).Get(encKey)
+		return rawBucket(tx, ns1).Put(encKey, value)
+	})
+	if err != nil {
+		t.Fatalf("unable to move key: %v", err)
+	}
+	err = walletdb.View(cdb, func(tx walletdb.ReadTx) error {
+		return tx.ReadBucket(ns1).ForEach(func(_, _ []byte) error {
+			return nil
+		})
+	})
+	if err != ErrMisplaced {
+		t.Fatalf("ForEach: got %v, want %v", err, ErrMisplaced)
+	}
+}
+
+// TestCursorIndex tests that cursors see the keys added to and removed from a
+// bucket after the keys of the bucket were indexed by another cursor.
+func TestCursorIndex(t *testing.T) {
+	db, err := walletdb.Create(testDBType)
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	defer db.Close()
+
+	keys := func(b walletdb.ReadBucket) []string {
+		var keys []string
+		c := b.ReadCursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			keys = append(keys, string(k))
+		}
+		return keys
+	}
+	assertKeys := func(b walletdb.ReadBucket, want ...string) {
+		t.Helper()
+
+		if got := keys(b); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got keys %q, want %q", got, want)
+		}
+	}
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		b, err := tx.CreateTopLevelBucket([]byte("bucket"))
+		if err != nil {
+			return err
+		}
+		for _, k := range []string{"a", "c"} {
+			if err := b.Put([]byte(k), []byte{1}); err != nil {
+				return err
+			}
+		}
+		assertKeys(b, "a", "c")
+
+		// Replacing a value keeps the keys, adding one changes them.
+		if err := b.Put([]byte("c"), []byte{2}); err != nil {
+			return err
+		}
+		if err := b.Put([]byte("b"), []byte{1}); err != nil {
+			return err
+		}
+		assertKeys(b, "a", "b", "c")
+		if k, v := b.ReadCursor().Seek([]byte("bb")); string(k) != "c" ||
+			!bytes.Equal(v, []byte{2}) {
+
+			t.Fatalf("seek returned %q %x", k, v)
+		}
+
+		// Nested buckets are keys of their parent, and deleting them
+		// forgets their keys.
+		nested, err := b.CreateBucket([]byte("d"))
+		if err != nil {
+			return err
+		}
+		if err := nested.Put([]byte("x"), []byte{1}); err != nil {
+			return err
+		}
+		assertKeys(nested, "x")
+		assertKeys(b, "a", "b", "c", "d")
+		if err := b.DeleteNestedBucket([]byte("d")); err != nil {
+			return err
+		}
+		nested, err = b.CreateBucket([]byte("d"))
+		if err != nil {
+			return err
+		}
+		assertKeys(nested)
+
+		// Keys deleted through the bucket or a cursor are removed.
+		if err := b.Delete([]byte("a")); err != nil {
+			return err
+		}
+		c := b.ReadWriteCursor()
+		if k, _ := c.Seek([]byte("c")); string(k) != "c" {
+			t.Fatalf("seek returned %q", k)
+		}
+		if err := c.Delete(); err != nil {
+			return err
+		}
+		if k, _ := c.Next(); string(k) != "d" {
+			t.Fatalf("next key after deletion is %q", k)
+		}
+		assertKeys(b, "b", "d")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
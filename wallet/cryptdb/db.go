@@ -0,0 +1,702 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package cryptdb
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// transaction wraps a transaction of the underlying database, encrypting the
+// keys and values it writes and decrypting those it reads.
+type transaction struct {
+	inner walletdb.ReadTx
+	keys  *keys
+
+	// err is the first decryption error of the transaction.  Reads can't
+	// return an error, so it fails the commit instead.
+	err error
+
+	// indexes caches the decrypted and sorted keys of the buckets cursors
+	// were positioned in, by bucket path, until the keys of the bucket
+	// change.
+	indexes map[string][]cursorKey
+}
+
+// Enforce transaction implements the walletdb transaction interfaces.
+var _ walletdb.ReadWriteTx = (*transaction)(nil)
+
+// setErr records the first decryption error of the transaction.
+func (tx *transaction) setErr(err error) {
+	if tx.err == nil {
+		tx.err = err
+	}
+}
+
+// rw returns the underlying read-write transaction.
+func (tx *transaction) rw() (walletdb.ReadWriteTx, error) {
+	rwtx, ok := tx.inner.(walletdb.ReadWriteTx)
+	if !ok {
+		return nil, walletdb.ErrTxNotWritable
+	}
+	return rwtx, nil
+}
+
+// keyIndex returns the decrypted keys of the bucket in sorted order.  The index
+// is cached until the keys of the bucket change, and must not be modified, as
+// it's shared by the cursors of the bucket.
+func (tx *transaction) keyIndex(b *bucket) ([]cursorKey, error) {
+	if index, ok := tx.indexes[string(b.path)]; ok {
+		return index, nil
+	}
+
+	var index []cursorKey
+	err := b.inner.ForEach(func(k, _ []byte) error {
+		key, err := tx.keys.decryptKey(b.path, k)
+		if err != nil {
+			return err
+		}
+		index = append(index, cursorKey{
+			key:    key,
+			encKey: append([]byte{}, k...),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(index, func(i, j int) bool {
+		return bytes.Compare(index[i].key, index[j].key) < 0
+	})
+
+	if tx.indexes == nil {
+		tx.indexes = make(map[string][]cursorKey)
+	}
+	tx.indexes[string(b.path)] = index
+	return index, nil
+}
+
+// keyChanged drops the cached key index of the bucket at the path if the key
+// was added to or removed from it, as given by exists.
+func (tx *transaction) keyChanged(path, key []byte, exists bool) {
+	index, ok := tx.indexes[string(path)]
+	if !ok {
+		return
+	}
+	i := sort.Search(len(index), func(i int) bool {
+		return bytes.Compare(index[i].key, key) >= 0
+	})
+	indexed := i < len(index) && bytes.Equal(index[i].key, key)
+	if indexed != exists {
+		delete(tx.indexes, string(path))
+	}
+}
+
+// bucketDeleted drops the cached key indexes of the deleted bucket at the path
+// and of the buckets nested in it.  Paths are length prefixed, so those of the
+// nested buckets start with the path of the deleted bucket.
+func (tx *transaction) bucketDeleted(path []byte) {
+	for p := range tx.indexes {
+		if strings.HasPrefix(p, string(path)) {
+			delete(tx.indexes, p)
+		}
+	}
+}
+
+// bucket wraps a bucket of the underlying database with its path, returning
+// nil if it doesn't exist.
+func (tx *transaction) bucket(inner walletdb.ReadBucket, path []byte) *bucket {
+	if inner == nil {
+		return nil
+	}
+	return &bucket{tx: tx, inner: inner, path: path}
+}
+
+// ReadBucket opens the root bucket for read only access.  If the bucket
+// described by the key does not exist, nil is returned.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) ReadBucket(key []byte) walletdb.ReadBucket {
+	return tx.ReadWriteBucket(key)
+}
+
+// ForEachBucket will iterate through all top level buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) ForEachBucket(fn func(key []byte) error) error {
+	return tx.inner.ForEachBucket(func(key []byte) error {
+		if bytes.Equal(key, metaBucketKey) {
+			return nil
+		}
+		name, err := tx.keys.decryptKey(nil, key)
+		if err != nil {
+			return err
+		}
+		return fn(name)
+	})
+}
+
+// ReadWriteBucket opens the root bucket for read/write access.  If the bucket
+// described by the key does not exist, nil is returned.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) ReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	b := tx.bucket(
+		tx.inner.ReadBucket(tx.keys.encryptKey(nil, key)),
+		appendPath(nil, key),
+	)
+	// Don't return a non-nil interface to a nil pointer.
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// CreateTopLevelBucket creates the top level bucket for a key if it does not
+// exist.  The newly-created bucket it returned.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) CreateTopLevelBucket(key []byte) (
+	walletdb.ReadWriteBucket, error) {
+
+	rwtx, err := tx.rw()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+	inner, err := rwtx.CreateTopLevelBucket(tx.keys.encryptKey(nil, key))
+	if err != nil {
+		return nil, err
+	}
+	return tx.bucket(inner, appendPath(nil, key)), nil
+}
+
+// DeleteTopLevelBucket deletes the top level bucket for a key.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) DeleteTopLevelBucket(key []byte) error {
+	rwtx, err := tx.rw()
+	if err != nil {
+		return err
+	}
+	err = rwtx.DeleteTopLevelBucket(tx.keys.encryptKey(nil, key))
+	if err != nil {
+		return err
+	}
+	tx.bucketDeleted(appendPath(nil, key))
+	return nil
+}
+
+// Commit commits all changes that have been made through the root bucket and
+// all of its sub-buckets to persistent storage.  The transaction is rolled
+// back instead if any value failed to decrypt.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) Commit() error {
+	rwtx, err := tx.rw()
+	if err != nil {
+		return err
+	}
+	if tx.err != nil {
+		_ = rwtx.Rollback()
+		return tx.err
+	}
+	return rwtx.Commit()
+}
+
+// Rollback undoes all changes that have been made to the root bucket and all
+// of its sub-buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) Rollback() error {
+	return tx.inner.Rollback()
+}
+
+// OnCommit takes a function closure that will be executed when the
+// transaction successfully gets committed.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) OnCommit(f func()) {
+	if rwtx, err := tx.rw(); err == nil {
+		rwtx.OnCommit(f)
+	}
+}
+
+// bucket wraps a bucket of the underlying database and implements the walletdb
+// Bucket interfaces.
+type bucket struct {
+	tx    *transaction
+	inner walletdb.ReadBucket
+
+	// path is the path of the bucket from the root, which the encryption
+	// of its keys and values is bound to.
+	path []byte
+}
+
+// Enforce bucket implements the walletdb Bucket interfaces.
+var _ walletdb.ReadWriteBucket = (*bucket)(nil)
+
+// rw returns the underlying read-write bucket.
+func (b *bucket) rw() (walletdb.ReadWriteBucket, error) {
+	rwb, ok := b.inner.(walletdb.ReadWriteBucket)
+	if !ok {
+		return nil, walletdb.ErrTxNotWritable
+	}
+	return rwb, nil
+}
+
+// NestedReadWriteBucket retrieves a nested bucket with the given key.  Returns
+// nil if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) NestedReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	encKey := b.tx.keys.encryptKey(b.path, key)
+	nested := b.tx.bucket(
+		b.inner.NestedReadBucket(encKey), appendPath(b.path, key),
+	)
+	// Don't return a non-nil interface to a nil pointer.
+	if nested == nil {
+		return nil
+	}
+	return nested
+}
+
+// NestedReadBucket retrieves a nested bucket with the given key.  Returns nil
+// if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) NestedReadBucket(key []byte) walletdb.ReadBucket {
+	return b.NestedReadWriteBucket(key)
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) CreateBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	rwb, err := b.rw()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+	inner, err := rwb.CreateBucket(b.tx.keys.encryptKey(b.path, key))
+	if err != nil {
+		return nil, err
+	}
+	b.tx.keyChanged(b.path, key, true)
+	return b.tx.bucket(inner, appendPath(b.path, key)), nil
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) CreateBucketIfNotExists(key []byte) (
+	walletdb.ReadWriteBucket, error) {
+
+	rwb, err := b.rw()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+	inner, err := rwb.CreateBucketIfNotExists(
+		b.tx.keys.encryptKey(b.path, key),
+	)
+	if err != nil {
+		return nil, err
+	}
+	b.tx.keyChanged(b.path, key, true)
+	return b.tx.bucket(inner, appendPath(b.path, key)), nil
+}
+
+// DeleteNestedBucket removes a nested bucket with the given key.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) DeleteNestedBucket(key []byte) error {
+	rwb, err := b.rw()
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return walletdb.ErrIncompatibleValue
+	}
+	err = rwb.DeleteNestedBucket(b.tx.keys.encryptKey(b.path, key))
+	if err != nil {
+		return err
+	}
+	b.tx.keyChanged(b.path, key, false)
+	b.tx.bucketDeleted(appendPath(b.path, key))
+	return nil
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket,
+// in key order.  This includes nested buckets, in which case the value is nil,
+// but it does not include the key/value pairs within those nested buckets.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return c.err
+}
+
+// Put saves the specified key/value pair to the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Put(key, value []byte) error {
+	rwb, err := b.rw()
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return walletdb.ErrKeyRequired
+	}
+	encValue, err := b.tx.keys.encryptValue(b.path, key, value)
+	if err != nil {
+		return err
+	}
+	err = rwb.Put(b.tx.keys.encryptKey(b.path, key), encValue)
+	if err != nil {
+		return err
+	}
+	b.tx.keyChanged(b.path, key, true)
+	return nil
+}
+
+// get returns the decrypted value of a key given its encryption, or nil if it
+// doesn't exist or is a nested bucket.
+func (b *bucket) get(key, encKey []byte) []byte {
+	encValue := b.inner.Get(encKey)
+	if encValue == nil {
+		return nil
+	}
+	value, err := b.tx.keys.decryptValue(b.path, key, encValue)
+	if err != nil {
+		b.tx.setErr(err)
+		return nil
+	}
+	return value
+}
+
+// Get returns the value for the given key.  Returns nil if the key does not
+// exist in this bucket.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) Get(key []byte) []byte {
+	return b.get(key, b.tx.keys.encryptKey(b.path, key))
+}
+
+// Delete removes the specified key from the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Delete(key []byte) error {
+	rwb, err := b.rw()
+	if err != nil {
+		return err
+	}
+	err = rwb.Delete(b.tx.keys.encryptKey(b.path, key))
+	if err != nil {
+		return err
+	}
+	b.tx.keyChanged(b.path, key, false)
+	return nil
+}
+
+func (b *bucket) cursor() *cursor {
+	return &cursor{bucket: b, pos: -1}
+}
+
+// ReadCursor returns a new cursor, allowing for iteration over the bucket's
+// key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ReadCursor() walletdb.ReadCursor {
+	return b.cursor()
+}
+
+// ReadWriteCursor returns a new cursor, allowing for iteration over the
+// bucket's key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) ReadWriteCursor() walletdb.ReadWriteCursor {
+	return b.cursor()
+}
+
+// Tx returns the bucket's transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Tx() walletdb.ReadWriteTx {
+	return b.tx
+}
+
+// NextSequence returns an autoincrementing integer for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) NextSequence() (uint64, error) {
+	rwb, err := b.rw()
+	if err != nil {
+		return 0, err
+	}
+	return rwb.NextSequence()
+}
+
+// SetSequence updates the sequence number for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) SetSequence(v uint64) error {
+	rwb, err := b.rw()
+	if err != nil {
+		return err
+	}
+	return rwb.SetSequence(v)
+}
+
+// Sequence returns the current integer for the bucket without incrementing it.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Sequence() uint64 {
+	rwb, ok := b.inner.(walletdb.ReadWriteBucket)
+	if !ok {
+		return 0
+	}
+	return rwb.Sequence()
+}
+
+// cursorKey is a key of a bucket and its encryption.
+type cursorKey struct {
+	key    []byte
+	encKey []byte
+}
+
+// cursor represents a cursor over key/value pairs and nested buckets of a
+// bucket.
+//
+// Encrypted keys don't sort in the order of their plaintext, so positioning
+// the cursor with First, Last or Seek uses the decrypted and sorted keys of the
+// bucket, which are cached by the transaction until they change.  Next and Prev
+// move within the keys the cursor was positioned in, so the cursor remains
+// valid when the current key is deleted.
+type cursor struct {
+	bucket *bucket
+	keys   []cursorKey
+	pos    int
+
+	// err is the first error decrypting the keys of the bucket.
+	err error
+}
+
+// Enforce cursor implements the walletdb cursor interfaces.
+var _ walletdb.ReadWriteCursor = (*cursor)(nil)
+
+// load loads the sorted keys of the bucket.
+func (c *cursor) load() {
+	keys, err := c.bucket.tx.keyIndex(c.bucket)
+	if err != nil {
+		c.keys = nil
+		c.err = err
+		c.bucket.tx.setErr(err)
+		return
+	}
+	c.keys = keys
+}
+
+// current returns the key/value pair the cursor is positioned at.
+func (c *cursor) current() (key, value []byte) {
+	if c.pos < 0 || c.pos >= len(c.keys) {
+		return nil, nil
+	}
+	k := &c.keys[c.pos]
+	return k.key, c.bucket.get(k.key, k.encKey)
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor.
+//
+// This function is part of the walletdb.ReadWriteCursor interface
+// implementation.
+func (c *cursor) Delete() error {
+	rwb, err := c.bucket.rw()
+	if err != nil {
+		return err
+	}
+	if c.pos < 0 || c.pos >= len(c.keys) {
+		return nil
+	}
+	k := &c.keys[c.pos]
+	if err := rwb.Delete(k.encKey); err != nil {
+		return err
+	}
+	c.bucket.tx.keyChanged(c.bucket.path, k.key, false)
+	return nil
+}
+
+// First positions the cursor at the first key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) First() (key, value []byte) {
+	c.load()
+	c.pos = 0
+	return c.current()
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Last() (key, value []byte) {
+	c.load()
+	c.pos = len(c.keys) - 1
+	return c.current()
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Next() (key, value []byte) {
+	if c.pos < 0 || c.pos >= len(c.keys) {
+		return nil, nil
+	}
+	c.pos++
+	return c.current()
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Prev() (key, value []byte) {
+	if c.pos <= 0 {
+		c.pos = -1
+		return nil, nil
+	}
+	c.pos--
+	return c.current()
+}
+
+// Seek positions the cursor at the passed seek key.  If the key does not
+// exist, the cursor is moved to the next key after seek.  Returns the new
+// pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Seek(seek []byte) (key, value []byte) {
+	c.load()
+	c.pos = sort.Search(len(c.keys), func(i int) bool {
+		return bytes.Compare(c.keys[i].key, seek) >= 0
+	})
+	return c.current()
+}
+
+// cryptDB wraps a database, encrypting its keys and values, and implements the
+// walletdb.DB interface.
+type cryptDB struct {
+	inner walletdb.DB
+	keys  *keys
+}
+
+// Enforce cryptDB implements the walletdb.DB interface.
+var _ walletdb.DB = (*cryptDB)(nil)
+
+// BeginReadTx opens a database read transaction.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) BeginReadTx() (walletdb.ReadTx, error) {
+	tx, err := db.inner.BeginReadTx()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{inner: tx, keys: db.keys}, nil
+}
+
+// BeginReadWriteTx opens a database read+write transaction.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	tx, err := db.inner.BeginReadWriteTx()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{inner: tx, keys: db.keys}, nil
+}
+
+// Copy writes a copy of the encrypted database to the provided writer.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) Copy(w io.Writer) error {
+	return db.inner.Copy(w)
+}
+
+// Close closes the underlying database and clears the encryption keys.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) Close() error {
+	if err := db.inner.Close(); err != nil {
+		return err
+	}
+	db.keys.zero()
+	return nil
+}
+
+// PrintStats returns all collected stats of the underlying database pretty
+// printed into a string.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) PrintStats() string {
+	return db.inner.PrintStats()
+}
+
+// View opens a database read transaction and executes the function f with the
+// transaction passed as a parameter.  The transaction and retries are managed
+// by the underlying database.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) View(f func(tx walletdb.ReadTx) error, reset func()) error {
+	return db.inner.View(func(itx walletdb.ReadTx) error {
+		tx := &transaction{inner: itx, keys: db.keys}
+		if err := f(tx); err != nil {
+			return err
+		}
+		return tx.err
+	}, reset)
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with the transaction passed as a parameter.  The transaction is committed if
+// f succeeds and every value read decrypted successfully.  The transaction and
+// retries are managed by the underlying database.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *cryptDB) Update(f func(tx walletdb.ReadWriteTx) error,
+	reset func()) error {
+
+	return db.inner.Update(func(itx walletdb.ReadWriteTx) error {
+		tx := &transaction{inner: itx, keys: db.keys}
+		if err := f(tx); err != nil {
+			return err
+		}
+		return tx.err
+	}, reset)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package cryptdb wraps a walletdb database of any driver to encrypt it at rest.
+
+The keys, bucket names and values of the database are encrypted with
+NaCl secretbox under a random master key, which is stored encrypted with a key
+derived from a passphrase with scrypt.  Values are encrypted with a random
+nonce, and keys with a nonce derived from the key so they can be looked up.
+Both are bound to their bucket and key, so encrypted data moved to another
+location fails to decrypt.  Bucket sequences, and the number and sizes of keys
+and values, are not hidden.
+
+Since encrypted keys don't sort in the order of their plaintext, positioning a
+cursor decrypts and sorts every key of its bucket.  The sorted keys are cached
+by the transaction until keys are added to or removed from the bucket, so
+repeatedly seeking in a bucket only sorts its keys once.
+
+A new database is encrypted with Encrypt.  An existing database is copied to a
+new database with EncryptCopy, as encrypting it in place would leave its
+cleartext in the free pages of the database file, and the copy then replaces
+it.  Both return the encrypted database, which is reopened with Open:
+
+	src, err := walletdb.Open("bdb", "path/to/wallet.db", true, timeout)
+	if err != nil {
+		// Handle error
+	}
+	dst, err := walletdb.Create("bdb", "path/to/wallet.db.tmp", true, timeout)
+	if err != nil {
+		// Handle error
+	}
+	scryptOpts := &waddrmgr.DefaultScryptOptions
+	db, err := cryptdb.EncryptCopy(dst, src, passphrase, scryptOpts)
+	if err != nil {
+		// Handle error
+	}
+	// Close the databases and rename wallet.db.tmp to wallet.db.
+
+	db, err := walletdb.Open("bdb", "path/to/wallet.db", true, timeout)
+	if err != nil {
+		// Handle error
+	}
+	db, err = cryptdb.Open(db, passphrase)
+	if err != nil {
+		// Handle error
+	}
+*/
+package cryptdb
@@ -0,0 +1,443 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// InvoiceStatus describes the payment state of an invoice.
+type InvoiceStatus uint8
+
+const (
+	// InvoiceStatusUnpaid is the status of an invoice which has not
+	// received any payment yet.
+	InvoiceStatusUnpaid InvoiceStatus = iota
+
+	// InvoiceStatusPartial is the status of an invoice which received less
+	// than the requested amount.
+	InvoiceStatusPartial
+
+	// InvoiceStatusPaid is the status of an invoice which received exactly
+	// the requested amount.
+	InvoiceStatusPaid
+
+	// InvoiceStatusOverpaid is the status of an invoice which received more
+	// than the requested amount.
+	InvoiceStatusOverpaid
+
+	// InvoiceStatusExpired is the status of an invoice which was not fully
+	// paid before its expiry time.
+	InvoiceStatusExpired
+)
+
+// String returns the string representation of an InvoiceStatus.
+func (s InvoiceStatus) String() string {
+	switch s {
+	case InvoiceStatusUnpaid:
+		return "unpaid"
+	case InvoiceStatusPartial:
+		return "partial"
+	case InvoiceStatusPaid:
+		return "paid"
+	case InvoiceStatusOverpaid:
+		return "overpaid"
+	case InvoiceStatusExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// Invoice is a request for a payment to a fresh address of the wallet.
+type Invoice struct {
+	// ID identifies the invoice.
+	ID uint64
+
+	// KeyScope and Account identify the account the invoice address was
+	// derived from.
+	KeyScope waddrmgr.KeyScope
+	Account  uint32
+
+	// Address is the address reserved for payments of the invoice.
+	Address btcutil.Address
+
+	// Amount is the requested amount. Any payment fully pays an invoice
+	// with a zero amount.
+	Amount btcutil.Amount
+
+	// Memo is a description of the invoice, included as the message of the
+	// BIP21 URI.
+	Memo string
+
+	// Created is the creation time of the invoice.
+	Created time.Time
+
+	// Expiry is the time after which an invoice which is not fully paid is
+	// expired. The invoice never expires if it is zero.
+	Expiry time.Time
+
+	// Status is the payment state of the invoice.
+	Status InvoiceStatus
+
+	// Payments are the wallet credits paying to the invoice address.
+	Payments []InvoicePayment
+}
+
+// InvoicePayment is a wallet credit paying to the address of an invoice.
+type InvoicePayment struct {
+	OutPoint wire.OutPoint
+	Amount   btcutil.Amount
+}
+
+// Received returns the total amount paid to the invoice.
+func (inv *Invoice) Received() btcutil.Amount {
+	var total btcutil.Amount
+	for _, p := range inv.Payments {
+		total += p.Amount
+	}
+	return total
+}
+
+// URI returns the BIP21 URI requesting payment of the invoice.
+func (inv *Invoice) URI() string {
+	var params []string
+	if inv.Amount > 0 {
+		amount := strconv.FormatFloat(inv.Amount.ToBTC(), 'f', -1, 64)
+		params = append(params, "amount="+amount)
+	}
+	if inv.Memo != "" {
+		// BIP21 requires percent-encoding, so spaces must not be
+		// encoded as '+' like in a query string.
+		memo := strings.ReplaceAll(url.QueryEscape(inv.Memo), "+", "%20")
+		params = append(params, "message="+memo)
+	}
+
+	uri := "bitcoin:" + inv.Address.EncodeAddress()
+	if len(params) != 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
+
+// status returns the status of the invoice at the given time.
+func (inv *Invoice) status(now time.Time) InvoiceStatus {
+	received := inv.Received()
+	switch {
+	case received > 0 && received >= inv.Amount:
+		if received > inv.Amount && inv.Amount > 0 {
+			return InvoiceStatusOverpaid
+		}
+		return InvoiceStatusPaid
+	case !inv.Expiry.IsZero() && !now.Before(inv.Expiry):
+		return InvoiceStatusExpired
+	case received > 0:
+		return InvoiceStatusPartial
+	default:
+		return InvoiceStatusUnpaid
+	}
+}
+
+// CreateInvoice creates an invoice requesting the amount, reserving a new
+// external address of the account for it. An expiry of zero creates an invoice
+// that never expires.
+func (w *Wallet) CreateInvoice(scope waddrmgr.KeyScope, account uint32,
+	amount btcutil.Amount, memo string, expiry time.Duration) (*Invoice, error) {
+
+	switch {
+	case amount < 0:
+		return nil, errors.New("invoice amount may not be negative")
+	case expiry < 0:
+		return nil, errors.New("invoice expiry may not be negative")
+	case len(memo) > 0xffff:
+		return nil, errors.New("invoice memo is too long")
+	}
+
+	addr, err := w.NewAddress(account, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inv := &Invoice{
+		KeyScope: scope,
+		Account:  account,
+		Address:  addr,
+		Amount:   amount,
+		Memo:     memo,
+		Created:  now,
+		Status:   InvoiceStatusUnpaid,
+	}
+	if expiry != 0 {
+		inv.Expiry = now.Add(expiry)
+	}
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(winvoiceNamespaceKey)
+		if err := putInvoice(ns, inv); err != nil {
+			return err
+		}
+		return setInvoiceOpen(ns, inv, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Invoice returns the invoice with the given ID.
+func (w *Wallet) Invoice(id uint64) (*Invoice, error) {
+	var inv *Invoice
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(winvoiceNamespaceKey)
+
+		var err error
+		inv, err = fetchInvoice(ns, id, w.chainParams)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The stored status is only updated when the wallet processes a block,
+	// so an invoice may have expired since.
+	inv.Status = inv.status(time.Now())
+	return inv, nil
+}
+
+// Invoices returns every invoice of the wallet, ordered by ID.
+func (w *Wallet) Invoices() ([]*Invoice, error) {
+	var invoices []*Invoice
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(winvoiceNamespaceKey)
+		return forEachInvoice(ns, w.chainParams, func(inv *Invoice) error {
+			invoices = append(invoices, inv)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, inv := range invoices {
+		inv.Status = inv.status(now)
+	}
+	return invoices, nil
+}
+
+// updateInvoice removes the payments of the invoice which are no longer known
+// to the transaction store, for example because they were double spent, and
+// updates its status. The invoice is stored if it was modified by the caller
+// or here, and clients are notified if the status changed.
+//
+// The invoice stays open while it is neither expired nor fully paid, or while
+// any of the payments fully paying it is unmined and may still be double
+// spent.
+func (w *Wallet) updateInvoice(dbtx walletdb.ReadWriteTx, inv *Invoice,
+	now time.Time, modified bool) error {
+
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+	invoiceNs := dbtx.ReadWriteBucket(winvoiceNamespaceKey)
+
+	var unmined bool
+	payments := inv.Payments[:0]
+	for _, p := range inv.Payments {
+		details, err := w.TxStore.TxDetails(txmgrNs, &p.OutPoint.Hash)
+		if err != nil {
+			return err
+		}
+		if details != nil {
+			payments = append(payments, p)
+			unmined = unmined || details.Block.Height == -1
+		}
+	}
+	if len(payments) != len(inv.Payments) {
+		modified = true
+	}
+	inv.Payments = payments
+
+	prevStatus := inv.Status
+	inv.Status = inv.status(now)
+
+	var open bool
+	switch inv.Status {
+	case InvoiceStatusUnpaid, InvoiceStatusPartial:
+		open = true
+	case InvoiceStatusPaid, InvoiceStatusOverpaid:
+		open = unmined
+	}
+	if err := setInvoiceOpen(invoiceNs, inv, open); err != nil {
+		return err
+	}
+
+	if !modified && inv.Status == prevStatus {
+		return nil
+	}
+
+	if err := putInvoice(invoiceNs, inv); err != nil {
+		return err
+	}
+	if inv.Status != prevStatus {
+		w.NtfnServer.notifyInvoice(inv, prevStatus)
+	}
+	return nil
+}
+
+// addInvoicePayments records the outputs of the transaction paying to invoice
+// addresses as payments of these invoices.
+func (w *Wallet) addInvoicePayments(dbtx walletdb.ReadWriteTx,
+	rec *wtxmgr.TxRecord) error {
+
+	invoiceNs := dbtx.ReadBucket(winvoiceNamespaceKey)
+
+	now := time.Now()
+	invoices := make(map[uint64]*Invoice)
+	for i, output := range rec.MsgTx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			output.PkScript, w.chainParams,
+		)
+		if err != nil || len(addrs) != 1 {
+			continue
+		}
+
+		inv, err := fetchInvoiceByAddress(invoiceNs, addrs[0], w.chainParams)
+		if err != nil {
+			return err
+		}
+		if inv == nil {
+			continue
+		}
+		if known, ok := invoices[inv.ID]; ok {
+			inv = known
+		}
+
+		payment := InvoicePayment{
+			OutPoint: wire.OutPoint{Hash: rec.Hash, Index: uint32(i)},
+			Amount:   btcutil.Amount(output.Value),
+		}
+		var exists bool
+		for _, p := range inv.Payments {
+			if p.OutPoint == payment.OutPoint {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		inv.Payments = append(inv.Payments, payment)
+		invoices[inv.ID] = inv
+	}
+
+	for _, inv := range invoices {
+		log.Infof("Received payment for invoice %d in transaction %v",
+			inv.ID, rec.Hash)
+
+		if err := w.updateInvoice(dbtx, inv, now, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateInvoices updates the status of every open invoice, which may still
+// change status either because it can expire or because it has unmined
+// payments which may be removed from the transaction store.
+func (w *Wallet) updateInvoices(dbtx walletdb.ReadWriteTx) error {
+	invoiceNs := dbtx.ReadBucket(winvoiceNamespaceKey)
+
+	var invoices []*Invoice
+	err := forEachOpenInvoice(invoiceNs, w.chainParams, func(inv *Invoice) error {
+		invoices = append(invoices, inv)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, inv := range invoices {
+		if err := w.updateInvoice(dbtx, inv, now, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// minedInvoices returns the IDs of the invoices paid by transactions mined at
+// or above the height.  It is called before these blocks are rolled back, as
+// the invoices are closed once all their payments are mined, and must be
+// reopened by reopenInvoices once their payments are unmined again.
+func (w *Wallet) minedInvoices(dbtx walletdb.ReadTx, height int32) ([]uint64, error) {
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+	invoiceNs := dbtx.ReadBucket(winvoiceNamespaceKey)
+
+	var ids []uint64
+	seen := make(map[uint64]struct{})
+	addInvoice := func(output *wire.TxOut) error {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			output.PkScript, w.chainParams,
+		)
+		if err != nil || len(addrs) != 1 {
+			return nil
+		}
+		inv, err := fetchInvoiceByAddress(invoiceNs, addrs[0], w.chainParams)
+		if err != nil || inv == nil {
+			return err
+		}
+		if _, ok := seen[inv.ID]; !ok {
+			seen[inv.ID] = struct{}{}
+			ids = append(ids, inv.ID)
+		}
+		return nil
+	}
+
+	err := w.TxStore.RangeTransactions(txmgrNs, height, math.MaxInt32,
+		func(details []wtxmgr.TxDetails) (bool, error) {
+			for i := range details {
+				for _, output := range details[i].MsgTx.TxOut {
+					if err := addInvoice(output); err != nil {
+						return false, err
+					}
+				}
+			}
+			return false, nil
+		})
+	return ids, err
+}
+
+// reopenInvoices updates the invoices after their payments were unmined by a
+// reorg, which reopens them until their payments are mined again, so they are
+// updated if the payments are double spent meanwhile.
+func (w *Wallet) reopenInvoices(dbtx walletdb.ReadWriteTx, ids []uint64) error {
+	invoiceNs := dbtx.ReadBucket(winvoiceNamespaceKey)
+
+	now := time.Now()
+	for _, id := range ids {
+		inv, err := fetchInvoice(invoiceNs, id, w.chainParams)
+		if err != nil {
+			return err
+		}
+		if err := w.updateInvoice(dbtx, inv, now, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
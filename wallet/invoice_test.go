@@ -0,0 +1,397 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestInvoiceURI ensures invoices produce valid BIP21 URIs.
+func TestInvoiceURI(t *testing.T) {
+	t.Parallel()
+
+	addr, err := btcutil.DecodeAddress(
+		"mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", &chaincfg.TestNet3Params,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		amount btcutil.Amount
+		memo   string
+		uri    string
+	}{
+		{
+			name: "address only",
+			uri:  "bitcoin:mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn",
+		},
+		{
+			name:   "amount",
+			amount: 150000000,
+			uri:    "bitcoin:mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn?amount=1.5",
+		},
+		{
+			name:   "amount and memo",
+			amount: 1,
+			memo:   "Order #42 & co",
+			uri: "bitcoin:mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn?" +
+				"amount=0.00000001&message=Order%20%2342%20%26%20co",
+		},
+	}
+
+	for _, test := range tests {
+		inv := &Invoice{
+			Address: addr,
+			Amount:  test.amount,
+			Memo:    test.memo,
+		}
+		if uri := inv.URI(); uri != test.uri {
+			t.Errorf("%s: expected URI %q, got %q", test.name,
+				test.uri, uri)
+		}
+	}
+}
+
+// TestInvoiceStatus ensures the status of an invoice follows the payments to
+// its address, and that clients are notified of status changes.
+func TestInvoiceStatus(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	const amount = 100000
+	inv, err := w.CreateInvoice(
+		waddrmgr.KeyScopeBIP0084, 0, amount, "test", time.Hour,
+	)
+	if err != nil {
+		t.Fatalf("unable to create invoice: %v", err)
+	}
+	if inv.ID == 0 || inv.Status != InvoiceStatusUnpaid {
+		t.Fatalf("unexpected invoice: %+v", inv)
+	}
+
+	ntfns := w.NtfnServer.InvoiceNotifications()
+	defer ntfns.Done()
+
+	pkScript, err := txscript.PayToAddrScript(inv.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pay adds an unmined transaction paying the value to the invoice
+	// address, returning its record and the result of the database update.
+	// The update blocks until the notification is received.
+	var payments uint32
+	pay := func(value int64) (*wtxmgr.TxRecord, <-chan error) {
+		payments++
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{1},
+				Index: payments,
+			},
+		})
+		tx.AddTxOut(wire.NewTxOut(value, pkScript))
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+				return w.addRelevantTx(tx, rec, nil)
+			})
+		}()
+		return rec, errChan
+	}
+
+	assertNotification := func(status, prevStatus InvoiceStatus,
+		received btcutil.Amount) {
+
+		t.Helper()
+
+		select {
+		case n := <-ntfns.C:
+			if n.Invoice.ID != inv.ID ||
+				n.Invoice.Status != status ||
+				n.PreviousStatus != prevStatus ||
+				n.Invoice.Received() != received {
+
+				t.Fatalf("unexpected notification: %+v, "+
+					"invoice %+v", n, n.Invoice)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notification for status %v", status)
+		}
+	}
+
+	_, errChan := pay(amount / 2)
+	assertNotification(InvoiceStatusPartial, InvoiceStatusUnpaid, amount/2)
+	if err := <-errChan; err != nil {
+		t.Fatalf("unable to add payment: %v", err)
+	}
+
+	rec, errChan := pay(amount)
+	assertNotification(
+		InvoiceStatusOverpaid, InvoiceStatusPartial, amount/2+amount,
+	)
+	if err := <-errChan; err != nil {
+		t.Fatalf("unable to add payment: %v", err)
+	}
+
+	// Removing the second payment from the transaction store, as done for
+	// double spends, must revert the invoice to partially paid once the
+	// invoices are updated.
+	done := make(chan error, 1)
+	go func() {
+		done <- walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+			err := w.TxStore.RemoveUnminedTx(ns, rec)
+			if err != nil {
+				return err
+			}
+			return w.updateInvoices(tx)
+		})
+	}()
+	assertNotification(InvoiceStatusPartial, InvoiceStatusOverpaid, amount/2)
+	if err := <-done; err != nil {
+		t.Fatalf("unable to remove payment: %v", err)
+	}
+
+	fetched, err := w.Invoice(inv.ID)
+	if err != nil {
+		t.Fatalf("unable to fetch invoice: %v", err)
+	}
+	if fetched.Status != InvoiceStatusPartial ||
+		len(fetched.Payments) != 1 || fetched.Memo != "test" ||
+		fetched.Address.EncodeAddress() != inv.Address.EncodeAddress() {
+
+		t.Fatalf("unexpected invoice: %+v", fetched)
+	}
+
+	// An invoice which is not fully paid before its expiry is expired.
+	expiring, err := w.CreateInvoice(
+		waddrmgr.KeyScopeBIP0084, 0, amount, "", time.Nanosecond,
+	)
+	if err != nil {
+		t.Fatalf("unable to create invoice: %v", err)
+	}
+	invoices, err := w.Invoices()
+	if err != nil {
+		t.Fatalf("unable to list invoices: %v", err)
+	}
+	if len(invoices) != 2 || invoices[1].ID != expiring.ID ||
+		invoices[1].Status != InvoiceStatusExpired {
+
+		t.Fatalf("expected second invoice to be expired: %+v",
+			invoices[1])
+	}
+
+	if _, err := w.Invoice(expiring.ID + 1); err != ErrInvoiceNotFound {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+}
+
+// TestOpenInvoices ensures only invoices which may still change status are
+// updated when blocks are connected.
+func TestOpenInvoices(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	const amount = 100000
+	create := func(expiry time.Duration) *Invoice {
+		t.Helper()
+
+		inv, err := w.CreateInvoice(
+			waddrmgr.KeyScopeBIP0084, 0, amount, "", expiry,
+		)
+		if err != nil {
+			t.Fatalf("unable to create invoice: %v", err)
+		}
+		return inv
+	}
+	paid, expired, unpaid := create(time.Hour), create(time.Nanosecond),
+		create(0)
+
+	// Fully pay the first invoice in a mined transaction.
+	pkScript, err := txscript.PayToAddrScript(paid.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{Hash: chainhash.Hash{1}, Height: 100},
+		Time:  time.Now(),
+	}
+
+	var open []uint64
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		if err := w.addRelevantTx(tx, rec, block); err != nil {
+			return err
+		}
+		if err := w.updateInvoices(tx); err != nil {
+			return err
+		}
+
+		ns := tx.ReadBucket(winvoiceNamespaceKey)
+		return forEachOpenInvoice(ns, w.chainParams, func(inv *Invoice) error {
+			open = append(open, inv.ID)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("unable to update invoices: %v", err)
+	}
+	if len(open) != 1 || open[0] != unpaid.ID {
+		t.Fatalf("expected only invoice %d to be open, got %v "+
+			"(paid %d, expired %d)", unpaid.ID, open, paid.ID,
+			expired.ID)
+	}
+}
+
+// reorgChainClient is a mock chain client returning the headers of the blocks
+// a wallet rolls back to.
+type reorgChainClient struct {
+	mockChainClient
+}
+
+func (c *reorgChainClient) GetBlockHeader(*chainhash.Hash) (*wire.BlockHeader,
+	error) {
+
+	return &wire.BlockHeader{Timestamp: time.Now()}, nil
+}
+
+// TestInvoiceReorg ensures an invoice paid by a transaction whose block is
+// disconnected is reopened, so it's no longer paid if the payment is double
+// spent in the new chain.
+func TestInvoiceReorg(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+	w.chainClient = &reorgChainClient{}
+
+	const amount = 100000
+	inv, err := w.CreateInvoice(waddrmgr.KeyScopeBIP0084, 0, amount, "", 0)
+	if err != nil {
+		t.Fatalf("unable to create invoice: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(inv.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// spend returns a transaction spending the same output to the script.
+	spend := func(pkScript []byte) *wtxmgr.TxRecord {
+		t.Helper()
+
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{9}},
+		})
+		tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	block := func(hash byte, height int32) wtxmgr.BlockMeta {
+		return wtxmgr.BlockMeta{
+			Block: wtxmgr.Block{
+				Hash:   chainhash.Hash{hash},
+				Height: height,
+			},
+			Time: time.Now(),
+		}
+	}
+	update := func(f func(walletdb.ReadWriteTx) error) {
+		t.Helper()
+
+		if err := walletdb.Update(w.db, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertInvoice := func(status InvoiceStatus, open bool) {
+		t.Helper()
+
+		got, err := w.Invoice(inv.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != status {
+			t.Fatalf("got status %v, want %v", got.Status, status)
+		}
+		var isOpen bool
+		err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(winvoiceNamespaceKey)
+			return forEachOpenInvoice(ns, w.chainParams,
+				func(open *Invoice) error {
+					isOpen = isOpen || open.ID == inv.ID
+					return nil
+				})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isOpen != open {
+			t.Fatalf("got open %v, want %v", isOpen, open)
+		}
+	}
+
+	// The invoice is paid in block 100, which closes it.
+	paidIn := block(100, 100)
+	update(func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		for height := int32(1); height <= 100; height++ {
+			err := w.Manager.SetSyncedTo(ns, &waddrmgr.BlockStamp{
+				Height: height,
+				Hash:   chainhash.Hash{byte(height)},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return w.addRelevantTx(tx, spend(pkScript), &paidIn)
+	})
+	w.SetChainSynced(true)
+	assertInvoice(InvoiceStatusPaid, false)
+
+	// Disconnecting the block unmines the payment and reopens the invoice.
+	update(func(tx walletdb.ReadWriteTx) error {
+		return w.disconnectBlock(tx, paidIn)
+	})
+	assertInvoice(InvoiceStatusPaid, true)
+
+	// The payment is double spent in the new block 100, so the invoice is
+	// no longer paid.
+	doubleSpentIn := block(101, 100)
+	update(func(tx walletdb.ReadWriteTx) error {
+		err := w.addRelevantTx(tx, spend([]byte{0x51}), &doubleSpentIn)
+		if err != nil {
+			return err
+		}
+		return w.connectBlock(tx, doubleSpentIn)
+	})
+	assertInvoice(InvoiceStatusUnpaid, true)
+}
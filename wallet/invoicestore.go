@@ -0,0 +1,297 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The invoice namespace contains three buckets. The invoices bucket stores
+// every invoice keyed by its big endian ID, and the address bucket maps the
+// encoded address of each invoice to its ID. The open bucket maps the IDs of
+// the invoices whose status may still change without receiving a payment to
+// their status, so they can be updated without loading every invoice. Each
+// invoice is serialized as follows:
+//
+//   [0]       status
+//   [1:5]     key scope purpose (4 bytes)
+//   [5:9]     key scope coin type (4 bytes)
+//   [9:13]    account (4 bytes)
+//   [13:21]   amount in satoshis (8 bytes)
+//   [21:29]   creation time as unix seconds (8 bytes)
+//   [29:37]   expiry time as unix seconds (8 bytes)
+//   ...       address, as a 2 byte length followed by the encoded address
+//   ...       memo, as a 2 byte length followed by the memo
+//   ...       number of payments (4 bytes)
+//   ...       payments, each as a 32 byte hash, 4 byte index and 8 byte
+//             amount in satoshis
+//
+// All integers are encoded big endian. An expiry time of zero means the
+// invoice never expires.
+
+var (
+	// ErrInvoiceNotFound is returned when an invoice ID is not known to the
+	// wallet.
+	ErrInvoiceNotFound = errors.New("invoice not found")
+
+	// winvoiceNamespaceKey is the key of the top level bucket storing the
+	// invoices.
+	winvoiceNamespaceKey = []byte("winvoice")
+
+	invoicesBucketKey     = []byte("invoices")
+	invoiceAddrsBucketKey = []byte("addrs")
+	openInvoicesBucketKey = []byte("open")
+)
+
+// createInvoiceBuckets creates the buckets of the invoice namespace if they
+// don't exist yet.
+func createInvoiceBuckets(ns walletdb.ReadWriteBucket) error {
+	for _, k := range [][]byte{
+		invoicesBucketKey, invoiceAddrsBucketKey, openInvoicesBucketKey,
+	} {
+		if _, err := ns.CreateBucketIfNotExists(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func invoiceKey(id uint64) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], id)
+	return k[:]
+}
+
+func writeInvoiceString(w *bytes.Buffer, s string) {
+	var l [2]byte
+	binary.BigEndian.PutUint16(l[:], uint16(len(s)))
+	w.Write(l[:])
+	w.WriteString(s)
+}
+
+func readInvoiceString(r *bytes.Reader) (string, error) {
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return "", err
+	}
+	s := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func timeFromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+func serializeInvoice(inv *Invoice) []byte {
+	var b bytes.Buffer
+	var u32 [4]byte
+	var u64 [8]byte
+
+	putUint32 := func(v uint32) {
+		binary.BigEndian.PutUint32(u32[:], v)
+		b.Write(u32[:])
+	}
+	putUint64 := func(v uint64) {
+		binary.BigEndian.PutUint64(u64[:], v)
+		b.Write(u64[:])
+	}
+
+	b.WriteByte(byte(inv.Status))
+	putUint32(inv.KeyScope.Purpose)
+	putUint32(inv.KeyScope.Coin)
+	putUint32(inv.Account)
+	putUint64(uint64(inv.Amount))
+	putUint64(uint64(unixTime(inv.Created)))
+	putUint64(uint64(unixTime(inv.Expiry)))
+	writeInvoiceString(&b, inv.Address.EncodeAddress())
+	writeInvoiceString(&b, inv.Memo)
+
+	putUint32(uint32(len(inv.Payments)))
+	for _, p := range inv.Payments {
+		b.Write(p.OutPoint.Hash[:])
+		putUint32(p.OutPoint.Index)
+		putUint64(uint64(p.Amount))
+	}
+
+	return b.Bytes()
+}
+
+func deserializeInvoice(id uint64, v []byte,
+	chainParams *chaincfg.Params) (*Invoice, error) {
+
+	const headerSize = 1 + 3*4 + 3*8
+	if len(v) < headerSize {
+		return nil, fmt.Errorf("short invoice %d: %d bytes", id, len(v))
+	}
+
+	inv := &Invoice{
+		ID:     id,
+		Status: InvoiceStatus(v[0]),
+		KeyScope: waddrmgr.KeyScope{
+			Purpose: binary.BigEndian.Uint32(v[1:5]),
+			Coin:    binary.BigEndian.Uint32(v[5:9]),
+		},
+		Account: binary.BigEndian.Uint32(v[9:13]),
+		Amount:  btcutil.Amount(binary.BigEndian.Uint64(v[13:21])),
+		Created: timeFromUnix(int64(binary.BigEndian.Uint64(v[21:29]))),
+		Expiry:  timeFromUnix(int64(binary.BigEndian.Uint64(v[29:37]))),
+	}
+
+	r := bytes.NewReader(v[headerSize:])
+	encodedAddr, err := readInvoiceString(r)
+	if err != nil {
+		return nil, err
+	}
+	inv.Address, err = btcutil.DecodeAddress(encodedAddr, chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode address of invoice "+
+			"%d: %v", id, err)
+	}
+	if inv.Memo, err = readInvoiceString(r); err != nil {
+		return nil, err
+	}
+
+	var u32 [4]byte
+	var u64 [8]byte
+	if _, err := io.ReadFull(r, u32[:]); err != nil {
+		return nil, err
+	}
+	numPayments := binary.BigEndian.Uint32(u32[:])
+	inv.Payments = make([]InvoicePayment, 0, numPayments)
+	for i := uint32(0); i < numPayments; i++ {
+		var p InvoicePayment
+		if _, err := io.ReadFull(r, p.OutPoint.Hash[:]); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, u32[:]); err != nil {
+			return nil, err
+		}
+		p.OutPoint.Index = binary.BigEndian.Uint32(u32[:])
+		if _, err := io.ReadFull(r, u64[:]); err != nil {
+			return nil, err
+		}
+		p.Amount = btcutil.Amount(binary.BigEndian.Uint64(u64[:]))
+		inv.Payments = append(inv.Payments, p)
+	}
+
+	return inv, nil
+}
+
+// putInvoice stores the invoice and indexes it by its address. If the invoice
+// does not have an ID yet, a new one is assigned from the bucket sequence.
+func putInvoice(ns walletdb.ReadWriteBucket, inv *Invoice) error {
+	invoices := ns.NestedReadWriteBucket(invoicesBucketKey)
+	if inv.ID == 0 {
+		id, err := invoices.NextSequence()
+		if err != nil {
+			return err
+		}
+		inv.ID = id
+	}
+
+	err := invoices.Put(invoiceKey(inv.ID), serializeInvoice(inv))
+	if err != nil {
+		return err
+	}
+
+	addrs := ns.NestedReadWriteBucket(invoiceAddrsBucketKey)
+	return addrs.Put([]byte(inv.Address.EncodeAddress()), invoiceKey(inv.ID))
+}
+
+// setInvoiceOpen adds the invoice to or removes it from the index of open
+// invoices.
+func setInvoiceOpen(ns walletdb.ReadWriteBucket, inv *Invoice, open bool) error {
+	openInvoices := ns.NestedReadWriteBucket(openInvoicesBucketKey)
+	k := invoiceKey(inv.ID)
+	v := openInvoices.Get(k)
+	switch {
+	case !open && v != nil:
+		return openInvoices.Delete(k)
+	case open && (len(v) == 0 || v[0] != byte(inv.Status)):
+		return openInvoices.Put(k, []byte{byte(inv.Status)})
+	default:
+		return nil
+	}
+}
+
+// fetchInvoice returns the invoice with the given ID, or ErrInvoiceNotFound if
+// it doesn't exist.
+func fetchInvoice(ns walletdb.ReadBucket, id uint64,
+	chainParams *chaincfg.Params) (*Invoice, error) {
+
+	v := ns.NestedReadBucket(invoicesBucketKey).Get(invoiceKey(id))
+	if v == nil {
+		return nil, ErrInvoiceNotFound
+	}
+	return deserializeInvoice(id, v, chainParams)
+}
+
+// fetchInvoiceByAddress returns the invoice paid to by the address, or nil if
+// there is none.
+func fetchInvoiceByAddress(ns walletdb.ReadBucket, addr btcutil.Address,
+	chainParams *chaincfg.Params) (*Invoice, error) {
+
+	k := ns.NestedReadBucket(invoiceAddrsBucketKey).Get(
+		[]byte(addr.EncodeAddress()),
+	)
+	if k == nil {
+		return nil, nil
+	}
+	return fetchInvoice(ns, binary.BigEndian.Uint64(k), chainParams)
+}
+
+// forEachInvoice calls f with every invoice, ordered by ID.
+func forEachInvoice(ns walletdb.ReadBucket, chainParams *chaincfg.Params,
+	f func(*Invoice) error) error {
+
+	return ns.NestedReadBucket(invoicesBucketKey).ForEach(func(k, v []byte) error {
+		inv, err := deserializeInvoice(
+			binary.BigEndian.Uint64(k), v, chainParams,
+		)
+		if err != nil {
+			return err
+		}
+		return f(inv)
+	})
+}
+
+// forEachOpenInvoice calls f with every open invoice, ordered by ID.
+func forEachOpenInvoice(ns walletdb.ReadBucket, chainParams *chaincfg.Params,
+	f func(*Invoice) error) error {
+
+	openInvoices := ns.NestedReadBucket(openInvoicesBucketKey)
+	return openInvoices.ForEach(func(k, _ []byte) error {
+		inv, err := fetchInvoice(
+			ns, binary.BigEndian.Uint64(k), chainParams,
+		)
+		if err != nil {
+			return err
+		}
+		return f(inv)
+	})
+}
@@ -16,7 +16,10 @@ import (
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/internal/prompt"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/cryptdb"
 	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/walletdb/migration"
+	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
 const (
@@ -26,6 +29,21 @@ const (
 	// DefaultDBTimeout is the default timeout value when opening the wallet
 	// database.
 	DefaultDBTimeout = 60 * time.Second
+
+	// BoltDBDriver is the walletdb driver of wallet databases backed by
+	// bbolt, registered by the walletdb/bdb package.  It is the default
+	// driver of the loader.
+	BoltDBDriver = "bdb"
+
+	// SQLiteDBDriver is the walletdb driver of wallet databases backed by
+	// SQLite, registered by the walletdb/sqlite package.
+	SQLiteDBDriver = "sqlite"
+
+	// MemDBDriver is the walletdb driver of wallet databases kept in
+	// memory, registered by the walletdb/memdb package.  Nothing is written
+	// to the loader's directory, so a wallet created with the driver is
+	// lost once it's unloaded or the process exits, and can't be opened.
+	MemDBDriver = "memdb"
 )
 
 var (
@@ -53,6 +71,9 @@ type Loader struct {
 	callbacks      []func(*Wallet)
 	chainParams    *chaincfg.Params
 	dbDirPath      string
+	dbDriver       string
+	encryptDB      bool
+	wrapDB         func(walletdb.DB) walletdb.DB
 	noFreelistSync bool
 	timeout        time.Duration
 	recoveryWindow uint32
@@ -74,6 +95,7 @@ func NewLoader(chainParams *chaincfg.Params, dbDirPath string,
 	return &Loader{
 		chainParams:    chainParams,
 		dbDirPath:      dbDirPath,
+		dbDriver:       BoltDBDriver,
 		noFreelistSync: noFreelistSync,
 		timeout:        timeout,
 		recoveryWindow: recoveryWindow,
@@ -105,6 +127,50 @@ func NewLoaderWithDB(chainParams *chaincfg.Params, recoveryWindow uint32,
 	}, nil
 }
 
+// SetDBDriver sets the walletdb driver used to create and open the wallet
+// database, which is BoltDBDriver by default.  The package of the driver must
+// be imported by the caller to register it.  This must be called before the
+// wallet is created or opened.
+func (l *Loader) SetDBDriver(dbDriver string) {
+	l.mu.Lock()
+	l.dbDriver = dbDriver
+	l.mu.Unlock()
+}
+
+// SetDBEncryption sets whether the loader encrypts the wallet database with a
+// key derived from the public passphrase.  If set, new wallet databases are
+// encrypted, and unencrypted wallet databases are replaced by an encrypted copy
+// when opened.  Encrypted wallet databases are always opened with the public
+// passphrase, regardless of this setting.  This must be called before the
+// wallet is created or opened.
+func (l *Loader) SetDBEncryption(encrypt bool) {
+	l.mu.Lock()
+	l.encryptDB = encrypt
+	l.mu.Unlock()
+}
+
+// SetDBWrapper sets a function wrapping the wallet database once it's created
+// or opened, and decrypted if it's encrypted, before the wallet is loaded from
+// it.  This is useful to instrument the database transactions of the wallet.
+// This must be called before the wallet is created or opened.
+func (l *Loader) SetDBWrapper(wrap func(walletdb.DB) walletdb.DB) {
+	l.mu.Lock()
+	l.wrapDB = wrap
+	l.mu.Unlock()
+}
+
+// dbArgs returns the walletdb arguments used to create or open the wallet
+// database with the loader's driver.
+func (l *Loader) dbArgs(dbPath string) []interface{} {
+	switch l.dbDriver {
+	case SQLiteDBDriver:
+		return []interface{}{dbPath, l.timeout}
+	case MemDBDriver:
+		return nil
+	}
+	return []interface{}{dbPath, l.noFreelistSync, l.timeout}
+}
+
 // onLoaded executes each added callback and prevents loader from loading any
 // additional wallets.  Requires mutex to be locked.
 func (l *Loader) onLoaded(w *Wallet) {
@@ -218,18 +284,30 @@ func (l *Loader) createNewWallet(pubPassphrase, privPassphrase []byte,
 	if l.localDB {
 		dbPath := filepath.Join(l.dbDirPath, WalletDBName)
 
-		// Create the wallet database backed by bolt db.
-		err = os.MkdirAll(l.dbDirPath, 0700)
+		// Create the wallet database with the loader's driver.
+		if l.dbDriver != MemDBDriver {
+			err = os.MkdirAll(l.dbDirPath, 0700)
+			if err != nil {
+				return nil, err
+			}
+		}
+		l.db, err = walletdb.Create(l.dbDriver, l.dbArgs(dbPath)...)
 		if err != nil {
 			return nil, err
 		}
-		l.db, err = walletdb.Create(
-			"bdb", dbPath, l.noFreelistSync, l.timeout,
+	}
+
+	if l.encryptDB {
+		l.db, err = cryptdb.Encrypt(
+			l.db, pubPassphrase, &waddrmgr.DefaultScryptOptions,
 		)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if l.wrapDB != nil {
+		l.db = l.wrapDB(l.db)
+	}
 
 	// Initialize the newly created database for the wallet before opening.
 	if isWatchingOnly {
@@ -280,17 +358,20 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 	}
 
 	if l.localDB {
+		if l.dbDriver == MemDBDriver {
+			return nil, errors.New("wallets of the memdb driver " +
+				"can't be opened")
+		}
+
 		var err error
 		// Ensure that the network directory exists.
 		if err = checkCreateDir(l.dbDirPath); err != nil {
 			return nil, err
 		}
 
-		// Open the database using the boltdb backend.
+		// Open the database using the loader's driver.
 		dbPath := filepath.Join(l.dbDirPath, WalletDBName)
-		l.db, err = walletdb.Open(
-			"bdb", dbPath, l.noFreelistSync, l.timeout,
-		)
+		l.db, err = walletdb.Open(l.dbDriver, l.dbArgs(dbPath)...)
 		if err != nil {
 			log.Errorf("Failed to open database: %v", err)
 			return nil, err
@@ -309,7 +390,18 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 			ObtainPrivatePass: noConsole,
 		}
 	}
-	w, err := Open(l.db, pubPassphrase, cbs, l.chainParams, l.recoveryWindow)
+	db, err := l.openCryptDB(pubPassphrase, cbs)
+	var w *Wallet
+	if err == nil {
+		l.db = db
+		if l.wrapDB != nil {
+			l.db = l.wrapDB(l.db)
+		}
+		w, err = Open(
+			l.db, pubPassphrase, cbs, l.chainParams,
+			l.recoveryWindow,
+		)
+	}
 	if err != nil {
 		// If opening the wallet fails (e.g. because of wrong
 		// passphrase), we must close the backing database to
@@ -329,10 +421,142 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 	return w, nil
 }
 
-// WalletExists returns whether a file exists at the loader's database path.
-// This may return an error for unexpected I/O failures.
+// openCryptDB returns the wallet database decrypted with the public passphrase
+// if it's encrypted, after backing it up if it needs to be upgraded.  If the
+// loader encrypts wallet databases, an unencrypted database is encrypted once
+// the wallet is opened, which verifies the public passphrase and upgrades the
+// database.
+func (l *Loader) openCryptDB(pubPassphrase []byte,
+	cbs *waddrmgr.OpenCallbacks) (walletdb.DB, error) {
+
+	encrypted, err := cryptdb.IsEncrypted(l.db)
+	if err != nil {
+		return nil, err
+	}
+	db := l.db
+	if encrypted {
+		db, err = cryptdb.Open(l.db, pubPassphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := l.backupBeforeUpgrade(db); err != nil {
+		return nil, err
+	}
+	if encrypted || !l.encryptDB {
+		return db, nil
+	}
+
+	w, err := Open(l.db, pubPassphrase, cbs, l.chainParams, l.recoveryWindow)
+	if err != nil {
+		return nil, err
+	}
+	w.Manager.Close()
+
+	log.Infof("Encrypting wallet database")
+	return l.encryptDBFile(pubPassphrase)
+}
+
+// encryptDBFile encrypts the loader's unencrypted wallet database by writing
+// an encrypted copy next to it, which then replaces the database file.
+// Encrypting the file in place would leave the cleartext in its free pages.
+// The encrypted database is returned, opened with the public passphrase.
+func (l *Loader) encryptDBFile(pubPassphrase []byte) (walletdb.DB, error) {
+	if !l.localDB {
+		return nil, errors.New("only wallet databases opened from the " +
+			"loader's directory can be encrypted")
+	}
+
+	dbPath := filepath.Join(l.dbDirPath, WalletDBName)
+	tmpPath := dbPath + ".encrypt"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	dst, err := walletdb.Create(l.dbDriver, l.dbArgs(tmpPath)...)
+	if err != nil {
+		return nil, err
+	}
+	cdb, err := cryptdb.EncryptCopy(
+		dst, l.db, pubPassphrase, &waddrmgr.DefaultScryptOptions,
+	)
+	if err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := cdb.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	if err := l.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return nil, err
+	}
+	db, err := walletdb.Open(l.dbDriver, l.dbArgs(dbPath)...)
+	if err != nil {
+		return nil, err
+	}
+	l.db = db
+	return cryptdb.Open(db, pubPassphrase)
+}
+
+// backupBeforeUpgrade writes a copy of the wallet database next to it if the
+// address or transaction manager has migrations to apply when the wallet is
+// opened, so that the database can be restored if the upgrade fails or the
+// previous release needs to be run again.  Databases not opened by the loader
+// aren't backed up.
+func (l *Loader) backupBeforeUpgrade(db walletdb.DB) error {
+	if !l.localDB {
+		return nil
+	}
+
+	var pending bool
+	err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+		// Missing namespaces are reported when opening the wallet.
+		addrMgrBucket := tx.ReadBucket(waddrmgrNamespaceKey)
+		txMgrBucket := tx.ReadBucket(wtxmgrNamespaceKey)
+		if addrMgrBucket == nil || txMgrBucket == nil {
+			return nil
+		}
+
+		addrMgrVersions, err := migration.Pending(
+			waddrmgr.NewMigrationManager(nil), addrMgrBucket,
+		)
+		if err != nil {
+			return err
+		}
+		txMgrVersions, err := migration.Pending(
+			wtxmgr.NewMigrationManager(nil), txMgrBucket,
+		)
+		if err != nil {
+			return err
+		}
+		pending = len(addrMgrVersions) != 0 || len(txMgrVersions) != 0
+		return nil
+	})
+	if err != nil || !pending {
+		return err
+	}
+
+	backupPath := filepath.Join(l.dbDirPath, fmt.Sprintf("%s.%s.bak",
+		WalletDBName, time.Now().Format("20060102150405")))
+	log.Infof("Backing up wallet database to %v before upgrading",
+		backupPath)
+	return migration.Backup(db, backupPath)
+}
+
+// WalletExists returns whether a file exists at the loader's database path,
+// which is never the case for the MemDBDriver.  This may return an error for
+// unexpected I/O failures.
 func (l *Loader) WalletExists() (bool, error) {
 	if l.localDB {
+		if l.dbDriver == MemDBDriver {
+			return false, nil
+		}
 		dbPath := filepath.Join(l.dbDirPath, WalletDBName)
 		return fileExists(dbPath)
 	}
@@ -0,0 +1,431 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/cryptdb"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/sqlite"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestLoaderSQLite tests that a wallet created with the SQLite driver can be
+// reopened with the same addresses.
+func TestLoaderSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_wallet_sqlite")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.MinSeedBytes)
+	if err != nil {
+		t.Fatalf("unable to create seed: %v", err)
+	}
+	pubPass := []byte("hello")
+	privPass := []byte("world")
+
+	loader := NewLoader(
+		&chaincfg.TestNet3Params, dir, true, defaultDBTimeout, 250,
+	)
+	loader.SetDBDriver(SQLiteDBDriver)
+	w, err := loader.CreateNewWallet(pubPass, privPass, seed, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	w.chainClient = &mockChainClient{}
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	loader = NewLoader(
+		&chaincfg.TestNet3Params, dir, true, defaultDBTimeout, 250,
+	)
+	loader.SetDBDriver(SQLiteDBDriver)
+	w, err = loader.OpenExistingWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	defer loader.UnloadWallet()
+	w.chainClient = &mockChainClient{}
+
+	current, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to get current address: %v", err)
+	}
+	if current.EncodeAddress() != addr.EncodeAddress() {
+		t.Fatalf("current address %v, want %v", current, addr)
+	}
+}
+
+// TestLoaderMemDB tests that a wallet can be created in an in-memory database,
+// and opened from an export of that database.
+func TestLoaderMemDB(t *testing.T) {
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.MinSeedBytes)
+	if err != nil {
+		t.Fatalf("unable to create seed: %v", err)
+	}
+	pubPass := []byte("hello")
+	privPass := []byte("world")
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	defer db.Close()
+	loader, err := NewLoaderWithDB(
+		&chaincfg.TestNet3Params, 250, db, func() (bool, error) {
+			return false, nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unable to create loader: %v", err)
+	}
+	w, err := loader.CreateNewWallet(pubPass, privPass, seed, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	w.chainClient = &mockChainClient{}
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := db.Copy(&buf); err != nil {
+		t.Fatalf("unable to export db: %v", err)
+	}
+	importedDB, err := walletdb.Open("memdb", &buf)
+	if err != nil {
+		t.Fatalf("unable to import db: %v", err)
+	}
+	defer importedDB.Close()
+	loader, err = NewLoaderWithDB(
+		&chaincfg.TestNet3Params, 250, importedDB,
+		func() (bool, error) {
+			return true, nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("unable to create loader: %v", err)
+	}
+	w, err = loader.OpenExistingWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	defer loader.UnloadWallet()
+	w.chainClient = &mockChainClient{}
+
+	current, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to get current address: %v", err)
+	}
+	if current.EncodeAddress() != addr.EncodeAddress() {
+		t.Fatalf("current address %v, want %v", current, addr)
+	}
+}
+
+// TestLoaderMemDBDriver tests that a wallet created with the memdb driver
+// leaves no database in the loader's directory and can't be opened again.
+func TestLoaderMemDBDriver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_wallet_memdb")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dbDir := filepath.Join(dir, "wallet")
+
+	pubPass := []byte("hello")
+	privPass := []byte("world")
+
+	loader := NewLoader(
+		&chaincfg.TestNet3Params, dbDir, true, defaultDBTimeout, 250,
+	)
+	loader.SetDBDriver(MemDBDriver)
+	w, err := loader.CreateNewWallet(pubPass, privPass, nil, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	w.chainClient = &mockChainClient{}
+	if _, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084); err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	if _, err := os.Stat(dbDir); !os.IsNotExist(err) {
+		t.Fatalf("wallet directory was created: %v", err)
+	}
+	exists, err := loader.WalletExists()
+	if err != nil {
+		t.Fatalf("unable to check wallet existence: %v", err)
+	}
+	if exists {
+		t.Fatalf("memdb wallet reported to exist")
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	if _, err := loader.OpenExistingWallet(pubPass, false); err == nil {
+		t.Fatalf("expected error opening memdb wallet")
+	}
+}
+
+// TestLoaderEncryptDB tests that an existing wallet database is replaced by a
+// copy encrypted with the public passphrase, and that it follows changes of the
+// public passphrase.
+func TestLoaderEncryptDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_wallet_encryptdb")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.MinSeedBytes)
+	if err != nil {
+		t.Fatalf("unable to create seed: %v", err)
+	}
+	pubPass := []byte("hello")
+	privPass := []byte("world")
+
+	loader := NewLoader(
+		&chaincfg.TestNet3Params, dir, true, defaultDBTimeout, 250,
+	)
+	w, err := loader.CreateNewWallet(pubPass, privPass, seed, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	w.chainClient = &mockChainClient{}
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	// openWallet opens the wallet, which is encrypted if encryptDB is set,
+	// and checks its current address.
+	openWallet := func(pubPass []byte, encryptDB bool) (*Loader, error) {
+		loader := NewLoader(
+			&chaincfg.TestNet3Params, dir, true, defaultDBTimeout,
+			250,
+		)
+		loader.SetDBEncryption(encryptDB)
+		w, err := loader.OpenExistingWallet(pubPass, false)
+		if err != nil {
+			return nil, err
+		}
+		w.chainClient = &mockChainClient{}
+
+		current, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+		if err != nil {
+			loader.UnloadWallet()
+			return nil, err
+		}
+		if current.EncodeAddress() != addr.EncodeAddress() {
+			loader.UnloadWallet()
+			return nil, fmt.Errorf("current address %v, want %v",
+				current, addr)
+		}
+		return loader, nil
+	}
+
+	// isEncrypted returns whether the wallet database is encrypted.
+	isEncrypted := func() bool {
+		db, err := walletdb.Open(
+			BoltDBDriver, filepath.Join(dir, WalletDBName), true,
+			defaultDBTimeout,
+		)
+		if err != nil {
+			t.Fatalf("unable to open db: %v", err)
+		}
+		defer db.Close()
+		encrypted, err := cryptdb.IsEncrypted(db)
+		if err != nil {
+			t.Fatalf("unable to check db encryption: %v", err)
+		}
+		return encrypted
+	}
+
+	// The database isn't encrypted with a wrong public passphrase.
+	_, err = openWallet([]byte("wrong"), true)
+	if !waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+	if isEncrypted() {
+		t.Fatal("wallet db encrypted with wrong passphrase")
+	}
+
+	loader, err = openWallet(pubPass, true)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+	if !isEncrypted() {
+		t.Fatal("wallet db not encrypted")
+	}
+
+	// The cleartext of the database doesn't remain in the free pages of
+	// the file.
+	dbBytes, err := ioutil.ReadFile(filepath.Join(dir, WalletDBName))
+	if err != nil {
+		t.Fatalf("unable to read db: %v", err)
+	}
+	if bytes.Contains(dbBytes, waddrmgrNamespaceKey) {
+		t.Fatal("encrypted wallet db contains cleartext")
+	}
+
+	// The encrypted database is opened with the new public passphrase
+	// once changed, whether or not the loader encrypts databases.
+	loader, err = openWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open encrypted wallet: %v", err)
+	}
+	w, _ = loader.LoadedWallet()
+	newPubPass := []byte("hello2")
+	if err := w.ChangePublicPassphrase(pubPass, newPubPass); err != nil {
+		t.Fatalf("unable to change public passphrase: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	_, err = openWallet(pubPass, false)
+	if !waddrmgr.IsError(err, waddrmgr.ErrWrongPassphrase) {
+		t.Fatalf("expected ErrWrongPassphrase, got %v", err)
+	}
+	loader, err = openWallet(newPubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open encrypted wallet: %v", err)
+	}
+	loader.UnloadWallet()
+}
+
+// TestLoaderUpgradeBackup tests that the loader backs up the wallet database
+// before upgrading it, and only then.
+func TestLoaderUpgradeBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_wallet_upgradebackup")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.MinSeedBytes)
+	if err != nil {
+		t.Fatalf("unable to create seed: %v", err)
+	}
+	pubPass := []byte("hello")
+	privPass := []byte("world")
+
+	newLoader := func() *Loader {
+		return NewLoader(
+			&chaincfg.TestNet3Params, dir, true, defaultDBTimeout,
+			250,
+		)
+	}
+	loader := newLoader()
+	_, err = loader.CreateNewWallet(pubPass, privPass, seed, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	// backups returns the backups of the wallet database.
+	backups := func() []string {
+		matches, err := filepath.Glob(
+			filepath.Join(dir, WalletDBName+".*.bak"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return matches
+	}
+
+	// Opening an up to date wallet doesn't back it up.
+	loader = newLoader()
+	if _, err := loader.OpenExistingWallet(pubPass, false); err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+	if len(backups()) != 0 {
+		t.Fatalf("unexpected backups %v", backups())
+	}
+
+	// Downgrade the transaction manager so it's upgraded when the wallet
+	// is opened.
+	dbPath := filepath.Join(dir, WalletDBName)
+	db, err := walletdb.Open(BoltDBDriver, dbPath, true, defaultDBTimeout)
+	if err != nil {
+		t.Fatalf("unable to open db: %v", err)
+	}
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return wtxmgr.NewMigrationManager(ns).SetVersion(ns, 1)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("unable to downgrade db: %v", err)
+	}
+
+	loader = newLoader()
+	if _, err := loader.OpenExistingWallet(pubPass, false); err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+	matches := backups()
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 backup, got %v", matches)
+	}
+
+	// The backup holds the database before the upgrade.
+	backup, err := walletdb.Open(
+		BoltDBDriver, matches[0], true, defaultDBTimeout,
+	)
+	if err != nil {
+		t.Fatalf("unable to open backup: %v", err)
+	}
+	defer backup.Close()
+	err = walletdb.View(backup, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmgrNamespaceKey)
+		version, err := wtxmgr.NewMigrationManager(nil).
+			CurrentVersion(ns)
+		if err != nil {
+			return err
+		}
+		if version != 1 {
+			return fmt.Errorf("backup version %d, want 1", version)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
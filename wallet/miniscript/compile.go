@@ -0,0 +1,185 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// ErrTapscriptUnsupported is returned when a policy is compiled to a tapscript.
+var ErrTapscriptUnsupported = errors.New("tapscript compilation is not " +
+	"supported")
+
+// compiler builds the witness script of a policy.
+type compiler struct {
+	b *txscript.ScriptBuilder
+
+	// ops is the number of opcodes in the script counting towards the
+	// limit of txscript.MaxOpsPerScript.
+	ops int
+
+	// key returns the serialized public key of a key name.
+	key func(name string) ([]byte, error)
+}
+
+// op adds a non-push opcode to the script.
+func (c *compiler) op(op byte) {
+	c.b.AddOp(op)
+	c.ops++
+}
+
+// compile adds the script of the node.  If verify is set, the script fails
+// unless the node is satisfied and leaves nothing on the stack, otherwise it
+// leaves a true value on the stack if the node is satisfied.
+func (c *compiler) compile(n *node, verify bool) error {
+	switch n.frag {
+	case fragPk:
+		key, err := c.key(n.key)
+		if err != nil {
+			return err
+		}
+		c.b.AddData(key)
+		if verify {
+			c.op(txscript.OP_CHECKSIGVERIFY)
+		} else {
+			c.op(txscript.OP_CHECKSIG)
+		}
+
+	case fragOlder, fragAfter:
+		c.b.AddInt64(int64(n.value))
+		if n.frag == fragOlder {
+			c.op(txscript.OP_CHECKSEQUENCEVERIFY)
+		} else {
+			c.op(txscript.OP_CHECKLOCKTIMEVERIFY)
+		}
+		if verify {
+			c.op(txscript.OP_VERIFY)
+		}
+
+	case fragSha256, fragHash160:
+		c.op(txscript.OP_SIZE)
+		c.b.AddInt64(preimageSize)
+		c.op(txscript.OP_EQUALVERIFY)
+		if n.frag == fragSha256 {
+			c.op(txscript.OP_SHA256)
+		} else {
+			c.op(txscript.OP_HASH160)
+		}
+		c.b.AddData(n.hash)
+		c.equal(verify)
+
+	case fragAnd:
+		if err := c.compile(n.subs[0], true); err != nil {
+			return err
+		}
+		return c.compile(n.subs[1], verify)
+
+	case fragOr:
+		c.op(txscript.OP_IF)
+		if err := c.compile(n.subs[0], verify); err != nil {
+			return err
+		}
+		c.op(txscript.OP_ELSE)
+		if err := c.compile(n.subs[1], verify); err != nil {
+			return err
+		}
+		c.op(txscript.OP_ENDIF)
+
+	case fragThresh:
+		// The result of each policy, one if satisfied and zero
+		// otherwise, is kept on the alt stack while the next policy
+		// is evaluated, and the results are summed up.
+		for i, sub := range n.subs {
+			if i > 0 {
+				c.op(txscript.OP_TOALTSTACK)
+			}
+			if err := c.compileDissatisfiable(sub); err != nil {
+				return err
+			}
+			if i > 0 {
+				c.op(txscript.OP_FROMALTSTACK)
+				c.op(txscript.OP_ADD)
+			}
+		}
+		c.b.AddInt64(int64(n.value))
+		c.equal(verify)
+	}
+
+	return nil
+}
+
+// compileDissatisfiable adds the script of a node which leaves one on the stack
+// if the node is satisfied, or zero if it is dissatisfied.  Key checks already
+// leave zero when given an empty signature, while other nodes are selected
+// with an OP_IF.
+func (c *compiler) compileDissatisfiable(n *node) error {
+	if n.frag == fragPk {
+		return c.compile(n, false)
+	}
+	c.op(txscript.OP_IF)
+	if err := c.compile(n, true); err != nil {
+		return err
+	}
+	c.b.AddOp(txscript.OP_1)
+	c.op(txscript.OP_ELSE)
+	c.b.AddOp(txscript.OP_0)
+	c.op(txscript.OP_ENDIF)
+	return nil
+}
+
+func (c *compiler) equal(verify bool) {
+	if verify {
+		c.op(txscript.OP_EQUALVERIFY)
+	} else {
+		c.op(txscript.OP_EQUAL)
+	}
+}
+
+// script compiles the policy with the given key lookup function.
+func (p *Policy) script(key func(name string) ([]byte, error)) ([]byte,
+	error) {
+
+	c := &compiler{b: txscript.NewScriptBuilder(), key: key}
+	if err := c.compile(p.root, false); err != nil {
+		return nil, err
+	}
+	script, err := c.b.Script()
+	if err != nil {
+		return nil, err
+	}
+	if len(script) > txscript.MaxScriptSize {
+		return nil, fmt.Errorf("witness script of %d bytes exceeds "+
+			"the maximum of %d", len(script), txscript.MaxScriptSize)
+	}
+	if c.ops > txscript.MaxOpsPerScript {
+		return nil, fmt.Errorf("witness script has %d opcodes, more "+
+			"than the maximum of %d", c.ops, txscript.MaxOpsPerScript)
+	}
+	return script, nil
+}
+
+// Compile returns the witness script of the policy, with the public key of
+// each key name of the policy.
+func (p *Policy) Compile(keys map[string]*btcec.PublicKey) ([]byte, error) {
+	return p.script(func(name string) ([]byte, error) {
+		key, ok := keys[name]
+		if !ok {
+			return nil, fmt.Errorf("no public key for key %q", name)
+		}
+		return key.SerializeCompressed(), nil
+	})
+}
+
+// CompileTapscript would return the tapscript leaf of the policy.  Tapscripts
+// use OP_CHECKSIGADD for thresholds and x-only keys, and their spends can't be
+// verified or signed with the txscript package, so ErrTapscriptUnsupported is
+// always returned.
+func (p *Policy) CompileTapscript(map[string]*btcec.PublicKey) ([]byte, error) {
+	return nil, ErrTapscriptUnsupported
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package miniscript compiles spending policies, such as
+// or(pk(A),and(pk(B),older(52560))), to witness scripts and produces the
+// witnesses satisfying them.
+//
+// A policy is made up of the following fragments:
+//
+//	pk(NAME)            a signature by the key NAME
+//	older(N)            a relative lock time of N, as used in input sequences
+//	after(N)            an absolute lock time of N, as used in transactions
+//	sha256(H)           a 32 byte preimage of the hex encoded SHA256 hash H
+//	hash160(H)          a 32 byte preimage of the hex encoded hash160 H
+//	and(X,Y)            both X and Y
+//	or(X,Y)             either X or Y
+//	thresh(K,X1,...,Xn) at least K of the n policies
+//
+// The arguments of or may be prefixed with a probability, as in
+// or(9@pk(A),pk(B)).  Probabilities are accepted for compatibility with other
+// policy compilers, but don't change the compiled script.
+//
+// Policies are compiled to scripts spent with a P2WSH witness.  Each or
+// fragment is compiled to an OP_IF, so the witness selects the branches of the
+// policy that are satisfied, and each fragment of a thresh that isn't a key is
+// wrapped in an OP_IF so it can be left unsatisfied.
+//
+// Tapscript compilation is not supported, as the txscript package can neither
+// verify nor sign taproot spends.  CompileTapscript always returns
+// ErrTapscriptUnsupported.
+package miniscript
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// fragment identifies the kind of a policy node.
+type fragment uint8
+
+const (
+	fragPk fragment = iota
+	fragOlder
+	fragAfter
+	fragSha256
+	fragHash160
+	fragAnd
+	fragOr
+	fragThresh
+)
+
+// fragmentNames maps the name of each fragment in a policy to the fragment.
+var fragmentNames = map[string]fragment{
+	"pk":      fragPk,
+	"older":   fragOlder,
+	"after":   fragAfter,
+	"sha256":  fragSha256,
+	"hash160": fragHash160,
+	"and":     fragAnd,
+	"or":      fragOr,
+	"thresh":  fragThresh,
+}
+
+// node is a fragment of a parsed policy.
+type node struct {
+	frag fragment
+
+	// key is the name of the key of a pk fragment.
+	key string
+
+	// value is the lock time of an older or after fragment, or the
+	// threshold of a thresh fragment.
+	value uint32
+
+	// hash is the hash of a sha256 or hash160 fragment.
+	hash []byte
+
+	// subs are the policies combined by an and, or or thresh fragment.
+	subs []*node
+
+	// weights are the probabilities of the policies of an or fragment, or
+	// zero if none was given.
+	weights []uint32
+}
+
+// Policy is a parsed spending policy.
+type Policy struct {
+	root *node
+}
+
+// ParsePolicy parses a spending policy.  Whitespace in the policy is ignored.
+func ParsePolicy(policy string) (*Policy, error) {
+	policy = strings.Join(strings.Fields(policy), "")
+	root, err := parseNode(policy)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{root: root}, nil
+}
+
+// parseNode parses a single fragment and its arguments.
+func parseNode(s string) (*node, error) {
+	open := strings.IndexByte(s, '(')
+	if open == -1 || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid policy fragment %q", s)
+	}
+	name := s[:open]
+	frag, ok := fragmentNames[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown policy fragment %q", name)
+	}
+	args, err := splitArgs(s[open+1 : len(s)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	n := &node{frag: frag}
+	switch frag {
+	case fragPk:
+		if len(args) != 1 || strings.ContainsAny(args[0], "(),@") {
+			return nil, fmt.Errorf("invalid key in %q", s)
+		}
+		n.key = args[0]
+
+	case fragOlder, fragAfter:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a single lock time", name)
+		}
+		n.value, err = parseLockTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if frag == fragOlder &&
+			n.value&^(wire.SequenceLockTimeIsSeconds|
+				wire.SequenceLockTimeMask) != 0 {
+
+			return nil, fmt.Errorf("invalid relative lock time %d",
+				n.value)
+		}
+
+	case fragSha256, fragHash160:
+		size := 32
+		if frag == fragHash160 {
+			size = 20
+		}
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a single hash", name)
+		}
+		n.hash, err = hex.DecodeString(args[0])
+		if err != nil || len(n.hash) != size {
+			return nil, fmt.Errorf("invalid %s hash %q", name,
+				args[0])
+		}
+
+	case fragAnd, fragOr:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s takes two policies", name)
+		}
+		for _, arg := range args {
+			var weight uint32
+			if at := strings.IndexByte(arg, '@'); at != -1 &&
+				at < strings.IndexByte(arg, '(') {
+
+				if frag != fragOr {
+					return nil, fmt.Errorf("probabilities "+
+						"are only allowed in or: %q", s)
+				}
+				w, err := strconv.ParseUint(arg[:at], 10, 32)
+				if err != nil || w == 0 {
+					return nil, fmt.Errorf("invalid "+
+						"probability in %q", s)
+				}
+				weight = uint32(w)
+				arg = arg[at+1:]
+			}
+			sub, err := parseNode(arg)
+			if err != nil {
+				return nil, err
+			}
+			n.subs = append(n.subs, sub)
+			n.weights = append(n.weights, weight)
+		}
+		if frag == fragAnd {
+			n.weights = nil
+		}
+
+	case fragThresh:
+		if len(args) < 2 {
+			return nil, errors.New("thresh takes a threshold and " +
+				"at least one policy")
+		}
+		k, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil || k == 0 || k > uint64(len(args)-1) {
+			return nil, fmt.Errorf("invalid threshold in %q", s)
+		}
+		n.value = uint32(k)
+		for _, arg := range args[1:] {
+			sub, err := parseNode(arg)
+			if err != nil {
+				return nil, err
+			}
+			n.subs = append(n.subs, sub)
+		}
+	}
+
+	return n, nil
+}
+
+// parseLockTime parses the lock time of an older or after fragment.
+func parseLockTime(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || v == 0 || v >= 1<<31 {
+		return 0, fmt.Errorf("invalid lock time %q", s)
+	}
+	return uint32(v), nil
+}
+
+// splitArgs splits the arguments of a fragment at the commas which aren't
+// nested in another fragment.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses "+
+					"in %q", s)
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", s)
+	}
+	args = append(args, s[start:])
+	for _, arg := range args {
+		if arg == "" {
+			return nil, fmt.Errorf("empty argument in %q", s)
+		}
+	}
+	return args, nil
+}
+
+// String returns the policy in its canonical form, without whitespace.
+func (p *Policy) String() string {
+	var b strings.Builder
+	p.root.write(&b)
+	return b.String()
+}
+
+func (n *node) write(b *strings.Builder) {
+	for name, frag := range fragmentNames {
+		if frag == n.frag {
+			b.WriteString(name)
+			break
+		}
+	}
+	b.WriteByte('(')
+	switch n.frag {
+	case fragPk:
+		b.WriteString(n.key)
+	case fragOlder, fragAfter:
+		b.WriteString(strconv.FormatUint(uint64(n.value), 10))
+	case fragSha256, fragHash160:
+		b.WriteString(hex.EncodeToString(n.hash))
+	case fragThresh:
+		b.WriteString(strconv.FormatUint(uint64(n.value), 10))
+		for _, sub := range n.subs {
+			b.WriteByte(',')
+			sub.write(b)
+		}
+	default:
+		for i, sub := range n.subs {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if n.weights != nil && n.weights[i] != 0 {
+				b.WriteString(strconv.FormatUint(
+					uint64(n.weights[i]), 10,
+				))
+				b.WriteByte('@')
+			}
+			sub.write(b)
+		}
+	}
+	b.WriteByte(')')
+}
+
+// Keys returns the names of the keys of the policy, in the order they first
+// appear.
+func (p *Policy) Keys() []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.frag == fragPk {
+			if _, ok := seen[n.key]; !ok {
+				seen[n.key] = struct{}{}
+				keys = append(keys, n.key)
+			}
+		}
+		for _, sub := range n.subs {
+			walk(sub)
+		}
+	}
+	walk(p.root)
+	return keys
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestParsePolicy tests that valid policies are parsed into their canonical
+// form and invalid policies are rejected.
+func TestParsePolicy(t *testing.T) {
+	t.Parallel()
+
+	hash := hex.EncodeToString(make([]byte, 32))
+	tests := []struct {
+		policy    string
+		canonical string
+		keys      []string
+		valid     bool
+	}{{
+		policy:    "or(pk(A), and(pk(B), older(52560)))",
+		canonical: "or(pk(A),and(pk(B),older(52560)))",
+		keys:      []string{"A", "B"},
+		valid:     true,
+	}, {
+		policy:    "or(99@pk(A),1@and(pk(B),after(700000)))",
+		canonical: "or(99@pk(A),1@and(pk(B),after(700000)))",
+		keys:      []string{"A", "B"},
+		valid:     true,
+	}, {
+		policy: "thresh(2,pk(A),pk(B),and(pk(A),sha256(" + hash +
+			")))",
+		canonical: "thresh(2,pk(A),pk(B),and(pk(A),sha256(" + hash +
+			")))",
+		keys:  []string{"A", "B"},
+		valid: true,
+	}, {
+		policy: "pk(A",
+	}, {
+		policy: "pk(A))",
+	}, {
+		policy: "multi(1,A,B)",
+	}, {
+		policy: "and(pk(A))",
+	}, {
+		policy: "and(1@pk(A),pk(B))",
+	}, {
+		policy: "older(0)",
+	}, {
+		policy: "older(2147483648)",
+	}, {
+		policy: "after(-1)",
+	}, {
+		policy: "thresh(3,pk(A),pk(B))",
+	}, {
+		policy: "thresh(0,pk(A))",
+	}, {
+		policy: "sha256(00)",
+	}, {
+		policy: "hash160(" + hash + ")",
+	}, {
+		policy: "or(pk(A),)",
+	}}
+
+	for _, test := range tests {
+		p, err := ParsePolicy(test.policy)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unable to parse: %v", test.policy, err)
+			continue
+		}
+		if p.String() != test.canonical {
+			t.Errorf("%s: got canonical form %s, want %s",
+				test.policy, p, test.canonical)
+		}
+		if fmt.Sprint(p.Keys()) != fmt.Sprint(test.keys) {
+			t.Errorf("%s: got keys %v, want %v", test.policy,
+				p.Keys(), test.keys)
+		}
+	}
+}
+
+// testSatisfier signs with a set of private keys and knows a set of
+// preimages, and checks lock times against a transaction input.
+type testSatisfier struct {
+	tx            *wire.MsgTx
+	sigHashes     *txscript.TxSigHashes
+	witnessScript []byte
+	amount        int64
+	privKeys      map[string]*btcec.PrivateKey
+	preimages     [][]byte
+}
+
+func (s *testSatisfier) Sign(key string) ([]byte, error) {
+	privKey, ok := s.privKeys[key]
+	if !ok {
+		return nil, nil
+	}
+	return txscript.RawTxInWitnessSignature(
+		s.tx, s.sigHashes, 0, s.amount, s.witnessScript,
+		txscript.SigHashAll, privKey,
+	)
+}
+
+func (s *testSatisfier) Preimage(hash []byte) []byte {
+	for _, preimage := range s.preimages {
+		sha := sha256.Sum256(preimage)
+		if bytes.Equal(sha[:], hash) ||
+			bytes.Equal(btcutil.Hash160(preimage), hash) {
+
+			return preimage
+		}
+	}
+	return nil
+}
+
+func (s *testSatisfier) CheckOlder(sequence uint32) bool {
+	txSequence := s.tx.TxIn[0].Sequence
+	return txSequence&wire.SequenceLockTimeDisabled == 0 &&
+		txSequence >= sequence
+}
+
+func (s *testSatisfier) CheckAfter(lockTime uint32) bool {
+	return s.tx.LockTime >= lockTime
+}
+
+// TestSatisfy tests that compiled policies are satisfied with the available
+// signatures, preimages and lock times, and that the witnesses are valid.
+func TestSatisfy(t *testing.T) {
+	t.Parallel()
+
+	privKeys := make(map[string]*btcec.PrivateKey)
+	pubKeys := make(map[string]*btcec.PublicKey)
+	for _, name := range []string{"A", "B", "C"} {
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatal(err)
+		}
+		privKeys[name] = privKey
+		pubKeys[name] = privKey.PubKey()
+	}
+	preimage := bytes.Repeat([]byte{7}, 32)
+	sha := sha256.Sum256(preimage)
+	shaHex := hex.EncodeToString(sha[:])
+	hash160Hex := hex.EncodeToString(btcutil.Hash160(preimage))
+
+	tests := []struct {
+		name      string
+		policy    string
+		signers   []string
+		preimages [][]byte
+		sequence  uint32
+		lockTime  uint32
+
+		// satisfied is whether the policy can be satisfied, and
+		// items is the number of stack items of the satisfaction.
+		satisfied bool
+		items     int
+	}{{
+		name:      "primary key",
+		policy:    "or(pk(A),and(pk(B),older(52560)))",
+		signers:   []string{"A", "B"},
+		satisfied: true,
+		items:     2,
+	}, {
+		name:      "recovery key before timelock",
+		policy:    "or(pk(A),and(pk(B),older(52560)))",
+		signers:   []string{"B"},
+		sequence:  52559,
+		satisfied: false,
+	}, {
+		name:      "recovery key after timelock",
+		policy:    "or(pk(A),and(pk(B),older(52560)))",
+		signers:   []string{"B"},
+		sequence:  52560,
+		satisfied: true,
+		items:     2,
+	}, {
+		name:      "absolute timelock",
+		policy:    "and(pk(C),after(700000))",
+		signers:   []string{"C"},
+		lockTime:  700000,
+		satisfied: true,
+		items:     1,
+	}, {
+		name:      "threshold of keys",
+		policy:    "thresh(2,pk(A),pk(B),pk(C))",
+		signers:   []string{"A", "C"},
+		satisfied: true,
+		items:     3,
+	}, {
+		name:      "threshold with preimage",
+		policy:    "thresh(2,pk(A),sha256(" + shaHex + "),older(10))",
+		signers:   []string{"A"},
+		preimages: [][]byte{preimage},
+		satisfied: true,
+		items:     4,
+	}, {
+		name:      "threshold with lock time",
+		policy:    "thresh(2,pk(A),sha256(" + shaHex + "),older(10))",
+		signers:   []string{"A"},
+		sequence:  10,
+		satisfied: true,
+		items:     3,
+	}, {
+		name:      "threshold not met",
+		policy:    "thresh(2,pk(A),sha256(" + shaHex + "),older(10))",
+		signers:   []string{"A"},
+		satisfied: false,
+	}, {
+		name:      "htlc claim",
+		policy:    "or(and(pk(A),hash160(" + hash160Hex + ")),and(pk(B),after(500)))",
+		signers:   []string{"A", "B"},
+		preimages: [][]byte{preimage},
+		satisfied: true,
+		items:     3,
+	}, {
+		name:      "htlc refund",
+		policy:    "or(and(pk(A),hash160(" + hash160Hex + ")),and(pk(B),after(500)))",
+		signers:   []string{"A", "B"},
+		lockTime:  500,
+		satisfied: true,
+		items:     2,
+	}}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := ParsePolicy(test.policy)
+			if err != nil {
+				t.Fatalf("unable to parse policy: %v", err)
+			}
+			witnessScript, err := policy.Compile(pubKeys)
+			if err != nil {
+				t.Fatalf("unable to compile policy: %v", err)
+			}
+			scriptHash := sha256.Sum256(witnessScript)
+			pkScript, err := txscript.NewScriptBuilder().
+				AddOp(txscript.OP_0).AddData(scriptHash[:]).
+				Script()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			const amount = 100000
+			tx := &wire.MsgTx{
+				Version: 2,
+				TxIn: []*wire.TxIn{{
+					Sequence: wire.MaxTxInSequenceNum,
+				}},
+				TxOut:    []*wire.TxOut{wire.NewTxOut(90000, pkScript)},
+				LockTime: test.lockTime,
+			}
+			if test.sequence != 0 || test.lockTime != 0 {
+				tx.TxIn[0].Sequence = test.sequence
+			}
+			s := &testSatisfier{
+				tx:            tx,
+				sigHashes:     txscript.NewTxSigHashes(tx),
+				witnessScript: witnessScript,
+				amount:        amount,
+				privKeys:      make(map[string]*btcec.PrivateKey),
+				preimages:     test.preimages,
+			}
+			for _, signer := range test.signers {
+				s.privKeys[signer] = privKeys[signer]
+			}
+
+			sat, err := policy.Satisfy(s)
+			if !test.satisfied {
+				if err != ErrUnsatisfiable {
+					t.Fatalf("expected ErrUnsatisfiable, got %v",
+						err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to satisfy policy: %v", err)
+			}
+			if len(sat.Witness) != test.items {
+				t.Fatalf("got %d stack items, want %d",
+					len(sat.Witness), test.items)
+			}
+
+			tx.TxIn[0].Witness = append(sat.Witness, witnessScript)
+			vm, err := txscript.NewEngine(
+				pkScript, tx, 0, txscript.StandardVerifyFlags,
+				nil, s.sigHashes, amount,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := vm.Execute(); err != nil {
+				t.Fatalf("invalid witness: %v", err)
+			}
+
+			maxSize, err := policy.MaxWitnessSize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := tx.TxIn[0].Witness.SerializeSize(); size > maxSize {
+				t.Fatalf("witness size %d exceeds maximum %d",
+					size, maxSize)
+			}
+		})
+	}
+}
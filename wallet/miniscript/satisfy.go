@@ -0,0 +1,370 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package miniscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"sort"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// preimageSize is the size of the preimages of sha256 and hash160
+	// fragments.
+	preimageSize = 32
+
+	// maxSigSize is the size of the largest DER signature with its
+	// sighash type.
+	maxSigSize = 73
+)
+
+// ErrUnsatisfiable is returned when a policy can't be satisfied with the
+// signatures, preimages and lock times available to a Satisfier.
+var ErrUnsatisfiable = errors.New("policy can not be satisfied")
+
+// Satisfier provides what is needed to satisfy the fragments of a policy.
+type Satisfier interface {
+	// Sign returns a signature with sighash type by the key name, or nil
+	// if the key can't sign.
+	Sign(key string) ([]byte, error)
+
+	// Preimage returns the preimage of the SHA256 hash or hash160, or
+	// nil if it is unknown.
+	Preimage(hash []byte) []byte
+
+	// CheckOlder returns whether the input spending the script may use the
+	// relative lock time.
+	CheckOlder(sequence uint32) bool
+
+	// CheckAfter returns whether the transaction spending the script may
+	// use the absolute lock time.
+	CheckAfter(lockTime uint32) bool
+}
+
+// Satisfaction is a witness satisfying a policy, together with the input
+// sequence and transaction lock time it requires.
+type Satisfaction struct {
+	// Witness is the witness stack satisfying the policy, not including
+	// the witness script.
+	Witness wire.TxWitness
+
+	// Sequence is the sequence the spending input requires, or zero if
+	// no relative lock time is used.
+	Sequence uint32
+
+	// LockTime is the lock time the spending transaction requires, or
+	// zero if no absolute lock time is used.
+	LockTime uint32
+}
+
+// satisfaction is a possible witness for a node.
+type satisfaction struct {
+	witness  [][]byte
+	sequence uint32
+	lockTime uint32
+	ok       bool
+}
+
+// unsatisfied is returned for nodes that can't be satisfied.
+var unsatisfied = satisfaction{}
+
+// push returns a satisfaction with only the given stack item.
+func push(item []byte) satisfaction {
+	return satisfaction{witness: [][]byte{item}, ok: true}
+}
+
+// size returns the serialized size of the witness stack items.
+func (s satisfaction) size() int {
+	var size int
+	for _, item := range s.witness {
+		size += wire.VarIntSerializeSize(uint64(len(item))) + len(item)
+	}
+	return size
+}
+
+// join returns the satisfaction of a node evaluating the script of a before
+// the script of b, which means the stack items of a are above those of b.
+func join(b, a satisfaction) satisfaction {
+	if !a.ok || !b.ok {
+		return unsatisfied
+	}
+
+	s := satisfaction{ok: true}
+	s.witness = append(s.witness, b.witness...)
+	s.witness = append(s.witness, a.witness...)
+
+	// Relative lock times in blocks and in seconds can't be combined, and
+	// neither can absolute lock times in heights and timestamps.
+	s.sequence, s.ok = mergeLockTimes(
+		a.sequence, b.sequence,
+		func(v uint32) bool {
+			return v&wire.SequenceLockTimeIsSeconds != 0
+		},
+	)
+	if !s.ok {
+		return unsatisfied
+	}
+	s.lockTime, s.ok = mergeLockTimes(
+		a.lockTime, b.lockTime,
+		func(v uint32) bool {
+			return v >= txscript.LockTimeThreshold
+		},
+	)
+	if !s.ok {
+		return unsatisfied
+	}
+	return s
+}
+
+// mergeLockTimes returns the larger of two lock times of the same unit, with
+// zero meaning no lock time.
+func mergeLockTimes(a, b uint32, isTime func(uint32) bool) (uint32, bool) {
+	switch {
+	case a == 0:
+		return b, true
+	case b == 0:
+		return a, true
+	case isTime(a) != isTime(b):
+		return 0, false
+	case a > b:
+		return a, true
+	default:
+		return b, true
+	}
+}
+
+// cheaper returns the smaller of two satisfactions.
+func cheaper(a, b satisfaction) satisfaction {
+	switch {
+	case !a.ok:
+		return b
+	case !b.ok:
+		return a
+	case b.size() < a.size():
+		return b
+	default:
+		return a
+	}
+}
+
+// satisfy returns the smallest witness satisfying the node.
+func (n *node) satisfy(s Satisfier) (satisfaction, error) {
+	switch n.frag {
+	case fragPk:
+		sig, err := s.Sign(n.key)
+		if err != nil || sig == nil {
+			return unsatisfied, err
+		}
+		return push(sig), nil
+
+	case fragOlder:
+		if !s.CheckOlder(n.value) {
+			return unsatisfied, nil
+		}
+		return satisfaction{sequence: n.value, ok: true}, nil
+
+	case fragAfter:
+		if !s.CheckAfter(n.value) {
+			return unsatisfied, nil
+		}
+		return satisfaction{lockTime: n.value, ok: true}, nil
+
+	case fragSha256, fragHash160:
+		preimage := s.Preimage(n.hash)
+		if len(preimage) != preimageSize {
+			return unsatisfied, nil
+		}
+		var hash []byte
+		if n.frag == fragSha256 {
+			h := sha256.Sum256(preimage)
+			hash = h[:]
+		} else {
+			hash = btcutil.Hash160(preimage)
+		}
+		if !bytes.Equal(hash, n.hash) {
+			return unsatisfied, nil
+		}
+		return push(preimage), nil
+
+	case fragAnd:
+		x, err := n.subs[0].satisfy(s)
+		if err != nil {
+			return unsatisfied, err
+		}
+		y, err := n.subs[1].satisfy(s)
+		if err != nil {
+			return unsatisfied, err
+		}
+		return join(y, x), nil
+
+	case fragOr:
+		x, err := n.subs[0].satisfy(s)
+		if err != nil {
+			return unsatisfied, err
+		}
+		y, err := n.subs[1].satisfy(s)
+		if err != nil {
+			return unsatisfied, err
+		}
+		return cheaper(join(x, push([]byte{1})), join(y, push(nil))), nil
+
+	case fragThresh:
+		return n.satisfyThresh(s)
+	}
+
+	return unsatisfied, nil
+}
+
+// satisfyThresh returns the smallest witness satisfying the threshold of
+// policies of a thresh node, leaving the other policies dissatisfied.
+func (n *node) satisfyThresh(s Satisfier) (satisfaction, error) {
+	sats := make([]satisfaction, len(n.subs))
+	dissats := make([]satisfaction, len(n.subs))
+	var candidates []int
+	for i, sub := range n.subs {
+		sat, err := sub.satisfy(s)
+		if err != nil {
+			return unsatisfied, err
+		}
+
+		// Policies other than keys are selected with an OP_IF.  Keys
+		// are dissatisfied with an empty signature, and everything
+		// else by not selecting it.
+		if sub.frag != fragPk {
+			sat = join(sat, push([]byte{1}))
+		}
+		sats[i] = sat
+		dissats[i] = push(nil)
+		if sat.ok {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) < int(n.value) {
+		return unsatisfied, nil
+	}
+
+	// Satisfy the policies adding the least to the size of the witness.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		return sats[a].size()-dissats[a].size() <
+			sats[b].size()-dissats[b].size()
+	})
+	chosen := append([]satisfaction(nil), dissats...)
+	for _, i := range candidates[:n.value] {
+		chosen[i] = sats[i]
+	}
+
+	// The first policy is evaluated first, so its stack items are on top.
+	result := satisfaction{ok: true}
+	for _, sat := range chosen {
+		result = join(sat, result)
+	}
+	return result, nil
+}
+
+// Satisfy returns the smallest witness satisfying the policy with the
+// signatures, preimages and lock times available to the satisfier.
+// ErrUnsatisfiable is returned if the policy can't be satisfied.
+func (p *Policy) Satisfy(s Satisfier) (*Satisfaction, error) {
+	sat, err := p.root.satisfy(s)
+	if err != nil {
+		return nil, err
+	}
+	if !sat.ok {
+		return nil, ErrUnsatisfiable
+	}
+	return &Satisfaction{
+		Witness:  sat.witness,
+		Sequence: sat.sequence,
+		LockTime: sat.lockTime,
+	}, nil
+}
+
+// witnessCost is the worst case size and number of stack items of a witness.
+type witnessCost struct {
+	size  int
+	items int
+}
+
+func (c witnessCost) add(o witnessCost) witnessCost {
+	return witnessCost{size: c.size + o.size, items: c.items + o.items}
+}
+
+func (c witnessCost) max(o witnessCost) witnessCost {
+	if o.size > c.size {
+		c.size = o.size
+	}
+	if o.items > c.items {
+		c.items = o.items
+	}
+	return c
+}
+
+var (
+	// sigCost is the cost of the largest signature.
+	sigCost = witnessCost{size: 1 + maxSigSize, items: 1}
+
+	// trueCost is the cost of the stack item selecting an OP_IF branch.
+	trueCost = witnessCost{size: 2, items: 1}
+
+	// falseCost is the cost of an empty stack item, used for an OP_ELSE
+	// branch or an empty signature.
+	falseCost = witnessCost{size: 1, items: 1}
+)
+
+// maxCost returns the worst case cost of a witness satisfying the node.
+func (n *node) maxCost() witnessCost {
+	switch n.frag {
+	case fragPk:
+		return sigCost
+
+	case fragSha256, fragHash160:
+		return witnessCost{size: 1 + preimageSize, items: 1}
+
+	case fragAnd:
+		return n.subs[0].maxCost().add(n.subs[1].maxCost())
+
+	case fragOr:
+		return n.subs[0].maxCost().add(trueCost).
+			max(n.subs[1].maxCost().add(falseCost))
+
+	case fragThresh:
+		var cost witnessCost
+		for _, sub := range n.subs {
+			sat := sigCost
+			if sub.frag != fragPk {
+				sat = sub.maxCost().add(trueCost)
+			}
+			cost = cost.add(sat.max(falseCost))
+		}
+		return cost
+	}
+
+	// Lock times don't add to the witness.
+	return witnessCost{}
+}
+
+// MaxWitnessSize returns the worst case serialized size of a witness
+// satisfying the policy, including the witness script.  As each byte of a
+// witness has a weight of one, this is also the worst case weight of the
+// witness.
+func (p *Policy) MaxWitnessSize() (int, error) {
+	// The size of the script doesn't depend on the keys.
+	script, err := p.script(func(string) ([]byte, error) {
+		return make([]byte, 33), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	cost := p.root.maxCost()
+	return wire.VarIntSerializeSize(uint64(cost.items+1)) + cost.size +
+		wire.VarIntSerializeSize(uint64(len(script))) + len(script), nil
+}
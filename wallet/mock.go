@@ -63,6 +63,19 @@ func (m *mockChainClient) SendRawTransaction(*wire.MsgTx, bool) (
 	return nil, nil
 }
 
+func (m *mockChainClient) TestMempoolAccept(tx *wire.MsgTx) (
+	*chain.MempoolAcceptResult, error) {
+	return &chain.MempoolAcceptResult{
+		TxID:    tx.TxHash(),
+		Allowed: true,
+	}, nil
+}
+
+func (m *mockChainClient) GetUtxo(*wire.OutPoint, []byte, uint32) (
+	*wire.TxOut, error) {
+	return nil, chain.ErrOutputSpent
+}
+
 func (m *mockChainClient) Rescan(*chainhash.Hash, []btcutil.Address,
 	map[wire.OutPoint]btcutil.Address) error {
 	return nil
@@ -6,12 +6,18 @@
 package wallet
 
 import (
+	"crypto/sha256"
 	"errors"
+	"fmt"
 
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
 // MakeMultiSigScript creates a multi-signature script that can be redeemed with
@@ -112,3 +118,363 @@ func (w *Wallet) ImportP2SHRedeemScript(script []byte) (*btcutil.AddressScriptHa
 	})
 	return p2shAddr, err
 }
+
+// ImportWitnessScript adds a witness script to the wallet, returning its P2WSH
+// address, or its P2SH-P2WSH address if nested is set.
+func (w *Wallet) ImportWitnessScript(script []byte, nested bool) (
+	btcutil.Address, error) {
+
+	var addr btcutil.Address
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		bs := &waddrmgr.BlockStamp{
+			Hash:   *w.ChainParams().GenesisHash,
+			Height: 0,
+		}
+
+		// Witness scripts are imported into the BIP0084 scope, like
+		// regular P2SH scripts.
+		bip84Mgr, err := w.Manager.FetchScopedKeyManager(
+			waddrmgr.KeyScopeBIP0084,
+		)
+		if err != nil {
+			return err
+		}
+
+		addrInfo, err := bip84Mgr.ImportWitnessScript(
+			addrmgrNs, script, bs, nested,
+		)
+		if err != nil {
+			// Don't care if it's already there, but still have to
+			// return the address since the address manager didn't
+			// return anything useful.
+			if waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+				addr, err = WitnessScriptAddress(
+					script, nested, w.chainParams,
+				)
+				return err
+			}
+			return err
+		}
+
+		addr = addrInfo.Address()
+		return nil
+	})
+	return addr, err
+}
+
+// WitnessScriptAddress returns the P2WSH address of a witness script, or its
+// P2SH-P2WSH address if nested is set.
+func WitnessScriptAddress(script []byte, nested bool,
+	chainParams *chaincfg.Params) (btcutil.Address, error) {
+
+	scriptHash := sha256.Sum256(script)
+	p2wshAddr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], chainParams,
+	)
+	if err != nil || !nested {
+		return p2wshAddr, err
+	}
+
+	witnessProgram, err := txscript.PayToAddrScript(p2wshAddr)
+	if err != nil {
+		return nil, err
+	}
+	return btcutil.NewAddressScriptHash(witnessProgram, chainParams)
+}
+
+// signWitnessMultiSig returns the witness stack spending a P2WSH output, or a
+// nested P2WSH output, locked by the multisig witness script. Valid signatures
+// of the previous witness are kept, and signatures are added for each of the
+// script's public keys that getKey returns a private key for, until the
+// required number of signatures is reached. getKey must return a nil key
+// without an error for keys that are unknown to the caller.
+func signWitnessMultiSig(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes,
+	idx int, amount int64, witnessScript []byte,
+	hashType txscript.SigHashType, prevWitness wire.TxWitness,
+	chainParams *chaincfg.Params,
+	getKey func(*btcutil.AddressPubKeyHash) (*btcec.PrivateKey, error)) (
+	wire.TxWitness, error) {
+
+	class, addrs, nRequired, err := txscript.ExtractPkScriptAddrs(
+		witnessScript, chainParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if class != txscript.MultiSigTy {
+		return nil, fmt.Errorf("unsupported witness script class %v",
+			class)
+	}
+
+	// The signatures of a previous witness are found between the dummy
+	// element consumed by OP_CHECKMULTISIG and the witness script.
+	var prevSigs [][]byte
+	if len(prevWitness) > 2 {
+		prevSigs = prevWitness[1 : len(prevWitness)-1]
+	}
+
+	// The signatures must be in the order of the public keys in the
+	// script.
+	sigs := make([][]byte, 0, nRequired)
+	for _, addr := range addrs {
+		if len(sigs) == nRequired {
+			break
+		}
+		pubKeyAddr, ok := addr.(*btcutil.AddressPubKey)
+		if !ok {
+			continue
+		}
+
+		sig := findWitnessSig(
+			tx, sigHashes, idx, amount, witnessScript, prevSigs,
+			pubKeyAddr.PubKey(),
+		)
+		if sig != nil {
+			sigs = append(sigs, sig)
+			continue
+		}
+
+		privKey, err := getKey(pubKeyAddr.AddressPubKeyHash())
+		if err != nil {
+			return nil, err
+		}
+		if privKey == nil {
+			continue
+		}
+		sig, err = txscript.RawTxInWitnessSignature(
+			tx, sigHashes, idx, amount, witnessScript, hashType,
+			privKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+
+	witness := make(wire.TxWitness, 0, len(sigs)+2)
+	witness = append(witness, nil)
+	witness = append(witness, sigs...)
+	witness = append(witness, witnessScript)
+	return witness, nil
+}
+
+// findWitnessSig returns the signature of sigs that is a valid signature of
+// the input by the public key, or nil if there is none.
+func findWitnessSig(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, idx int,
+	amount int64, witnessScript []byte, sigs [][]byte,
+	pubKey *btcec.PublicKey) []byte {
+
+	for _, sig := range sigs {
+		if len(sig) == 0 {
+			continue
+		}
+		hashType := txscript.SigHashType(sig[len(sig)-1])
+		signature, err := btcec.ParseDERSignature(
+			sig[:len(sig)-1], btcec.S256(),
+		)
+		if err != nil {
+			continue
+		}
+		hash, err := txscript.CalcWitnessSigHash(
+			witnessScript, sigHashes, hashType, tx, idx, amount,
+		)
+		if err != nil {
+			continue
+		}
+		if signature.Verify(hash, pubKey) {
+			return sig
+		}
+	}
+	return nil
+}
+
+// witnessSigScript returns the signature script spending a P2SH-P2WSH
+// output, which pushes the P2WSH witness program of the witness script.
+func witnessSigScript(witnessScript []byte) ([]byte, error) {
+	scriptHash := sha256.Sum256(witnessScript)
+	witnessProgram, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).AddData(scriptHash[:]).Script()
+	if err != nil {
+		return nil, err
+	}
+	return txscript.NewScriptBuilder().AddData(witnessProgram).Script()
+}
+
+// witnessScriptForOutput returns the witness script of an output paying to a
+// P2WSH or P2SH-P2WSH address, looked up with getScript, and whether it is
+// nested. The script of a P2SH address may be either the P2WSH witness program
+// or the witness script itself. A nil script is returned for outputs that are
+// not known to pay to a witness script.
+func witnessScriptForOutput(pkScript []byte, chainParams *chaincfg.Params,
+	getScript txscript.ScriptDB) ([]byte, bool) {
+
+	class, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, chainParams,
+	)
+	if err != nil || len(addrs) != 1 {
+		return nil, false
+	}
+	script, err := getScript.GetScript(addrs[0])
+	if err != nil {
+		return nil, false
+	}
+
+	switch class {
+	case txscript.WitnessV0ScriptHashTy:
+		return script, false
+
+	case txscript.ScriptHashTy:
+		if txscript.IsPayToWitnessScriptHash(script) {
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				script, chainParams,
+			)
+			if err != nil || len(addrs) != 1 {
+				return nil, false
+			}
+			witnessScript, err := getScript.GetScript(addrs[0])
+			if err != nil {
+				return nil, false
+			}
+			return witnessScript, true
+		}
+
+		nestedAddr, err := WitnessScriptAddress(script, true, chainParams)
+		if err != nil {
+			return nil, false
+		}
+		if nestedAddr.EncodeAddress() == addrs[0].EncodeAddress() {
+			return script, true
+		}
+	}
+
+	return nil, false
+}
+
+// maxWitnessSigSize is the size of the largest DER signature with its sighash
+// type.
+const maxWitnessSigSize = 73
+
+// witnessSpender spends outputs paying to a witness script which isn't a
+// multisig script, such as the script of a template or of a policy account.
+type witnessSpender interface {
+	// timelocks returns the input sequence and transaction lock time
+	// required to spend the output, either of which is zero if not
+	// required, and whether the output can be spent by the wallet in the
+	// block after bs.
+	timelocks(output *wtxmgr.Credit, bs *waddrmgr.BlockStamp) (uint32,
+		uint32, bool)
+
+	// maxWitnessSize returns the worst case size of the witness spending
+	// the witness script, including the script itself.
+	maxWitnessSize(witnessScript []byte) int
+
+	// sign returns the witness spending the witness script, using the
+	// signatures and preimages of the previous witness of the input and
+	// signing with the keys of getKey.  getKey must return a nil key
+	// without an error for keys that are unknown to the caller.
+	sign(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, idx int,
+		amount int64, witnessScript []byte,
+		hashType txscript.SigHashType, prevWitness wire.TxWitness,
+		chainParams *chaincfg.Params,
+		getKey func(*btcutil.AddressPubKeyHash) (*btcec.PrivateKey,
+			error)) (wire.TxWitness, error)
+}
+
+// fetchWitnessSpender returns the spender of the witness script with the given
+// SHA256 hash, either its script template or the policy of the account it was
+// derived for, or nil if there is none.
+func fetchWitnessSpender(dbtx walletdb.ReadTx,
+	scriptHash []byte) (witnessSpender, error) {
+
+	template, err := fetchScriptTemplate(
+		dbtx.ReadBucket(wscriptNamespaceKey), scriptHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if template != nil {
+		return template, nil
+	}
+
+	spender, err := fetchPolicySpender(
+		dbtx.ReadBucket(wpolicyNamespaceKey), scriptHash,
+	)
+	if err != nil || spender == nil {
+		return nil, err
+	}
+	return spender, nil
+}
+
+// signWitnessScriptInput adds signatures from the keys of getKey to the input
+// of tx spending an output of the amount which pays to the witness script,
+// nested within a P2SH output if nested is set.  The witness script is spent
+// with its spender if there is one, and must otherwise be a multisig script.
+func signWitnessScriptInput(tx *wire.MsgTx, idx int,
+	sigHashes *txscript.TxSigHashes, amount int64, witnessScript []byte,
+	spender witnessSpender, nested bool, hashType txscript.SigHashType,
+	chainParams *chaincfg.Params, getKey txscript.KeyDB) error {
+
+	// Keys that can't be found are skipped, unless the wallet is locked.
+	keyFn := func(addr *btcutil.AddressPubKeyHash) (*btcec.PrivateKey,
+		error) {
+
+		privKey, _, err := getKey.GetKey(addr)
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, nil
+		}
+		return privKey, nil
+	}
+
+	txIn := tx.TxIn[idx]
+	var (
+		witness wire.TxWitness
+		err     error
+	)
+	if spender != nil {
+		witness, err = spender.sign(
+			tx, sigHashes, idx, amount, witnessScript, hashType,
+			txIn.Witness, chainParams, keyFn,
+		)
+	} else {
+		witness, err = signWitnessMultiSig(
+			tx, sigHashes, idx, amount, witnessScript, hashType,
+			txIn.Witness, chainParams, keyFn,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	var sigScript []byte
+	if nested {
+		sigScript, err = witnessSigScript(witnessScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	txIn.Witness = witness
+	txIn.SignatureScript = sigScript
+	return nil
+}
+
+// prevOutAmount returns the amount of a previous output recorded by the
+// wallet.
+func (w *Wallet) prevOutAmount(txmgrNs walletdb.ReadBucket,
+	outPoint wire.OutPoint) (int64, error) {
+
+	txDetails, err := w.TxStore.TxDetails(txmgrNs, &outPoint.Hash)
+	if err != nil {
+		return 0, err
+	}
+	if txDetails == nil || int(outPoint.Index) >= len(txDetails.MsgTx.TxOut) {
+		return 0, fmt.Errorf("amount of witness input %v is unknown",
+			outPoint)
+	}
+	return txDetails.MsgTx.TxOut[outPoint.Index].Value, nil
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// TestWitnessMultiSig tests that P2WSH and P2SH-P2WSH multisig scripts can be
+// imported into two wallets, and that an output paying to them can be spent
+// with a signature from ComputeInputScript of one wallet and SignTransaction
+// of the other.
+func TestWitnessMultiSig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		nested bool
+	}{{
+		name:   "P2WSH",
+		nested: false,
+	}, {
+		name:   "P2SH-P2WSH",
+		nested: true,
+	}}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			testWitnessMultiSig(t, tc.nested)
+		})
+	}
+}
+
+func testWitnessMultiSig(t *testing.T, nested bool) {
+	w1, cleanup := testWallet(t)
+	defer cleanup()
+	w2, cleanup := testWallet(t)
+	defer cleanup()
+
+	// Create a 2-of-2 multisig script of a key from each wallet, and
+	// import it into both.
+	var pubKeys []btcutil.Address
+	for _, w := range []*Wallet{w1, w2} {
+		addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+		if err != nil {
+			t.Fatalf("unable to get current address: %v", err)
+		}
+		pubKey, err := w.PubKeyForAddress(addr)
+		if err != nil {
+			t.Fatalf("unable to get public key: %v", err)
+		}
+		pubKeyAddr, err := btcutil.NewAddressPubKey(
+			pubKey.SerializeCompressed(), w.ChainParams(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKeys = append(pubKeys, pubKeyAddr)
+	}
+	script, err := w1.MakeMultiSigScript(pubKeys, 2)
+	if err != nil {
+		t.Fatalf("unable to make multisig script: %v", err)
+	}
+
+	wantAddr, err := WitnessScriptAddress(script, nested, w1.ChainParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []*Wallet{w1, w2} {
+		addr, err := w.ImportWitnessScript(script, nested)
+		if err != nil {
+			t.Fatalf("unable to import witness script: %v", err)
+		}
+		if addr.EncodeAddress() != wantAddr.EncodeAddress() {
+			t.Fatalf("unexpected address %v, want %v", addr,
+				wantAddr)
+		}
+	}
+
+	// Importing the script again returns the same address.
+	addr, err := w1.ImportWitnessScript(script, nested)
+	if err != nil {
+		t.Fatalf("unable to import duplicate witness script: %v", err)
+	}
+	if addr.EncodeAddress() != wantAddr.EncodeAddress() {
+		t.Fatalf("unexpected address %v, want %v", addr, wantAddr)
+	}
+
+	// Add an output paying to the multisig address to both wallets.
+	pkScript, err := txscript.PayToAddrScript(wantAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxOut := wire.NewTxOut(100000, pkScript)
+	incomingTx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{utxOut},
+	}
+	addUtxo(t, w1, incomingTx)
+	addUtxo(t, w2, incomingTx)
+
+	outgoingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{
+				Hash: incomingTx.TxHash(),
+			},
+		}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(90000, pkScript)},
+	}
+	sigHashes := txscript.NewTxSigHashes(outgoingTx)
+
+	// The first wallet only provides one of the signatures.
+	witness, sigScript, err := w1.ComputeInputScript(
+		outgoingTx, utxOut, 0, sigHashes, txscript.SigHashAll, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to compute input script: %v", err)
+	}
+	if len(witness) != 3 {
+		t.Fatalf("unexpected witness stack length, got %d, wanted %d",
+			len(witness), 3)
+	}
+	if nested != (len(sigScript) != 0) {
+		t.Fatalf("unexpected signature script %x", sigScript)
+	}
+	outgoingTx.TxIn[0].Witness = witness
+	outgoingTx.TxIn[0].SignatureScript = sigScript
+
+	// The second wallet adds its own signature to complete the input.
+	signErrs, err := w2.SignTransaction(
+		outgoingTx, txscript.SigHashAll, nil, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to sign transaction: %v", err)
+	}
+	if len(signErrs) != 0 {
+		t.Fatalf("unexpected signature errors: %v", signErrs[0].Error)
+	}
+	if len(outgoingTx.TxIn[0].Witness) != 4 {
+		t.Fatalf("unexpected witness stack length, got %d, wanted %d",
+			len(outgoingTx.TxIn[0].Witness), 4)
+	}
+
+	err = validateMsgTx(
+		outgoingTx, [][]byte{pkScript}, []btcutil.Amount{100000},
+	)
+	if err != nil {
+		t.Fatalf("error validating tx: %v", err)
+	}
+}
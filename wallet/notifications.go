@@ -6,7 +6,9 @@ package wallet
 
 import (
 	"bytes"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
@@ -31,12 +33,16 @@ import (
 // order wallet created them, but there is no guaranteed synchronization between
 // different clients.
 type NotificationServer struct {
-	transactions   []chan *TransactionNotifications
-	currentTxNtfn  *TransactionNotifications // coalesce this since wallet does not add mined txs together
-	spentness      map[uint32][]chan *SpentnessNotifications
-	accountClients []chan *AccountNotification
-	mu             sync.Mutex // Only protects registered client channels
-	wallet         *Wallet    // smells like hacks
+	transactions     []chan *TransactionNotifications
+	txHandlers       []*txHandler
+	currentTxNtfn    *TransactionNotifications // coalesce this since wallet does not add mined txs together
+	spentness        map[uint32][]chan *SpentnessNotifications
+	accountClients   []chan *AccountNotification
+	invoiceClients   []chan *InvoiceNotification
+	lockStateClients []chan *LockStateNotification
+	rescanClients    []chan *RescanNotification
+	mu               sync.Mutex // Only protects registered client channels
+	wallet           *Wallet    // smells like hacks
 }
 
 func newNotificationServer(wallet *Wallet) *NotificationServer {
@@ -46,7 +52,9 @@ func newNotificationServer(wallet *Wallet) *NotificationServer {
 	}
 }
 
-func lookupInputAccount(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails, deb wtxmgr.DebitRecord) uint32 {
+func lookupInputAccount(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails,
+	deb wtxmgr.DebitRecord) (waddrmgr.KeyScope, uint32) {
+
 	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
 
@@ -56,39 +64,50 @@ func lookupInputAccount(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetai
 	prev, err := w.TxStore.TxDetails(txmgrNs, &prevOP.Hash)
 	if err != nil {
 		log.Errorf("Cannot query previous transaction details for %v: %v", prevOP.Hash, err)
-		return 0
+		return waddrmgr.KeyScopeBIP0044, 0
 	}
 	if prev == nil {
 		log.Errorf("Missing previous transaction %v", prevOP.Hash)
-		return 0
+		return waddrmgr.KeyScopeBIP0044, 0
 	}
 	prevOut := prev.MsgTx.TxOut[prevOP.Index]
 	_, addrs, _, err := txscript.ExtractPkScriptAddrs(prevOut.PkScript, w.chainParams)
-	var inputAcct uint32
+	var (
+		manager   *waddrmgr.ScopedKeyManager
+		inputAcct uint32
+	)
 	if err == nil && len(addrs) > 0 {
-		_, inputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
+		manager, inputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
 	}
-	if err != nil {
+	if err != nil || manager == nil {
 		log.Errorf("Cannot fetch account for previous output %v: %v", prevOP, err)
-		inputAcct = 0
+		return waddrmgr.KeyScopeBIP0044, 0
 	}
-	return inputAcct
+	return manager.Scope(), inputAcct
 }
 
 func lookupOutputChain(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails,
-	cred wtxmgr.CreditRecord) (account uint32, internal bool) {
+	cred wtxmgr.CreditRecord) (scope waddrmgr.KeyScope, account uint32, internal bool) {
 
 	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 
+	scope = waddrmgr.KeyScopeBIP0044
 	output := details.MsgTx.TxOut[cred.Index]
 	_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript, w.chainParams)
-	var ma waddrmgr.ManagedAddress
+	var (
+		manager *waddrmgr.ScopedKeyManager
+		ma      waddrmgr.ManagedAddress
+	)
 	if err == nil && len(addrs) > 0 {
-		ma, err = w.Manager.Address(addrmgrNs, addrs[0])
+		manager, _, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
 	}
-	if err != nil {
+	if err == nil && manager != nil {
+		ma, err = manager.Address(addrmgrNs, addrs[0])
+	}
+	if err != nil || ma == nil {
 		log.Errorf("Cannot fetch account for wallet output: %v", err)
 	} else {
+		scope = manager.Scope()
 		account = ma.InternalAccount()
 		internal = ma.Internal()
 	}
@@ -118,10 +137,12 @@ func makeTxSummary(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails) T
 	if len(details.Debits) != 0 {
 		inputs = make([]TransactionSummaryInput, len(details.Debits))
 		for i, d := range details.Debits {
+			scope, acct := lookupInputAccount(dbtx, w, details, d)
 			inputs[i] = TransactionSummaryInput{
-				Index:           d.Index,
-				PreviousAccount: lookupInputAccount(dbtx, w, details, d),
-				PreviousAmount:  d.Amount,
+				Index:            d.Index,
+				PreviousKeyScope: scope,
+				PreviousAccount:  acct,
+				PreviousAmount:   d.Amount,
 			}
 		}
 	}
@@ -132,9 +153,11 @@ func makeTxSummary(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails) T
 		if !mine {
 			continue
 		}
-		acct, internal := lookupOutputChain(dbtx, w, details, details.Credits[credIndex])
+		scope, acct, internal := lookupOutputChain(dbtx, w, details,
+			details.Credits[credIndex])
 		output := TransactionSummaryOutput{
 			Index:    uint32(i),
+			KeyScope: scope,
 			Account:  acct,
 			Internal: internal,
 		}
@@ -151,7 +174,14 @@ func makeTxSummary(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails) T
 	}
 }
 
-func totalBalances(dbtx walletdb.ReadTx, w *Wallet, m map[uint32]btcutil.Amount) error {
+// scopedAccount identifies an account by its key scope and number, as account
+// numbers are only unique within a scope.
+type scopedAccount struct {
+	scope   waddrmgr.KeyScope
+	account uint32
+}
+
+func totalBalances(dbtx walletdb.ReadTx, w *Wallet, m map[scopedAccount]btcutil.Amount) error {
 	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 	unspent, err := w.TxStore.UnspentOutputs(dbtx.ReadBucket(wtxmgrNamespaceKey))
 	if err != nil {
@@ -159,42 +189,54 @@ func totalBalances(dbtx walletdb.ReadTx, w *Wallet, m map[uint32]btcutil.Amount)
 	}
 	for i := range unspent {
 		output := &unspent[i]
-		var outputAcct uint32
+		var (
+			manager    *waddrmgr.ScopedKeyManager
+			outputAcct uint32
+		)
 		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
 			output.PkScript, w.chainParams)
 		if err == nil && len(addrs) > 0 {
-			_, outputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
+			manager, outputAcct, err = w.Manager.AddrAccount(addrmgrNs, addrs[0])
 		}
-		if err == nil {
-			_, ok := m[outputAcct]
-			if ok {
-				m[outputAcct] += output.Amount
+		if err == nil && manager != nil {
+			key := scopedAccount{manager.Scope(), outputAcct}
+			if _, ok := m[key]; ok {
+				m[key] += output.Amount
 			}
 		}
 	}
 	return nil
 }
 
-func flattenBalanceMap(m map[uint32]btcutil.Amount) []AccountBalance {
+func flattenBalanceMap(m map[scopedAccount]btcutil.Amount) []AccountBalance {
 	s := make([]AccountBalance, 0, len(m))
 	for k, v := range m {
-		s = append(s, AccountBalance{Account: k, TotalBalance: v})
+		s = append(s, AccountBalance{
+			KeyScope:     k.scope,
+			Account:      k.account,
+			TotalBalance: v,
+		})
 	}
 	return s
 }
 
-func relevantAccounts(_ *Wallet, m map[uint32]btcutil.Amount, txs []TransactionSummary) {
+func relevantAccounts(_ *Wallet, m map[scopedAccount]btcutil.Amount, txs []TransactionSummary) {
 	for _, tx := range txs {
 		for _, d := range tx.MyInputs {
-			m[d.PreviousAccount] = 0
+			m[scopedAccount{d.PreviousKeyScope, d.PreviousAccount}] = 0
 		}
 		for _, c := range tx.MyOutputs {
-			m[c.Account] = 0
+			m[scopedAccount{c.KeyScope, c.Account}] = 0
 		}
 	}
 }
 
-func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, details *wtxmgr.TxDetails) {
+// notifyUnminedTransaction notifies the clients and handlers of an unmined
+// transaction.  An error is only returned if a handler fails, in which case
+// the clients are not notified.
+func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadWriteTx,
+	details *wtxmgr.TxDetails) error {
+
 	// Sanity check: should not be currently coalescing a notification for
 	// mined transactions at the same time that an unmined tx is notified.
 	if s.currentTxNtfn != nil {
@@ -205,31 +247,35 @@ func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, deta
 	defer s.mu.Unlock()
 	s.mu.Lock()
 	clients := s.transactions
-	if len(clients) == 0 {
-		return
+	if len(clients) == 0 && len(s.txHandlers) == 0 {
+		return nil
 	}
 
 	unminedTxs := []TransactionSummary{makeTxSummary(dbtx, s.wallet, details)}
 	unminedHashes, err := s.wallet.TxStore.UnminedTxHashes(dbtx.ReadBucket(wtxmgrNamespaceKey))
 	if err != nil {
 		log.Errorf("Cannot fetch unmined transaction hashes: %v", err)
-		return
+		return nil
 	}
-	bals := make(map[uint32]btcutil.Amount)
+	bals := make(map[scopedAccount]btcutil.Amount)
 	relevantAccounts(s.wallet, bals, unminedTxs)
 	err = totalBalances(dbtx, s.wallet, bals)
 	if err != nil {
 		log.Errorf("Cannot determine balances for relevant accounts: %v", err)
-		return
+		return nil
 	}
 	n := &TransactionNotifications{
 		UnminedTransactions:      unminedTxs,
 		UnminedTransactionHashes: unminedHashes,
 		NewBalances:              flattenBalanceMap(bals),
 	}
+	if err := s.handleTransactions(dbtx, n); err != nil {
+		return err
+	}
 	for _, c := range clients {
 		c <- n
 	}
+	return nil
 }
 
 func (s *NotificationServer) notifyDetachedBlock(hash *chainhash.Hash) {
@@ -257,7 +303,13 @@ func (s *NotificationServer) notifyMinedTransaction(dbtx walletdb.ReadTx, detail
 		append(txs, makeTxSummary(dbtx, s.wallet, details)) //  nolint:gocritic
 }
 
-func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wtxmgr.BlockMeta) {
+// notifyAttachedBlock notifies the clients and handlers of the blocks attached
+// since the last notification.  An error is only returned if a handler fails,
+// in which case the notification is kept, so it's handled again along with
+// the next attached block.
+func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadWriteTx,
+	block *wtxmgr.BlockMeta) error {
+
 	if s.currentTxNtfn == nil {
 		s.currentTxNtfn = &TransactionNotifications{}
 	}
@@ -277,16 +329,16 @@ func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wt
 	// chain length to determine if this is the new best block.
 	if s.wallet.ChainSynced() {
 		if len(s.currentTxNtfn.DetachedBlocks) >= len(s.currentTxNtfn.AttachedBlocks) {
-			return
+			return nil
 		}
 	}
 
 	defer s.mu.Unlock()
 	s.mu.Lock()
 	clients := s.transactions
-	if len(clients) == 0 {
+	if len(clients) == 0 && len(s.txHandlers) == 0 {
 		s.currentTxNtfn = nil
-		return
+		return nil
 	}
 
 	// The UnminedTransactions field is intentionally not set.  Since the
@@ -300,25 +352,43 @@ func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wt
 	unminedHashes, err := s.wallet.TxStore.UnminedTxHashes(txmgrNs)
 	if err != nil {
 		log.Errorf("Cannot fetch unmined transaction hashes: %v", err)
-		return
+		return nil
 	}
 	s.currentTxNtfn.UnminedTransactionHashes = unminedHashes
 
-	bals := make(map[uint32]btcutil.Amount)
+	bals := make(map[scopedAccount]btcutil.Amount)
 	for _, b := range s.currentTxNtfn.AttachedBlocks {
 		relevantAccounts(s.wallet, bals, b.Transactions)
 	}
 	err = totalBalances(dbtx, s.wallet, bals)
 	if err != nil {
 		log.Errorf("Cannot determine balances for relevant accounts: %v", err)
-		return
+		return nil
 	}
 	s.currentTxNtfn.NewBalances = flattenBalanceMap(bals)
 
+	if err := s.handleTransactions(dbtx, s.currentTxNtfn); err != nil {
+		return err
+	}
 	for _, c := range clients {
 		c <- s.currentTxNtfn
 	}
 	s.currentTxNtfn = nil
+	return nil
+}
+
+// handleTransactions calls the registered transaction notification handlers,
+// stopping at the first which fails.  It must be called with the mutex held.
+func (s *NotificationServer) handleTransactions(dbtx walletdb.ReadWriteTx,
+	n *TransactionNotifications) error {
+
+	for _, h := range s.txHandlers {
+		if err := h.handle(dbtx, n); err != nil {
+			return fmt.Errorf("unable to handle transaction "+
+				"notification: %v", err)
+		}
+	}
+	return nil
 }
 
 // TransactionNotifications is a notification of changes to the wallet's
@@ -370,19 +440,23 @@ type TransactionSummary struct {
 
 // TransactionSummaryInput describes a transaction input that is relevant to the
 // wallet.  The Index field marks the transaction input index of the transaction
-// (not included here).  The PreviousAccount and PreviousAmount fields describe
-// how much this input debits from a wallet account.
+// (not included here).  The PreviousKeyScope, PreviousAccount and
+// PreviousAmount fields describe how much this input debits from a wallet
+// account.
 type TransactionSummaryInput struct {
-	Index           uint32
-	PreviousAccount uint32
-	PreviousAmount  btcutil.Amount
+	Index            uint32
+	PreviousKeyScope waddrmgr.KeyScope
+	PreviousAccount  uint32
+	PreviousAmount   btcutil.Amount
 }
 
 // TransactionSummaryOutput describes wallet properties of a transaction output
 // controlled by the wallet.  The Index field marks the transaction output index
-// of the transaction (not included here).
+// of the transaction (not included here).  The account of the output is
+// identified by the KeyScope and Account fields.
 type TransactionSummaryOutput struct {
 	Index    uint32
+	KeyScope waddrmgr.KeyScope
 	Account  uint32
 	Internal bool
 }
@@ -390,8 +464,10 @@ type TransactionSummaryOutput struct {
 // AccountBalance associates a total (zero confirmation) balance with an
 // account.  Balances for other minimum confirmation counts require more
 // expensive logic and it is not clear which minimums a client is interested in,
-// so they are not included.
+// so they are not included.  Account numbers are only unique within the key
+// scope of the account.
 type AccountBalance struct {
+	KeyScope     waddrmgr.KeyScope
 	Account      uint32
 	TotalBalance btcutil.Amount
 }
@@ -446,6 +522,48 @@ func (c *TransactionNotificationsClient) Done() {
 	}()
 }
 
+// TransactionNotificationsHandler handles a transaction notification within
+// the database transaction of the wallet which caused it.
+type TransactionNotificationsHandler func(walletdb.ReadWriteTx,
+	*TransactionNotifications) error
+
+// txHandler is a registered TransactionNotificationsHandler.
+type txHandler struct {
+	handle TransactionNotificationsHandler
+}
+
+// HandleTransactionNotifications registers a handler which is called with
+// every transaction notification before the database transaction of the wallet
+// which caused it is committed.  Unlike clients of TransactionNotifications,
+// a handler persisting the notification in the wallet database can't lose it
+// in a crash, as its changes are committed together with those of the wallet.
+// An error returned by the handler fails the database transaction, so neither
+// the changes of the wallet nor those of the handler are committed.
+//
+// The handler must not block or open another database transaction.  The
+// returned function deregisters the handler.
+func (s *NotificationServer) HandleTransactionNotifications(
+	handle TransactionNotificationsHandler) func() {
+
+	h := &txHandler{handle: handle}
+	s.mu.Lock()
+	s.txHandlers = append(s.txHandlers, h)
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, registered := range s.txHandlers {
+			if registered == h {
+				s.txHandlers = append(
+					s.txHandlers[:i:i], s.txHandlers[i+1:]...,
+				)
+				break
+			}
+		}
+	}
+}
+
 // SpentnessNotifications is a notification that is fired for transaction
 // outputs controlled by some account's keys.  The notification may be about a
 // newly added unspent transaction output or that a previously unspent output is
@@ -613,3 +731,212 @@ func (c *AccountNotificationsClient) Done() {
 		s.mu.Unlock()
 	}()
 }
+
+// InvoiceNotification is fired when the status of an invoice changes.
+type InvoiceNotification struct {
+	Invoice        *Invoice
+	PreviousStatus InvoiceStatus
+}
+
+func (s *NotificationServer) notifyInvoice(inv *Invoice, prevStatus InvoiceStatus) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	clients := s.invoiceClients
+	if len(clients) == 0 {
+		return
+	}
+	n := &InvoiceNotification{
+		Invoice:        inv,
+		PreviousStatus: prevStatus,
+	}
+	for _, c := range clients {
+		c <- n
+	}
+}
+
+// InvoiceNotificationsClient receives InvoiceNotifications over the channel C.
+type InvoiceNotificationsClient struct {
+	C      chan *InvoiceNotification
+	server *NotificationServer
+}
+
+// InvoiceNotifications returns a client for receiving InvoiceNotifications over
+// a channel.  The channel is unbuffered.  When finished, the client's Done
+// method should be called to disassociate the client from the server.
+func (s *NotificationServer) InvoiceNotifications() InvoiceNotificationsClient {
+	c := make(chan *InvoiceNotification)
+	s.mu.Lock()
+	s.invoiceClients = append(s.invoiceClients, c)
+	s.mu.Unlock()
+	return InvoiceNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *InvoiceNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.invoiceClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.invoiceClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// LockStateNotification is fired when the wallet is locked or unlocked.
+type LockStateNotification struct {
+	Locked bool
+}
+
+func (s *NotificationServer) notifyLockState(locked bool) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	clients := s.lockStateClients
+	if len(clients) == 0 {
+		return
+	}
+	n := &LockStateNotification{Locked: locked}
+	for _, c := range clients {
+		// Never wait for the client, as the wallet is locking or
+		// unlocking.  A notification the client has not received yet
+		// is stale and replaced by the new lock state.  The client
+		// can not be closed meanwhile, as Done holds the mutex to
+		// close it.
+		for sent := false; !sent; {
+			select {
+			case c <- n:
+				sent = true
+			default:
+				select {
+				case <-c:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// LockStateNotificationsClient receives LockStateNotifications over the
+// channel C.
+type LockStateNotificationsClient struct {
+	C      chan *LockStateNotification
+	server *NotificationServer
+}
+
+// LockStateNotifications returns a client for receiving
+// LockStateNotifications over a channel.  The channel buffers a single
+// notification, which is replaced by the next one if it is not received in
+// time, so slow clients only miss intermediate lock states and never hold up
+// locking or unlocking the wallet.  When finished, the client's Done method
+// should be called to disassociate the client from the server.
+func (s *NotificationServer) LockStateNotifications() LockStateNotificationsClient {
+	c := make(chan *LockStateNotification, 1)
+	s.mu.Lock()
+	s.lockStateClients = append(s.lockStateClients, c)
+	s.mu.Unlock()
+	return LockStateNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *LockStateNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.lockStateClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.lockStateClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// RescanNotification reports the progress of the rescan performed for a batch
+// of rescan jobs.  It is fired for every block the chain server reports
+// progress for, and when the rescan finishes.
+type RescanNotification struct {
+	Hash     *chainhash.Hash
+	Height   int32
+	Time     time.Time
+	Finished bool
+}
+
+func (s *NotificationServer) notifyRescan(n *RescanNotification) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.rescanClients {
+		c <- n
+	}
+}
+
+// RescanNotificationsClient receives RescanNotifications over the channel C.
+type RescanNotificationsClient struct {
+	C      chan *RescanNotification
+	server *NotificationServer
+}
+
+// RescanNotifications returns a client for receiving RescanNotifications over
+// a channel.  The channel is unbuffered.  When finished, the client's Done
+// method should be called to disassociate the client from the server.
+func (s *NotificationServer) RescanNotifications() RescanNotificationsClient {
+	c := make(chan *RescanNotification)
+	s.mu.Lock()
+	s.rescanClients = append(s.rescanClients, c)
+	s.mu.Unlock()
+	return RescanNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *RescanNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.rescanClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.rescanClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestLockStateNotifications tests that lock state notifications are sent
+// when the lock state of the wallet changes, including when a failed unlock
+// locks the wallet, and that clients which do not receive them in time don't
+// hold up the wallet and only miss intermediate lock states.
+func TestLockStateNotifications(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	ntfns := w.NtfnServer.LockStateNotifications()
+	defer ntfns.Done()
+
+	assertNotification := func(locked bool) {
+		t.Helper()
+
+		select {
+		case n := <-ntfns.C:
+			if n.Locked != locked {
+				t.Fatalf("got locked %v, want %v", n.Locked,
+					locked)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notification with locked %v", locked)
+		}
+	}
+	assertNoNotification := func() {
+		t.Helper()
+
+		select {
+		case n := <-ntfns.C:
+			t.Fatalf("unexpected notification with locked %v",
+				n.Locked)
+		default:
+		}
+	}
+	unlock := func(passphrase string) error {
+		return w.Unlock([]byte(passphrase), nil)
+	}
+
+	w.Lock()
+	assertNotification(true)
+
+	if err := unlock("world"); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	assertNotification(false)
+
+	// Unlocking the unlocked wallet does not change its lock state, so the
+	// next notification is sent by the failed unlock locking the wallet.
+	if err := unlock("world"); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	if err := unlock("wrong"); err == nil {
+		t.Fatal("unlocked wallet with the wrong passphrase")
+	}
+	assertNotification(true)
+
+	// Locking the locked wallet does not change its lock state either.
+	w.Lock()
+	if err := unlock("world"); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	assertNotification(false)
+	assertNoNotification()
+
+	// Lock state changes the client hasn't received are replaced by the
+	// latest lock state.  Lock returns before the wallet is locked, which
+	// Locked waits for.
+	w.Lock()
+	if err := unlock("world"); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	w.Lock()
+	if !w.Locked() {
+		t.Fatal("wallet not locked")
+	}
+	assertNotification(true)
+	assertNoNotification()
+}
+
+// TestTransactionNotificationsHandler tests that transaction notification
+// handlers are called within the database transaction of the wallet, so their
+// changes are committed with it, and aren't called once deregistered.
+func TestTransactionNotificationsHandler(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucketKey := []byte("handled")
+	var handled int
+	remove := w.NtfnServer.HandleTransactionNotifications(
+		func(tx walletdb.ReadWriteTx, n *TransactionNotifications) error {
+			handled++
+			b, err := tx.CreateTopLevelBucket(bucketKey)
+			if err != nil {
+				return err
+			}
+			txHash := n.UnminedTransactions[0].Hash
+			return b.Put(txHash[:], []byte{1})
+		},
+	)
+
+	// receive adds an unmined transaction paying to the wallet.
+	var index uint32
+	receive := func() *wtxmgr.TxRecord {
+		t.Helper()
+
+		index++
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: index},
+		})
+		tx.AddTxOut(wire.NewTxOut(100000, pkScript))
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			return w.addRelevantTx(tx, rec, nil)
+		})
+		if err != nil {
+			t.Fatalf("unable to add transaction: %v", err)
+		}
+		return rec
+	}
+
+	rec := receive()
+	if handled != 1 {
+		t.Fatalf("handler called %d times, want 1", handled)
+	}
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		b := tx.ReadBucket(bucketKey)
+		if b == nil || b.Get(rec.Hash[:]) == nil {
+			t.Fatal("changes of the handler were not committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remove()
+	receive()
+	if handled != 1 {
+		t.Fatalf("handler called after it was deregistered")
+	}
+}
+
+// TestTransactionNotificationsHandlerError tests that a failing transaction
+// notification handler fails the database transaction of the wallet, so
+// neither the changes of the wallet nor those of the handler are committed.
+func TestTransactionNotificationsHandlerError(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucketKey := []byte("handled")
+	remove := w.NtfnServer.HandleTransactionNotifications(
+		func(tx walletdb.ReadWriteTx, n *TransactionNotifications) error {
+			if _, err := tx.CreateTopLevelBucket(bucketKey); err != nil {
+				return err
+			}
+			return errors.New("handler failed")
+		},
+	)
+	defer remove()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 1}})
+	tx.AddTxOut(wire.NewTxOut(100000, pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, nil)
+	})
+	if err == nil {
+		t.Fatal("transaction added although its handler failed")
+	}
+
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		if tx.ReadBucket(bucketKey) != nil {
+			t.Fatal("changes of the handler were committed")
+		}
+		details, err := w.TxStore.TxDetails(
+			tx.ReadBucket(wtxmgrNamespaceKey), &rec.Hash,
+		)
+		if err != nil {
+			return err
+		}
+		if details != nil {
+			t.Fatal("transaction was committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBalanceNotificationScopes tests that the outputs and balances of
+// transaction notifications identify accounts by key scope and number, so the
+// accounts with the same number in different scopes are not confused.
+func TestBalanceNotificationScopes(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	amounts := map[waddrmgr.KeyScope]btcutil.Amount{
+		waddrmgr.KeyScopeBIP0044: 100000,
+		waddrmgr.KeyScopeBIP0084: 200000,
+	}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 1}})
+	for _, scope := range []waddrmgr.KeyScope{
+		waddrmgr.KeyScopeBIP0044, waddrmgr.KeyScopeBIP0084,
+	} {
+		addr, err := w.NewAddress(0, scope)
+		if err != nil {
+			t.Fatalf("unable to create address: %v", err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(amounts[scope]), pkScript))
+	}
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ntfn *TransactionNotifications
+	remove := w.NtfnServer.HandleTransactionNotifications(
+		func(_ walletdb.ReadWriteTx, n *TransactionNotifications) error {
+			ntfn = n
+			return nil
+		},
+	)
+	defer remove()
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, nil)
+	})
+	if err != nil {
+		t.Fatalf("unable to add transaction: %v", err)
+	}
+	if ntfn == nil || len(ntfn.UnminedTransactions) != 1 {
+		t.Fatal("transaction not notified")
+	}
+
+	outputs := ntfn.UnminedTransactions[0].MyOutputs
+	if len(outputs) != 2 {
+		t.Fatalf("got %d outputs, want 2", len(outputs))
+	}
+	for _, output := range outputs {
+		value := btcutil.Amount(tx.TxOut[output.Index].Value)
+		if output.Account != 0 || amounts[output.KeyScope] != value {
+			t.Errorf("output %d of %v paid to account %d of "+
+				"scope %v", output.Index, value, output.Account,
+				output.KeyScope)
+		}
+	}
+
+	if len(ntfn.NewBalances) != 2 {
+		t.Fatalf("got %d balances, want 2", len(ntfn.NewBalances))
+	}
+	for _, bal := range ntfn.NewBalances {
+		if bal.Account != 0 || bal.TotalBalance != amounts[bal.KeyScope] {
+			t.Errorf("got balance %v of account %d of scope %v",
+				bal.TotalBalance, bal.Account, bal.KeyScope)
+		}
+	}
+}
@@ -0,0 +1,925 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// payjoinVersion is the version of the payjoin protocol implemented
+	// by the wallet.
+	payjoinVersion = 1
+
+	// maxPayjoinRequestSize is the maximum size of the body of a payjoin
+	// request or response.
+	maxPayjoinRequestSize = 1 << 20
+
+	// PayjoinLeaseDuration is the duration for which the receiver leases
+	// the output it adds to a payjoin proposal, giving the sender time to
+	// broadcast the payjoin transaction.
+	PayjoinLeaseDuration = 10 * time.Minute
+)
+
+// payjoinLockID is the lease ID of the outputs added to payjoin proposals.
+var payjoinLockID = wtxmgr.LockID(chainhash.HashH([]byte("payjoin")))
+
+// Error codes a payjoin receiver responds with, as defined by BIP0078.
+const (
+	PayjoinUnavailable        = "unavailable"
+	PayjoinNotEnoughMoney     = "not-enough-money"
+	PayjoinVersionUnsupported = "version-unsupported"
+	PayjoinOriginalRejected   = "original-psbt-rejected"
+)
+
+// PayjoinError is the error a payjoin receiver responds with if it doesn't
+// create a proposal.
+type PayjoinError struct {
+	Code    string `json:"errorCode"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *PayjoinError) Error() string {
+	return fmt.Sprintf("payjoin error %s: %s", e.Code, e.Message)
+}
+
+// payjoinError creates a PayjoinError.
+func payjoinError(code, format string, args ...interface{}) *PayjoinError {
+	return &PayjoinError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// PayjoinURI is a BIP0021 payment request with a payjoin endpoint.
+type PayjoinURI struct {
+	// Address is the address to pay to.
+	Address btcutil.Address
+
+	// Amount is the requested amount.
+	Amount btcutil.Amount
+
+	// Endpoint is the URL the original PSBT is posted to.
+	Endpoint *url.URL
+
+	// OutputSubstitution is set unless the receiver disabled the
+	// substitution of its output with the pjos=0 parameter.
+	OutputSubstitution bool
+}
+
+// ParsePayjoinURI parses a BIP0021 URI requesting a payjoin to an address of
+// the network. The endpoint given by the pj parameter must use HTTPS, unless it
+// is an onion service.
+func ParsePayjoinURI(uri string, params *chaincfg.Params) (*PayjoinURI, error) {
+	const scheme = "bitcoin:"
+	if len(uri) < len(scheme) ||
+		!strings.EqualFold(uri[:len(scheme)], scheme) {
+
+		return nil, errors.New("not a bitcoin URI")
+	}
+	uri = uri[len(scheme):]
+
+	var query string
+	if i := strings.IndexByte(uri, '?'); i >= 0 {
+		uri, query = uri[:i], uri[i+1:]
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI parameters: %v", err)
+	}
+
+	addr, err := btcutil.DecodeAddress(uri, params)
+	if err != nil {
+		return nil, err
+	}
+	if !addr.IsForNet(params) {
+		return nil, fmt.Errorf("address %v is not intended for use "+
+			"on %v", uri, params.Name)
+	}
+	result := &PayjoinURI{Address: addr, OutputSubstitution: true}
+
+	for key := range values {
+		// Unknown required parameters must be rejected.
+		if strings.HasPrefix(key, "req-") {
+			return nil, fmt.Errorf("unsupported required "+
+				"parameter %s", key)
+		}
+	}
+	if v := values.Get("amount"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %q", v)
+		}
+		result.Amount, err = btcutil.NewAmount(f)
+		if err != nil || result.Amount <= 0 {
+			return nil, fmt.Errorf("invalid amount %q", v)
+		}
+	}
+
+	pj := values.Get("pj")
+	if pj == "" {
+		return nil, errors.New("URI has no payjoin endpoint")
+	}
+	result.Endpoint, err = url.Parse(pj)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payjoin endpoint: %v", err)
+	}
+	switch {
+	case result.Endpoint.Scheme == "https":
+	case result.Endpoint.Scheme == "http" &&
+		strings.HasSuffix(result.Endpoint.Hostname(), ".onion"):
+	default:
+		return nil, fmt.Errorf("payjoin endpoint %v must use HTTPS",
+			result.Endpoint)
+	}
+	result.OutputSubstitution = values.Get("pjos") != "0"
+
+	return result, nil
+}
+
+// PayjoinParams are the optional parameters of a payjoin request.
+type PayjoinParams struct {
+	// AdditionalFeeOutputIndex is the index of the sender's output the
+	// receiver may subtract fees from, or -1 if there is none.
+	AdditionalFeeOutputIndex int32
+
+	// MaxAdditionalFeeContribution is the maximum amount the receiver may
+	// subtract from the additional fee output.
+	MaxAdditionalFeeContribution btcutil.Amount
+
+	// MinFeeRate is the minimum fee rate of the proposal in satoshis per
+	// kilobyte, or zero if any fee rate is accepted.
+	MinFeeRate btcutil.Amount
+
+	// DisableOutputSubstitution forbids the receiver to change the script
+	// or decrease the amount of its output.
+	DisableOutputSubstitution bool
+}
+
+// query encodes the parameters as the query of a payjoin request.
+func (p *PayjoinParams) query(q url.Values) {
+	q.Set("v", strconv.Itoa(payjoinVersion))
+	if p.AdditionalFeeOutputIndex >= 0 {
+		q.Set("additionalfeeoutputindex",
+			strconv.Itoa(int(p.AdditionalFeeOutputIndex)))
+		q.Set("maxadditionalfeecontribution",
+			strconv.FormatInt(int64(p.MaxAdditionalFeeContribution), 10))
+	}
+	if p.MinFeeRate > 0 {
+		// The fee rate is given in satoshis per virtual byte.
+		q.Set("minfeerate", strconv.FormatFloat(
+			float64(p.MinFeeRate)/1000, 'f', -1, 64,
+		))
+	}
+	if p.DisableOutputSubstitution {
+		q.Set("disableoutputsubstitution", "true")
+	}
+}
+
+// parsePayjoinParams decodes the parameters of a payjoin request.
+func parsePayjoinParams(q url.Values) (*PayjoinParams, error) {
+	p := &PayjoinParams{AdditionalFeeOutputIndex: -1}
+	if v := q.Get("additionalfeeoutputindex"); v != "" {
+		index, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid additional fee output "+
+				"index %q", v)
+		}
+		p.AdditionalFeeOutputIndex = int32(index)
+	}
+	if v := q.Get("maxadditionalfeecontribution"); v != "" {
+		amount, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid maximum additional fee "+
+				"contribution %q", v)
+		}
+		p.MaxAdditionalFeeContribution = btcutil.Amount(amount)
+	}
+	if v := q.Get("minfeerate"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid minimum fee rate %q", v)
+		}
+		p.MinFeeRate = btcutil.Amount(rate * 1000)
+	}
+	p.DisableOutputSubstitution = q.Get("disableoutputsubstitution") == "true"
+	return p, nil
+}
+
+// RequestPayjoin posts the original PSBT of a payment to the payjoin endpoint
+// of the receiver and returns its payjoin proposal. The proposal must be
+// checked before it is signed. A PayjoinError is returned if the receiver
+// declined the request.
+func RequestPayjoin(client *http.Client, endpoint *url.URL,
+	original *psbt.Packet, params *PayjoinParams) (*psbt.Packet, error) {
+
+	var body bytes.Buffer
+	if err := original.Serialize(&body); err != nil {
+		return nil, err
+	}
+	b64 := []byte(base64.StdEncoding.EncodeToString(body.Bytes()))
+
+	reqURL := *endpoint
+	q := reqURL.Query()
+	params.query(q)
+	reqURL.RawQuery = q.Encode()
+
+	resp, err := client.Post(
+		reqURL.String(), "text/plain", bytes.NewReader(b64),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(
+		io.LimitReader(resp.Body, maxPayjoinRequestSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var pjErr PayjoinError
+		if json.Unmarshal(respBody, &pjErr) == nil && pjErr.Code != "" {
+			return nil, &pjErr
+		}
+		return nil, fmt.Errorf("payjoin endpoint responded with %v",
+			resp.Status)
+	}
+
+	return psbt.NewFromRawBytes(bytes.NewReader(bytes.TrimSpace(respBody)),
+		true)
+}
+
+// psbtInputUtxo returns the output spent by an input, or nil if the input has
+// no UTXO information.
+func psbtInputUtxo(in *psbt.PInput, prevOut *wire.OutPoint) *wire.TxOut {
+	switch {
+	case in.WitnessUtxo != nil:
+		return in.WitnessUtxo
+	case in.NonWitnessUtxo != nil &&
+		int(prevOut.Index) < len(in.NonWitnessUtxo.TxOut):
+
+		return in.NonWitnessUtxo.TxOut[prevOut.Index]
+	default:
+		return nil
+	}
+}
+
+// psbtFeeRate returns the fee of a signed transaction along with its fee rate
+// in satoshis per kilobyte. The inputs describe the outputs spent by the
+// transaction.
+func psbtFeeRate(tx *wire.MsgTx, inputs []psbt.PInput) (btcutil.Amount,
+	btcutil.Amount, error) {
+
+	var fee int64
+	for i, txIn := range tx.TxIn {
+		utxo := psbtInputUtxo(&inputs[i], &txIn.PreviousOutPoint)
+		if utxo == nil {
+			return 0, 0, fmt.Errorf("input %d has no UTXO "+
+				"information", i)
+		}
+		fee += utxo.Value
+	}
+	for _, txOut := range tx.TxOut {
+		fee -= txOut.Value
+	}
+	if fee < 0 {
+		return 0, 0, errors.New("outputs exceed the inputs")
+	}
+
+	vSize := (blockchain.GetTransactionWeight(btcutil.NewTx(tx)) +
+		blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor
+	return btcutil.Amount(fee), btcutil.Amount(fee * 1000 / vSize), nil
+}
+
+// clearPsbtDerivations removes the BIP0032 derivations of every input and
+// output, which must not be shared with the other party of a payjoin.
+func clearPsbtDerivations(packet *psbt.Packet) {
+	for i := range packet.Inputs {
+		packet.Inputs[i].Bip32Derivation = nil
+	}
+	for i := range packet.Outputs {
+		packet.Outputs[i].Bip32Derivation = nil
+	}
+}
+
+// SendPayjoin pays the receiver of a payjoin URI with a packet funded by
+// FundPsbt, which must contain an output paying the URI's address. The
+// original transaction is signed and posted to the payjoin endpoint, and the
+// proposal of the receiver is checked against the rules of BIP0078 before it
+// is signed and broadcast. The change output may be used to contribute to the
+// fees of the inputs added by the receiver at the given fee rate, which is also
+// the minimum fee rate accepted for the proposal.
+//
+// If the receiver doesn't respond with a valid proposal, the original
+// transaction is broadcast instead. The broadcast transaction is returned
+// along with whether it is the payjoin transaction.
+func (w *Wallet) SendPayjoin(client *http.Client, packet *psbt.Packet,
+	changeIndex int32, feeSatPerKB btcutil.Amount, uri *PayjoinURI,
+	label string) (*wire.MsgTx, bool, error) {
+
+	payeeScript, err := txscript.PayToAddrScript(uri.Address)
+	if err != nil {
+		return nil, false, err
+	}
+	paymentIndex := -1
+	for i, txOut := range packet.UnsignedTx.TxOut {
+		if bytes.Equal(txOut.PkScript, payeeScript) {
+			paymentIndex = i
+			break
+		}
+	}
+	if paymentIndex < 0 {
+		return nil, false, fmt.Errorf("PSBT does not pay to %v",
+			uri.Address)
+	}
+
+	// The unsigned inputs are kept to sign the proposal, as finalizing
+	// removes their scripts.
+	inputs := append([]psbt.PInput(nil), packet.Inputs...)
+
+	if _, err := w.SignPsbt(packet); err != nil {
+		return nil, false, err
+	}
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		return nil, false, fmt.Errorf("unable to finalize original "+
+			"transaction: %v", err)
+	}
+	originalTx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, false, err
+	}
+	clearPsbtDerivations(packet)
+
+	// The receiver may subtract the fees of one input of the type of ours
+	// from the change output.
+	params := &PayjoinParams{
+		AdditionalFeeOutputIndex:  changeIndex,
+		MinFeeRate:                feeSatPerKB,
+		DisableOutputSubstitution: !uri.OutputSubstitution,
+	}
+	if changeIndex >= 0 {
+		_, feeRate, err := psbtFeeRate(originalTx, packet.Inputs)
+		if err != nil {
+			return nil, false, err
+		}
+		utxo := psbtInputUtxo(
+			&inputs[0], &originalTx.TxIn[0].PreviousOutPoint,
+		)
+		vSize := txsizes.GetMinInputVirtualSize(utxo.PkScript)
+		params.MaxAdditionalFeeContribution = feeRate *
+			btcutil.Amount(vSize) / 1000
+	}
+
+	tx, err := w.payjoin(
+		client, uri.Endpoint, packet, inputs, params, paymentIndex,
+	)
+	if err == nil {
+		err = w.PublishTransaction(tx, label)
+		if err == nil {
+			return tx, true, nil
+		}
+	}
+
+	log.Warnf("Payjoin with %v failed, broadcasting original "+
+		"transaction: %v", uri.Endpoint.Host, err)
+	if err := w.PublishTransaction(originalTx, label); err != nil {
+		return nil, false, err
+	}
+	return originalTx, false, nil
+}
+
+// payjoin requests a payjoin proposal for the original PSBT, checks and signs
+// it and returns the final transaction.
+func (w *Wallet) payjoin(client *http.Client, endpoint *url.URL,
+	original *psbt.Packet, inputs []psbt.PInput, params *PayjoinParams,
+	paymentIndex int) (*wire.MsgTx, error) {
+
+	proposal, err := RequestPayjoin(client, endpoint, original, params)
+	if err != nil {
+		return nil, err
+	}
+	err = checkPayjoinProposal(original, proposal, params, paymentIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restore the information needed to sign our inputs, which was
+	// cleared by the receiver.
+	ours := make(map[wire.OutPoint]int, len(inputs))
+	for i, txIn := range original.UnsignedTx.TxIn {
+		ours[txIn.PreviousOutPoint] = i
+	}
+	for i, txIn := range proposal.UnsignedTx.TxIn {
+		if j, ok := ours[txIn.PreviousOutPoint]; ok {
+			proposal.Inputs[i] = inputs[j]
+		}
+	}
+
+	if _, err := w.SignPsbt(proposal); err != nil {
+		return nil, err
+	}
+	if err := psbt.MaybeFinalizeAll(proposal); err != nil {
+		return nil, fmt.Errorf("unable to finalize payjoin "+
+			"transaction: %v", err)
+	}
+	tx, err := psbt.Extract(proposal)
+	if err != nil {
+		return nil, err
+	}
+
+	_, feeRate, err := psbtFeeRate(tx, proposal.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	if feeRate < params.MinFeeRate {
+		return nil, fmt.Errorf("fee rate of payjoin proposal %v/kB is "+
+			"below %v/kB", feeRate, params.MinFeeRate)
+	}
+	return tx, nil
+}
+
+// checkPayjoinProposal checks a payjoin proposal against the original PSBT as
+// required of the sender by BIP0078. Apart from fees paid from the additional
+// fee output, the proposal must not spend any of the sender's funds.
+func checkPayjoinProposal(original, proposal *psbt.Packet,
+	params *PayjoinParams, paymentIndex int) error {
+
+	origTx, propTx := original.UnsignedTx, proposal.UnsignedTx
+	if err := psbt.VerifyInputOutputLen(proposal, true, true); err != nil {
+		return err
+	}
+	if propTx.Version != origTx.Version {
+		return errors.New("proposal changed the transaction version")
+	}
+	if propTx.LockTime != origTx.LockTime {
+		return errors.New("proposal changed the lock time")
+	}
+
+	// The receiver's inputs must be of the type of the sender's inputs, if
+	// all of them are of the same type.
+	origInputs := make(map[wire.OutPoint]int, len(origTx.TxIn))
+	inputClass := txscript.NonStandardTy
+	var inputScript []byte
+	for i, txIn := range origTx.TxIn {
+		origInputs[txIn.PreviousOutPoint] = i
+		utxo := psbtInputUtxo(&original.Inputs[i], &txIn.PreviousOutPoint)
+		if utxo == nil {
+			return fmt.Errorf("original input %d has no UTXO "+
+				"information", i)
+		}
+		class := txscript.GetScriptClass(utxo.PkScript)
+		if i == 0 {
+			inputClass, inputScript = class, utxo.PkScript
+		} else if class != inputClass {
+			inputClass = txscript.NonStandardTy
+		}
+	}
+
+	sequence := origTx.TxIn[0].Sequence
+	var found int
+	for i, txIn := range propTx.TxIn {
+		in := &proposal.Inputs[i]
+		if len(in.Bip32Derivation) > 0 || len(in.PartialSigs) > 0 {
+			return fmt.Errorf("proposal input %d has derivations "+
+				"or partial signatures", i)
+		}
+		if txIn.Sequence != sequence {
+			return fmt.Errorf("proposal input %d has a different "+
+				"sequence", i)
+		}
+
+		if _, ok := origInputs[txIn.PreviousOutPoint]; ok {
+			if isFinalized(in) {
+				return fmt.Errorf("sender input %d of proposal "+
+					"is finalized", i)
+			}
+			if in.WitnessUtxo != nil || in.NonWitnessUtxo != nil {
+				return fmt.Errorf("sender input %d of proposal "+
+					"has UTXO information", i)
+			}
+			found++
+			continue
+		}
+
+		if !isFinalized(in) {
+			return fmt.Errorf("receiver input %d is not finalized", i)
+		}
+		utxo := psbtInputUtxo(in, &txIn.PreviousOutPoint)
+		if utxo == nil {
+			return fmt.Errorf("receiver input %d has no UTXO "+
+				"information", i)
+		}
+		if inputClass != txscript.NonStandardTy &&
+			txscript.GetScriptClass(utxo.PkScript) != inputClass {
+
+			return fmt.Errorf("receiver input %d is of another "+
+				"type than the sender's inputs", i)
+		}
+	}
+	if found != len(origTx.TxIn) {
+		return errors.New("proposal is missing sender inputs")
+	}
+	added := len(propTx.TxIn) - len(origTx.TxIn)
+
+	origFee, origFeeRate, err := psbtFeeRate(origTx, original.Inputs)
+	if err != nil {
+		return err
+	}
+	var propFee int64
+	for i, txIn := range propTx.TxIn {
+		if j, ok := origInputs[txIn.PreviousOutPoint]; ok {
+			propFee += psbtInputUtxo(
+				&original.Inputs[j], &txIn.PreviousOutPoint,
+			).Value
+			continue
+		}
+		propFee += psbtInputUtxo(
+			&proposal.Inputs[i], &txIn.PreviousOutPoint,
+		).Value
+	}
+	for _, txOut := range propTx.TxOut {
+		propFee -= txOut.Value
+	}
+
+	// Every original output must be kept, with the exception of the
+	// payment output if it may be substituted. Outputs not in the original
+	// transaction belong to the receiver.
+	matched := make([]bool, len(origTx.TxOut))
+	for i, txOut := range propTx.TxOut {
+		if len(proposal.Outputs[i].Bip32Derivation) > 0 {
+			return fmt.Errorf("proposal output %d has derivations", i)
+		}
+
+		j := -1
+		for k, origOut := range origTx.TxOut {
+			if !matched[k] &&
+				bytes.Equal(origOut.PkScript, txOut.PkScript) {
+
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			continue
+		}
+		matched[j] = true
+		origOut := origTx.TxOut[j]
+
+		switch {
+		case j == int(params.AdditionalFeeOutputIndex):
+			contribution := btcutil.Amount(origOut.Value - txOut.Value)
+			if contribution <= 0 {
+				if contribution < 0 {
+					return errors.New("proposal increased " +
+						"the additional fee output")
+				}
+				continue
+			}
+			if contribution > params.MaxAdditionalFeeContribution {
+				return fmt.Errorf("fee contribution %v exceeds "+
+					"the maximum of %v", contribution,
+					params.MaxAdditionalFeeContribution)
+			}
+			if contribution > btcutil.Amount(propFee)-origFee {
+				return errors.New("fee contribution is not " +
+					"paying fees")
+			}
+			vSize := txsizes.GetMinInputVirtualSize(inputScript)
+			if contribution > origFeeRate*btcutil.Amount(added*vSize)/1000 {
+
+				return errors.New("fee contribution exceeds " +
+					"the fees of the added inputs")
+			}
+
+		case j == paymentIndex:
+			if params.DisableOutputSubstitution &&
+				txOut.Value < origOut.Value {
+
+				return errors.New("proposal decreased the " +
+					"payment output")
+			}
+
+		case txOut.Value != origOut.Value:
+			return fmt.Errorf("proposal changed output %d", j)
+		}
+	}
+	for j, ok := range matched {
+		if ok {
+			continue
+		}
+		if j == paymentIndex && !params.DisableOutputSubstitution {
+			continue
+		}
+		return fmt.Errorf("proposal is missing output %d", j)
+	}
+
+	return nil
+}
+
+// isFinalized returns whether a partial input has its final scripts.
+func isFinalized(in *psbt.PInput) bool {
+	return len(in.FinalScriptSig) > 0 || len(in.FinalScriptWitness) > 0
+}
+
+// PayjoinReceiver is an HTTP handler of payjoin requests as described by
+// BIP0078. Any payment to an address of the wallet is turned into a payjoin
+// by adding an unspent output of the receiving account as an input and
+// increasing the payment output by its amount. The added output is leased for
+// PayjoinLeaseDuration.
+//
+// NOTE: The handler serves plain HTTP, so it must be exposed as an onion
+// service or behind a proxy terminating TLS.
+type PayjoinReceiver struct {
+	wallet  *Wallet
+	account uint32
+	minConf int32
+
+	mu   sync.Mutex
+	seen map[wire.OutPoint]struct{}
+}
+
+// NewPayjoinReceiver creates a payjoin receiver contributing outputs of the
+// account with at least minConf confirmations.
+func NewPayjoinReceiver(w *Wallet, account uint32,
+	minConf int32) *PayjoinReceiver {
+
+	return &PayjoinReceiver{
+		wallet:  w,
+		account: account,
+		minConf: minConf,
+		seen:    make(map[wire.OutPoint]struct{}),
+	}
+}
+
+// ServeHTTP responds to a payjoin request with a payjoin proposal, or with the
+// BIP0078 error explaining why no proposal is created.
+func (r *PayjoinReceiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	proposal, err := r.handleRequest(req)
+	if err != nil {
+		pjErr, ok := err.(*PayjoinError)
+		if !ok {
+			// Internal errors aren't revealed to the sender.
+			log.Errorf("Unable to create payjoin proposal: %v", err)
+			pjErr = payjoinError(PayjoinUnavailable,
+				"the receiver is unable to create a proposal")
+		}
+		var body interface{} = pjErr
+		if pjErr.Code == PayjoinVersionUnsupported {
+			body = struct {
+				*PayjoinError
+				Supported []int `json:"supported"`
+			}{pjErr, []int{payjoinVersion}}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(rw).Encode(body)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := proposal.Serialize(&buf); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/plain")
+	_, _ = io.WriteString(rw, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// handleRequest decodes a payjoin request and creates its proposal.
+func (r *PayjoinReceiver) handleRequest(req *http.Request) (*psbt.Packet,
+	error) {
+
+	q := req.URL.Query()
+	if v := q.Get("v"); v != "" && v != strconv.Itoa(payjoinVersion) {
+		return nil, payjoinError(PayjoinVersionUnsupported,
+			"version %s is not supported", v)
+	}
+	params, err := parsePayjoinParams(q)
+	if err != nil {
+		return nil, payjoinError(PayjoinOriginalRejected, "%v", err)
+	}
+
+	body, err := ioutil.ReadAll(
+		io.LimitReader(req.Body, maxPayjoinRequestSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+	original, err := psbt.NewFromRawBytes(
+		bytes.NewReader(bytes.TrimSpace(body)), true,
+	)
+	if err != nil {
+		return nil, payjoinError(PayjoinOriginalRejected,
+			"invalid PSBT: %v", err)
+	}
+
+	return r.Proposal(original, params)
+}
+
+// Proposal creates the payjoin proposal for an original PSBT paying to the
+// wallet. The original PSBT must be finalized, so the receiver can broadcast
+// it instead, and its inputs must not have been offered before.
+func (r *PayjoinReceiver) Proposal(original *psbt.Packet,
+	params *PayjoinParams) (*psbt.Packet, error) {
+
+	w := r.wallet
+	rejected := func(format string, args ...interface{}) error {
+		return payjoinError(PayjoinOriginalRejected, format, args...)
+	}
+
+	if err := psbt.VerifyInputOutputLen(original, true, true); err != nil {
+		return nil, rejected("%v", err)
+	}
+	origTx, err := psbt.Extract(original)
+	if err != nil {
+		return nil, rejected("original PSBT is not finalized")
+	}
+	_, origFeeRate, err := psbtFeeRate(origTx, original.Inputs)
+	if err != nil {
+		return nil, rejected("%v", err)
+	}
+
+	// The inputs of the sender must not be ours, and must not have been
+	// offered before, which would allow probing for our outputs.
+	for i, txIn := range origTx.TxIn {
+		utxo := psbtInputUtxo(&original.Inputs[i], &txIn.PreviousOutPoint)
+		if _, err := w.fetchOutputAddr(utxo.PkScript); err != ErrNotMine {
+			return nil, rejected("input %d spends an output of the "+
+				"receiver", i)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, txIn := range origTx.TxIn {
+		if _, ok := r.seen[txIn.PreviousOutPoint]; ok {
+			return nil, rejected("input %v was already offered",
+				txIn.PreviousOutPoint)
+		}
+	}
+
+	// The original transaction must be valid, so it can be broadcast if
+	// the sender doesn't follow up with the payjoin.
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.testMempoolAccept(chainClient, origTx); err != nil {
+		return nil, rejected("%v", err)
+	}
+	for _, txIn := range origTx.TxIn {
+		r.seen[txIn.PreviousOutPoint] = struct{}{}
+	}
+
+	receiverIndex := -1
+	for i, txOut := range origTx.TxOut {
+		if _, err := w.fetchOutputAddr(txOut.PkScript); err == nil {
+			receiverIndex = i
+			break
+		}
+	}
+	if receiverIndex < 0 {
+		return nil, rejected("original transaction doesn't pay to " +
+			"the receiver")
+	}
+
+	// Contribute an unspent output of the same type as the sender's
+	// first input, so the inputs of the transaction are not mixed.
+	senderUtxo := psbtInputUtxo(
+		&original.Inputs[0], &origTx.TxIn[0].PreviousOutPoint,
+	)
+	senderClass := txscript.GetScriptClass(senderUtxo.PkScript)
+	unspent, err := w.UnspentOutputs(OutputSelectionPolicy{
+		Account:               r.account,
+		RequiredConfirmations: r.minConf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*TransactionOutput
+	for _, output := range unspent {
+		if w.LockedOutpoint(output.OutPoint) ||
+			txscript.GetScriptClass(output.Output.PkScript) != senderClass {
+
+			continue
+		}
+		candidates = append(candidates, output)
+	}
+	if len(candidates) == 0 {
+		return nil, payjoinError(PayjoinUnavailable,
+			"no outputs to contribute")
+	}
+	contributed := candidates[rand.Intn(len(candidates))]
+	utxo := &contributed.Output
+
+	// The fees of the added input are paid at the fee rate of the
+	// original transaction, or the minimum fee rate if it is higher.
+	// The sender may contribute to them from its additional fee output.
+	feeRate := origFeeRate
+	if params.MinFeeRate > feeRate {
+		feeRate = params.MinFeeRate
+	}
+	inputFee := feeRate *
+		btcutil.Amount(txsizes.GetMinInputVirtualSize(utxo.PkScript)) /
+		1000
+	var contribution btcutil.Amount
+	feeIndex := int(params.AdditionalFeeOutputIndex)
+	if feeIndex >= 0 && feeIndex < len(origTx.TxOut) &&
+		feeIndex != receiverIndex {
+
+		contribution = inputFee
+		if contribution > params.MaxAdditionalFeeContribution {
+			contribution = params.MaxAdditionalFeeContribution
+		}
+		feeOut := *origTx.TxOut[feeIndex]
+		feeOut.Value -= int64(contribution)
+		if txrules.IsDustOutput(&feeOut, txrules.DefaultRelayFeePerKb) {
+			contribution = 0
+		}
+	}
+	receiverFee := inputFee - contribution
+	if btcutil.Amount(utxo.Value) <= receiverFee {
+		return nil, payjoinError(PayjoinNotEnoughMoney,
+			"contributed output can't pay its fees")
+	}
+
+	// Insert our input at a random position and pay it to our output.
+	proposalTx := origTx.Copy()
+	for _, txIn := range proposalTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	txIn := wire.NewTxIn(&contributed.OutPoint, nil, nil)
+	txIn.Sequence = origTx.TxIn[0].Sequence
+	inputIndex := rand.Intn(len(proposalTx.TxIn) + 1)
+	proposalTx.TxIn = append(proposalTx.TxIn, nil)
+	copy(proposalTx.TxIn[inputIndex+1:], proposalTx.TxIn[inputIndex:])
+	proposalTx.TxIn[inputIndex] = txIn
+	proposalTx.TxOut[receiverIndex].Value += utxo.Value -
+		int64(receiverFee)
+	if feeIndex >= 0 && contribution > 0 {
+		proposalTx.TxOut[feeIndex].Value -= int64(contribution)
+	}
+
+	proposal, err := psbt.NewFromUnsignedTx(proposalTx)
+	if err != nil {
+		return nil, err
+	}
+	prevTx, addr, err := w.psbtInputInfo(&contributed.OutPoint)
+	if err != nil {
+		return nil, err
+	}
+	in := &proposal.Inputs[inputIndex]
+	in.SighashType = txscript.SigHashAll
+	err = w.addPsbtInputInfo(
+		in, prevTx, contributed.OutPoint.Index, addr, false,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.SignPsbt(proposal); err != nil {
+		return nil, err
+	}
+	if err := psbt.Finalize(proposal, inputIndex); err != nil {
+		return nil, fmt.Errorf("unable to finalize input: %v", err)
+	}
+
+	_, err = w.LeaseOutput(
+		payjoinLockID, contributed.OutPoint, PayjoinLeaseDuration,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// fundPayjoinWallet adds a P2WKH output of the amount to the wallet and
+// returns the address it pays to.
+func fundPayjoinWallet(t *testing.T, w *Wallet, prevHash chainhash.Hash,
+	amount int64) btcutil.Address {
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to get current address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("unable to convert wallet address: %v", err)
+	}
+	incomingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Hash: prevHash},
+		}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(amount, pkScript)},
+	}
+	addUtxo(t, w, incomingTx)
+	return addr
+}
+
+// TestParsePayjoinURI tests the parsing of BIP0021 URIs with payjoin
+// parameters.
+func TestParsePayjoinURI(t *testing.T) {
+	t.Parallel()
+
+	const addr = "tb1qcr8te4kr609gcawutmrza0j4xv80jy8zmfp6l0"
+	tests := []struct {
+		uri          string
+		amount       btcutil.Amount
+		substitution bool
+		valid        bool
+	}{
+		{"bitcoin:" + addr + "?amount=0.01&pj=https://example.com/pj",
+			1000000, true, true},
+		{"BITCOIN:" + addr + "?pj=https%3A%2F%2Fexample.com%2Fpj%3Fa%3Db&pjos=0",
+			0, false, true},
+		{"bitcoin:" + addr + "?pj=http://example.onion/pj", 0, true, true},
+		{"bitcoin:" + addr + "?pj=http://example.com/pj", 0, true, false},
+		{"bitcoin:" + addr + "?amount=0.01", 0, true, false},
+		{"bitcoin:" + addr + "?pj=https://example.com&req-x=1", 0, true,
+			false},
+		{"bitcoin:" + addr + "?pj=https://example.com&amount=-1", 0, true,
+			false},
+		{"bitcoin:bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" +
+			"?pj=https://example.com", 0, true, false},
+	}
+	for _, test := range tests {
+		uri, err := ParsePayjoinURI(test.uri, &chaincfg.TestNet3Params)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.uri)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unable to parse: %v", test.uri, err)
+			continue
+		}
+		if uri.Address.EncodeAddress() != addr ||
+			uri.Amount != test.amount ||
+			uri.OutputSubstitution != test.substitution {
+
+			t.Errorf("%s: unexpected result %+v", test.uri, uri)
+		}
+	}
+}
+
+// TestPayjoin tests a payjoin between two wallets, and that the sender falls
+// back to the original transaction if the receiver declines the request.
+func TestPayjoin(t *testing.T) {
+	sender, cleanup := testWallet(t)
+	defer cleanup()
+	receiver, cleanup := testWallet(t)
+	defer cleanup()
+
+	fundPayjoinWallet(t, sender, chainhash.Hash{1}, 5000000)
+	receiverAddr := fundPayjoinWallet(t, receiver, chainhash.Hash{2}, 2000000)
+
+	payjoinReceiver := NewPayjoinReceiver(receiver, 0, 0)
+	server := httptest.NewTLSServer(payjoinReceiver)
+	defer server.Close()
+
+	uri, err := ParsePayjoinURI(
+		"bitcoin:"+receiverAddr.EncodeAddress()+"?amount=0.01&pj="+
+			server.URL, receiver.ChainParams(),
+	)
+	if err != nil {
+		t.Fatalf("unable to parse URI: %v", err)
+	}
+
+	pay := func() (*psbt.Packet, int32) {
+		pkScript, err := txscript.PayToAddrScript(uri.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packet, err := psbt.New(
+			nil, []*wire.TxOut{wire.NewTxOut(int64(uri.Amount), pkScript)},
+			2, 0, nil,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changeIndex, err := sender.FundPsbt(
+			packet, nil, 1, 0, 2000, CoinSelectionLargest,
+		)
+		if err != nil {
+			t.Fatalf("unable to fund PSBT: %v", err)
+		}
+		return packet, changeIndex
+	}
+
+	packet, changeIndex := pay()
+	original := packet.UnsignedTx.Copy()
+	tx, payjoin, err := sender.SendPayjoin(
+		server.Client(), packet, changeIndex, 2000, uri, "",
+	)
+	if err != nil {
+		t.Fatalf("unable to send payjoin: %v", err)
+	}
+	if !payjoin {
+		t.Fatal("expected payjoin transaction")
+	}
+	if len(tx.TxIn) != 2 || len(tx.TxOut) != len(original.TxOut) {
+		t.Fatalf("unexpected payjoin transaction with %d inputs and "+
+			"%d outputs", len(tx.TxIn), len(tx.TxOut))
+	}
+
+	// The receiver's output includes its contributed input, minus the
+	// fees not paid by the sender's change.
+	var outputs int64
+	for i, txOut := range tx.TxOut {
+		outputs += txOut.Value
+		if i != int(changeIndex) &&
+			txOut.Value <= 1000000+2000000-1000 {
+
+			t.Fatalf("unexpected receiver output value %v",
+				txOut.Value)
+		}
+	}
+	if fee := 5000000 + 2000000 - outputs; fee <= 0 || fee > 1000 {
+		t.Fatalf("unexpected fee %v", fee)
+	}
+
+	// The contributed output is leased, so another payment falls back to
+	// the original transaction.
+	fundPayjoinWallet(t, sender, chainhash.Hash{3}, 5000000)
+	packet, changeIndex = pay()
+	tx, payjoin, err = sender.SendPayjoin(
+		server.Client(), packet, changeIndex, 2000, uri, "",
+	)
+	if err != nil {
+		t.Fatalf("unable to send payment: %v", err)
+	}
+	if payjoin || len(tx.TxIn) != 1 {
+		t.Fatal("expected original transaction")
+	}
+
+	// Inputs that were offered before are rejected.
+	_, err = payjoinReceiver.Proposal(
+		packet, &PayjoinParams{AdditionalFeeOutputIndex: -1},
+	)
+	if pjErr, ok := err.(*PayjoinError); !ok ||
+		pjErr.Code != PayjoinOriginalRejected {
+
+		t.Fatalf("expected rejected original, got %v", err)
+	}
+}
+
+// TestCheckPayjoinProposal tests that the sender rejects proposals that
+// violate the rules of BIP0078.
+func TestCheckPayjoinProposal(t *testing.T) {
+	sender, cleanup := testWallet(t)
+	defer cleanup()
+	receiver, cleanup := testWallet(t)
+	defer cleanup()
+
+	fundPayjoinWallet(t, sender, chainhash.Hash{1}, 5000000)
+	receiverAddr := fundPayjoinWallet(t, receiver, chainhash.Hash{2}, 2000000)
+	pkScript, err := txscript.PayToAddrScript(receiverAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet, err := psbt.New(
+		nil, []*wire.TxOut{wire.NewTxOut(1000000, pkScript)}, 2, 0, nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeIndex, err := sender.FundPsbt(
+		packet, nil, 1, 0, 2000, CoinSelectionLargest,
+	)
+	if err != nil {
+		t.Fatalf("unable to fund PSBT: %v", err)
+	}
+	paymentIndex := 1 - int(changeIndex)
+	if _, err := sender.SignPsbt(packet); err != nil {
+		t.Fatal(err)
+	}
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		t.Fatal(err)
+	}
+
+	params := &PayjoinParams{
+		AdditionalFeeOutputIndex:     changeIndex,
+		MaxAdditionalFeeContribution: 500,
+		DisableOutputSubstitution:    true,
+	}
+	newProposal := func() *psbt.Packet {
+		r := NewPayjoinReceiver(receiver, 0, 0)
+		proposal, err := r.Proposal(packet, params)
+		if err != nil {
+			t.Fatalf("unable to create proposal: %v", err)
+		}
+		// Release the contributed output for the next proposal.
+		for _, txIn := range proposal.UnsignedTx.TxIn {
+			_ = receiver.ReleaseOutput(payjoinLockID,
+				txIn.PreviousOutPoint)
+		}
+		return proposal
+	}
+
+	proposal := newProposal()
+	err = checkPayjoinProposal(packet, proposal, params, paymentIndex)
+	if err != nil {
+		t.Fatalf("valid proposal rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *psbt.Packet)
+	}{{
+		name: "lock time",
+		modify: func(p *psbt.Packet) {
+			p.UnsignedTx.LockTime++
+		},
+	}, {
+		name: "sequence",
+		modify: func(p *psbt.Packet) {
+			p.UnsignedTx.TxIn[0].Sequence--
+		},
+	}, {
+		name: "removed input",
+		modify: func(p *psbt.Packet) {
+			p.UnsignedTx.TxIn = p.UnsignedTx.TxIn[:1]
+			p.Inputs = p.Inputs[:1]
+		},
+	}, {
+		name: "unfinalized receiver input",
+		modify: func(p *psbt.Packet) {
+			for i := range p.Inputs {
+				p.Inputs[i].FinalScriptWitness = nil
+			}
+		},
+	}, {
+		name: "fee contribution",
+		modify: func(p *psbt.Packet) {
+			p.UnsignedTx.TxOut[changeIndex].Value -= 1000
+		},
+	}, {
+		name: "changed payment",
+		modify: func(p *psbt.Packet) {
+			p.UnsignedTx.TxOut[paymentIndex].Value = 1000
+		},
+	}, {
+		name: "removed change",
+		modify: func(p *psbt.Packet) {
+			p.UnsignedTx.TxOut = []*wire.TxOut{
+				p.UnsignedTx.TxOut[paymentIndex],
+			}
+			p.Outputs = p.Outputs[:1]
+		},
+	}}
+	for _, test := range tests {
+		proposal := newProposal()
+		test.modify(proposal)
+		err := checkPayjoinProposal(packet, proposal, params, paymentIndex)
+		if err == nil {
+			t.Errorf("%s: expected proposal to be rejected", test.name)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/miniscript"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+var (
+	// ErrNoAccountPolicy is returned when addresses are requested from an
+	// account of the policy key scope which has no spending policy yet.
+	ErrNoAccountPolicy = errors.New("account has no spending policy")
+
+	// policyAddrSchema is the address schema of the policy key scope.  The
+	// addresses of its accounts pay to the witness scripts of their
+	// policies, while the keys of the wallet are derived as P2WKH keys.
+	policyAddrSchema = waddrmgr.ScopeAddrSchema{
+		ExternalAddrType: waddrmgr.WitnessPubKey,
+		InternalAddrType: waddrmgr.WitnessPubKey,
+	}
+)
+
+// AccountPolicy is the spending policy of an account of the
+// waddrmgr.KeyScopePolicy key scope.
+type AccountPolicy struct {
+	// Policy is the miniscript policy of the account, in its canonical
+	// form.
+	Policy string
+
+	// Keys are the extended public keys of the key names of the policy.
+	// The witness script of each address is compiled with the child keys
+	// at the branch and index of the address.
+	Keys map[string]*hdkeychain.ExtendedKey
+
+	// Self is the key name of the account's own extended public key.
+	Self string
+}
+
+// NewPolicyAccount creates a new account of the waddrmgr.KeyScopePolicy key
+// scope, creating the key scope first if needed, and returns the account
+// number and its extended public key.  The extended public key is shared with
+// the other signers of the policy, which is then set with SetAccountPolicy.
+// The wallet must be unlocked.
+func (w *Wallet) NewPolicyAccount(name string) (uint32,
+	*hdkeychain.ExtendedKey, error) {
+
+	var (
+		account uint32
+		props   *waddrmgr.AccountProperties
+	)
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		manager, err := w.Manager.FetchScopedKeyManager(
+			waddrmgr.KeyScopePolicy,
+		)
+		if err != nil {
+			manager, err = w.Manager.NewScopedKeyManager(
+				addrmgrNs, waddrmgr.KeyScopePolicy,
+				policyAddrSchema,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		account, err = manager.NewAccount(addrmgrNs, name)
+		if err != nil {
+			return err
+		}
+		props, err = manager.AccountProperties(addrmgrNs, account)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	w.NtfnServer.notifyAccountProperties(props)
+	return account, props.AccountPubKey, nil
+}
+
+// SetAccountPolicy sets the miniscript spending policy of an account of the
+// waddrmgr.KeyScopePolicy key scope.  Keys must hold the extended public key
+// of each key name of the policy, one of which is the account's own extended
+// public key.  The policy of an account can't be changed once it is set, as
+// addresses may have been derived from it.
+func (w *Wallet) SetAccountPolicy(account uint32, policy string,
+	keys map[string]*hdkeychain.ExtendedKey) error {
+
+	p, err := miniscript.ParsePolicy(policy)
+	if err != nil {
+		return err
+	}
+	names := p.Keys()
+	if len(names) != len(keys) {
+		return fmt.Errorf("policy has %d keys, but %d extended keys "+
+			"were given", len(names), len(keys))
+	}
+	for _, name := range names {
+		key, ok := keys[name]
+		if !ok {
+			return fmt.Errorf("no extended key for key %q", name)
+		}
+		if key.IsPrivate() {
+			return fmt.Errorf("extended key for key %q is private",
+				name)
+		}
+	}
+
+	manager, err := w.Manager.FetchScopedKeyManager(waddrmgr.KeyScopePolicy)
+	if err != nil {
+		return err
+	}
+
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		policyNs := tx.ReadWriteBucket(wpolicyNamespaceKey)
+
+		props, err := manager.AccountProperties(addrmgrNs, account)
+		if err != nil {
+			return err
+		}
+		record, err := fetchPolicyRecord(policyNs, account)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			return fmt.Errorf("account %d already has a policy",
+				account)
+		}
+
+		// The wallet signs for the key name of the account's extended
+		// public key, which must be given exactly once.
+		record = &policyRecord{
+			policy: p.String(),
+			keys:   keys,
+			names:  names,
+		}
+		for _, name := range names {
+			if !sameExtendedKey(keys[name], props.AccountPubKey) {
+				continue
+			}
+			if record.self != "" {
+				return fmt.Errorf("account key is used for both "+
+					"%q and %q", record.self, name)
+			}
+			record.self = name
+		}
+		if record.self == "" {
+			return fmt.Errorf("policy does not use the extended "+
+				"public key of account %d", account)
+		}
+
+		// Make sure the policy compiles to a valid witness script.
+		if _, err := record.witnessScript(0, 0); err != nil {
+			return err
+		}
+
+		return putPolicyRecord(policyNs, account, record)
+	})
+}
+
+// sameExtendedKey returns whether two extended keys have the same public key
+// and chain code, regardless of their versions.
+func sameExtendedKey(a, b *hdkeychain.ExtendedKey) bool {
+	aPub, err := a.ECPubKey()
+	if err != nil {
+		return false
+	}
+	bPub, err := b.ECPubKey()
+	if err != nil {
+		return false
+	}
+	return aPub.IsEqual(bPub) && bytes.Equal(a.ChainCode(), b.ChainCode())
+}
+
+// AccountPolicy returns the spending policy of an account of the
+// waddrmgr.KeyScopePolicy key scope.  ErrNoAccountPolicy is returned if the
+// account has no policy.
+func (w *Wallet) AccountPolicy(account uint32) (*AccountPolicy, error) {
+	var record *policyRecord
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		var err error
+		record, err = fetchPolicyRecord(
+			tx.ReadBucket(wpolicyNamespaceKey), account,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrNoAccountPolicy
+	}
+	return &AccountPolicy{
+		Policy: record.policy,
+		Keys:   record.keys,
+		Self:   record.self,
+	}, nil
+}
+
+// pubKeys returns the public keys of the key names of the policy at the branch
+// and index of an address.
+func (r *policyRecord) pubKeys(branch,
+	index uint32) (map[string]*btcec.PublicKey, error) {
+
+	pubKeys := make(map[string]*btcec.PublicKey, len(r.keys))
+	for name, key := range r.keys {
+		branchKey, err := key.Derive(branch)
+		if err != nil {
+			return nil, err
+		}
+		child, err := branchKey.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys[name], err = child.ECPubKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pubKeys, nil
+}
+
+// witnessScript returns the witness script of the policy at the branch and
+// index of an address.
+func (r *policyRecord) witnessScript(branch, index uint32) ([]byte, error) {
+	policy, err := miniscript.ParsePolicy(r.policy)
+	if err != nil {
+		return nil, err
+	}
+	pubKeys, err := r.pubKeys(branch, index)
+	if err != nil {
+		return nil, err
+	}
+	return policy.Compile(pubKeys)
+}
+
+// policyAddress returns the P2WSH address of a policy account for the
+// wallet's key address derived at the same branch and index, importing the
+// witness script into the account if it wasn't already.
+func (w *Wallet) policyAddress(addrmgrNs walletdb.ReadWriteBucket,
+	manager *waddrmgr.ScopedKeyManager,
+	keyAddr waddrmgr.ManagedAddress) (btcutil.Address, error) {
+
+	pubKeyAddr, ok := keyAddr.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil, fmt.Errorf("address %v of a policy account is not "+
+			"a public key address", keyAddr.Address())
+	}
+	_, path, _ := pubKeyAddr.DerivationInfo()
+	account := keyAddr.InternalAccount()
+
+	policyNs := addrmgrNs.Tx().ReadWriteBucket(wpolicyNamespaceKey)
+	record, err := fetchPolicyRecord(policyNs, account)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrNoAccountPolicy
+	}
+	witnessScript, err := record.witnessScript(path.Branch, path.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := w.Manager.SyncedTo()
+	_, err = manager.ImportAccountWitnessScript(
+		addrmgrNs, account, witnessScript, &bs,
+	)
+	if err != nil && !waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+		return nil, err
+	}
+
+	scriptHash := sha256.Sum256(witnessScript)
+	err = putPolicyScript(policyNs, scriptHash[:], &policyScript{
+		account: account,
+		branch:  path.Branch,
+		index:   path.Index,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return btcutil.NewAddressWitnessScriptHash(scriptHash[:], w.chainParams)
+}
+
+// policySpender spends the witness script of a policy account address.
+type policySpender struct {
+	policy  *miniscript.Policy
+	self    string
+	pubKeys map[string]*btcec.PublicKey
+	maxSize int
+}
+
+// fetchPolicySpender returns the spender of the witness script with the given
+// SHA256 hash, or nil if it isn't the script of a policy account.
+func fetchPolicySpender(ns walletdb.ReadBucket,
+	scriptHash []byte) (*policySpender, error) {
+
+	script, err := fetchPolicyScript(ns, scriptHash)
+	if err != nil || script == nil {
+		return nil, err
+	}
+	record, err := fetchPolicyRecord(ns, script.account)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrNoAccountPolicy
+	}
+
+	policy, err := miniscript.ParsePolicy(record.policy)
+	if err != nil {
+		return nil, err
+	}
+	pubKeys, err := record.pubKeys(script.branch, script.index)
+	if err != nil {
+		return nil, err
+	}
+	maxSize, err := policy.MaxWitnessSize()
+	if err != nil {
+		return nil, err
+	}
+	return &policySpender{
+		policy:  policy,
+		self:    record.self,
+		pubKeys: pubKeys,
+		maxSize: maxSize,
+	}, nil
+}
+
+// timelocks returns the sequence and lock time of the smallest witness the
+// wallet can spend the output with on its own, using the timelocks which have
+// passed in the block after bs.
+//
+// This is part of the witnessSpender interface.
+func (s *policySpender) timelocks(output *wtxmgr.Credit,
+	bs *waddrmgr.BlockStamp) (uint32, uint32, bool) {
+
+	sat, err := s.policy.Satisfy(&policyPlanner{
+		self:   s.self,
+		output: output,
+		bs:     bs,
+	})
+	if err != nil {
+		return 0, 0, false
+	}
+	return sat.Sequence, sat.LockTime, true
+}
+
+// maxWitnessSize returns the worst case size of a witness satisfying the
+// policy.
+//
+// This is part of the witnessSpender interface.
+func (s *policySpender) maxWitnessSize([]byte) int {
+	return s.maxSize
+}
+
+// sign returns the smallest witness satisfying the policy with the signatures
+// and preimages of the previous witness and the keys of getKey, using the
+// sequence and lock time of the transaction.  If the policy can't be satisfied
+// yet, the witness holds the signatures and preimages that are known, so the
+// other signers can complete it.
+//
+// This is part of the witnessSpender interface.
+func (s *policySpender) sign(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes,
+	idx int, amount int64, witnessScript []byte,
+	hashType txscript.SigHashType, prevWitness wire.TxWitness,
+	chainParams *chaincfg.Params,
+	getKey func(*btcutil.AddressPubKeyHash) (*btcec.PrivateKey, error)) (
+	wire.TxWitness, error) {
+
+	signer := &policySigner{
+		tx:            tx,
+		sigHashes:     sigHashes,
+		idx:           idx,
+		amount:        amount,
+		witnessScript: witnessScript,
+		hashType:      hashType,
+		prevWitness:   prevWitness,
+		chainParams:   chainParams,
+		pubKeys:       s.pubKeys,
+		getKey:        getKey,
+	}
+	sat, err := s.policy.Satisfy(signer)
+	switch {
+	case err == miniscript.ErrUnsatisfiable:
+		return append(signer.known, witnessScript), nil
+	case err != nil:
+		return nil, err
+	}
+	return append(sat.Witness, witnessScript), nil
+}
+
+// policySigner satisfies a policy for an input of a transaction.
+type policySigner struct {
+	tx            *wire.MsgTx
+	sigHashes     *txscript.TxSigHashes
+	idx           int
+	amount        int64
+	witnessScript []byte
+	hashType      txscript.SigHashType
+	prevWitness   wire.TxWitness
+	chainParams   *chaincfg.Params
+	pubKeys       map[string]*btcec.PublicKey
+	getKey        func(*btcutil.AddressPubKeyHash) (*btcec.PrivateKey, error)
+
+	// known are the signatures and preimages found so far.
+	known wire.TxWitness
+}
+
+// addKnown records a signature or preimage found for the policy.
+func (s *policySigner) addKnown(item []byte) {
+	for _, known := range s.known {
+		if bytes.Equal(known, item) {
+			return
+		}
+	}
+	s.known = append(s.known, item)
+}
+
+// Sign returns the signature of the key from the previous witness, or signs
+// with the key if getKey returns its private key.
+//
+// This is part of the miniscript.Satisfier interface.
+func (s *policySigner) Sign(name string) ([]byte, error) {
+	pubKey := s.pubKeys[name]
+	sig := findWitnessSig(
+		s.tx, s.sigHashes, s.idx, s.amount, s.witnessScript,
+		s.prevWitness, pubKey,
+	)
+	if sig == nil {
+		keyAddr, err := btcutil.NewAddressPubKeyHash(
+			btcutil.Hash160(pubKey.SerializeCompressed()),
+			s.chainParams,
+		)
+		if err != nil {
+			return nil, err
+		}
+		privKey, err := s.getKey(keyAddr)
+		if err != nil || privKey == nil {
+			return nil, err
+		}
+		sig, err = txscript.RawTxInWitnessSignature(
+			s.tx, s.sigHashes, s.idx, s.amount, s.witnessScript,
+			s.hashType, privKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.addKnown(sig)
+	return sig, nil
+}
+
+// Preimage returns the preimage of the hash from the previous witness.
+//
+// This is part of the miniscript.Satisfier interface.
+func (s *policySigner) Preimage(hash []byte) []byte {
+	for _, item := range s.prevWitness {
+		if bytes.Equal(preimageHash(item, len(hash)), hash) {
+			s.addKnown(item)
+			return item
+		}
+	}
+	return nil
+}
+
+// CheckOlder returns whether the sequence of the input satisfies the relative
+// lock time, as checked by OP_CHECKSEQUENCEVERIFY.
+//
+// This is part of the miniscript.Satisfier interface.
+func (s *policySigner) CheckOlder(sequence uint32) bool {
+	txSequence := s.tx.TxIn[s.idx].Sequence
+	if s.tx.Version < 2 ||
+		txSequence&wire.SequenceLockTimeDisabled != 0 ||
+		txSequence&wire.SequenceLockTimeIsSeconds !=
+			sequence&wire.SequenceLockTimeIsSeconds {
+
+		return false
+	}
+	return txSequence&wire.SequenceLockTimeMask >=
+		sequence&wire.SequenceLockTimeMask
+}
+
+// CheckAfter returns whether the lock time of the transaction satisfies the
+// absolute lock time, as checked by OP_CHECKLOCKTIMEVERIFY.
+//
+// This is part of the miniscript.Satisfier interface.
+func (s *policySigner) CheckAfter(lockTime uint32) bool {
+	if s.tx.TxIn[s.idx].Sequence == wire.MaxTxInSequenceNum ||
+		(s.tx.LockTime < txscript.LockTimeThreshold) !=
+			(lockTime < txscript.LockTimeThreshold) {
+
+		return false
+	}
+	return s.tx.LockTime >= lockTime
+}
+
+// policyPlanner satisfies a policy with placeholder signatures by the wallet's
+// key, to find the timelocks and size of the witness the wallet can spend an
+// output with on its own.
+type policyPlanner struct {
+	self   string
+	output *wtxmgr.Credit
+	bs     *waddrmgr.BlockStamp
+}
+
+// Sign returns a placeholder signature of the largest size for the wallet's
+// key.
+//
+// This is part of the miniscript.Satisfier interface.
+func (p *policyPlanner) Sign(name string) ([]byte, error) {
+	if name != p.self {
+		return nil, nil
+	}
+	return make([]byte, maxWitnessSigSize), nil
+}
+
+// Preimage returns nil, as the wallet doesn't know any preimages.
+//
+// This is part of the miniscript.Satisfier interface.
+func (p *policyPlanner) Preimage([]byte) []byte {
+	return nil
+}
+
+// CheckOlder returns whether the relative lock time has passed for the output.
+//
+// This is part of the miniscript.Satisfier interface.
+func (p *policyPlanner) CheckOlder(sequence uint32) bool {
+	return relativeLockReached(sequence, p.output, p.bs)
+}
+
+// CheckAfter returns whether the absolute lock time has passed.
+//
+// This is part of the miniscript.Satisfier interface.
+func (p *policyPlanner) CheckAfter(lockTime uint32) bool {
+	return absoluteLockReached(lockTime, p.bs)
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// TestPolicyAccount tests that the addresses of a policy account pay to the
+// witness script of its policy, and that their outputs are spent by the
+// wallet alone once the timelock is reached, or together with the other
+// signer.
+func TestPolicyAccount(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	// The mock chain is at height 500000, so the wallet can spend on its
+	// own with the timelocked branch.
+	const (
+		policy   = "or(and(pk(A),pk(B)),and(pk(A),after(400000)))"
+		lockTime = 400000
+	)
+
+	account, accountKey, err := w.NewPolicyAccount("vault")
+	if err != nil {
+		t.Fatalf("unable to create policy account: %v", err)
+	}
+	seed := bytes.Repeat([]byte{1}, hdkeychain.RecommendedSeedLen)
+	cosigner, err := hdkeychain.NewMaster(seed, w.ChainParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cosignerKey, err := cosigner.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Addresses can't be derived before the policy is set.
+	if _, err := w.NewAddress(account, waddrmgr.KeyScopePolicy); err !=
+		ErrNoAccountPolicy {
+
+		t.Fatalf("expected ErrNoAccountPolicy, got %v", err)
+	}
+
+	// The policy must use the account key, and only public keys.
+	invalid := []map[string]*hdkeychain.ExtendedKey{
+		{"A": accountKey},
+		{"A": cosignerKey, "B": cosignerKey},
+		{"A": accountKey, "B": cosigner},
+	}
+	for i, keys := range invalid {
+		if err := w.SetAccountPolicy(account, policy, keys); err == nil {
+			t.Fatalf("expected invalid keys %d to be rejected", i)
+		}
+	}
+	keys := map[string]*hdkeychain.ExtendedKey{
+		"A": accountKey,
+		"B": cosignerKey,
+	}
+	if err := w.SetAccountPolicy(account, policy, keys); err != nil {
+		t.Fatalf("unable to set account policy: %v", err)
+	}
+	if err := w.SetAccountPolicy(account, policy, keys); err == nil {
+		t.Fatal("expected policy to be set only once")
+	}
+	accountPolicy, err := w.AccountPolicy(account)
+	if err != nil {
+		t.Fatalf("unable to fetch account policy: %v", err)
+	}
+	if accountPolicy.Policy != policy || accountPolicy.Self != "A" {
+		t.Fatalf("unexpected account policy %+v", accountPolicy)
+	}
+
+	addr, err := w.NewAddress(account, waddrmgr.KeyScopePolicy)
+	if err != nil {
+		t.Fatalf("unable to get new address: %v", err)
+	}
+	if _, ok := addr.(*btcutil.AddressWitnessScriptHash); !ok {
+		t.Fatalf("unexpected address type %T", addr)
+	}
+	current, err := w.CurrentAddress(account, waddrmgr.KeyScopePolicy)
+	if err != nil {
+		t.Fatalf("unable to get current address: %v", err)
+	}
+	if current.String() != addr.String() {
+		t.Fatalf("got current address %v, want %v", current, addr)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fundingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{1}},
+		}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(100000, pkScript)},
+	}
+	addUtxo(t, w, fundingTx)
+
+	// The wallet spends the output on its own with the timelocked branch.
+	// The transaction is signed and validated by txToOutputs.
+	tx, err := w.txToOutputs(
+		[]*wire.TxOut{wire.NewTxOut(50000, pkScript)},
+		&waddrmgr.KeyScopePolicy, account, 1, 1000,
+		CoinSelectionLargest, false,
+	)
+	if err != nil {
+		t.Fatalf("unable to spend policy output: %v", err)
+	}
+	if tx.Tx.LockTime != lockTime {
+		t.Fatalf("got lock time %d, want %d", tx.Tx.LockTime, lockTime)
+	}
+	if tx.ChangeIndex < 0 ||
+		!txscript.IsPayToWitnessScriptHash(
+			tx.Tx.TxOut[tx.ChangeIndex].PkScript,
+		) {
+
+		t.Fatal("expected change paying to the policy")
+	}
+
+	// With the other signer's signature, the output is spent without a
+	// lock time.
+	managedAddr, err := w.AddressInfo(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	witnessScript, err := managedAddr.(waddrmgr.ManagedScriptAddress).
+		Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchKey, err := cosigner.Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	childKey, err := branchKey.Derive(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey, err := childKey.ECPrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spendTx := &wire.MsgTx{
+		Version: 2,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Hash: fundingTx.TxHash()},
+			Sequence:         wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(90000, pkScript)},
+	}
+	signErrs, err := w.SignTransaction(
+		spendTx, txscript.SigHashAll, nil, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to sign transaction: %v", err)
+	}
+	if len(signErrs) != 1 {
+		t.Fatal("expected policy to be unsatisfied without cosigner")
+	}
+
+	sig, err := txscript.RawTxInWitnessSignature(
+		spendTx, txscript.NewTxSigHashes(spendTx), 0, 100000,
+		witnessScript, txscript.SigHashAll, privKey,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendTx.TxIn[0].Witness = append(
+		spendTx.TxIn[0].Witness[:len(spendTx.TxIn[0].Witness)-1], sig,
+		witnessScript,
+	)
+	signErrs, err = w.SignTransaction(
+		spendTx, txscript.SigHashAll, nil, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to sign transaction: %v", err)
+	}
+	if len(signErrs) != 0 {
+		t.Fatalf("unable to spend with cosigner: %v", signErrs[0].Error)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The policy namespace stores the spending policies of the accounts of the
+// policy key scope, and the derivation of the witness scripts of their
+// addresses.
+//
+// The accounts bucket is keyed by the 4 byte big endian account number, and
+// each policy is serialized as follows:
+//
+//   [0:2]     policy length (2 bytes)
+//   ...       policy, in its canonical form
+//   ...       name of the wallet's key, as a 1 byte length followed by the
+//             name
+//   ...       number of keys (1 byte)
+//   ...       keys, each a 1 byte length followed by the key name and a 1
+//             byte length followed by the extended public key
+//
+// The scripts bucket is keyed by the SHA256 hash of each witness script, and
+// each value is serialized as follows:
+//
+//   [0:4]     account (4 bytes)
+//   [4:8]     branch (4 bytes)
+//   [8:12]    index (4 bytes)
+//
+// All integers are encoded big endian.
+
+var (
+	// wpolicyNamespaceKey is the key of the top level bucket storing the
+	// account policies.
+	wpolicyNamespaceKey = []byte("wpolicy")
+
+	// policyAccountsBucketKey is the key of the bucket storing the policy
+	// of each account.
+	policyAccountsBucketKey = []byte("accounts")
+
+	// policyScriptsBucketKey is the key of the bucket storing the
+	// derivation of each witness script of a policy account.
+	policyScriptsBucketKey = []byte("scripts")
+)
+
+// createPolicyBuckets creates the nested buckets of the policy namespace.
+func createPolicyBuckets(ns walletdb.ReadWriteBucket) error {
+	for _, key := range [][]byte{
+		policyAccountsBucketKey, policyScriptsBucketKey,
+	} {
+		if _, err := ns.CreateBucketIfNotExists(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policyRecord is the stored policy of an account.
+type policyRecord struct {
+	policy string
+	self   string
+	keys   map[string]*hdkeychain.ExtendedKey
+	names  []string
+}
+
+// policyScript is the derivation of a witness script of a policy account.
+type policyScript struct {
+	account uint32
+	branch  uint32
+	index   uint32
+}
+
+func serializePolicyRecord(r *policyRecord) ([]byte, error) {
+	var b bytes.Buffer
+
+	if len(r.policy) > 0xffff {
+		return nil, fmt.Errorf("policy of %d bytes is too long",
+			len(r.policy))
+	}
+	var l [2]byte
+	binary.BigEndian.PutUint16(l[:], uint16(len(r.policy)))
+	b.Write(l[:])
+	b.WriteString(r.policy)
+
+	writeString := func(s string) error {
+		if len(s) > 0xff {
+			return fmt.Errorf("%q is too long", s)
+		}
+		b.WriteByte(byte(len(s)))
+		b.WriteString(s)
+		return nil
+	}
+	if err := writeString(r.self); err != nil {
+		return nil, err
+	}
+
+	if len(r.names) > 0xff {
+		return nil, fmt.Errorf("policy has too many keys")
+	}
+	b.WriteByte(byte(len(r.names)))
+	for _, name := range r.names {
+		if err := writeString(name); err != nil {
+			return nil, err
+		}
+		if err := writeString(r.keys[name].String()); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func deserializePolicyRecord(v []byte) (*policyRecord, error) {
+	r := bytes.NewReader(v)
+
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, err
+	}
+	policy := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(r, policy); err != nil {
+		return nil, err
+	}
+
+	readString := func() (string, error) {
+		n, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		s := make([]byte, n)
+		if _, err := io.ReadFull(r, s); err != nil {
+			return "", err
+		}
+		return string(s), nil
+	}
+	self, err := readString()
+	if err != nil {
+		return nil, err
+	}
+
+	numKeys, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	record := &policyRecord{
+		policy: string(policy),
+		self:   self,
+		keys:   make(map[string]*hdkeychain.ExtendedKey, numKeys),
+	}
+	for i := 0; i < int(numKeys); i++ {
+		name, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		xpub, err := readString()
+		if err != nil {
+			return nil, err
+		}
+		key, err := hdkeychain.NewKeyFromString(xpub)
+		if err != nil {
+			return nil, err
+		}
+		record.names = append(record.names, name)
+		record.keys[name] = key
+	}
+
+	return record, nil
+}
+
+func policyAccountKey(account uint32) []byte {
+	var k [4]byte
+	binary.BigEndian.PutUint32(k[:], account)
+	return k[:]
+}
+
+// putPolicyRecord stores the policy of an account.
+func putPolicyRecord(ns walletdb.ReadWriteBucket, account uint32,
+	r *policyRecord) error {
+
+	v, err := serializePolicyRecord(r)
+	if err != nil {
+		return err
+	}
+	return ns.NestedReadWriteBucket(policyAccountsBucketKey).Put(
+		policyAccountKey(account), v,
+	)
+}
+
+// fetchPolicyRecord returns the policy of an account, or nil if the account
+// has none.
+func fetchPolicyRecord(ns walletdb.ReadBucket,
+	account uint32) (*policyRecord, error) {
+
+	v := ns.NestedReadBucket(policyAccountsBucketKey).Get(
+		policyAccountKey(account),
+	)
+	if v == nil {
+		return nil, nil
+	}
+	return deserializePolicyRecord(v)
+}
+
+// putPolicyScript stores the derivation of the witness script with the given
+// hash.
+func putPolicyScript(ns walletdb.ReadWriteBucket, scriptHash []byte,
+	s *policyScript) error {
+
+	var v [12]byte
+	binary.BigEndian.PutUint32(v[0:4], s.account)
+	binary.BigEndian.PutUint32(v[4:8], s.branch)
+	binary.BigEndian.PutUint32(v[8:12], s.index)
+	return ns.NestedReadWriteBucket(policyScriptsBucketKey).Put(
+		scriptHash, v[:],
+	)
+}
+
+// fetchPolicyScript returns the derivation of the witness script with the
+// given hash, or nil if it isn't the script of a policy account.
+func fetchPolicyScript(ns walletdb.ReadBucket,
+	scriptHash []byte) (*policyScript, error) {
+
+	v := ns.NestedReadBucket(policyScriptsBucketKey).Get(scriptHash)
+	if v == nil {
+		return nil, nil
+	}
+	if len(v) != 12 {
+		return nil, fmt.Errorf("invalid policy script of %d bytes",
+			len(v))
+	}
+	return &policyScript{
+		account: binary.BigEndian.Uint32(v[0:4]),
+		branch:  binary.BigEndian.Uint32(v[4:8]),
+		index:   binary.BigEndian.Uint32(v[8:12]),
+	}, nil
+}
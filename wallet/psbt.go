@@ -6,11 +6,13 @@ package wallet
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcutil/psbt"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
@@ -19,6 +21,11 @@ import (
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
+// ErrSighashMismatch is returned by SignPsbtHashType if an input the wallet
+// signs already has a sighash type other than the requested one.
+var ErrSighashMismatch = errors.New("specified sighash value does not match " +
+	"value stored in PSBT")
+
 // FundPsbt creates a fully populated PSBT packet that contains enough inputs to
 // fund the outputs specified in the passed in packet with the specified fee
 // rate. If there is change left, a change output from the wallet is added and
@@ -36,6 +43,10 @@ import (
 // inputs aren't enough to fund the outputs with the given fee rate, an error is
 // returned.
 //
+// NOTE: For packets decoded from a PSBTv2, inputs and a change output are only
+// added if the modifiable flags of the packet allow it, and the order of the
+// inputs and outputs is preserved rather than sorted according to BIP 69.
+//
 // NOTE: A caller of the method should hold the global coin selection lock of
 // the wallet. However, no UTXO specific lock lease is acquired for any of the
 // selected/validated inputs by this method. It is in the caller's
@@ -81,9 +92,11 @@ func (w *Wallet) FundPsbt(packet *psbt.Packet, keyScope *waddrmgr.KeyScope,
 	// addInputInfo is a helper function that fetches the UTXO information
 	// of an input and attaches it to the PSBT packet.
 	addInputInfo := func(inputs []*wire.TxIn) error {
-		packet.Inputs = make([]psbt.PInput, len(inputs))
+		if len(packet.Inputs) != len(inputs) {
+			packet.Inputs = make([]psbt.PInput, len(inputs))
+		}
 		for idx, in := range inputs {
-			tx, utxo, derivationPath, _, err := w.FetchInputInfo(
+			prevTx, addr, err := w.psbtInputInfo(
 				&in.PreviousOutPoint,
 			)
 			if err != nil {
@@ -91,45 +104,35 @@ func (w *Wallet) FundPsbt(packet *psbt.Packet, keyScope *waddrmgr.KeyScope,
 					err)
 			}
 
-			// As a fix for CVE-2020-14199 we have to always include
-			// the full non-witness UTXO in the PSBT for segwit v0.
-			packet.Inputs[idx].NonWitnessUtxo = tx
-
-			// To make it more obvious that this is actually a
-			// witness output being spent, we also add the same
-			// information as the witness UTXO.
-			packet.Inputs[idx].WitnessUtxo = &wire.TxOut{
-				Value:    utxo.Value,
-				PkScript: utxo.PkScript,
+			// Attach the UTXO, redeem script and derivation path
+			// so an offline wallet is able to sign the input.
+			if packet.Inputs[idx].SighashType == 0 {
+				packet.Inputs[idx].SighashType = txscript.SigHashAll
 			}
-			packet.Inputs[idx].SighashType = txscript.SigHashAll
-
-			// Include the derivation path for each input.
-			packet.Inputs[idx].Bip32Derivation = []*psbt.Bip32Derivation{
-				derivationPath,
+			err = w.addPsbtInputInfo(
+				&packet.Inputs[idx], prevTx,
+				in.PreviousOutPoint.Index, addr, true,
+			)
+			if err != nil {
+				return fmt.Errorf("error fetching UTXO "+
+					"script: %v", err)
 			}
 
 			// We don't want to include the witness or any script
 			// on the unsigned TX just yet.
 			packet.UnsignedTx.TxIn[idx].Witness = wire.TxWitness{}
 			packet.UnsignedTx.TxIn[idx].SignatureScript = nil
-
-			// For nested P2WKH we need to add the redeem script to
-			// the input, otherwise an offline wallet won't be able
-			// to sign for it. For normal P2WKH this will be nil.
-			addr, witnessProgram, _, err := w.scriptForOutput(utxo)
-			if err != nil {
-				return fmt.Errorf("error fetching UTXO "+
-					"script: %v", err)
-			}
-			if addr.AddrType() == waddrmgr.NestedWitnessPubKey {
-				packet.Inputs[idx].RedeemScript = witnessProgram
-			}
 		}
 
 		return nil
 	}
 
+	// A PSBTv2 packet states whether inputs and outputs may be added.
+	modifiable, isV2 := PsbtModifiable(packet)
+	if isV2 && len(txIn) == 0 && modifiable&PsbtInputsModifiable == 0 {
+		return 0, fmt.Errorf("PSBT inputs are not modifiable")
+	}
+
 	var tx *txauthor.AuthoredTx
 	switch {
 	// We need to do coin selection.
@@ -176,7 +179,8 @@ func (w *Wallet) FundPsbt(packet *psbt.Packet, keyScope *waddrmgr.KeyScope,
 		// enough".
 		credits := make([]wtxmgr.Credit, len(txIn))
 		for idx, in := range txIn {
-			utxo := packet.Inputs[idx].WitnessUtxo
+			prevTx := packet.Inputs[idx].NonWitnessUtxo
+			utxo := prevTx.TxOut[in.PreviousOutPoint.Index]
 			credits[idx] = wtxmgr.Credit{
 				OutPoint: in.PreviousOutPoint,
 				Amount:   btcutil.Amount(utxo.Value),
@@ -217,6 +221,10 @@ func (w *Wallet) FundPsbt(packet *psbt.Packet, keyScope *waddrmgr.KeyScope,
 	// If there is a change output, we need to copy it over to the PSBT now.
 	var changeTxOut *wire.TxOut
 	if tx.ChangeIndex >= 0 {
+		if isV2 && modifiable&PsbtOutputsModifiable == 0 {
+			return 0, fmt.Errorf("PSBT outputs are not modifiable " +
+				"to add change")
+		}
 		changeTxOut = tx.Tx.TxOut[tx.ChangeIndex]
 		packet.UnsignedTx.TxOut = append(
 			packet.UnsignedTx.TxOut, changeTxOut,
@@ -226,10 +234,14 @@ func (w *Wallet) FundPsbt(packet *psbt.Packet, keyScope *waddrmgr.KeyScope,
 
 	// Now that we have the final PSBT ready, we can sort it according to
 	// BIP 69. This will sort the wire inputs and outputs and move the
-	// partial inputs and outputs accordingly.
-	err = psbt.InPlaceSort(packet)
-	if err != nil {
-		return 0, fmt.Errorf("could not sort PSBT: %v", err)
+	// partial inputs and outputs accordingly. PSBTv2 packets are built
+	// incrementally by several parties that may rely on the positions of
+	// their inputs and outputs, so they're left in order.
+	if !isV2 {
+		err = psbt.InPlaceSort(packet)
+		if err != nil {
+			return 0, fmt.Errorf("could not sort PSBT: %v", err)
+		}
 	}
 
 	// The change output index might have changed after the sorting. We need
@@ -390,6 +402,393 @@ func (w *Wallet) FinalizePsbt(keyScope *waddrmgr.KeyScope, account uint32,
 	return nil
 }
 
+// UpdatePsbt adds the information the wallet has about the inputs and outputs
+// of a packet, acting as the updater role of BIP 174. Inputs spending outputs
+// of the wallet receive their UTXO and redeem script, outputs paying to nested
+// P2WKH addresses of the wallet receive their redeem script. If
+// includeDerivations is set, the BIP 32 derivation paths of wallet keys are
+// added as well. Inputs and outputs unknown to the wallet, as well as finalized
+// inputs, are left untouched.
+func (w *Wallet) UpdatePsbt(packet *psbt.Packet, includeDerivations bool) error {
+	err := psbt.VerifyInputOutputLen(packet, false, false)
+	if err != nil {
+		return err
+	}
+
+	for idx, txIn := range packet.UnsignedTx.TxIn {
+		in := &packet.Inputs[idx]
+		if len(in.FinalScriptSig) > 0 || len(in.FinalScriptWitness) > 0 {
+			continue
+		}
+
+		prevTx, addr, err := w.psbtInputInfo(&txIn.PreviousOutPoint)
+		if err == ErrNotMine {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = w.addPsbtInputInfo(
+			in, prevTx, txIn.PreviousOutPoint.Index, addr,
+			includeDerivations,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for idx, txOut := range packet.UnsignedTx.TxOut {
+		addr, err := w.fetchOutputAddr(txOut.PkScript)
+		if err == ErrNotMine {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		pubKeyAddr, ok := addr.(waddrmgr.ManagedPubKeyAddress)
+		if !ok {
+			continue
+		}
+
+		out := &packet.Outputs[idx]
+		if pubKeyAddr.AddrType() == waddrmgr.NestedWitnessPubKey &&
+			out.RedeemScript == nil {
+
+			_, witnessProgram, _, err := w.scriptForOutput(txOut)
+			if err != nil {
+				return err
+			}
+			out.RedeemScript = witnessProgram
+		}
+
+		derivation := psbtDerivation(pubKeyAddr)
+		if includeDerivations && derivation != nil &&
+			!hasDerivation(out.Bip32Derivation, derivation.PubKey) {
+
+			out.Bip32Derivation = append(
+				out.Bip32Derivation, derivation,
+			)
+		}
+	}
+
+	return nil
+}
+
+// SignPsbt adds a partial signature to every input of the packet that spends
+// an output the wallet holds the private key for, and returns the indexes of
+// the inputs it signed. Each input is signed with its own sighash type, or
+// SIGHASH_ALL if none is set. Unlike FinalizePsbt, inputs the wallet can't sign
+// for, or has already signed, are left untouched and no input is finalized, so
+// signatures can be collected from several signers before the packet is
+// finalized.
+//
+// For packets decoded from a PSBTv2, the modifiable flags are updated to
+// reflect the sighash types of the added signatures as described by BIP0370.
+//
+// NOTE: Only inputs spending outputs of wallet transactions, or with the full
+// non-witness UTXO attached, can be signed, see UpdatePsbt. P2PKH inputs
+// always require the full non-witness UTXO. An error is returned if the
+// witness UTXO of an input doesn't match the output it spends.
+func (w *Wallet) SignPsbt(packet *psbt.Packet) ([]uint32, error) {
+	return w.signPsbt(packet, 0)
+}
+
+// SignPsbtHashType is like SignPsbt, but signs the inputs that have no sighash
+// type with the passed one, which is stored in them. ErrSighashMismatch is
+// returned if an input the wallet signs has a different sighash type. Inputs
+// the wallet doesn't sign keep their sighash type, so the fields seen by the
+// other signers of the packet aren't changed.
+func (w *Wallet) SignPsbtHashType(packet *psbt.Packet,
+	hashType txscript.SigHashType) ([]uint32, error) {
+
+	return w.signPsbt(packet, hashType)
+}
+
+// signPsbt signs the inputs of the packet as described by SignPsbt. If
+// hashType is not zero, it is the sighash type of the inputs without one.
+func (w *Wallet) signPsbt(packet *psbt.Packet,
+	defaultHashType txscript.SigHashType) ([]uint32, error) {
+
+	err := psbt.VerifyInputOutputLen(packet, true, true)
+	if err != nil {
+		return nil, err
+	}
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return nil, err
+	}
+
+	var signed []uint32
+	tx := packet.UnsignedTx
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for idx, txIn := range tx.TxIn {
+		in := &packet.Inputs[idx]
+		if len(in.FinalScriptSig) > 0 || len(in.FinalScriptWitness) > 0 {
+			continue
+		}
+
+		// Find the output spent by the input. Segwit signatures commit
+		// to its value, which therefore must never be taken from the
+		// witness UTXO of the packet (CVE-2020-14199). Instead it is
+		// looked up in the transaction store, or taken from the full
+		// previous transaction as its hash is committed to by the
+		// outpoint.
+		prevOut := txIn.PreviousOutPoint
+		utxo, err := w.walletOutput(&prevOut)
+		if err != nil {
+			return nil, err
+		}
+		if in.NonWitnessUtxo != nil {
+			if in.NonWitnessUtxo.TxHash() != prevOut.Hash ||
+				int(prevOut.Index) >= len(in.NonWitnessUtxo.TxOut) {
+
+				return nil, fmt.Errorf("non-witness UTXO of input "+
+					"%d doesn't match its outpoint %v", idx,
+					prevOut)
+			}
+			if utxo == nil {
+				utxo = in.NonWitnessUtxo.TxOut[prevOut.Index]
+			}
+		}
+		if utxo == nil {
+			continue
+		}
+		if in.WitnessUtxo != nil &&
+			(in.WitnessUtxo.Value != utxo.Value ||
+				!bytes.Equal(in.WitnessUtxo.PkScript, utxo.PkScript)) {
+
+			return nil, fmt.Errorf("witness UTXO of input %d doesn't "+
+				"match the output %v it spends", idx, prevOut)
+		}
+
+		addr, err := w.fetchOutputAddr(utxo.PkScript)
+		if err == ErrNotMine {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pubKeyAddr, ok := addr.(waddrmgr.ManagedPubKeyAddress)
+		if !ok {
+			continue
+		}
+
+		pubKey := pubKeyAddr.PubKey().SerializeCompressed()
+		if !pubKeyAddr.Compressed() {
+			pubKey = pubKeyAddr.PubKey().SerializeUncompressed()
+		}
+		if hasPartialSig(in.PartialSigs, pubKey) {
+			continue
+		}
+
+		privKey, err := pubKeyAddr.PrivKey()
+		switch {
+		case waddrmgr.IsError(err, waddrmgr.ErrWatchingOnly):
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		// Only the inputs the wallet signs get the requested sighash
+		// type, the others are left as the other signers expect them.
+		hashType := in.SighashType
+		switch {
+		case defaultHashType == 0:
+		case hashType == 0:
+			hashType = defaultHashType
+		case hashType != defaultHashType:
+			return nil, ErrSighashMismatch
+		}
+		if hashType == 0 {
+			hashType = txscript.SigHashAll
+		}
+
+		var sig, redeemScript []byte
+		switch pubKeyAddr.AddrType() {
+		case waddrmgr.PubKeyHash:
+			if in.NonWitnessUtxo == nil {
+				continue
+			}
+			sig, err = txscript.RawTxInSignature(
+				tx, idx, utxo.PkScript, hashType, privKey,
+			)
+
+		case waddrmgr.WitnessPubKey, waddrmgr.NestedWitnessPubKey:
+			var witnessProgram []byte
+			_, witnessProgram, _, err = w.scriptForOutput(utxo)
+			if err != nil {
+				return nil, err
+			}
+			sig, err = txscript.RawTxInWitnessSignature(
+				tx, sigHashes, idx, utxo.Value, witnessProgram,
+				hashType, privKey,
+			)
+			if pubKeyAddr.AddrType() == waddrmgr.NestedWitnessPubKey {
+				redeemScript = witnessProgram
+			}
+
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error signing input %d: %v",
+				idx, err)
+		}
+
+		_, err = updater.Sign(idx, sig, pubKey, redeemScript, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to add signature for "+
+				"input %d: %v", idx, err)
+		}
+		if defaultHashType != 0 {
+			in.SighashType = hashType
+		}
+		updatePsbtModifiable(packet, hashType)
+		signed = append(signed, uint32(idx))
+	}
+
+	return signed, nil
+}
+
+// walletOutput returns the output spent by the outpoint if it belongs to a
+// transaction of the wallet, or nil otherwise.
+func (w *Wallet) walletOutput(prevOut *wire.OutPoint) (*wire.TxOut, error) {
+	txDetail, err := UnstableAPI(w).TxDetails(&prevOut.Hash)
+	if err != nil || txDetail == nil {
+		return nil, err
+	}
+
+	prevTx := &txDetail.TxRecord.MsgTx
+	if prevOut.Index >= uint32(len(prevTx.TxOut)) {
+		return nil, fmt.Errorf("invalid output index %v for "+
+			"transaction with %v outputs", prevOut.Index,
+			len(prevTx.TxOut))
+	}
+	return prevTx.TxOut[prevOut.Index], nil
+}
+
+// psbtInputInfo returns the previous transaction and the wallet address of the
+// output spent by an input. ErrNotMine is returned if the output doesn't belong
+// to a public key address of the wallet.
+func (w *Wallet) psbtInputInfo(prevOut *wire.OutPoint) (*wire.MsgTx,
+	waddrmgr.ManagedPubKeyAddress, error) {
+
+	txDetail, err := UnstableAPI(w).TxDetails(&prevOut.Hash)
+	if err != nil {
+		return nil, nil, err
+	} else if txDetail == nil {
+		return nil, nil, ErrNotMine
+	}
+
+	prevTx := &txDetail.TxRecord.MsgTx
+	if prevOut.Index >= uint32(len(prevTx.TxOut)) {
+		return nil, nil, fmt.Errorf("invalid output index %v for "+
+			"transaction with %v outputs", prevOut.Index,
+			len(prevTx.TxOut))
+	}
+	addr, err := w.fetchOutputAddr(prevTx.TxOut[prevOut.Index].PkScript)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubKeyAddr, ok := addr.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil, nil, ErrNotMine
+	}
+
+	return prevTx, pubKeyAddr, nil
+}
+
+// addPsbtInputInfo attaches the UTXO information of an input spending an
+// output of the wallet to the partial input. Fields that are already set are
+// not replaced.
+func (w *Wallet) addPsbtInputInfo(in *psbt.PInput, prevTx *wire.MsgTx,
+	prevIndex uint32, addr waddrmgr.ManagedPubKeyAddress,
+	includeDerivation bool) error {
+
+	// As a fix for CVE-2020-14199 we have to always include the full
+	// non-witness UTXO in the PSBT for segwit v0.
+	if in.NonWitnessUtxo == nil {
+		in.NonWitnessUtxo = prevTx
+	}
+
+	// To make it more obvious that a witness output is being spent, we
+	// also add the same information as the witness UTXO. This must not be
+	// done for P2PKH outputs as signers would treat them as P2WKH.
+	utxo := prevTx.TxOut[prevIndex]
+	switch addr.AddrType() {
+	case waddrmgr.NestedWitnessPubKey:
+		// For nested P2WKH we need to add the redeem script to the
+		// input, otherwise an offline wallet won't be able to sign for
+		// it.
+		if in.RedeemScript == nil {
+			_, witnessProgram, _, err := w.scriptForOutput(utxo)
+			if err != nil {
+				return err
+			}
+			in.RedeemScript = witnessProgram
+		}
+		fallthrough
+
+	case waddrmgr.WitnessPubKey:
+		if in.WitnessUtxo == nil {
+			in.WitnessUtxo = &wire.TxOut{
+				Value:    utxo.Value,
+				PkScript: utxo.PkScript,
+			}
+		}
+	}
+
+	derivation := psbtDerivation(addr)
+	if includeDerivation && derivation != nil &&
+		!hasDerivation(in.Bip32Derivation, derivation.PubKey) {
+
+		in.Bip32Derivation = append(in.Bip32Derivation, derivation)
+	}
+
+	return nil
+}
+
+// psbtDerivation returns the BIP 32 derivation of a wallet address, or nil if
+// the address wasn't derived from the wallet's master key.
+func psbtDerivation(addr waddrmgr.ManagedPubKeyAddress) *psbt.Bip32Derivation {
+	keyScope, derivationPath, ok := addr.DerivationInfo()
+	if !ok {
+		return nil
+	}
+
+	return &psbt.Bip32Derivation{
+		PubKey:               addr.PubKey().SerializeCompressed(),
+		MasterKeyFingerprint: derivationPath.MasterKeyFingerprint,
+		Bip32Path: []uint32{
+			keyScope.Purpose + hdkeychain.HardenedKeyStart,
+			keyScope.Coin + hdkeychain.HardenedKeyStart,
+			derivationPath.Account,
+			derivationPath.Branch,
+			derivationPath.Index,
+		},
+	}
+}
+
+// hasDerivation returns whether a derivation of the public key is present.
+func hasDerivation(derivations []*psbt.Bip32Derivation, pubKey []byte) bool {
+	for _, derivation := range derivations {
+		if bytes.Equal(derivation.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPartialSig returns whether a signature of the public key is present.
+func hasPartialSig(sigs []*psbt.PartialSig, pubKey []byte) bool {
+	for _, sig := range sigs {
+		if bytes.Equal(sig.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
 // constantInputSource creates an input source function that always returns the
 // static set of user-selected UTXOs.
 func constantInputSource(eligible []wtxmgr.Credit) txauthor.InputSource {
@@ -7,9 +7,11 @@ package wallet
 import (
 	"bytes"
 	"encoding/hex"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
@@ -425,3 +427,326 @@ func TestFinalizePsbt(t *testing.T) {
 		t.Fatalf("error validating tx: %v", err)
 	}
 }
+
+// TestSignPsbt tests that the inputs of a PSBT spending wallet outputs are
+// updated and signed without finalizing them, while foreign inputs are left
+// untouched.
+func TestSignPsbt(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	// Create a P2PKH, a P2WKH and a nested P2WKH address we can send coins
+	// to.
+	var pkScripts [][]byte
+	for _, scope := range []waddrmgr.KeyScope{
+		waddrmgr.KeyScopeBIP0044, waddrmgr.KeyScopeBIP0084,
+		waddrmgr.KeyScopeBIP0049Plus,
+	} {
+		addr, err := w.CurrentAddress(0, scope)
+		if err != nil {
+			t.Fatalf("unable to get current address: %v", err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("unable to convert wallet address: %v", err)
+		}
+		pkScripts = append(pkScripts, pkScript)
+	}
+
+	incomingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(1000000, pkScripts[0]),
+			wire.NewTxOut(1000000, pkScripts[1]),
+			wire.NewTxOut(1000000, pkScripts[2]),
+		},
+	}
+	addUtxo(t, w, incomingTx)
+
+	// The last input spends an output the wallet doesn't know about.
+	foreignUtxo := wire.NewTxOut(500000, testScriptP2WKH)
+	tx := &wire.MsgTx{
+		Version: 2,
+		TxOut: []*wire.TxOut{{
+			PkScript: testScriptP2WSH,
+			Value:    3400000,
+		}},
+	}
+	for i := range incomingTx.TxOut {
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  incomingTx.TxHash(),
+				Index: uint32(i),
+			},
+		})
+	}
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: 7}})
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		t.Fatalf("unable to create PSBT: %v", err)
+	}
+	packet.Inputs[1].SighashType = txscript.SigHashNone |
+		txscript.SigHashAnyOneCanPay
+	packet.Inputs[3].WitnessUtxo = foreignUtxo
+
+	if err := w.UpdatePsbt(packet, true); err != nil {
+		t.Fatalf("unable to update PSBT: %v", err)
+	}
+
+	// Only segwit inputs get the witness UTXO and only the nested P2WKH
+	// input needs a redeem script.
+	for i, in := range packet.Inputs[:3] {
+		if in.NonWitnessUtxo == nil || len(in.Bip32Derivation) != 1 {
+			t.Fatalf("input %d not updated: %+v", i, in)
+		}
+		if (in.WitnessUtxo != nil) != (i > 0) {
+			t.Fatalf("unexpected witness UTXO of input %d", i)
+		}
+		if (in.RedeemScript != nil) != (i == 2) {
+			t.Fatalf("unexpected redeem script of input %d", i)
+		}
+	}
+	if packet.Inputs[3].NonWitnessUtxo != nil {
+		t.Fatalf("foreign input was updated")
+	}
+
+	signed, err := w.SignPsbt(packet)
+	if err != nil {
+		t.Fatalf("unable to sign PSBT: %v", err)
+	}
+	if !reflect.DeepEqual(signed, []uint32{0, 1, 2}) {
+		t.Fatalf("unexpected signed inputs %v", signed)
+	}
+
+	// Each wallet input received a signature with its sighash type, and
+	// nothing was finalized.
+	for i, in := range packet.Inputs[:3] {
+		if len(in.PartialSigs) != 1 {
+			t.Fatalf("expected one signature for input %d, got %d",
+				i, len(in.PartialSigs))
+		}
+		if len(in.FinalScriptSig) != 0 ||
+			len(in.FinalScriptWitness) != 0 {
+
+			t.Fatalf("input %d was finalized", i)
+		}
+		sig := in.PartialSigs[0].Signature
+		hashType := txscript.SigHashType(sig[len(sig)-1])
+		expected := txscript.SigHashAll
+		if i == 1 {
+			expected = txscript.SigHashNone |
+				txscript.SigHashAnyOneCanPay
+		}
+		if hashType != expected {
+			t.Fatalf("input %d signed with sighash type %v, "+
+				"expected %v", i, hashType, expected)
+		}
+	}
+	if len(packet.Inputs[3].PartialSigs) != 0 {
+		t.Fatalf("foreign input was signed")
+	}
+
+	// Signing again doesn't add duplicate signatures.
+	signed, err = w.SignPsbt(packet)
+	if err != nil {
+		t.Fatalf("unable to sign PSBT again: %v", err)
+	}
+	if len(signed) != 0 {
+		t.Fatalf("inputs %v signed again", signed)
+	}
+	for i, in := range packet.Inputs[:3] {
+		if len(in.PartialSigs) != 1 {
+			t.Fatalf("input %d has %d signatures", i,
+				len(in.PartialSigs))
+		}
+	}
+
+	// The signed inputs can be finalized. We fake the witness of the
+	// foreign input so the transaction can be extracted and the wallet
+	// inputs verified.
+	for i := range packet.Inputs[:3] {
+		if err := psbt.Finalize(packet, i); err != nil {
+			t.Fatalf("unable to finalize input %d: %v", i, err)
+		}
+	}
+	var witness bytes.Buffer
+	err = psbt.WriteTxWitness(&witness, [][]byte{{0x01}})
+	if err != nil {
+		t.Fatalf("unable to serialize witness: %v", err)
+	}
+	packet.Inputs[3].FinalScriptWitness = witness.Bytes()
+	finalTx, err := psbt.Extract(packet)
+	if err != nil {
+		t.Fatalf("error extracting final TX from PSBT: %v", err)
+	}
+	err = validateMsgTx(
+		finalTx, pkScripts,
+		[]btcutil.Amount{1000000, 1000000, 1000000},
+	)
+	if err != nil {
+		t.Fatalf("error validating tx: %v", err)
+	}
+}
+
+// TestSignPsbtHashType tests that the requested sighash type is only applied
+// to the inputs the wallet signs.
+func TestSignPsbtHashType(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to get current address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("unable to convert wallet address: %v", err)
+	}
+	incomingTx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(1000000, pkScript)},
+	}
+	addUtxo(t, w, incomingTx)
+
+	// The second input spends an output the wallet doesn't know about
+	// and has no sighash type.
+	newPacket := func() *psbt.Packet {
+		tx := &wire.MsgTx{
+			Version: 2,
+			TxIn: []*wire.TxIn{{
+				PreviousOutPoint: wire.OutPoint{
+					Hash: incomingTx.TxHash(),
+				},
+			}, {
+				PreviousOutPoint: wire.OutPoint{Index: 7},
+			}},
+			TxOut: []*wire.TxOut{wire.NewTxOut(1400000, testScriptP2WSH)},
+		}
+		packet, err := psbt.NewFromUnsignedTx(tx)
+		if err != nil {
+			t.Fatalf("unable to create PSBT: %v", err)
+		}
+		packet.Inputs[1].WitnessUtxo = wire.NewTxOut(
+			500000, testScriptP2WKH,
+		)
+		if err := w.UpdatePsbt(packet, true); err != nil {
+			t.Fatalf("unable to update PSBT: %v", err)
+		}
+		return packet
+	}
+
+	hashType := txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
+	packet := newPacket()
+	signed, err := w.SignPsbtHashType(packet, hashType)
+	if err != nil {
+		t.Fatalf("unable to sign PSBT: %v", err)
+	}
+	if !reflect.DeepEqual(signed, []uint32{0}) {
+		t.Fatalf("unexpected signed inputs %v", signed)
+	}
+	if packet.Inputs[0].SighashType != hashType {
+		t.Fatalf("signed input has sighash type %v, expected %v",
+			packet.Inputs[0].SighashType, hashType)
+	}
+	sig := packet.Inputs[0].PartialSigs[0].Signature
+	if txscript.SigHashType(sig[len(sig)-1]) != hashType {
+		t.Fatalf("input signed with sighash type %v, expected %v",
+			txscript.SigHashType(sig[len(sig)-1]), hashType)
+	}
+	if packet.Inputs[1].SighashType != 0 {
+		t.Fatalf("foreign input got sighash type %v",
+			packet.Inputs[1].SighashType)
+	}
+
+	// A different sighash type of a foreign input is no error, but one of
+	// an input the wallet signs is.
+	packet = newPacket()
+	packet.Inputs[1].SighashType = txscript.SigHashNone
+	if _, err := w.SignPsbtHashType(packet, hashType); err != nil {
+		t.Fatalf("unable to sign PSBT: %v", err)
+	}
+	packet = newPacket()
+	packet.Inputs[0].SighashType = txscript.SigHashNone
+	_, err = w.SignPsbtHashType(packet, hashType)
+	if err != ErrSighashMismatch {
+		t.Fatalf("expected ErrSighashMismatch, got %v", err)
+	}
+}
+
+// TestSignPsbtWitnessUtxo tests that inputs are only signed with the value of
+// the spent output known to the wallet, and never with the value of a witness
+// UTXO supplied with the packet (CVE-2020-14199).
+func TestSignPsbtWitnessUtxo(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to get current address: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("unable to convert wallet address: %v", err)
+	}
+	incomingTx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(1000000, pkScript)},
+	}
+	addUtxo(t, w, incomingTx)
+
+	newPacket := func(prevOut wire.OutPoint,
+		witnessUtxo *wire.TxOut) *psbt.Packet {
+
+		t.Helper()
+
+		tx := &wire.MsgTx{
+			Version: 2,
+			TxIn:    []*wire.TxIn{{PreviousOutPoint: prevOut}},
+			TxOut: []*wire.TxOut{{
+				PkScript: testScriptP2WSH,
+				Value:    900000,
+			}},
+		}
+		packet, err := psbt.NewFromUnsignedTx(tx)
+		if err != nil {
+			t.Fatalf("unable to create PSBT: %v", err)
+		}
+		packet.Inputs[0].WitnessUtxo = witnessUtxo
+		return packet
+	}
+	walletOutPoint := wire.OutPoint{Hash: incomingTx.TxHash()}
+
+	// A witness UTXO with an inflated value is refused.
+	packet := newPacket(walletOutPoint, wire.NewTxOut(10000000, pkScript))
+	if _, err := w.SignPsbt(packet); err == nil {
+		t.Fatal("signed input with a mismatching witness UTXO")
+	}
+	if len(packet.Inputs[0].PartialSigs) != 0 {
+		t.Fatal("input with a mismatching witness UTXO was signed")
+	}
+
+	// An output unknown to the wallet can't be verified without the full
+	// previous transaction, so it isn't signed.
+	packet = newPacket(
+		wire.OutPoint{Hash: chainhash.Hash{1}},
+		wire.NewTxOut(1000000, pkScript),
+	)
+	signed, err := w.SignPsbt(packet)
+	if err != nil {
+		t.Fatalf("unable to sign PSBT: %v", err)
+	}
+	if len(signed) != 0 {
+		t.Fatalf("signed unknown output with inputs %v", signed)
+	}
+
+	// A matching witness UTXO is signed without the full previous
+	// transaction.
+	packet = newPacket(walletOutPoint, wire.NewTxOut(1000000, pkScript))
+	signed, err = w.SignPsbt(packet)
+	if err != nil {
+		t.Fatalf("unable to sign PSBT: %v", err)
+	}
+	if !reflect.DeepEqual(signed, []uint32{0}) {
+		t.Fatalf("unexpected signed inputs %v", signed)
+	}
+}
@@ -0,0 +1,808 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/psbt"
+)
+
+// PSBT versions understood by DecodePsbt and EncodePsbt.
+const (
+	// PsbtV0 is the original BIP0174 format where the inputs and outputs
+	// are defined by a global unsigned transaction.
+	PsbtV0 uint32 = 0
+
+	// PsbtV2 is the BIP0370 format where the transaction fields are
+	// spread over the global, input and output maps, which allows inputs
+	// and outputs to be added after the packet has been created.
+	PsbtV2 uint32 = 2
+)
+
+// Key types introduced by BIP0370. The psbt package only knows about the
+// BIP0174 types, so these are converted to and from the packet's unsigned
+// transaction, or kept as unknowns where there is no equivalent.
+const (
+	psbtGlobalTxVersion        = 0x02
+	psbtGlobalFallbackLocktime = 0x03
+	psbtGlobalInputCount       = 0x04
+	psbtGlobalOutputCount      = 0x05
+	psbtGlobalTxModifiable     = 0x06
+	psbtGlobalVersion          = 0xfb
+
+	psbtInPreviousTxid           = 0x0e
+	psbtInOutputIndex            = 0x0f
+	psbtInSequence               = 0x10
+	psbtInRequiredTimeLocktime   = 0x11
+	psbtInRequiredHeightLocktime = 0x12
+
+	psbtOutAmount = 0x03
+	psbtOutScript = 0x04
+
+	// psbtOutMaxKnownType is the highest output key type that is understood
+	// by the psbt package. The psbt package rejects any other output
+	// fields, so they are moved to proprietary global fields when a packet
+	// is decoded and restored by EncodePsbt.
+	psbtOutMaxKnownType = 0x02
+
+	psbtProprietaryType = 0xfc
+
+	// psbtProprietaryOutputField is the subtype of the proprietary global
+	// fields holding an output field the psbt package doesn't understand.
+	// The key data is the index of the output as a little-endian uint32,
+	// followed by the key of the output field.
+	psbtProprietaryOutputField = 0x00
+
+	// psbtProprietaryHeightHint is the subtype of the proprietary input
+	// field holding the height of the block that contains the output
+	// spent by the input, as a little-endian uint32. It has no key data.
+	psbtProprietaryHeightHint = 0x01
+)
+
+// psbtProprietaryID is the identifier of the proprietary fields used by the
+// wallet.
+var psbtProprietaryID = []byte("btcwallet")
+
+// Flags of the PSBT_GLOBAL_TX_MODIFIABLE field.
+const (
+	// PsbtInputsModifiable is set when inputs may be added to or removed
+	// from a PSBTv2 packet.
+	PsbtInputsModifiable byte = 1 << 0
+
+	// PsbtOutputsModifiable is set when outputs may be added to or removed
+	// from a PSBTv2 packet.
+	PsbtOutputsModifiable byte = 1 << 1
+
+	// PsbtHasSigHashSingle is set when the packet contains a signature
+	// using SIGHASH_SINGLE, which pins the input to the output at the
+	// same index.
+	PsbtHasSigHashSingle byte = 1 << 2
+)
+
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// psbtKV is a raw key-value pair of a PSBT map. The key includes the key type
+// as its first byte.
+type psbtKV struct {
+	key   []byte
+	value []byte
+}
+
+// DecodePsbt parses a binary PSBT of either version 0 or version 2 and returns
+// the packet along with its version. Version 2 packets are converted to the
+// version 0 representation of the psbt package: the unsigned transaction is
+// assembled from the per-input and per-output fields with the lock time
+// chosen as described by BIP0370. The required lock times of the inputs and
+// the modifiable flags are kept as unknowns so that EncodePsbt is able to
+// restore them.
+func DecodePsbt(b []byte) (*psbt.Packet, uint32, error) {
+	r := bytes.NewReader(b)
+	magic := make([]byte, len(psbtMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(magic, psbtMagic) {
+		return nil, 0, psbt.ErrInvalidMagicBytes
+	}
+	globals, err := readPsbtMap(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version := PsbtV0
+	if kv := findPsbtKV(globals, psbtGlobalVersion); kv != nil {
+		if len(kv.value) != 4 {
+			return nil, 0, psbt.ErrInvalidPsbtFormat
+		}
+		version = binary.LittleEndian.Uint32(kv.value)
+	}
+	switch version {
+	case PsbtV0:
+		b, err = stashPsbtV0OutputFields(b, r, globals)
+		if err != nil {
+			return nil, 0, err
+		}
+		packet, err := psbt.NewFromRawBytes(bytes.NewReader(b), false)
+		if err != nil {
+			return nil, 0, err
+		}
+		return packet, PsbtV0, nil
+
+	case PsbtV2:
+		packet, err := decodePsbtV2(r, globals)
+		if err != nil {
+			return nil, 0, err
+		}
+		return packet, PsbtV2, nil
+
+	default:
+		return nil, 0, fmt.Errorf("unsupported PSBT version %d", version)
+	}
+}
+
+// EncodePsbt serializes a packet using the given PSBT version. Unlike the
+// serialization of the psbt package, global unknowns are included and output
+// fields kept by DecodePsbt are restored. Fields that only exist in the other
+// version are omitted.
+func EncodePsbt(packet *psbt.Packet, version uint32) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, err
+	}
+
+	// Parse the serialization again to work with the raw maps. This keeps
+	// the per-field serialization in the psbt package.
+	r := bytes.NewReader(buf.Bytes()[len(psbtMagic):])
+	globals, err := readPsbtMap(r)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([][]psbtKV, len(packet.Inputs))
+	for i := range inputs {
+		if inputs[i], err = readPsbtMap(r); err != nil {
+			return nil, err
+		}
+	}
+	outputs := make([][]psbtKV, len(packet.Outputs))
+	for i := range outputs {
+		if outputs[i], err = readPsbtMap(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var unknowns []psbtKV
+	for _, u := range packet.Unknowns {
+		index, key, ok := parsePsbtOutputFieldKey(u.Key)
+		if ok && index < uint32(len(outputs)) {
+			outputs[index] = append(outputs[index], psbtKV{
+				key: key, value: u.Value,
+			})
+			continue
+		}
+		unknowns = append(unknowns, psbtKV{key: u.Key, value: u.Value})
+	}
+
+	switch version {
+	case PsbtV0:
+		globals = append(globals, filterPsbtKVs(unknowns, isPsbtV2Global)...)
+		for i := range inputs {
+			inputs[i] = filterPsbtKVs(inputs[i], isPsbtV2Input)
+		}
+
+	case PsbtV2:
+		globals, err = psbtV2Globals(packet, unknowns)
+		if err != nil {
+			return nil, err
+		}
+		for i, txIn := range packet.UnsignedTx.TxIn {
+			in := filterPsbtKVs(inputs[i], func(keyType byte) bool {
+				return keyType >= psbtInPreviousTxid &&
+					keyType <= psbtInSequence
+			})
+			prevOut := txIn.PreviousOutPoint
+			in = append(in,
+				psbtKV{[]byte{psbtInPreviousTxid}, prevOut.Hash[:]},
+				psbtKV{[]byte{psbtInOutputIndex}, uint32LE(prevOut.Index)},
+				psbtKV{[]byte{psbtInSequence}, uint32LE(txIn.Sequence)},
+			)
+			inputs[i] = in
+		}
+		for i, txOut := range packet.UnsignedTx.TxOut {
+			var amount [8]byte
+			binary.LittleEndian.PutUint64(amount[:], uint64(txOut.Value))
+			outputs[i] = append(outputs[i],
+				psbtKV{[]byte{psbtOutAmount}, amount[:]},
+				psbtKV{[]byte{psbtOutScript}, txOut.PkScript},
+			)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported PSBT version %d", version)
+	}
+
+	buf.Reset()
+	buf.Write(psbtMagic)
+	writePsbtMap(&buf, globals)
+	for _, in := range inputs {
+		writePsbtMap(&buf, in)
+	}
+	for _, out := range outputs {
+		writePsbtMap(&buf, out)
+	}
+	return buf.Bytes(), nil
+}
+
+// PsbtModifiable returns the PSBT_GLOBAL_TX_MODIFIABLE flags of a packet that
+// was decoded from a PSBTv2. The boolean is false for version 0 packets, which
+// don't restrict modifications.
+func PsbtModifiable(packet *psbt.Packet) (byte, bool) {
+	for _, u := range packet.Unknowns {
+		if len(u.Key) == 1 && u.Key[0] == psbtGlobalTxModifiable &&
+			len(u.Value) == 1 {
+
+			return u.Value[0], true
+		}
+	}
+	return 0, false
+}
+
+// setPsbtModifiable replaces the PSBT_GLOBAL_TX_MODIFIABLE flags of a packet.
+func setPsbtModifiable(packet *psbt.Packet, flags byte) {
+	for i, u := range packet.Unknowns {
+		if len(u.Key) == 1 && u.Key[0] == psbtGlobalTxModifiable {
+			packet.Unknowns[i].Value = []byte{flags}
+			return
+		}
+	}
+	packet.Unknowns = append(packet.Unknowns, psbt.Unknown{
+		Key:   []byte{psbtGlobalTxModifiable},
+		Value: []byte{flags},
+	})
+}
+
+// CombinePsbtModifiable merges the modifiable flags of src into dst when two
+// PSBTv2 packets are combined. Inputs and outputs remain modifiable only if
+// both packets allow it, while a SIGHASH_SINGLE signature in either packet is
+// retained.
+func CombinePsbtModifiable(dst, src *psbt.Packet) {
+	srcFlags, ok := PsbtModifiable(src)
+	if !ok {
+		return
+	}
+	dstFlags, ok := PsbtModifiable(dst)
+	if !ok {
+		return
+	}
+	const modifiable = PsbtInputsModifiable | PsbtOutputsModifiable
+	flags := (dstFlags & srcFlags & modifiable) |
+		((dstFlags | srcFlags) &^ modifiable)
+	setPsbtModifiable(dst, flags)
+}
+
+// updatePsbtModifiable clears and sets the modifiable flags of a PSBTv2
+// packet after a signature with the given hash type was added, as required
+// of signers by BIP0370. Version 0 packets are left untouched.
+func updatePsbtModifiable(packet *psbt.Packet, hashType txscript.SigHashType) {
+	flags, ok := PsbtModifiable(packet)
+	if !ok {
+		return
+	}
+	if hashType&txscript.SigHashAnyOneCanPay == 0 {
+		flags &^= PsbtInputsModifiable
+	}
+	switch hashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashNone:
+	case txscript.SigHashSingle:
+		flags &^= PsbtOutputsModifiable
+		flags |= PsbtHasSigHashSingle
+	default:
+		flags &^= PsbtOutputsModifiable
+	}
+	setPsbtModifiable(packet, flags)
+}
+
+// decodePsbtV2 converts the remaining maps of a PSBTv2 to a packet. The global
+// map has already been read.
+func decodePsbtV2(r *bytes.Reader, globals []psbtKV) (*psbt.Packet, error) {
+	readCount := func(keyType byte) (uint64, error) {
+		kv := findPsbtKV(globals, keyType)
+		if kv == nil {
+			return 0, fmt.Errorf("PSBTv2 is missing global "+
+				"field 0x%02x", keyType)
+		}
+		return wire.ReadVarInt(bytes.NewReader(kv.value), 0)
+	}
+	numInputs, err := readCount(psbtGlobalInputCount)
+	if err != nil {
+		return nil, err
+	}
+	numOutputs, err := readCount(psbtGlobalOutputCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every map takes at least a byte, which bounds the counts before
+	// anything is allocated.
+	if numInputs+numOutputs > uint64(r.Len()) {
+		return nil, psbt.ErrInvalidPsbtFormat
+	}
+
+	txVersion := findPsbtKV(globals, psbtGlobalTxVersion)
+	if txVersion == nil || len(txVersion.value) != 4 {
+		return nil, fmt.Errorf("PSBTv2 is missing a valid transaction " +
+			"version")
+	}
+	tx := wire.NewMsgTx(int32(binary.LittleEndian.Uint32(txVersion.value)))
+	if tx.Version < 2 {
+		return nil, fmt.Errorf("PSBTv2 transaction version must be at "+
+			"least 2, got %d", tx.Version)
+	}
+
+	inputs := make([][]psbtKV, numInputs)
+	lockTimes := make([]psbtRequiredLockTime, numInputs)
+	for i := range inputs {
+		in, err := readPsbtMap(r)
+		if err != nil {
+			return nil, err
+		}
+
+		txIn, lockTime, err := psbtV2TxIn(in)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		tx.AddTxIn(txIn)
+		lockTimes[i] = lockTime
+
+		// The transaction fields are part of the unsigned transaction
+		// now, while the required lock times are kept as unknowns.
+		inputs[i] = filterPsbtKVs(in, func(keyType byte) bool {
+			return keyType >= psbtInPreviousTxid &&
+				keyType <= psbtInSequence
+		})
+	}
+
+	outputs := make([][]psbtKV, numOutputs)
+	for i := range outputs {
+		out, err := readPsbtMap(r)
+		if err != nil {
+			return nil, err
+		}
+
+		amount := findPsbtKV(out, psbtOutAmount)
+		script := findPsbtKV(out, psbtOutScript)
+		if amount == nil || len(amount.value) != 8 || script == nil {
+			return nil, fmt.Errorf("output %d is missing its amount "+
+				"or script", i)
+		}
+		tx.AddTxOut(wire.NewTxOut(
+			int64(binary.LittleEndian.Uint64(amount.value)),
+			script.value,
+		))
+
+		outputs[i] = out
+	}
+	stashed := stashPsbtOutputFields(outputs, func(keyType byte) bool {
+		return keyType == psbtOutAmount || keyType == psbtOutScript
+	})
+
+	var fallback uint32
+	if kv := findPsbtKV(globals, psbtGlobalFallbackLocktime); kv != nil {
+		if len(kv.value) != 4 {
+			return nil, psbt.ErrInvalidPsbtFormat
+		}
+		fallback = binary.LittleEndian.Uint32(kv.value)
+	}
+	tx.LockTime, err = psbtLockTime(lockTimes, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	// The packet is assembled as a version 0 serialization so the fields
+	// are parsed and validated by the psbt package. The modifiable flags
+	// are always kept, as a missing field means nothing may be modified.
+	var txBuf bytes.Buffer
+	if err := tx.SerializeNoWitness(&txBuf); err != nil {
+		return nil, err
+	}
+	if findPsbtKV(globals, byte(psbt.UnsignedTxType)) != nil {
+		return nil, errors.New("PSBTv2 must not contain an unsigned " +
+			"transaction")
+	}
+	v0Globals := []psbtKV{{[]byte{byte(psbt.UnsignedTxType)}, txBuf.Bytes()}}
+	v0Globals = append(v0Globals, filterPsbtKVs(globals, func(keyType byte) bool {
+		return keyType != psbtGlobalTxModifiable &&
+			isPsbtV2Global(keyType)
+	})...)
+	if findPsbtKV(globals, psbtGlobalTxModifiable) == nil {
+		v0Globals = append(v0Globals, psbtKV{
+			[]byte{psbtGlobalTxModifiable}, []byte{0},
+		})
+	}
+	v0Globals = append(v0Globals, stashed...)
+
+	var buf bytes.Buffer
+	buf.Write(psbtMagic)
+	writePsbtMap(&buf, v0Globals)
+	for _, in := range inputs {
+		writePsbtMap(&buf, in)
+	}
+	for _, out := range outputs {
+		writePsbtMap(&buf, out)
+	}
+	return psbt.NewFromRawBytes(&buf, false)
+}
+
+// stashPsbtV0OutputFields moves the output fields of a version 0 PSBT that
+// the psbt package doesn't understand to proprietary global fields. The
+// global map has already been read from r. The serialization is returned
+// unchanged if there are no such fields, or if it can't be parsed, in which
+// case the psbt package reports the error.
+func stashPsbtV0OutputFields(b []byte, r *bytes.Reader,
+	globals []psbtKV) ([]byte, error) {
+
+	unsignedTx := findPsbtKV(globals, byte(psbt.UnsignedTxType))
+	if unsignedTx == nil {
+		return b, nil
+	}
+	var tx wire.MsgTx
+	err := tx.DeserializeNoWitness(bytes.NewReader(unsignedTx.value))
+	if err != nil {
+		return b, nil
+	}
+
+	inputs := make([][]psbtKV, len(tx.TxIn))
+	for i := range inputs {
+		if inputs[i], err = readPsbtMap(r); err != nil {
+			return b, nil
+		}
+	}
+	outputs := make([][]psbtKV, len(tx.TxOut))
+	for i := range outputs {
+		if outputs[i], err = readPsbtMap(r); err != nil {
+			return b, nil
+		}
+	}
+
+	stashed := stashPsbtOutputFields(outputs, func(byte) bool {
+		return false
+	})
+	if len(stashed) == 0 {
+		return b, nil
+	}
+	for _, kv := range stashed {
+		for _, g := range globals {
+			if bytes.Equal(g.key, kv.key) {
+				return nil, psbt.ErrDuplicateKey
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(psbtMagic)
+	writePsbtMap(&buf, append(globals, stashed...))
+	for _, in := range inputs {
+		writePsbtMap(&buf, in)
+	}
+	for _, out := range outputs {
+		writePsbtMap(&buf, out)
+	}
+	return buf.Bytes(), nil
+}
+
+// stashPsbtOutputFields removes the fields the psbt package doesn't understand
+// from the output maps and returns them as proprietary global fields, except
+// for the fields matched by the derived function, which are dropped.
+func stashPsbtOutputFields(outputs [][]psbtKV,
+	derived func(keyType byte) bool) []psbtKV {
+
+	var stashed []psbtKV
+	for i, out := range outputs {
+		outputs[i] = filterPsbtKVs(out, func(keyType byte) bool {
+			return keyType > psbtOutMaxKnownType
+		})
+		for _, kv := range out {
+			if kv.key[0] <= psbtOutMaxKnownType || derived(kv.key[0]) {
+				continue
+			}
+			stashed = append(stashed, psbtKV{
+				key:   psbtOutputFieldKey(uint32(i), kv.key),
+				value: kv.value,
+			})
+		}
+	}
+	return stashed
+}
+
+// psbtOutputFieldKey returns the key of the proprietary global field holding
+// the output field with the given key.
+func psbtOutputFieldKey(index uint32, key []byte) []byte {
+	return psbtProprietaryKey(
+		psbtProprietaryOutputField, append(uint32LE(index), key...),
+	)
+}
+
+// parsePsbtOutputFieldKey returns the output index and the key of the output
+// field held by a proprietary global field. The boolean is false if the key
+// isn't one created by psbtOutputFieldKey.
+func parsePsbtOutputFieldKey(key []byte) (uint32, []byte, bool) {
+	keyData, ok := parsePsbtProprietaryKey(key, psbtProprietaryOutputField)
+	if !ok || len(keyData) <= 4 {
+		return 0, nil, false
+	}
+	return binary.LittleEndian.Uint32(keyData[:4]), keyData[4:], true
+}
+
+// psbtProprietaryKey returns the key of a proprietary field of the wallet with
+// the given subtype and key data.
+func psbtProprietaryKey(subtype uint64, keyData []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(psbtProprietaryType)
+	_ = wire.WriteVarBytes(&buf, 0, psbtProprietaryID)
+	_ = wire.WriteVarInt(&buf, 0, subtype)
+	buf.Write(keyData)
+	return buf.Bytes()
+}
+
+// parsePsbtProprietaryKey returns the key data of a proprietary field of the
+// wallet with the given subtype. The boolean is false if the key is of another
+// field.
+func parsePsbtProprietaryKey(key []byte, subtype uint64) ([]byte, bool) {
+	if len(key) == 0 || key[0] != psbtProprietaryType {
+		return nil, false
+	}
+	r := bytes.NewReader(key[1:])
+	id, err := wire.ReadVarBytes(r, 0, uint32(len(key)), "identifier")
+	if err != nil || !bytes.Equal(id, psbtProprietaryID) {
+		return nil, false
+	}
+	keySubtype, err := wire.ReadVarInt(r, 0)
+	if err != nil || keySubtype != subtype {
+		return nil, false
+	}
+	return key[len(key)-r.Len():], true
+}
+
+// psbtRequiredLockTime holds the lock times an input of a PSBTv2 requires.
+// A zero value means no lock time of that kind is required.
+type psbtRequiredLockTime struct {
+	time   uint32
+	height uint32
+}
+
+// psbtV2TxIn creates the transaction input described by the fields of a PSBTv2
+// input map and returns the lock times it requires.
+func psbtV2TxIn(in []psbtKV) (*wire.TxIn, psbtRequiredLockTime, error) {
+	var lockTime psbtRequiredLockTime
+
+	txid := findPsbtKV(in, psbtInPreviousTxid)
+	index := findPsbtKV(in, psbtInOutputIndex)
+	if txid == nil || len(txid.value) != chainhash.HashSize ||
+		index == nil || len(index.value) != 4 {
+
+		return nil, lockTime, errors.New("missing previous outpoint")
+	}
+	var hash chainhash.Hash
+	copy(hash[:], txid.value)
+	prevOut := wire.NewOutPoint(&hash, binary.LittleEndian.Uint32(index.value))
+	txIn := wire.NewTxIn(prevOut, nil, nil)
+
+	if kv := findPsbtKV(in, psbtInSequence); kv != nil {
+		if len(kv.value) != 4 {
+			return nil, lockTime, errors.New("invalid sequence")
+		}
+		txIn.Sequence = binary.LittleEndian.Uint32(kv.value)
+	}
+
+	if kv := findPsbtKV(in, psbtInRequiredTimeLocktime); kv != nil {
+		if len(kv.value) != 4 {
+			return nil, lockTime, errors.New("invalid required time " +
+				"lock time")
+		}
+		lockTime.time = binary.LittleEndian.Uint32(kv.value)
+		if lockTime.time < txscript.LockTimeThreshold {
+			return nil, lockTime, fmt.Errorf("required time lock "+
+				"time %d is a height", lockTime.time)
+		}
+	}
+	if kv := findPsbtKV(in, psbtInRequiredHeightLocktime); kv != nil {
+		if len(kv.value) != 4 {
+			return nil, lockTime, errors.New("invalid required " +
+				"height lock time")
+		}
+		lockTime.height = binary.LittleEndian.Uint32(kv.value)
+		if lockTime.height == 0 ||
+			lockTime.height >= txscript.LockTimeThreshold {
+
+			return nil, lockTime, fmt.Errorf("invalid required "+
+				"height lock time %d", lockTime.height)
+		}
+	}
+
+	return txIn, lockTime, nil
+}
+
+// psbtLockTime determines the lock time of a PSBTv2 transaction. If no input
+// requires a lock time, the fallback is used. Otherwise the kind of lock time
+// supported by all inputs that specify one is used, preferring heights, and
+// the highest requirement of that kind is chosen.
+func psbtLockTime(lockTimes []psbtRequiredLockTime, fallback uint32) (uint32, error) {
+	var (
+		required           bool
+		allHeight          = true
+		allTime            = true
+		maxHeight, maxTime uint32
+	)
+	for _, lockTime := range lockTimes {
+		if lockTime.height == 0 && lockTime.time == 0 {
+			continue
+		}
+		required = true
+		if lockTime.height == 0 {
+			allHeight = false
+		}
+		if lockTime.time == 0 {
+			allTime = false
+		}
+		if lockTime.height > maxHeight {
+			maxHeight = lockTime.height
+		}
+		if lockTime.time > maxTime {
+			maxTime = lockTime.time
+		}
+	}
+
+	switch {
+	case !required:
+		return fallback, nil
+	case allHeight:
+		return maxHeight, nil
+	case allTime:
+		return maxTime, nil
+	default:
+		return 0, errors.New("inputs require incompatible lock times")
+	}
+}
+
+// psbtV2Globals creates the global map of a PSBTv2 for a packet. The unknowns
+// of the packet are included except for fields that are derived from the
+// unsigned transaction.
+func psbtV2Globals(packet *psbt.Packet, unknowns []psbtKV) ([]psbtKV, error) {
+	tx := packet.UnsignedTx
+	if tx.Version < 2 {
+		return nil, fmt.Errorf("PSBTv2 requires a transaction version "+
+			"of at least 2, got %d", tx.Version)
+	}
+
+	var inputCount, outputCount bytes.Buffer
+	err := wire.WriteVarInt(&inputCount, 0, uint64(len(tx.TxIn)))
+	if err != nil {
+		return nil, err
+	}
+	err = wire.WriteVarInt(&outputCount, 0, uint64(len(tx.TxOut)))
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock time of the unsigned transaction satisfies the required
+	// lock times of all inputs, so it is a suitable fallback.
+	globals := []psbtKV{
+		{[]byte{psbtGlobalTxVersion}, uint32LE(uint32(tx.Version))},
+		{[]byte{psbtGlobalFallbackLocktime}, uint32LE(tx.LockTime)},
+		{[]byte{psbtGlobalInputCount}, inputCount.Bytes()},
+		{[]byte{psbtGlobalOutputCount}, outputCount.Bytes()},
+	}
+	for _, kv := range unknowns {
+		switch kv.key[0] {
+		case psbtGlobalTxVersion, psbtGlobalFallbackLocktime,
+			psbtGlobalInputCount, psbtGlobalOutputCount,
+			psbtGlobalVersion:
+
+			continue
+		}
+		globals = append(globals, kv)
+	}
+	globals = append(globals, psbtKV{
+		[]byte{psbtGlobalVersion}, uint32LE(PsbtV2),
+	})
+	return globals, nil
+}
+
+// isPsbtV2Global returns true for the global key types that are only valid in
+// a PSBTv2, as well as the version field.
+func isPsbtV2Global(keyType byte) bool {
+	return (keyType >= psbtGlobalTxVersion &&
+		keyType <= psbtGlobalTxModifiable) ||
+		keyType == psbtGlobalVersion
+}
+
+// isPsbtV2Input returns true for the input key types that are only valid in a
+// PSBTv2.
+func isPsbtV2Input(keyType byte) bool {
+	return keyType >= psbtInPreviousTxid &&
+		keyType <= psbtInRequiredHeightLocktime
+}
+
+// filterPsbtKVs returns the pairs whose key type is not matched by the
+// exclude function.
+func filterPsbtKVs(kvs []psbtKV, exclude func(keyType byte) bool) []psbtKV {
+	filtered := make([]psbtKV, 0, len(kvs))
+	for _, kv := range kvs {
+		if !exclude(kv.key[0]) {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// findPsbtKV returns the pair of a map that has the given key type and no key
+// data, or nil if there is none.
+func findPsbtKV(kvs []psbtKV, keyType byte) *psbtKV {
+	for i := range kvs {
+		if len(kvs[i].key) == 1 && kvs[i].key[0] == keyType {
+			return &kvs[i]
+		}
+	}
+	return nil
+}
+
+// readPsbtMap reads the key-value pairs of a PSBT map up to and including its
+// separator.
+func readPsbtMap(r io.Reader) ([]psbtKV, error) {
+	var kvs []psbtKV
+	for {
+		keyLen, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, psbt.ErrInvalidPsbtFormat
+		}
+		if keyLen == 0 {
+			return kvs, nil
+		}
+		if keyLen > psbt.MaxPsbtKeyLength {
+			return nil, psbt.ErrInvalidKeydata
+		}
+		key := make([]byte, keyLen)
+		if _, err := io.ReadFull(r, key); err != nil {
+			return nil, psbt.ErrInvalidPsbtFormat
+		}
+		value, err := wire.ReadVarBytes(
+			r, 0, psbt.MaxPsbtValueLength, "PSBT value",
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			if bytes.Equal(kv.key, key) {
+				return nil, psbt.ErrDuplicateKey
+			}
+		}
+		kvs = append(kvs, psbtKV{key: key, value: value})
+	}
+}
+
+// writePsbtMap writes the key-value pairs of a PSBT map followed by its
+// separator.
+func writePsbtMap(w *bytes.Buffer, kvs []psbtKV) {
+	for _, kv := range kvs {
+		// Writing to a bytes.Buffer can't fail.
+		_ = wire.WriteVarBytes(w, 0, kv.key)
+		_ = wire.WriteVarBytes(w, 0, kv.value)
+	}
+	w.WriteByte(0x00)
+}
+
+// uint32LE returns the little-endian serialization of a uint32.
+func uint32LE(v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return b[:]
+}
@@ -0,0 +1,393 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/psbt"
+)
+
+// TestPsbtV2RoundTrip tests that a packet survives a conversion to a PSBTv2
+// and back.
+func TestPsbtV2RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{1}, Index: 3},
+		Sequence:         wire.MaxTxInSequenceNum - 2,
+	})
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{2}},
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxOut(wire.NewTxOut(1000, testScriptP2WKH))
+	tx.AddTxOut(wire.NewTxOut(2000, testScriptP2WSH))
+	tx.LockTime = 650000
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(5000, testScriptP2WKH)
+	packet.Inputs[1].SighashType = txscript.SigHashAll
+	packet.Inputs[1].Unknowns = []*psbt.Unknown{
+		{Key: []byte{0xfc, 0x01}, Value: []byte{0xaa}},
+	}
+	packet.Outputs[1].WitnessScript = []byte{txscript.OP_TRUE}
+	packet.Unknowns = []psbt.Unknown{
+		{Key: []byte{0xfc, 0x02}, Value: []byte{0xbb}},
+	}
+
+	v2, err := EncodePsbt(packet, PsbtV2)
+	if err != nil {
+		t.Fatalf("unable to encode PSBTv2: %v", err)
+	}
+	decoded, version, err := DecodePsbt(v2)
+	if err != nil {
+		t.Fatalf("unable to decode PSBTv2: %v", err)
+	}
+	if version != PsbtV2 {
+		t.Fatalf("expected version %d, got %d", PsbtV2, version)
+	}
+	if decoded.UnsignedTx.TxHash() != tx.TxHash() {
+		t.Fatalf("unsigned transaction changed: %v",
+			decoded.UnsignedTx.TxHash())
+	}
+
+	// A PSBTv2 without modifiable flags doesn't allow any modifications.
+	if flags, ok := PsbtModifiable(decoded); !ok || flags != 0 {
+		t.Fatalf("expected unmodifiable packet, got %v, %v", flags, ok)
+	}
+
+	// Encoding the decoded packet again must result in the same PSBTv2,
+	// while the version 0 encoding must match the original packet.
+	v2Again, err := EncodePsbt(decoded, PsbtV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodedAgain, _, err := DecodePsbt(v2Again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0, err := EncodePsbt(decodedAgain, PsbtV0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := EncodePsbt(packet, PsbtV0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v0, want) {
+		t.Fatalf("version 0 encoding changed:\nwant %x\ngot  %x",
+			want, v0)
+	}
+
+	// Global unknowns are part of the version 0 encoding.
+	v0Packet, version, err := DecodePsbt(v0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != PsbtV0 || len(v0Packet.Unknowns) != 1 ||
+		!bytes.Equal(v0Packet.Unknowns[0].Value, []byte{0xbb}) {
+
+		t.Fatalf("unexpected version 0 packet: version %d, "+
+			"unknowns %v", version, v0Packet.Unknowns)
+	}
+
+	// Transactions of version 1 can't be encoded as a PSBTv2.
+	packet.UnsignedTx.Version = 1
+	if _, err := EncodePsbt(packet, PsbtV2); err == nil {
+		t.Fatal("expected error encoding version 1 transaction")
+	}
+}
+
+// TestPsbtOutputFields tests that output fields the psbt package doesn't
+// understand are kept when a PSBT is decoded and encoded again in either
+// version.
+func TestPsbtOutputFields(t *testing.T) {
+	t.Parallel()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: chainhash.Hash{1}},
+	})
+	tx.AddTxOut(wire.NewTxOut(1000, testScriptP2WKH))
+	tx.AddTxOut(wire.NewTxOut(2000, testScriptP2WSH))
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// readMaps returns the global, input and output maps of a serialized
+	// PSBT.
+	readMaps := func(b []byte) ([]psbtKV, [][]psbtKV, [][]psbtKV) {
+		t.Helper()
+
+		r := bytes.NewReader(b[len(psbtMagic):])
+		globals, err := readPsbtMap(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		maps := make([][]psbtKV, len(tx.TxIn)+len(tx.TxOut))
+		for i := range maps {
+			if maps[i], err = readPsbtMap(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return globals, maps[:len(tx.TxIn)], maps[len(tx.TxIn):]
+	}
+
+	// A taproot internal key and a proprietary field are added to the
+	// second output.
+	fields := []psbtKV{
+		{[]byte{0x05}, bytes.Repeat([]byte{0x02}, 32)},
+		{[]byte{0xfc, 0x03, 'f', 'o', 'o', 0x01}, []byte{0xaa}},
+	}
+	for _, version := range []uint32{PsbtV0, PsbtV2} {
+		b, err := EncodePsbt(packet, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		globals, inputs, outputs := readMaps(b)
+		outputs[1] = append(outputs[1], fields...)
+
+		var buf bytes.Buffer
+		buf.Write(psbtMagic)
+		writePsbtMap(&buf, globals)
+		for _, in := range inputs {
+			writePsbtMap(&buf, in)
+		}
+		for _, out := range outputs {
+			writePsbtMap(&buf, out)
+		}
+
+		decoded, decodedVersion, err := DecodePsbt(buf.Bytes())
+		if err != nil {
+			t.Fatalf("unable to decode version %d: %v", version,
+				err)
+		}
+		if decodedVersion != version {
+			t.Fatalf("expected version %d, got %d", version,
+				decodedVersion)
+		}
+
+		for _, encodeVersion := range []uint32{PsbtV0, PsbtV2} {
+			b, err := EncodePsbt(decoded, encodeVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			globals, _, outputs := readMaps(b)
+			for _, kv := range globals {
+				_, _, ok := parsePsbtOutputFieldKey(kv.key)
+				if ok {
+					t.Fatalf("version %d encoding contains "+
+						"global output field %x",
+						encodeVersion, kv.key)
+				}
+			}
+			for _, field := range fields {
+				var found bool
+				for _, kv := range outputs[1] {
+					found = found ||
+						(bytes.Equal(kv.key, field.key) &&
+							bytes.Equal(kv.value, field.value))
+				}
+				if !found {
+					t.Fatalf("version %d encoding of "+
+						"version %d is missing output "+
+						"field %x", encodeVersion,
+						version, field.key)
+				}
+			}
+		}
+	}
+}
+
+// TestDecodePsbtV2LockTime tests that the lock time of a PSBTv2 transaction is
+// determined by the required lock times of its inputs, which are kept when
+// the packet is encoded again.
+func TestDecodePsbtV2LockTime(t *testing.T) {
+	t.Parallel()
+
+	u32 := func(v uint32) []byte {
+		return uint32LE(v)
+	}
+	input := func(hash byte, lockTimes ...psbtKV) []psbtKV {
+		return append([]psbtKV{
+			{[]byte{psbtInPreviousTxid}, bytes.Repeat([]byte{hash}, 32)},
+			{[]byte{psbtInOutputIndex}, u32(0)},
+		}, lockTimes...)
+	}
+	height := func(v uint32) psbtKV {
+		return psbtKV{[]byte{psbtInRequiredHeightLocktime}, u32(v)}
+	}
+	time := func(v uint32) psbtKV {
+		return psbtKV{[]byte{psbtInRequiredTimeLocktime}, u32(v)}
+	}
+
+	tests := []struct {
+		name     string
+		inputs   [][]psbtKV
+		lockTime uint32
+		valid    bool
+	}{{
+		name:     "fallback",
+		inputs:   [][]psbtKV{input(1), input(2)},
+		lockTime: 100,
+		valid:    true,
+	}, {
+		name: "heights",
+		inputs: [][]psbtKV{
+			input(1, height(650000)), input(2, height(660000)),
+			input(3),
+		},
+		lockTime: 660000,
+		valid:    true,
+	}, {
+		name: "height preferred",
+		inputs: [][]psbtKV{
+			input(1, height(650000), time(1600000000)),
+			input(2, height(640000)),
+		},
+		lockTime: 650000,
+		valid:    true,
+	}, {
+		name: "time required",
+		inputs: [][]psbtKV{
+			input(1, height(650000), time(1600000000)),
+			input(2, time(1700000000)),
+		},
+		lockTime: 1700000000,
+		valid:    true,
+	}, {
+		name: "incompatible",
+		inputs: [][]psbtKV{
+			input(1, height(650000)), input(2, time(1700000000)),
+		},
+		valid: false,
+	}, {
+		name:   "time below threshold",
+		inputs: [][]psbtKV{input(1, time(650000))},
+		valid:  false,
+	}}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		buf.Write(psbtMagic)
+		var count [1]byte
+		count[0] = byte(len(test.inputs))
+		writePsbtMap(&buf, []psbtKV{
+			{[]byte{psbtGlobalTxVersion}, u32(2)},
+			{[]byte{psbtGlobalFallbackLocktime}, u32(100)},
+			{[]byte{psbtGlobalInputCount}, count[:]},
+			{[]byte{psbtGlobalOutputCount}, []byte{1}},
+			{[]byte{psbtGlobalTxModifiable}, []byte{PsbtInputsModifiable}},
+			{[]byte{psbtGlobalVersion}, u32(PsbtV2)},
+		})
+		for _, in := range test.inputs {
+			writePsbtMap(&buf, in)
+		}
+		var amount [8]byte
+		binary.LittleEndian.PutUint64(amount[:], 1000)
+		writePsbtMap(&buf, []psbtKV{
+			{[]byte{psbtOutAmount}, amount[:]},
+			{[]byte{psbtOutScript}, testScriptP2WKH},
+		})
+
+		packet, _, err := DecodePsbt(buf.Bytes())
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unable to decode: %v", test.name, err)
+			continue
+		}
+		if packet.UnsignedTx.LockTime != test.lockTime {
+			t.Errorf("%s: expected lock time %d, got %d", test.name,
+				test.lockTime, packet.UnsignedTx.LockTime)
+		}
+		if flags, _ := PsbtModifiable(packet); flags != PsbtInputsModifiable {
+			t.Errorf("%s: unexpected modifiable flags %v",
+				test.name, flags)
+		}
+
+		// The required lock times must survive a round trip.
+		b, err := EncodePsbt(packet, PsbtV2)
+		if err != nil {
+			t.Errorf("%s: unable to encode: %v", test.name, err)
+			continue
+		}
+		packet, _, err = DecodePsbt(b)
+		if err != nil {
+			t.Errorf("%s: unable to decode again: %v", test.name, err)
+			continue
+		}
+		if packet.UnsignedTx.LockTime != test.lockTime {
+			t.Errorf("%s: lock time changed to %d", test.name,
+				packet.UnsignedTx.LockTime)
+		}
+		for i, in := range test.inputs {
+			if len(packet.Inputs[i].Unknowns) != len(in)-2 {
+				t.Errorf("%s: input %d lost its required lock "+
+					"times", test.name, i)
+			}
+		}
+	}
+}
+
+// TestPsbtModifiable tests the modifiable flags of a PSBTv2 are updated by
+// signatures and merged when packets are combined.
+func TestPsbtModifiable(t *testing.T) {
+	t.Parallel()
+
+	const all = PsbtInputsModifiable | PsbtOutputsModifiable
+	tests := []struct {
+		hashType txscript.SigHashType
+		flags    byte
+	}{
+		{txscript.SigHashAll, 0},
+		{txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+			PsbtInputsModifiable},
+		{txscript.SigHashNone, PsbtOutputsModifiable},
+		{txscript.SigHashNone | txscript.SigHashAnyOneCanPay, all},
+		{txscript.SigHashSingle, PsbtHasSigHashSingle},
+		{txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+			PsbtInputsModifiable | PsbtHasSigHashSingle},
+	}
+	for _, test := range tests {
+		packet := &psbt.Packet{}
+		updatePsbtModifiable(packet, test.hashType)
+		if _, ok := PsbtModifiable(packet); ok {
+			t.Fatalf("version 0 packet became modifiable")
+		}
+
+		setPsbtModifiable(packet, all)
+		updatePsbtModifiable(packet, test.hashType)
+		flags, _ := PsbtModifiable(packet)
+		if flags != test.flags {
+			t.Errorf("%v: expected flags %03b, got %03b",
+				test.hashType, test.flags, flags)
+		}
+	}
+
+	dst, src := &psbt.Packet{}, &psbt.Packet{}
+	setPsbtModifiable(dst, all)
+	setPsbtModifiable(src, PsbtInputsModifiable|PsbtHasSigHashSingle)
+	CombinePsbtModifiable(dst, src)
+	flags, _ := PsbtModifiable(dst)
+	if flags != PsbtInputsModifiable|PsbtHasSigHashSingle {
+		t.Fatalf("unexpected combined flags %03b", flags)
+	}
+}
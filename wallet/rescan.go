@@ -5,11 +5,17 @@
 package wallet
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/chain"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
@@ -32,12 +38,72 @@ type RescanFinishedMsg struct {
 // outpoints spendable by the addresses thought to be unspent.  After the
 // rescan completes, the error result of the rescan RPC is sent on the Err
 // channel.
+//
+// Jobs which are not part of the initial sync are persisted along with their
+// progress, so they can be resumed after a restart.
 type RescanJob struct {
 	InitialSync bool
 	Addrs       []btcutil.Address
 	OutPoints   map[wire.OutPoint]btcutil.Address
 	BlockStamp  waddrmgr.BlockStamp
 	err         chan error
+	errOnce     sync.Once
+
+	// StopHeight is the height of the last block to rescan for the job.
+	// The backend rescans the whole batch through the best block, but the
+	// job is finished and no longer tracked once the rescan has passed
+	// this height. Zero rescans through the best block.
+	StopHeight int32
+
+	// Progress, if non-nil, receives the blocks the job has been rescanned
+	// through. Sends never block, so progress is dropped if the channel is
+	// full.
+	Progress chan<- waddrmgr.BlockStamp
+
+	// stopped, if non-nil, is closed once the rescan has passed the stop
+	// height or finished.
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// id is the ID of the persisted rescan record. It is zero for initial
+	// sync jobs, which are not persisted.
+	id uint64
+}
+
+// ID returns the ID used to refer to a persisted rescan job, or zero for
+// initial sync jobs which are not persisted.
+func (job *RescanJob) ID() uint64 {
+	return job.id
+}
+
+// finish sends the result of the rescan on the job's error channel. Only the
+// first result is delivered, so a job that was paused or canceled while its
+// batch is still being rescanned does not block the batch completion.
+func (job *RescanJob) finish(err error) {
+	job.errOnce.Do(func() {
+		job.err <- err
+	})
+}
+
+// stop closes the job's stopped channel, if any.
+func (job *RescanJob) stop() {
+	job.stopOnce.Do(func() {
+		if job.stopped != nil {
+			close(job.stopped)
+		}
+	})
+}
+
+// notifyProgress sends the block the job has been rescanned through on the
+// job's progress channel without blocking.
+func (job *RescanJob) notifyProgress(bs waddrmgr.BlockStamp) {
+	if job.Progress == nil {
+		return
+	}
+	select {
+	case job.Progress <- bs:
+	default:
+	}
 }
 
 // rescanBatch is a collection of one or more RescanJobs that were merged
@@ -47,31 +113,67 @@ type rescanBatch struct {
 	addrs       []btcutil.Address
 	outpoints   map[wire.OutPoint]btcutil.Address
 	bs          waddrmgr.BlockStamp
-	errChans    []chan error
+	jobs        []*RescanJob
+
+	// detached records the IDs of jobs that were paused or canceled
+	// while the batch was being rescanned. The progress of these jobs is
+	// no longer persisted. It must only be accessed by the
+	// rescanBatchHandler goroutine.
+	detached map[uint64]struct{}
 }
 
 // SubmitRescan submits a RescanJob to the RescanManager.  A channel is
 // returned with the final error of the rescan.  The channel is buffered
 // and does not need to be read to prevent a deadlock.
+//
+// Jobs which are not part of the initial sync are written to the database
+// before being submitted, so they are resumed if the wallet is restarted
+// before the rescan finishes.
 func (w *Wallet) SubmitRescan(job *RescanJob) <-chan error {
 	errChan := make(chan error, 1)
 	job.err = errChan
+
+	if !job.InitialSync {
+		rec := &rescanRecord{
+			state:     RescanStateActive,
+			start:     job.BlockStamp,
+			stop:      job.StopHeight,
+			addrs:     job.Addrs,
+			outPoints: job.OutPoints,
+		}
+		err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+			return putRescanRecord(ns, rec)
+		})
+		if err != nil {
+			errChan <- err
+			return errChan
+		}
+		job.id = rec.id
+	}
+
 	select {
 	case w.rescanAddJob <- job:
 	case <-w.quitChan():
-		errChan <- ErrWalletShuttingDown
+		job.finish(ErrWalletShuttingDown)
 	}
 	return errChan
 }
 
 // batch creates the rescanBatch for a single rescan job.
 func (job *RescanJob) batch() *rescanBatch {
+	outpoints := make(map[wire.OutPoint]btcutil.Address, len(job.OutPoints))
+	for op, addr := range job.OutPoints {
+		outpoints[op] = addr
+	}
+
 	return &rescanBatch{
 		initialSync: job.InitialSync,
-		addrs:       job.Addrs,
-		outpoints:   job.OutPoints,
+		addrs:       append([]btcutil.Address(nil), job.Addrs...),
+		outpoints:   outpoints,
 		bs:          job.BlockStamp,
-		errChans:    []chan error{job.err},
+		jobs:        []*RescanJob{job},
+		detached:    make(map[uint64]struct{}),
 	}
 }
 
@@ -91,18 +193,401 @@ func (b *rescanBatch) merge(job *RescanJob) {
 	if job.BlockStamp.Height < b.bs.Height {
 		b.bs = job.BlockStamp
 	}
-	b.errChans = append(b.errChans, job.err)
+	b.jobs = append(b.jobs, job)
+}
+
+// without returns a new batch containing all jobs of the batch except the one
+// with the given ID, or nil if no jobs remain.
+func (b *rescanBatch) without(id uint64) *rescanBatch {
+	var remaining *rescanBatch
+	for _, job := range b.jobs {
+		switch {
+		case job.id == id:
+		case remaining == nil:
+			remaining = job.batch()
+		default:
+			remaining.merge(job)
+		}
+	}
+	return remaining
 }
 
-// done iterates through all error channels, duplicating sending the error
-// to inform callers that the rescan finished (or could not complete due
-// to an error).
+// job returns the job of the batch with the given persisted ID, or nil if the
+// batch does not contain it.
+func (b *rescanBatch) job(id uint64) *RescanJob {
+	if b == nil || id == 0 {
+		return nil
+	}
+	for _, job := range b.jobs {
+		if job.id == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// done iterates through all jobs, duplicating sending the error to inform
+// callers that the rescan finished (or could not complete due to an error).
 func (b *rescanBatch) done(err error) {
-	for _, c := range b.errChans {
-		c <- err
+	for _, job := range b.jobs {
+		job.finish(err)
+	}
+}
+
+// rescanControlOp is an operation requested on persisted rescan jobs.
+type rescanControlOp uint8
+
+const (
+	rescanOpList rescanControlOp = iota
+	rescanOpPause
+	rescanOpResume
+	rescanOpCancel
+	rescanOpResumeAll
+)
+
+// rescanControlRequest is a request to inspect or modify the persisted rescan
+// jobs, handled by the rescanBatchHandler goroutine so that queued and running
+// batches are kept consistent with the database.
+type rescanControlRequest struct {
+	op   rescanControlOp
+	id   uint64
+	resp chan rescanControlResponse
+}
+
+type rescanControlResponse struct {
+	statuses []*RescanStatus
+	err      error
+}
+
+// RescanStatus describes a persisted rescan job.
+type RescanStatus struct {
+	// ID identifies the rescan job.
+	ID uint64
+
+	// State is the persisted state of the job.
+	State RescanState
+
+	// Running is true if the job is part of the batch currently being
+	// rescanned.
+	Running bool
+
+	// Queued is true if the job waits for the current batch to finish.
+	Queued bool
+
+	// StartBlock is the block the rescan job was started from.
+	StartBlock waddrmgr.BlockStamp
+
+	// ProgressBlock is the last block the rescan job reported progress
+	// for. Its height is zero if no progress has been reported yet.
+	ProgressBlock waddrmgr.BlockStamp
+
+	// Addrs are the addresses the job rescans for.
+	Addrs []btcutil.Address
+
+	// OutPoints are the outpoints the job watches for spends.
+	OutPoints map[wire.OutPoint]btcutil.Address
+}
+
+// Rescans returns the status of every rescan job which has not yet finished,
+// including paused jobs.
+func (w *Wallet) Rescans() ([]*RescanStatus, error) {
+	resp, err := w.controlRescan(rescanOpList, 0)
+	return resp.statuses, err
+}
+
+// PauseRescan pauses the rescan job with the given ID. A paused job keeps its
+// progress and is not resumed on startup until ResumeRescan is called.
+//
+// Rescans can not be interrupted at the chain backend, so a job paused while
+// being rescanned is only detached from its batch: ErrRescanPaused is sent on
+// its error channel and its progress is no longer recorded, but the backend
+// completes the underlying rescan.
+func (w *Wallet) PauseRescan(id uint64) error {
+	_, err := w.controlRescan(rescanOpPause, id)
+	return err
+}
+
+// ResumeRescan resumes a paused rescan job from the last block it reported
+// progress for.
+func (w *Wallet) ResumeRescan(id uint64) error {
+	_, err := w.controlRescan(rescanOpResume, id)
+	return err
+}
+
+// CancelRescan cancels the rescan job with the given ID and removes it from the
+// database. Just like PauseRescan, a job canceled while being rescanned is
+// only detached from its batch and ErrRescanCanceled is sent on its error
+// channel.
+func (w *Wallet) CancelRescan(id uint64) error {
+	_, err := w.controlRescan(rescanOpCancel, id)
+	return err
+}
+
+// resumeRescans submits every active rescan job found in the database which
+// isn't already queued or running.
+func (w *Wallet) resumeRescans() error {
+	_, err := w.controlRescan(rescanOpResumeAll, 0)
+	return err
+}
+
+// controlRescan performs the operation on the persisted rescan jobs. If the
+// wallet is synchronizing with a chain backend, the request is handled by the
+// rescanBatchHandler. Otherwise no job can be queued or running, so the
+// database is operated on directly.
+func (w *Wallet) controlRescan(op rescanControlOp,
+	id uint64) (rescanControlResponse, error) {
+
+	if _, err := w.requireChainClient(); err != nil {
+		if op == rescanOpResume || op == rescanOpResumeAll {
+			return rescanControlResponse{}, err
+		}
+		statuses, _, err := w.updateRescanRecords(op, id, nil, nil)
+		return rescanControlResponse{statuses: statuses}, err
+	}
+
+	req := &rescanControlRequest{
+		op:   op,
+		id:   id,
+		resp: make(chan rescanControlResponse, 1),
+	}
+	select {
+	case w.rescanControl <- req:
+	case <-w.quitChan():
+		return rescanControlResponse{}, ErrWalletShuttingDown
+	}
+
+	select {
+	case resp := <-req.resp:
+		return resp, resp.err
+	case <-w.quitChan():
+		return rescanControlResponse{}, ErrWalletShuttingDown
 	}
 }
 
+// updateRescanRecords performs the database part of a rescan control
+// operation. The current and next batches, which may be nil, are used to
+// report which jobs are running or queued, and to avoid resuming jobs which
+// are already tracked. Records which must be resumed are returned.
+func (w *Wallet) updateRescanRecords(op rescanControlOp, id uint64,
+	curBatch, nextBatch *rescanBatch) ([]*RescanStatus, []*rescanRecord,
+	error) {
+
+	// tracked returns whether the job is part of a batch and hasn't been
+	// detached from it.
+	tracked := func(id uint64) bool {
+		return (curBatch.job(id) != nil && !curBatch.isDetached(id)) ||
+			nextBatch.job(id) != nil
+	}
+
+	var (
+		statuses []*RescanStatus
+		resume   []*rescanRecord
+	)
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+
+		switch op {
+		case rescanOpList:
+			recs, err := fetchRescanRecords(ns, w.chainParams)
+			if err != nil {
+				return err
+			}
+			for _, rec := range recs {
+				running := curBatch.job(rec.id) != nil &&
+					!curBatch.isDetached(rec.id)
+				statuses = append(statuses, &RescanStatus{
+					ID:            rec.id,
+					State:         rec.state,
+					Running:       running,
+					Queued:        nextBatch.job(rec.id) != nil,
+					StartBlock:    rec.start,
+					ProgressBlock: rec.progress,
+					Addrs:         rec.addrs,
+					OutPoints:     rec.outPoints,
+				})
+			}
+			return nil
+
+		case rescanOpResumeAll:
+			recs, err := fetchRescanRecords(ns, w.chainParams)
+			if err != nil {
+				return err
+			}
+			for _, rec := range recs {
+				if rec.state == RescanStateActive &&
+					!tracked(rec.id) {
+
+					resume = append(resume, rec)
+				}
+			}
+			return nil
+		}
+
+		rec, err := fetchRescanRecord(ns, id, w.chainParams)
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case rescanOpPause:
+			rec.state = RescanStatePaused
+			return putRescanRecord(ns, rec)
+
+		case rescanOpCancel:
+			return deleteRescanRecord(ns, id)
+
+		case rescanOpResume:
+			if tracked(id) {
+				return nil
+			}
+			rec.state = RescanStateActive
+			resume = append(resume, rec)
+			return putRescanRecord(ns, rec)
+		}
+
+		return nil
+	})
+
+	return statuses, resume, err
+}
+
+// updateRescanProgress records the progress of every job of the batch which is
+// persisted and still attached to it.
+func (w *Wallet) updateRescanProgress(b *rescanBatch,
+	n *chain.RescanProgress) error {
+
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+
+		for _, job := range b.jobs {
+			if job.id == 0 || b.isDetached(job.id) ||
+				n.Height <= job.BlockStamp.Height {
+
+				continue
+			}
+
+			rec, err := fetchRescanRecord(ns, job.id, w.chainParams)
+			if err == ErrRescanNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if n.Height <= rec.progress.Height {
+				continue
+			}
+			rec.progress = waddrmgr.BlockStamp{
+				Height:    n.Height,
+				Hash:      *n.Hash,
+				Timestamp: n.Time,
+			}
+			if err := putRescanRecord(ns, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// stopRescans reports the rescanned block to the attached jobs of the batch
+// and finishes the jobs whose stop height has been reached. These jobs are
+// detached from the batch and their records deleted, as the remainder of the
+// batch rescan is of no interest to them.
+func (w *Wallet) stopRescans(b *rescanBatch, height int32,
+	hash *chainhash.Hash, t time.Time) error {
+
+	bs := waddrmgr.BlockStamp{
+		Height:    height,
+		Hash:      *hash,
+		Timestamp: t,
+	}
+
+	var stopped []*RescanJob
+	for _, job := range b.jobs {
+		if b.isDetached(job.id) || height < job.BlockStamp.Height {
+			continue
+		}
+		job.notifyProgress(bs)
+		if job.StopHeight != 0 && height >= job.StopHeight {
+			stopped = append(stopped, job)
+		}
+	}
+	if len(stopped) == 0 {
+		return nil
+	}
+
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+
+		for _, job := range stopped {
+			if job.id == 0 {
+				continue
+			}
+			if err := deleteRescanRecord(ns, job.id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// The jobs are finished even if their records could not be removed,
+	// in which case they are rescanned again after a restart.
+	for _, job := range stopped {
+		if job.id != 0 {
+			b.detached[job.id] = struct{}{}
+		}
+		job.stop()
+		job.finish(nil)
+	}
+	return err
+}
+
+// removeFinishedRescans deletes the records of every job of the batch which is
+// still attached to it, since their rescans have completed, and reports the
+// final block to them.
+func (w *Wallet) removeFinishedRescans(b *rescanBatch,
+	n *chain.RescanFinished) error {
+
+	bs := waddrmgr.BlockStamp{
+		Height:    n.Height,
+		Hash:      *n.Hash,
+		Timestamp: n.Time,
+	}
+
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+
+		for _, job := range b.jobs {
+			if job.id == 0 || b.isDetached(job.id) {
+				continue
+			}
+			if err := deleteRescanRecord(ns, job.id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for _, job := range b.jobs {
+		if b.isDetached(job.id) {
+			continue
+		}
+		job.notifyProgress(bs)
+		job.stop()
+	}
+	return err
+}
+
+// isDetached returns whether the job with the given ID was detached from the
+// batch.
+func (b *rescanBatch) isDetached(id uint64) bool {
+	if b == nil {
+		return false
+	}
+	_, ok := b.detached[id]
+	return ok
+}
+
 // rescanBatchHandler handles incoming rescan request, serializing rescan
 // submissions, and possibly batching many waiting requests together so they
 // can be handled by a single rescan after the current one completes.
@@ -112,26 +597,87 @@ func (w *Wallet) rescanBatchHandler() {
 	var curBatch, nextBatch *rescanBatch
 	quit := w.quitChan()
 
+	// addJob starts a new batch for the job if no rescan is currently
+	// running, or otherwise adds it to the next batch. It returns false if
+	// the wallet is shutting down.
+	addJob := func(job *RescanJob) bool {
+		if curBatch != nil {
+			// Create next batch if it doesn't exist, or merge the
+			// job.
+			if nextBatch == nil {
+				nextBatch = job.batch()
+			} else {
+				nextBatch.merge(job)
+			}
+			return true
+		}
+
+		// Set current batch as this job and send request.
+		curBatch = job.batch()
+		select {
+		case w.rescanBatch <- curBatch:
+			return true
+		case <-quit:
+			job.finish(ErrWalletShuttingDown)
+			return false
+		}
+	}
+
 	for {
 		select {
 		case job := <-w.rescanAddJob:
-			if curBatch == nil {
-				// Set current batch as this job and send
-				// request.
-				curBatch = job.batch()
-				select {
-				case w.rescanBatch <- curBatch:
-				case <-quit:
-					job.err <- ErrWalletShuttingDown
-					return
+			if !addJob(job) {
+				return
+			}
+
+		case req := <-w.rescanControl:
+			statuses, resume, err := w.updateRescanRecords(
+				req.op, req.id, curBatch, nextBatch,
+			)
+			req.resp <- rescanControlResponse{
+				statuses: statuses,
+				err:      err,
+			}
+			if err != nil {
+				continue
+			}
+
+			// Jobs which are paused or canceled are removed from
+			// the next batch, or detached from the current one.
+			var jobErr error
+			switch req.op {
+			case rescanOpPause:
+				jobErr = ErrRescanPaused
+			case rescanOpCancel:
+				jobErr = ErrRescanCanceled
+			}
+			if jobErr != nil {
+				if job := nextBatch.job(req.id); job != nil {
+					nextBatch = nextBatch.without(req.id)
+					job.finish(jobErr)
 				}
-			} else {
-				// Create next batch if it doesn't exist, or
-				// merge the job.
-				if nextBatch == nil {
-					nextBatch = job.batch()
-				} else {
-					nextBatch.merge(job)
+				if job := curBatch.job(req.id); job != nil {
+					curBatch.detached[req.id] = struct{}{}
+					job.finish(jobErr)
+				}
+			}
+
+			for _, rec := range resume {
+				log.Infof("Resuming rescan %d from block %v "+
+					"(height %d)", rec.id,
+					rec.resumeStamp().Hash,
+					rec.resumeStamp().Height)
+
+				job := &RescanJob{
+					Addrs:      rec.addrs,
+					OutPoints:  rec.outPoints,
+					BlockStamp: rec.resumeStamp(),
+					StopHeight: rec.stop,
+					err:        make(chan error, 1),
+					id:         rec.id,
+				}
+				if !addJob(job) {
+					return
 				}
 			}
 
@@ -144,15 +690,24 @@ func (w *Wallet) rescanBatchHandler() {
 						"currently running")
 					continue
 				}
+				err := w.updateRescanProgress(curBatch, n)
+				if err != nil {
+					log.Errorf("Unable to persist rescan "+
+						"progress: %v", err)
+				}
+				err = w.stopRescans(curBatch, n.Height, n.Hash,
+					n.Time)
+				if err != nil {
+					log.Errorf("Unable to stop rescans: %v",
+						err)
+				}
 				select {
 				case w.rescanProgress <- &RescanProgressMsg{
 					Addresses:    curBatch.addrs,
 					Notification: n,
 				}:
 				case <-quit:
-					for _, errChan := range curBatch.errChans {
-						errChan <- ErrWalletShuttingDown
-					}
+					curBatch.done(ErrWalletShuttingDown)
 					return
 				}
 
@@ -163,15 +718,18 @@ func (w *Wallet) rescanBatchHandler() {
 						"currently running")
 					continue
 				}
+				err := w.removeFinishedRescans(curBatch, n)
+				if err != nil {
+					log.Errorf("Unable to remove finished "+
+						"rescans: %v", err)
+				}
 				select {
 				case w.rescanFinished <- &RescanFinishedMsg{
 					Addresses:    curBatch.addrs,
 					Notification: n,
 				}:
 				case <-quit:
-					for _, errChan := range curBatch.errChans {
-						errChan <- ErrWalletShuttingDown
-					}
+					curBatch.done(ErrWalletShuttingDown)
 					return
 				}
 
@@ -181,9 +739,7 @@ func (w *Wallet) rescanBatchHandler() {
 					select {
 					case w.rescanBatch <- curBatch:
 					case <-quit:
-						for _, errChan := range curBatch.errChans {
-							errChan <- ErrWalletShuttingDown
-						}
+						curBatch.done(ErrWalletShuttingDown)
 						return
 					}
 				}
@@ -213,6 +769,11 @@ out:
 			n := msg.Notification
 			log.Infof("Rescanned through block %v (height %d)",
 				n.Hash, n.Height)
+			w.NtfnServer.notifyRescan(&RescanNotification{
+				Hash:   n.Hash,
+				Height: n.Height,
+				Time:   n.Time,
+			})
 
 		case msg := <-w.rescanFinished:
 			n := msg.Notification
@@ -221,6 +782,12 @@ out:
 			log.Infof("Finished rescan for %d %s (synced to block "+
 				"%s, height %d)", len(addrs), noun, n.Hash,
 				n.Height)
+			w.NtfnServer.notifyRescan(&RescanNotification{
+				Hash:     n.Hash,
+				Height:   n.Height,
+				Time:     n.Time,
+				Finished: true,
+			})
 
 			go w.resendUnminedTxs()
 
@@ -282,16 +849,9 @@ func (w *Wallet) Rescan(addrs []btcutil.Address, unspent []wtxmgr.Credit) error
 func (w *Wallet) rescanWithTarget(addrs []btcutil.Address,
 	unspent []wtxmgr.Credit, startStamp *waddrmgr.BlockStamp) error {
 
-	outpoints := make(map[wire.OutPoint]btcutil.Address, len(unspent))
-	for _, output := range unspent {
-		_, outputAddrs, _, err := txscript.ExtractPkScriptAddrs(
-			output.PkScript, w.chainParams,
-		)
-		if err != nil {
-			return err
-		}
-
-		outpoints[output.OutPoint] = outputAddrs[0]
+	outpoints, err := w.rescanOutPoints(unspent)
+	if err != nil {
+		return err
 	}
 
 	// If a start block stamp was provided, we will use that as the initial
@@ -307,12 +867,199 @@ func (w *Wallet) rescanWithTarget(addrs []btcutil.Address,
 		OutPoints:   outpoints,
 		BlockStamp:  *startStamp,
 	}
+	errChan := w.SubmitRescan(job)
 
-	// Submit merged job and block until rescan completes.
+	// Now that the sync rescan has been queued, resume any rescans that
+	// were interrupted by a previous shutdown. They'll be batched together
+	// and performed once the rescan above completes.
+	if err := w.resumeRescans(); err != nil {
+		log.Errorf("Unable to resume rescans: %v", err)
+	}
+
+	// Block until the rescan completes.
 	select {
-	case err := <-w.SubmitRescan(job):
+	case err := <-errChan:
 		return err
 	case <-w.quitChan():
 		return ErrWalletShuttingDown
 	}
 }
+
+// rescanOutPoints maps the outpoints of the unspent outputs to the address
+// they pay to, as required by a RescanJob.
+func (w *Wallet) rescanOutPoints(
+	unspent []wtxmgr.Credit) (map[wire.OutPoint]btcutil.Address, error) {
+
+	outpoints := make(map[wire.OutPoint]btcutil.Address, len(unspent))
+	for _, output := range unspent {
+		_, outputAddrs, _, err := txscript.ExtractPkScriptAddrs(
+			output.PkScript, w.chainParams,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		outpoints[output.OutPoint] = outputAddrs[0]
+	}
+	return outpoints, nil
+}
+
+// RescanSummary describes the result of RescanBlockchain.
+type RescanSummary struct {
+	// StartBlock is the first block that was rescanned.
+	StartBlock waddrmgr.BlockStamp
+
+	// StopBlock is the last block that was rescanned.
+	StopBlock waddrmgr.BlockStamp
+
+	// Transactions are the hashes of every wallet transaction mined in the
+	// rescanned blocks, in block order.
+	Transactions []chainhash.Hash
+
+	// NewTransactions are the hashes of the transactions found by the
+	// rescan which were not known to the wallet before.
+	NewTransactions []chainhash.Hash
+}
+
+// RescanBlockchain rescans the blocks between the start and stop heights,
+// inclusive, for transactions relevant to every active address and unspent
+// output of the wallet. A stop height of zero rescans through the best block
+// of the chain backend.
+//
+// If progress is non-nil, the blocks the rescan has passed through are sent on
+// it as the rescan proceeds. Sends never block, so the channel should be
+// buffered. The rescan is performed as a persisted rescan job, so it is
+// resumed if the wallet is restarted and can be paused or canceled while it
+// runs, in which case ErrRescanPaused or ErrRescanCanceled is returned.
+func (w *Wallet) RescanBlockchain(startHeight, stopHeight int32,
+	progress chan<- waddrmgr.BlockStamp) (*RescanSummary, error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	if stopHeight == 0 {
+		stopHeight = bestHeight
+	}
+	switch {
+	case startHeight < 0:
+		return nil, fmt.Errorf("invalid start height %d", startHeight)
+	case stopHeight > bestHeight:
+		return nil, fmt.Errorf("stop height %d is beyond the best "+
+			"block height %d", stopHeight, bestHeight)
+	case stopHeight < startHeight:
+		return nil, fmt.Errorf("stop height %d is below start height "+
+			"%d", stopHeight, startHeight)
+	}
+
+	summary := &RescanSummary{
+		StartBlock: waddrmgr.BlockStamp{Height: startHeight},
+		StopBlock:  waddrmgr.BlockStamp{Height: stopHeight},
+	}
+	for _, bs := range []*waddrmgr.BlockStamp{
+		&summary.StartBlock, &summary.StopBlock,
+	} {
+		hash, err := chainClient.GetBlockHash(int64(bs.Height))
+		if err != nil {
+			return nil, err
+		}
+		bs.Hash = *hash
+	}
+
+	// Record the transactions of the range known before the rescan, so
+	// the new ones can be reported.
+	_, known, err := w.minedTxHashes(startHeight, stopHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		addrs   []btcutil.Address
+		unspent []wtxmgr.Credit
+	)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		var err error
+		addrs, unspent, err = w.activeData(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	outpoints, err := w.rescanOutPoints(unspent)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &RescanJob{
+		Addrs:      addrs,
+		OutPoints:  outpoints,
+		BlockStamp: summary.StartBlock,
+		StopHeight: stopHeight,
+		Progress:   progress,
+		stopped:    make(chan struct{}),
+	}
+	errChan := w.SubmitRescan(job)
+
+	// Depending on the backend, the job may be reported as done as soon as
+	// the rescan was started, so wait until the rescan has passed the stop
+	// height as well.
+	for stopped := false; !stopped; {
+		select {
+		case err := <-errChan:
+			if err != nil {
+				return nil, err
+			}
+			errChan = nil
+		case <-job.stopped:
+			stopped = true
+		case <-w.quitChan():
+			return nil, ErrWalletShuttingDown
+		}
+	}
+
+	found, _, err := w.minedTxHashes(startHeight, stopHeight)
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range found {
+		summary.Transactions = append(summary.Transactions, hash)
+		if _, ok := known[hash]; !ok {
+			summary.NewTransactions = append(
+				summary.NewTransactions, hash,
+			)
+		}
+	}
+	return summary, nil
+}
+
+// minedTxHashes returns the hashes of the wallet transactions mined between
+// the start and stop heights, inclusive, in block order. They are returned as a
+// slice and as a set.
+func (w *Wallet) minedTxHashes(startHeight, stopHeight int32) (
+	[]chainhash.Hash, map[chainhash.Hash]struct{}, error) {
+
+	var hashes []chainhash.Hash
+	set := make(map[chainhash.Hash]struct{})
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		return w.TxStore.RangeTransactions(ns, startHeight, stopHeight,
+			func(details []wtxmgr.TxDetails) (bool, error) {
+				for i := range details {
+					hash := details[i].Hash
+					if _, ok := set[hash]; ok {
+						continue
+					}
+					set[hash] = struct{}{}
+					hashes = append(hashes, hash)
+				}
+				return false, nil
+			})
+	})
+	return hashes, set, err
+}
@@ -0,0 +1,179 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// rescanChainClient is a mock chain client with a chain of 100 blocks whose
+// hashes are derived from their height.
+type rescanChainClient struct {
+	mockChainClient
+}
+
+func (c *rescanChainClient) GetBestBlock() (*chainhash.Hash, int32, error) {
+	return &chainhash.Hash{100}, 100, nil
+}
+
+func (c *rescanChainClient) GetBlockHash(height int64) (*chainhash.Hash,
+	error) {
+
+	return &chainhash.Hash{byte(height)}, nil
+}
+
+// insertMinedTx stores the transaction in the wallet as mined in the block at
+// the given height.
+func insertMinedTx(t *testing.T, w *Wallet, tx *wire.MsgTx, height int32) {
+	t.Helper()
+
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   chainhash.Hash{byte(height)},
+			Height: height,
+		},
+		Time: time.Now(),
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return w.TxStore.InsertTx(ns, rec, block)
+	})
+	if err != nil {
+		t.Fatalf("unable to insert tx: %v", err)
+	}
+}
+
+// TestRescanBlockchain ensures a rescan of a height range reports its progress,
+// finishes once the stop height is passed, and summarizes the transactions
+// found in the range.
+func TestRescanBlockchain(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+	defer w.Stop()
+
+	w.chainClient = &rescanChainClient{}
+	w.wg.Add(1)
+	go w.rescanBatchHandler()
+
+	// A transaction of the range is already known before the rescan.
+	knownTx := TstTx.MsgTx()
+	insertMinedTx(t, w, knownTx, 40)
+
+	type rescanResult struct {
+		summary *RescanSummary
+		err     error
+	}
+	progress := make(chan waddrmgr.BlockStamp, 10)
+	done := make(chan rescanResult, 1)
+	go func() {
+		summary, err := w.RescanBlockchain(30, 60, progress)
+		done <- rescanResult{summary, err}
+	}()
+
+	select {
+	case b := <-w.rescanBatch:
+		if b.bs.Height != 30 || b.bs.Hash != (chainhash.Hash{30}) {
+			t.Fatalf("unexpected rescan start block %v", b.bs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("rescan batch not started")
+	}
+
+	// The rescan finds a new transaction of the range.
+	newTx := wire.NewMsgTx(2)
+	newTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: *TstTxHash},
+	})
+	newTx.AddTxOut(wire.NewTxOut(1000, knownTx.TxOut[0].PkScript))
+	insertMinedTx(t, w, newTx, 50)
+
+	for i, height := range []int32{50, 70} {
+		w.rescanNotifications <- &chain.RescanProgress{
+			Hash:   &chainhash.Hash{byte(height)},
+			Height: height,
+		}
+		select {
+		case <-w.rescanProgress:
+		case <-time.After(time.Second):
+			t.Fatal("rescan progress not forwarded")
+		}
+
+		select {
+		case bs := <-progress:
+			if bs.Height != height {
+				t.Fatalf("expected progress at height %d, "+
+					"got %d", height, bs.Height)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("rescan progress not reported")
+		}
+
+		if i == 0 {
+			select {
+			case r := <-done:
+				t.Fatalf("rescan finished before stop height: "+
+					"%v", r.err)
+			default:
+			}
+		}
+	}
+
+	var r rescanResult
+	select {
+	case r = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("rescan not finished after passing stop height")
+	}
+	if r.err != nil {
+		t.Fatalf("unable to rescan: %v", r.err)
+	}
+
+	summary := r.summary
+	if summary.StartBlock.Height != 30 || summary.StopBlock.Height != 60 ||
+		summary.StopBlock.Hash != (chainhash.Hash{60}) {
+
+		t.Fatalf("unexpected rescanned range: %v - %v",
+			summary.StartBlock, summary.StopBlock)
+	}
+	if len(summary.Transactions) != 2 ||
+		summary.Transactions[0] != knownTx.TxHash() ||
+		summary.Transactions[1] != newTx.TxHash() {
+
+		t.Fatalf("unexpected transactions: %v", summary.Transactions)
+	}
+	if len(summary.NewTransactions) != 1 ||
+		summary.NewTransactions[0] != newTx.TxHash() {
+
+		t.Fatalf("unexpected new transactions: %v",
+			summary.NewTransactions)
+	}
+
+	// The job is no longer tracked once it passed its stop height.
+	rescans, err := w.Rescans()
+	if err != nil {
+		t.Fatalf("unable to list rescans: %v", err)
+	}
+	if len(rescans) != 0 {
+		t.Fatalf("expected no rescans, got %d", len(rescans))
+	}
+
+	// Invalid ranges are rejected.
+	if _, err := w.RescanBlockchain(60, 30, nil); err == nil {
+		t.Fatal("expected error for stop height below start height")
+	}
+	if _, err := w.RescanBlockchain(0, 101, nil); err == nil {
+		t.Fatal("expected error for stop height beyond best block")
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The rescan namespace stores every rescan job which has been submitted but
+// not yet completed, keyed by the big endian job ID. Each value is serialized
+// as follows:
+//
+//   [0]       state
+//   [1:5]     start block height (4 bytes)
+//   [5:37]    start block hash (32 bytes)
+//   [37:41]   progress block height (4 bytes)
+//   [41:73]   progress block hash (32 bytes)
+//   [73:77]   stop height (4 bytes)
+//   [77:81]   number of addresses (4 bytes)
+//   ...       addresses, each as a 2 byte length followed by the encoded
+//             address string
+//   ...       number of outpoints (4 bytes)
+//   ...       outpoints, each as a 32 byte hash, 4 byte index, 2 byte length
+//             and the encoded address string paid to by the output
+//
+// All integers are encoded big endian. A progress height of zero means the
+// rescan job has not reported any progress yet, and a stop height of zero
+// means the job rescans through the best block.
+
+// RescanState describes the state of a persisted rescan job.
+type RescanState uint8
+
+const (
+	// RescanStateActive is the state of a rescan job that is either queued,
+	// currently being performed, or will be resumed on the next startup.
+	RescanStateActive RescanState = iota
+
+	// RescanStatePaused is the state of a rescan job that was paused by
+	// the user. Paused jobs are not resumed on startup.
+	RescanStatePaused
+)
+
+// String returns the string representation of a RescanState.
+func (s RescanState) String() string {
+	switch s {
+	case RescanStateActive:
+		return "active"
+	case RescanStatePaused:
+		return "paused"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+var (
+	// ErrRescanNotFound is returned when a rescan job ID is not known to
+	// the wallet.
+	ErrRescanNotFound = errors.New("rescan job not found")
+
+	// ErrRescanPaused is sent on the error channel of a rescan job when it
+	// is paused before finishing.
+	ErrRescanPaused = errors.New("rescan paused")
+
+	// ErrRescanCanceled is sent on the error channel of a rescan job when
+	// it is canceled before finishing.
+	ErrRescanCanceled = errors.New("rescan canceled")
+
+	// wrescanNamespaceKey is the key of the top level bucket storing the
+	// persisted rescan jobs.
+	wrescanNamespaceKey = []byte("wrescan")
+)
+
+// rescanRecord is the persisted form of a rescan job.
+type rescanRecord struct {
+	id        uint64
+	state     RescanState
+	start     waddrmgr.BlockStamp
+	progress  waddrmgr.BlockStamp
+	stop      int32
+	addrs     []btcutil.Address
+	outPoints map[wire.OutPoint]btcutil.Address
+}
+
+// resumeStamp returns the block the rescan job should be resumed from.
+func (r *rescanRecord) resumeStamp() waddrmgr.BlockStamp {
+	if r.progress.Height > r.start.Height {
+		return r.progress
+	}
+	return r.start
+}
+
+func rescanRecordKey(id uint64) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], id)
+	return k[:]
+}
+
+func writeRescanAddr(w *bytes.Buffer, addr btcutil.Address) {
+	encoded := addr.EncodeAddress()
+
+	var l [2]byte
+	binary.BigEndian.PutUint16(l[:], uint16(len(encoded)))
+	w.Write(l[:])
+	w.WriteString(encoded)
+}
+
+func readRescanAddr(r *bytes.Reader,
+	chainParams *chaincfg.Params) (btcutil.Address, error) {
+
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, err
+	}
+	encoded := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(r, encoded); err != nil {
+		return nil, err
+	}
+	return btcutil.DecodeAddress(string(encoded), chainParams)
+}
+
+func serializeRescanRecord(rec *rescanRecord) []byte {
+	var b bytes.Buffer
+	var u32 [4]byte
+
+	writeStamp := func(bs *waddrmgr.BlockStamp) {
+		binary.BigEndian.PutUint32(u32[:], uint32(bs.Height))
+		b.Write(u32[:])
+		b.Write(bs.Hash[:])
+	}
+
+	b.WriteByte(byte(rec.state))
+	writeStamp(&rec.start)
+	writeStamp(&rec.progress)
+	binary.BigEndian.PutUint32(u32[:], uint32(rec.stop))
+	b.Write(u32[:])
+
+	binary.BigEndian.PutUint32(u32[:], uint32(len(rec.addrs)))
+	b.Write(u32[:])
+	for _, addr := range rec.addrs {
+		writeRescanAddr(&b, addr)
+	}
+
+	binary.BigEndian.PutUint32(u32[:], uint32(len(rec.outPoints)))
+	b.Write(u32[:])
+	for op, addr := range rec.outPoints {
+		b.Write(op.Hash[:])
+		binary.BigEndian.PutUint32(u32[:], op.Index)
+		b.Write(u32[:])
+		writeRescanAddr(&b, addr)
+	}
+
+	return b.Bytes()
+}
+
+func deserializeRescanRecord(id uint64, v []byte,
+	chainParams *chaincfg.Params) (*rescanRecord, error) {
+
+	const headerSize = 1 + 2*(4+chainhash.HashSize) + 4 + 4
+	if len(v) < headerSize {
+		return nil, fmt.Errorf("short rescan record %d: %d bytes", id,
+			len(v))
+	}
+
+	rec := &rescanRecord{
+		id:    id,
+		state: RescanState(v[0]),
+	}
+
+	r := bytes.NewReader(v[1:])
+	var u32 [4]byte
+	readUint32 := func() (uint32, error) {
+		if _, err := io.ReadFull(r, u32[:]); err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint32(u32[:]), nil
+	}
+	readStamp := func(bs *waddrmgr.BlockStamp) error {
+		height, err := readUint32()
+		if err != nil {
+			return err
+		}
+		bs.Height = int32(height)
+		_, err = io.ReadFull(r, bs.Hash[:])
+		return err
+	}
+
+	if err := readStamp(&rec.start); err != nil {
+		return nil, err
+	}
+	if err := readStamp(&rec.progress); err != nil {
+		return nil, err
+	}
+	stop, err := readUint32()
+	if err != nil {
+		return nil, err
+	}
+	rec.stop = int32(stop)
+
+	numAddrs, err := readUint32()
+	if err != nil {
+		return nil, err
+	}
+	rec.addrs = make([]btcutil.Address, 0, numAddrs)
+	for i := uint32(0); i < numAddrs; i++ {
+		addr, err := readRescanAddr(r, chainParams)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read address of "+
+				"rescan record %d: %v", id, err)
+		}
+		rec.addrs = append(rec.addrs, addr)
+	}
+
+	numOutPoints, err := readUint32()
+	if err != nil {
+		return nil, err
+	}
+	rec.outPoints = make(map[wire.OutPoint]btcutil.Address, numOutPoints)
+	for i := uint32(0); i < numOutPoints; i++ {
+		var op wire.OutPoint
+		if _, err := io.ReadFull(r, op.Hash[:]); err != nil {
+			return nil, err
+		}
+		if op.Index, err = readUint32(); err != nil {
+			return nil, err
+		}
+		addr, err := readRescanAddr(r, chainParams)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read outpoint of "+
+				"rescan record %d: %v", id, err)
+		}
+		rec.outPoints[op] = addr
+	}
+
+	return rec, nil
+}
+
+// putRescanRecord stores the rescan record. If the record does not have an ID
+// yet, a new one is assigned from the bucket sequence.
+func putRescanRecord(ns walletdb.ReadWriteBucket, rec *rescanRecord) error {
+	if rec.id == 0 {
+		id, err := ns.NextSequence()
+		if err != nil {
+			return err
+		}
+		rec.id = id
+	}
+	return ns.Put(rescanRecordKey(rec.id), serializeRescanRecord(rec))
+}
+
+// fetchRescanRecord returns the rescan record with the given ID, or
+// ErrRescanNotFound if it doesn't exist.
+func fetchRescanRecord(ns walletdb.ReadBucket, id uint64,
+	chainParams *chaincfg.Params) (*rescanRecord, error) {
+
+	v := ns.Get(rescanRecordKey(id))
+	if v == nil {
+		return nil, ErrRescanNotFound
+	}
+	return deserializeRescanRecord(id, v, chainParams)
+}
+
+// fetchRescanRecords returns all persisted rescan records ordered by ID.
+func fetchRescanRecords(ns walletdb.ReadBucket,
+	chainParams *chaincfg.Params) ([]*rescanRecord, error) {
+
+	var recs []*rescanRecord
+	err := ns.ForEach(func(k, v []byte) error {
+		if len(k) != 8 {
+			return nil
+		}
+		rec, err := deserializeRescanRecord(
+			binary.BigEndian.Uint64(k), v, chainParams,
+		)
+		if err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+		return nil
+	})
+	return recs, err
+}
+
+// deleteRescanRecord removes the rescan record with the given ID.
+func deleteRescanRecord(ns walletdb.ReadWriteBucket, id uint64) error {
+	return ns.Delete(rescanRecordKey(id))
+}
@@ -0,0 +1,248 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// testRescanJob returns a rescan job for a newly derived wallet address
+// starting at the given height.
+func testRescanJob(t *testing.T, w *Wallet, height int32) *RescanJob {
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to derive address: %v", err)
+	}
+
+	return &RescanJob{
+		Addrs: []btcutil.Address{addr},
+		OutPoints: map[wire.OutPoint]btcutil.Address{
+			{Hash: chainhash.Hash{byte(height)}, Index: 1}: addr,
+		},
+		BlockStamp: waddrmgr.BlockStamp{
+			Height: height,
+			Hash:   chainhash.Hash{byte(height)},
+		},
+	}
+}
+
+// TestRescanRecordSerialization ensures rescan records survive a round trip
+// through the database.
+func TestRescanRecordSerialization(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	job := testRescanJob(t, w, 100)
+	rec := &rescanRecord{
+		state:     RescanStatePaused,
+		start:     job.BlockStamp,
+		progress:  waddrmgr.BlockStamp{Height: 150},
+		addrs:     job.Addrs,
+		outPoints: job.OutPoints,
+	}
+
+	var fetched *rescanRecord
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+		if err := putRescanRecord(ns, rec); err != nil {
+			return err
+		}
+
+		var err error
+		fetched, err = fetchRescanRecord(ns, rec.id, w.chainParams)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to store rescan record: %v", err)
+	}
+
+	if rec.id == 0 {
+		t.Fatal("expected rescan record ID to be assigned")
+	}
+	if !reflect.DeepEqual(rec, fetched) {
+		t.Fatalf("expected record %+v, got %+v", rec, fetched)
+	}
+	if fetched.resumeStamp() != rec.progress {
+		t.Fatalf("expected resume from progress block %v, got %v",
+			rec.progress, fetched.resumeStamp())
+	}
+}
+
+// TestRescanControlOffline ensures persisted rescan jobs can be listed,
+// paused and canceled while the wallet isn't synchronizing with a backend.
+func TestRescanControlOffline(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	// Write two jobs to the database as if they had been submitted before
+	// a shutdown.
+	jobs := []*RescanJob{testRescanJob(t, w, 10), testRescanJob(t, w, 20)}
+	var ids []uint64
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wrescanNamespaceKey)
+		for _, job := range jobs {
+			rec := &rescanRecord{
+				start:     job.BlockStamp,
+				addrs:     job.Addrs,
+				outPoints: job.OutPoints,
+			}
+			if err := putRescanRecord(ns, rec); err != nil {
+				return err
+			}
+			ids = append(ids, rec.id)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to store rescan records: %v", err)
+	}
+
+	w.chainClient = nil
+
+	if err := w.PauseRescan(ids[0]); err != nil {
+		t.Fatalf("unable to pause rescan: %v", err)
+	}
+	if err := w.CancelRescan(ids[1]); err != nil {
+		t.Fatalf("unable to cancel rescan: %v", err)
+	}
+	if err := w.CancelRescan(ids[1]); err != ErrRescanNotFound {
+		t.Fatalf("expected ErrRescanNotFound, got %v", err)
+	}
+	if err := w.ResumeRescan(ids[0]); err == nil {
+		t.Fatal("expected resume without chain backend to fail")
+	}
+
+	statuses, err := w.Rescans()
+	if err != nil {
+		t.Fatalf("unable to list rescans: %v", err)
+	}
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 rescan, got %d", len(statuses))
+	}
+	if statuses[0].ID != ids[0] ||
+		statuses[0].State != RescanStatePaused ||
+		statuses[0].Running || statuses[0].Queued {
+
+		t.Fatalf("unexpected rescan status: %+v", statuses[0])
+	}
+}
+
+// TestRescanControl ensures queued and running rescan jobs can be paused,
+// canceled and resumed, and that their progress is persisted.
+func TestRescanControl(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+	defer w.Stop()
+
+	w.wg.Add(1)
+	go w.rescanBatchHandler()
+
+	// The first job is sent to the backend straight away, while the second
+	// waits for it to finish.
+	job1 := testRescanJob(t, w, 10)
+	errChan1 := w.SubmitRescan(job1)
+	select {
+	case <-w.rescanBatch:
+	case <-time.After(time.Second):
+		t.Fatal("rescan batch not started")
+	}
+
+	job2 := testRescanJob(t, w, 20)
+	errChan2 := w.SubmitRescan(job2)
+
+	assertStatus := func(id uint64, running, queued bool,
+		progress int32) {
+
+		t.Helper()
+
+		statuses, err := w.Rescans()
+		if err != nil {
+			t.Fatalf("unable to list rescans: %v", err)
+		}
+		for _, s := range statuses {
+			if s.ID != id {
+				continue
+			}
+			if s.Running != running || s.Queued != queued ||
+				s.ProgressBlock.Height != progress {
+
+				t.Fatalf("unexpected rescan status: %+v",
+					s)
+			}
+			return
+		}
+		t.Fatalf("rescan %d not found", id)
+	}
+
+	assertStatus(job1.ID(), true, false, 0)
+	assertStatus(job2.ID(), false, true, 0)
+
+	// Progress of the running job must be persisted.
+	w.rescanNotifications <- &chain.RescanProgress{
+		Hash:   &chainhash.Hash{15},
+		Height: 15,
+	}
+	select {
+	case <-w.rescanProgress:
+	case <-time.After(time.Second):
+		t.Fatal("rescan progress not forwarded")
+	}
+	assertStatus(job1.ID(), true, false, 15)
+
+	// Pausing the queued job removes it from the next batch.
+	if err := w.PauseRescan(job2.ID()); err != nil {
+		t.Fatalf("unable to pause rescan: %v", err)
+	}
+	if err := <-errChan2; err != ErrRescanPaused {
+		t.Fatalf("expected ErrRescanPaused, got %v", err)
+	}
+	assertStatus(job2.ID(), false, false, 0)
+
+	// Canceling the running job detaches it from the current batch.
+	if err := w.CancelRescan(job1.ID()); err != nil {
+		t.Fatalf("unable to cancel rescan: %v", err)
+	}
+	if err := <-errChan1; err != ErrRescanCanceled {
+		t.Fatalf("expected ErrRescanCanceled, got %v", err)
+	}
+
+	// Resuming the paused job queues it behind the running batch.
+	if err := w.ResumeRescan(job2.ID()); err != nil {
+		t.Fatalf("unable to resume rescan: %v", err)
+	}
+	assertStatus(job2.ID(), false, true, 0)
+
+	// Once the current batch finishes, the resumed job is started.
+	w.rescanNotifications <- &chain.RescanFinished{
+		Hash:   &chainhash.Hash{30},
+		Height: 30,
+	}
+	select {
+	case <-w.rescanFinished:
+	case <-time.After(time.Second):
+		t.Fatal("rescan finish not forwarded")
+	}
+	select {
+	case b := <-w.rescanBatch:
+		if b.bs != job2.BlockStamp {
+			t.Fatalf("expected resumed batch to start at %v, "+
+				"got %v", job2.BlockStamp, b.bs)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("resumed rescan batch not started")
+	}
+	assertStatus(job2.ID(), true, false, 0)
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// reserveProofPrefix is prepended to the message of a proof of reserves before
+// it is hashed into the outpoint of the commitment input.
+const reserveProofPrefix = "Proof-of-Reserves: "
+
+// InvalidReserveProofError is returned by VerifyReserveProof if a proof of
+// reserves is not valid, as opposed to errors of the chain backend.
+type InvalidReserveProofError struct {
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *InvalidReserveProofError) Error() string {
+	return "invalid proof of reserves: " + e.Reason
+}
+
+// invalidReserveProof creates an InvalidReserveProofError.
+func invalidReserveProof(format string, args ...interface{}) error {
+	return &InvalidReserveProofError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// ScopedAccount identifies an account of a key scope.
+type ScopedAccount struct {
+	Scope   waddrmgr.KeyScope
+	Account uint32
+}
+
+// ReserveProofCommitment returns the outpoint spent by the first input of a
+// proof of reserves for the given message. The outpoint doesn't exist, which
+// makes the proof transaction invalid, while the message is committed to by
+// every signature of the proof.
+func ReserveProofCommitment(message string) wire.OutPoint {
+	hash := sha256.Sum256([]byte(reserveProofPrefix + message))
+	return wire.OutPoint{Hash: chainhash.Hash(hash), Index: 0}
+}
+
+// CreateReserveProof builds and signs a proof of reserves as described by
+// BIP0127. The proof is a PSBT of a transaction that can never be mined: its
+// first input spends the commitment outpoint of the message, and the other
+// inputs spend the proven outputs into a single OP_TRUE output. The proven
+// outputs are the given wallet outputs along with all outputs of the given
+// accounts, each with at least minConf confirmations.
+//
+// Every input spending an output the wallet holds the private key for is
+// signed and finalized. Inputs of watch-only accounts are left unsigned, so
+// the proof can be completed by an offline signer. The height of the block
+// containing each mined output is added to its input as a proprietary field,
+// which VerifyReserveProof uses as the height hint to look up the output.
+func (w *Wallet) CreateReserveProof(message string, outPoints []wire.OutPoint,
+	accounts []ScopedAccount, minConf int32) (*psbt.Packet, error) {
+
+	if len(outPoints) == 0 && len(accounts) == 0 {
+		return nil, errors.New("at least one output or account is " +
+			"required to prove reserves")
+	}
+
+	requested := make(map[wire.OutPoint]bool, len(outPoints))
+	for _, op := range outPoints {
+		requested[op] = false
+	}
+	inAccounts := make(map[ScopedAccount]struct{}, len(accounts))
+	for _, account := range accounts {
+		inAccounts[account] = struct{}{}
+	}
+
+	// Find the unspent outputs to prove. Outputs that are explicitly
+	// requested must be unspent wallet outputs.
+	var (
+		proven  []wire.OutPoint
+		heights = make(map[wire.OutPoint]int32)
+	)
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+		syncBlock := w.Manager.SyncedTo()
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+		for _, output := range unspent {
+			if !confirmed(minConf, output.Height, syncBlock.Height) {
+				continue
+			}
+			heights[output.OutPoint] = output.Height
+			if _, ok := requested[output.OutPoint]; ok {
+				requested[output.OutPoint] = true
+				proven = append(proven, output.OutPoint)
+				continue
+			}
+			if len(inAccounts) == 0 {
+				continue
+			}
+
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) == 0 {
+				continue
+			}
+			scopedMgr, account, err := w.Manager.AddrAccount(
+				addrmgrNs, addrs[0],
+			)
+			if err != nil {
+				continue
+			}
+			scopedAccount := ScopedAccount{
+				Scope:   scopedMgr.Scope(),
+				Account: account,
+			}
+			if _, ok := inAccounts[scopedAccount]; ok {
+				proven = append(proven, output.OutPoint)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range outPoints {
+		if !requested[op] {
+			return nil, fmt.Errorf("output %v is not an unspent "+
+				"wallet output with %d confirmations", op, minConf)
+		}
+	}
+	if len(proven) == 0 {
+		return nil, errors.New("no outputs to prove reserves with")
+	}
+
+	// The commitment input comes first and the proven outputs are sent to
+	// a single output.
+	tx := wire.NewMsgTx(wire.TxVersion)
+	commitment := ReserveProofCommitment(message)
+	tx.AddTxIn(wire.NewTxIn(&commitment, nil, nil))
+	for i := range proven {
+		tx.AddTxIn(wire.NewTxIn(&proven[i], nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range proven {
+		prevTx, addr, err := w.psbtInputInfo(&proven[i])
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch output %v: %v",
+				proven[i], err)
+		}
+		in := &packet.Inputs[i+1]
+		in.SighashType = txscript.SigHashAll
+		err = w.addPsbtInputInfo(in, prevTx, proven[i].Index, addr, true)
+		if err != nil {
+			return nil, err
+		}
+		utxo := prevTx.TxOut[proven[i].Index]
+		packet.UnsignedTx.TxOut[0].Value += utxo.Value
+	}
+
+	if _, err := w.SignPsbt(packet); err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(packet.Inputs); i++ {
+		if len(packet.Inputs[i].PartialSigs) > 0 {
+			if err := psbt.Finalize(packet, i); err != nil {
+				return nil, fmt.Errorf("unable to finalize input "+
+					"%d: %v", i, err)
+			}
+		}
+	}
+
+	// The height hints are added last, as finalizing an input removes its
+	// unknown fields.
+	for i, op := range proven {
+		height := heights[op]
+		if height < 0 {
+			continue
+		}
+		in := &packet.Inputs[i+1]
+		in.Unknowns = append(in.Unknowns, &psbt.Unknown{
+			Key:   psbtProprietaryKey(psbtProprietaryHeightHint, nil),
+			Value: uint32LE(uint32(height)),
+		})
+	}
+
+	return packet, nil
+}
+
+// VerifyReserveProof checks a proof of reserves created for the given message
+// and returns the amount it proves. The outputs spent by the proof are looked
+// up in the UTXO set of the chain backend, so the proof is only valid while
+// all of them are unspent. Light clients scan the chain for each output from
+// the height hint recorded in its input by CreateReserveProof, or from the
+// given height hint if there is none. An InvalidReserveProofError is returned
+// if the proof is not valid.
+func VerifyReserveProof(packet *psbt.Packet, message string,
+	chainClient chain.Interface, heightHint uint32) (btcutil.Amount, error) {
+
+	tx := packet.UnsignedTx
+	switch {
+	case len(tx.TxIn) < 2:
+		return 0, invalidReserveProof("no outputs are proven")
+	case len(packet.Inputs) != len(tx.TxIn):
+		return 0, invalidReserveProof("PSBT inputs don't match the " +
+			"transaction")
+	case tx.TxIn[0].PreviousOutPoint != ReserveProofCommitment(message):
+		return 0, invalidReserveProof("first input doesn't commit to " +
+			"the message")
+	case len(tx.TxOut) != 1 || len(tx.TxOut[0].PkScript) != 1 ||
+		tx.TxOut[0].PkScript[0] != txscript.OP_TRUE:
+
+		return 0, invalidReserveProof("proof must have a single " +
+			"OP_TRUE output")
+	}
+
+	// Assemble the signed transaction from the finalized inputs.
+	signed := tx.Copy()
+	seen := make(map[wire.OutPoint]struct{}, len(tx.TxIn))
+	for i := 1; i < len(tx.TxIn); i++ {
+		in := &packet.Inputs[i]
+		txIn := signed.TxIn[i]
+		if len(in.FinalScriptSig) == 0 && len(in.FinalScriptWitness) == 0 {
+			return 0, invalidReserveProof("input %d is not "+
+				"finalized", i)
+		}
+		if _, ok := seen[txIn.PreviousOutPoint]; ok {
+			return 0, invalidReserveProof("output %v is proven "+
+				"twice", txIn.PreviousOutPoint)
+		}
+		seen[txIn.PreviousOutPoint] = struct{}{}
+
+		txIn.SignatureScript = in.FinalScriptSig
+		if len(in.FinalScriptWitness) > 0 {
+			witness, err := parseWitness(in.FinalScriptWitness)
+			if err != nil {
+				return 0, invalidReserveProof("input %d has an "+
+					"invalid witness: %v", i, err)
+			}
+			txIn.Witness = witness
+		}
+	}
+
+	// Every proven output must be unspent, and every signature must commit
+	// to the whole transaction including the commitment input.
+	var total btcutil.Amount
+	sigHashes := txscript.NewTxSigHashes(signed)
+	for i := 1; i < len(signed.TxIn); i++ {
+		txIn := signed.TxIn[i]
+
+		// The script of the output is only a hint for light clients
+		// and is never trusted.
+		var pkScript []byte
+		if utxo := packet.Inputs[i].WitnessUtxo; utxo != nil {
+			pkScript = utxo.PkScript
+		} else if prevTx := packet.Inputs[i].NonWitnessUtxo; prevTx != nil &&
+			int(txIn.PreviousOutPoint.Index) < len(prevTx.TxOut) {
+
+			pkScript = prevTx.TxOut[txIn.PreviousOutPoint.Index].PkScript
+		}
+		utxo, err := chainClient.GetUtxo(
+			&txIn.PreviousOutPoint, pkScript,
+			reserveProofHeightHint(packet.Inputs[i], heightHint),
+		)
+		if err == chain.ErrOutputSpent {
+			return 0, invalidReserveProof("output %v is spent",
+				txIn.PreviousOutPoint)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if !sigHashAllOnly(txIn) {
+			return 0, invalidReserveProof("input %d is not signed "+
+				"with SIGHASH_ALL", i)
+		}
+		vm, err := txscript.NewEngine(
+			utxo.PkScript, signed, i, txscript.StandardVerifyFlags,
+			nil, sigHashes, utxo.Value,
+		)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			return 0, invalidReserveProof("input %d: %v", i, err)
+		}
+		total += btcutil.Amount(utxo.Value)
+	}
+
+	if btcutil.Amount(tx.TxOut[0].Value) != total {
+		return 0, invalidReserveProof("output value %v doesn't match "+
+			"the proven amount %v", btcutil.Amount(tx.TxOut[0].Value),
+			total)
+	}
+	return total, nil
+}
+
+// reserveProofHeightHint returns the height hint recorded in an input of a
+// proof of reserves, or the default height hint if there is none. The hint is
+// never trusted: an output that can't be found from it makes the proof
+// invalid.
+func reserveProofHeightHint(in psbt.PInput, defaultHint uint32) uint32 {
+	for _, u := range in.Unknowns {
+		keyData, ok := parsePsbtProprietaryKey(
+			u.Key, psbtProprietaryHeightHint,
+		)
+		if ok && len(keyData) == 0 && len(u.Value) == 4 {
+			return binary.LittleEndian.Uint32(u.Value)
+		}
+	}
+	return defaultHint
+}
+
+// sigHashAllOnly returns false if any signature pushed by the input uses
+// another sighash type than SIGHASH_ALL. Such signatures don't necessarily
+// commit to the commitment input, so they could be reused for proofs of other
+// messages.
+func sigHashAllOnly(txIn *wire.TxIn) bool {
+	items, err := txscript.PushedData(txIn.SignatureScript)
+	if err != nil {
+		return false
+	}
+	items = append(items, txIn.Witness...)
+	for _, item := range items {
+		if len(item) < 2 {
+			continue
+		}
+		_, err := btcec.ParseDERSignature(item[:len(item)-1], btcec.S256())
+		if err != nil {
+			continue
+		}
+		if txscript.SigHashType(item[len(item)-1]) != txscript.SigHashAll {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// utxoChainClient is a mock chain client with a fixed UTXO set. The height
+// hints of the lookups are recorded.
+type utxoChainClient struct {
+	mockChainClient
+
+	utxos       map[wire.OutPoint]*wire.TxOut
+	heightHints []uint32
+}
+
+func (c *utxoChainClient) GetUtxo(op *wire.OutPoint, _ []byte,
+	heightHint uint32) (*wire.TxOut, error) {
+
+	c.heightHints = append(c.heightHints, heightHint)
+	utxo, ok := c.utxos[*op]
+	if !ok {
+		return nil, chain.ErrOutputSpent
+	}
+	return utxo, nil
+}
+
+// TestReserveProof tests that a proof of reserves over the outputs of an
+// account can be verified against the UTXO set, and that it is invalid for
+// another message or once an output is spent.
+func TestReserveProof(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	incomingTx := &wire.MsgTx{TxIn: []*wire.TxIn{{}}}
+	for _, scope := range []waddrmgr.KeyScope{
+		waddrmgr.KeyScopeBIP0044, waddrmgr.KeyScopeBIP0084,
+		waddrmgr.KeyScopeBIP0049Plus,
+	} {
+		addr, err := w.CurrentAddress(0, scope)
+		if err != nil {
+			t.Fatalf("unable to get current address: %v", err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("unable to convert wallet address: %v", err)
+		}
+		incomingTx.AddTxOut(wire.NewTxOut(1000000, pkScript))
+	}
+	addUtxo(t, w, incomingTx)
+
+	chainClient := &utxoChainClient{
+		utxos: make(map[wire.OutPoint]*wire.TxOut),
+	}
+	for i, txOut := range incomingTx.TxOut {
+		op := wire.OutPoint{Hash: incomingTx.TxHash(), Index: uint32(i)}
+		chainClient.utxos[op] = txOut
+	}
+
+	const message = "reserves at block 123456"
+	if _, err := w.CreateReserveProof(message, nil, nil, 0); err == nil {
+		t.Fatal("expected error without outputs or accounts")
+	}
+	unknown := wire.OutPoint{Index: 1}
+	_, err := w.CreateReserveProof(message, []wire.OutPoint{unknown}, nil, 0)
+	if err == nil {
+		t.Fatal("expected error proving an unknown output")
+	}
+
+	// Accounts with the same number in other key scopes are not included.
+	bip84, err := w.CreateReserveProof(message, nil, []ScopedAccount{
+		{Scope: waddrmgr.KeyScopeBIP0084, Account: 0},
+	}, 0)
+	if err != nil {
+		t.Fatalf("unable to create proof: %v", err)
+	}
+	if len(bip84.UnsignedTx.TxIn) != 2 ||
+		bip84.UnsignedTx.TxIn[1].PreviousOutPoint.Index != 1 {
+
+		t.Fatalf("expected only the BIP0084 output to be proven, got "+
+			"%v", bip84.UnsignedTx.TxIn)
+	}
+
+	packet, err := w.CreateReserveProof(message, nil, []ScopedAccount{
+		{Scope: waddrmgr.KeyScopeBIP0044, Account: 0},
+		{Scope: waddrmgr.KeyScopeBIP0084, Account: 0},
+		{Scope: waddrmgr.KeyScopeBIP0049Plus, Account: 0},
+	}, 0)
+	if err != nil {
+		t.Fatalf("unable to create proof: %v", err)
+	}
+	if len(packet.UnsignedTx.TxIn) != 4 {
+		t.Fatalf("expected 4 inputs, got %d", len(packet.UnsignedTx.TxIn))
+	}
+
+	amount, err := VerifyReserveProof(packet, message, chainClient, 0)
+	if err != nil {
+		t.Fatalf("unable to verify proof: %v", err)
+	}
+	if amount != btcutil.Amount(3000000) {
+		t.Fatalf("expected amount of 3000000, got %v", amount)
+	}
+
+	// The outputs are looked up from the height of their block.
+	for _, hint := range chainClient.heightHints {
+		if hint != uint32(testBlockHeight) {
+			t.Fatalf("expected height hint %d, got %d",
+				testBlockHeight, hint)
+		}
+	}
+
+	// A proof of a single output.
+	single, err := w.CreateReserveProof(
+		message, []wire.OutPoint{packet.UnsignedTx.TxIn[2].PreviousOutPoint},
+		nil, 0,
+	)
+	if err != nil {
+		t.Fatalf("unable to create proof: %v", err)
+	}
+	amount, err = VerifyReserveProof(single, message, chainClient, 0)
+	if err != nil || amount != btcutil.Amount(1000000) {
+		t.Fatalf("unexpected result of single proof: %v, %v", amount,
+			err)
+	}
+
+	assertInvalid := func(name string, packet *psbt.Packet,
+		message string) {
+
+		_, err := VerifyReserveProof(packet, message, chainClient, 0)
+		if _, ok := err.(*InvalidReserveProofError); !ok {
+			t.Fatalf("%s: expected invalid proof error, got %v",
+				name, err)
+		}
+	}
+
+	assertInvalid("other message", packet, message+"!")
+
+	// Inflating the output breaks the signatures.
+	packet.UnsignedTx.TxOut[0].Value++
+	assertInvalid("inflated output", packet, message)
+	packet.UnsignedTx.TxOut[0].Value--
+
+	// Spending any of the outputs invalidates the proof.
+	delete(chainClient.utxos, packet.UnsignedTx.TxIn[1].PreviousOutPoint)
+	assertInvalid("spent output", packet, message)
+}
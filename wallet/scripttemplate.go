@@ -0,0 +1,364 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// ErrScriptTemplateNotFound is returned when no spending template was imported
+// for a witness script.
+var ErrScriptTemplateNotFound = errors.New("script template not found")
+
+// ScriptTemplate describes how the wallet spends an output paying to an
+// imported witness script, such as a timelocked vault or an HTLC. The witness
+// spending the output is made up of a signature by the signing key, the
+// preimage if the script requires one, the branch selectors and the witness
+// script itself, in that order.
+type ScriptTemplate struct {
+	// PubKey is the public key of the wallet key signing the spend.
+	PubKey *btcec.PublicKey
+
+	// Sequence is the sequence of the spending input required by an
+	// OP_CHECKSEQUENCEVERIFY in the script, or zero if there is none.
+	Sequence uint32
+
+	// LockTime is the lock time of the spending transaction required by an
+	// OP_CHECKLOCKTIMEVERIFY in the script, or zero if there is none.
+	LockTime uint32
+
+	// PreimageHash is the hash of the preimage required by the script,
+	// either a 32 byte SHA256 hash or a 20 byte hash160.  It is nil if the
+	// script does not require a preimage.
+	PreimageHash []byte
+
+	// Preimage is the preimage of PreimageHash.  It may be left unset
+	// until the preimage is known, for example when the other party of an
+	// HTLC reveals it, and the template imported again.
+	Preimage []byte
+
+	// Branches are the conditions of the OP_IF and OP_NOTIF opcodes of the
+	// script, in the order they are executed.
+	Branches []bool
+}
+
+// witness returns the witness stack spending the witness script with the
+// signature.
+func (t *ScriptTemplate) witness(sig, witnessScript []byte) wire.TxWitness {
+	witness := wire.TxWitness{sig}
+	if t.PreimageHash != nil {
+		witness = append(witness, t.Preimage)
+	}
+
+	// The first condition executed is at the top of the stack, so the
+	// branches are pushed in reverse order.
+	for i := len(t.Branches) - 1; i >= 0; i-- {
+		if t.Branches[i] {
+			witness = append(witness, []byte{1})
+		} else {
+			witness = append(witness, nil)
+		}
+	}
+
+	return append(witness, witnessScript)
+}
+
+// validate checks that the template is consistent, and that its signing key
+// and preimage hash are used by the witness script.
+func (t *ScriptTemplate) validate(witnessScript []byte) error {
+	if t.PubKey == nil {
+		return errors.New("script template has no signing key")
+	}
+	if t.Sequence&wire.SequenceLockTimeDisabled != 0 {
+		return errors.New("script template sequence has relative " +
+			"lock time disabled")
+	}
+	if len(t.Branches) > 255 {
+		return errors.New("script template has too many branches")
+	}
+
+	pushes, err := txscript.PushedData(witnessScript)
+	if err != nil {
+		return err
+	}
+	pushed := func(data []byte) bool {
+		for _, push := range pushes {
+			if bytes.Equal(push, data) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !pushed(t.PubKey.SerializeCompressed()) {
+		return errors.New("signing key of script template is not " +
+			"used by the witness script")
+	}
+
+	switch len(t.PreimageHash) {
+	case 0:
+		if t.Preimage != nil {
+			return errors.New("script template has a preimage " +
+				"but no preimage hash")
+		}
+		return nil
+	case 20, 32:
+	default:
+		return fmt.Errorf("script template preimage hash has invalid "+
+			"length %d", len(t.PreimageHash))
+	}
+	if !pushed(t.PreimageHash) {
+		return errors.New("preimage hash of script template is not " +
+			"used by the witness script")
+	}
+	if t.Preimage != nil && !bytes.Equal(
+		preimageHash(t.Preimage, len(t.PreimageHash)), t.PreimageHash) {
+
+		return errors.New("preimage does not match the preimage hash " +
+			"of the script template")
+	}
+
+	return nil
+}
+
+// preimageHash returns the SHA256 hash of the preimage if size is 32, or its
+// hash160 if size is 20.
+func preimageHash(preimage []byte, size int) []byte {
+	if size == 20 {
+		return btcutil.Hash160(preimage)
+	}
+	hash := sha256.Sum256(preimage)
+	return hash[:]
+}
+
+// timelocks returns the sequence and lock time required by the template, and
+// whether an output spent with it can be included in the block after bs.
+//
+// This is part of the witnessSpender interface.
+func (t *ScriptTemplate) timelocks(output *wtxmgr.Credit,
+	bs *waddrmgr.BlockStamp) (uint32, uint32, bool) {
+
+	if t.PreimageHash != nil && t.Preimage == nil {
+		return 0, 0, false
+	}
+	if t.Sequence != 0 && !relativeLockReached(t.Sequence, output, bs) {
+		return 0, 0, false
+	}
+	if t.LockTime != 0 && !absoluteLockReached(t.LockTime, bs) {
+		return 0, 0, false
+	}
+	return t.Sequence, t.LockTime, true
+}
+
+// maxWitnessSize returns the size of the witness spending the witness script
+// with a signature of the largest size.
+//
+// This is part of the witnessSpender interface.
+func (t *ScriptTemplate) maxWitnessSize(witnessScript []byte) int {
+	sig := make([]byte, maxWitnessSigSize)
+	return t.witness(sig, witnessScript).SerializeSize()
+}
+
+// sign returns the witness spending the witness script with the template.
+//
+// This is part of the witnessSpender interface.
+func (t *ScriptTemplate) sign(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes,
+	idx int, amount int64, witnessScript []byte,
+	hashType txscript.SigHashType, _ wire.TxWitness,
+	chainParams *chaincfg.Params,
+	getKey func(*btcutil.AddressPubKeyHash) (*btcec.PrivateKey, error)) (
+	wire.TxWitness, error) {
+
+	return signScriptTemplate(
+		tx, sigHashes, idx, amount, witnessScript, t, hashType,
+		chainParams, getKey,
+	)
+}
+
+// relativeLockReached returns whether the relative lock time of the input
+// sequence has passed for an output spent in the block after bs.
+func relativeLockReached(sequence uint32, output *wtxmgr.Credit,
+	bs *waddrmgr.BlockStamp) bool {
+
+	lock := int64(sequence & wire.SequenceLockTimeMask)
+	if sequence&wire.SequenceLockTimeIsSeconds != 0 {
+		elapsed := bs.Timestamp.Unix() - output.BlockMeta.Time.Unix()
+		return output.Height != -1 &&
+			elapsed >= lock<<wire.SequenceLockTimeGranularity
+	}
+	return confirmed(int32(lock), output.Height, bs.Height)
+}
+
+// absoluteLockReached returns whether the lock time has passed for a
+// transaction included in the block after bs.
+func absoluteLockReached(lockTime uint32, bs *waddrmgr.BlockStamp) bool {
+	if lockTime < txscript.LockTimeThreshold {
+		return int64(lockTime) <= int64(bs.Height)
+	}
+	return int64(lockTime) <= bs.Timestamp.Unix()
+}
+
+// applyTimelocks sets the version, lock time and sequence of the transaction
+// input spending an output which requires the sequence and lock time, either
+// of which may be zero if not required.
+func applyTimelocks(tx *wire.MsgTx, idx int, sequence, lockTime uint32) {
+	if sequence != 0 {
+		if tx.Version < 2 {
+			tx.Version = 2
+		}
+		tx.TxIn[idx].Sequence = sequence
+	}
+	if lockTime != 0 {
+		if lockTime > tx.LockTime {
+			tx.LockTime = lockTime
+		}
+
+		// The lock time is only enforced for inputs that aren't final.
+		if tx.TxIn[idx].Sequence == wire.MaxTxInSequenceNum {
+			tx.TxIn[idx].Sequence = wire.MaxTxInSequenceNum - 1
+		}
+	}
+}
+
+// ImportScriptTemplate imports a witness script into the wallet together with
+// the template used to spend outputs paying to it, returning the P2WSH address
+// of the script.  The signing key of the template must belong to the wallet.
+// Importing a script again replaces its template, which allows adding the
+// preimage once it is known.
+func (w *Wallet) ImportScriptTemplate(witnessScript []byte,
+	template *ScriptTemplate) (btcutil.Address, error) {
+
+	if err := template.validate(witnessScript); err != nil {
+		return nil, err
+	}
+
+	scriptHash := sha256.Sum256(witnessScript)
+	addr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], w.chainParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		wscriptNs := tx.ReadWriteBucket(wscriptNamespaceKey)
+
+		// Make sure the wallet is able to sign with the key.
+		pubKeyHash := btcutil.Hash160(template.PubKey.SerializeCompressed())
+		keyAddr, err := btcutil.NewAddressPubKeyHash(
+			pubKeyHash, w.chainParams,
+		)
+		if err != nil {
+			return err
+		}
+		managedAddr, err := w.Manager.Address(addrmgrNs, keyAddr)
+		if err != nil {
+			return err
+		}
+		if _, ok := managedAddr.(waddrmgr.ManagedPubKeyAddress); !ok {
+			return fmt.Errorf("signing key address %v is not a "+
+				"public key address", keyAddr)
+		}
+
+		bs := &waddrmgr.BlockStamp{
+			Hash:   *w.ChainParams().GenesisHash,
+			Height: 0,
+		}
+		bip84Mgr, err := w.Manager.FetchScopedKeyManager(
+			waddrmgr.KeyScopeBIP0084,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = bip84Mgr.ImportWitnessScript(
+			addrmgrNs, witnessScript, bs, false,
+		)
+		if err != nil &&
+			!waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+
+			return err
+		}
+
+		return putScriptTemplate(wscriptNs, scriptHash[:], template)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// ScriptTemplate returns the spending template of the witness script of a
+// P2WSH address.
+func (w *Wallet) ScriptTemplate(addr btcutil.Address) (*ScriptTemplate, error) {
+	p2wshAddr, ok := addr.(*btcutil.AddressWitnessScriptHash)
+	if !ok {
+		return nil, fmt.Errorf("address %v is not a P2WSH address",
+			addr)
+	}
+
+	var template *ScriptTemplate
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wscriptNamespaceKey)
+		var err error
+		template, err = fetchScriptTemplate(ns, p2wshAddr.ScriptAddress())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrScriptTemplateNotFound
+	}
+	return template, nil
+}
+
+// signScriptTemplate returns the witness spending an output of the amount
+// paying to the witness script with its template.  getKey must return the
+// private key of the template's signing key.
+func signScriptTemplate(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes,
+	idx int, amount int64, witnessScript []byte, template *ScriptTemplate,
+	hashType txscript.SigHashType, chainParams *chaincfg.Params,
+	getKey func(*btcutil.AddressPubKeyHash) (*btcec.PrivateKey, error)) (
+	wire.TxWitness, error) {
+
+	if template.PreimageHash != nil && template.Preimage == nil {
+		return nil, errors.New("preimage of witness script is unknown")
+	}
+
+	pubKeyHash := btcutil.Hash160(template.PubKey.SerializeCompressed())
+	keyAddr, err := btcutil.NewAddressPubKeyHash(pubKeyHash, chainParams)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := getKey(keyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if privKey == nil {
+		return nil, fmt.Errorf("signing key %v of witness script is "+
+			"unknown", keyAddr)
+	}
+
+	sig, err := txscript.RawTxInWitnessSignature(
+		tx, sigHashes, idx, amount, witnessScript, hashType, privKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return template.witness(sig, witnessScript), nil
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// templateKey returns the public key of a new external address of the wallet.
+func templateKey(t *testing.T, w *Wallet) *btcec.PublicKey {
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to get new address: %v", err)
+	}
+	pubKey, err := w.PubKeyForAddress(addr)
+	if err != nil {
+		t.Fatalf("unable to get public key: %v", err)
+	}
+	return pubKey
+}
+
+// fundScriptTemplate imports the witness script with the template and adds an
+// output paying to it to the wallet, returning the output's script and
+// outpoint.
+func fundScriptTemplate(t *testing.T, w *Wallet, prevHash chainhash.Hash,
+	script []byte, template *ScriptTemplate) ([]byte, wire.OutPoint) {
+
+	addr, err := w.ImportScriptTemplate(script, template)
+	if err != nil {
+		t.Fatalf("unable to import script template: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Hash: prevHash},
+		}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(100000, pkScript)},
+	}
+	addUtxo(t, w, tx)
+	return pkScript, wire.OutPoint{Hash: tx.TxHash()}
+}
+
+// TestScriptTemplateSerialization tests that script templates are stored and
+// loaded unchanged.
+func TestScriptTemplateSerialization(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	preimage := []byte("preimage")
+	templates := []*ScriptTemplate{{
+		PubKey:   privKey.PubKey(),
+		Sequence: 144,
+	}, {
+		PubKey:       privKey.PubKey(),
+		LockTime:     600000,
+		PreimageHash: btcutil.Hash160(preimage),
+		Preimage:     preimage,
+		Branches:     []bool{true, false},
+	}}
+	for i, template := range templates {
+		got, err := deserializeScriptTemplate(
+			serializeScriptTemplate(template),
+		)
+		if err != nil {
+			t.Fatalf("template %d: unable to deserialize: %v", i,
+				err)
+		}
+		if !reflect.DeepEqual(got, template) {
+			t.Fatalf("template %d: got %+v, want %+v", i, got,
+				template)
+		}
+	}
+}
+
+// TestScriptTemplateTimelocks tests that outputs paying to CSV and CLTV
+// witness scripts are spent by txToOutputs once their timelocks are reached.
+func TestScriptTemplateTimelocks(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	// The mock chain is at height 500000, so an output locked until
+	// height 600000 can't be spent yet.
+	const csv, cltv, immatureCLTV = 144, 400000, 600000
+
+	pubKey := templateKey(t, w).SerializeCompressed()
+	csvScript, err := txscript.NewScriptBuilder().
+		AddInt64(csv).AddOp(txscript.OP_CHECKSEQUENCEVERIFY).
+		AddOp(txscript.OP_DROP).AddData(pubKey).
+		AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cltvScript := func(lockTime int64) []byte {
+		script, err := txscript.NewScriptBuilder().
+			AddInt64(lockTime).
+			AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).
+			AddOp(txscript.OP_DROP).AddData(pubKey).
+			AddOp(txscript.OP_CHECKSIG).Script()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return script
+	}
+
+	key, err := btcec.ParsePubKey(pubKey, btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	csvPkScript, _ := fundScriptTemplate(
+		t, w, chainhash.Hash{1}, csvScript,
+		&ScriptTemplate{PubKey: key, Sequence: csv},
+	)
+	cltvPkScript, _ := fundScriptTemplate(
+		t, w, chainhash.Hash{2}, cltvScript(cltv),
+		&ScriptTemplate{PubKey: key, LockTime: cltv},
+	)
+	fundScriptTemplate(
+		t, w, chainhash.Hash{3}, cltvScript(immatureCLTV),
+		&ScriptTemplate{PubKey: key, LockTime: immatureCLTV},
+	)
+
+	// Both spendable outputs are needed to pay 150000 satoshis, while the
+	// immature output can't be used to pay more.
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.txToOutputs(
+		[]*wire.TxOut{wire.NewTxOut(250000, pkScript)},
+		&waddrmgr.KeyScopeBIP0084, waddrmgr.ImportedAddrAccount, 1,
+		1000, CoinSelectionLargest, true,
+	)
+	if err == nil {
+		t.Fatal("expected immature output to be skipped")
+	}
+
+	// The transaction is signed and validated by txToOutputs.
+	tx, err := w.txToOutputs(
+		[]*wire.TxOut{wire.NewTxOut(150000, pkScript)},
+		&waddrmgr.KeyScopeBIP0084, waddrmgr.ImportedAddrAccount, 1,
+		1000, CoinSelectionLargest, false,
+	)
+	if err != nil {
+		t.Fatalf("unable to spend timelocked outputs: %v", err)
+	}
+	if tx.Tx.Version != 2 || tx.Tx.LockTime != cltv {
+		t.Fatalf("unexpected version %d and lock time %d",
+			tx.Tx.Version, tx.Tx.LockTime)
+	}
+	for i, txIn := range tx.Tx.TxIn {
+		switch string(tx.PrevScripts[i]) {
+		case string(csvPkScript):
+			if txIn.Sequence != csv {
+				t.Fatalf("unexpected CSV input sequence %d",
+					txIn.Sequence)
+			}
+		case string(cltvPkScript):
+			if txIn.Sequence == wire.MaxTxInSequenceNum {
+				t.Fatal("CLTV input is final")
+			}
+		default:
+			t.Fatalf("unexpected input %v", txIn.PreviousOutPoint)
+		}
+	}
+}
+
+// TestScriptTemplateHTLC tests that both branches of an HTLC can be spent
+// with SignTransaction and ComputeInputScript, and that the preimage is
+// required to spend the success branch.
+func TestScriptTemplateHTLC(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	const lockTime = 400000
+	preimage := []byte("htlc preimage")
+	hash := sha256.Sum256(preimage)
+	receiverKey := templateKey(t, w)
+	refundKey := templateKey(t, w)
+
+	script, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_IF).
+		AddOp(txscript.OP_SHA256).AddData(hash[:]).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddData(receiverKey.SerializeCompressed()).
+		AddOp(txscript.OP_ELSE).
+		AddInt64(lockTime).AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).
+		AddOp(txscript.OP_DROP).
+		AddData(refundKey.SerializeCompressed()).
+		AddOp(txscript.OP_ENDIF).
+		AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signing key must be used by the script.
+	_, err = w.ImportScriptTemplate(script, &ScriptTemplate{
+		PubKey:       templateKey(t, w),
+		PreimageHash: hash[:],
+		Branches:     []bool{true},
+	})
+	if err == nil {
+		t.Fatal("expected template with unused key to be rejected")
+	}
+
+	success := &ScriptTemplate{
+		PubKey:       receiverKey,
+		PreimageHash: hash[:],
+		Branches:     []bool{true},
+	}
+	pkScript, outPoint := fundScriptTemplate(
+		t, w, chainhash.Hash{1}, script, success,
+	)
+	newTx := func() *wire.MsgTx {
+		return &wire.MsgTx{
+			Version: 2,
+			TxIn: []*wire.TxIn{{
+				PreviousOutPoint: outPoint,
+				Sequence:         wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{wire.NewTxOut(90000, pkScript)},
+		}
+	}
+
+	// The success branch can't be spent until the preimage is known.
+	tx := newTx()
+	signErrs, err := w.SignTransaction(
+		tx, txscript.SigHashAll, nil, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to sign transaction: %v", err)
+	}
+	if len(signErrs) != 1 {
+		t.Fatal("expected missing preimage error")
+	}
+
+	success.Preimage = preimage
+	if _, err := w.ImportScriptTemplate(script, success); err != nil {
+		t.Fatalf("unable to import preimage: %v", err)
+	}
+	addr, err := WitnessScriptAddress(script, false, w.ChainParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, err := w.ScriptTemplate(addr)
+	if err != nil {
+		t.Fatalf("unable to fetch template: %v", err)
+	}
+	if !reflect.DeepEqual(template, success) {
+		t.Fatalf("unexpected template %+v", template)
+	}
+
+	tx = newTx()
+	signErrs, err = w.SignTransaction(
+		tx, txscript.SigHashAll, nil, nil, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to sign transaction: %v", err)
+	}
+	if len(signErrs) != 0 {
+		t.Fatalf("unable to spend success branch: %v",
+			signErrs[0].Error)
+	}
+
+	// The refund branch is spent once the lock time is reached.
+	refund := &ScriptTemplate{
+		PubKey:   refundKey,
+		LockTime: lockTime,
+		Branches: []bool{false},
+	}
+	if _, err := w.ImportScriptTemplate(script, refund); err != nil {
+		t.Fatalf("unable to import refund template: %v", err)
+	}
+	tx = newTx()
+	applyTimelocks(tx, 0, refund.Sequence, refund.LockTime)
+	output := wire.NewTxOut(100000, pkScript)
+	witness, sigScript, err := w.ComputeInputScript(
+		tx, output, 0, txscript.NewTxSigHashes(tx),
+		txscript.SigHashAll, nil,
+	)
+	if err != nil {
+		t.Fatalf("unable to compute input script: %v", err)
+	}
+	tx.TxIn[0].Witness = witness
+	tx.TxIn[0].SignatureScript = sigScript
+	err = validateMsgTx(
+		tx, [][]byte{pkScript}, []btcutil.Amount{100000},
+	)
+	if err != nil {
+		t.Fatalf("unable to spend refund branch: %v", err)
+	}
+}
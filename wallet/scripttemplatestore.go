@@ -0,0 +1,136 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The script template namespace stores the spending template of each imported
+// witness script, keyed by the SHA256 hash of the witness script. Each
+// template is serialized as follows:
+//
+//   [0:33]    compressed public key of the signing key
+//   [33:37]   sequence (4 bytes)
+//   [37:41]   lock time (4 bytes)
+//   [41]      number of branches
+//   ...       branches, one byte each
+//   ...       preimage hash, as a 1 byte length followed by the hash
+//   ...       preimage, as a 2 byte length followed by the preimage
+//
+// All integers are encoded big endian.
+
+var (
+	// wscriptNamespaceKey is the key of the top level bucket storing the
+	// script templates.
+	wscriptNamespaceKey = []byte("wscript")
+)
+
+func serializeScriptTemplate(t *ScriptTemplate) []byte {
+	var b bytes.Buffer
+	var u32 [4]byte
+
+	b.Write(t.PubKey.SerializeCompressed())
+	binary.BigEndian.PutUint32(u32[:], t.Sequence)
+	b.Write(u32[:])
+	binary.BigEndian.PutUint32(u32[:], t.LockTime)
+	b.Write(u32[:])
+
+	b.WriteByte(byte(len(t.Branches)))
+	for _, branch := range t.Branches {
+		if branch {
+			b.WriteByte(1)
+		} else {
+			b.WriteByte(0)
+		}
+	}
+
+	b.WriteByte(byte(len(t.PreimageHash)))
+	b.Write(t.PreimageHash)
+
+	var l [2]byte
+	binary.BigEndian.PutUint16(l[:], uint16(len(t.Preimage)))
+	b.Write(l[:])
+	b.Write(t.Preimage)
+
+	return b.Bytes()
+}
+
+func deserializeScriptTemplate(v []byte) (*ScriptTemplate, error) {
+	const headerSize = 33 + 2*4 + 1
+	if len(v) < headerSize {
+		return nil, fmt.Errorf("short script template: %d bytes",
+			len(v))
+	}
+
+	pubKey, err := btcec.ParsePubKey(v[:33], btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	t := &ScriptTemplate{
+		PubKey:   pubKey,
+		Sequence: binary.BigEndian.Uint32(v[33:37]),
+		LockTime: binary.BigEndian.Uint32(v[37:41]),
+	}
+
+	r := bytes.NewReader(v[headerSize:])
+	branches := make([]byte, v[headerSize-1])
+	if _, err := io.ReadFull(r, branches); err != nil {
+		return nil, err
+	}
+	for _, branch := range branches {
+		t.Branches = append(t.Branches, branch != 0)
+	}
+
+	hashLen, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if hashLen != 0 {
+		t.PreimageHash = make([]byte, hashLen)
+		if _, err := io.ReadFull(r, t.PreimageHash); err != nil {
+			return nil, err
+		}
+	}
+
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, err
+	}
+	if preimageLen := binary.BigEndian.Uint16(l[:]); preimageLen != 0 {
+		t.Preimage = make([]byte, preimageLen)
+		if _, err := io.ReadFull(r, t.Preimage); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// putScriptTemplate stores the template of the witness script with the given
+// hash.
+func putScriptTemplate(ns walletdb.ReadWriteBucket, scriptHash []byte,
+	t *ScriptTemplate) error {
+
+	return ns.Put(scriptHash, serializeScriptTemplate(t))
+}
+
+// fetchScriptTemplate returns the template of the witness script with the
+// given hash, or nil if there is none.
+func fetchScriptTemplate(ns walletdb.ReadBucket,
+	scriptHash []byte) (*ScriptTemplate, error) {
+
+	v := ns.Get(scriptHash)
+	if v == nil {
+		return nil, nil
+	}
+	return deserializeScriptTemplate(v)
+}
@@ -5,6 +5,7 @@
 package wallet
 
 import (
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -12,6 +13,7 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
 )
 
 // scriptForOutput returns the address, witness program and redeem script for a
@@ -87,11 +89,26 @@ type PrivKeyTweaker func(*btcec.PrivateKey) (*btcec.PrivateKey, error)
 // transaction with the signature as defined within the passed SignDescriptor.
 // This method is capable of generating the proper input script for both
 // regular p2wkh output and p2wkh outputs nested within a regular p2sh output.
+// Outputs paying to a witness script, either native or nested within a p2sh
+// output, are spent with the script's template or account policy, or signed
+// with each of the wallet's keys of a multisig script.
 func (w *Wallet) ComputeInputScript(tx *wire.MsgTx, output *wire.TxOut,
 	inputIndex int, sigHashes *txscript.TxSigHashes,
 	hashType txscript.SigHashType, tweaker PrivKeyTweaker) (wire.TxWitness,
 	[]byte, error) {
 
+	outputAddr, err := w.fetchOutputAddr(output.PkScript)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch outputAddr.AddrType() {
+	case waddrmgr.WitnessScript, waddrmgr.NestedWitnessScript:
+		return w.computeWitnessScriptInput(
+			tx, output, inputIndex, sigHashes, hashType, tweaker,
+			outputAddr.(waddrmgr.ManagedScriptAddress),
+		)
+	}
+
 	walletAddr, witnessProgram, sigScript, err := w.scriptForOutput(output)
 	if err != nil {
 		return nil, nil, err
@@ -121,3 +138,80 @@ func (w *Wallet) ComputeInputScript(tx *wire.MsgTx, output *wire.TxOut,
 
 	return witnessScript, sigScript, nil
 }
+
+// computeWitnessScriptInput generates the witness and signature script
+// spending an output paying to a witness script, using the script's template
+// or account policy if it has one, or otherwise signing the multisig script
+// with each of its keys held by the wallet.
+func (w *Wallet) computeWitnessScriptInput(tx *wire.MsgTx, output *wire.TxOut,
+	inputIndex int, sigHashes *txscript.TxSigHashes,
+	hashType txscript.SigHashType, tweaker PrivKeyTweaker,
+	scriptAddr waddrmgr.ManagedScriptAddress) (wire.TxWitness, []byte,
+	error) {
+
+	witnessScript, err := scriptAddr.Script()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	getKey := func(addr *btcutil.AddressPubKeyHash) (*btcec.PrivateKey,
+		error) {
+
+		walletAddr, err := w.AddressInfo(addr)
+		if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pubKeyAddr, ok := walletAddr.(waddrmgr.ManagedPubKeyAddress)
+		if !ok {
+			return nil, nil
+		}
+		privKey, err := pubKeyAddr.PrivKey()
+		if err != nil {
+			return nil, err
+		}
+		if tweaker != nil {
+			return tweaker(privKey)
+		}
+		return privKey, nil
+	}
+
+	var spender witnessSpender
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		scriptHash := sha256.Sum256(witnessScript)
+		var err error
+		spender, err = fetchWitnessSpender(dbtx, scriptHash[:])
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var witness wire.TxWitness
+	if spender != nil {
+		witness, err = spender.sign(
+			tx, sigHashes, inputIndex, output.Value, witnessScript,
+			hashType, nil, w.chainParams, getKey,
+		)
+	} else {
+		witness, err = signWitnessMultiSig(
+			tx, sigHashes, inputIndex, output.Value, witnessScript,
+			hashType, nil, w.chainParams, getKey,
+		)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sigScript []byte
+	if scriptAddr.AddrType() == waddrmgr.NestedWitnessScript {
+		sigScript, err = witnessSigScript(witnessScript)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return witness, sigScript, nil
+}
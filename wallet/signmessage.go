@@ -0,0 +1,327 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// MessageSignatureFormat describes how a message signature is created.
+type MessageSignatureFormat uint8
+
+const (
+	// MessageSignatureDefault selects the format based on the type of the
+	// signing address: legacy for P2PKH, simple for P2WKH and full for
+	// NP2WKH addresses.
+	MessageSignatureDefault MessageSignatureFormat = iota
+
+	// MessageSignatureLegacy is the compact signature format used by
+	// Bitcoin Core before BIP0322, which only supports P2PKH addresses.
+	MessageSignatureLegacy
+
+	// MessageSignatureSimple is the BIP0322 format consisting of the
+	// witness stack of the virtual to_sign transaction. It is only
+	// supported by native witness addresses.
+	MessageSignatureSimple
+
+	// MessageSignatureFull is the BIP0322 format consisting of the complete
+	// virtual to_sign transaction.
+	MessageSignatureFull
+)
+
+// String returns the name of the format.
+func (f MessageSignatureFormat) String() string {
+	switch f {
+	case MessageSignatureDefault:
+		return "default"
+	case MessageSignatureLegacy:
+		return "legacy"
+	case MessageSignatureSimple:
+		return "simple"
+	case MessageSignatureFull:
+		return "full"
+	default:
+		return fmt.Sprintf("unknown format %d", uint8(f))
+	}
+}
+
+// bip322Tag is the tag of the BIP0322 message hash.
+var bip322Tag = sha256.Sum256([]byte("BIP0322-signed-message"))
+
+// legacyMessageHash returns the hash signed by a legacy message signature.
+func legacyMessageHash(message string) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
+	_ = wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// bip322MessageHash returns the tagged hash of a message committed to by the
+// to_spend transaction of BIP0322.
+func bip322MessageHash(message string) []byte {
+	h := sha256.New()
+	h.Write(bip322Tag[:])
+	h.Write(bip322Tag[:])
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
+
+// bip322ToSpend creates the virtual transaction of BIP0322 whose only output,
+// paying to the given script, is spent by a message signature.
+func bip322ToSpend(pkScript []byte, message string) (*wire.MsgTx, error) {
+	sigScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(bip322MessageHash(message)).
+		Script()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(0)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex)
+	txIn := wire.NewTxIn(prevOut, sigScript, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	return tx, nil
+}
+
+// bip322ToSign creates the unsigned virtual transaction of BIP0322 that spends
+// the output of the to_spend transaction.
+func bip322ToSign(toSpend *wire.MsgTx) *wire.MsgTx {
+	tx := wire.NewMsgTx(0)
+	toSpendHash := toSpend.TxHash()
+	txIn := wire.NewTxIn(wire.NewOutPoint(&toSpendHash, 0), nil, nil)
+	txIn.Sequence = 0
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return tx
+}
+
+// SignMessage signs a message with the private key of a wallet address. The
+// legacy format produces a compact signature of the message hash, while the
+// BIP0322 formats prove that the address' output script can be spent by
+// signing a virtual transaction committing to the message.
+func (w *Wallet) SignMessage(addr btcutil.Address, message string,
+	format MessageSignatureFormat) ([]byte, error) {
+
+	managedAddr, err := w.AddressInfo(addr)
+	if err != nil {
+		return nil, err
+	}
+	pubKeyAddr, ok := managedAddr.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil, fmt.Errorf("address %v does not have an associated "+
+			"private key", addr)
+	}
+	addrType := pubKeyAddr.AddrType()
+
+	if format == MessageSignatureDefault {
+		switch addrType {
+		case waddrmgr.WitnessPubKey:
+			format = MessageSignatureSimple
+		case waddrmgr.NestedWitnessPubKey:
+			format = MessageSignatureFull
+		default:
+			format = MessageSignatureLegacy
+		}
+	}
+
+	switch {
+	case format == MessageSignatureLegacy && addrType != waddrmgr.PubKeyHash:
+		return nil, fmt.Errorf("legacy message signatures are only "+
+			"supported by P2PKH addresses, not %v", addr)
+
+	case format == MessageSignatureSimple && addrType != waddrmgr.WitnessPubKey:
+		return nil, fmt.Errorf("simple message signatures are only "+
+			"supported by P2WKH addresses, not %v", addr)
+
+	case format > MessageSignatureFull:
+		return nil, fmt.Errorf("unknown message signature format %d",
+			uint8(format))
+	}
+
+	privKey, err := pubKeyAddr.PrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if format == MessageSignatureLegacy {
+		return btcec.SignCompact(
+			btcec.S256(), privKey, legacyMessageHash(message),
+			pubKeyAddr.Compressed(),
+		)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(pubKeyAddr.Address())
+	if err != nil {
+		return nil, err
+	}
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return nil, err
+	}
+	toSign := bip322ToSign(toSpend)
+	txIn := toSign.TxIn[0]
+
+	switch addrType {
+	case waddrmgr.PubKeyHash:
+		txIn.SignatureScript, err = txscript.SignatureScript(
+			toSign, 0, pkScript, txscript.SigHashAll, privKey,
+			pubKeyAddr.Compressed(),
+		)
+
+	case waddrmgr.WitnessPubKey, waddrmgr.NestedWitnessPubKey:
+		txIn.Witness, txIn.SignatureScript, err = w.ComputeInputScript(
+			toSign, toSpend.TxOut[0], 0,
+			txscript.NewTxSigHashes(toSign), txscript.SigHashAll,
+			nil,
+		)
+
+	default:
+		err = fmt.Errorf("message signatures are not supported by "+
+			"address %v", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if format == MessageSignatureSimple {
+		err = psbt.WriteTxWitness(&buf, txIn.Witness)
+	} else {
+		err = toSign.Serialize(&buf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// VerifyMessage checks whether a signature of a message was created by the
+// owner of an address. Legacy signatures are accepted for P2PKH addresses, and
+// BIP0322 signatures in the simple and full format for any address whose
+// output script can be executed. The format used by the signature is
+// returned along with the result. An error is only returned if the address
+// can't be verified at all.
+func VerifyMessage(addr btcutil.Address, message string, sig []byte) (bool,
+	MessageSignatureFormat, error) {
+
+	// Legacy signatures are recovered to a public key, which must match
+	// the address.
+	switch addr := addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		if pubKey, ok := recoverLegacyPubKey(message, sig); ok {
+			valid := bytes.Equal(btcutil.Hash160(pubKey), addr.Hash160()[:])
+			return valid, MessageSignatureLegacy, nil
+		}
+
+	case *btcutil.AddressPubKey:
+		if pubKey, ok := recoverLegacyPubKey(message, sig); ok {
+			valid := bytes.Equal(pubKey, addr.ScriptAddress())
+			return valid, MessageSignatureLegacy, nil
+		}
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return false, MessageSignatureDefault, err
+	}
+	toSpend, err := bip322ToSpend(pkScript, message)
+	if err != nil {
+		return false, MessageSignatureDefault, err
+	}
+	toSign := bip322ToSign(toSpend)
+
+	// Signatures that decode as a witness stack use the simple format.
+	// Otherwise the signature must be a to_sign transaction spending the
+	// to_spend transaction of the message.
+	format := MessageSignatureSimple
+	witness, err := parseWitness(sig)
+	if err == nil {
+		toSign.TxIn[0].Witness = witness
+	} else {
+		format = MessageSignatureFull
+		var signed wire.MsgTx
+		r := bytes.NewReader(sig)
+		if signed.Deserialize(r) != nil || r.Len() != 0 {
+			return false, format, nil
+		}
+		if len(signed.TxIn) != 1 {
+			return false, format, nil
+		}
+
+		// Apart from the input scripts, the signed transaction must
+		// match the virtual to_sign transaction.
+		txIn := toSign.TxIn[0]
+		txIn.SignatureScript = signed.TxIn[0].SignatureScript
+		txIn.Witness = signed.TxIn[0].Witness
+		if signed.TxHash() != toSign.TxHash() {
+			return false, format, nil
+		}
+	}
+
+	vm, err := txscript.NewEngine(
+		pkScript, toSign, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(toSign), 0,
+	)
+	if err != nil {
+		return false, format, nil
+	}
+	return vm.Execute() == nil, format, nil
+}
+
+// recoverLegacyPubKey returns the serialized public key a legacy message
+// signature was created with.
+func recoverLegacyPubKey(message string, sig []byte) ([]byte, bool) {
+	pubKey, compressed, err := btcec.RecoverCompact(
+		btcec.S256(), sig, legacyMessageHash(message),
+	)
+	if err != nil {
+		return nil, false
+	}
+	if compressed {
+		return pubKey.SerializeCompressed(), true
+	}
+	return pubKey.SerializeUncompressed(), true
+}
+
+// parseWitness decodes a consensus encoded witness stack, such as a simple
+// BIP0322 signature. The encoding must not contain any trailing bytes.
+func parseWitness(b []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(b)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > uint64(r.Len()) {
+		return nil, errors.New("invalid witness stack size")
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(
+			r, 0, txscript.MaxScriptSize, "witness item",
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, io.ErrShortBuffer
+	}
+	return witness, nil
+}
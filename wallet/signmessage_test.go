@@ -0,0 +1,151 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// TestBip322Vectors tests the message hash and the verification of simple
+// signatures against the test vectors of BIP0322.
+func TestBip322Vectors(t *testing.T) {
+	t.Parallel()
+
+	hashes := map[string]string{
+		"":            "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1",
+		"Hello World": "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a",
+	}
+	for message, want := range hashes {
+		got := hex.EncodeToString(bip322MessageHash(message))
+		if got != want {
+			t.Errorf("message hash of %q: expected %s, got %s",
+				message, want, got)
+		}
+	}
+
+	addr, err := btcutil.DecodeAddress(
+		"bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l",
+		&chaincfg.MainNetParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := map[string]string{
+		"": "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxF" +
+			"SeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+		"Hello World": "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtp" +
+			"tFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	}
+	for message, b64 := range sigs {
+		sig, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid, format, err := VerifyMessage(addr, message, sig)
+		if err != nil {
+			t.Fatalf("unable to verify %q: %v", message, err)
+		}
+		if !valid || format != MessageSignatureSimple {
+			t.Errorf("expected valid simple signature of %q, got "+
+				"%v, %v", message, valid, format)
+		}
+
+		// The signature must not be valid for another message.
+		valid, _, _ = VerifyMessage(addr, message+"!", sig)
+		if valid {
+			t.Errorf("signature of %q valid for another message",
+				message)
+		}
+	}
+}
+
+// TestSignMessage tests that messages signed by wallet addresses in every
+// supported format can be verified.
+func TestSignMessage(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addrs := make(map[waddrmgr.KeyScope]btcutil.Address)
+	for _, scope := range []waddrmgr.KeyScope{
+		waddrmgr.KeyScopeBIP0044, waddrmgr.KeyScopeBIP0084,
+		waddrmgr.KeyScopeBIP0049Plus,
+	} {
+		addr, err := w.CurrentAddress(0, scope)
+		if err != nil {
+			t.Fatalf("unable to get current address: %v", err)
+		}
+		addrs[scope] = addr
+	}
+
+	tests := []struct {
+		name   string
+		scope  waddrmgr.KeyScope
+		format MessageSignatureFormat
+		want   MessageSignatureFormat
+		valid  bool
+	}{
+		{"p2pkh default", waddrmgr.KeyScopeBIP0044,
+			MessageSignatureDefault, MessageSignatureLegacy, true},
+		{"p2pkh full", waddrmgr.KeyScopeBIP0044,
+			MessageSignatureFull, MessageSignatureFull, true},
+		{"p2pkh simple", waddrmgr.KeyScopeBIP0044,
+			MessageSignatureSimple, 0, false},
+		{"p2wkh default", waddrmgr.KeyScopeBIP0084,
+			MessageSignatureDefault, MessageSignatureSimple, true},
+		{"p2wkh full", waddrmgr.KeyScopeBIP0084,
+			MessageSignatureFull, MessageSignatureFull, true},
+		{"p2wkh legacy", waddrmgr.KeyScopeBIP0084,
+			MessageSignatureLegacy, 0, false},
+		{"np2wkh default", waddrmgr.KeyScopeBIP0049Plus,
+			MessageSignatureDefault, MessageSignatureFull, true},
+		{"np2wkh simple", waddrmgr.KeyScopeBIP0049Plus,
+			MessageSignatureSimple, 0, false},
+	}
+
+	const message = "Hello World"
+	for _, test := range tests {
+		addr := addrs[test.scope]
+		sig, err := w.SignMessage(addr, message, test.format)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unable to sign: %v", test.name, err)
+			continue
+		}
+
+		valid, format, err := VerifyMessage(addr, message, sig)
+		if err != nil || !valid || format != test.want {
+			t.Errorf("%s: expected valid %v signature, got %v, %v, "+
+				"%v", test.name, test.want, valid, format, err)
+		}
+
+		// Neither another message nor another address may verify.
+		valid, _, _ = VerifyMessage(addr, message+"!", sig)
+		if valid {
+			t.Errorf("%s: signature valid for another message",
+				test.name)
+		}
+		for scope, other := range addrs {
+			if scope == test.scope {
+				continue
+			}
+			valid, _, _ = VerifyMessage(other, message, sig)
+			if valid {
+				t.Errorf("%s: signature valid for %v", test.name,
+					other)
+			}
+		}
+	}
+}
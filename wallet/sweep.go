@@ -0,0 +1,459 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// ErrNothingToSweep is returned when no unspent outputs paying to the swept
+// keys are found.
+var ErrNothingToSweep = errors.New("no unspent outputs found for the keys")
+
+// maxSweepTxVirtualSize is the largest estimated virtual size of a sweep
+// transaction, which keeps it within the standard transaction weight of
+// 400000.  Outputs that don't fit are swept by additional transactions.
+var maxSweepTxVirtualSize = 400000 / blockchain.WitnessScaleFactor
+
+// sweepSecrets is an implementation of txauthor.SecretsSource for the keys
+// being swept, which are never stored by the wallet.
+type sweepSecrets struct {
+	keys        map[string]*btcutil.WIF
+	chainParams *chaincfg.Params
+}
+
+// newSweepSecrets returns the secrets of the keys, indexed by their P2PKH
+// address and, for compressed keys, their P2WKH and NP2WKH addresses.
+func newSweepSecrets(keys []*btcutil.WIF,
+	chainParams *chaincfg.Params) (*sweepSecrets, error) {
+
+	s := &sweepSecrets{
+		keys:        make(map[string]*btcutil.WIF),
+		chainParams: chainParams,
+	}
+	for _, wif := range keys {
+		if !wif.IsForNet(chainParams) {
+			return nil, errors.New("key is not intended for " +
+				chainParams.Name)
+		}
+
+		pubKeyHash := btcutil.Hash160(wif.SerializePubKey())
+		addrs := make([]btcutil.Address, 0, 3)
+		p2pkh, err := btcutil.NewAddressPubKeyHash(
+			pubKeyHash, chainParams,
+		)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, p2pkh)
+
+		// Witness outputs can only pay to compressed keys.
+		if wif.CompressPubKey {
+			p2wkh, err := btcutil.NewAddressWitnessPubKeyHash(
+				pubKeyHash, chainParams,
+			)
+			if err != nil {
+				return nil, err
+			}
+			witnessProgram, err := txscript.PayToAddrScript(p2wkh)
+			if err != nil {
+				return nil, err
+			}
+			np2wkh, err := btcutil.NewAddressScriptHash(
+				witnessProgram, chainParams,
+			)
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, p2wkh, np2wkh)
+		}
+
+		for _, addr := range addrs {
+			s.keys[addr.EncodeAddress()] = wif
+		}
+	}
+	return s, nil
+}
+
+// GetKey returns the private key of an address of the swept keys.
+//
+// This is part of the txscript.KeyDB interface.
+func (s *sweepSecrets) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool,
+	error) {
+
+	wif, ok := s.keys[addr.EncodeAddress()]
+	if !ok {
+		return nil, false, fmt.Errorf("no key for address %v", addr)
+	}
+	return wif.PrivKey, wif.CompressPubKey, nil
+}
+
+// GetScript returns an error, as the swept keys have no scripts.
+//
+// This is part of the txscript.ScriptDB interface.
+func (s *sweepSecrets) GetScript(addr btcutil.Address) ([]byte, error) {
+	return nil, fmt.Errorf("no script for address %v", addr)
+}
+
+// ChainParams returns the network of the swept keys.
+//
+// This is part of the txauthor.SecretsSource interface.
+func (s *sweepSecrets) ChainParams() *chaincfg.Params {
+	return s.chainParams
+}
+
+// addrs returns the addresses of the swept keys.
+func (s *sweepSecrets) addrs() ([]btcutil.Address, error) {
+	addrs := make([]btcutil.Address, 0, len(s.keys))
+	for encoded := range s.keys {
+		addr, err := btcutil.DecodeAddress(encoded, s.chainParams)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// sweepOutput is an unspent output paying to a swept key.
+type sweepOutput struct {
+	outPoint wire.OutPoint
+	output   *wire.TxOut
+	height   int32
+}
+
+// findSweepOutputs filters the blocks from startHeight to the tip of the chain
+// for the outputs paying to the addresses, and returns those which are still
+// unspent.
+func findSweepOutputs(chainClient chain.Interface, addrs []btcutil.Address,
+	startHeight int32) ([]sweepOutput, error) {
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// The addresses are all watched as external addresses of an unused
+	// key scope, as the swept keys have no derivation.
+	watchedAddrs := make(map[waddrmgr.ScopedIndex]btcutil.Address)
+	for i, addr := range addrs {
+		watchedAddrs[waddrmgr.ScopedIndex{Index: uint32(i)}] = addr
+	}
+	pkScripts := make(map[string]btcutil.Address, len(addrs))
+	for _, addr := range addrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+		pkScripts[string(pkScript)] = addr
+	}
+
+	// Only the hashes of the blocks are fetched, as their times aren't
+	// needed to filter them.
+	found := make(map[wire.OutPoint]sweepOutput)
+	watchedOutPoints := make(map[wire.OutPoint]btcutil.Address)
+	var batch []wtxmgr.BlockMeta
+	for height := startHeight; height <= bestHeight; height++ {
+		hash, err := chainClient.GetBlockHash(int64(height))
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, wtxmgr.BlockMeta{
+			Block: wtxmgr.Block{Hash: *hash, Height: height},
+		})
+		if len(batch) < recoveryBatchSize && height != bestHeight {
+			continue
+		}
+
+		// The blocks are filtered until no block of the batch has
+		// any relevant transactions, as each response only reports
+		// the first block that does.
+		for len(batch) > 0 {
+			resp, err := chainClient.FilterBlocks(
+				&chain.FilterBlocksRequest{
+					Blocks:           batch,
+					ExternalAddrs:    watchedAddrs,
+					WatchedOutPoints: watchedOutPoints,
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+			if resp == nil {
+				break
+			}
+
+			for _, tx := range resp.RelevantTxns {
+				for _, txIn := range tx.TxIn {
+					op := txIn.PreviousOutPoint
+					delete(found, op)
+					delete(watchedOutPoints, op)
+				}
+				txHash := tx.TxHash()
+				for i, txOut := range tx.TxOut {
+					addr, ok := pkScripts[string(txOut.PkScript)]
+					if !ok {
+						continue
+					}
+					op := wire.OutPoint{
+						Hash:  txHash,
+						Index: uint32(i),
+					}
+					found[op] = sweepOutput{
+						outPoint: op,
+						output:   txOut,
+						height:   resp.BlockMeta.Height,
+					}
+					watchedOutPoints[op] = addr
+				}
+			}
+			batch = batch[resp.BatchIndex+1:]
+		}
+		batch = batch[:0]
+	}
+
+	// Outputs may have been spent since the tip was fetched, or be spent
+	// by unconfirmed transactions, so make sure they are still unspent.
+	outputs := make([]sweepOutput, 0, len(found))
+	for _, output := range found {
+		_, err := chainClient.GetUtxo(
+			&output.outPoint, output.output.PkScript,
+			uint32(output.height),
+		)
+		if err == chain.ErrOutputSpent {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// sweepInputCounts counts the inputs of a sweep transaction by type, for the
+// estimation of its size.
+type sweepInputCounts struct {
+	p2pkh, p2wkh, np2wkh int
+
+	// uncompressed is the number of P2PKH inputs with an uncompressed
+	// public key.
+	uncompressed int
+}
+
+// add counts an input spending the output script.
+func (c *sweepInputCounts) add(pkScript []byte, secrets *sweepSecrets) {
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		c.p2wkh++
+	case txscript.IsPayToScriptHash(pkScript):
+		c.np2wkh++
+	default:
+		c.p2pkh++
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			pkScript, secrets.chainParams,
+		)
+		if err == nil && len(addrs) == 1 &&
+			!secrets.keys[addrs[0].EncodeAddress()].CompressPubKey {
+
+			c.uncompressed++
+		}
+	}
+}
+
+// virtualSize returns the worst case virtual size of a sweep transaction with
+// the counted inputs and the output.
+func (c *sweepInputCounts) virtualSize(txOut *wire.TxOut) int {
+	// Uncompressed public keys are 32 bytes larger than the compressed
+	// keys the P2PKH input size is estimated with.
+	return txsizes.EstimateVirtualSize(
+		c.p2pkh, c.p2wkh, c.np2wkh, []*wire.TxOut{txOut}, 0,
+	) + c.uncompressed*32
+}
+
+// groupSweepOutputs splits the outputs into groups that are each spent by one
+// transaction paying to an output with the script, so that no transaction
+// exceeds maxSweepTxVirtualSize.
+func groupSweepOutputs(outputs []sweepOutput, pkScript []byte,
+	secrets *sweepSecrets) [][]sweepOutput {
+
+	txOut := wire.NewTxOut(0, pkScript)
+	var (
+		groups [][]sweepOutput
+		group  []sweepOutput
+		counts sweepInputCounts
+	)
+	for _, output := range outputs {
+		next := counts
+		next.add(output.output.PkScript, secrets)
+		if len(group) > 0 &&
+			next.virtualSize(txOut) > maxSweepTxVirtualSize {
+
+			groups = append(groups, group)
+			group = nil
+			next = sweepInputCounts{}
+			next.add(output.output.PkScript, secrets)
+		}
+		group = append(group, output)
+		counts = next
+	}
+	return append(groups, group)
+}
+
+// SweepPrivKeys spends all unspent outputs paying to the P2PKH, P2WKH and
+// NP2WKH addresses of the private keys to new internal addresses of the
+// account, and publishes the transactions.  The outputs are found by filtering
+// the blocks from startHeight to the tip of the chain, which should be the
+// height at which the keys were first used.  The outputs are spent by as many
+// transactions as needed to keep each within the standard transaction size.
+// The keys are only used to sign the transactions and are never stored by the
+// wallet.
+//
+// If publishing a transaction fails, the transactions published before it are
+// returned along with the error.
+func (w *Wallet) SweepPrivKeys(keys []*btcutil.WIF, startHeight int32,
+	scope waddrmgr.KeyScope, account uint32,
+	feeSatPerKb btcutil.Amount) ([]*wire.MsgTx, error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys to sweep")
+	}
+	if startHeight < 0 {
+		return nil, fmt.Errorf("invalid start height %d", startHeight)
+	}
+
+	secrets, err := newSweepSecrets(keys, w.chainParams)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := secrets.addrs()
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := findSweepOutputs(chainClient, addrs, startHeight)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, ErrNothingToSweep
+	}
+
+	// Each transaction derives its change address in its own database
+	// transaction, as the next address of the account is only advanced
+	// once the derivation is committed.  The outputs are grouped with the
+	// script of the first change address, which has the same size as
+	// those of the following transactions.
+	var (
+		groups   [][]sweepOutput
+		sweepTxs []*wire.MsgTx
+	)
+	for i := 0; i == 0 || i < len(groups); i++ {
+		err := walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+			addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
+			addr, err := w.newChangeAddress(addrmgrNs, account, scope)
+			if err != nil {
+				return err
+			}
+			pkScript, err := txscript.PayToAddrScript(addr)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				groups = groupSweepOutputs(
+					outputs, pkScript, secrets,
+				)
+			}
+
+			tx, err := w.sweepTx(
+				groups[i], pkScript, secrets, feeSatPerKb,
+			)
+			if err != nil {
+				return err
+			}
+			sweepTxs = append(sweepTxs, tx)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, tx := range sweepTxs {
+		if err := w.PublishTransaction(tx, "sweep"); err != nil {
+			return sweepTxs[:i], err
+		}
+	}
+	return sweepTxs, nil
+}
+
+// sweepTx creates and signs a transaction spending the outputs to the script.
+func (w *Wallet) sweepTx(outputs []sweepOutput, pkScript []byte,
+	secrets *sweepSecrets, feeSatPerKb btcutil.Amount) (*wire.MsgTx,
+	error) {
+
+	var (
+		tx              = wire.NewMsgTx(wire.TxVersion)
+		prevScripts     = make([][]byte, 0, len(outputs))
+		prevInputValues = make([]btcutil.Amount, 0, len(outputs))
+		total           btcutil.Amount
+		counts          sweepInputCounts
+	)
+	for _, output := range outputs {
+		pkScript := output.output.PkScript
+		counts.add(pkScript, secrets)
+
+		outPoint := output.outPoint
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		prevScripts = append(prevScripts, pkScript)
+		amount := btcutil.Amount(output.output.Value)
+		prevInputValues = append(prevInputValues, amount)
+		total += amount
+	}
+
+	txOut := wire.NewTxOut(0, pkScript)
+	fee := txrules.FeeForSerializeSize(feeSatPerKb, counts.virtualSize(txOut))
+	txOut.Value = int64(total - fee)
+	if txOut.Value <= 0 ||
+		txrules.IsDustOutput(txOut, txrules.DefaultRelayFeePerKb) {
+
+		return nil, fmt.Errorf("swept amount %v does not cover the fee "+
+			"of %v", total, fee)
+	}
+	tx.AddTxOut(txOut)
+
+	authored := &txauthor.AuthoredTx{
+		Tx:              tx,
+		PrevScripts:     prevScripts,
+		PrevInputValues: prevInputValues,
+		TotalInput:      total,
+		ChangeIndex:     -1,
+	}
+	if err := authored.AddAllInputScripts(secrets); err != nil {
+		return nil, err
+	}
+	if err := validateMsgTx(tx, prevScripts, prevInputValues); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// blocksChainClient is a mock chain client with a fixed chain of blocks and
+// UTXO set.
+type blocksChainClient struct {
+	utxoChainClient
+
+	blocks [][]*wire.MsgTx
+}
+
+func (c *blocksChainClient) GetBestBlock() (*chainhash.Hash, int32, error) {
+	height := int32(len(c.blocks) - 1)
+	return &chainhash.Hash{byte(height)}, height, nil
+}
+
+func (c *blocksChainClient) GetBlockHash(height int64) (*chainhash.Hash,
+	error) {
+
+	return &chainhash.Hash{byte(height)}, nil
+}
+
+// FilterBlocks returns the first block of the batch with any transactions,
+// regardless of whether they're relevant.
+func (c *blocksChainClient) FilterBlocks(req *chain.FilterBlocksRequest) (
+	*chain.FilterBlocksResponse, error) {
+
+	for i, meta := range req.Blocks {
+		txs := c.blocks[meta.Height]
+		if len(txs) == 0 {
+			continue
+		}
+		return &chain.FilterBlocksResponse{
+			BatchIndex:   uint32(i),
+			BlockMeta:    meta,
+			RelevantTxns: txs,
+		}, nil
+	}
+	return nil, nil
+}
+
+// TestSweepPrivKeys tests that the unspent outputs of private keys are swept
+// to an internal address of the wallet, and that spent outputs are ignored.
+func TestSweepPrivKeys(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create key: %v", err)
+	}
+	wif, err := btcutil.NewWIF(privKey, w.chainParams, true)
+	if err != nil {
+		t.Fatalf("unable to encode key: %v", err)
+	}
+	pubKeyHash := btcutil.Hash160(wif.SerializePubKey())
+	p2pkh, err := btcutil.NewAddressPubKeyHash(pubKeyHash, w.chainParams)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	p2wkh, err := btcutil.NewAddressWitnessPubKeyHash(
+		pubKeyHash, w.chainParams,
+	)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+
+	// The key receives three outputs at height 1, one of which is spent
+	// at height 3.
+	fundingTx := &wire.MsgTx{TxIn: []*wire.TxIn{{}}}
+	for _, addr := range []btcutil.Address{p2pkh, p2wkh, p2wkh} {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("unable to create script: %v", err)
+		}
+		fundingTx.AddTxOut(wire.NewTxOut(100000, pkScript))
+	}
+	spentOp := wire.OutPoint{Hash: fundingTx.TxHash(), Index: 2}
+	spendingTx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{wire.NewTxIn(&spentOp, nil, nil)},
+		TxOut: []*wire.TxOut{wire.NewTxOut(90000, []byte{0x51})},
+	}
+
+	chainClient := &blocksChainClient{
+		utxoChainClient: utxoChainClient{
+			utxos: make(map[wire.OutPoint]*wire.TxOut),
+		},
+		blocks: [][]*wire.MsgTx{
+			nil, {fundingTx}, nil, {spendingTx}, nil,
+		},
+	}
+	for i, txOut := range fundingTx.TxOut[:2] {
+		op := wire.OutPoint{Hash: fundingTx.TxHash(), Index: uint32(i)}
+		chainClient.utxos[op] = txOut
+	}
+	w.chainClient = chainClient
+
+	// Nothing is found when searching from after the funding block.
+	_, err = w.SweepPrivKeys(
+		[]*btcutil.WIF{wif}, 2, waddrmgr.KeyScopeBIP0084, 0,
+		txrules.DefaultRelayFeePerKb,
+	)
+	if err != ErrNothingToSweep {
+		t.Fatalf("expected ErrNothingToSweep, got %v", err)
+	}
+
+	txs, err := w.SweepPrivKeys(
+		[]*btcutil.WIF{wif}, 0, waddrmgr.KeyScopeBIP0084, 0,
+		txrules.DefaultRelayFeePerKb,
+	)
+	if err != nil {
+		t.Fatalf("unable to sweep keys: %v", err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(txs))
+	}
+	tx := txs[0]
+	if len(tx.TxIn) != 2 {
+		t.Fatalf("expected 2 inputs, got %d", len(tx.TxIn))
+	}
+	for _, txIn := range tx.TxIn {
+		if txIn.PreviousOutPoint == spentOp {
+			t.Fatalf("spent output %v was swept", spentOp)
+		}
+	}
+	if len(tx.TxOut) != 1 {
+		t.Fatalf("expected 1 output, got %d", len(tx.TxOut))
+	}
+	if tx.TxOut[0].Value >= 200000 || tx.TxOut[0].Value < 190000 {
+		t.Fatalf("unexpected swept amount %v", tx.TxOut[0].Value)
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		tx.TxOut[0].PkScript, w.chainParams,
+	)
+	if err != nil || len(addrs) != 1 {
+		t.Fatalf("unable to extract output address: %v", err)
+	}
+	info, err := w.AddressInfo(addrs[0])
+	if err != nil {
+		t.Fatalf("swept output doesn't pay to the wallet: %v", err)
+	}
+	if !info.Internal() {
+		t.Fatal("swept output doesn't pay to an internal address")
+	}
+
+	// When the outputs don't fit in one transaction, each is swept by its
+	// own transaction to a different address.
+	defer func(size int) { maxSweepTxVirtualSize = size }(
+		maxSweepTxVirtualSize,
+	)
+	maxSweepTxVirtualSize = 200
+	txs, err = w.SweepPrivKeys(
+		[]*btcutil.WIF{wif}, 0, waddrmgr.KeyScopeBIP0084, 0,
+		txrules.DefaultRelayFeePerKb,
+	)
+	if err != nil {
+		t.Fatalf("unable to sweep keys: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txs))
+	}
+	for _, tx := range txs {
+		if len(tx.TxIn) != 1 {
+			t.Fatalf("expected 1 input, got %d", len(tx.TxIn))
+		}
+	}
+	if txs[0].TxIn[0].PreviousOutPoint == txs[1].TxIn[0].PreviousOutPoint {
+		t.Fatal("output swept twice")
+	}
+	if string(txs[0].TxOut[0].PkScript) == string(txs[1].TxOut[0].PkScript) {
+		t.Fatal("transactions pay to the same address")
+	}
+
+	// The swept key is never stored by the wallet.
+	if _, err := w.AddressInfo(p2wkh); err == nil {
+		t.Fatal("swept key was imported")
+	}
+}
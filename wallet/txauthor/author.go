@@ -70,6 +70,11 @@ type ChangeSource struct {
 	ScriptSize int
 }
 
+// WitnessSizeSource returns the worst case serialized size of the witness
+// spending a P2WSH output with the previous output script, and whether the
+// size is known.
+type WitnessSizeSource func(pkScript []byte) (int, bool)
+
 // NewUnsignedTransaction creates an unsigned transaction paying to one or more
 // non-change outputs.  An appropriate transaction fee is included based on the
 // transaction size.
@@ -93,6 +98,20 @@ type ChangeSource struct {
 func NewUnsignedTransaction(outputs []*wire.TxOut, feeRatePerKb btcutil.Amount,
 	fetchInputs InputSource, changeSource *ChangeSource) (*AuthoredTx, error) {
 
+	return NewUnsignedTransactionWithWitnessSizes(
+		outputs, feeRatePerKb, fetchInputs, changeSource, nil,
+	)
+}
+
+// NewUnsignedTransactionWithWitnessSizes creates an unsigned transaction like
+// NewUnsignedTransaction, estimating the size of each P2WSH input with the
+// witness size returned by witnessSizes.  P2WSH inputs of unknown witness size
+// are estimated as P2PKH inputs.
+func NewUnsignedTransactionWithWitnessSizes(outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount, fetchInputs InputSource,
+	changeSource *ChangeSource, witnessSizes WitnessSizeSource) (
+	*AuthoredTx, error) {
+
 	targetAmount := SumOutputValues(outputs)
 	estimatedSize := txsizes.EstimateVirtualSize(
 		0, 1, 0, outputs, changeSource.ScriptSize,
@@ -110,7 +129,10 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, feeRatePerKb btcutil.Amount,
 
 		// We count the types of inputs, which we'll use to estimate
 		// the vsize of the transaction.
-		var nested, p2wpkh, p2pkh int
+		var (
+			nested, p2wpkh, p2pkh int
+			p2wsh                 []int
+		)
 		for _, pkScript := range scripts {
 			switch {
 			// If this is a p2sh output, we assume this is a
@@ -119,13 +141,26 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, feeRatePerKb btcutil.Amount,
 				nested++
 			case txscript.IsPayToWitnessPubKeyHash(pkScript):
 				p2wpkh++
+			case txscript.IsPayToWitnessScriptHash(pkScript) &&
+				witnessSizes != nil:
+
+				size, ok := witnessSizes(pkScript)
+				if !ok {
+					p2pkh++
+					continue
+				}
+				p2wsh = append(p2wsh, size)
+			// P2WSH outputs of unknown witness size are also
+			// estimated as P2PKH, which is larger than the spends
+			// of the common witness script templates.
 			default:
 				p2pkh++
 			}
 		}
 
-		maxSignedSize := txsizes.EstimateVirtualSize(
-			p2pkh, p2wpkh, nested, outputs, changeSource.ScriptSize,
+		maxSignedSize := txsizes.EstimateVirtualSizeWithP2WSH(
+			p2pkh, p2wpkh, nested, p2wsh, outputs,
+			changeSource.ScriptSize,
 		)
 		maxRequiredFee := txrules.FeeForSerializeSize(feeRatePerKb, maxSignedSize)
 		remainingAmount := inputAmount - targetAmount
@@ -198,6 +233,16 @@ type SecretsSource interface {
 	ChainParams() *chaincfg.Params
 }
 
+// WitnessScriptSigner is an optional interface of a SecretsSource which is
+// able to spend P2WSH outputs.  The witness script and the witness stack
+// satisfying it are not known from the previous output script alone, so
+// SecretsSources implementing this interface must provide the complete
+// witness for the input.
+type WitnessScriptSigner interface {
+	SignWitnessScript(tx *wire.MsgTx, hashCache *txscript.TxSigHashes,
+		idx int, pkScript []byte, inputValue int64) (wire.TxWitness, error)
+}
+
 // AddAllInputScripts modifies transaction a transaction by adding inputs
 // scripts for each input.  Previous output scripts being redeemed by each input
 // are passed in prevPkScripts and the slice length must match the number of
@@ -237,6 +282,18 @@ func AddAllInputScripts(tx *wire.MsgTx, prevPkScripts [][]byte, inputValues []bt
 			if err != nil {
 				return err
 			}
+		case txscript.IsPayToWitnessScriptHash(pkScript):
+			signer, ok := secrets.(WitnessScriptSigner)
+			if !ok {
+				return errors.New("secrets source is unable " +
+					"to spend witness script outputs")
+			}
+			witness, err := signer.SignWitnessScript(tx, hashCache,
+				i, pkScript, int64(inputValues[i]))
+			if err != nil {
+				return err
+			}
+			inputs[i].Witness = witness
 		default:
 			sigScript := inputs[i].SignatureScript
 			script, err := txscript.SignTxOutput(chainParams, tx, i,
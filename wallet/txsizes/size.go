@@ -115,6 +115,23 @@ const (
 	RedeemNestedP2WPKHInputSize = 32 + 4 + 1 +
 		RedeemNestedP2WPKHScriptSize + 4
 
+	// P2WSHPkScriptSize is the size of a transaction output script that
+	// pays to a witness script hash. It is calculated as:
+	//
+	//   - OP_0
+	//   - OP_DATA_32
+	//   - 32 bytes script hash
+	P2WSHPkScriptSize = 1 + 1 + 32
+
+	// RedeemP2WSHInputSize is the size of a transaction input redeeming a
+	// P2WSH output, not including its witness. It is calculated as:
+	//
+	//   - 32 bytes previous tx
+	//   - 4 bytes output index
+	//   - 1 byte encoding empty redeem script
+	//   - 4 bytes sequence
+	RedeemP2WSHInputSize = 32 + 4 + 1 + 4
+
 	// RedeemP2WPKHInputWitnessWeight is the worst case weight of
 	// a witness for spending P2WPKH and nested P2WPKH outputs. It
 	// is calculated as:
@@ -162,6 +179,20 @@ func EstimateSerializeSize(inputCount int, txOuts []*wire.TxOut, addChangeOutput
 // change output if addChangeOutput is true.
 func EstimateVirtualSize(numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns int,
 	txOuts []*wire.TxOut, changeScriptSize int) int {
+
+	return EstimateVirtualSizeWithP2WSH(
+		numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns, nil, txOuts,
+		changeScriptSize,
+	)
+}
+
+// EstimateVirtualSizeWithP2WSH returns a worst case virtual size estimate
+// like EstimateVirtualSize, for a transaction which additionally spends a
+// P2WSH output for each of the worst case witness sizes in p2wshWitnessSizes.
+func EstimateVirtualSizeWithP2WSH(numP2PKHIns, numP2WPKHIns,
+	numNestedP2WPKHIns int, p2wshWitnessSizes []int, txOuts []*wire.TxOut,
+	changeScriptSize int) int {
+
 	outputCount := len(txOuts)
 
 	changeOutputSize := 0
@@ -172,29 +203,36 @@ func EstimateVirtualSize(numP2PKHIns, numP2WPKHIns, numNestedP2WPKHIns int,
 		outputCount++
 	}
 
+	numP2WSHIns := len(p2wshWitnessSizes)
+
 	// Version 4 bytes + LockTime 4 bytes + Serialized var int size for the
 	// number of transaction inputs and outputs + size of redeem scripts +
 	// the size out the serialized outputs and change.
 	baseSize := 8 +
 		wire.VarIntSerializeSize(
-			uint64(numP2PKHIns+numP2WPKHIns+numNestedP2WPKHIns)) +
+			uint64(numP2PKHIns+numP2WPKHIns+numNestedP2WPKHIns+
+				numP2WSHIns)) +
 		wire.VarIntSerializeSize(uint64(len(txOuts))) +
 		numP2PKHIns*RedeemP2PKHInputSize +
 		numP2WPKHIns*RedeemP2WPKHInputSize +
 		numNestedP2WPKHIns*RedeemNestedP2WPKHInputSize +
+		numP2WSHIns*RedeemP2WSHInputSize +
 		SumOutputSerializeSizes(txOuts) +
 		changeOutputSize
 
 	// If this transaction has any witness inputs, we must count the
 	// witness data.
 	witnessWeight := 0
-	if numP2WPKHIns+numNestedP2WPKHIns > 0 {
+	numWitnessIns := numP2WPKHIns + numNestedP2WPKHIns + numP2WSHIns
+	if numWitnessIns > 0 {
 		// Additional 2 weight units for segwit marker + flag.
 		witnessWeight = 2 +
-			wire.VarIntSerializeSize(
-				uint64(numP2WPKHIns+numNestedP2WPKHIns)) +
+			wire.VarIntSerializeSize(uint64(numWitnessIns)) +
 			numP2WPKHIns*RedeemP2WPKHInputWitnessWeight +
 			numNestedP2WPKHIns*RedeemP2WPKHInputWitnessWeight
+		for _, size := range p2wshWitnessSizes {
+			witnessWeight += size
+		}
 	}
 
 	// We add 3 to the witness weight to make sure the result is
@@ -176,3 +176,33 @@ func TestEstimateVirtualSize(t *testing.T) {
 		}
 	}
 }
+
+func TestEstimateVirtualSizeWithP2WSH(t *testing.T) {
+	// Spending a P2WSH output to two outputs, with a witness of the same
+	// size as the P2WPKH witness. We reuse the P2WPKH transaction of
+	// BIP-143, as the inputs have the same size.
+	txHex := "01000000000101ef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac0247304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee0121025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee635711000000"
+	b, err := hex.DecodeString(txHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &wire.MsgTx{}
+	if err := tx.Deserialize(bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+
+	est := EstimateVirtualSizeWithP2WSH(
+		0, 0, 0, []int{RedeemP2WPKHInputWitnessWeight}, tx.TxOut, 0,
+	)
+	if est != 147 {
+		t.Fatalf("expected estimated vsize to be 147, instead got %d",
+			est)
+	}
+
+	// Without P2WSH inputs, the estimate matches EstimateVirtualSize.
+	est = EstimateVirtualSizeWithP2WSH(0, 1, 0, nil, tx.TxOut, 0)
+	if want := EstimateVirtualSize(0, 1, 0, tx.TxOut, 0); est != want {
+		t.Fatalf("expected estimated vsize to be %d, instead got %d",
+			want, est)
+	}
+}
@@ -7,6 +7,7 @@ package wallet
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/chain"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/cryptdb"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
 	"github.com/btcsuite/btcwallet/walletdb"
@@ -105,6 +107,10 @@ type Wallet struct {
 	Manager *waddrmgr.Manager
 	TxStore *wtxmgr.Store
 
+	// dbEncrypted is whether db is encrypted with the public passphrase
+	// by the cryptdb package.
+	dbEncrypted bool
+
 	chainClient        chain.Interface
 	chainClientLock    sync.Mutex
 	chainClientSynced  bool
@@ -119,6 +125,7 @@ type Wallet struct {
 	// any waiting requests, before being sent to another goroutine to
 	// call the rescan RPC.
 	rescanAddJob        chan *RescanJob
+	rescanControl       chan *rescanControlRequest
 	rescanBatch         chan *rescanBatch
 	rescanNotifications chan interface{} // From chain server
 	rescanProgress      chan *RescanProgressMsg
@@ -1271,11 +1278,16 @@ out:
 	for {
 		select {
 		case req := <-w.unlockRequests:
+			wasLocked := w.Manager.IsLocked()
 			err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 				addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 				return w.Manager.Unlock(addrmgrNs, req.passphrase)
 			})
 			if err != nil {
+				// A failed unlock may lock the manager.
+				if !wasLocked && w.Manager.IsLocked() {
+					w.NtfnServer.notifyLockState(true)
+				}
 				req.err <- err
 				continue
 			}
@@ -1285,16 +1297,29 @@ out:
 			} else {
 				log.Info("The wallet has been temporarily unlocked")
 			}
+			if wasLocked {
+				w.NtfnServer.notifyLockState(false)
+			}
 			req.err <- nil
 			continue
 
 		case req := <-w.changePassphrase:
 			err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
 				addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
-				return w.Manager.ChangePassphrase(
+				err := w.Manager.ChangePassphrase(
 					addrmgrNs, req.old, req.new, req.private,
 					&waddrmgr.DefaultScryptOptions,
 				)
+				if err != nil || req.private || !w.dbEncrypted {
+					return err
+				}
+
+				// An encrypted wallet database is opened with
+				// the public passphrase.
+				return cryptdb.SetPassphrase(
+					tx, req.new,
+					&waddrmgr.DefaultScryptOptions,
+				)
 			})
 			req.err <- err
 			continue
@@ -1309,6 +1334,15 @@ out:
 				if err != nil {
 					return err
 				}
+				if w.dbEncrypted {
+					err = cryptdb.SetPassphrase(
+						tx, req.publicNew,
+						&waddrmgr.DefaultScryptOptions,
+					)
+					if err != nil {
+						return err
+					}
+				}
 
 				return w.Manager.ChangePassphrase(
 					addrmgrNs, req.privateOld, req.privateNew,
@@ -1353,10 +1387,14 @@ out:
 		// timer expiring.  Lock the manager here.
 		timeout = nil
 		err := w.Manager.Lock()
-		if err != nil && !waddrmgr.IsError(err, waddrmgr.ErrLocked) {
-			log.Errorf("Could not lock wallet: %v", err)
-		} else {
+		switch {
+		case err == nil:
+			log.Info("The wallet has been locked")
+			w.NtfnServer.notifyLockState(true)
+		case waddrmgr.IsError(err, waddrmgr.ErrLocked):
 			log.Info("The wallet has been locked")
+		default:
+			log.Errorf("Could not lock wallet: %v", err)
 		}
 	}
 	w.wg.Done()
@@ -1491,6 +1529,37 @@ func (w *Wallet) CalculateBalance(confirms int32) (btcutil.Amount, error) {
 	return balance, err
 }
 
+// TxStoreStats records counts of the outputs and transactions tracked by the
+// wallet's transaction store.
+type TxStoreStats struct {
+	UnspentOutputs int
+	UnminedTxs     int
+}
+
+// TxStoreStats returns the number of unspent outputs, including unmined ones,
+// and of unmined transactions in the wallet's transaction store.
+func (w *Wallet) TxStoreStats() (*TxStoreStats, error) {
+	var stats TxStoreStats
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+		unmined, err := w.TxStore.UnminedTxHashes(txmgrNs)
+		if err != nil {
+			return err
+		}
+		stats.UnspentOutputs = len(unspent)
+		stats.UnminedTxs = len(unmined)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 // Balances records total, spendable (by policy), and immature coinbase
 // reward balance amounts.
 type Balances struct {
@@ -1578,6 +1647,21 @@ func (w *Wallet) CurrentAddress(account uint32, scope waddrmgr.KeyScope) (btcuti
 			return err
 		}
 
+		// The last address of a policy account is the address of the
+		// policy's witness script for the last key.
+		if scope == waddrmgr.KeyScopePolicy {
+			policyAddr, err := w.policyAddress(
+				addrmgrNs, manager, maddr,
+			)
+			if err != nil {
+				return err
+			}
+			maddr, err = w.Manager.Address(addrmgrNs, policyAddr)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Get next chained address if the last one has already been
 		// used.
 		if maddr.Used(addrmgrNs) {
@@ -2145,6 +2229,27 @@ func (w *Wallet) ListTransactions(from, count int) ([]btcjson.ListTransactionsRe
 	return txList, err
 }
 
+// ListTransactionDetails returns the listtransactions results of a single
+// transaction recorded by the wallet, or no results if the transaction is not
+// recorded.
+func (w *Wallet) ListTransactionDetails(txHash *chainhash.Hash) ([]btcjson.ListTransactionsResult, error) {
+	var txList []btcjson.ListTransactionsResult
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		details, err := w.TxStore.TxDetails(txmgrNs, txHash)
+		if err != nil || details == nil {
+			return err
+		}
+
+		syncBlock := w.Manager.SyncedTo()
+		txList = listTransactions(tx, details, w.Manager,
+			syncBlock.Height, w.chainParams)
+		return nil
+	})
+	return txList, err
+}
+
 // ListAddressTransactions returns a slice of objects with details about
 // recorded transactions to or from any address belonging to a set.  This is
 // intended to be used for listaddresstransactions RPC replies.
@@ -2981,6 +3086,16 @@ func (w *Wallet) newAddress(addrmgrNs walletdb.ReadWriteBucket, account uint32,
 		return nil, nil, err
 	}
 
+	// Addresses of policy accounts pay to the witness script of the
+	// account's policy, rather than to the wallet's key.
+	if scope == waddrmgr.KeyScopePolicy {
+		addr, err := w.policyAddress(addrmgrNs, manager, addrs[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return addr, props, nil
+	}
+
 	return addrs[0].Address(), props, nil
 }
 
@@ -3032,6 +3147,10 @@ func (w *Wallet) newChangeAddress(addrmgrNs walletdb.ReadWriteBucket,
 		return nil, err
 	}
 
+	if scope == waddrmgr.KeyScopePolicy {
+		return w.policyAddress(addrmgrNs, manager, addrs[0])
+	}
+
 	return addrs[0].Address(), nil
 }
 
@@ -3259,6 +3378,7 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
 
+		sigHashes := txscript.NewTxSigHashes(tx)
 		for i, txIn := range tx.TxIn {
 			prevOutScript, ok := additionalPrevScripts[txIn.PreviousOutPoint]
 			if !ok {
@@ -3330,15 +3450,62 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 				return sa.Script()
 			})
 
+			// Inputs spending witness scripts are signed using the
+			// amount of the previous output, which must be known by
+			// the wallet, and the script's spender if it has one.
+			var (
+				amount  int64
+				spender witnessSpender
+			)
+			witnessScript, nested := witnessScriptForOutput(
+				prevOutScript, w.chainParams, getScript,
+			)
+			if witnessScript != nil {
+				var err error
+				amount, err = w.prevOutAmount(
+					txmgrNs, txIn.PreviousOutPoint,
+				)
+				if err != nil {
+					signErrors = append(signErrors, SignatureError{
+						InputIndex: uint32(i),
+						Error:      err,
+					})
+					continue
+				}
+
+				scriptHash := sha256.Sum256(witnessScript)
+				spender, err = fetchWitnessSpender(
+					dbtx, scriptHash[:],
+				)
+				if err != nil {
+					return err
+				}
+			}
+
 			// SigHashSingle inputs can only be signed if there's a
 			// corresponding output. However this could be already signed,
 			// so we always verify the output.
 			if (hashType&txscript.SigHashSingle) !=
 				txscript.SigHashSingle || i < len(tx.TxOut) {
 
-				script, err := txscript.SignTxOutput(w.ChainParams(),
-					tx, i, prevOutScript, hashType, getKey,
-					getScript, txIn.SignatureScript)
+				var err error
+				if witnessScript != nil {
+					err = signWitnessScriptInput(
+						tx, i, sigHashes, amount,
+						witnessScript, spender, nested,
+						hashType, w.chainParams, getKey,
+					)
+				} else {
+					var script []byte
+					script, err = txscript.SignTxOutput(
+						w.ChainParams(), tx, i,
+						prevOutScript, hashType, getKey,
+						getScript, txIn.SignatureScript,
+					)
+					if err == nil {
+						txIn.SignatureScript = script
+					}
+				}
 				// Failure to sign isn't an error, it just means that
 				// the tx isn't complete.
 				if err != nil {
@@ -3348,13 +3515,12 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 					})
 					continue
 				}
-				txIn.SignatureScript = script
 			}
 
 			// Either it was already signed or we just signed it.
 			// Find out if it is completely satisfied or still needs more.
 			vm, err := txscript.NewEngine(prevOutScript, tx, i,
-				txscript.StandardVerifyFlags, nil, nil, 0)
+				txscript.StandardVerifyFlags, nil, sigHashes, amount)
 			if err == nil {
 				err = vm.Execute()
 			}
@@ -3408,6 +3574,25 @@ func (e *ErrReplacement) Unwrap() error {
 	return e.backendError
 }
 
+// ErrMempoolRejected is an error returned from PublishTransaction in case the
+// chain backend's mempool acceptance test rejected the transaction before it
+// was written to the wallet's transaction store or broadcast.
+type ErrMempoolRejected struct {
+	// TxID is the hash of the rejected transaction.
+	TxID chainhash.Hash
+
+	// Reason is the reject reason reported by the backend.
+	Reason string
+}
+
+// Error returns the string representation of ErrMempoolRejected.
+//
+// NOTE: Satisfies the error interface.
+func (e *ErrMempoolRejected) Error() string {
+	return fmt.Sprintf("transaction %v rejected from mempool: %v", e.TxID,
+		e.Reason)
+}
+
 // PublishTransaction sends the transaction to the consensus RPC server so it
 // can be propagated to other nodes and eventually mined.
 //
@@ -3431,6 +3616,14 @@ func (w *Wallet) reliablyPublishTransaction(tx *wire.MsgTx,
 		return nil, err
 	}
 
+	// Before touching the database, we'll make sure the backend would
+	// accept the transaction into its mempool. This prevents invalid
+	// transactions from being written to the store only to be removed
+	// again once the broadcast fails.
+	if err := w.testMempoolAccept(chainClient, tx); err != nil {
+		return nil, err
+	}
+
 	// As we aim for this to be general reliable transaction broadcast API,
 	// we'll write this tx to disk as an unconfirmed transaction. This way,
 	// upon restarts, we'll always rebroadcast it, and also add it to our
@@ -3496,6 +3689,74 @@ func (w *Wallet) reliablyPublishTransaction(tx *wire.MsgTx,
 	return w.publishTransaction(tx)
 }
 
+// testMempoolAccept asks the chain backend whether the transaction would be
+// accepted into its mempool. Rejections are mapped to ErrDoubleSpend,
+// ErrReplacement or ErrMempoolRejected, each of which carries the reject reason
+// reported by the backend. Transactions the backend already knows about are
+// not considered rejected, as broadcasting them again is harmless.
+func (w *Wallet) testMempoolAccept(chainClient chain.Interface,
+	tx *wire.MsgTx) error {
+
+	result, err := chainClient.TestMempoolAccept(tx)
+	if err != nil {
+		return err
+	}
+	if result.Allowed {
+		return nil
+	}
+
+	rejectErr := &ErrMempoolRejected{
+		TxID:   tx.TxHash(),
+		Reason: result.RejectReason,
+	}
+
+	// match is a helper method to easily string match on the reject
+	// reason.
+	match := func(s string) bool {
+		return strings.Contains(
+			strings.ToLower(result.RejectReason), s,
+		)
+	}
+
+	switch {
+	// The transaction is already in the mempool or the chain, which will
+	// be handled once it's broadcast.
+	case match("txn-already-in-mempool"), match("txn-already-known"),
+		match("already have transaction"),
+		match("transaction already exists"):
+
+		return nil
+
+	// The transaction spends outputs that are already spent, or spent by a
+	// non-replaceable transaction in the mempool.
+	case match("txn-mempool-conflict"), match("already spent"),
+		match("already been spent"):
+
+		return &ErrDoubleSpend{backendError: rejectErr}
+
+	// The transaction spends outputs the backend doesn't know about. These
+	// may just as well be outputs of a parent that hasn't been broadcast
+	// yet, so this is not reported as a double spend.
+	case match("missing-inputs"), match("missing inputs"),
+		match("bad-txns-inputs-missingorspent"),
+		match("orphan transaction"):
+
+		return rejectErr
+
+	// The transaction attempts to replace a transaction in the mempool
+	// but doesn't satisfy the replacement policy.
+	case match("bad-txns-spends-conflicting-tx"), match("insufficient fee"),
+		match("too many potential replacements"),
+		match("replacement-adds-unconfirmed"),
+		match("replacement transaction"):
+
+		return &ErrReplacement{backendError: rejectErr}
+
+	default:
+		return rejectErr
+	}
+}
+
 // publishTransaction attempts to send an unconfirmed transaction to the
 // wallet's current backend. In the event that sending the transaction fails for
 // whatever reason, it will be removed from the wallet's unconfirmed transaction
@@ -3791,6 +4052,28 @@ func create(db walletdb.DB, pubPass, privPass []byte,
 		if err != nil {
 			return err
 		}
+		_, err = tx.CreateTopLevelBucket(wrescanNamespaceKey)
+		if err != nil {
+			return err
+		}
+		invoiceNs, err := tx.CreateTopLevelBucket(winvoiceNamespaceKey)
+		if err != nil {
+			return err
+		}
+		if err := createInvoiceBuckets(invoiceNs); err != nil {
+			return err
+		}
+		_, err = tx.CreateTopLevelBucket(wscriptNamespaceKey)
+		if err != nil {
+			return err
+		}
+		policyNs, err := tx.CreateTopLevelBucket(wpolicyNamespaceKey)
+		if err != nil {
+			return err
+		}
+		if err := createPolicyBuckets(policyNs); err != nil {
+			return err
+		}
 
 		err = waddrmgr.Create(
 			addrmgrNs, rootKey, pubPass, privPass, params, nil,
@@ -3836,9 +4119,35 @@ func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 			return errors.New("missing transaction manager namespace")
 		}
 
+		// Wallets created before rescan jobs, invoices, script
+		// templates and account policies were persisted don't have
+		// their namespaces yet, so make sure they exist.
+		_, err := tx.CreateTopLevelBucket(wrescanNamespaceKey)
+		if err != nil {
+			return err
+		}
+		invoiceNs, err := tx.CreateTopLevelBucket(winvoiceNamespaceKey)
+		if err != nil {
+			return err
+		}
+		if err := createInvoiceBuckets(invoiceNs); err != nil {
+			return err
+		}
+		_, err = tx.CreateTopLevelBucket(wscriptNamespaceKey)
+		if err != nil {
+			return err
+		}
+		policyNs, err := tx.CreateTopLevelBucket(wpolicyNamespaceKey)
+		if err != nil {
+			return err
+		}
+		if err := createPolicyBuckets(policyNs); err != nil {
+			return err
+		}
+
 		addrMgrUpgrader := waddrmgr.NewMigrationManager(addrMgrBucket)
 		txMgrUpgrader := wtxmgr.NewMigrationManager(txMgrBucket)
-		err := migration.Upgrade(txMgrUpgrader, addrMgrUpgrader)
+		err = migration.Upgrade(txMgrUpgrader, addrMgrUpgrader)
 		if err != nil {
 			return err
 		}
@@ -3858,16 +4167,23 @@ func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 		return nil, err
 	}
 
+	dbEncrypted, err := cryptdb.IsEncrypted(db)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Infof("Opened wallet") // TODO: log balance? last sync height?
 
 	w := &Wallet{
 		publicPassphrase:    pubPass,
 		db:                  db,
+		dbEncrypted:         dbEncrypted,
 		Manager:             addrMgr,
 		TxStore:             txMgr,
 		lockedOutpoints:     map[wire.OutPoint]struct{}{},
 		recoveryWindow:      recoveryWindow,
 		rescanAddJob:        make(chan *RescanJob),
+		rescanControl:       make(chan *rescanControlRequest),
 		rescanBatch:         make(chan *rescanBatch),
 		rescanNotifications: make(chan interface{}),
 		rescanProgress:      make(chan *RescanProgressMsg),
@@ -2,9 +2,12 @@ package wallet
 
 import (
 	"encoding/hex"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/chain"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 
@@ -203,3 +206,124 @@ func TestLabelTransaction(t *testing.T) {
 		})
 	}
 }
+
+// rejectingChainClient is a mock chain client whose mempool rejects every
+// transaction with a fixed reason.
+type rejectingChainClient struct {
+	mockChainClient
+
+	rejectReason string
+}
+
+func (c *rejectingChainClient) TestMempoolAccept(tx *wire.MsgTx) (
+	*chain.MempoolAcceptResult, error) {
+
+	return &chain.MempoolAcceptResult{
+		TxID:         tx.TxHash(),
+		RejectReason: c.rejectReason,
+	}, nil
+}
+
+// TestPublishTransactionMempoolReject ensures that transactions rejected by the
+// backend's mempool acceptance test are never written to the transaction
+// store, and that the reject reason is surfaced through the returned error.
+func TestPublishTransactionMempoolReject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		rejectReason string
+		stored       bool
+		checkErr     func(error) bool
+	}{
+		{
+			name:         "double spend",
+			rejectReason: "txn-mempool-conflict",
+			checkErr: func(err error) bool {
+				var target *ErrDoubleSpend
+				return errors.As(err, &target)
+			},
+		},
+		{
+			name:         "replacement",
+			rejectReason: "insufficient fee",
+			checkErr: func(err error) bool {
+				var target *ErrReplacement
+				return errors.As(err, &target)
+			},
+		},
+		{
+			name:         "missing inputs",
+			rejectReason: "missing-inputs",
+			checkErr: func(err error) bool {
+				var target *ErrDoubleSpend
+				return !errors.As(err, &target)
+			},
+		},
+		{
+			name:         "other policy",
+			rejectReason: "dust",
+			checkErr: func(err error) bool {
+				return true
+			},
+		},
+		{
+			name:         "already in mempool",
+			rejectReason: "txn-already-in-mempool",
+			stored:       true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, cleanup := testWallet(t)
+			defer cleanup()
+
+			w.chainClient = &rejectingChainClient{
+				rejectReason: test.rejectReason,
+			}
+
+			err := w.PublishTransaction(TstTx.MsgTx(), "")
+			if test.stored {
+				if err != nil {
+					t.Fatalf("unable to publish tx: %v", err)
+				}
+			} else {
+				var rejectErr *ErrMempoolRejected
+				if !errors.As(err, &rejectErr) {
+					t.Fatalf("expected ErrMempoolRejected, "+
+						"got: %v", err)
+				}
+				if rejectErr.Reason != test.rejectReason {
+					t.Fatalf("expected reason %q, got %q",
+						test.rejectReason,
+						rejectErr.Reason)
+				}
+				if !test.checkErr(err) {
+					t.Fatalf("unexpected error type: %T",
+						err)
+				}
+			}
+
+			var details *wtxmgr.TxDetails
+			err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+				ns := tx.ReadBucket(wtxmgrNamespaceKey)
+
+				var err error
+				details, err = w.TxStore.TxDetails(ns, TstTxHash)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("unable to fetch tx details: %v", err)
+			}
+			if test.stored != (details != nil) {
+				t.Fatalf("expected tx stored=%v, got %v",
+					test.stored, details != nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// copyVersion is the version of the serialization written by Copy.
+const copyVersion = 1
+
+var (
+	// copyMagic identifies a copy of a memdb database.
+	copyMagic = []byte("memdb")
+
+	// errInvalidCopy is returned when importing data that isn't a valid
+	// copy of a memdb database.
+	errInvalidCopy = errors.New("invalid memdb database copy")
+)
+
+const (
+	entryValue  byte = 0
+	entryBucket byte = 1
+)
+
+// writeCopy serializes the tree of buckets under root.
+//
+// The copy starts with copyMagic and copyVersion, followed by the root bucket.
+// A bucket is serialized as its sequence and number of entries, each as a
+// varint, followed by its entries in key order.  An entry is its key, prefixed
+// by its length, and either entryValue and the value, prefixed by its length,
+// or entryBucket and the serialized nested bucket.
+func writeCopy(w io.Writer, root *node) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(copyMagic)
+	bw.WriteByte(copyVersion)
+
+	var buf [binary.MaxVarintLen64]byte
+	writeUvarint := func(v uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], v)])
+	}
+
+	var writeNode func(n *node)
+	writeNode = func(n *node) {
+		writeUvarint(n.seq)
+		writeUvarint(uint64(len(n.entries)))
+		for _, e := range n.entries {
+			writeUvarint(uint64(len(e.key)))
+			bw.Write(e.key)
+			if e.child != nil {
+				bw.WriteByte(entryBucket)
+				writeNode(e.child)
+				continue
+			}
+			bw.WriteByte(entryValue)
+			writeUvarint(uint64(len(e.value)))
+			bw.Write(e.value)
+		}
+	}
+	writeNode(root)
+
+	// Write errors are sticky, so they're all returned by Flush.
+	return bw.Flush()
+}
+
+// readCopy deserializes a tree of buckets written by writeCopy.
+func readCopy(r io.Reader) (*node, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(copyMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(copyMagic)], copyMagic) {
+		return nil, errInvalidCopy
+	}
+	if version := header[len(copyMagic)]; version != copyVersion {
+		return nil, fmt.Errorf("unsupported memdb database copy "+
+			"version %d", version)
+	}
+
+	readBytes := func(max uint64) ([]byte, error) {
+		l, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if l > max {
+			return nil, errInvalidCopy
+		}
+		b := make([]byte, l)
+		_, err = io.ReadFull(br, b)
+		return b, err
+	}
+
+	var readNode func() (*node, error)
+	readNode = func() (*node, error) {
+		seq, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		count, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+
+		n := &node{seq: seq}
+		for i := uint64(0); i < count; i++ {
+			key, err := readBytes(maxKeySize)
+			if err != nil {
+				return nil, err
+			}
+			if len(key) == 0 || (len(n.entries) > 0 && bytes.Compare(
+				n.entries[len(n.entries)-1].key, key) >= 0) {
+
+				return nil, errInvalidCopy
+			}
+
+			kind, err := br.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			e := entry{key: key}
+			switch kind {
+			case entryValue:
+				e.value, err = readBytes(maxValueSize)
+			case entryBucket:
+				e.child, err = readNode()
+			default:
+				err = errInvalidCopy
+			}
+			if err != nil {
+				return nil, err
+			}
+			n.entries = append(n.entries, e)
+		}
+		return n, nil
+	}
+
+	root, err := readNode()
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = errInvalidCopy
+	}
+	return root, err
+}
@@ -0,0 +1,829 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+const (
+	// maxKeySize is the maximum length of a key, matching the limit of
+	// the bdb driver.
+	maxKeySize = 32768
+
+	// maxValueSize is the maximum length of a value, matching the limit
+	// of the bdb driver.
+	maxValueSize = (1 << 31) - 2
+)
+
+// node is the content of a bucket.  Nodes reachable from a committed root are
+// never modified: a read-write transaction copies every node it modifies, and
+// the nodes on the path to it, so read transactions keep a consistent
+// snapshot of the database.
+type node struct {
+	// entries are the keys of the bucket, sorted bytewise.
+	entries []entry
+	seq     uint64
+}
+
+// entry is a key of a bucket, holding either a value or a nested bucket.
+type entry struct {
+	key   []byte
+	value []byte
+	child *node
+}
+
+// search returns the index of the first entry whose key is not less than key,
+// and whether the key was found at that index.
+func (n *node) search(key []byte) (int, bool) {
+	i := sort.Search(len(n.entries), func(i int) bool {
+		return bytes.Compare(n.entries[i].key, key) >= 0
+	})
+	return i, i < len(n.entries) && bytes.Equal(n.entries[i].key, key)
+}
+
+// clone returns a shallow copy of the node.  The keys, values and nested
+// buckets are shared, as they're never modified in place.
+func (n *node) clone() *node {
+	entries := make([]entry, len(n.entries))
+	copy(entries, n.entries)
+	return &node{entries: entries, seq: n.seq}
+}
+
+// transaction represents a database transaction.  It can either be read-only
+// or read-write and implements the walletdb Tx interfaces.
+type transaction struct {
+	db       *db
+	root     *node
+	writable bool
+	closed   bool
+
+	// owned holds the nodes copied by a read-write transaction, which it
+	// may modify in place.
+	owned map[*node]struct{}
+
+	onCommit []func()
+}
+
+// Enforce transaction implements the walletdb transaction interfaces.
+var _ walletdb.ReadWriteTx = (*transaction)(nil)
+
+func (tx *transaction) rootBucket() *bucket {
+	return &bucket{tx: tx}
+}
+
+// ReadBucket opens the root bucket for read only access.  If the bucket
+// described by the key does not exist, nil is returned.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) ReadBucket(key []byte) walletdb.ReadBucket {
+	return tx.ReadWriteBucket(key)
+}
+
+// ForEachBucket will iterate through all top level buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) ForEachBucket(fn func(key []byte) error) error {
+	return tx.rootBucket().ForEach(func(k, _ []byte) error {
+		return fn(k)
+	})
+}
+
+// ReadWriteBucket opens the root bucket for read/write access.  If the bucket
+// described by the key does not exist, nil is returned.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) ReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	return tx.rootBucket().NestedReadWriteBucket(key)
+}
+
+// CreateTopLevelBucket creates the top level bucket for a key if it does not
+// exist.  The newly-created bucket it returned.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) CreateTopLevelBucket(key []byte) (
+	walletdb.ReadWriteBucket, error) {
+
+	return tx.rootBucket().CreateBucketIfNotExists(key)
+}
+
+// DeleteTopLevelBucket deletes the top level bucket for a key.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) DeleteTopLevelBucket(key []byte) error {
+	return tx.rootBucket().DeleteNestedBucket(key)
+}
+
+// close releases the write lock of a read-write transaction once it is
+// committed or rolled back.
+func (tx *transaction) close() {
+	tx.closed = true
+	tx.root = nil
+	tx.owned = nil
+	if tx.writable {
+		tx.db.writeMtx.Unlock()
+	}
+}
+
+// Commit commits all changes that have been made through the root bucket and
+// all of its sub-buckets, making them visible to new transactions.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) Commit() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	if !tx.writable {
+		return walletdb.ErrTxNotWritable
+	}
+
+	tx.db.mtx.Lock()
+	tx.db.root = tx.root
+	tx.db.mtx.Unlock()
+	tx.close()
+
+	for _, f := range tx.onCommit {
+		f()
+	}
+	return nil
+}
+
+// Rollback undoes all changes that have been made to the root bucket and all
+// of its sub-buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) Rollback() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	tx.close()
+	return nil
+}
+
+// OnCommit takes a function closure that will be executed when the
+// transaction successfully gets committed.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) OnCommit(f func()) {
+	tx.onCommit = append(tx.onCommit, f)
+}
+
+// bucket is an internal type used to represent a collection of key/value pairs
+// and implements the walletdb Bucket interfaces.
+//
+// A bucket is identified by its key in its parent bucket rather than by its
+// node, as the node is replaced when the bucket is first modified by a
+// read-write transaction.
+type bucket struct {
+	tx     *transaction
+	parent *bucket
+	key    []byte
+}
+
+// Enforce bucket implements the walletdb Bucket interfaces.
+var _ walletdb.ReadWriteBucket = (*bucket)(nil)
+
+// node returns the current node of the bucket, or nil if the bucket has been
+// deleted or the transaction is closed.
+func (b *bucket) node() *node {
+	if b.tx.closed {
+		return nil
+	}
+	if b.parent == nil {
+		return b.tx.root
+	}
+	parent := b.parent.node()
+	if parent == nil {
+		return nil
+	}
+	i, ok := parent.search(b.key)
+	if !ok {
+		return nil
+	}
+	return parent.entries[i].child
+}
+
+// writableNode returns the node of the bucket which the transaction may modify
+// in place, copying it and the nodes of its parent buckets if they're shared
+// with committed transactions.
+func (b *bucket) writableNode() (*node, error) {
+	switch {
+	case b.tx.closed:
+		return nil, walletdb.ErrTxClosed
+	case !b.tx.writable:
+		return nil, walletdb.ErrTxNotWritable
+	}
+
+	if b.parent == nil {
+		if _, ok := b.tx.owned[b.tx.root]; !ok {
+			b.tx.root = b.tx.root.clone()
+			b.tx.owned[b.tx.root] = struct{}{}
+		}
+		return b.tx.root, nil
+	}
+
+	parent, err := b.parent.writableNode()
+	if err != nil {
+		return nil, err
+	}
+	i, ok := parent.search(b.key)
+	if !ok || parent.entries[i].child == nil {
+		return nil, walletdb.ErrBucketNotFound
+	}
+	n := parent.entries[i].child
+	if _, ok := b.tx.owned[n]; !ok {
+		n = n.clone()
+		b.tx.owned[n] = struct{}{}
+		parent.entries[i].child = n
+	}
+	return n, nil
+}
+
+// nested returns the handle of a nested bucket.
+func (b *bucket) nested(key []byte) *bucket {
+	return &bucket{
+		tx:     b.tx,
+		parent: b,
+		key:    append([]byte{}, key...),
+	}
+}
+
+// NestedReadWriteBucket retrieves a nested bucket with the given key.  Returns
+// nil if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) NestedReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+	i, ok := n.search(key)
+	// Don't return a non-nil interface to a nil pointer.
+	if !ok || n.entries[i].child == nil {
+		return nil
+	}
+	return b.nested(key)
+}
+
+// NestedReadBucket retrieves a nested bucket with the given key.  Returns nil
+// if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) NestedReadBucket(key []byte) walletdb.ReadBucket {
+	return b.NestedReadWriteBucket(key)
+}
+
+// createBucket creates a nested bucket, returning the existing bucket instead
+// if mayExist is set.
+func (b *bucket) createBucket(key []byte, mayExist bool) (
+	walletdb.ReadWriteBucket, error) {
+
+	n, err := b.writableNode()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+
+	i, ok := n.search(key)
+	switch {
+	case ok && n.entries[i].child == nil:
+		return nil, walletdb.ErrIncompatibleValue
+	case ok && !mayExist:
+		return nil, walletdb.ErrBucketExists
+	case ok:
+		return b.nested(key), nil
+	}
+
+	child := &node{}
+	b.tx.owned[child] = struct{}{}
+	n.entries = append(n.entries, entry{})
+	copy(n.entries[i+1:], n.entries[i:])
+	n.entries[i] = entry{key: append([]byte{}, key...), child: child}
+	return b.nested(key), nil
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+// Returns ErrBucketExists if the bucket already exists, ErrBucketNameRequired
+// if the key is empty, or ErrIncompatibleValue if the key is a value.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) CreateBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	return b.createBucket(key, false)
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.  Returns ErrBucketNameRequired if
+// the key is empty or ErrIncompatibleValue if the key is a value.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) CreateBucketIfNotExists(key []byte) (
+	walletdb.ReadWriteBucket, error) {
+
+	return b.createBucket(key, true)
+}
+
+// DeleteNestedBucket removes a nested bucket with the given key.  Returns
+// ErrTxNotWritable if attempted against a read-only transaction and
+// ErrBucketNotFound if the specified bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) DeleteNestedBucket(key []byte) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return walletdb.ErrIncompatibleValue
+	}
+
+	i, ok := n.search(key)
+	switch {
+	case !ok:
+		return walletdb.ErrBucketNotFound
+	case n.entries[i].child == nil:
+		return walletdb.ErrIncompatibleValue
+	}
+	n.entries = append(n.entries[:i], n.entries[i+1:]...)
+	return nil
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket.
+// This includes nested buckets, in which case the value is nil, but it does not
+// include the key/value pairs within those nested buckets.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put saves the specified key/value pair to the bucket.  Keys that do not
+// already exist are added and keys that already exist are overwritten.  Returns
+// ErrTxNotWritable if attempted against a read-only transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Put(key, value []byte) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(key) == 0:
+		return walletdb.ErrKeyRequired
+	case len(key) > maxKeySize:
+		return walletdb.ErrKeyTooLarge
+	case len(value) > maxValueSize:
+		return walletdb.ErrValueTooLarge
+	}
+
+	// The value is copied, as the caller may reuse it.
+	value = append([]byte{}, value...)
+
+	i, ok := n.search(key)
+	switch {
+	case ok && n.entries[i].child != nil:
+		return walletdb.ErrIncompatibleValue
+	case ok:
+		n.entries[i].value = value
+		return nil
+	}
+	n.entries = append(n.entries, entry{})
+	copy(n.entries[i+1:], n.entries[i:])
+	n.entries[i] = entry{key: append([]byte{}, key...), value: value}
+	return nil
+}
+
+// Get returns the value for the given key.  Returns nil if the key does not
+// exist in this bucket, or is a nested bucket.
+//
+// NOTE: The value returned by this function must not be modified.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) Get(key []byte) []byte {
+	n := b.node()
+	if n == nil {
+		return nil
+	}
+	i, ok := n.search(key)
+	if !ok {
+		return nil
+	}
+	return n.entries[i].value
+}
+
+// Delete removes the specified key from the bucket.  Deleting a key that does
+// not exist does not return an error.  Returns ErrTxNotWritable if attempted
+// against a read-only transaction, and ErrIncompatibleValue if the key is a
+// nested bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Delete(key []byte) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+
+	i, ok := n.search(key)
+	switch {
+	case !ok:
+		return nil
+	case n.entries[i].child != nil:
+		return walletdb.ErrIncompatibleValue
+	}
+	n.entries = append(n.entries[:i], n.entries[i+1:]...)
+	return nil
+}
+
+func (b *bucket) cursor() *cursor {
+	return &cursor{bucket: b}
+}
+
+// ReadCursor returns a new cursor, allowing for iteration over the bucket's
+// key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ReadCursor() walletdb.ReadCursor {
+	return b.cursor()
+}
+
+// ReadWriteCursor returns a new cursor, allowing for iteration over the
+// bucket's key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) ReadWriteCursor() walletdb.ReadWriteCursor {
+	return b.cursor()
+}
+
+// Tx returns the bucket's transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Tx() walletdb.ReadWriteTx {
+	return b.tx
+}
+
+// NextSequence returns an autoincrementing integer for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) NextSequence() (uint64, error) {
+	n, err := b.writableNode()
+	if err != nil {
+		return 0, err
+	}
+	n.seq++
+	return n.seq, nil
+}
+
+// SetSequence updates the sequence number for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) SetSequence(v uint64) error {
+	n, err := b.writableNode()
+	if err != nil {
+		return err
+	}
+	n.seq = v
+	return nil
+}
+
+// Sequence returns the current integer for the bucket without incrementing it.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Sequence() uint64 {
+	n := b.node()
+	if n == nil {
+		return 0
+	}
+	return n.seq
+}
+
+// cursor represents a cursor over key/value pairs and nested buckets of a
+// bucket.
+//
+// The cursor only remembers the key it is positioned at, and every move
+// searches the key following or preceding it, so the cursor remains valid when
+// the bucket is modified.
+type cursor struct {
+	bucket *bucket
+
+	// key is the key the cursor is positioned at.  It is nil if the
+	// cursor isn't positioned.
+	key []byte
+}
+
+// Enforce cursor implements the walletdb cursor interfaces.
+var _ walletdb.ReadWriteCursor = (*cursor)(nil)
+
+// moveTo positions the cursor at the entry at index i of the bucket's node,
+// and returns the key/value pair.  The cursor isn't moved if the index is out
+// of range.
+func (c *cursor) moveTo(n *node, i int) (key, value []byte) {
+	if i < 0 || i >= len(n.entries) {
+		return nil, nil
+	}
+	e := &n.entries[i]
+	c.key = e.key
+	return e.key, e.value
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor.  Returns ErrTxNotWritable if attempted on a
+// read-only transaction, or ErrIncompatibleValue if attempted when the cursor
+// points to a nested bucket.
+//
+// This function is part of the walletdb.ReadWriteCursor interface
+// implementation.
+func (c *cursor) Delete() error {
+	if _, err := c.bucket.writableNode(); err != nil {
+		return err
+	}
+	if c.key == nil {
+		return nil
+	}
+	return c.bucket.Delete(c.key)
+}
+
+// First positions the cursor at the first key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) First() (key, value []byte) {
+	c.key = nil
+	n := c.bucket.node()
+	if n == nil {
+		return nil, nil
+	}
+	return c.moveTo(n, 0)
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Last() (key, value []byte) {
+	c.key = nil
+	n := c.bucket.node()
+	if n == nil {
+		return nil, nil
+	}
+	return c.moveTo(n, len(n.entries)-1)
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Next() (key, value []byte) {
+	n := c.bucket.node()
+	if n == nil || c.key == nil {
+		return nil, nil
+	}
+	i, ok := n.search(c.key)
+	if ok {
+		i++
+	}
+	return c.moveTo(n, i)
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Prev() (key, value []byte) {
+	n := c.bucket.node()
+	if n == nil || c.key == nil {
+		return nil, nil
+	}
+	i, _ := n.search(c.key)
+	return c.moveTo(n, i-1)
+}
+
+// Seek positions the cursor at the passed seek key.  If the key does not
+// exist, the cursor is moved to the next key after seek.  Returns the new
+// pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Seek(seek []byte) (key, value []byte) {
+	n := c.bucket.node()
+	if n == nil {
+		return nil, nil
+	}
+
+	// A cursor seeking past the last key is positioned at the seek key,
+	// so moving it backward returns the last key.
+	c.key = append([]byte{}, seek...)
+	i, _ := n.search(seek)
+	return c.moveTo(n, i)
+}
+
+// db represents a collection of namespaces which are kept in memory and
+// implements the walletdb.DB interface.
+//
+// Read transactions see the root committed when they began, and are never
+// blocked.  Read-write transactions are serialized by writeMtx.
+type db struct {
+	mtx    sync.Mutex // Protects root and closed.
+	root   *node
+	closed bool
+
+	writeMtx sync.Mutex
+}
+
+// Enforce db implements the walletdb.DB interface.
+var _ walletdb.DB = (*db)(nil)
+
+// committedRoot returns the root of the last committed transaction.
+func (db *db) committedRoot() (*node, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return nil, walletdb.ErrDbNotOpen
+	}
+	return db.root, nil
+}
+
+// BeginReadTx opens a database read transaction.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) BeginReadTx() (walletdb.ReadTx, error) {
+	root, err := db.committedRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{db: db, root: root}, nil
+}
+
+// BeginReadWriteTx opens a database read+write transaction.  It blocks until
+// every other read+write transaction is committed or rolled back.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	db.writeMtx.Lock()
+	root, err := db.committedRoot()
+	if err != nil {
+		db.writeMtx.Unlock()
+		return nil, err
+	}
+	return &transaction{
+		db:       db,
+		root:     root,
+		writable: true,
+		owned:    make(map[*node]struct{}),
+	}, nil
+}
+
+// Copy writes a copy of the database to the provided writer.  The copy can be
+// imported by opening a memdb database with a reader of it.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) Copy(w io.Writer) error {
+	root, err := db.committedRoot()
+	if err != nil {
+		return err
+	}
+	return writeCopy(w, root)
+}
+
+// Close discards the database.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) Close() error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return walletdb.ErrDbNotOpen
+	}
+	db.closed = true
+	db.root = nil
+	return nil
+}
+
+// PrintStats returns all collected stats pretty printed into a string.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) PrintStats() string {
+	root, err := db.committedRoot()
+	if err != nil {
+		return err.Error()
+	}
+
+	var buckets, keys int
+	var count func(n *node)
+	count = func(n *node) {
+		buckets++
+		for _, e := range n.entries {
+			if e.child != nil {
+				count(e.child)
+			} else {
+				keys++
+			}
+		}
+	}
+	count(root)
+	return fmt.Sprintf("%d buckets, %d keys", buckets-1, keys)
+}
+
+// View opens a database read transaction and executes the function f with the
+// transaction passed as a parameter. After f exits, the transaction is rolled
+// back. If f errors, its error is returned, not a rollback error (if any
+// occur). The passed reset function is called before the start of the
+// transaction and can be used to reset intermediate state. As callers may
+// expect retries of the f closure (depending on the database backend used), the
+// reset function will be called before each retry respectively.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) View(f func(tx walletdb.ReadTx) error, reset func()) error {
+	// Transactions are never retried, so the reset function is only
+	// called once.
+	reset()
+
+	tx, err := db.BeginReadTx()
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.(*transaction).closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	rollbackErr := tx.Rollback()
+	if err != nil {
+		return err
+	}
+	return rollbackErr
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with the transaction passed as a parameter. After f exits, if f did not
+// error, the transaction is committed. Otherwise, if f did error, the
+// transaction is rolled back. If the rollback fails, the original error
+// returned by f is still returned. If the commit fails, the commit error is
+// returned. As callers may expect retries of the f closure (depending on the
+// database backend used), the reset function will be called before each retry
+// respectively.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) Update(f func(tx walletdb.ReadWriteTx) error,
+	reset func()) error {
+
+	// Transactions are never retried, so the reset function is only
+	// called once.
+	reset()
+
+	tx, err := db.BeginReadWriteTx()
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.(*transaction).closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	if err != nil {
+		// Want to return the original error, not a rollback error if
+		// any occur.
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// newDB returns a database with the provided root, which is empty if nil.
+func newDB(root *node) *db {
+	if root == nil {
+		root = &node{}
+	}
+	return &db{root: root}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package memdb implements an instance of walletdb that keeps the database in
+memory.
+
+The database is a tree of copy-on-write buckets: a read-write transaction
+copies every bucket it modifies, so read transactions see a snapshot of the
+database as of when they began and are never blocked.  Read-write transactions
+are serialized.  Nothing is written to disk, which makes the driver suited to
+tests and short-lived wallets, such as signing-only wallets.
+
+Usage
+
+This package is only a driver to the walletdb package and provides the database
+type of "memdb".  The Create function takes no parameters and creates an empty
+database:
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		// Handle error
+	}
+
+A database can be exported with Copy, and imported by passing a reader of the
+copy as the only parameter of the Open function:
+
+	var buf bytes.Buffer
+	if err := db.Copy(&buf); err != nil {
+		// Handle error
+	}
+
+	db, err := walletdb.Open("memdb", &buf)
+	if err != nil {
+		// Handle error
+	}
+*/
+package memdb
@@ -0,0 +1,61 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+const (
+	dbType = "memdb"
+)
+
+// openDBDriver is the callback provided during driver registration that
+// imports a copy of a database written by Copy.
+func openDBDriver(args ...interface{}) (walletdb.DB, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("invalid arguments to %s.Open -- "+
+			"expected database copy reader", dbType)
+	}
+
+	r, ok := args[0].(io.Reader)
+	if !ok {
+		return nil, fmt.Errorf("first argument to %s.Open is invalid "+
+			"-- expected database copy io.Reader", dbType)
+	}
+
+	root, err := readCopy(r)
+	if err != nil {
+		return nil, err
+	}
+	return newDB(root), nil
+}
+
+// createDBDriver is the callback provided during driver registration that
+// creates an empty database.
+func createDBDriver(args ...interface{}) (walletdb.DB, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("invalid arguments to %s.Create -- "+
+			"expected no arguments", dbType)
+	}
+
+	return newDB(nil), nil
+}
+
+func init() {
+	// Register the driver.
+	driver := walletdb.Driver{
+		DbType: dbType,
+		Create: createDBDriver,
+		Open:   openDBDriver,
+	}
+	if err := walletdb.RegisterDriver(driver); err != nil {
+		panic(fmt.Sprintf("Failed to regiser database driver '%s': %v",
+			dbType, err))
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// dbType is the database type name for this driver.
+const dbType = "memdb"
+
+// TestCreateOpenFail ensures that errors related to creating and opening a
+// database are handled properly.
+func TestCreateOpenFail(t *testing.T) {
+	// Ensure that attempting to open a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr := fmt.Errorf("invalid arguments to %s.Open -- expected "+
+		"database copy reader", dbType)
+	if _, err := walletdb.Open(dbType); err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with an invalid type for
+	// the first parameter returns the expected error.
+	wantErr = fmt.Errorf("first argument to %s.Open is invalid -- "+
+		"expected database copy io.Reader", dbType)
+	if _, err := walletdb.Open(dbType, 1); err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open something other than a copy of a
+	// database returns an error.
+	invalidCopies := []string{
+		"", "memdb", "notmemdb", "memdb\x02", "memdb\x01\x00\x01",
+	}
+	for _, data := range invalidCopies {
+		_, err := walletdb.Open(dbType, strings.NewReader(data))
+		if err == nil {
+			t.Errorf("Open: expected error importing %q", data)
+			return
+		}
+	}
+
+	// Ensure that attempting to create a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr = fmt.Errorf("invalid arguments to %s.Create -- expected "+
+		"no arguments", dbType)
+	if _, err := walletdb.Create(dbType, 1); err.Error() != wantErr.Error() {
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure operations against a closed database return the expected
+	// error.
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Errorf("Create: unexpected error: %v", err)
+		return
+	}
+	db.Close()
+
+	wantErr = walletdb.ErrDbNotOpen
+	if _, err := db.BeginReadTx(); err != wantErr {
+		t.Errorf("BeginReadTx: did not receive expected error - got "+
+			"%v, want %v", err, wantErr)
+		return
+	}
+	if _, err := db.BeginReadWriteTx(); err != wantErr {
+		t.Errorf("BeginReadWriteTx: did not receive expected error - "+
+			"got %v, want %v", err, wantErr)
+		return
+	}
+	if err := db.Copy(&bytes.Buffer{}); err != wantErr {
+		t.Errorf("Copy: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+}
+
+// TestCopy ensures that values, nested buckets and sequences are imported
+// from a copy of the database, and that the imported database is independent
+// of the original one.
+func TestCopy(t *testing.T) {
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer db.Close()
+
+	// Create a namespace with a nested bucket and put some values into
+	// them so they can be tested for existence in the copy.
+	storeValues := map[string]string{
+		"ns1key1": "foo1",
+		"ns1key2": "foo2",
+		"ns1key3": "",
+	}
+	ns1Key := []byte("ns1")
+	nestedKey := []byte("nested")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns1, err := tx.CreateTopLevelBucket(ns1Key)
+		if err != nil {
+			return err
+		}
+		nested, err := ns1.CreateBucket(nestedKey)
+		if err != nil {
+			return err
+		}
+		if err := nested.SetSequence(42); err != nil {
+			return err
+		}
+
+		for _, b := range []walletdb.ReadWriteBucket{ns1, nested} {
+			for k, v := range storeValues {
+				err := b.Put([]byte(k), []byte(v))
+				if err != nil {
+					return fmt.Errorf("Put: unexpected "+
+						"error: %v", err)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ns1 Update: unexpected error: %v", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := db.Copy(&buf); err != nil {
+		t.Errorf("Copy: unexpected error: %v", err)
+		return
+	}
+	copyDB, err := walletdb.Open(dbType, &buf)
+	if err != nil {
+		t.Errorf("Failed to import database copy (%s) %v", dbType, err)
+		return
+	}
+	defer copyDB.Close()
+
+	// Modifying the original database must not affect the copy.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		return tx.DeleteTopLevelBucket(ns1Key)
+	})
+	if err != nil {
+		t.Errorf("ns1 Update: unexpected error: %v", err)
+		return
+	}
+
+	err = walletdb.View(copyDB, func(tx walletdb.ReadTx) error {
+		ns1 := tx.ReadBucket(ns1Key)
+		if ns1 == nil {
+			return fmt.Errorf("ReadTx.ReadBucket: unexpected nil " +
+				"root bucket")
+		}
+		nested := ns1.NestedReadBucket(nestedKey)
+		if nested == nil {
+			return fmt.Errorf("NestedReadBucket: unexpected nil " +
+				"bucket")
+		}
+		seq := nested.(walletdb.ReadWriteBucket).Sequence()
+		if seq != 42 {
+			return fmt.Errorf("Sequence: got %d, want 42", seq)
+		}
+
+		for _, b := range []walletdb.ReadBucket{ns1, nested} {
+			for k, v := range storeValues {
+				gotVal := b.Get([]byte(k))
+				if !reflect.DeepEqual(gotVal, []byte(v)) {
+					return fmt.Errorf("Get: key '%s' does "+
+						"not match expected value - "+
+						"got %s, want %s", k, gotVal, v)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("copy View: unexpected error: %v", err)
+	}
+}
+
+// TestSnapshotIsolation ensures that read transactions aren't blocked by a
+// read-write transaction and don't see changes committed after they began.
+func TestSnapshotIsolation(t *testing.T) {
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer db.Close()
+
+	nsKey := []byte("ns")
+	key := []byte("key")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(nsKey)
+		if err != nil {
+			return err
+		}
+		return ns.Put(key, []byte("old"))
+	})
+	if err != nil {
+		t.Errorf("Update: unexpected error: %v", err)
+		return
+	}
+
+	readTx, err := db.BeginReadTx()
+	if err != nil {
+		t.Errorf("BeginReadTx: unexpected error: %v", err)
+		return
+	}
+	defer readTx.Rollback()
+	ns := readTx.ReadBucket(nsKey)
+
+	writeTx, err := db.BeginReadWriteTx()
+	if err != nil {
+		t.Errorf("BeginReadWriteTx: unexpected error: %v", err)
+		return
+	}
+	err = writeTx.ReadWriteBucket(nsKey).Put(key, []byte("new"))
+	if err != nil {
+		t.Errorf("Put: unexpected error: %v", err)
+		return
+	}
+	if _, err := writeTx.CreateTopLevelBucket([]byte("ns2")); err != nil {
+		t.Errorf("CreateTopLevelBucket: unexpected error: %v", err)
+		return
+	}
+
+	// A read transaction can begin while the write transaction is open,
+	// and doesn't see its uncommitted changes.
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		if v := tx.ReadBucket(nsKey).Get(key); string(v) != "old" {
+			return fmt.Errorf("Get: got %s, want old", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("View: unexpected error: %v", err)
+		return
+	}
+
+	if err := writeTx.Commit(); err != nil {
+		t.Errorf("Commit: unexpected error: %v", err)
+		return
+	}
+
+	// The read transaction which began before the commit still sees the
+	// old snapshot, while new transactions see the changes.
+	if v := ns.Get(key); string(v) != "old" {
+		t.Errorf("Get: got %s, want old", v)
+		return
+	}
+	if readTx.ReadBucket([]byte("ns2")) != nil {
+		t.Error("ReadBucket: bucket created after the transaction " +
+			"began is visible")
+		return
+	}
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		if v := tx.ReadBucket(nsKey).Get(key); string(v) != "new" {
+			return fmt.Errorf("Get: got %s, want new", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("View: unexpected error: %v", err)
+	}
+}
+
+// TestCursor ensures that cursors iterate over the keys of a bucket in order,
+// and that deleting the current key doesn't invalidate the cursor.
+func TestCursor(t *testing.T) {
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer db.Close()
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket([]byte("ns"))
+		if err != nil {
+			return err
+		}
+
+		keys := [][]byte{
+			{0x01}, {0x01, 0x00}, {0x02}, {0x7f}, {0xff},
+		}
+		for i := len(keys) - 1; i >= 0; i-- {
+			if err := ns.Put(keys[i], keys[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := ns.CreateBucket([]byte{0x03}); err != nil {
+			return err
+		}
+
+		// Delete every other key while iterating.
+		c := ns.ReadWriteCursor()
+		var got [][]byte
+		i := 0
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			got = append(got, k)
+			if i%2 == 0 && !bytes.Equal(k, []byte{0x03}) {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+			i++
+		}
+		want := [][]byte{
+			{0x01}, {0x01, 0x00}, {0x02}, {0x03}, {0x7f}, {0xff},
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("iterated keys %x, want %x", got,
+				want)
+		}
+
+		var remaining [][]byte
+		for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+			remaining = append(remaining, k)
+		}
+		want = [][]byte{{0xff}, {0x03}, {0x01, 0x00}}
+		if !reflect.DeepEqual(remaining, want) {
+			return fmt.Errorf("remaining keys %x, want %x",
+				remaining, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Update: unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// ChangeKind describes how a key of a namespace is changed by a migration.
+type ChangeKind uint8
+
+const (
+	// KeyAdded indicates that a key or nested bucket was added.
+	KeyAdded ChangeKind = iota
+
+	// KeyDeleted indicates that a key or nested bucket was deleted.
+	KeyDeleted
+
+	// KeyModified indicates that the value of a key was modified, or that
+	// a key was replaced by a nested bucket or vice versa.
+	KeyModified
+)
+
+// String returns a human-readable string of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case KeyAdded:
+		return "added"
+	case KeyDeleted:
+		return "deleted"
+	case KeyModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a change to a key of a service's namespace.
+type Change struct {
+	// Buckets is the path of nested buckets within the namespace holding
+	// the key.
+	Buckets [][]byte
+
+	// Key is the changed key, which is the name of a nested bucket if
+	// Bucket is set.
+	Key []byte
+
+	// Bucket indicates that the key is the name of a nested bucket, in
+	// which case the changes to its keys are reported separately.
+	Bucket bool
+
+	// Kind describes how the key was changed.
+	Kind ChangeKind
+}
+
+// Report describes the migrations of a service applied by a dry run.
+type Report struct {
+	// Name is the name of the service.
+	Name string
+
+	// CurrentVersion is the version of the service's database before the
+	// migrations.
+	CurrentVersion uint32
+
+	// Versions are the version numbers that would be applied, in order.
+	Versions []uint32
+
+	// Changes are the changes the migrations would make to the service's
+	// namespace, with the changes to a bucket's keys following the bucket.
+	Changes []Change
+}
+
+// DryRun applies the pending migrations of the services returned by mgrs
+// within a database transaction which is always rolled back, and reports the
+// changes they would make. The database is left unchanged.
+//
+// NOTE: Every key and value of the namespaces of the services is held in
+// memory while comparing them.
+func DryRun(db walletdb.DB,
+	mgrs func(walletdb.ReadWriteTx) ([]Manager, error)) ([]*Report, error) {
+
+	tx, err := db.BeginReadWriteTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	services, err := mgrs(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*Report, 0, len(services))
+	for _, mgr := range services {
+		ns := mgr.Namespace()
+		currentVersion, err := mgr.CurrentVersion(ns)
+		if err != nil {
+			return nil, err
+		}
+		report := &Report{
+			Name:           mgr.Name(),
+			CurrentVersion: currentVersion,
+		}
+
+		versions, err := Pending(mgr, ns)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			report.Versions = append(report.Versions, version.Number)
+		}
+
+		before, err := snapshotNamespace(ns)
+		if err != nil {
+			return nil, err
+		}
+		if err := upgrade(mgr); err != nil {
+			return nil, err
+		}
+		after, err := snapshotNamespace(ns)
+		if err != nil {
+			return nil, err
+		}
+		report.Changes = diffSnapshots(before, after)
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// snapshotEntry is a key of a namespace held by a snapshot.
+type snapshotEntry struct {
+	buckets [][]byte
+	key     []byte
+	value   []byte
+	bucket  bool
+}
+
+// snapshot holds every key of a namespace, by the encoding of their path.
+type snapshot map[string]*snapshotEntry
+
+// snapshotNamespace returns a snapshot of every key and nested bucket of a
+// namespace. A nil namespace has an empty snapshot.
+func snapshotNamespace(ns walletdb.ReadWriteBucket) (snapshot, error) {
+	s := make(snapshot)
+	if ns == nil {
+		return s, nil
+	}
+	return s, s.add(nil, ns)
+}
+
+// add adds the keys of the bucket at the path, and of its nested buckets, to
+// the snapshot.
+func (s snapshot) add(path [][]byte, b walletdb.ReadWriteBucket) error {
+	return b.ForEach(func(k, v []byte) error {
+		entry := &snapshotEntry{
+			buckets: path,
+			key:     append([]byte{}, k...),
+		}
+
+		// Nested buckets have a nil value.
+		var nested walletdb.ReadWriteBucket
+		if v == nil {
+			nested = b.NestedReadWriteBucket(k)
+		}
+		if nested == nil {
+			entry.value = append([]byte{}, v...)
+			s[entryID(path, k)] = entry
+			return nil
+		}
+
+		entry.bucket = true
+		s[entryID(path, k)] = entry
+		nestedPath := append(append([][]byte{}, path...), entry.key)
+		return s.add(nestedPath, nested)
+	})
+}
+
+// entryID encodes the path of a key, with each component prefixed by its
+// length, so that the keys of a bucket sort after the bucket.
+func entryID(path [][]byte, key []byte) string {
+	var buf bytes.Buffer
+	var l [4]byte
+	for _, component := range path {
+		binary.BigEndian.PutUint32(l[:], uint32(len(component)))
+		buf.Write(l[:])
+		buf.Write(component)
+	}
+	binary.BigEndian.PutUint32(l[:], uint32(len(key)))
+	buf.Write(l[:])
+	buf.Write(key)
+	return buf.String()
+}
+
+// diffSnapshots returns the changes from one snapshot to another.
+func diffSnapshots(before, after snapshot) []Change {
+	var ids []string
+	for id := range before {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var changes []Change
+	for _, id := range ids {
+		oldEntry, newEntry := before[id], after[id]
+		var kind ChangeKind
+		switch {
+		case newEntry == nil:
+			kind = KeyDeleted
+			newEntry = oldEntry
+		case oldEntry == nil:
+			kind = KeyAdded
+		case oldEntry.bucket != newEntry.bucket ||
+			!bytes.Equal(oldEntry.value, newEntry.value):
+
+			kind = KeyModified
+		default:
+			continue
+		}
+		changes = append(changes, Change{
+			Buckets: newEntry.buckets,
+			Key:     newEntry.key,
+			Bucket:  newEntry.bucket,
+			Kind:    kind,
+		})
+	}
+
+	return changes
+}
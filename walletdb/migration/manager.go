@@ -2,6 +2,8 @@ package migration
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"sort"
 
 	"github.com/btcsuite/btcwallet/walletdb"
@@ -13,6 +15,10 @@ var (
 	// as some upgrades may not be backwards-compatible.
 	ErrReversion = errors.New("reverting to a previous version is not " +
 		"supported")
+
+	// ErrNoRollback is an error returned when an attempt to roll back a
+	// version which has no rollback is detected.
+	ErrNoRollback = errors.New("version has no rollback")
 )
 
 // Version denotes the version number of the database. A migration can be used
@@ -25,6 +31,12 @@ type Version struct {
 	// state. Care must be taken so that consequent migrations build off of
 	// the previous one in order to ensure the consistency of the database.
 	Migration func(walletdb.ReadWriteBucket) error
+
+	// Rollback represents an optional migration function that reverts the
+	// changes made by Migration, bringing the database back to the
+	// previous version. Versions without a Migration don't need one to
+	// be rolled back.
+	Rollback func(walletdb.ReadWriteBucket) error
 }
 
 // Manager is an interface that exposes the necessary methods needed in order to
@@ -85,6 +97,47 @@ func VersionsToApply(currentVersion uint32, versions []Version) []Version {
 	return upgradeVersions
 }
 
+// Pending returns the versions of a service which are yet to be applied to the
+// given namespace, in the order they'll be applied. ErrReversion is returned if
+// the namespace is at a later version than the latest one of the service.
+func Pending(mgr Manager, ns walletdb.ReadBucket) ([]Version, error) {
+	currentVersion, err := mgr.CurrentVersion(ns)
+	if err != nil {
+		return nil, err
+	}
+	versions := mgr.Versions()
+	if currentVersion > GetLatestVersion(versions) {
+		return nil, ErrReversion
+	}
+
+	return VersionsToApply(currentVersion, versions), nil
+}
+
+// Backup writes a copy of the database to a new file at the given path, which
+// can be opened with the database's driver in its place. This should be done
+// before upgrading, so that the database can be restored if an upgrade fails
+// or needs to be reverted.
+func Backup(db walletdb.DB, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = db.Copy(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
+
 // Upgrade attempts to upgrade a group of services exposed through the Manager
 // interface. Each service will go through its available versions and determine
 // whether it needs to apply any.
@@ -160,3 +213,59 @@ func upgrade(mgr Manager) error {
 
 	return nil
 }
+
+// Rollback attempts to revert a service exposed through its implementation of
+// the Manager interface to the target version, by applying the rollbacks of
+// every version newer than the target in reverse order. ErrNoRollback is
+// returned, without applying any rollback, if one of these versions has a
+// migration but no rollback. This allows an operator to revert a database to
+// the version understood by a previous release.
+//
+// NOTE: In order to guarantee fault-tolerance, the rollback should happen
+// within a single database transaction.
+func Rollback(mgr Manager, target uint32) error {
+	ns := mgr.Namespace()
+	currentVersion, err := mgr.CurrentVersion(ns)
+	if err != nil {
+		return err
+	}
+	if target > currentVersion {
+		return fmt.Errorf("unable to roll back %v from version %d to "+
+			"later version %d", mgr.Name(), currentVersion, target)
+	}
+
+	// We'll roll back the versions newer than the target that have been
+	// applied, starting from the latest one.
+	var versions []Version
+	for _, version := range VersionsToApply(target, mgr.Versions()) {
+		if version.Number > currentVersion {
+			break
+		}
+		if version.Migration != nil && version.Rollback == nil {
+			log.Errorf("Unable to roll back %v migration #%d: "+
+				"no rollback available", mgr.Name(),
+				version.Number)
+			return ErrNoRollback
+		}
+		versions = append(versions, version)
+	}
+
+	mgrName := mgr.Name()
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		if version.Rollback == nil {
+			continue
+		}
+
+		log.Infof("Rolling back %v migration #%d", mgrName,
+			version.Number)
+
+		if err := version.Rollback(ns); err != nil {
+			log.Errorf("Unable to roll back %v migration #%d: %v",
+				mgrName, version.Number, err)
+			return err
+		}
+	}
+
+	return mgr.SetVersion(ns, target)
+}
@@ -3,10 +3,14 @@ package migration_test
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
 	"github.com/btcsuite/btcwallet/walletdb/migration"
 	"github.com/davecgh/go-spew/spew"
 )
@@ -341,3 +345,295 @@ func TestUpgradeMultipleVersions(t *testing.T) {
 			latestVersion)
 	}
 }
+
+// TestPending ensures that the pending versions of a service are returned in
+// the order they'll be applied, and that reversions are detected.
+func TestPending(t *testing.T) {
+	t.Parallel()
+
+	m := &mockMigrationManager{
+		currentVersion: 1,
+		versions: []migration.Version{
+			{Number: 3},
+			{Number: 0},
+			{Number: 2},
+			{Number: 1},
+		},
+	}
+
+	pending, err := migration.Pending(m, nil)
+	if err != nil {
+		t.Fatalf("unable to fetch pending versions: %v", err)
+	}
+	var numbers []uint32
+	for _, version := range pending {
+		numbers = append(numbers, version.Number)
+	}
+	if !reflect.DeepEqual(numbers, []uint32{2, 3}) {
+		t.Fatalf("expected pending versions [2 3], got %v", numbers)
+	}
+
+	m.currentVersion = 4
+	if _, err := migration.Pending(m, nil); err != migration.ErrReversion {
+		t.Fatalf("expected Pending to fail with ErrReversion, got %v",
+			err)
+	}
+}
+
+// TestRollback ensures that the rollbacks of the versions newer than the target
+// are applied in reverse order, and that none are applied if one is missing.
+func TestRollback(t *testing.T) {
+	t.Parallel()
+
+	var rolledBack []uint32
+	rollback := func(number uint32) func(walletdb.ReadWriteBucket) error {
+		return func(walletdb.ReadWriteBucket) error {
+			rolledBack = append(rolledBack, number)
+			return nil
+		}
+	}
+	migrate := func(walletdb.ReadWriteBucket) error {
+		return nil
+	}
+
+	m := &mockMigrationManager{
+		currentVersion: 3,
+		versions: []migration.Version{
+			{Number: 0},
+			{Number: 1, Migration: migrate, Rollback: rollback(1)},
+			{Number: 2},
+			{Number: 3, Migration: migrate, Rollback: rollback(3)},
+			{Number: 4, Migration: migrate, Rollback: rollback(4)},
+		},
+	}
+
+	if err := migration.Rollback(m, 4); err == nil {
+		t.Fatal("expected Rollback to a later version to fail")
+	}
+
+	if err := migration.Rollback(m, 0); err != nil {
+		t.Fatalf("unable to roll back: %v", err)
+	}
+	if !reflect.DeepEqual(rolledBack, []uint32{3, 1}) {
+		t.Fatalf("expected rollbacks [3 1], got %v", rolledBack)
+	}
+	if m.currentVersion != 0 {
+		t.Fatalf("expected current version 0, got %d",
+			m.currentVersion)
+	}
+
+	// A version without a rollback prevents any rollback from being
+	// applied.
+	rolledBack = nil
+	m.currentVersion = 4
+	m.versions[3].Rollback = nil
+	if err := migration.Rollback(m, 1); err != migration.ErrNoRollback {
+		t.Fatalf("expected Rollback to fail with ErrNoRollback, got %v",
+			err)
+	}
+	if len(rolledBack) != 0 || m.currentVersion != 4 {
+		t.Fatalf("expected no rollbacks, got %v at version %d",
+			rolledBack, m.currentVersion)
+	}
+}
+
+var (
+	versionKey = []byte("version")
+	nsKey      = []byte("ns")
+)
+
+// bucketMigrationManager is a migration manager of a service storing its
+// version within its namespace.
+type bucketMigrationManager struct {
+	ns       walletdb.ReadWriteBucket
+	versions []migration.Version
+}
+
+var _ migration.Manager = (*bucketMigrationManager)(nil)
+
+func (m *bucketMigrationManager) Name() string {
+	return "bucket"
+}
+
+func (m *bucketMigrationManager) Namespace() walletdb.ReadWriteBucket {
+	return m.ns
+}
+
+func (m *bucketMigrationManager) CurrentVersion(ns walletdb.ReadBucket) (uint32, error) {
+	v := ns.Get(versionKey)
+	if len(v) != 1 {
+		return 0, errors.New("missing version")
+	}
+	return uint32(v[0]), nil
+}
+
+func (m *bucketMigrationManager) SetVersion(ns walletdb.ReadWriteBucket, version uint32) error {
+	return ns.Put(versionKey, []byte{byte(version)})
+}
+
+func (m *bucketMigrationManager) Versions() []migration.Version {
+	return m.versions
+}
+
+// TestDryRun ensures that a dry run reports the changes of the migrations
+// without applying them.
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	defer db.Close()
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(nsKey)
+		if err != nil {
+			return err
+		}
+		if err := ns.Put([]byte("a"), []byte("1")); err != nil {
+			return err
+		}
+		if err := ns.Put([]byte("b"), []byte("2")); err != nil {
+			return err
+		}
+		return ns.Put(versionKey, []byte{0})
+	})
+	if err != nil {
+		t.Fatalf("unable to populate db: %v", err)
+	}
+
+	versions := []migration.Version{
+		{Number: 0},
+		{
+			Number: 1,
+			Migration: func(ns walletdb.ReadWriteBucket) error {
+				err := ns.Put([]byte("a"), []byte("3"))
+				if err != nil {
+					return err
+				}
+				if err := ns.Delete([]byte("b")); err != nil {
+					return err
+				}
+				c, err := ns.CreateBucket([]byte("c"))
+				if err != nil {
+					return err
+				}
+				return c.Put([]byte("d"), []byte("4"))
+			},
+		},
+	}
+	reports, err := migration.DryRun(db,
+		func(tx walletdb.ReadWriteTx) ([]migration.Manager, error) {
+			return []migration.Manager{&bucketMigrationManager{
+				ns:       tx.ReadWriteBucket(nsKey),
+				versions: versions,
+			}}, nil
+		})
+	if err != nil {
+		t.Fatalf("unable to dry run: %v", err)
+	}
+
+	expected := []*migration.Report{{
+		Name:           "bucket",
+		CurrentVersion: 0,
+		Versions:       []uint32{1},
+		Changes: []migration.Change{
+			{Key: []byte("a"), Kind: migration.KeyModified},
+			{Key: []byte("b"), Kind: migration.KeyDeleted},
+			{
+				Key:    []byte("c"),
+				Bucket: true,
+				Kind:   migration.KeyAdded,
+			},
+			{
+				Buckets: [][]byte{[]byte("c")},
+				Key:     []byte("d"),
+				Kind:    migration.KeyAdded,
+			},
+			{Key: versionKey, Kind: migration.KeyModified},
+		},
+	}}
+	if !reflect.DeepEqual(reports, expected) {
+		t.Fatalf("report mismatch\nexpected: %v\ngot: %v",
+			spew.Sdump(expected), spew.Sdump(reports))
+	}
+
+	// The database must be left unchanged.
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(nsKey)
+		if v := ns.Get([]byte("b")); !reflect.DeepEqual(v, []byte("2")) {
+			return fmt.Errorf("expected b=2, got %q", v)
+		}
+		if v := ns.Get(versionKey); !reflect.DeepEqual(v, []byte{0}) {
+			return fmt.Errorf("expected version 0, got %v", v)
+		}
+		if ns.NestedReadBucket([]byte("c")) != nil {
+			return errors.New("unexpected bucket c")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBackup ensures that a backup of the database can be opened in its place,
+// and that existing files aren't overwritten.
+func TestBackup(t *testing.T) {
+	t.Parallel()
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create db: %v", err)
+	}
+	defer db.Close()
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(nsKey)
+		if err != nil {
+			return err
+		}
+		return ns.Put(versionKey, []byte{1})
+	})
+	if err != nil {
+		t.Fatalf("unable to populate db: %v", err)
+	}
+
+	tempDir, err := ioutil.TempDir("", "migration")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	backupPath := filepath.Join(tempDir, "backup.db")
+	if err := migration.Backup(db, backupPath); err != nil {
+		t.Fatalf("unable to back up db: %v", err)
+	}
+	if err := migration.Backup(db, backupPath); err == nil {
+		t.Fatal("expected backup over an existing file to fail")
+	}
+
+	f, err := os.Open(backupPath)
+	if err != nil {
+		t.Fatalf("unable to open backup: %v", err)
+	}
+	defer f.Close()
+	backup, err := walletdb.Open("memdb", f)
+	if err != nil {
+		t.Fatalf("unable to open backup db: %v", err)
+	}
+	defer backup.Close()
+	err = walletdb.View(backup, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(nsKey)
+		if ns == nil {
+			return errors.New("missing namespace")
+		}
+		if v := ns.Get(versionKey); !reflect.DeepEqual(v, []byte{1}) {
+			return fmt.Errorf("expected version 1, got %v", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,948 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlite
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+
+	// Register the pure Go SQLite database/sql driver.
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// maxKeySize is the maximum length of a key, matching the limit of
+	// the bdb driver.
+	maxKeySize = 32768
+
+	// maxValueSize is the maximum length of a value, matching the limit
+	// of the bdb driver.
+	maxValueSize = (1 << 31) - 2
+
+	// rootBucketID is the ID of the bucket holding the top level buckets.
+	rootBucketID = 0
+)
+
+// schema creates the tables of the database.  Every bucket has a row in the
+// buckets table holding its sequence, and every key of a bucket has a row in
+// the kv table holding either its value or the ID of the nested bucket.  The
+// top level buckets are nested buckets of the root bucket.
+//
+// Keys are compared with memcmp, so they are ordered bytewise like in the bdb
+// driver.
+const schema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	id INTEGER PRIMARY KEY,
+	sequence INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS kv (
+	bucket INTEGER NOT NULL,
+	key BLOB NOT NULL,
+	value BLOB,
+	child INTEGER,
+	PRIMARY KEY (bucket, key)
+) WITHOUT ROWID;
+INSERT OR IGNORE INTO buckets (id, sequence) VALUES (0, 0);
+`
+
+// subBuckets selects the ID of a bucket and of all its nested buckets.
+const subBuckets = `
+WITH RECURSIVE sub(id) AS (
+	SELECT ?
+	UNION ALL
+	SELECT kv.child FROM kv JOIN sub ON kv.bucket = sub.id
+	WHERE kv.child IS NOT NULL
+)
+SELECT id FROM sub`
+
+// convertErr converts some database/sql errors to the equivalent walletdb
+// error.
+func convertErr(err error) error {
+	switch err {
+	case sql.ErrTxDone:
+		return walletdb.ErrTxClosed
+	case sql.ErrConnDone:
+		return walletdb.ErrDbNotOpen
+	}
+
+	// Return the original error if none of the above applies.
+	return err
+}
+
+// transaction represents a database transaction.  It can either be read-only
+// or read-write and implements the walletdb Tx interfaces.
+type transaction struct {
+	db       *db
+	sqlTx    *sql.Tx
+	writable bool
+	closed   bool
+
+	// err is the first error of an operation which can't return it, such
+	// as Get.  Committing the transaction fails with this error, so the
+	// changes of a transaction which may have read incorrect data are
+	// never stored.
+	err error
+
+	onCommit []func()
+}
+
+// Enforce transaction implements the walletdb transaction interfaces.
+var _ walletdb.ReadWriteTx = (*transaction)(nil)
+
+// setErr records the first error of an operation which can't return it.
+func (tx *transaction) setErr(err error) {
+	if tx.err == nil {
+		tx.err = convertErr(err)
+	}
+}
+
+func (tx *transaction) root() *bucket {
+	return &bucket{tx: tx, id: rootBucketID}
+}
+
+// ReadBucket opens the root bucket for read only access.  If the bucket
+// described by the key does not exist, nil is returned.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) ReadBucket(key []byte) walletdb.ReadBucket {
+	return tx.ReadWriteBucket(key)
+}
+
+// ForEachBucket will iterate through all top level buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) ForEachBucket(fn func(key []byte) error) error {
+	return tx.root().ForEach(func(k, _ []byte) error {
+		return fn(k)
+	})
+}
+
+// ReadWriteBucket opens the root bucket for read/write access.  If the bucket
+// described by the key does not exist, nil is returned.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) ReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	return tx.root().NestedReadWriteBucket(key)
+}
+
+// CreateTopLevelBucket creates the top level bucket for a key if it does not
+// exist.  The newly-created bucket it returned.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) CreateTopLevelBucket(key []byte) (
+	walletdb.ReadWriteBucket, error) {
+
+	return tx.root().CreateBucketIfNotExists(key)
+}
+
+// DeleteTopLevelBucket deletes the top level bucket for a key.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) DeleteTopLevelBucket(key []byte) error {
+	return tx.root().DeleteNestedBucket(key)
+}
+
+// close releases the write lock of a read-write transaction once it is
+// committed or rolled back.
+func (tx *transaction) close() {
+	tx.closed = true
+	if tx.writable {
+		tx.db.writeMtx.Unlock()
+	}
+}
+
+// Commit commits all changes that have been made through the root bucket and
+// all of its sub-buckets to persistent storage.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) Commit() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	if !tx.writable {
+		return walletdb.ErrTxNotWritable
+	}
+	defer tx.close()
+
+	if tx.err != nil {
+		_ = tx.sqlTx.Rollback()
+		return tx.err
+	}
+	if err := tx.sqlTx.Commit(); err != nil {
+		return convertErr(err)
+	}
+
+	for _, f := range tx.onCommit {
+		f()
+	}
+	return nil
+}
+
+// Rollback undoes all changes that have been made to the root bucket and all
+// of its sub-buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) Rollback() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	defer tx.close()
+
+	return convertErr(tx.sqlTx.Rollback())
+}
+
+// OnCommit takes a function closure that will be executed when the
+// transaction successfully gets committed.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) OnCommit(f func()) {
+	tx.onCommit = append(tx.onCommit, f)
+}
+
+// bucket is an internal type used to represent a collection of key/value pairs
+// and implements the walletdb Bucket interfaces.
+type bucket struct {
+	tx *transaction
+	id int64
+}
+
+// Enforce bucket implements the walletdb Bucket interfaces.
+var _ walletdb.ReadWriteBucket = (*bucket)(nil)
+
+// lookup returns the value of a key, or the ID of the nested bucket if the key
+// is a bucket.  The value of an existing key which isn't a bucket is never
+// nil.
+func (b *bucket) lookup(key []byte) (value []byte, child sql.NullInt64,
+	found bool, err error) {
+
+	if b.tx.closed {
+		return nil, child, false, walletdb.ErrTxClosed
+	}
+
+	err = b.tx.sqlTx.QueryRow(
+		"SELECT value, child FROM kv WHERE bucket = ? AND key = ?",
+		b.id, key,
+	).Scan(&value, &child)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, child, false, nil
+	case err != nil:
+		return nil, child, false, convertErr(err)
+	}
+	if value == nil && !child.Valid {
+		value = []byte{}
+	}
+	return value, child, true, nil
+}
+
+// checkWritable returns an error if the bucket can't be modified.
+func (b *bucket) checkWritable() error {
+	switch {
+	case b.tx.closed:
+		return walletdb.ErrTxClosed
+	case !b.tx.writable:
+		return walletdb.ErrTxNotWritable
+	}
+	return nil
+}
+
+// NestedReadWriteBucket retrieves a nested bucket with the given key.  Returns
+// nil if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) NestedReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	_, child, _, err := b.lookup(key)
+	if err != nil {
+		b.tx.setErr(err)
+		return nil
+	}
+	// Don't return a non-nil interface to a nil pointer.
+	if !child.Valid {
+		return nil
+	}
+	return &bucket{tx: b.tx, id: child.Int64}
+}
+
+// NestedReadBucket retrieves a nested bucket with the given key.  Returns nil
+// if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) NestedReadBucket(key []byte) walletdb.ReadBucket {
+	return b.NestedReadWriteBucket(key)
+}
+
+// createBucket inserts a new nested bucket for a key which is not in use.
+func (b *bucket) createBucket(key []byte) (*bucket, error) {
+	res, err := b.tx.sqlTx.Exec("INSERT INTO buckets (sequence) VALUES (0)")
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"INSERT INTO kv (bucket, key, child) VALUES (?, ?, ?)",
+		b.id, key, id,
+	)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	return &bucket{tx: b.tx, id: id}, nil
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+// Returns ErrBucketExists if the bucket already exists, ErrBucketNameRequired
+// if the key is empty, or ErrIncompatibleValue if the key is a value.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) CreateBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	if err := b.checkWritable(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+
+	_, child, found, err := b.lookup(key)
+	switch {
+	case err != nil:
+		return nil, err
+	case child.Valid:
+		return nil, walletdb.ErrBucketExists
+	case found:
+		return nil, walletdb.ErrIncompatibleValue
+	}
+	return b.createBucket(key)
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.  Returns ErrBucketNameRequired if
+// the key is empty or ErrIncompatibleValue if the key is a value.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) CreateBucketIfNotExists(key []byte) (
+	walletdb.ReadWriteBucket, error) {
+
+	if err := b.checkWritable(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+
+	_, child, found, err := b.lookup(key)
+	switch {
+	case err != nil:
+		return nil, err
+	case child.Valid:
+		return &bucket{tx: b.tx, id: child.Int64}, nil
+	case found:
+		return nil, walletdb.ErrIncompatibleValue
+	}
+	return b.createBucket(key)
+}
+
+// DeleteNestedBucket removes a nested bucket with the given key, and all of
+// the buckets nested within it.  Returns ErrTxNotWritable if attempted against
+// a read-only transaction and ErrBucketNotFound if the specified bucket does
+// not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) DeleteNestedBucket(key []byte) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return walletdb.ErrIncompatibleValue
+	}
+
+	_, child, found, err := b.lookup(key)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return walletdb.ErrBucketNotFound
+	case !child.Valid:
+		return walletdb.ErrIncompatibleValue
+	}
+
+	// The buckets must be deleted before their keys, as the nested
+	// buckets are found through the keys.
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM buckets WHERE id IN ("+subBuckets+")", child.Int64,
+	)
+	if err != nil {
+		return convertErr(err)
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM kv WHERE bucket IN ("+subBuckets+")", child.Int64,
+	)
+	if err != nil {
+		return convertErr(err)
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM kv WHERE bucket = ? AND key = ?", b.id, key,
+	)
+	return convertErr(err)
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket.
+// This includes nested buckets, in which case the value is nil, but it does not
+// include the key/value pairs within those nested buckets.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return c.err
+}
+
+// Put saves the specified key/value pair to the bucket.  Keys that do not
+// already exist are added and keys that already exist are overwritten.  Returns
+// ErrTxNotWritable if attempted against a read-only transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Put(key, value []byte) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	switch {
+	case len(key) == 0:
+		return walletdb.ErrKeyRequired
+	case len(key) > maxKeySize:
+		return walletdb.ErrKeyTooLarge
+	case len(value) > maxValueSize:
+		return walletdb.ErrValueTooLarge
+	}
+
+	// The value of a nested bucket isn't overwritten, in which case no
+	// row is affected.
+	res, err := b.tx.sqlTx.Exec(`
+		INSERT INTO kv (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value
+		WHERE kv.child IS NULL`,
+		b.id, key, value,
+	)
+	if err != nil {
+		return convertErr(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return walletdb.ErrIncompatibleValue
+	}
+	return nil
+}
+
+// Get returns the value for the given key.  Returns nil if the key does not
+// exist in this bucket, or is a nested bucket.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) Get(key []byte) []byte {
+	value, _, _, err := b.lookup(key)
+	if err != nil {
+		b.tx.setErr(err)
+		return nil
+	}
+	return value
+}
+
+// Delete removes the specified key from the bucket.  Deleting a key that does
+// not exist does not return an error.  Returns ErrTxNotWritable if attempted
+// against a read-only transaction, and ErrIncompatibleValue if the key is a
+// nested bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Delete(key []byte) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	_, child, found, err := b.lookup(key)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return nil
+	case child.Valid:
+		return walletdb.ErrIncompatibleValue
+	}
+
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM kv WHERE bucket = ? AND key = ?", b.id, key,
+	)
+	return convertErr(err)
+}
+
+func (b *bucket) cursor() *cursor {
+	return &cursor{bucket: b}
+}
+
+// ReadCursor returns a new cursor, allowing for iteration over the bucket's
+// key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ReadCursor() walletdb.ReadCursor {
+	return b.cursor()
+}
+
+// ReadWriteCursor returns a new cursor, allowing for iteration over the
+// bucket's key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) ReadWriteCursor() walletdb.ReadWriteCursor {
+	return b.cursor()
+}
+
+// Tx returns the bucket's transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Tx() walletdb.ReadWriteTx {
+	return b.tx
+}
+
+// NextSequence returns an autoincrementing integer for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) NextSequence() (uint64, error) {
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	_, err := b.tx.sqlTx.Exec(
+		"UPDATE buckets SET sequence = sequence + 1 WHERE id = ?", b.id,
+	)
+	if err != nil {
+		return 0, convertErr(err)
+	}
+	var seq int64
+	err = b.tx.sqlTx.QueryRow(
+		"SELECT sequence FROM buckets WHERE id = ?", b.id,
+	).Scan(&seq)
+	if err != nil {
+		return 0, convertErr(err)
+	}
+	return uint64(seq), nil
+}
+
+// SetSequence updates the sequence number for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) SetSequence(v uint64) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	_, err := b.tx.sqlTx.Exec(
+		"UPDATE buckets SET sequence = ? WHERE id = ?", int64(v), b.id,
+	)
+	return convertErr(err)
+}
+
+// Sequence returns the current integer for the bucket without incrementing it.
+//
+// This function is part of the walletdb.ReadWriteBucket interface
+// implementation.
+func (b *bucket) Sequence() uint64 {
+	if b.tx.closed {
+		return 0
+	}
+
+	var seq int64
+	err := b.tx.sqlTx.QueryRow(
+		"SELECT sequence FROM buckets WHERE id = ?", b.id,
+	).Scan(&seq)
+	if err != nil {
+		b.tx.setErr(err)
+		return 0
+	}
+	return uint64(seq)
+}
+
+// cursor represents a cursor over key/value pairs and nested buckets of a
+// bucket.
+//
+// The cursor only remembers the key it is positioned at, and every move
+// queries the key following or preceding it, so the cursor remains valid when
+// the bucket is modified.
+type cursor struct {
+	bucket *bucket
+
+	// key is the key the cursor is positioned at.  It is nil if the
+	// cursor isn't positioned.
+	key []byte
+
+	// err is the first error of a move of the cursor.
+	err error
+}
+
+// Enforce cursor implements the walletdb cursor interfaces.
+var _ walletdb.ReadWriteCursor = (*cursor)(nil)
+
+// move positions the cursor at the first key returned by the query, and
+// returns the key/value pair.  The cursor isn't moved if no key is found.
+func (c *cursor) move(query string, args ...interface{}) (key, value []byte) {
+	tx := c.bucket.tx
+	if tx.closed {
+		return nil, nil
+	}
+
+	var child sql.NullInt64
+	err := tx.sqlTx.QueryRow(query, args...).Scan(&key, &value, &child)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		if c.err == nil {
+			c.err = convertErr(err)
+		}
+		tx.setErr(err)
+		return nil, nil
+	}
+
+	c.key = key
+	switch {
+	case child.Valid:
+		value = nil
+	case value == nil:
+		value = []byte{}
+	}
+	return key, value
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor.  Returns ErrTxNotWritable if attempted on a
+// read-only transaction, or ErrIncompatibleValue if attempted when the cursor
+// points to a nested bucket.
+//
+// This function is part of the walletdb.ReadWriteCursor interface
+// implementation.
+func (c *cursor) Delete() error {
+	if err := c.bucket.checkWritable(); err != nil {
+		return err
+	}
+	if c.key == nil {
+		return nil
+	}
+	return c.bucket.Delete(c.key)
+}
+
+// First positions the cursor at the first key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) First() (key, value []byte) {
+	c.key = nil
+	return c.move(`
+		SELECT key, value, child FROM kv WHERE bucket = ?
+		ORDER BY key LIMIT 1`,
+		c.bucket.id,
+	)
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Last() (key, value []byte) {
+	c.key = nil
+	return c.move(`
+		SELECT key, value, child FROM kv WHERE bucket = ?
+		ORDER BY key DESC LIMIT 1`,
+		c.bucket.id,
+	)
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Next() (key, value []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	return c.move(`
+		SELECT key, value, child FROM kv WHERE bucket = ? AND key > ?
+		ORDER BY key LIMIT 1`,
+		c.bucket.id, c.key,
+	)
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Prev() (key, value []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	return c.move(`
+		SELECT key, value, child FROM kv WHERE bucket = ? AND key < ?
+		ORDER BY key DESC LIMIT 1`,
+		c.bucket.id, c.key,
+	)
+}
+
+// Seek positions the cursor at the passed seek key.  If the key does not
+// exist, the cursor is moved to the next key after seek.  Returns the new
+// pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Seek(seek []byte) (key, value []byte) {
+	// A cursor seeking past the last key is positioned at the seek key,
+	// so moving it backward returns the last key.
+	c.key = append([]byte{}, seek...)
+	return c.move(`
+		SELECT key, value, child FROM kv WHERE bucket = ? AND key >= ?
+		ORDER BY key LIMIT 1`,
+		c.bucket.id, c.key,
+	)
+}
+
+// db represents a collection of namespaces which are persisted in a SQLite
+// database and implements the walletdb.DB interface.
+//
+// Read transactions are snapshot isolated from writes by SQLite's write-ahead
+// log, and are served by a pool of connections.  Writes go through a single
+// connection and are serialized by writeMtx.
+type db struct {
+	closed int32 // To be used atomically.
+
+	path    string
+	readDB  *sql.DB
+	writeDB *sql.DB
+
+	writeMtx sync.Mutex
+}
+
+// Enforce db implements the walletdb.DB interface.
+var _ walletdb.DB = (*db)(nil)
+
+// BeginReadTx opens a database read transaction.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) BeginReadTx() (walletdb.ReadTx, error) {
+	if atomic.LoadInt32(&db.closed) != 0 {
+		return nil, walletdb.ErrDbNotOpen
+	}
+	sqlTx, err := db.readDB.Begin()
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	return &transaction{db: db, sqlTx: sqlTx}, nil
+}
+
+// BeginReadWriteTx opens a database read+write transaction.  It blocks until
+// every other read+write transaction is committed or rolled back.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	if atomic.LoadInt32(&db.closed) != 0 {
+		return nil, walletdb.ErrDbNotOpen
+	}
+	db.writeMtx.Lock()
+	sqlTx, err := db.writeDB.Begin()
+	if err != nil {
+		db.writeMtx.Unlock()
+		return nil, convertErr(err)
+	}
+	return &transaction{db: db, sqlTx: sqlTx, writable: true}, nil
+}
+
+// Copy writes a copy of the database to the provided writer.  The copy is a
+// compacted SQLite database written to a temporary file next to the database.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) Copy(w io.Writer) error {
+	f, err := ioutil.TempFile(filepath.Dir(db.path), "walletcopy")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	// VACUUM INTO only writes to empty files.
+	if _, err := db.readDB.Exec("VACUUM INTO ?", f.Name()); err != nil {
+		return convertErr(err)
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Close cleanly shuts down the database and syncs all data.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) Close() error {
+	if !atomic.CompareAndSwapInt32(&db.closed, 0, 1) {
+		return walletdb.ErrDbNotOpen
+	}
+	readErr := db.readDB.Close()
+	writeErr := db.writeDB.Close()
+	if writeErr != nil {
+		return writeErr
+	}
+	return readErr
+}
+
+// PrintStats returns all collected stats pretty printed into a string.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) PrintStats() string {
+	var buf bytes.Buffer
+	stats := db.readDB.Stats()
+	fmt.Fprintf(&buf, "read connections: %d open, %d in use\n",
+		stats.OpenConnections, stats.InUse)
+	stats = db.writeDB.Stats()
+	fmt.Fprintf(&buf, "write connections: %d open, %d in use, "+
+		"waited %v\n", stats.OpenConnections, stats.InUse,
+		stats.WaitDuration)
+	return buf.String()
+}
+
+// View opens a database read transaction and executes the function f with the
+// transaction passed as a parameter. After f exits, the transaction is rolled
+// back. If f errors, its error is returned, not a rollback error (if any
+// occur). The passed reset function is called before the start of the
+// transaction and can be used to reset intermediate state. As callers may
+// expect retries of the f closure (depending on the database backend used), the
+// reset function will be called before each retry respectively.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) View(f func(tx walletdb.ReadTx) error, reset func()) error {
+	// Transactions are never retried, so the reset function is only
+	// called once.
+	reset()
+
+	tx, err := db.BeginReadTx()
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.(*transaction).closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	rollbackErr := tx.Rollback()
+	if err != nil {
+		return err
+	}
+	return rollbackErr
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with the transaction passed as a parameter. After f exits, if f did not
+// error, the transaction is committed. Otherwise, if f did error, the
+// transaction is rolled back. If the rollback fails, the original error
+// returned by f is still returned. If the commit fails, the commit error is
+// returned. As callers may expect retries of the f closure (depending on the
+// database backend used), the reset function will be called before each retry
+// respectively.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *db) Update(f func(tx walletdb.ReadWriteTx) error,
+	reset func()) error {
+
+	// Transactions are never retried, so the reset function is only
+	// called once.
+	reset()
+
+	tx, err := db.BeginReadWriteTx()
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.(*transaction).closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	if err != nil {
+		// Want to return the original error, not a rollback error if
+		// any occur.
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// fileExists reports whether the named file or directory exists.
+func fileExists(name string) bool {
+	if _, err := os.Stat(name); err != nil {
+		if os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// openDB opens the database at the provided path.  walletdb.ErrDbDoesNotExist
+// is returned if the database doesn't exist and the create flag is not set.
+func openDB(dbPath string, create bool, timeout time.Duration) (walletdb.DB,
+	error) {
+
+	if !create && !fileExists(dbPath) {
+		return nil, walletdb.ErrDbDoesNotExist
+	}
+
+	// Every connection waits up to the timeout for the locks held by
+	// other processes, and uses the write-ahead log so readers never
+	// block the writer.
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)"+
+		"&_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)",
+		dbPath, timeout.Milliseconds())
+
+	readDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write transactions take the write lock when they begin, rather than
+	// when they first write, so they can't fail to upgrade their lock.
+	writeDB, err := sql.Open("sqlite", dsn+"&_txlock=immediate")
+	if err != nil {
+		readDB.Close()
+		return nil, err
+	}
+	writeDB.SetMaxOpenConns(1)
+
+	if _, err := writeDB.Exec(schema); err != nil {
+		readDB.Close()
+		writeDB.Close()
+		return nil, err
+	}
+
+	return &db{path: dbPath, readDB: readDB, writeDB: writeDB}, nil
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package sqlite implements an instance of walletdb that uses SQLite for the
+backing datastore.
+
+The database is accessed through modernc.org/sqlite, a pure Go port of SQLite,
+so the driver doesn't require cgo.  The database uses SQLite's write-ahead log:
+read transactions see a snapshot of the database and never block the single
+read-write transaction.  Unlike bbolt, the file is compacted by Copy, which
+writes a vacuumed copy of the database.
+
+Usage
+
+This package is only a driver to the walletdb package and provides the database
+type of "sqlite". The only parameters the Open and Create functions take are the
+database path as a string and a timeout value for acquiring the database locks
+held by other processes as a time.Duration:
+
+	db, err := walletdb.Open("sqlite", "path/to/database.db", 60*time.Second)
+	if err != nil {
+		// Handle error
+	}
+
+	db, err := walletdb.Create("sqlite", "path/to/database.db", 60*time.Second)
+	if err != nil {
+		// Handle error
+	}
+*/
+package sqlite
@@ -0,0 +1,344 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlite_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/sqlite"
+)
+
+const (
+	// dbType is the database type name for this driver.
+	dbType = "sqlite"
+
+	// defaultDBTimeout is the value of db timeout for testing.
+	defaultDBTimeout = 10 * time.Second
+)
+
+// TestCreateOpenFail ensures that errors related to creating and opening a
+// database are handled properly.
+func TestCreateOpenFail(t *testing.T) {
+	// Ensure that attempting to open a database that doesn't exist returns
+	// the expected error.
+	wantErr := walletdb.ErrDbDoesNotExist
+	if _, err := walletdb.Open(
+		dbType, "noexist.db", defaultDBTimeout,
+	); err != wantErr {
+
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr = fmt.Errorf("invalid arguments to %s.Open -- expected "+
+		"database path and timeout option", dbType)
+	if _, err := walletdb.Open(
+		dbType, 1, 2, 3,
+	); err.Error() != wantErr.Error() {
+
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with an invalid type for
+	// the first parameter returns the expected error.
+	wantErr = fmt.Errorf("first argument to %s.Open is invalid -- "+
+		"expected database path string", dbType)
+	if _, err := walletdb.Open(
+		dbType, 1, defaultDBTimeout,
+	); err.Error() != wantErr.Error() {
+
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with an invalid type for
+	// the second parameter returns the expected error.
+	wantErr = fmt.Errorf("second argument to %s.Open is invalid -- "+
+		"expected timeout time.Duration", dbType)
+	if _, err := walletdb.Open(
+		dbType, "noexist.db", 1,
+	); err.Error() != wantErr.Error() {
+
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr = fmt.Errorf("invalid arguments to %s.Create -- expected "+
+		"database path and timeout option", dbType)
+	if _, err := walletdb.Create(
+		dbType, 1, 2, 3,
+	); err.Error() != wantErr.Error() {
+
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure operations against a closed database return the expected
+	// error.
+	tempDir, err := ioutil.TempDir("", "createfail")
+	if err != nil {
+		t.Errorf("unable to create temp dir: %v", err)
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "db")
+	db, err := walletdb.Create(dbType, dbPath, defaultDBTimeout)
+	if err != nil {
+		t.Errorf("Create: unexpected error: %v", err)
+		return
+	}
+	db.Close()
+
+	wantErr = walletdb.ErrDbNotOpen
+	if _, err := db.BeginReadTx(); err != wantErr {
+		t.Errorf("BeginReadTx: did not receive expected error - got "+
+			"%v, want %v", err, wantErr)
+		return
+	}
+}
+
+// TestPersistence ensures that values, nested buckets and sequences stored are
+// still valid after closing and reopening the database, and in a copy of the
+// database.
+func TestPersistence(t *testing.T) {
+	// Create a new database to run tests against.
+	tempDir, err := ioutil.TempDir("", "persistencetest")
+	if err != nil {
+		t.Errorf("unable to create temp dir: %v", err)
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "db")
+	db, err := walletdb.Create(dbType, dbPath, defaultDBTimeout)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+
+	// Create a namespace with a nested bucket and put some values into
+	// them so they can be tested for existence on re-open.
+	storeValues := map[string]string{
+		"ns1key1": "foo1",
+		"ns1key2": "foo2",
+		"ns1key3": "",
+	}
+	ns1Key := []byte("ns1")
+	nestedKey := []byte("nested")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns1, err := tx.CreateTopLevelBucket(ns1Key)
+		if err != nil {
+			return err
+		}
+		nested, err := ns1.CreateBucket(nestedKey)
+		if err != nil {
+			return err
+		}
+		if err := nested.SetSequence(42); err != nil {
+			return err
+		}
+
+		for _, b := range []walletdb.ReadWriteBucket{ns1, nested} {
+			for k, v := range storeValues {
+				err := b.Put([]byte(k), []byte(v))
+				if err != nil {
+					return fmt.Errorf("Put: unexpected "+
+						"error: %v", err)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ns1 Update: unexpected error: %v", err)
+		return
+	}
+
+	// checkValues ensures the values previously stored in the namespace
+	// and its nested bucket still exist and are correct.
+	checkValues := func(db walletdb.DB) error {
+		return walletdb.View(db, func(tx walletdb.ReadTx) error {
+			ns1 := tx.ReadBucket(ns1Key)
+			if ns1 == nil {
+				return fmt.Errorf("ReadTx.ReadBucket: " +
+					"unexpected nil root bucket")
+			}
+			nested := ns1.NestedReadBucket(nestedKey)
+			if nested == nil {
+				return fmt.Errorf("NestedReadBucket: " +
+					"unexpected nil bucket")
+			}
+			seq := nested.(walletdb.ReadWriteBucket).Sequence()
+			if seq != 42 {
+				return fmt.Errorf("Sequence: got %d, want 42",
+					seq)
+			}
+
+			for _, b := range []walletdb.ReadBucket{ns1, nested} {
+				for k, v := range storeValues {
+					gotVal := b.Get([]byte(k))
+					if !reflect.DeepEqual(gotVal, []byte(v)) {
+						return fmt.Errorf("Get: key "+
+							"'%s' does not match "+
+							"expected value - got "+
+							"%s, want %s", k,
+							gotVal, v)
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+
+	// Copy the database, then close and reopen it to ensure the values
+	// persist.
+	var buf bytes.Buffer
+	if err := db.Copy(&buf); err != nil {
+		t.Errorf("Copy: unexpected error: %v", err)
+		return
+	}
+	db.Close()
+	db, err = walletdb.Open(dbType, dbPath, defaultDBTimeout)
+	if err != nil {
+		t.Errorf("Failed to open test database (%s) %v", dbType, err)
+		return
+	}
+	defer db.Close()
+	if err := checkValues(db); err != nil {
+		t.Errorf("ns1 View: unexpected error: %v", err)
+		return
+	}
+
+	copyPath := filepath.Join(tempDir, "copy")
+	if err := ioutil.WriteFile(copyPath, buf.Bytes(), 0600); err != nil {
+		t.Errorf("unable to write copy: %v", err)
+		return
+	}
+	copyDB, err := walletdb.Open(dbType, copyPath, defaultDBTimeout)
+	if err != nil {
+		t.Errorf("Failed to open database copy (%s) %v", dbType, err)
+		return
+	}
+	defer copyDB.Close()
+	if err := checkValues(copyDB); err != nil {
+		t.Errorf("copy View: unexpected error: %v", err)
+		return
+	}
+}
+
+// TestCursor ensures that cursors iterate over the keys of a bucket in order,
+// and that deleting the current key doesn't invalidate the cursor.
+func TestCursor(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "cursortest")
+	if err != nil {
+		t.Errorf("unable to create temp dir: %v", err)
+		return
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := walletdb.Create(
+		dbType, filepath.Join(tempDir, "db"), defaultDBTimeout,
+	)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer db.Close()
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket([]byte("ns"))
+		if err != nil {
+			return err
+		}
+
+		// Keys are ordered bytewise, so shorter prefixes come first
+		// and 0xff sorts last.
+		keys := [][]byte{
+			{0x01}, {0x01, 0x00}, {0x02}, {0x7f}, {0xff},
+		}
+		for i := len(keys) - 1; i >= 0; i-- {
+			if err := ns.Put(keys[i], keys[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := ns.CreateBucket([]byte{0x03}); err != nil {
+			return err
+		}
+
+		c := ns.ReadWriteCursor()
+		k, v := c.Seek([]byte{0x03})
+		if !bytes.Equal(k, []byte{0x03}) || v != nil {
+			return fmt.Errorf("Seek: got %x/%x, want nested "+
+				"bucket 03", k, v)
+		}
+		if err := c.Delete(); err != walletdb.ErrIncompatibleValue {
+			return fmt.Errorf("Delete: got %v, want %v", err,
+				walletdb.ErrIncompatibleValue)
+		}
+
+		// Delete every other key while iterating.
+		var got [][]byte
+		i := 0
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			got = append(got, k)
+			if i%2 == 0 && !bytes.Equal(k, []byte{0x03}) {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+			i++
+		}
+		want := [][]byte{
+			{0x01}, {0x01, 0x00}, {0x02}, {0x03}, {0x7f}, {0xff},
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Errorf("iterated keys %x, want %x", got,
+				want)
+		}
+
+		var remaining [][]byte
+		for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+			remaining = append(remaining, k)
+		}
+		want = [][]byte{{0xff}, {0x03}, {0x01, 0x00}}
+		if !reflect.DeepEqual(remaining, want) {
+			return fmt.Errorf("remaining keys %x, want %x",
+				remaining, want)
+		}
+
+		// Seeking past the last key leaves the cursor after it.
+		if k, _ := c.Seek([]byte{0xff, 0x00}); k != nil {
+			return fmt.Errorf("Seek: got %x, want nil", k)
+		}
+		if k, _ := c.Prev(); !bytes.Equal(k, []byte{0xff}) {
+			return fmt.Errorf("Prev: got %x, want ff", k)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Update: unexpected error: %v", err)
+	}
+}
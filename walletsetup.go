@@ -16,10 +16,13 @@ import (
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
 	"github.com/btcsuite/btcwallet/internal/prompt"
+	"github.com/btcsuite/btcwallet/rpc/macaroons"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/walletdb"
 	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/sqlite"
 )
 
 // networkDir returns the directory name of a network directory to hold wallet
@@ -103,6 +106,9 @@ func createWallet(cfg *config) error {
 	loader := wallet.NewLoader(
 		activeNet.Params, dbDir, true, cfg.DBTimeout, 250,
 	)
+	loader.SetDBDriver(cfg.DBDriver)
+	loader.SetDBEncryption(cfg.EncryptDB)
+	loader.OnWalletCreated(macaroons.Create)
 
 	// When there is a legacy keystore, open it now to ensure any errors
 	// don't end up exiting the process after the user has spent time
@@ -213,8 +219,16 @@ func createSimulationWallet(cfg *config) error {
 	dbPath := filepath.Join(netDir, wallet.WalletDBName)
 	fmt.Println("Creating the wallet...")
 
-	// Create the wallet database backed by bolt db.
-	db, err := walletdb.Create("bdb", dbPath, true, cfg.DBTimeout)
+	// Create the wallet database with the configured driver.
+	var db walletdb.DB
+	var err error
+	if cfg.DBDriver == wallet.SQLiteDBDriver {
+		db, err = walletdb.Create(cfg.DBDriver, dbPath, cfg.DBTimeout)
+	} else {
+		db, err = walletdb.Create(
+			cfg.DBDriver, dbPath, true, cfg.DBTimeout,
+		)
+	}
 	if err != nil {
 		return err
 	}
@@ -230,6 +244,24 @@ func createSimulationWallet(cfg *config) error {
 	return nil
 }
 
+// createMemSimulationWallet creates and loads a simulation wallet, with the
+// same passwords as those created by createSimulationWallet, in a database
+// kept in memory.  The wallet is lost when the process exits.
+func createMemSimulationWallet(loader *wallet.Loader) error {
+	privPass := []byte("password")
+	pubPass := []byte(wallet.InsecurePubPassphrase)
+
+	fmt.Println("Creating the wallet in memory...")
+	_, err := loader.CreateNewWallet(pubPass, privPass, nil, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("The wallet has been created successfully.  It will be " +
+		"lost when btcwallet exits.")
+	return nil
+}
+
 // checkCreateDir checks that the path exists and is a directory.
 // If path does not exist, it is created.
 func checkCreateDir(path string) error {
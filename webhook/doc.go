@@ -0,0 +1,39 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package webhook delivers wallet events to HTTP endpoints.
+
+A Dispatcher subscribes to the transaction notifications of a wallet and
+creates the following events:
+
+	received           a wallet transaction pays to a non-change address
+	confirmed          a wallet transaction reached the configured number of
+	                   confirmations
+	blockdisconnected  a block was detached from the main chain by a reorg
+
+Each event is POSTed as a JSON object to every endpoint subscribed to its type:
+
+	{
+	  "id": "received:<txid>",
+	  "type": "received",
+	  "created": 1609459200,
+	  "data": { ... }
+	}
+
+Events are written to a queue in the wallet database by the same database
+transaction as the wallet changes causing them, and are only removed once an
+endpoint answered with a 2xx status.  Failed deliveries are retried with
+exponential backoff, including across restarts, so delivery is at-least-once.  The id of an event is
+deterministic and also sent in the Idempotency-Key header, allowing receivers
+to ignore duplicates.
+
+Every request is signed with the secret of the endpoint, which must not be
+shared with other endpoints.  The
+X-Webhook-Signature header contains "sha256=" followed by the hex encoded
+HMAC-SHA256 of the X-Webhook-Timestamp header value, a period, and the request
+body.  Receivers should recompute the signature with Signature and reject
+requests with a stale timestamp.
+*/
+package webhook
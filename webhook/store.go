@@ -0,0 +1,260 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// The webhook namespace of the wallet database contains three buckets:
+//
+// The queue bucket stores the deliveries which haven't succeeded yet, keyed by
+// a big endian sequence number so they are retried in the order they were
+// queued.  Each delivery is serialized as follows:
+//
+//   [0:4]     number of failed attempts (4 bytes)
+//   [4:12]    time of the next attempt as unix nanoseconds (8 bytes)
+//   ...       endpoint URL, as a 2 byte length followed by the URL
+//   ...       event ID, as a 2 byte length followed by the ID
+//   ...       event type, as a 2 byte length followed by the type
+//   ...       request body
+//
+// The keys bucket maps the endpoint URL and event ID, separated by a zero
+// byte, to the time the delivery was queued as unix nanoseconds.  It prevents
+// queueing the same event for an endpoint twice while the key is retained.
+//
+// The pending bucket maps the hash of each mined wallet transaction which has
+// not reached the required number of confirmations to the hash and height of
+// the block it was mined in.
+//
+// All integers are encoded big endian.
+
+var (
+	// namespaceKey is the key of the top level bucket of the webhook
+	// dispatcher.
+	namespaceKey = []byte("webhook")
+
+	queueBucketKey   = []byte("queue")
+	keysBucketKey    = []byte("keys")
+	pendingBucketKey = []byte("pending")
+
+	errShortDelivery = errors.New("short webhook delivery")
+)
+
+// delivery is a queued request of an event to an endpoint.
+type delivery struct {
+	seq         uint64
+	attempts    uint32
+	nextAttempt time.Time
+	url         string
+	eventID     string
+	eventType   EventType
+	body        []byte
+}
+
+// pendingTx is a mined wallet transaction awaiting confirmations.
+type pendingTx struct {
+	hash      chainhash.Hash
+	blockHash chainhash.Hash
+	height    int32
+}
+
+// createBuckets creates the webhook namespace and its buckets if they don't
+// exist yet.
+func createBuckets(tx walletdb.ReadWriteTx) error {
+	ns, err := tx.CreateTopLevelBucket(namespaceKey)
+	if err != nil {
+		return err
+	}
+	for _, k := range [][]byte{queueBucketKey, keysBucketKey, pendingBucketKey} {
+		if _, err := ns.CreateBucketIfNotExists(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func uint64Key(v uint64) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], v)
+	return k[:]
+}
+
+func deliveryKey(url, eventID string) []byte {
+	k := make([]byte, 0, len(url)+1+len(eventID))
+	k = append(k, url...)
+	k = append(k, 0)
+	return append(k, eventID...)
+}
+
+func appendString(b []byte, s string) []byte {
+	var l [2]byte
+	binary.BigEndian.PutUint16(l[:], uint16(len(s)))
+	b = append(b, l[:]...)
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errShortDelivery
+	}
+	l := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+l {
+		return "", nil, errShortDelivery
+	}
+	return string(b[2 : 2+l]), b[2+l:], nil
+}
+
+func serializeDelivery(d *delivery) []byte {
+	b := make([]byte, 12, 12+6+len(d.url)+len(d.eventID)+
+		len(d.eventType)+len(d.body))
+	binary.BigEndian.PutUint32(b[0:4], d.attempts)
+	binary.BigEndian.PutUint64(b[4:12], uint64(d.nextAttempt.UnixNano()))
+	b = appendString(b, d.url)
+	b = appendString(b, d.eventID)
+	b = appendString(b, string(d.eventType))
+	return append(b, d.body...)
+}
+
+func deserializeDelivery(seq uint64, v []byte) (*delivery, error) {
+	if len(v) < 12 {
+		return nil, errShortDelivery
+	}
+	d := &delivery{
+		seq:         seq,
+		attempts:    binary.BigEndian.Uint32(v[0:4]),
+		nextAttempt: time.Unix(0, int64(binary.BigEndian.Uint64(v[4:12]))),
+	}
+
+	var (
+		eventType string
+		err       error
+	)
+	rest := v[12:]
+	if d.url, rest, err = readString(rest); err != nil {
+		return nil, err
+	}
+	if d.eventID, rest, err = readString(rest); err != nil {
+		return nil, err
+	}
+	if eventType, rest, err = readString(rest); err != nil {
+		return nil, err
+	}
+	d.eventType = EventType(eventType)
+	d.body = append([]byte(nil), rest...)
+	return d, nil
+}
+
+// putDelivery queues a new delivery, unless the event was already queued for
+// the endpoint.  It returns whether the delivery was queued.
+func putDelivery(ns walletdb.ReadWriteBucket, d *delivery,
+	now time.Time) (bool, error) {
+
+	keys := ns.NestedReadWriteBucket(keysBucketKey)
+	k := deliveryKey(d.url, d.eventID)
+	if keys.Get(k) != nil {
+		return false, nil
+	}
+	err := keys.Put(k, uint64Key(uint64(now.UnixNano())))
+	if err != nil {
+		return false, err
+	}
+
+	queue := ns.NestedReadWriteBucket(queueBucketKey)
+	d.seq, err = queue.NextSequence()
+	if err != nil {
+		return false, err
+	}
+	return true, updateDelivery(ns, d)
+}
+
+// updateDelivery stores the delivery under its existing sequence number.
+func updateDelivery(ns walletdb.ReadWriteBucket, d *delivery) error {
+	queue := ns.NestedReadWriteBucket(queueBucketKey)
+	return queue.Put(uint64Key(d.seq), serializeDelivery(d))
+}
+
+// deleteDelivery removes the delivery from the queue.  Its key is retained so
+// the event isn't queued again.
+func deleteDelivery(ns walletdb.ReadWriteBucket, seq uint64) error {
+	return ns.NestedReadWriteBucket(queueBucketKey).Delete(uint64Key(seq))
+}
+
+// fetchDeliveries returns every queued delivery in queue order.
+func fetchDeliveries(ns walletdb.ReadBucket) ([]*delivery, error) {
+	var deliveries []*delivery
+	queue := ns.NestedReadBucket(queueBucketKey)
+	err := queue.ForEach(func(k, v []byte) error {
+		d, err := deserializeDelivery(binary.BigEndian.Uint64(k), v)
+		if err != nil {
+			return fmt.Errorf("webhook delivery %x: %v", k, err)
+		}
+		deliveries = append(deliveries, d)
+		return nil
+	})
+	return deliveries, err
+}
+
+// pruneKeys removes the keys of deliveries queued before the given time.
+func pruneKeys(ns walletdb.ReadWriteBucket, before time.Time) error {
+	keys := ns.NestedReadWriteBucket(keysBucketKey)
+
+	var expired [][]byte
+	err := keys.ForEach(func(k, v []byte) error {
+		queued := time.Unix(0, int64(binary.BigEndian.Uint64(v)))
+		if queued.Before(before) {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range expired {
+		if err := keys.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putPendingTx records a mined transaction awaiting confirmations.
+func putPendingTx(ns walletdb.ReadWriteBucket, p *pendingTx) error {
+	var v [36]byte
+	copy(v[:32], p.blockHash[:])
+	binary.BigEndian.PutUint32(v[32:], uint32(p.height))
+	pending := ns.NestedReadWriteBucket(pendingBucketKey)
+	return pending.Put(p.hash[:], v[:])
+}
+
+// deletePendingTx stops tracking the confirmations of a transaction.
+func deletePendingTx(ns walletdb.ReadWriteBucket, hash *chainhash.Hash) error {
+	return ns.NestedReadWriteBucket(pendingBucketKey).Delete(hash[:])
+}
+
+// fetchPendingTxs returns every transaction awaiting confirmations.
+func fetchPendingTxs(ns walletdb.ReadBucket) ([]*pendingTx, error) {
+	var txs []*pendingTx
+	pending := ns.NestedReadBucket(pendingBucketKey)
+	err := pending.ForEach(func(k, v []byte) error {
+		if len(k) != chainhash.HashSize || len(v) != 36 {
+			return fmt.Errorf("invalid pending webhook tx %x", k)
+		}
+		p := &pendingTx{
+			height: int32(binary.BigEndian.Uint32(v[32:])),
+		}
+		copy(p.hash[:], k)
+		copy(p.blockHash[:], v[:32])
+		txs = append(txs, p)
+		return nil
+	})
+	return txs, err
+}
@@ -0,0 +1,795 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// EventType describes the kind of a webhook event.
+type EventType string
+
+const (
+	// EventReceived is delivered when a transaction paying to a non-change
+	// address of the wallet is first seen, either unmined or in a block.
+	EventReceived EventType = "received"
+
+	// EventConfirmed is delivered when a mined wallet transaction reaches
+	// the configured number of confirmations.
+	EventConfirmed EventType = "confirmed"
+
+	// EventBlockDisconnected is delivered when a reorg detaches a block from
+	// the main chain.
+	EventBlockDisconnected EventType = "blockdisconnected"
+)
+
+// EventTypes lists every event type delivered by the dispatcher.
+var EventTypes = []EventType{
+	EventReceived,
+	EventConfirmed,
+	EventBlockDisconnected,
+}
+
+// Names of the HTTP headers set on every webhook request.
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	EventHeader          = "X-Webhook-Event"
+	TimestampHeader      = "X-Webhook-Timestamp"
+	SignatureHeader      = "X-Webhook-Signature"
+)
+
+const (
+	// DefaultConfirmations is the default number of confirmations of a
+	// transaction before the confirmed event is delivered.
+	DefaultConfirmations = 6
+
+	// DefaultRetryInterval is the default delay before the first retry of
+	// a failed delivery.  The delay doubles with every failed attempt.
+	DefaultRetryInterval = 10 * time.Second
+
+	// DefaultMaxRetryInterval is the default maximum delay between two
+	// attempts of a delivery.
+	DefaultMaxRetryInterval = time.Hour
+
+	// DefaultMaxAttempts is the default number of attempts after which a
+	// delivery is dropped.
+	DefaultMaxAttempts = 48
+
+	// DefaultKeyRetention is the default duration for which an event is
+	// remembered after it was queued, preventing it from being delivered
+	// to an endpoint again.
+	DefaultKeyRetention = 7 * 24 * time.Hour
+
+	// defaultTimeout is the timeout of requests sent with the default HTTP
+	// client.
+	defaultTimeout = 30 * time.Second
+)
+
+// Endpoint is an HTTP(S) URL events are delivered to.
+type Endpoint struct {
+	// URL is the address requests are POSTed to.
+	URL string
+
+	// Secret is the key used to sign the requests.  Every endpoint must
+	// have its own secret, as a receiver knowing the secret of another
+	// endpoint could forge requests to it.
+	Secret []byte
+
+	// Events are the event types delivered to the endpoint.  Every event
+	// type is delivered if empty.
+	Events []EventType
+}
+
+// ParseEndpoint parses an endpoint of the form url,secret, as used by the
+// --webhook option.  The secret can't contain a comma.
+func ParseEndpoint(s string) (Endpoint, error) {
+	i := strings.LastIndex(s, ",")
+	if i <= 0 {
+		return Endpoint{}, errors.New("webhook must be of the form " +
+			"url,secret")
+	}
+	if i == len(s)-1 {
+		return Endpoint{}, errors.New("webhook secret must not be " +
+			"empty")
+	}
+	return Endpoint{
+		URL:    s[:i],
+		Secret: []byte(s[i+1:]),
+	}, nil
+}
+
+// subscribed returns whether events of the type are delivered to the endpoint.
+func (e *Endpoint) subscribed(t EventType) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, s := range e.Events {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Config describes the endpoints and delivery policy of a dispatcher.  Zero
+// values are replaced by their defaults.
+type Config struct {
+	Endpoints        []Endpoint
+	Confirmations    int32
+	Client           *http.Client
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+	MaxAttempts      uint32
+	KeyRetention     time.Duration
+}
+
+// Event is the JSON body of a webhook request.
+type Event struct {
+	// ID identifies the event, and is identical for every attempt to
+	// deliver it.
+	ID string `json:"id"`
+
+	// Type is the kind of the event, which determines the type of Data.
+	Type EventType `json:"type"`
+
+	// Created is the time the event was created as unix seconds.
+	Created int64 `json:"created"`
+
+	// Data is a ReceivedData, ConfirmedData or BlockDisconnectedData
+	// object, depending on the event type.
+	Data json.RawMessage `json:"data"`
+}
+
+// BlockInfo identifies a block of the main chain.
+type BlockInfo struct {
+	Hash   string `json:"hash"`
+	Height int32  `json:"height"`
+}
+
+// ReceivedOutput is an output paying to a non-change address of the wallet.
+type ReceivedOutput struct {
+	Index   uint32 `json:"index"`
+	Account uint32 `json:"account"`
+	Amount  int64  `json:"amount"`
+}
+
+// ReceivedData is the data of an EventReceived event.  Block is nil if the
+// transaction was unmined when it was first seen.
+type ReceivedData struct {
+	TxID    string           `json:"txid"`
+	Outputs []ReceivedOutput `json:"outputs"`
+	Block   *BlockInfo       `json:"block,omitempty"`
+}
+
+// ConfirmedData is the data of an EventConfirmed event.
+type ConfirmedData struct {
+	TxID          string    `json:"txid"`
+	Confirmations int32     `json:"confirmations"`
+	Block         BlockInfo `json:"block"`
+}
+
+// BlockDisconnectedData is the data of an EventBlockDisconnected event.
+type BlockDisconnectedData struct {
+	Hash string `json:"hash"`
+}
+
+// event is an event ready to be queued.
+type event struct {
+	id   string
+	typ  EventType
+	body []byte
+}
+
+func newEvent(typ EventType, id string, data interface{},
+	now time.Time) (*event, error) {
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(&Event{
+		ID:      id,
+		Type:    typ,
+		Created: now.Unix(),
+		Data:    rawData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event{id: id, typ: typ, body: body}, nil
+}
+
+// Signature returns the value of the signature header of a request with the
+// timestamp and body, signed with the secret of the endpoint.
+func Signature(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher creates webhook events from the notifications of a wallet and
+// delivers them to the configured endpoints.
+type Dispatcher struct {
+	db         walletdb.DB
+	ntfnServer *wallet.NotificationServer
+	cfg        Config
+	endpoints  map[string]*Endpoint
+
+	// removeHandler deregisters the transaction notification handler of
+	// the dispatcher.
+	removeHandler func()
+
+	// wake signals the delivery handler that new deliveries were queued.
+	wake chan struct{}
+
+	ctx      context.Context
+	cancel   func()
+	quitOnce sync.Once
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a dispatcher for the events of the wallet.  The delivery queue
+// is stored in the wallet database.
+func New(w *wallet.Wallet, cfg *Config) (*Dispatcher, error) {
+	return newDispatcher(w.Database(), w.NtfnServer, cfg)
+}
+
+func newDispatcher(db walletdb.DB, ntfnServer *wallet.NotificationServer,
+	cfg *Config) (*Dispatcher, error) {
+
+	d := &Dispatcher{
+		db:         db,
+		ntfnServer: ntfnServer,
+		cfg:        *cfg,
+		endpoints:  make(map[string]*Endpoint, len(cfg.Endpoints)),
+		wake:       make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+	}
+
+	if len(d.cfg.Endpoints) == 0 {
+		return nil, errors.New("no webhook endpoints")
+	}
+	secrets := make(map[string]struct{}, len(d.cfg.Endpoints))
+	for i := range d.cfg.Endpoints {
+		e := &d.cfg.Endpoints[i]
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook URL %q: %v",
+				e.URL, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("webhook URL %q is not an "+
+				"HTTP(S) URL", e.URL)
+		}
+		if len(e.Secret) == 0 {
+			return nil, fmt.Errorf("no secret for webhook URL %q",
+				e.URL)
+		}
+		if _, ok := d.endpoints[e.URL]; ok {
+			return nil, fmt.Errorf("duplicate webhook URL %q",
+				e.URL)
+		}
+		if _, ok := secrets[string(e.Secret)]; ok {
+			return nil, fmt.Errorf("secret of webhook URL %q is "+
+				"used by another webhook", e.URL)
+		}
+		secrets[string(e.Secret)] = struct{}{}
+		for _, t := range e.Events {
+			if !validEventType(t) {
+				return nil, fmt.Errorf("unknown webhook event "+
+					"type %q", t)
+			}
+		}
+		d.endpoints[e.URL] = e
+	}
+
+	if d.cfg.Confirmations < 0 {
+		return nil, errors.New("negative webhook confirmations")
+	}
+	if d.cfg.Confirmations == 0 {
+		d.cfg.Confirmations = DefaultConfirmations
+	}
+	if d.cfg.Client == nil {
+		d.cfg.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	if d.cfg.RetryInterval <= 0 {
+		d.cfg.RetryInterval = DefaultRetryInterval
+	}
+	if d.cfg.MaxRetryInterval <= 0 {
+		d.cfg.MaxRetryInterval = DefaultMaxRetryInterval
+	}
+	if d.cfg.MaxAttempts == 0 {
+		d.cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if d.cfg.KeyRetention <= 0 {
+		d.cfg.KeyRetention = DefaultKeyRetention
+	}
+
+	if err := walletdb.Update(db, createBuckets); err != nil {
+		return nil, err
+	}
+
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	return d, nil
+}
+
+func validEventType(t EventType) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Start subscribes to the wallet notifications and starts delivering queued
+// events, including those queued before a restart.
+//
+// The events of a notification are queued in the database transaction of the
+// wallet which caused it, so no event is lost if the process exits before it
+// is delivered.
+func (d *Dispatcher) Start() {
+	if d.ntfnServer != nil {
+		d.removeHandler = d.ntfnServer.HandleTransactionNotifications(
+			d.handleNotification,
+		)
+	}
+
+	d.wg.Add(1)
+	go d.deliveryHandler()
+}
+
+// Stop signals the dispatcher to shut down.  Undelivered events remain queued
+// and are delivered by the next dispatcher started for the wallet.
+func (d *Dispatcher) Stop() {
+	d.quitOnce.Do(func() {
+		if d.removeHandler != nil {
+			d.removeHandler()
+		}
+		close(d.quit)
+		d.cancel()
+	})
+}
+
+// WaitForShutdown blocks until all dispatcher goroutines have finished.
+func (d *Dispatcher) WaitForShutdown() {
+	d.wg.Wait()
+}
+
+// handleNotification queues the events of a transaction notification within
+// the database transaction of the wallet.  The delivery handler is woken once
+// the transaction is committed.
+func (d *Dispatcher) handleNotification(tx walletdb.ReadWriteTx,
+	n *wallet.TransactionNotifications) error {
+
+	ns := tx.ReadWriteBucket(namespaceKey)
+	queued, err := d.queueNotification(ns, n, time.Now())
+	if err != nil {
+		return fmt.Errorf("unable to queue webhook events: %v", err)
+	}
+	if queued {
+		tx.OnCommit(func() {
+			select {
+			case d.wake <- struct{}{}:
+			default:
+			}
+		})
+	}
+	return nil
+}
+
+// queueNotification queues the events of a transaction notification.  It
+// returns whether any delivery was queued.
+func (d *Dispatcher) queueNotification(ns walletdb.ReadWriteBucket,
+	n *wallet.TransactionNotifications, now time.Time) (bool, error) {
+
+	var queued bool
+	queue := func(ev *event) error {
+		ok, err := d.queueEvent(ns, ev, now)
+		queued = queued || ok
+		return err
+	}
+
+	// Detached blocks are reported before the blocks replacing them, and
+	// the transactions they contained must be confirmed again in a new
+	// block.
+	for _, hash := range n.DetachedBlocks {
+		ev, err := newEvent(
+			EventBlockDisconnected,
+			"blockdisconnected:"+hash.String(),
+			&BlockDisconnectedData{Hash: hash.String()}, now,
+		)
+		if err != nil {
+			return false, err
+		}
+		if err := queue(ev); err != nil {
+			return false, err
+		}
+		if err := forgetBlock(ns, hash); err != nil {
+			return false, err
+		}
+	}
+
+	for i := range n.UnminedTransactions {
+		ev, err := receivedEvent(&n.UnminedTransactions[i], nil, now)
+		if err != nil {
+			return false, err
+		}
+		if ev == nil {
+			continue
+		}
+		if err := queue(ev); err != nil {
+			return false, err
+		}
+	}
+
+	for i := range n.AttachedBlocks {
+		b := &n.AttachedBlocks[i]
+		for j := range b.Transactions {
+			txSummary := &b.Transactions[j]
+			ev, err := receivedEvent(txSummary, b, now)
+			if err != nil {
+				return false, err
+			}
+			if ev != nil {
+				if err := queue(ev); err != nil {
+					return false, err
+				}
+			}
+
+			err = putPendingTx(ns, &pendingTx{
+				hash:      *txSummary.Hash,
+				blockHash: *b.Hash,
+				height:    b.Height,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if err := d.confirmPending(ns, b, now, queue); err != nil {
+			return false, err
+		}
+	}
+
+	return queued, nil
+}
+
+// receivedEvent returns the received event of the transaction, or nil if it
+// doesn't pay to a non-change address of the wallet.
+func receivedEvent(txSummary *wallet.TransactionSummary, b *wallet.Block,
+	now time.Time) (*event, error) {
+
+	var tx wire.MsgTx
+	err := tx.Deserialize(bytes.NewReader(txSummary.Transaction))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode transaction %v: %v",
+			txSummary.Hash, err)
+	}
+
+	data := &ReceivedData{TxID: txSummary.Hash.String()}
+	for _, output := range txSummary.MyOutputs {
+		if output.Internal || int(output.Index) >= len(tx.TxOut) {
+			continue
+		}
+		data.Outputs = append(data.Outputs, ReceivedOutput{
+			Index:   output.Index,
+			Account: output.Account,
+			Amount:  tx.TxOut[output.Index].Value,
+		})
+	}
+	if len(data.Outputs) == 0 {
+		return nil, nil
+	}
+	if b != nil {
+		data.Block = &BlockInfo{Hash: b.Hash.String(), Height: b.Height}
+	}
+
+	return newEvent(EventReceived, "received:"+data.TxID, data, now)
+}
+
+// forgetBlock stops tracking the confirmations of the transactions mined in a
+// detached block.
+func forgetBlock(ns walletdb.ReadWriteBucket, hash *chainhash.Hash) error {
+	pending, err := fetchPendingTxs(ns)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		if p.blockHash != *hash {
+			continue
+		}
+		if err := deletePendingTx(ns, &p.hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmPending queues the confirmed events of the pending transactions which
+// reached the required number of confirmations with the attached block.
+func (d *Dispatcher) confirmPending(ns walletdb.ReadWriteBucket,
+	b *wallet.Block, now time.Time, queue func(*event) error) error {
+
+	pending, err := fetchPendingTxs(ns)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		confs := b.Height - p.height + 1
+		if confs < d.cfg.Confirmations {
+			continue
+		}
+
+		txID := p.hash.String()
+		ev, err := newEvent(
+			EventConfirmed, "confirmed:"+txID+":"+p.blockHash.String(),
+			&ConfirmedData{
+				TxID:          txID,
+				Confirmations: confs,
+				Block: BlockInfo{
+					Hash:   p.blockHash.String(),
+					Height: p.height,
+				},
+			}, now,
+		)
+		if err != nil {
+			return err
+		}
+		if err := queue(ev); err != nil {
+			return err
+		}
+		if err := deletePendingTx(ns, &p.hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueEvent queues a delivery of the event to every subscribed endpoint which
+// it wasn't queued for yet.  It returns whether any delivery was queued.
+func (d *Dispatcher) queueEvent(ns walletdb.ReadWriteBucket, ev *event,
+	now time.Time) (bool, error) {
+
+	var queued bool
+	for i := range d.cfg.Endpoints {
+		e := &d.cfg.Endpoints[i]
+		if !e.subscribed(ev.typ) {
+			continue
+		}
+
+		ok, err := putDelivery(ns, &delivery{
+			nextAttempt: now,
+			url:         e.URL,
+			eventID:     ev.id,
+			eventType:   ev.typ,
+			body:        ev.body,
+		}, now)
+		if err != nil {
+			return false, err
+		}
+		queued = queued || ok
+	}
+	return queued, nil
+}
+
+// deliveryHandler delivers the queued events when they are due.  It must be
+// run as a goroutine.
+func (d *Dispatcher) deliveryHandler() {
+	defer d.wg.Done()
+
+	// Keys of delivered events are pruned at most once per hour, as the
+	// retention is only a lower bound.
+	const pruneInterval = time.Hour
+	var lastPrune time.Time
+
+	for {
+		now := time.Now()
+		if now.Sub(lastPrune) >= pruneInterval {
+			err := walletdb.Update(d.db, func(tx walletdb.ReadWriteTx) error {
+				ns := tx.ReadWriteBucket(namespaceKey)
+				return pruneKeys(ns, now.Add(-d.cfg.KeyRetention))
+			})
+			if err != nil {
+				log.Errorf("Unable to prune webhook keys: %v", err)
+			}
+			lastPrune = now
+		}
+
+		next, err := d.deliverDue(now)
+		if err != nil {
+			log.Errorf("Unable to deliver webhooks: %v", err)
+		}
+		if next.IsZero() || next.After(lastPrune.Add(pruneInterval)) {
+			next = lastPrune.Add(pruneInterval)
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-d.wake:
+			timer.Stop()
+		case <-d.quit:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// deliverDue attempts every delivery which is due, and returns the time the
+// next delivery is due, or the zero time if the queue is empty.
+func (d *Dispatcher) deliverDue(now time.Time) (time.Time, error) {
+	var deliveries []*delivery
+	err := walletdb.View(d.db, func(tx walletdb.ReadTx) error {
+		var err error
+		deliveries, err = fetchDeliveries(tx.ReadBucket(namespaceKey))
+		return err
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	schedule := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	// Once a delivery to an endpoint fails, the remaining deliveries to it
+	// are postponed until its retry, instead of waiting for each of them
+	// to time out.
+	failed := make(map[string]time.Time)
+
+	for _, dl := range deliveries {
+		select {
+		case <-d.quit:
+			return time.Time{}, nil
+		default:
+		}
+
+		endpoint, ok := d.endpoints[dl.url]
+		if !ok {
+			log.Warnf("Dropping webhook event %s for removed "+
+				"endpoint %s", dl.eventID, dl.url)
+			err := d.updateQueue(func(ns walletdb.ReadWriteBucket) error {
+				return deleteDelivery(ns, dl.seq)
+			})
+			if err != nil {
+				return time.Time{}, err
+			}
+			continue
+		}
+		if retry, ok := failed[dl.url]; ok {
+			schedule(retry)
+			continue
+		}
+		if dl.nextAttempt.After(now) {
+			schedule(dl.nextAttempt)
+			continue
+		}
+
+		err := d.post(endpoint, dl)
+		if err == nil {
+			log.Debugf("Delivered webhook event %s to %s",
+				dl.eventID, dl.url)
+			err := d.updateQueue(func(ns walletdb.ReadWriteBucket) error {
+				return deleteDelivery(ns, dl.seq)
+			})
+			if err != nil {
+				return time.Time{}, err
+			}
+			continue
+		}
+
+		// The request may have been interrupted by a shutdown, which
+		// is not a failure of the endpoint.
+		if d.ctx.Err() != nil {
+			return time.Time{}, nil
+		}
+
+		dl.attempts++
+		if dl.attempts >= d.cfg.MaxAttempts {
+			log.Errorf("Dropping webhook event %s for %s after %d "+
+				"attempts: %v", dl.eventID, dl.url, dl.attempts,
+				err)
+			err := d.updateQueue(func(ns walletdb.ReadWriteBucket) error {
+				return deleteDelivery(ns, dl.seq)
+			})
+			if err != nil {
+				return time.Time{}, err
+			}
+			continue
+		}
+
+		dl.nextAttempt = time.Now().Add(d.retryInterval(dl.attempts))
+		log.Warnf("Unable to deliver webhook event %s to %s (attempt "+
+			"%d, retrying at %v): %v", dl.eventID, dl.url,
+			dl.attempts, dl.nextAttempt, err)
+		err = d.updateQueue(func(ns walletdb.ReadWriteBucket) error {
+			return updateDelivery(ns, dl)
+		})
+		if err != nil {
+			return time.Time{}, err
+		}
+		failed[dl.url] = dl.nextAttempt
+		schedule(dl.nextAttempt)
+	}
+
+	return next, nil
+}
+
+func (d *Dispatcher) updateQueue(f func(walletdb.ReadWriteBucket) error) error {
+	return walletdb.Update(d.db, func(tx walletdb.ReadWriteTx) error {
+		return f(tx.ReadWriteBucket(namespaceKey))
+	})
+}
+
+// retryInterval returns the delay before the next attempt of a delivery which
+// failed the given number of times.
+func (d *Dispatcher) retryInterval(attempts uint32) time.Duration {
+	interval := d.cfg.RetryInterval
+	for i := uint32(1); i < attempts; i++ {
+		interval *= 2
+		if interval >= d.cfg.MaxRetryInterval {
+			return d.cfg.MaxRetryInterval
+		}
+	}
+	return interval
+}
+
+// post sends a signed request of the delivery to the endpoint.
+func (d *Dispatcher) post(endpoint *Endpoint, dl *delivery) error {
+	req, err := http.NewRequestWithContext(
+		d.ctx, http.MethodPost, dl.url, bytes.NewReader(dl.body),
+	)
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, dl.eventID)
+	req.Header.Set(EventHeader, string(dl.eventType))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Signature(endpoint.Secret, timestamp, dl.body))
+
+	resp, err := d.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drain the response so the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
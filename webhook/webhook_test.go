@@ -0,0 +1,498 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+var testSecret = []byte("webhook secret")
+
+// testDB creates an empty database in a temporary directory.
+func testDB(t *testing.T) (walletdb.DB, func()) {
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	db, err := walletdb.Create(
+		"bdb", filepath.Join(dir, "wallet.db"), true, time.Second*10,
+	)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		t.Fatalf("unable to create db: %v", err)
+	}
+	return db, func() {
+		db.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// request is a webhook request received by a testReceiver.
+type request struct {
+	header http.Header
+	body   []byte
+	event  Event
+}
+
+// testReceiver is a webhook endpoint which answers with the configured status
+// and records every request.
+type testReceiver struct {
+	*httptest.Server
+	status   int32
+	requests chan *request
+}
+
+func newTestReceiver(t *testing.T, status int) *testReceiver {
+	r := &testReceiver{
+		status:   int32(status),
+		requests: make(chan *request, 100),
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Errorf("unable to read request: %v", err)
+				return
+			}
+			rec := &request{header: req.Header, body: body}
+			if err := json.Unmarshal(body, &rec.event); err != nil {
+				t.Errorf("unable to decode event: %v", err)
+			}
+			r.requests <- rec
+			w.WriteHeader(int(atomic.LoadInt32(&r.status)))
+		},
+	))
+	return r
+}
+
+func (r *testReceiver) setStatus(status int) {
+	atomic.StoreInt32(&r.status, int32(status))
+}
+
+// next returns the next request received, verifying its signature.
+func (r *testReceiver) next(t *testing.T) *request {
+	t.Helper()
+
+	var req *request
+	select {
+	case req = <-r.requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook request received")
+	}
+
+	timestamp, err := strconv.ParseInt(req.header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp: %v", err)
+	}
+	sig := Signature(testSecret, timestamp, req.body)
+	if req.header.Get(SignatureHeader) != sig {
+		t.Fatalf("invalid signature %q, expected %q",
+			req.header.Get(SignatureHeader), sig)
+	}
+	if req.header.Get(IdempotencyKeyHeader) != req.event.ID ||
+		req.header.Get(EventHeader) != string(req.event.Type) {
+
+		t.Fatalf("headers %v don't match event %+v", req.header,
+			req.event)
+	}
+	return req
+}
+
+// assertNoRequest ensures no further request is received.
+func (r *testReceiver) assertNoRequest(t *testing.T) {
+	t.Helper()
+
+	select {
+	case req := <-r.requests:
+		t.Fatalf("unexpected request for event %+v", req.event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func testConfig(url string) *Config {
+	return &Config{
+		Endpoints:     []Endpoint{{URL: url, Secret: testSecret}},
+		Confirmations: 2,
+		RetryInterval: 10 * time.Millisecond,
+	}
+}
+
+// queuedDeliveries returns the deliveries remaining in the queue.
+func queuedDeliveries(t *testing.T, db walletdb.DB) []*delivery {
+	t.Helper()
+
+	var deliveries []*delivery
+	err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+		var err error
+		deliveries, err = fetchDeliveries(tx.ReadBucket(namespaceKey))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to fetch deliveries: %v", err)
+	}
+	return deliveries
+}
+
+// notify handles a transaction notification in a database transaction, like
+// the wallet does.
+func notify(t *testing.T, d *Dispatcher, n *wallet.TransactionNotifications) {
+	t.Helper()
+
+	err := walletdb.Update(d.db, func(tx walletdb.ReadWriteTx) error {
+		return d.handleNotification(tx, n)
+	})
+	if err != nil {
+		t.Fatalf("unable to handle notification: %v", err)
+	}
+}
+
+// TestDispatcherEvents ensures events are created from wallet notifications
+// and delivered once to the endpoint.
+func TestDispatcherEvents(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := testDB(t)
+	defer cleanup()
+	receiver := newTestReceiver(t, http.StatusOK)
+	defer receiver.Close()
+
+	d, err := newDispatcher(db, nil, testConfig(receiver.URL))
+	if err != nil {
+		t.Fatalf("unable to create dispatcher: %v", err)
+	}
+	d.Start()
+	defer d.WaitForShutdown()
+	defer d.Stop()
+
+	// The transaction pays to an address of the wallet and to change.
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x52}))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	txHash := tx.TxHash()
+	summary := wallet.TransactionSummary{
+		Hash:        &txHash,
+		Transaction: buf.Bytes(),
+		MyOutputs: []wallet.TransactionSummaryOutput{
+			{Index: 0, Account: 1},
+			{Index: 1, Internal: true},
+		},
+	}
+
+	// The transaction is received once, even though it is notified again
+	// and mined later.
+	unmined := &wallet.TransactionNotifications{
+		UnminedTransactions: []wallet.TransactionSummary{summary},
+	}
+	notify(t, d, unmined)
+	notify(t, d, unmined)
+
+	req := receiver.next(t)
+	var received ReceivedData
+	if err := json.Unmarshal(req.event.Data, &received); err != nil {
+		t.Fatal(err)
+	}
+	if req.event.Type != EventReceived ||
+		req.event.ID != "received:"+txHash.String() ||
+		received.TxID != txHash.String() || received.Block != nil ||
+		len(received.Outputs) != 1 ||
+		received.Outputs[0] != (ReceivedOutput{Index: 0, Account: 1, Amount: 5000}) {
+
+		t.Fatalf("unexpected received event %s", req.body)
+	}
+
+	block100 := chainhash.Hash{100}
+	notify(t, d, &wallet.TransactionNotifications{
+		AttachedBlocks: []wallet.Block{{
+			Hash:         &block100,
+			Height:       100,
+			Transactions: []wallet.TransactionSummary{summary},
+		}},
+	})
+	receiver.assertNoRequest(t)
+
+	// The transaction is confirmed by the next block.
+	block101 := chainhash.Hash{101}
+	notify(t, d, &wallet.TransactionNotifications{
+		AttachedBlocks: []wallet.Block{{Hash: &block101, Height: 101}},
+	})
+
+	req = receiver.next(t)
+	var confirmed ConfirmedData
+	if err := json.Unmarshal(req.event.Data, &confirmed); err != nil {
+		t.Fatal(err)
+	}
+	expected := ConfirmedData{
+		TxID:          txHash.String(),
+		Confirmations: 2,
+		Block:         BlockInfo{Hash: block100.String(), Height: 100},
+	}
+	if req.event.Type != EventConfirmed || confirmed != expected {
+		t.Fatalf("unexpected confirmed event %s", req.body)
+	}
+
+	// A reorg detaching both blocks is reported.
+	notify(t, d, &wallet.TransactionNotifications{
+		DetachedBlocks: []*chainhash.Hash{&block101, &block100},
+	})
+	for _, hash := range []chainhash.Hash{block101, block100} {
+		req = receiver.next(t)
+		var disconnected BlockDisconnectedData
+		err := json.Unmarshal(req.event.Data, &disconnected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.event.Type != EventBlockDisconnected ||
+			disconnected.Hash != hash.String() {
+
+			t.Fatalf("unexpected disconnected event %s", req.body)
+		}
+	}
+	receiver.assertNoRequest(t)
+
+	if deliveries := queuedDeliveries(t, db); len(deliveries) != 0 {
+		t.Fatalf("expected empty queue, got %d deliveries",
+			len(deliveries))
+	}
+}
+
+// TestDispatcherRetry ensures failed deliveries are retried with the same
+// idempotency key, including after a restart.
+func TestDispatcherRetry(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := testDB(t)
+	defer cleanup()
+	receiver := newTestReceiver(t, http.StatusInternalServerError)
+	defer receiver.Close()
+
+	d, err := newDispatcher(db, nil, testConfig(receiver.URL))
+	if err != nil {
+		t.Fatalf("unable to create dispatcher: %v", err)
+	}
+	d.Start()
+
+	hash := chainhash.Hash{1}
+	notify(t, d, &wallet.TransactionNotifications{
+		DetachedBlocks: []*chainhash.Hash{&hash},
+	})
+	id := receiver.next(t).event.ID
+	if next := receiver.next(t).event.ID; next != id {
+		t.Fatalf("retry of %s has ID %s", id, next)
+	}
+
+	d.Stop()
+	d.WaitForShutdown()
+
+	deliveries := queuedDeliveries(t, db)
+	if len(deliveries) != 1 || deliveries[0].eventID != id ||
+		deliveries[0].attempts == 0 {
+
+		t.Fatalf("unexpected queued deliveries: %+v", deliveries)
+	}
+
+	// A new dispatcher delivers the queued event once the endpoint
+	// recovers.
+	receiver.setStatus(http.StatusOK)
+	time.Sleep(100 * time.Millisecond)
+	for len(receiver.requests) != 0 {
+		<-receiver.requests
+	}
+
+	d, err = newDispatcher(db, nil, testConfig(receiver.URL))
+	if err != nil {
+		t.Fatalf("unable to create dispatcher: %v", err)
+	}
+	d.Start()
+	defer d.WaitForShutdown()
+	defer d.Stop()
+
+	if next := receiver.next(t).event.ID; next != id {
+		t.Fatalf("retry of %s has ID %s", id, next)
+	}
+	receiver.assertNoRequest(t)
+
+	if deliveries := queuedDeliveries(t, db); len(deliveries) != 0 {
+		t.Fatalf("expected empty queue, got %d deliveries",
+			len(deliveries))
+	}
+}
+
+// TestDispatcherQueueError ensures a notification whose events can't be
+// queued fails the database transaction it's handled in, so none of its
+// changes are committed.
+func TestDispatcherQueueError(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	// Deliveries to the endpoint can't be queued, as its URL is longer
+	// than the maximum key size of the database.
+	cfg := testConfig("http://localhost/" + strings.Repeat("a", 40000))
+	d, err := newDispatcher(db, nil, cfg)
+	if err != nil {
+		t.Fatalf("unable to create dispatcher: %v", err)
+	}
+
+	blockHash := chainhash.Hash{1}
+	pending := &pendingTx{hash: chainhash.Hash{2}, blockHash: blockHash}
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		return putPendingTx(tx.ReadWriteBucket(namespaceKey), pending)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The block is forgotten before its disconnection is queued, which
+	// must not be committed when queuing fails.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		return d.handleNotification(tx, &wallet.TransactionNotifications{
+			DetachedBlocks: []*chainhash.Hash{&blockHash},
+		})
+	})
+	if err == nil {
+		t.Fatal("notification handled although queuing failed")
+	}
+
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		txs, err := fetchPendingTxs(tx.ReadBucket(namespaceKey))
+		if err != nil {
+			return err
+		}
+		if len(txs) != 1 || *txs[0] != *pending {
+			t.Fatalf("pending transactions changed to %+v", txs)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries := queuedDeliveries(t, db); len(deliveries) != 0 {
+		t.Fatalf("expected empty queue, got %d deliveries",
+			len(deliveries))
+	}
+}
+
+// TestParseEndpoint tests parsing the endpoints of --webhook options.
+func TestParseEndpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s      string
+		url    string
+		secret string
+		valid  bool
+	}{
+		{
+			s:      "https://localhost/events,secret",
+			url:    "https://localhost/events",
+			secret: "secret",
+			valid:  true,
+		},
+		{
+			s:      "https://localhost/events?a=1,2,secret",
+			url:    "https://localhost/events?a=1,2",
+			secret: "secret",
+			valid:  true,
+		},
+		{s: "https://localhost/events"},
+		{s: "https://localhost/events,"},
+		{s: ",secret"},
+	}
+
+	for _, test := range tests {
+		e, err := ParseEndpoint(test.s)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected error", test.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.s, err)
+			continue
+		}
+		if e.URL != test.url || string(e.Secret) != test.secret {
+			t.Errorf("%q: got URL %q and secret %q, want %q and %q",
+				test.s, e.URL, e.Secret, test.url, test.secret)
+		}
+	}
+}
+
+// TestDispatcherConfig ensures invalid configurations are rejected.
+func TestDispatcherConfig(t *testing.T) {
+	t.Parallel()
+
+	db, cleanup := testDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name      string
+		endpoints []Endpoint
+	}{
+		{
+			name: "no endpoints",
+		},
+		{
+			name: "invalid scheme",
+			endpoints: []Endpoint{
+				{URL: "ftp://localhost", Secret: testSecret},
+			},
+		},
+		{
+			name: "no secret",
+			endpoints: []Endpoint{
+				{URL: "http://localhost"},
+			},
+		},
+		{
+			name: "duplicate URL",
+			endpoints: []Endpoint{
+				{URL: "http://localhost", Secret: testSecret},
+				{URL: "http://localhost", Secret: []byte("other")},
+			},
+		},
+		{
+			name: "duplicate secret",
+			endpoints: []Endpoint{
+				{URL: "http://localhost:1", Secret: testSecret},
+				{URL: "http://localhost:2", Secret: testSecret},
+			},
+		},
+		{
+			name: "unknown event",
+			endpoints: []Endpoint{{
+				URL:    "http://localhost",
+				Secret: testSecret,
+				Events: []EventType{"spent"},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := newDispatcher(db, nil, &Config{Endpoints: test.endpoints})
+		if err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/webhook"
+)
+
+// webhookService runs the webhook dispatcher of the loaded wallet.
+type webhookService struct {
+	mu         sync.Mutex
+	dispatcher *webhook.Dispatcher
+}
+
+// start creates and starts a dispatcher delivering the events of the wallet to
+// the configured webhooks, which are validated when the config is loaded.
+func (s *webhookService) start(w *wallet.Wallet) error {
+	endpoints := make([]webhook.Endpoint, 0, len(cfg.Webhooks))
+	for _, opt := range cfg.Webhooks {
+		e, err := webhook.ParseEndpoint(opt)
+		if err != nil {
+			return err
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	d, err := webhook.New(w, &webhook.Config{
+		Endpoints:     endpoints,
+		Confirmations: cfg.WebhookConfs,
+	})
+	if err != nil {
+		return err
+	}
+	d.Start()
+
+	s.mu.Lock()
+	s.dispatcher = d
+	s.mu.Unlock()
+	return nil
+}
+
+// stop stops the dispatcher, if it was started, and waits for it to finish.
+func (s *webhookService) stop() {
+	s.mu.Lock()
+	d := s.dispatcher
+	s.dispatcher = nil
+	s.mu.Unlock()
+
+	if d != nil {
+		d.Stop()
+		d.WaitForShutdown()
+	}
+}
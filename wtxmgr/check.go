@@ -0,0 +1,282 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wtxmgr
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// Inconsistency describes records of the store which don't agree with each
+// other, and which can be repaired by Repair.
+type Inconsistency struct {
+	// Description describes the inconsistent records.
+	Description string
+
+	// repair fixes the inconsistency.  It is nil for mined balance
+	// mismatches, as the mined balance is recomputed after every other
+	// repair.
+	repair func(ns walletdb.ReadWriteBucket) error
+}
+
+// String returns the description of the inconsistency.
+func (i Inconsistency) String() string {
+	return i.Description
+}
+
+// describeCreditKey returns a description of the credit or debit with the key.
+func describeCreditKey(k []byte) string {
+	var txHash chainhash.Hash
+	copy(txHash[:], k[0:32])
+	return fmt.Sprintf("%v:%d (height %d)", txHash,
+		byteOrder.Uint32(k[68:72]), int32(byteOrder.Uint32(k[32:36])))
+}
+
+// creditOutPointKey returns the unspent index key of the credit with the key.
+func creditOutPointKey(credKey []byte) []byte {
+	var txHash chainhash.Hash
+	copy(txHash[:], credKey[0:32])
+	return canonicalOutPoint(&txHash, extractRawCreditIndex(credKey))
+}
+
+// Check verifies that the records of the store are consistent with each
+// other, and returns the inconsistencies found:
+//
+//   - every debit refers to a credit marked as spent by it;
+//   - every unspent index entry refers to an unspent credit;
+//   - every unspent credit has an unspent index entry;
+//   - every spent credit is spent by a debit which refers to it;
+//   - the mined balance is the total of the unspent credits.
+//
+// Malformed records are returned as an error, as they can't be repaired.
+func Check(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var inconsistencies []Inconsistency
+	credits := ns.NestedReadBucket(bucketCredits)
+
+	// respent records the credits which are marked spent by their debit
+	// when repaired, so they aren't also repaired as unspent.
+	respent := make(map[string]struct{})
+	debits := ns.NestedReadBucket(bucketDebits)
+	unspent := ns.NestedReadBucket(bucketUnspent)
+
+	err := debits.ForEach(func(k, v []byte) error {
+		if len(k) < 72 || len(v) < 80 {
+			str := fmt.Sprintf("%s: malformed debit %x", bucketDebits,
+				k)
+			return storeError(ErrData, str, nil)
+		}
+		debitKey := append([]byte{}, k...)
+		credKey := append([]byte{}, extractRawDebitCreditKey(v)...)
+
+		credValue := credits.Get(credKey)
+		switch {
+		// A debit of a missing credit can't be restored, as the
+		// credit's transaction output is unknown.
+		case credValue == nil:
+			inconsistencies = append(inconsistencies, Inconsistency{
+				Description: fmt.Sprintf("debit %s spends "+
+					"missing credit %s",
+					describeCreditKey(debitKey),
+					describeCreditKey(credKey)),
+				repair: func(ns walletdb.ReadWriteBucket) error {
+					return deleteRawDebit(ns, debitKey)
+				},
+			})
+
+		case len(credValue) < 81 ||
+			!bytes.Equal(credValue[9:81], debitKey):
+
+			respent[string(credKey)] = struct{}{}
+			inconsistencies = append(inconsistencies, Inconsistency{
+				Description: fmt.Sprintf("credit %s is not "+
+					"marked spent by debit %s",
+					describeCreditKey(credKey),
+					describeCreditKey(debitKey)),
+				repair: func(ns walletdb.ReadWriteBucket) error {
+					return repairDebitCredit(
+						ns, debitKey, credKey,
+					)
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Dangling unspent index entries are removed before the entries of
+	// unspent credits are put, as they may share the outpoint.
+	err = unspent.ForEach(func(k, v []byte) error {
+		credKey := existsRawUnspent(ns, k)
+		if credKey != nil {
+			_, spent, err := fetchRawCreditAmountSpent(
+				credits.Get(credKey),
+			)
+			if err == nil && !spent {
+				return nil
+			}
+		}
+		outPointKey := append([]byte{}, k...)
+		inconsistencies = append(inconsistencies, Inconsistency{
+			Description: fmt.Sprintf("unspent index entry %x "+
+				"has no unspent credit", outPointKey),
+			repair: func(ns walletdb.ReadWriteBucket) error {
+				return deleteRawUnspent(ns, outPointKey)
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var minedBalance btcutil.Amount
+	err = credits.ForEach(func(k, v []byte) error {
+		if len(k) < 72 {
+			str := fmt.Sprintf("%s: malformed credit %x",
+				bucketCredits, k)
+			return storeError(ErrData, str, nil)
+		}
+		amt, spent, err := fetchRawCreditAmountSpent(v)
+		if err != nil {
+			return err
+		}
+		credKey := append([]byte{}, k...)
+		outPointKey := creditOutPointKey(credKey)
+
+		if !spent {
+			minedBalance += amt
+			indexKey := existsRawUnspent(ns, outPointKey)
+			if bytes.Equal(indexKey, credKey) {
+				return nil
+			}
+			inconsistencies = append(inconsistencies, Inconsistency{
+				Description: fmt.Sprintf("unspent credit %s "+
+					"is missing from the unspent index",
+					describeCreditKey(credKey)),
+				repair: func(ns walletdb.ReadWriteBucket) error {
+					return putRawUnspent(
+						ns, outPointKey, credKey[32:68],
+					)
+				},
+			})
+			return nil
+		}
+
+		if len(v) < 81 {
+			str := fmt.Sprintf("%s: malformed spent credit %s",
+				bucketCredits, describeCreditKey(credKey))
+			return storeError(ErrData, str, nil)
+		}
+		debitKey := v[9:81]
+		debitValue := debits.Get(debitKey)
+		if len(debitValue) >= 80 && bytes.Equal(
+			extractRawDebitCreditKey(debitValue), credKey) {
+
+			return nil
+		}
+		if _, ok := respent[string(credKey)]; ok {
+			return nil
+		}
+		inconsistencies = append(inconsistencies, Inconsistency{
+			Description: fmt.Sprintf("credit %s is spent by "+
+				"missing debit %s", describeCreditKey(credKey),
+				describeCreditKey(debitKey)),
+			repair: func(ns walletdb.ReadWriteBucket) error {
+				_, err := unspendRawCredit(ns, credKey)
+				if err != nil {
+					return err
+				}
+				return putRawUnspent(
+					ns, outPointKey, credKey[32:68],
+				)
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storedBalance, err := fetchMinedBalance(ns)
+	if err != nil {
+		return nil, err
+	}
+	if storedBalance != minedBalance {
+		inconsistencies = append(inconsistencies, Inconsistency{
+			Description: fmt.Sprintf("mined balance %v doesn't "+
+				"match the unspent credits total %v",
+				storedBalance, minedBalance),
+		})
+	}
+
+	return inconsistencies, nil
+}
+
+// repairDebitCredit marks the credit of a debit as spent by it, removing the
+// credit from the unspent index.
+func repairDebitCredit(ns walletdb.ReadWriteBucket, debitKey,
+	credKey []byte) error {
+
+	var spender indexedIncidence
+	copy(spender.txHash[:], debitKey[0:32])
+	spender.block.Height = int32(byteOrder.Uint32(debitKey[32:36]))
+	copy(spender.block.Hash[:], debitKey[36:68])
+	spender.index = byteOrder.Uint32(debitKey[68:72])
+	if _, err := spendCredit(ns, credKey, &spender); err != nil {
+		return err
+	}
+
+	return deleteRawUnspent(ns, creditOutPointKey(credKey))
+}
+
+// Repair checks the consistency of the records of the store with Check, and
+// repairs the inconsistencies found, which are returned.  Debits of missing
+// credits are removed, credits are marked as spent by the debits spending
+// them, or as unspent if their debit is missing, the unspent index is rebuilt
+// from the unspent credits and the mined balance is recomputed.
+func Repair(ns walletdb.ReadWriteBucket) ([]Inconsistency, error) {
+	inconsistencies, err := Check(ns)
+	if err != nil || len(inconsistencies) == 0 {
+		return inconsistencies, err
+	}
+
+	for _, i := range inconsistencies {
+		if i.repair == nil {
+			continue
+		}
+		if err := i.repair(ns); err != nil {
+			return nil, err
+		}
+	}
+
+	// The repairs may change the spent credits, so the mined balance is
+	// recomputed once they're all done.
+	var minedBalance btcutil.Amount
+	credits := ns.NestedReadBucket(bucketCredits)
+	err = credits.ForEach(func(k, v []byte) error {
+		amt, spent, err := fetchRawCreditAmountSpent(v)
+		if err != nil {
+			return err
+		}
+		if !spent {
+			minedBalance += amt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := putMinedBalance(ns, minedBalance); err != nil {
+		return nil, err
+	}
+
+	return inconsistencies, nil
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wtxmgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// TestCheckRepair tests that inconsistencies between the credits, debits,
+// unspent index and mined balance of the store are found and repaired.
+func TestCheckRepair(t *testing.T) {
+	t.Parallel()
+
+	s, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	// Insert both credits of a transaction, and a transaction spending
+	// the first of them.
+	recvRec, err := NewTxRecord(TstRecvSerializedTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendingRec, err := NewTxRecord(TstSpendingSerializedTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitDBTx(t, s, db, func(ns walletdb.ReadWriteBucket) {
+		err := s.InsertTx(ns, recvRec, TstRecvTxBlockDetails)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := uint32(0); i < 2; i++ {
+			err := s.AddCredit(
+				ns, recvRec, TstRecvTxBlockDetails, i, false,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = s.InsertTx(ns, spendingRec, TstSignedTxBlockDetails)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.AddCredit(
+			ns, spendingRec, TstSignedTxBlockDetails, 0, false,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	checkConsistent := func(ns walletdb.ReadBucket) {
+		t.Helper()
+
+		inconsistencies, err := Check(ns)
+		if err != nil {
+			t.Fatalf("unable to check store: %v", err)
+		}
+		if len(inconsistencies) != 0 {
+			t.Fatalf("unexpected inconsistencies: %v",
+				inconsistencies)
+		}
+	}
+	commitDBTx(t, s, db, func(ns walletdb.ReadWriteBucket) {
+		checkConsistent(ns)
+	})
+
+	// Remove the unspent index entry of the second credit, add an entry
+	// of an unknown output, remove the debit of the spent credit, and
+	// change the mined balance.
+	recvBlock := &TstRecvTxBlockDetails.Block
+	commitDBTx(t, s, db, func(ns walletdb.ReadWriteBucket) {
+		err := deleteRawUnspent(
+			ns, canonicalOutPoint(&recvRec.Hash, 1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unknown := wire.OutPoint{Hash: chainhash.Hash{1}}
+		err = putUnspent(ns, &unknown, recvBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		debitKey := keyDebit(
+			&spendingRec.Hash, 0, &TstSignedTxBlockDetails.Block,
+		)
+		if err := deleteRawDebit(ns, debitKey); err != nil {
+			t.Fatal(err)
+		}
+		if err := putMinedBalance(ns, 1); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	commitDBTx(t, s, db, func(ns walletdb.ReadWriteBucket) {
+		inconsistencies, err := Check(ns)
+		if err != nil {
+			t.Fatalf("unable to check store: %v", err)
+		}
+		if len(inconsistencies) != 4 {
+			t.Fatalf("expected 4 inconsistencies, got %d: %v",
+				len(inconsistencies), inconsistencies)
+		}
+
+		repaired, err := Repair(ns)
+		if err != nil {
+			t.Fatalf("unable to repair store: %v", err)
+		}
+		if len(repaired) != len(inconsistencies) {
+			t.Fatalf("expected %d repairs, got %d",
+				len(inconsistencies), len(repaired))
+		}
+		checkConsistent(ns)
+
+		// The credit of the missing debit is unspent again.
+		var wantBalance btcutil.Amount
+		for _, txOut := range recvRec.MsgTx.TxOut {
+			wantBalance += btcutil.Amount(txOut.Value)
+		}
+		wantBalance += btcutil.Amount(spendingRec.MsgTx.TxOut[0].Value)
+		minedBalance, err := fetchMinedBalance(ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if minedBalance != wantBalance {
+			t.Fatalf("mined balance: got %v, want %v",
+				minedBalance, wantBalance)
+		}
+		unspent, err := s.UnspentOutputs(ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unspent) != 3 {
+			t.Fatalf("expected 3 unspent outputs, got %d",
+				len(unspent))
+		}
+	})
+}
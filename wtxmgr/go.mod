@@ -6,7 +6,7 @@ require (
 	github.com/btcsuite/btcd v0.0.0-20190824003749-130ea5bddde3
 	github.com/btcsuite/btclog v0.0.0-20170628155309-84c8d2346e9f
 	github.com/btcsuite/btcutil v0.0.0-20190425235716-9e5f4b9a998d
-	github.com/btcsuite/btcwallet/walletdb v1.3.5
+	github.com/btcsuite/btcwallet/walletdb v1.4.0
 	github.com/lightningnetwork/lnd/clock v1.0.1
 	github.com/stretchr/testify v1.5.1 // indirect
 )
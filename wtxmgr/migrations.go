@@ -9,6 +9,8 @@ import (
 // reflect the latest database state. If the database happens to be at a version
 // number lower than the latest, migrations will be performed in order to catch
 // it up.
+//
+// Every version can be rolled back.
 var versions = []migration.Version{
 	{
 		Number:    1,
@@ -17,6 +19,7 @@ var versions = []migration.Version{
 	{
 		Number:    2,
 		Migration: dropTransactionHistory,
+		Rollback:  rollbackDropTransactionHistory,
 	},
 }
 
@@ -64,7 +67,7 @@ func (m *MigrationManager) CurrentVersion(ns walletdb.ReadBucket) (uint32, error
 	if ns == nil {
 		ns = m.ns
 	}
-	return fetchVersion(m.ns)
+	return fetchVersion(ns)
 }
 
 // SetVersion sets the version of the service's database.
@@ -76,7 +79,7 @@ func (m *MigrationManager) SetVersion(ns walletdb.ReadWriteBucket,
 	if ns == nil {
 		ns = m.ns
 	}
-	return putVersion(m.ns, version)
+	return putVersion(ns, version)
 }
 
 // Versions returns all of the available database versions of the service.
@@ -108,3 +111,10 @@ func dropTransactionHistory(ns walletdb.ReadWriteBucket) error {
 	// Finally, we'll insert a 0 value for our mined balance.
 	return putMinedBalance(ns, 0)
 }
+
+// rollbackDropTransactionHistory rolls back dropTransactionHistory without
+// changing the store, since version 1 reads it the same way.  The history
+// dropped by the migration can't be restored, but is rebuilt by rescanning.
+func rollbackDropTransactionHistory(walletdb.ReadWriteBucket) error {
+	return nil
+}
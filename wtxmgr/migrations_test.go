@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/walletdb/migration"
 )
 
 // applyMigration is a helper function that allows us to assert the state of the
@@ -185,3 +186,70 @@ func TestMigrationDropTransactionHistory(t *testing.T) {
 		false,
 	)
 }
+
+// TestMigrationManagerVersion ensures that the migration manager reads and
+// writes the version of the bucket it's given, rather than the bucket it was
+// created with, as done when checking for pending migrations.
+func TestMigrationManagerVersion(t *testing.T) {
+	t.Parallel()
+
+	_, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatalf("unable to create test store: %v", err)
+	}
+	defer teardown()
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(namespaceKey)
+		if ns == nil {
+			return errors.New("top-level namespace does not exist")
+		}
+
+		mgr := NewMigrationManager(nil)
+		version, err := mgr.CurrentVersion(ns)
+		if err != nil {
+			return err
+		}
+		if version != getLatestVersion() {
+			return fmt.Errorf("expected version %d, got %d",
+				getLatestVersion(), version)
+		}
+
+		if err := mgr.SetVersion(ns, 1); err != nil {
+			return err
+		}
+		version, err = fetchVersion(ns)
+		if err != nil {
+			return err
+		}
+		if version != 1 {
+			return fmt.Errorf("expected version 1, got %d", version)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMigrationRollback ensures that the store can be rolled back to its first
+// version.
+func TestMigrationRollback(t *testing.T) {
+	t.Parallel()
+
+	rollback := func(ns walletdb.ReadWriteBucket) error {
+		return migration.Rollback(NewMigrationManager(ns), 1)
+	}
+	afterMigration := func(ns walletdb.ReadWriteBucket, _ *Store) error {
+		version, err := fetchVersion(ns)
+		if err != nil {
+			return err
+		}
+		if version != 1 {
+			return fmt.Errorf("expected version 1, got %d", version)
+		}
+		return nil
+	}
+	noop := func(walletdb.ReadWriteBucket, *Store) error { return nil }
+	applyMigration(t, noop, afterMigration, rollback, false)
+}